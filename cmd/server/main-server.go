@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -18,12 +19,14 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/authkey"
 	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
 	"github.com/wavetermdev/waveterm/pkg/service"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/waveai"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wcloud"
@@ -46,6 +49,8 @@ var BuildTime = "0"
 const InitialTelemetryWait = 10 * time.Second
 const TelemetryTick = 2 * time.Minute
 const TelemetryInterval = 4 * time.Hour
+const BlockTrashSweepTick = 10 * time.Minute
+const AIConversationSweepTick = time.Hour
 
 var shutdownOnce sync.Once
 
@@ -60,6 +65,10 @@ func doShutdown(reason string) {
 		// TODO deal with flush in progress
 		clearTempFiles()
 		filestore.WFS.FlushCache(ctx)
+		err := wstore.FlushAsyncMeta(ctx)
+		if err != nil {
+			log.Printf("error flushing meta updates: %v\n", err)
+		}
 		watcher := wconfig.GetWatcher()
 		if watcher != nil {
 			watcher.Close()
@@ -70,6 +79,15 @@ func doShutdown(reason string) {
 	})
 }
 
+// restarts the shells that were running when the server shut down (once the shell startup files are written)
+func restoreSession(startupFilesDone chan struct{}) {
+	defer func() {
+		panichandler.PanicHandler("restoreSession", recover())
+	}()
+	<-startupFilesDone
+	blockcontroller.RestoreSession(context.Background())
+}
+
 func installShutdownSignalHandlers() {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGTERM, syscall.SIGINT)
@@ -112,6 +130,68 @@ func telemetryLoop() {
 	}
 }
 
+// permanently deletes blocks that have been in the trash for longer than the configured retention
+func blockTrashSweepLoop() {
+	defer func() {
+		panichandler.PanicHandler("blockTrashSweepLoop", recover())
+	}()
+	for {
+		ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+		numPurged, err := wcore.SweepDeletedBlocks(ctx, wcore.GetBlockTrashRetention())
+		cancelFn()
+		if err != nil {
+			log.Printf("error sweeping deleted blocks: %v\n", err)
+		} else if numPurged > 0 {
+			log.Printf("purged %d deleted block(s)\n", numPurged)
+		}
+		time.Sleep(BlockTrashSweepTick)
+	}
+}
+
+// deletes the ai conversations older than client:airetentiondays (when it is set)
+func aiConversationSweepLoop() {
+	defer func() {
+		panichandler.PanicHandler("aiConversationSweepLoop", recover())
+	}()
+	for {
+		ctx, cancelFn := context.WithTimeout(context.Background(), 30*time.Second)
+		if retention := waveai.GetConversationRetention(ctx); retention > 0 {
+			numPruned, err := waveai.PruneConversations(ctx, retention)
+			if err != nil {
+				log.Printf("error pruning ai conversations: %v\n", err)
+			} else if numPruned > 0 {
+				log.Printf("pruned %d ai conversation(s)\n", numPruned)
+			}
+		}
+		cancelFn()
+		time.Sleep(AIConversationSweepTick)
+	}
+}
+
+// report-only, run `wsh debug integrity --repair` to fix the issues
+func checkStoreIntegrity() {
+	defer func() {
+		panichandler.PanicHandler("checkStoreIntegrity", recover())
+	}()
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	report, err := wstore.CheckIntegrity(ctx)
+	if err != nil {
+		log.Printf("error checking wstore integrity: %v\n", err)
+		return
+	}
+	if report.NumIssues() == 0 {
+		return
+	}
+	log.Printf("[warning] wstore integrity check found %d issue(s)\n", report.NumIssues())
+	for _, issue := range report.Dangling {
+		log.Printf("  dangling %s %s=%s (%s)\n", issue.ORef, issue.Field, issue.RefId, issue.Reason)
+	}
+	for _, issue := range report.Orphans {
+		log.Printf("  orphan %s (%s)\n", issue.ORef, issue.Reason)
+	}
+}
+
 func panicTelemetryHandler() {
 	activity := wshrpc.ActivityUpdate{NumPanics: 1}
 	err := telemetry.UpdateActivity(context.Background(), activity)
@@ -209,11 +289,49 @@ func clearTempFiles() error {
 	return nil
 }
 
+// reverts the most recent migration of a store so the previous version of wave can open the database.  it holds the
+// wave lock, so it can't run while wave is (the running server would keep using the newer schema).
+func rollbackDBMigration(store string) error {
+	err := wavebase.CacheAndRemoveEnvVars()
+	if err != nil {
+		return err
+	}
+	waveLock, err := wavebase.AcquireWaveLock()
+	if err != nil {
+		return fmt.Errorf("error acquiring wave lock (quit wave before rolling back): %w", err)
+	}
+	defer waveLock.Close()
+	var version uint
+	switch store {
+	case "wstore":
+		version, err = wstore.RollbackLastMigration()
+	case "filestore":
+		version, err = filestore.RollbackLastMigration()
+	default:
+		return fmt.Errorf("invalid store %q (must be wstore or filestore)", store)
+	}
+	if err != nil {
+		return fmt.Errorf("error rolling back %s: %w", store, err)
+	}
+	log.Printf("rolled back %s to version %d, start the previous version of wave now (this version would migrate it back up)\n", store, version)
+	return nil
+}
+
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
 	log.SetPrefix("[wavesrv] ")
 	wavebase.WaveVersion = WaveVersion
 	wavebase.BuildTime = BuildTime
+	rollbackDB := flag.String("rollback-db", "", "revert the most recent migration of a store (wstore or filestore) and exit, wave must not be running")
+	flag.Parse()
+	if *rollbackDB != "" {
+		err := rollbackDBMigration(*rollbackDB)
+		if err != nil {
+			log.Printf("[error] %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	err := grabAndRemoveEnvVars()
 	if err != nil {
@@ -272,10 +390,13 @@ func main() {
 		return
 	}
 	panichandler.PanicTelemetryHandler = panicTelemetryHandler
+	eventbus.FindWindowForTabId = wstore.DBFindWindowForTabId
+	startupFilesDone := make(chan struct{})
 	go func() {
 		defer func() {
 			panichandler.PanicHandler("InitCustomShellStartupFiles", recover())
 		}()
+		defer close(startupFilesDone)
 		err := shellutil.InitCustomShellStartupFiles()
 		if err != nil {
 			log.Printf("error initializing wsh and shell-integration files: %v\n", err)
@@ -297,6 +418,10 @@ func main() {
 	startupActivityUpdate()
 	go stdinReadWatch()
 	go telemetryLoop()
+	go blockTrashSweepLoop()
+	go aiConversationSweepLoop()
+	go checkStoreIntegrity()
+	wcore.StartTabTitleResolver()
 	configWatcher()
 	blocklogger.InitBlockLogger()
 	webListener, err := web.MakeTCPListener("web")
@@ -323,6 +448,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "WAVESRV-ESTART ws:%s web:%s version:%s buildtime:%s\n", wsListener.Addr(), webListener.Addr(), WaveVersion, BuildTime)
 	}()
 	go wshutil.RunWshRpcOverListener(unixListener)
+	go restoreSession(startupFilesDone)
 	web.RunWebServer(webListener) // blocking
 	runtime.KeepAlive(waveLock)
 }
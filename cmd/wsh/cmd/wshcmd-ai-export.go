@@ -0,0 +1,105 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveai"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var aiExportOutFile string
+var aiExportFormat string
+
+var aiExportCmd = &cobra.Command{
+	Use:   "export blockid",
+	Short: "export an AI block's conversation (as markdown or json)",
+	Long: `export an AI block's conversation as markdown or json (the format defaults to json for a .json output file, and
+markdown otherwise).  conversations are kept after their block is closed, so a closed block's id can still be exported.`,
+	Example: "  wsh ai export 2 -o chat.md\n  wsh ai export 5ca1ab1e-8a2b-4c3d-9e4f-0123456789ab --format json | jq .",
+	Args:    cobra.ExactArgs(1),
+	RunE:    aiExportRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	aiExportCmd.Flags().StringVarP(&aiExportOutFile, "output", "o", "", "write the conversation to a file (instead of stdout)")
+	aiExportCmd.Flags().StringVar(&aiExportFormat, "format", "", "markdown or json")
+	aiCmd.AddCommand(aiExportCmd)
+}
+
+func getAiExportFormat() string {
+	if aiExportFormat != "" {
+		return strings.ToLower(aiExportFormat)
+	}
+	if strings.ToLower(filepath.Ext(aiExportOutFile)) == ".json" {
+		return waveai.ConversationFormat_Json
+	}
+	return waveai.ConversationFormat_Markdown
+}
+
+// the block may have been deleted, so full block ids are used as is
+func resolveAiExportBlockId(arg string) (string, error) {
+	if uuid.Validate(arg) == nil {
+		return arg, nil
+	}
+	oref, err := resolveSimpleId(arg)
+	if err != nil {
+		return "", err
+	}
+	return oref.OID, nil
+}
+
+func aiExportRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("ai:export", rtnErr == nil)
+	}()
+	format := getAiExportFormat()
+	if err := waveai.ValidateConversationFormat(format); err != nil {
+		OutputHelpMessage(cmd)
+		return err
+	}
+	blockId, err := resolveAiExportBlockId(args[0])
+	if err != nil {
+		return err
+	}
+	var output io.Writer = WrappedStdout
+	if aiExportOutFile != "" {
+		outFile, err := os.Create(aiExportOutFile)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer func() {
+			outFile.Close()
+			if rtnErr != nil {
+				os.Remove(aiExportOutFile)
+			}
+		}()
+		output = outFile
+	}
+	// written as it is received (long conversations are sent in chunks)
+	var numBytes int
+	respCh := wshclient.ExportAIConversationCommand(RpcClient, wshrpc.CommandExportAIConversationData{BlockId: blockId, Format: format}, &wshrpc.RpcOpts{Timeout: 30000})
+	for resp := range respCh {
+		if resp.Error != nil {
+			return fmt.Errorf("exporting conversation: %w", resp.Error)
+		}
+		if _, err := io.WriteString(output, resp.Response); err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+		numBytes += len(resp.Response)
+	}
+	if aiExportOutFile != "" {
+		WriteStdout("conversation saved to %s (%d bytes)\n", aiExportOutFile, numBytes)
+	}
+	return nil
+}
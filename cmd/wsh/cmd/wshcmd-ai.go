@@ -4,8 +4,10 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
 
@@ -16,9 +18,16 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
 )
 
+const AiMaxMessageSize = 10 * 1024
+
+const aiStdinName = "<stdin>"
+
 var aiCmd = &cobra.Command{
-	Use:                   "ai [-] [message...]",
-	Short:                 "Send a message to an AI block",
+	Use:   "ai [-] [message...]",
+	Short: "Send a message to an AI block",
+	Long: `send a message to the AI block in the current tab (one is created if the tab doesn't have one).
+piped stdin is attached to the message as context (if it is too large, the start of it is dropped).`,
+	Example:               "  wsh ai \"how do i list files by size\"\n  make 2>&1 | wsh ai \"why did this fail\"\n  git diff | wsh ai --stream \"write a commit message for this\"",
 	RunE:                  aiRun,
 	PreRunE:               preRunSetupRpcClient,
 	DisableFlagsInUseLine: true,
@@ -26,62 +35,79 @@ var aiCmd = &cobra.Command{
 
 var aiFileFlags []string
 var aiNewBlockFlag bool
+var aiStreamFlag bool
 
 func init() {
 	rootCmd.AddCommand(aiCmd)
-	aiCmd.Flags().BoolVarP(&aiNewBlockFlag, "new", "n", false, "create a new AI block")
+	aiCmd.Flags().BoolVarP(&aiNewBlockFlag, "new-block", "n", false, "create a new AI block")
+	aiCmd.Flags().BoolVar(&aiNewBlockFlag, "new", false, "create a new AI block")
+	aiCmd.Flags().MarkHidden("new")
+	aiCmd.Flags().BoolVar(&aiStreamFlag, "stream", false, "print the response to stdout (it is also shown in the block)")
 	aiCmd.Flags().StringArrayVarP(&aiFileFlags, "file", "f", nil, "attach file content (use '-' for stdin)")
 }
 
-func encodeFile(builder *strings.Builder, file io.Reader, fileName string) error {
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return fmt.Errorf("error reading file: %w", err)
-	}
+type aiContextFile struct {
+	name string
+	data []byte
+}
+
+func encodeFile(builder *strings.Builder, data []byte, fileName string) {
 	// Start delimiter with the file name
 	builder.WriteString(fmt.Sprintf("\n@@@start file %q\n", fileName))
-	// Read the file content and write it to the builder
 	builder.Write(data)
 	// End delimiter with the file name
 	builder.WriteString(fmt.Sprintf("\n@@@end file %q\n\n", fileName))
-	return nil
 }
 
-func aiRun(cmd *cobra.Command, args []string) (rtnErr error) {
-	defer func() {
-		sendActivity("ai", rtnErr == nil)
-	}()
-
-	if len(args) == 0 {
-		OutputHelpMessage(cmd)
-		return fmt.Errorf("no message provided")
+func getAiMessageSize(files []aiContextFile, message string) int {
+	var builder strings.Builder
+	for _, file := range files {
+		encodeFile(&builder, file.data, file.name)
 	}
+	return builder.Len() + len(message)
+}
 
-	var stdinUsed bool
-	var message strings.Builder
+// drops the start of the data (at a line boundary if possible) so that it is at most maxSize bytes
+func truncateAiContextHead(data []byte, maxSize int) []byte {
+	if len(data) <= maxSize {
+		return data
+	}
+	if maxSize <= 0 {
+		return nil
+	}
+	data = data[len(data)-maxSize:]
+	if idx := bytes.IndexByte(data, '\n'); idx >= 0 && idx < len(data)-1 {
+		data = data[idx+1:]
+	}
+	return data
+}
 
-	// Handle file attachments first
-	for _, file := range aiFileFlags {
-		if file == "-" {
-			if stdinUsed {
-				return fmt.Errorf("stdin (-) can only be used once")
-			}
-			stdinUsed = true
-			if err := encodeFile(&message, os.Stdin, "<stdin>"); err != nil {
-				return fmt.Errorf("reading from stdin: %w", err)
-			}
-		} else {
-			fd, err := os.Open(file)
-			if err != nil {
-				return fmt.Errorf("opening file %s: %w", file, err)
-			}
-			defer fd.Close()
-			if err := encodeFile(&message, fd, file); err != nil {
-				return fmt.Errorf("reading file %s: %w", file, err)
-			}
+// truncates the stdin context (from the head) so the message fits in AiMaxMessageSize
+func fitAiStdinContext(files []aiContextFile, message string) []aiContextFile {
+	overflow := getAiMessageSize(files, message) - AiMaxMessageSize
+	if overflow <= 0 {
+		return files
+	}
+	for idx := range files {
+		if files[idx].name != aiStdinName {
+			continue
 		}
+		origSize := len(files[idx].data)
+		files[idx].data = truncateAiContextHead(files[idx].data, origSize-overflow)
+		WriteStderr("[warning] stdin is %d bytes, only the last %d bytes were sent (the max message size is %dk)\n", origSize, len(files[idx].data), AiMaxMessageSize/1024)
 	}
+	return files
+}
+
+func isStdinPiped() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) == 0
+}
 
+func getAiBlockORef() (*waveobj.ORef, error) {
 	// Default to "waveai" block
 	isDefaultBlock := blockArg == ""
 	if isDefaultBlock {
@@ -102,30 +128,64 @@ func aiRun(cmd *cobra.Command, args []string) (rtnErr error) {
 			},
 		}
 
-		newORef, err := wshclient.CreateBlockCommand(RpcClient, *data, &wshrpc.RpcOpts{Timeout: 2000})
+		rtnData, err := wshclient.CreateBlockCommand(RpcClient, *data, &wshrpc.RpcOpts{Timeout: 2000})
 		if err != nil {
-			return fmt.Errorf("creating AI block: %w", err)
+			return nil, fmt.Errorf("creating AI block: %w", err)
 		}
-		fullORef = &newORef
+		fullORef = &rtnData.BlockORef
 		// Wait for the block's route to be available
 		gotRoute, err := wshclient.WaitForRouteCommand(RpcClient, wshrpc.CommandWaitForRouteData{
 			RouteId: wshutil.MakeFeBlockRouteId(fullORef.OID),
 			WaitMs:  4000,
 		}, &wshrpc.RpcOpts{Timeout: 5000})
 		if err != nil {
-			return fmt.Errorf("waiting for AI block: %w", err)
+			return nil, fmt.Errorf("waiting for AI block: %w", err)
 		}
 		if !gotRoute {
-			return fmt.Errorf("AI block route could not be established")
+			return nil, fmt.Errorf("AI block route could not be established")
 		}
 	} else if err != nil {
-		return fmt.Errorf("resolving block: %w", err)
+		return nil, fmt.Errorf("resolving block: %w", err)
 	}
+	return fullORef, nil
+}
 
-	// Create the route for this block
-	route := wshutil.MakeFeBlockRouteId(fullORef.OID)
+func aiRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("ai", rtnErr == nil)
+	}()
+
+	if len(args) == 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("no message provided")
+	}
+
+	var stdinUsed bool
+	var files []aiContextFile
+
+	// Handle file attachments first
+	for _, file := range aiFileFlags {
+		if file == "-" {
+			if stdinUsed {
+				return fmt.Errorf("stdin (-) can only be used once")
+			}
+			stdinUsed = true
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("reading from stdin: %w", err)
+			}
+			files = append(files, aiContextFile{name: aiStdinName, data: data})
+		} else {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("reading file %s: %w", file, err)
+			}
+			files = append(files, aiContextFile{name: file, data: data})
+		}
+	}
 
 	// Then handle main message
+	var message string
 	if args[0] == "-" {
 		if stdinUsed {
 			return fmt.Errorf("stdin (-) can only be used once")
@@ -134,20 +194,51 @@ func aiRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		if err != nil {
 			return fmt.Errorf("reading from stdin: %w", err)
 		}
-		message.Write(data)
+		if len(args) > 1 {
+			// wsh ai - "message", stdin is the context
+			files = append(files, aiContextFile{name: aiStdinName, data: data})
+			message = strings.Join(args[1:], " ")
+		} else {
+			message = string(data)
+		}
 	} else {
-		message.WriteString(strings.Join(args, " "))
+		message = strings.Join(args, " ")
+		if !stdinUsed && isStdinPiped() {
+			// piped input (e.g. make 2>&1 | wsh ai "why did this fail") is sent as context
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("reading from stdin: %w", err)
+			}
+			if len(data) > 0 {
+				files = append(files, aiContextFile{name: aiStdinName, data: data})
+			}
+		}
 	}
+	files = fitAiStdinContext(files, message)
 
-	if message.Len() == 0 {
+	var fullMessage strings.Builder
+	for _, file := range files {
+		encodeFile(&fullMessage, file.data, file.name)
+	}
+	fullMessage.WriteString(message)
+	if strings.TrimSpace(message) == "" && len(files) == 0 {
 		return fmt.Errorf("message is empty")
 	}
-	if message.Len() > 10*1024 {
-		return fmt.Errorf("current max message size is 10k")
+	if fullMessage.Len() > AiMaxMessageSize {
+		return fmt.Errorf("current max message size is %dk", AiMaxMessageSize/1024)
 	}
 
+	fullORef, err := getAiBlockORef()
+	if err != nil {
+		return err
+	}
+	// Create the route for this block
+	route := wshutil.MakeFeBlockRouteId(fullORef.OID)
 	messageData := wshrpc.AiMessageData{
-		Message: message.String(),
+		Message: fullMessage.String(),
+	}
+	if aiStreamFlag {
+		return streamAiResponse(messageData, route)
 	}
 	err = wshclient.AiSendMessageCommand(RpcClient, messageData, &wshrpc.RpcOpts{
 		Route:   route,
@@ -159,3 +250,31 @@ func aiRun(cmd *cobra.Command, args []string) (rtnErr error) {
 
 	return nil
 }
+
+func streamAiResponse(messageData wshrpc.AiMessageData, route string) error {
+	// the model can take a long time to respond, the block has its own ai timeout
+	respCh := wshclient.AiStreamMessageCommand(RpcClient, messageData, &wshrpc.RpcOpts{
+		Route:   route,
+		Timeout: math.MaxInt32,
+	})
+	var gotText bool
+	for resp := range respCh {
+		if resp.Error != nil {
+			if gotText {
+				WriteStdout("\n")
+			}
+			return fmt.Errorf("ai response: %w", resp.Error)
+		}
+		if resp.Response.Error != "" {
+			return fmt.Errorf("ai response: %s", resp.Response.Error)
+		}
+		if resp.Response.Text != "" {
+			gotText = true
+			WriteStdout("%s", resp.Response.Text)
+		}
+	}
+	if gotText {
+		WriteStdout("\n")
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFitAiStdinContext(t *testing.T) {
+	var lines []string
+	for idx := 0; idx < 2000; idx++ {
+		lines = append(lines, strings.Repeat("x", 20))
+	}
+	lines = append(lines, "error: the last line")
+	stdinData := []byte(strings.Join(lines, "\n"))
+	message := "why did this fail"
+	files := []aiContextFile{{name: "notes.txt", data: []byte("some notes")}, {name: aiStdinName, data: stdinData}}
+
+	files = fitAiStdinContext(files, message)
+	if size := getAiMessageSize(files, message); size > AiMaxMessageSize {
+		t.Fatalf("message is still %d bytes", size)
+	}
+	if string(files[0].data) != "some notes" {
+		t.Errorf("only stdin should be truncated, got %q", files[0].data)
+	}
+	truncated := string(files[1].data)
+	if !strings.HasSuffix(truncated, "error: the last line") {
+		t.Errorf("the end of stdin should be kept")
+	}
+	if !strings.HasPrefix(truncated, strings.Repeat("x", 20)+"\n") {
+		t.Errorf("expected truncation at a line boundary, got %q", truncated[:30])
+	}
+
+	small := []aiContextFile{{name: aiStdinName, data: []byte("short")}}
+	if rtn := fitAiStdinContext(small, message); string(rtn[0].data) != "short" {
+		t.Errorf("small stdin should not be truncated, got %q", rtn[0].data)
+	}
+}
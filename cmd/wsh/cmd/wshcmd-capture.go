@@ -0,0 +1,140 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var captureLines int
+var captureRaw bool
+var captureOutFile string
+var captureFollow bool
+var captureGrep string
+var captureIgnoreCase bool
+var captureMaxCount int
+
+var captureCmd = &cobra.Command{
+	Use:   "capture [blockid]",
+	Short: "print the scrollback of a terminal block",
+	Long: `print the scrollback of a terminal block (defaults to the current block) to stdout or a file.
+ansi escape sequences are removed unless --raw is given. use --follow to keep printing new output (like tail -f).
+with --grep only the lines that match the regexp are printed (with their line numbers), the search runs in wave.`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    captureRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	captureCmd.Flags().IntVarP(&captureLines, "lines", "n", 0, "only the last n lines (default is all of the scrollback)")
+	captureCmd.Flags().BoolVar(&captureRaw, "raw", false, "keep the ansi escape sequences")
+	captureCmd.Flags().StringVarP(&captureOutFile, "output", "o", "", "write to a file instead of stdout")
+	captureCmd.Flags().BoolVarP(&captureFollow, "follow", "f", false, "keep printing new output until interrupted")
+	captureCmd.Flags().StringVar(&captureGrep, "grep", "", "only print the lines that match this regexp")
+	captureCmd.Flags().BoolVarP(&captureIgnoreCase, "ignore-case", "i", false, "with --grep, ignore case")
+	captureCmd.Flags().IntVarP(&captureMaxCount, "max-count", "m", 0, "with --grep, stop after this many matches (default 1000)")
+	rootCmd.AddCommand(captureCmd)
+}
+
+func captureRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("capture", rtnErr == nil)
+	}()
+	if captureLines < 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid --lines %d", captureLines)
+	}
+	if captureGrep != "" && (captureFollow || captureRaw || captureLines > 0) {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--grep cannot be used with --follow, --raw, or --lines")
+	}
+	blockArg := "this"
+	if len(args) > 0 {
+		blockArg = args[0]
+	}
+	blockId, err := resolveTermBlockArg(blockArg)
+	if err != nil {
+		return err
+	}
+	var output io.Writer = WrappedStdout
+	if captureOutFile != "" {
+		fd, err := os.Create(captureOutFile)
+		if err != nil {
+			return fmt.Errorf("creating output file: %w", err)
+		}
+		defer fd.Close()
+		output = fd
+	}
+	if captureGrep != "" {
+		return captureGrepRun(blockId, output)
+	}
+	data := wshrpc.CommandGetScrollbackData{
+		BlockId: blockId,
+		Lines:   captureLines,
+		Raw:     captureRaw,
+		Follow:  captureFollow,
+	}
+	timeout := 60000
+	if captureFollow {
+		timeout = math.MaxInt32
+	}
+	respCh := wshclient.GetScrollbackCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: timeout})
+	for resp := range respCh {
+		if resp.Error != nil {
+			return fmt.Errorf("capturing scrollback: %w", resp.Error)
+		}
+		chunk, err := base64.StdEncoding.DecodeString(resp.Response.Data64)
+		if err != nil {
+			return fmt.Errorf("decoding scrollback: %w", err)
+		}
+		_, err = output.Write(chunk)
+		if err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+	}
+	return nil
+}
+
+func captureGrepRun(blockId string, output io.Writer) error {
+	data := wshrpc.CommandSearchScrollbackData{
+		BlockId:       blockId,
+		Pattern:       captureGrep,
+		CaseSensitive: !captureIgnoreCase,
+		MaxResults:    captureMaxCount,
+	}
+	rtn, err := wshclient.SearchScrollbackCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 60000})
+	if err != nil {
+		return fmt.Errorf("searching scrollback: %w", err)
+	}
+	lastLine := 0
+	for _, match := range rtn.Matches {
+		// a line with more than one match is printed once
+		if match.Line == lastLine {
+			continue
+		}
+		lastLine = match.Line
+		_, err = fmt.Fprintf(output, "%d:%s\n", match.Line, match.Snippet)
+		if err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+	}
+	if rtn.Truncated {
+		WriteStderr("[stopped after %d matches]\n", len(rtn.Matches))
+	} else if rtn.Incomplete {
+		WriteStderr("[the search stopped early, only %d bytes of the scrollback were searched]\n", rtn.ScannedBytes)
+	}
+	if len(rtn.Matches) == 0 {
+		// like grep
+		WshExitCode = 1
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"golang.org/x/term"
+)
+
+var closeTab bool
+var closeWindow bool
+var closeForce bool
+var closeAllBlocks bool
+var closeKill bool
+var closeKillGrace time.Duration
+var closeMatch []string
+var closeOlderThan time.Duration
+var closeYes bool
+var closeIncludePinned bool
+
+var closeCmd = &cobra.Command{
+	Use:   "close [blockid|tabid|windowid]",
+	Short: "close a block, tab, or window",
+	Long: `close a block, tab, or window. the type of the id is detected automatically.
+with no id, closes the current block (or the current tab/window with --tab/--window).
+
+with --match (or --older-than), closes all the blocks that match instead, e.g.
+  wsh close --match view=preview --match meta.url~=grafana --older-than 2h
+--match takes key=value (exact) or key~=regex, where the key is a meta key ("view", "meta.url", etc.).  with
+--tab only the blocks in that tab (the id arg, or the current tab) are matched, otherwise the blocks in all
+windows.  the matching blocks are listed and closed (in one transaction) after a confirmation (unless --yes).`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    closeRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	closeCmd.Flags().BoolVar(&closeTab, "tab", false, "close a tab (defaults to the current tab)")
+	closeCmd.Flags().BoolVar(&closeWindow, "window", false, "close a window (defaults to the current window)")
+	closeCmd.Flags().BoolVarP(&closeForce, "force", "f", false, "allow closing a pinned tab or the last tab in a window (closes the window), with --all-blocks also close pinned blocks")
+	closeCmd.Flags().BoolVar(&closeAllBlocks, "all-blocks", false, "close all the blocks in a tab (except pinned blocks), but keep the tab open")
+	closeCmd.Flags().BoolVar(&closeKill, "kill", false, "when closing a block, also kill the processes started by its shell")
+	closeCmd.Flags().DurationVar(&closeKillGrace, "grace", 2*time.Second, "with --kill, how long the processes get to exit before they are force killed")
+	closeCmd.Flags().StringArrayVar(&closeMatch, "match", nil, "close the blocks whose meta matches key=value or key~=regex (can be repeated, all must match)")
+	closeCmd.Flags().DurationVar(&closeOlderThan, "older-than", 0, "close the blocks that were created more than this long ago (e.g. 2h)")
+	closeCmd.Flags().BoolVarP(&closeYes, "yes", "y", false, "with --match, close the blocks without asking for confirmation")
+	closeCmd.Flags().BoolVar(&closeIncludePinned, "include-pinned", false, "with --match, also close pinned blocks")
+	rootCmd.AddCommand(closeCmd)
+}
+
+// with kill, the rpc waits for the processes to exit (grace, plus a second for the force kill)
+func deleteBlockWithKill(blockId string, kill bool, grace time.Duration) (*wshrpc.CommandDeleteBlockRtnData, error) {
+	data := wshrpc.CommandDeleteBlockData{BlockId: blockId}
+	timeout := 2000
+	if kill {
+		data.Kill = true
+		data.GraceMs = int(grace.Milliseconds())
+		timeout += int(grace.Milliseconds()) + 1000
+	}
+	return wshclient.DeleteBlockCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: timeout})
+}
+
+func formatKilledProcs(rtn *wshrpc.CommandDeleteBlockRtnData) string {
+	if rtn == nil || rtn.NumKilled == 0 {
+		return ""
+	}
+	if rtn.NumKilled == 1 {
+		return " (killed 1 process)"
+	}
+	return fmt.Sprintf(" (killed %d processes)", rtn.NumKilled)
+}
+
+type blockMatcher struct {
+	key   string
+	value string
+	re    *regexp.Regexp // set for key~=regex
+}
+
+// parses key=value or key~=regex (the key can have a "meta." prefix)
+func parseBlockMatcher(matchStr string) (*blockMatcher, error) {
+	eqIdx := strings.Index(matchStr, "=")
+	if eqIdx <= 0 {
+		return nil, fmt.Errorf("invalid --match %q (expected key=value or key~=regex)", matchStr)
+	}
+	key, value := matchStr[:eqIdx], matchStr[eqIdx+1:]
+	isRegex := strings.HasSuffix(key, "~")
+	key = strings.TrimPrefix(strings.TrimSuffix(key, "~"), "meta.")
+	if key == "" {
+		return nil, fmt.Errorf("invalid --match %q (no key)", matchStr)
+	}
+	matcher := &blockMatcher{key: key, value: value}
+	if isRegex {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --match %q: %w", matchStr, err)
+		}
+		matcher.re = re
+	}
+	return matcher, nil
+}
+
+// a key that isn't set matches as ""
+func (m *blockMatcher) matches(meta waveobj.MetaMapType) bool {
+	var valStr string
+	if val, ok := meta[m.key]; ok && val != nil {
+		valStr = fmt.Sprint(val)
+	}
+	if m.re != nil {
+		return m.re.MatchString(valStr)
+	}
+	return valStr == m.value
+}
+
+func filterBlocks(blocks []wshrpc.BlockListEntry, matchers []*blockMatcher, olderThan time.Duration, now time.Time) []wshrpc.BlockListEntry {
+	var rtn []wshrpc.BlockListEntry
+outer:
+	for _, block := range blocks {
+		for _, matcher := range matchers {
+			if !matcher.matches(block.Meta) {
+				continue outer
+			}
+		}
+		if olderThan > 0 {
+			// blocks created by older versions of wave don't have a created time, they never match
+			if block.CreatedTs == 0 || now.Sub(time.UnixMilli(block.CreatedTs)) < olderThan {
+				continue
+			}
+		}
+		rtn = append(rtn, block)
+	}
+	return rtn
+}
+
+func confirmClose(numBlocks int) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("stdin is not a terminal, use --yes to close the blocks without confirmation")
+	}
+	WriteStderr("close %d block(s)? [y/N] ", numBlocks)
+	line, err := bufio.NewReader(WrappedStdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false, nil
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+func closeMatchingRun(args []string) error {
+	var matchers []*blockMatcher
+	for _, matchStr := range closeMatch {
+		matcher, err := parseBlockMatcher(matchStr)
+		if err != nil {
+			return err
+		}
+		matchers = append(matchers, matcher)
+	}
+	if closeOlderThan < 0 {
+		return fmt.Errorf("invalid --older-than %v", closeOlderThan)
+	}
+	var listData wshrpc.CommandListData
+	if closeTab {
+		tabArg := "tab"
+		if len(args) > 0 {
+			tabArg = args[0]
+		}
+		oref, err := resolveSimpleId(tabArg)
+		if err != nil {
+			return fmt.Errorf("resolving tab id: %w", err)
+		}
+		if oref.OType != waveobj.OType_Tab {
+			return fmt.Errorf("%q is not a tab", tabArg)
+		}
+		listData.TabId = oref.OID
+	} else if len(args) > 0 {
+		return fmt.Errorf("--match cannot be used with an id (use --tab to only match the blocks in a tab)")
+	}
+	blocks, err := wshclient.ListBlocksCommand(RpcClient, listData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing blocks: %w", err)
+	}
+	matched := filterBlocks(blocks, matchers, closeOlderThan, time.Now())
+	if len(matched) == 0 {
+		WriteStdout("no matching blocks\n")
+		return nil
+	}
+	w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "BLOCKID\tTABID\tVIEW\tDETAILS\n")
+	blockIds := make([]string, 0, len(matched))
+	for _, block := range matched {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", block.BlockId, block.TabId, block.View, getBlockListDetails(block.Meta))
+		blockIds = append(blockIds, block.BlockId)
+	}
+	w.Flush()
+	if !closeYes {
+		ok, err := confirmClose(len(matched))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("canceled")
+		}
+	}
+	deleteData := wshrpc.CommandDeleteBlocksData{BlockIds: blockIds, IncludePinned: closeIncludePinned}
+	rtn, err := wshclient.DeleteBlocksCommand(RpcClient, deleteData, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("closing blocks: %w", err)
+	}
+	WriteStdout("closed %d block(s), skipped %d pinned\n", len(rtn.Deleted), len(rtn.Skipped))
+	return nil
+}
+
+func closeRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("close", rtnErr == nil)
+	}()
+	if len(closeMatch) > 0 || closeOlderThan != 0 {
+		if closeWindow || closeAllBlocks || closeKill {
+			OutputHelpMessage(cmd)
+			return fmt.Errorf("--match and --older-than cannot be used with --window, --all-blocks, or --kill")
+		}
+		return closeMatchingRun(args)
+	}
+	if closeYes || closeIncludePinned {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--yes and --include-pinned can only be used with --match or --older-than")
+	}
+	if closeTab && closeWindow {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("cannot use --tab and --window together")
+	}
+	if closeAllBlocks && closeWindow {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--all-blocks can only be used with a tab")
+	}
+	expectedOType := ""
+	if closeTab || closeAllBlocks {
+		expectedOType = waveobj.OType_Tab
+	} else if closeWindow {
+		expectedOType = waveobj.OType_Window
+	}
+	var oref *waveobj.ORef
+	var err error
+	if len(args) == 0 {
+		switch expectedOType {
+		case waveobj.OType_Window:
+			// the server finds the current window from the tab in the rpc context
+			oref = &waveobj.ORef{OType: waveobj.OType_Window}
+		case waveobj.OType_Tab:
+			oref, err = resolveSimpleId("tab")
+		default:
+			oref, err = resolveBlockArg()
+		}
+	} else {
+		oref, err = resolveSimpleId(args[0])
+		if err == nil && expectedOType != "" && oref.OType != expectedOType {
+			err = fmt.Errorf("%q is a %s, not a %s", args[0], oref.OType, expectedOType)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("resolving id: %w", err)
+	}
+	if closeAllBlocks && oref.OType != waveobj.OType_Tab {
+		return fmt.Errorf("--all-blocks can only be used with a tab")
+	}
+	if closeKill && oref.OType != waveobj.OType_Block {
+		return fmt.Errorf("--kill can only be used with a block")
+	}
+	switch oref.OType {
+	case waveobj.OType_Block:
+		rtn, err := deleteBlockWithKill(oref.OID, closeKill, closeKillGrace)
+		if err != nil {
+			return fmt.Errorf("closing block: %w", err)
+		}
+		WriteStdout("block closed%s\n", formatKilledProcs(rtn))
+	case waveobj.OType_Tab:
+		closeData := wshrpc.CommandCloseTabData{
+			TabId:     oref.OID,
+			Force:     closeForce,
+			AllBlocks: closeAllBlocks,
+		}
+		err = wshclient.CloseTabCommand(RpcClient, closeData, &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("closing tab: %w", err)
+		}
+		if closeAllBlocks {
+			WriteStdout("tab cleared\n")
+		} else {
+			WriteStdout("tab closed\n")
+		}
+	case waveobj.OType_Window:
+		err = wshclient.CloseWindowCommand(RpcClient, wshrpc.CommandCloseWindowData{WindowId: oref.OID}, &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("closing window: %w", err)
+		}
+		WriteStdout("window closed\n")
+	default:
+		return fmt.Errorf("cannot close object of type %q", oref.OType)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestFilterBlocks(t *testing.T) {
+	now := time.Now()
+	blocks := []wshrpc.BlockListEntry{
+		{BlockId: "b1", Meta: waveobj.MetaMapType{"view": "preview", "file": "/tmp/a.txt"}, CreatedTs: now.Add(-3 * time.Hour).UnixMilli()},
+		{BlockId: "b2", Meta: waveobj.MetaMapType{"view": "web", "url": "https://grafana.example.com/d/1"}, CreatedTs: now.Add(-time.Hour).UnixMilli()},
+		{BlockId: "b3", Meta: waveobj.MetaMapType{"view": "web", "url": "https://example.com"}},
+		{BlockId: "b4", Meta: waveobj.MetaMapType{"view": "term", "pinned": true}, CreatedTs: now.Add(-5 * time.Hour).UnixMilli()},
+	}
+	tests := []struct {
+		name      string
+		match     []string
+		olderThan time.Duration
+		expected  []string
+	}{
+		{name: "exact", match: []string{"view=preview"}, expected: []string{"b1"}},
+		{name: "regex", match: []string{"meta.url~=grafana"}, expected: []string{"b2"}},
+		{name: "all must match", match: []string{"view=web", "url~=^https://example"}, expected: []string{"b3"}},
+		{name: "missing key", match: []string{"url="}, expected: []string{"b1", "b4"}},
+		{name: "non-string value", match: []string{"pinned=true"}, expected: []string{"b4"}},
+		{name: "older than", olderThan: 2 * time.Hour, expected: []string{"b1", "b4"}},
+		{name: "older than and match", match: []string{"view=web"}, olderThan: 30 * time.Minute, expected: []string{"b2"}},
+	}
+	for _, tc := range tests {
+		var matchers []*blockMatcher
+		for _, matchStr := range tc.match {
+			matcher, err := parseBlockMatcher(matchStr)
+			if err != nil {
+				t.Fatalf("%s: error parsing %q: %v", tc.name, matchStr, err)
+			}
+			matchers = append(matchers, matcher)
+		}
+		var ids []string
+		for _, block := range filterBlocks(blocks, matchers, tc.olderThan, now) {
+			ids = append(ids, block.BlockId)
+		}
+		if !slices.Equal(ids, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, ids)
+		}
+	}
+	for _, badMatch := range []string{"view", "=preview", "~=x", "url~=("} {
+		if _, err := parseBlockMatcher(badMatch); err == nil {
+			t.Errorf("expected an error parsing %q", badMatch)
+		}
+	}
+}
@@ -4,15 +4,24 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/remote"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"golang.org/x/term"
 )
 
+// long enough to type a password (each prompt also has its own timeout)
+const ConnConnectInteractiveTimeoutMs = 5 * 60 * 1000
+
 var connCmd = &cobra.Command{
 	Use:   "conn",
 	Short: "manage Wave Terminal connections",
@@ -27,6 +36,27 @@ var connStatusCmd = &cobra.Command{
 	PreRunE: preRunSetupRpcClient,
 }
 
+var connListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "list the configured connections and their status",
+	Long:    "list the connections from connections.json and ~/.ssh/config (plus any that were used since waveterm started) and their status.  SOURCE is where the connection is configured (connections.json wins over ~/.ssh/config), RECONNECT shows the next auto-reconnect attempt for connections that dropped",
+	Args:    cobra.NoArgs,
+	RunE:    connListRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var connTestTimeout int
+var connListSource string
+
+var connTestCmd = &cobra.Command{
+	Use:     "test CONNECTION",
+	Short:   "test an ssh connection (reports the latency and the auth method used)",
+	Long:    "dial and authenticate an ssh connection without starting wsh or affecting an existing connection, then disconnect again",
+	Args:    cobra.ExactArgs(1),
+	RunE:    connTestRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
 var connReinstallCmd = &cobra.Command{
 	Use:     "reinstall CONNECTION",
 	Short:   "reinstall wsh on a connection",
@@ -67,14 +97,31 @@ var connEnsureCmd = &cobra.Command{
 	PreRunE: preRunSetupRpcClient,
 }
 
+var connTrustFingerprint string
+
+var connTrustCmd = &cobra.Command{
+	Use:     "trust CONNECTION --fingerprint SHA256:...",
+	Short:   "pre-approve the host key of an ssh connection",
+	Long:    "pre-approve the host key with this fingerprint (as printed by ssh-keygen -l), so the first connect adds it to Wave's known_hosts file without asking.  a key that doesn't match a key already in known_hosts is still refused",
+	Args:    cobra.ExactArgs(1),
+	RunE:    connTrustRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
 func init() {
 	rootCmd.AddCommand(connCmd)
 	connCmd.AddCommand(connStatusCmd)
+	connListCmd.Flags().StringVar(&connListSource, "source", "", "only list the connections from connections, sshconfig, or wsl")
+	connCmd.AddCommand(connListCmd)
+	connTestCmd.Flags().IntVar(&connTestTimeout, "timeout", 10, "seconds to wait for the connection before giving up")
+	connCmd.AddCommand(connTestCmd)
 	connCmd.AddCommand(connReinstallCmd)
 	connCmd.AddCommand(connDisconnectCmd)
 	connCmd.AddCommand(connDisconnectAllCmd)
 	connCmd.AddCommand(connConnectCmd)
 	connCmd.AddCommand(connEnsureCmd)
+	connTrustCmd.Flags().StringVar(&connTrustFingerprint, "fingerprint", "", "the SHA256 fingerprint of the host key")
+	connCmd.AddCommand(connTrustCmd)
 }
 
 func validateConnectionName(name string) error {
@@ -123,6 +170,106 @@ func connStatusRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func connListRun(cmd *cobra.Command, args []string) error {
+	switch connListSource {
+	case "", wshrpc.ConnSource_Connections, wshrpc.ConnSource_SshConfig, wshrpc.ConnSource_Wsl:
+	default:
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid --source %q (must be connections, sshconfig, or wsl)", connListSource)
+	}
+	allResp, err := wshclient.ConnListStatusCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("listing connections: %w", err)
+	}
+	wslResp, err := wshclient.WslStatusCommand(RpcClient, nil)
+	if err != nil {
+		return fmt.Errorf("getting wsl connection status: %w", err)
+	}
+	for _, conn := range wslResp {
+		conn.Source = wshrpc.ConnSource_Wsl
+		allResp = append(allResp, conn)
+	}
+	if connListSource != "" {
+		var filtered []wshrpc.ConnStatus
+		for _, conn := range allResp {
+			if conn.Source == connListSource {
+				filtered = append(filtered, conn)
+			}
+		}
+		allResp = filtered
+	}
+	if len(allResp) == 0 {
+		WriteStdout("no connections\n")
+		return nil
+	}
+	w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CONNECTION\tSOURCE\tSTATUS\tWSH\tRECONNECT\tLAST ERROR\n")
+	for _, conn := range allResp {
+		source := conn.Source
+		if source == "" {
+			// used since waveterm started, but not configured
+			source = "-"
+		}
+		wshStatus := "-"
+		if conn.Connected {
+			wshStatus = "no"
+			if conn.WshEnabled {
+				wshStatus = "yes"
+			}
+		}
+		lastErr := conn.Error
+		if lastErr != "" && conn.ErrorHop != "" {
+			lastErr = fmt.Sprintf("(at jump host %s) %s", conn.ErrorHop, lastErr)
+		}
+		if lastErr == "" {
+			lastErr = conn.WshError
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", conn.Connection, source, conn.Status, wshStatus, getReconnectStr(conn), lastErr)
+	}
+	w.Flush()
+	return nil
+}
+
+func getReconnectStr(conn wshrpc.ConnStatus) string {
+	if !conn.Reconnecting {
+		return "-"
+	}
+	if conn.NextReconnectTs == 0 {
+		return fmt.Sprintf("attempt %d (trying now)", conn.ReconnectAttempt)
+	}
+	nextTry := max(time.Until(time.UnixMilli(conn.NextReconnectTs)), 0).Round(time.Second)
+	return fmt.Sprintf("attempt %d in %v (%s)", conn.ReconnectAttempt, nextTry, time.UnixMilli(conn.NextReconnectTs).Format("15:04:05"))
+}
+
+func connTestRun(cmd *cobra.Command, args []string) error {
+	connName := args[0]
+	if strings.HasPrefix(connName, "wsl://") {
+		return fmt.Errorf("conn test is only supported for ssh connections")
+	}
+	if err := validateConnectionName(connName); err != nil {
+		return err
+	}
+	if connTestTimeout <= 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid --timeout %d", connTestTimeout)
+	}
+	data := wshrpc.CommandConnTestData{
+		ConnName: connName,
+		Timeout:  connTestTimeout * 1000,
+	}
+	// a little longer than the test itself, so the server can report the timeout
+	rpcTimeout := (connTestTimeout + 5) * 1000
+	rtn, err := wshclient.ConnTestCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: rpcTimeout})
+	if err != nil {
+		return fmt.Errorf("testing connection %q: %w", connName, err)
+	}
+	WriteStdout("connection %q ok\n", rtn.Connection)
+	WriteStdout("  auth method:  %s\n", rtn.AuthMethod)
+	WriteStdout("  connect time: %dms\n", rtn.ConnectTimeMs)
+	WriteStdout("  latency:      %dms\n", rtn.LatencyMs)
+	return nil
+}
+
 func connReinstallRun(cmd *cobra.Command, args []string) error {
 	connName := args[0]
 	if err := validateConnectionName(connName); err != nil {
@@ -177,11 +324,11 @@ func connConnectRun(cmd *cobra.Command, args []string) error {
 	if err := validateConnectionName(connName); err != nil {
 		return err
 	}
-	data := wshrpc.ConnRequest{
-		Host:       connName,
+	data := wshrpc.CommandConnConnectInteractiveData{
+		ConnName:   connName,
 		LogBlockId: RpcContext.BlockId,
 	}
-	err := wshclient.ConnConnectCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 60000})
+	err := connectInteractive(data)
 	if err != nil {
 		return fmt.Errorf("connecting connection: %w", err)
 	}
@@ -189,19 +336,156 @@ func connConnectRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func connTrustRun(cmd *cobra.Command, args []string) error {
+	connName := args[0]
+	if strings.HasPrefix(connName, "wsl://") {
+		return fmt.Errorf("conn trust is only supported for ssh connections")
+	}
+	if err := validateConnectionName(connName); err != nil {
+		return err
+	}
+	if connTrustFingerprint == "" {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--fingerprint is required")
+	}
+	fingerprint, err := remote.NormalizeHostKeyFingerprint(connTrustFingerprint)
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandConnTrustHostKeyData{
+		ConnName:    connName,
+		Fingerprint: fingerprint,
+	}
+	err = wshclient.ConnTrustHostKeyCommand(RpcClient, data, nil)
+	if err != nil {
+		return fmt.Errorf("trusting host key: %w", err)
+	}
+	WriteStdout("trusted host key %s for connection %q\n", fingerprint, connName)
+	return nil
+}
+
 func connEnsureRun(cmd *cobra.Command, args []string) error {
 	connName := args[0]
 	if err := validateConnectionName(connName); err != nil {
 		return err
 	}
-	data := wshrpc.ConnExtData{
+	data := wshrpc.CommandConnConnectInteractiveData{
 		ConnName:   connName,
 		LogBlockId: RpcContext.BlockId,
+		Ensure:     true,
 	}
-	err := wshclient.ConnEnsureCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 60000})
+	err := connectInteractive(data)
 	if err != nil {
 		return fmt.Errorf("ensuring connection: %w", err)
 	}
 	WriteStdout("wsh ensured on connection %q\n", connName)
 	return nil
 }
+
+// connects (or with Ensure, makes sure the connection is up).  the password, keyboard-interactive and host key
+// prompts are answered on this terminal instead of in the Wave window.
+func connectInteractive(data wshrpc.CommandConnConnectInteractiveData) error {
+	if UsingTermWshMode {
+		// stdin carries the rpc messages, the prompts are shown in the Wave window
+		if data.Ensure {
+			return wshclient.ConnEnsureCommand(RpcClient, wshrpc.ConnExtData{ConnName: data.ConnName, LogBlockId: data.LogBlockId}, &wshrpc.RpcOpts{Timeout: 60000})
+		}
+		connRequest := wshrpc.ConnRequest{Host: data.ConnName, Keywords: data.Keywords, LogBlockId: data.LogBlockId}
+		return wshclient.ConnConnectCommand(RpcClient, connRequest, &wshrpc.RpcOpts{Timeout: 60000})
+	}
+	respCh := wshclient.ConnConnectInteractiveCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: ConnConnectInteractiveTimeoutMs})
+	for resp := range respCh {
+		if resp.Error != nil {
+			return resp.Error
+		}
+		prompt := resp.Response
+		answer, err := readAuthPromptAnswer(prompt)
+		if err != nil {
+			// the connect fails with the cancel (the prompt is not left waiting for its timeout)
+			WriteStderr("%v\n", err)
+			answer = wshrpc.CommandAuthPromptResponseData{PromptId: prompt.PromptId, Cancel: true}
+		}
+		err = wshclient.AuthPromptResponseCommand(RpcClient, answer, nil)
+		if err != nil {
+			return fmt.Errorf("sending answer: %w", err)
+		}
+	}
+	return nil
+}
+
+func readAuthPromptAnswer(prompt wshrpc.ConnAuthPrompt) (wshrpc.CommandAuthPromptResponseData, error) {
+	rtn := wshrpc.CommandAuthPromptResponseData{PromptId: prompt.PromptId}
+	ttyFile, err := openPromptTerminal()
+	if err != nil {
+		return rtn, err
+	}
+	if ttyFile != os.Stdin {
+		defer ttyFile.Close()
+	}
+	WriteStderr("%s\n%s ", prompt.Title, strings.TrimSpace(prompt.QueryText))
+	if prompt.ResponseType == "confirm" {
+		WriteStderr("[y/N] ")
+		line, err := bufio.NewReader(ttyFile).ReadString('\n')
+		if err != nil {
+			return rtn, fmt.Errorf("reading answer: %w", err)
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		rtn.Confirm = answer == "y" || answer == "yes"
+		return rtn, nil
+	}
+	if prompt.Echo {
+		line, err := bufio.NewReader(ttyFile).ReadString('\n')
+		if err != nil {
+			return rtn, fmt.Errorf("reading answer: %w", err)
+		}
+		rtn.Text = strings.TrimRight(line, "\r\n")
+		return rtn, nil
+	}
+	barr, err := readNoEcho(ttyFile)
+	WriteStderr("\n")
+	if err != nil {
+		return rtn, fmt.Errorf("reading answer: %w", err)
+	}
+	rtn.Text = string(barr)
+	return rtn, nil
+}
+
+// stdin, or the controlling terminal when stdin is redirected
+func openPromptTerminal() (*os.File, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return os.Stdin, nil
+	}
+	ttyFile, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal to answer the prompt on")
+	}
+	return ttyFile, nil
+}
+
+// the terminal state while a secret is read, restored if wsh is interrupted (so echo is not left off)
+var noEchoTermLock = &sync.Mutex{}
+var noEchoTermRestoreFn func()
+
+func readNoEcho(ttyFile *os.File) ([]byte, error) {
+	fd := int(ttyFile.Fd())
+	oldState, err := term.GetState(fd)
+	if err == nil {
+		noEchoTermLock.Lock()
+		noEchoTermRestoreFn = func() { term.Restore(fd, oldState) }
+		noEchoTermLock.Unlock()
+		defer func() {
+			noEchoTermLock.Lock()
+			noEchoTermRestoreFn = nil
+			noEchoTermLock.Unlock()
+		}()
+	}
+	return term.ReadPassword(fd)
+}
+
+func restoreNoEchoTerm() {
+	noEchoTermLock.Lock()
+	defer noEchoTermLock.Unlock()
+	if noEchoTermRestoreFn != nil {
+		noEchoTermRestoreFn()
+	}
+}
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
@@ -45,10 +46,39 @@ func createBlockRun(cmd *cobra.Command, args []string) error {
 		},
 		Magnified: createBlockMagnified,
 	}
-	oref, err := wshclient.CreateBlockCommand(RpcClient, data, nil)
+	rtnData, err := wshclient.CreateBlockCommand(RpcClient, data, nil)
 	if err != nil {
 		return fmt.Errorf("create block failed: %v", err)
 	}
-	fmt.Printf("created block %s\n", oref.OID)
+	fmt.Printf("created block %s\n", rtnData.BlockORef.OID)
 	return nil
 }
+
+const blockPositionFlagHelp = `where to put the block in the layout: "end" (the right edge) or "after:[blockid]" (e.g. after:this)`
+
+// resolves the --tab and --position flags for a new block (tabArg defaults to the current tab),
+// "after:" block ids are resolved here so the server gets a real block id
+func resolveBlockPlacementArgs(tabArg string, position string) (string, string, error) {
+	var tabId string
+	if tabArg != "" {
+		tabORef, err := resolveSimpleId(tabArg)
+		if err != nil {
+			return "", "", fmt.Errorf("resolving tab id: %w", err)
+		}
+		if tabORef.OType != waveobj.OType_Tab {
+			return "", "", fmt.Errorf("%q is not a tab", tabArg)
+		}
+		tabId = tabORef.OID
+	}
+	if blockArg, ok := strings.CutPrefix(position, "after:"); ok {
+		blockORef, err := resolveSimpleId(blockArg)
+		if err != nil {
+			return "", "", fmt.Errorf("resolving position block id: %w", err)
+		}
+		if blockORef.OType != waveobj.OType_Block {
+			return "", "", fmt.Errorf("%q is not a block", blockArg)
+		}
+		position = "after:" + blockORef.OID
+	}
+	return tabId, position, nil
+}
@@ -5,8 +5,10 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 )
 
@@ -24,8 +26,48 @@ var debugBlockIdsCmd = &cobra.Command{
 	Hidden: true,
 }
 
+var debugCacheStatsCmd = &cobra.Command{
+	Use:    "cache",
+	Short:  "show wstore read cache stats",
+	RunE:   debugCacheStatsRun,
+	Hidden: true,
+}
+
+var debugIntegrityCmd = &cobra.Command{
+	Use:   "integrity [--repair]",
+	Short: "check (or repair) the references between windows, workspaces, tabs, and blocks",
+	Long: `check the references between the stored windows, workspaces, tabs, layouts, and blocks for dangling ids
+and orphaned objects.  with --repair, dangling references are pruned and orphans are re-parented
+(or deleted with --delete-orphans).`,
+	Args:   cobra.NoArgs,
+	RunE:   debugIntegrityRun,
+	Hidden: true,
+}
+
+var debugDBVersionCmd = &cobra.Command{
+	Use:   "dbversion",
+	Short: "show the database schema versions",
+	Long: `show the schema version of each database (wstore and filestore) and the version this build of wave expects.
+to open the database with the previous version of wave, quit wave and revert the most recent migration of a store
+with "wavesrv --rollback-db [wstore|filestore]", with WAVETERM_DATA_HOME and WAVETERM_CONFIG_HOME set to wave's
+data and config dirs (it refuses to run while wave is running).`,
+	Args:   cobra.NoArgs,
+	RunE:   debugDBVersionRun,
+	Hidden: true,
+}
+
+var debugIntegrityRepair bool
+var debugIntegrityDeleteOrphans bool
+var debugIntegrityJson bool
+
 func init() {
+	debugIntegrityCmd.Flags().BoolVar(&debugIntegrityRepair, "repair", false, "prune dangling references and re-parent orphans")
+	debugIntegrityCmd.Flags().BoolVar(&debugIntegrityDeleteOrphans, "delete-orphans", false, "delete orphans instead of re-parenting them (with --repair)")
+	debugIntegrityCmd.Flags().BoolVar(&debugIntegrityJson, "json", false, "output the report as json")
 	debugCmd.AddCommand(debugBlockIdsCmd)
+	debugCmd.AddCommand(debugCacheStatsCmd)
+	debugCmd.AddCommand(debugIntegrityCmd)
+	debugCmd.AddCommand(debugDBVersionCmd)
 	rootCmd.AddCommand(debugCmd)
 }
 
@@ -45,3 +87,79 @@ func debugBlockIdsRun(cmd *cobra.Command, args []string) error {
 	WriteStdout("%s\n", string(barr))
 	return nil
 }
+
+func debugCacheStatsRun(cmd *cobra.Command, args []string) error {
+	stats, err := wshclient.DebugCacheStatsCommand(RpcClient, nil)
+	if err != nil {
+		return err
+	}
+	barr, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	WriteStdout("%s\n", string(barr))
+	return nil
+}
+
+func writeIntegrityIssue(kind string, issue wshrpc.IntegrityIssueData) {
+	if issue.Field != "" {
+		WriteStdout("%-9s %s %s=%s (%s)\n", kind, issue.ORef, issue.Field, issue.RefId, issue.Reason)
+		return
+	}
+	WriteStdout("%-9s %s (%s)\n", kind, issue.ORef, issue.Reason)
+}
+
+func debugIntegrityRun(cmd *cobra.Command, args []string) error {
+	if debugIntegrityDeleteOrphans && !debugIntegrityRepair {
+		return fmt.Errorf("--delete-orphans requires --repair")
+	}
+	data := wshrpc.CommandDebugIntegrityData{Repair: debugIntegrityRepair, DeleteOrphans: debugIntegrityDeleteOrphans}
+	rtn, err := wshclient.DebugIntegrityCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return err
+	}
+	if debugIntegrityJson {
+		barr, err := json.MarshalIndent(rtn, "", "  ")
+		if err != nil {
+			return err
+		}
+		WriteStdout("%s\n", string(barr))
+		return nil
+	}
+	for _, issue := range rtn.Dangling {
+		writeIntegrityIssue("dangling", issue)
+	}
+	for _, issue := range rtn.Orphans {
+		writeIntegrityIssue("orphan", issue)
+	}
+	numIssues := len(rtn.Dangling) + len(rtn.Orphans)
+	if numIssues == 0 {
+		WriteStdout("no integrity issues found\n")
+		return nil
+	}
+	if rtn.Repaired {
+		WriteStdout("repaired %d issue(s), updated %d and deleted %d object(s)\n", numIssues, rtn.NumUpdated, rtn.NumDeleted)
+		return nil
+	}
+	WriteStdout("found %d issue(s), run with --repair to fix them\n", numIssues)
+	return nil
+}
+
+func debugDBVersionRun(cmd *cobra.Command, args []string) error {
+	rtn, err := wshclient.DebugDBVersionCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return err
+	}
+	for _, store := range rtn.Stores {
+		var note string
+		if store.Dirty {
+			note = " (dirty, the last migration did not complete)"
+		} else if store.Version > store.Expected {
+			note = " (newer than this version of wave)"
+		} else if store.Version < store.Expected {
+			note = " (older than this version of wave)"
+		}
+		WriteStdout("%-10s version %d, expected %d%s\n", store.Store, store.Version, store.Expected, note)
+	}
+	return nil
+}
@@ -5,11 +5,14 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 )
 
+var deleteBlockKill bool
+var deleteBlockKillGrace time.Duration
+
 var deleteBlockCmd = &cobra.Command{
 	Use:     "deleteblock",
 	Short:   "delete a block",
@@ -18,6 +21,8 @@ var deleteBlockCmd = &cobra.Command{
 }
 
 func init() {
+	deleteBlockCmd.Flags().BoolVar(&deleteBlockKill, "kill", false, "also kill the processes started by the block's shell")
+	deleteBlockCmd.Flags().DurationVar(&deleteBlockKillGrace, "grace", 2*time.Second, "with --kill, how long the processes get to exit before they are force killed")
 	rootCmd.AddCommand(deleteBlockCmd)
 }
 
@@ -32,13 +37,10 @@ func deleteBlockRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	if fullORef.OType != "block" {
 		return fmt.Errorf("object reference is not a block")
 	}
-	deleteBlockData := &wshrpc.CommandDeleteBlockData{
-		BlockId: fullORef.OID,
-	}
-	_, err = RpcClient.SendRpcRequest(wshrpc.Command_DeleteBlock, deleteBlockData, &wshrpc.RpcOpts{Timeout: 2000})
+	rtn, err := deleteBlockWithKill(fullORef.OID, deleteBlockKill, deleteBlockKillGrace)
 	if err != nil {
 		return fmt.Errorf("delete block failed: %v", err)
 	}
-	WriteStdout("block deleted\n")
+	WriteStdout("block deleted%s\n", formatKilledProcs(rtn))
 	return nil
 }
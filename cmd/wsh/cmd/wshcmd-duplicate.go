@@ -0,0 +1,60 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var duplicateMeta []string
+
+var duplicateCmd = &cobra.Command{
+	Use:     "duplicate [blockid]",
+	Short:   "duplicate a block",
+	Long:    "create a copy of a block (its view, connection, url, etc.) next to it in the same tab. defaults to the current block.",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    duplicateRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	duplicateCmd.Flags().StringArrayVar(&duplicateMeta, "meta", nil, "set a meta key on the copy (key=value, can be repeated)")
+	rootCmd.AddCommand(duplicateCmd)
+}
+
+func duplicateRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("duplicate", rtnErr == nil)
+	}()
+	blockArg := "this"
+	if len(args) > 0 {
+		blockArg = args[0]
+	}
+	blockORef, err := resolveSimpleId(blockArg)
+	if err != nil {
+		return fmt.Errorf("resolving block id: %w", err)
+	}
+	if blockORef.OType != waveobj.OType_Block {
+		return fmt.Errorf("%q is not a block", blockArg)
+	}
+	meta, err := parseMetaSets(duplicateMeta)
+	if err != nil {
+		return err
+	}
+	dupData := wshrpc.CommandDuplicateBlockData{
+		BlockId: blockORef.OID,
+		Meta:    meta,
+	}
+	newORef, err := wshclient.DuplicateBlockCommand(RpcClient, dupData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("duplicating block: %w", err)
+	}
+	WriteStdout("created block %s\n", newORef.OID)
+	return nil
+}
@@ -5,7 +5,6 @@ package cmd
 
 import (
 	"fmt"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
@@ -13,16 +12,21 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 )
 
+var editConfigReset bool
+
 var editConfigCmd = &cobra.Command{
-	Use:     "editconfig [configfile]",
-	Short:   "edit Wave configuration files",
-	Long:    "Edit Wave configuration files. Defaults to settings.json if no file specified. Common files: settings.json, presets.json, widgets.json",
+	Use:   "editconfig [section]",
+	Short: "edit Wave configuration files",
+	Long: `Edit Wave configuration files. Defaults to settings.json if no section is specified. Common sections: settings, connections, presets, widgets, termthemes, presets/ai (".json" is optional).
+The file is checked when it is saved, and an invalid file (bad json, unknown keys, or values of the wrong type) isn't written (the errors are shown in the editor).
+--reset replaces the file with Wave's defaults for the section before opening it (the old file is kept as <file>.bak).`,
 	Args:    cobra.MaximumNArgs(1),
 	RunE:    editConfigRun,
 	PreRunE: preRunSetupRpcClient,
 }
 
 func init() {
+	editConfigCmd.Flags().BoolVar(&editConfigReset, "reset", false, "write the defaults for the section before opening it")
 	rootCmd.AddCommand(editConfigCmd)
 }
 
@@ -31,24 +35,35 @@ func editConfigRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		sendActivity("editconfig", rtnErr == nil)
 	}()
 
-	// Get config directory from Wave info
-	resp, err := wshclient.WaveInfoCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
-	if err != nil {
-		return fmt.Errorf("getting Wave info: %w", err)
-	}
-
-	configFile := "settings.json" // default
+	var section string
 	if len(args) > 0 {
-		configFile = args[0]
+		section = args[0]
+	}
+	pathData := wshrpc.CommandConfigPathData{Section: section}
+	var configPath *wshrpc.ConfigPathRtnData
+	var err error
+	if editConfigReset {
+		configPath, err = wshclient.ResetConfigCommand(RpcClient, pathData, &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("resetting config: %w", err)
+		}
+		if configPath.BackupPath != "" {
+			WriteStdout("reset %s to the defaults (the old file is %s)\n", configPath.Section, configPath.BackupPath)
+		} else {
+			WriteStdout("reset %s to the defaults\n", configPath.Section)
+		}
+	} else {
+		configPath, err = wshclient.GetConfigPathCommand(RpcClient, pathData, &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("getting config path: %w", err)
+		}
 	}
-
-	settingsFile := filepath.Join(resp.ConfigDir, configFile)
 
 	wshCmd := &wshrpc.CommandCreateBlockData{
 		BlockDef: &waveobj.BlockDef{
 			Meta: map[string]interface{}{
 				waveobj.MetaKey_View: "preview",
-				waveobj.MetaKey_File: settingsFile,
+				waveobj.MetaKey_File: configPath.Path,
 				waveobj.MetaKey_Edit: true,
 			},
 		},
@@ -11,7 +11,6 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
-	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 )
@@ -67,17 +66,13 @@ func editorRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	if RpcContext.Conn != "" {
 		wshCmd.BlockDef.Meta[waveobj.MetaKey_Connection] = RpcContext.Conn
 	}
-	blockRef, err := wshclient.CreateBlockCommand(RpcClient, wshCmd, &wshrpc.RpcOpts{Timeout: 2000})
+	closeWaiter, err := startBlockCloseWaiter()
+	if err != nil {
+		return err
+	}
+	rtnData, err := wshclient.CreateBlockCommand(RpcClient, wshCmd, &wshrpc.RpcOpts{Timeout: 2000})
 	if err != nil {
 		return fmt.Errorf("running view command: %w", err)
 	}
-	doneCh := make(chan bool)
-	RpcClient.EventListener.On(wps.Event_BlockClose, func(event *wps.WaveEvent) {
-		if event.HasScope(blockRef.String()) {
-			close(doneCh)
-		}
-	})
-	wshclient.EventSubCommand(RpcClient, wps.SubscriptionRequest{Event: wps.Event_BlockClose, Scopes: []string{blockRef.String()}}, nil)
-	<-doneCh
-	return nil
+	return closeWaiter.wait([]*waveobj.ORef{&rtnData.BlockORef}, 0)
 }
@@ -0,0 +1,95 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var eventsSubTabId string
+var eventsSubBlockId string
+var eventsSubBufferSize int
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "subscribe to wave events",
+}
+
+var eventsSubCmd = &cobra.Command{
+	Use:   "sub {eventtype[,eventtype...]|*}",
+	Short: "print matching events as json lines until interrupted",
+	Long: `print the matching events as json lines (one event per line) until interrupted.
+event types include blockupdate, layoutaction, blockclose, controllerstatus, and waveobj:update (use * for all events).
+if wsh falls behind, events are dropped and a "dropped" event (with the number of dropped events) is printed.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    eventsSubRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	eventsSubCmd.Flags().StringVar(&eventsSubTabId, "tab", "", "only events for the given tab and its blocks (e.g. a tab id, or 'tab' for the current tab)")
+	eventsSubCmd.Flags().StringVarP(&eventsSubBlockId, "block", "b", "", "only events for the given block (e.g. a block id, or 'this' for the current block)")
+	eventsSubCmd.Flags().IntVar(&eventsSubBufferSize, "buffer", 0, "number of events to buffer before dropping events (default 256)")
+	eventsCmd.AddCommand(eventsSubCmd)
+	rootCmd.AddCommand(eventsCmd)
+}
+
+func eventsSubRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("events", rtnErr == nil)
+	}()
+	var eventTypes []string
+	for _, eventType := range strings.Split(args[0], ",") {
+		eventType = strings.TrimSpace(eventType)
+		if eventType != "" {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	if len(eventTypes) == 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("no event types given")
+	}
+	data := wshrpc.CommandStreamEventsData{EventTypes: eventTypes, BufferSize: eventsSubBufferSize}
+	if eventsSubTabId != "" {
+		oref, err := resolveSimpleId(eventsSubTabId)
+		if err != nil {
+			return fmt.Errorf("resolving tab id: %w", err)
+		}
+		if oref.OType != waveobj.OType_Tab {
+			return fmt.Errorf("%q is not a tab", eventsSubTabId)
+		}
+		data.TabId = oref.OID
+	}
+	if eventsSubBlockId != "" {
+		oref, err := resolveSimpleId(eventsSubBlockId)
+		if err != nil {
+			return fmt.Errorf("resolving block id: %w", err)
+		}
+		if oref.OType != waveobj.OType_Block {
+			return fmt.Errorf("%q is not a block", eventsSubBlockId)
+		}
+		data.BlockId = oref.OID
+	}
+	// the subscription lasts until wsh is killed (the server stops streaming when our route goes away)
+	respCh := wshclient.StreamEventsCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: math.MaxInt32})
+	for resp := range respCh {
+		if resp.Error != nil {
+			return fmt.Errorf("streaming events: %w", resp.Error)
+		}
+		barr, err := json.Marshal(resp.Response)
+		if err != nil {
+			return fmt.Errorf("formatting event: %w", err)
+		}
+		WriteStdout("%s\n", string(barr))
+	}
+	return nil
+}
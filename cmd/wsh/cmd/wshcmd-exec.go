@@ -0,0 +1,109 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+var execConn string
+var execInput bool
+var execNoConnect bool
+
+const execInputChunkSize = 32 * 1024
+
+var execCmd = &cobra.Command{
+	Use:   "exec [--conn name] -- command [args...]",
+	Short: "run a command on a connection (without a block)",
+	Long: `run a command on an ssh connection, without creating a block, and exit with its exit code.  the command's stdout
+and stderr are written to wsh's stdout and stderr.  defaults to the current block's connection.
+
+a single argument is run as a shell command (so "ls | wc -l" works), more arguments are passed as they are.  the
+connection is connected first if it isn't (use --no-connect to fail instead).  --input sends wsh's stdin to the
+command (as it is read, until EOF), and --timeout kills the command after the given time.`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    execRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	execCmd.Flags().StringVar(&execConn, "conn", "", "the connection to run the command on (defaults to the current block's connection)")
+	execCmd.Flags().BoolVar(&execInput, "input", false, "send stdin to the command")
+	execCmd.Flags().BoolVar(&execNoConnect, "no-connect", false, "fail if the connection isn't connected (instead of connecting)")
+	// the flags after the command are the command's
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd)
+}
+
+func execRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("exec", rtnErr == nil)
+	}()
+	connName := execConn
+	if connName == "" {
+		connName = RpcContext.Conn
+	}
+	if connName == "" || connName == "local" {
+		return fmt.Errorf("no connection, use --conn")
+	}
+	data := wshrpc.CommandRemoteExecData{
+		ConnName:  connName,
+		Cmd:       args,
+		Input:     execInput,
+		NoConnect: execNoConnect,
+	}
+	// no timeout (unless --timeout is given, which kills the command)
+	respCh := wshclient.RemoteExecCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: wshutil.MaxTimeoutMs})
+	for resp := range respCh {
+		if resp.Error != nil {
+			return fmt.Errorf("running command on %s: %w", connName, resp.Error)
+		}
+		packet := resp.Response
+		if packet.ExecId != "" {
+			go sendExecInput(packet.ExecId)
+			continue
+		}
+		if packet.Done {
+			if packet.ExitSignal != "" {
+				WriteStderr("wsh: command killed by signal %s\n", packet.ExitSignal)
+			}
+			WshExitCode = packet.ExitCode
+			return nil
+		}
+		output, err := base64.StdEncoding.DecodeString(packet.Data64)
+		if err != nil {
+			return fmt.Errorf("decoding output: %w", err)
+		}
+		if packet.Stream == "stderr" {
+			WrappedStderr.Write(output)
+		} else {
+			WrappedStdout.Write(output)
+		}
+	}
+	return fmt.Errorf("running command on %s: no exit code (the connection was closed)", connName)
+}
+
+// sends stdin to the command as it is read, stops when the command is gone (the input command fails)
+func sendExecInput(execId string) {
+	buf := make([]byte, execInputChunkSize)
+	for {
+		n, readErr := WrappedStdin.Read(buf)
+		data := wshrpc.CommandRemoteExecInputData{ExecId: execId, Eof: readErr != nil}
+		if n > 0 {
+			data.Input64 = base64.StdEncoding.EncodeToString(buf[:n])
+		}
+		if n > 0 || data.Eof {
+			err := wshclient.RemoteExecInputCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: wshutil.MaxTimeoutMs})
+			if err != nil || data.Eof {
+				return
+			}
+		}
+	}
+}
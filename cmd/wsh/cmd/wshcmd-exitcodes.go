@@ -0,0 +1,110 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+type errorCategory struct {
+	Name     string
+	ExitCode int
+	RpcCode  string // the wshrpc.ErrorCode_* of the rpc errors in this category
+	Desc     string
+}
+
+var (
+	errCatGeneral     = &errorCategory{Name: "error", ExitCode: 1, Desc: "any other error"}
+	errCatInvalidArg  = &errorCategory{Name: "invalid-argument", ExitCode: 2, RpcCode: wshrpc.ErrorCode_InvalidArg, Desc: "invalid arguments or flags (the usage is printed too)"}
+	errCatNotFound    = &errorCategory{Name: "not-found", ExitCode: 3, RpcCode: wshrpc.ErrorCode_NotFound, Desc: "the block, tab, window, workspace, connection, or file doesn't exist"}
+	errCatPermission  = &errorCategory{Name: "permission", ExitCode: 4, RpcCode: wshrpc.ErrorCode_Permission, Desc: "permission denied (e.g. reading or writing a file)"}
+	errCatConnRefused = &errorCategory{Name: "connection-refused", ExitCode: 5, RpcCode: wshrpc.ErrorCode_ConnRefused, Desc: "the Wave server (or the connection the request is for) isn't running or can't be reached"}
+	errCatTimeout     = &errorCategory{Name: "timeout", ExitCode: 6, RpcCode: wshrpc.ErrorCode_Timeout, Desc: "a request timed out (see --timeout)"}
+)
+
+// the exit codes (shown by "wsh help exit-codes")
+var errorCategories = []*errorCategory{errCatGeneral, errCatInvalidArg, errCatNotFound, errCatPermission, errCatConnRefused, errCatTimeout}
+
+// an error in a category (commands return one when the category isn't clear from the rpc error)
+type cmdError struct {
+	category *errorCategory
+	err      error
+}
+
+func (e *cmdError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cmdError) Unwrap() error {
+	return e.err
+}
+
+func makeCmdError(category *errorCategory, err error) error {
+	return &cmdError{category: category, err: err}
+}
+
+func cmdErrorf(category *errorCategory, format string, args ...any) error {
+	return &cmdError{category: category, err: fmt.Errorf(format, args...)}
+}
+
+// set when a command printed its usage for an error (see OutputHelpMessage) and when cobra got to running the
+// command (errors before that are from parsing the arguments), both make the error an invalid-argument error
+var usageErrorShown bool
+var cmdArgsParsed bool
+
+func getErrorCategory(err error) *errorCategory {
+	var cmdErr *cmdError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.category
+	}
+	var timeoutErr *wshutil.RpcTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return errCatTimeout
+	}
+	// error codes from the rpc error responses (see wshrpc.CodedError), or the well known local errors
+	if code := wshrpc.GetErrorCode(err); code != "" {
+		for _, category := range errorCategories {
+			if category.RpcCode == code {
+				return category
+			}
+		}
+	}
+	if usageErrorShown || !cmdArgsParsed {
+		return errCatInvalidArg
+	}
+	return errCatGeneral
+}
+
+var exitCodesCmd = &cobra.Command{
+	Use:   "exit-codes",
+	Short: "the exit codes wsh uses for errors",
+}
+
+func init() {
+	exitCodesCmd.Long = makeExitCodesHelp()
+	rootCmd.AddCommand(exitCodesCmd)
+}
+
+func makeExitCodesHelp() string {
+	var buf strings.Builder
+	buf.WriteString(`wsh exits with 0 when a command succeeds.  when it fails, it prints "wsh: <category>: <message>" to stderr
+and exits with the category's code, so scripts can tell the kinds of errors apart:
+
+`)
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, category := range errorCategories {
+		fmt.Fprintf(w, "  %d\t%s\t%s\n", category.ExitCode, category.Name, category.Desc)
+	}
+	w.Flush()
+	buf.WriteString(`
+wsh run --wait exits with the command's exit code instead, and wsh exits with 130 when it is interrupted (ctrl-c).`)
+	return buf.String()
+}
@@ -0,0 +1,46 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+func TestGetErrorCategory(t *testing.T) {
+	cmdArgsParsed = true
+	defer func() {
+		cmdArgsParsed = false
+	}()
+	tests := []struct {
+		name     string
+		err      error
+		expected *errorCategory
+	}{
+		{name: "general", err: errors.New("failed"), expected: errCatGeneral},
+		{name: "cmd error", err: fmt.Errorf("resolving id: %w", cmdErrorf(errCatNotFound, "id not found")), expected: errCatNotFound},
+		{name: "rpc code", err: fmt.Errorf("closing block: %w", wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block not found")), expected: errCatNotFound},
+		{name: "rpc timeout", err: fmt.Errorf("getting meta: %w", &wshutil.RpcTimeoutError{Timeout: time.Second}), expected: errCatTimeout},
+		{name: "local file", err: fmt.Errorf("reading file: %w", fs.ErrPermission), expected: errCatPermission},
+		{name: "wrapped with %v", err: fmt.Errorf("closing block: %v", wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block not found")), expected: errCatGeneral},
+	}
+	for _, tc := range tests {
+		if category := getErrorCategory(tc.err); category != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected.Name, category.Name)
+		}
+	}
+	exitCodes := make(map[int]bool)
+	for _, category := range errorCategories {
+		if exitCodes[category.ExitCode] {
+			t.Errorf("exit code %d is used twice", category.ExitCode)
+		}
+		exitCodes[category.ExitCode] = true
+	}
+}
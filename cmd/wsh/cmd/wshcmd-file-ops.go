@@ -0,0 +1,124 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+// wsh file rm (for paths that are not wavefile:// urls), mv, and mkdir use the same rpcs as the preview block
+
+const FileOpTimeout = 60000
+
+var fileOpConn string
+var fileOpAnyPath bool
+var fileRmRecursive bool
+var fileRmTrash bool
+
+var fileMvCmd = &cobra.Command{
+	Use:   "mv source destination",
+	Short: "move or rename a file or directory on a connection",
+	Long: `move or rename a file or directory on the block's connection (or the --conn connection).  moving into an
+existing directory keeps the name.  relative paths are relative to the block's current directory, the paths must be
+under it unless --any-path is given (for another connection the paths are relative to, and must be under, ~).`,
+	Example: "  wsh file mv notes.txt notes-old.txt\n  wsh file mv --conn myserver ~/build.log ~/logs",
+	Args:    cobra.ExactArgs(2),
+	RunE:    activityWrap("file", fileMvRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var fileMkdirCmd = &cobra.Command{
+	Use:     "mkdir path",
+	Short:   "create a directory (and its parents) on a connection",
+	Example: "  wsh file mkdir build/out\n  wsh file mkdir --conn myserver ~/backups",
+	Args:    cobra.ExactArgs(1),
+	RunE:    activityWrap("file", fileMkdirRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{fileRmCmd, fileMvCmd, fileMkdirCmd} {
+		cmd.Flags().StringVarP(&fileOpConn, "conn", "c", "", "the connection (defaults to the block's connection)")
+		cmd.Flags().BoolVar(&fileOpAnyPath, "any-path", false, "allow paths that are not under the current directory")
+	}
+	fileRmCmd.Flags().BoolVarP(&fileRmRecursive, "recursive", "r", false, "remove a directory and everything in it")
+	fileRmCmd.Flags().BoolVar(&fileRmTrash, "trash", false, "move to the trash (or the recycle bin) instead")
+	fileCmd.AddCommand(fileMvCmd)
+	fileCmd.AddCommand(fileMkdirCmd)
+}
+
+func isBlockConn(connName string) bool {
+	return connName == "" || connName == RpcContext.Conn || (RpcContext.Conn == "" && connName == wshrpc.LocalConnName)
+}
+
+// the root is the block's current directory (or ~ for another connection), relative paths are relative to it
+func makeFileOpData(fileArg string) wshrpc.CommandFileOpData {
+	data := wshrpc.CommandFileOpData{Connection: fileOpConn, NoRootCheck: fileOpAnyPath}
+	if data.Connection == "" {
+		data.Connection = RpcContext.Conn
+	}
+	if data.Connection == "" {
+		data.Connection = wshrpc.LocalConnName
+	}
+	if isBlockConn(fileOpConn) {
+		data.Root = getRemoteViewCwd()
+		data.Path = makeRemoteAbsPath(fileArg)
+		return data
+	}
+	data.Root = "~"
+	data.Path = path.Clean(fileArg)
+	if !path.IsAbs(data.Path) && data.Path != "~" && !strings.HasPrefix(data.Path, "~/") {
+		data.Path = "~/" + data.Path
+	}
+	return data
+}
+
+func fileOpRpcOpts() *wshrpc.RpcOpts {
+	return &wshrpc.RpcOpts{Timeout: FileOpTimeout}
+}
+
+func fileRmPathRun(fileArg string) error {
+	data := makeFileOpData(fileArg)
+	data.Recursive = fileRmRecursive
+	data.Trash = fileRmTrash
+	err := wshclient.FileRemoveCommand(RpcClient, data, fileOpRpcOpts())
+	if err != nil {
+		return fmt.Errorf("removing %s: %w", fileArg, err)
+	}
+	return nil
+}
+
+func fileMvRun(cmd *cobra.Command, args []string) error {
+	data := makeFileOpData(args[0])
+	data.NewPath = makeFileOpData(args[1]).Path
+	// moving into a directory
+	route := wshutil.MakeConnectionRouteId(data.Connection)
+	destInfo, err := wshclient.RemoteFileInfoCommand(RpcClient, data.NewPath, &wshrpc.RpcOpts{Route: route, Timeout: DefaultFileTimeout})
+	if err != nil {
+		return fmt.Errorf("getting file info for %s: %w", args[1], err)
+	}
+	if !destInfo.NotFound && destInfo.IsDir {
+		data.NewPath = path.Join(destInfo.Dir, path.Base(data.Path))
+	}
+	err = wshclient.FileRenameCommand(RpcClient, data, fileOpRpcOpts())
+	if err != nil {
+		return fmt.Errorf("moving %s: %w", args[0], err)
+	}
+	return nil
+}
+
+func fileMkdirRun(cmd *cobra.Command, args []string) error {
+	err := wshclient.FileMkdirCommand(RpcClient, makeFileOpData(args[0]), fileOpRpcOpts())
+	if err != nil {
+		return fmt.Errorf("creating directory %s: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"golang.org/x/term"
+)
+
+const DefaultTransferMaxSize = 1024 * 1024 * 1024 // 1GB
+
+var transferResume bool
+var transferRecursive bool
+var transferMaxSize int64
+
+var fileGetCmd = &cobra.Command{
+	Use:   "get remote-path [local-path]",
+	Short: "copy a file from the block's connection to the local machine",
+	Long: `copy a file from the block's remote connection to the machine that is running Wave (over the existing connection).
+relative remote paths are relative to the block's current directory, relative local paths are relative to your
+local home directory.  the local path defaults to ~/Downloads (or ~ if it doesn't exist).`,
+	Example: "  wsh file get ./build/app.log\n  wsh file get -r ~/project/dist ~/Desktop/dist",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    activityWrap("file", fileGetRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+var filePutCmd = &cobra.Command{
+	Use:   "put local-path [remote-path]",
+	Short: "copy a file from the local machine to the block's connection",
+	Long: `copy a file from the machine that is running Wave to the block's remote connection (over the existing connection).
+relative local paths are relative to your local home directory, relative remote paths are relative to the block's
+current directory (which is also the default remote path).`,
+	Example: "  wsh file put ~/Downloads/data.csv\n  wsh file put -r ~/project/config /etc/myapp",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    activityWrap("file", filePutRun),
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{fileGetCmd, filePutCmd} {
+		cmd.Flags().BoolVar(&transferResume, "resume", false, "continue a partial copy (the checksum is still verified for the whole file)")
+		cmd.Flags().BoolVarP(&transferRecursive, "recursive", "r", false, "copy a directory (sent as a tar stream)")
+		cmd.Flags().Int64Var(&transferMaxSize, "max-size", DefaultTransferMaxSize, "refuse to copy more than this many bytes (0 for no limit)")
+		fileCmd.AddCommand(cmd)
+	}
+}
+
+var windowsAbsPathRe = regexp.MustCompile(`^[a-zA-Z]:[\\/]`)
+
+// local paths are resolved against the local home directory (the cwd of wsh is on the remote)
+func makeLocalTransferPath(fileArg string) string {
+	if path.IsAbs(fileArg) || windowsAbsPathRe.MatchString(fileArg) || fileArg == "~" || strings.HasPrefix(fileArg, "~/") {
+		return fileArg
+	}
+	return "~/" + fileArg
+}
+
+func getDefaultLocalTransferDir() string {
+	localRoute := wshutil.MakeConnectionRouteId(wshrpc.LocalConnName)
+	finfo, err := wshclient.RemoteFileInfoCommand(RpcClient, "~/Downloads", &wshrpc.RpcOpts{Route: localRoute, Timeout: 2000})
+	if err == nil && !finfo.NotFound && finfo.IsDir {
+		return "~/Downloads"
+	}
+	return "~"
+}
+
+func checkTransferArgs(cmd *cobra.Command) error {
+	if RpcContext.Conn == "" || RpcContext.Conn == wshrpc.LocalConnName {
+		return fmt.Errorf("wsh file %s must be run in a block on a remote connection", cmd.Name())
+	}
+	if transferMaxSize < 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid --max-size %d", transferMaxSize)
+	}
+	if transferResume && transferRecursive {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--resume can't be used with --recursive")
+	}
+	return nil
+}
+
+func fileGetRun(cmd *cobra.Command, args []string) error {
+	err := checkTransferArgs(cmd)
+	if err != nil {
+		return err
+	}
+	localPath := getDefaultLocalTransferDir()
+	if len(args) > 1 {
+		localPath = makeLocalTransferPath(args[1])
+	}
+	data := wshrpc.CommandFileTransferData{
+		SrcConn:   RpcContext.Conn,
+		SrcPath:   makeRemoteAbsPath(args[0]),
+		DestConn:  wshrpc.LocalConnName,
+		DestPath:  localPath,
+		Resume:    transferResume,
+		Recursive: transferRecursive,
+		MaxSize:   transferMaxSize,
+	}
+	return runFileTransfer(data)
+}
+
+func filePutRun(cmd *cobra.Command, args []string) error {
+	err := checkTransferArgs(cmd)
+	if err != nil {
+		return err
+	}
+	remotePath := getRemoteViewCwd()
+	if len(args) > 1 {
+		remotePath = makeRemoteAbsPath(args[1])
+	}
+	data := wshrpc.CommandFileTransferData{
+		SrcConn:   wshrpc.LocalConnName,
+		SrcPath:   makeLocalTransferPath(args[0]),
+		DestConn:  RpcContext.Conn,
+		DestPath:  remotePath,
+		Resume:    transferResume,
+		Recursive: transferRecursive,
+		MaxSize:   transferMaxSize,
+	}
+	return runFileTransfer(data)
+}
+
+// runs the transfer (in wavesrv), with progress on stderr (when it is a terminal)
+func runFileTransfer(data wshrpc.CommandFileTransferData) error {
+	showProgress := term.IsTerminal(int(os.Stderr.Fd()))
+	var lastProgress wshrpc.FileTransferProgress
+	respCh := wshclient.FileTransferCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: math.MaxInt32})
+	for resp := range respCh {
+		if resp.Error != nil {
+			if showProgress && lastProgress.DestPath != "" {
+				WriteStderr("\n")
+			}
+			return fmt.Errorf("copying %s: %w", data.SrcPath, resp.Error)
+		}
+		lastProgress = resp.Response
+		if showProgress {
+			WriteStderr("\r%s", formatTransferProgress(lastProgress))
+		}
+	}
+	if !lastProgress.Done {
+		return fmt.Errorf("copying %s: transfer did not finish", data.SrcPath)
+	}
+	if showProgress {
+		WriteStderr("\n")
+	}
+	resumedStr := ""
+	if lastProgress.ResumedAt > 0 {
+		resumedStr = fmt.Sprintf(", resumed at %s", formatTransferSize(lastProgress.ResumedAt))
+	}
+	WriteStdout("copied %s to %s (%s%s, sha256 %s)\n", data.SrcPath, lastProgress.DestPath, formatTransferSize(lastProgress.Size), resumedStr, lastProgress.Sha256)
+	return nil
+}
+
+func formatTransferProgress(progress wshrpc.FileTransferProgress) string {
+	percent := 100
+	if progress.Size > 0 {
+		percent = int(progress.Transferred * 100 / progress.Size)
+	}
+	status := fmt.Sprintf("%s / %s (%d%%)", formatTransferSize(progress.Transferred), formatTransferSize(progress.Size), percent)
+	if progress.Done {
+		status += " verified"
+	}
+	return fmt.Sprintf("%s  %-32s", path.Base(progress.DestPath), status)
+}
+
+func formatTransferSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
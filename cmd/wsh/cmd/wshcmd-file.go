@@ -127,9 +127,12 @@ var fileInfoCmd = &cobra.Command{
 }
 
 var fileRmCmd = &cobra.Command{
-	Use:     "rm wavefile://zone/file",
-	Short:   "remove a wave file",
-	Example: "  wsh file rm wavefile://block/config.txt",
+	Use:   "rm {wavefile://zone/file|path}",
+	Short: "remove a wave file, or a file or directory on a connection",
+	Long: `remove a wave file (a wavefile:// url), or a file or directory on the block's connection (or the --conn
+connection).  relative paths are relative to the block's current directory, the paths must be under it unless
+--any-path is given (for another connection the paths are relative to, and must be under, ~).`,
+	Example: "  wsh file rm wavefile://block/config.txt\n  wsh file rm --trash old-build\n  wsh file rm -r --conn myserver ~/tmp/cache",
 	Args:    cobra.ExactArgs(1),
 	RunE:    activityWrap("file", fileRmRun),
 	PreRunE: preRunSetupRpcClient,
@@ -238,6 +241,9 @@ func fileInfoRun(cmd *cobra.Command, args []string) error {
 }
 
 func fileRmRun(cmd *cobra.Command, args []string) error {
+	if !strings.HasPrefix(args[0], WaveFilePrefix) {
+		return fileRmPathRun(args[0])
+	}
 	ref, err := parseWaveFileURL(args[0])
 	if err != nil {
 		return err
@@ -0,0 +1,51 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+var focusCmd = &cobra.Command{
+	Use:     "focus {blockid|tabid|windowid}",
+	Short:   "focus a block, tab, or window",
+	Long:    "focus a block, tab, or window. the type of the id is detected automatically (also brings the window to the front).",
+	Args:    cobra.ExactArgs(1),
+	RunE:    focusRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	rootCmd.AddCommand(focusCmd)
+}
+
+func focusRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("focus", rtnErr == nil)
+	}()
+	oref, err := resolveSimpleId(args[0])
+	if err != nil {
+		return fmt.Errorf("resolving id: %w", err)
+	}
+	switch oref.OType {
+	case waveobj.OType_Block:
+		err = wshclient.FocusBlockCommand(RpcClient, oref.OID, &wshrpc.RpcOpts{Timeout: 2000})
+	case waveobj.OType_Tab:
+		err = wshclient.FocusTabCommand(RpcClient, oref.OID, &wshrpc.RpcOpts{Timeout: 2000})
+	case waveobj.OType_Window:
+		err = wshclient.FocusWindowCommand(RpcClient, oref.OID, &wshrpc.RpcOpts{Timeout: 2000, Route: wshutil.ElectronRoute})
+	default:
+		return fmt.Errorf("cannot focus object of type %q", oref.OType)
+	}
+	if err != nil {
+		return fmt.Errorf("focusing %s: %w", oref.OType, err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+const historyImportBatchSize = 1000
+
+var historySearchPrefix bool
+var historySearchCwd string
+var historySearchConn string
+var historySearchFailed bool
+var historySearchLimit int
+var historySearchOffset int
+var historySearchJson bool
+var historyImportFile string
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "search the command history of all of your terminals",
+	Long: `search the command history of all of your terminals.  the commands are recorded by the shell integration
+(see "wsh shell-init"), and shell history files can be imported with "wsh history import".`,
+}
+
+var historySearchCmd = &cobra.Command{
+	Use:   "search [query]",
+	Short: "search the command history (newest first)",
+	Long: `search the command history (newest first) for the commands that contain the query (case sensitive).
+use --prefix for the commands that start with it.  --cwd matches the commands run in the directory or one under it.`,
+	Example: "  wsh history search kubectl --cwd ~/proj --failed\n  wsh history search --prefix 'git commit' -n 10",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    historySearchRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var historyImportCmd = &cobra.Command{
+	Use:   "import [bash|zsh]",
+	Short: "import a shell history file",
+	Long: `import a shell history file (~/.bash_history or ~/.zsh_history, use --file for another file) into the command
+history.  without a shell both files are imported (the ones that exist).  the commands are tagged with the shell
+they came from, and importing a shell's history again replaces the commands that were imported from it before.`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    historyImportRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	historySearchCmd.Flags().BoolVar(&historySearchPrefix, "prefix", false, "only the commands that start with the query")
+	historySearchCmd.Flags().StringVar(&historySearchCwd, "cwd", "", "only the commands run in this directory (or under it)")
+	historySearchCmd.Flags().StringVarP(&historySearchConn, "conn", "c", "", "only the commands run on this connection (local for local terminals)")
+	historySearchCmd.Flags().BoolVar(&historySearchFailed, "failed", false, "only the commands that exited with an error")
+	historySearchCmd.Flags().IntVarP(&historySearchLimit, "limit", "n", 50, "the number of commands to show (max 1000)")
+	historySearchCmd.Flags().IntVar(&historySearchOffset, "offset", 0, "skip this many (newer) commands, for the next page")
+	historySearchCmd.Flags().BoolVar(&historySearchJson, "json", false, "output as json")
+	historyImportCmd.Flags().StringVar(&historyImportFile, "file", "", "the history file to import (needs a shell)")
+	historyCmd.AddCommand(historySearchCmd)
+	historyCmd.AddCommand(historyImportCmd)
+	rootCmd.AddCommand(historyCmd)
+}
+
+func historySearchRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("history:search", rtnErr == nil)
+	}()
+	if historySearchLimit <= 0 || historySearchOffset < 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--limit must be positive and --offset can't be negative")
+	}
+	data := wshrpc.CommandSearchHistoryData{
+		Prefix:     historySearchPrefix,
+		Connection: historySearchConn,
+		Failed:     historySearchFailed,
+		Offset:     historySearchOffset,
+		Limit:      historySearchLimit,
+	}
+	if len(args) > 0 {
+		data.Query = args[0]
+	}
+	if historySearchCwd != "" {
+		cwd, err := filepath.Abs(wavebase.ExpandHomeDirSafe(historySearchCwd))
+		if err != nil {
+			return fmt.Errorf("resolving --cwd: %w", err)
+		}
+		data.Cwd = cwd
+	}
+	rtn, err := wshclient.SearchHistoryCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("searching history: %w", err)
+	}
+	if historySearchJson {
+		barr, err := json.MarshalIndent(rtn, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting output: %w", err)
+		}
+		WriteStdout("%s\n", string(barr))
+		return nil
+	}
+	if len(rtn.Records) == 0 {
+		WriteStdout("no commands found\n")
+		return nil
+	}
+	w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME\tEXIT\tCONN\tCWD\tCOMMAND\n")
+	for _, record := range rtn.Records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", formatHistoryTime(record.Ts), formatHistoryExitCode(record), formatHistoryConn(record), record.Cwd, formatHistoryCmd(record))
+	}
+	w.Flush()
+	if rtn.HasMore {
+		WriteStderr("(more commands, use --offset %d)\n", historySearchOffset+len(rtn.Records))
+	}
+	return nil
+}
+
+func formatHistoryTime(ts int64) string {
+	if ts == 0 {
+		return "-"
+	}
+	return time.UnixMilli(ts).Format("2006-01-02 15:04:05")
+}
+
+func formatHistoryExitCode(record wshrpc.HistoryRecord) string {
+	if record.ExitCode == nil {
+		return "-"
+	}
+	return fmt.Sprint(*record.ExitCode)
+}
+
+func formatHistoryConn(record wshrpc.HistoryRecord) string {
+	if record.Connection == "" {
+		return wshrpc.LocalConnName
+	}
+	return record.Connection
+}
+
+// one line per command
+func formatHistoryCmd(record wshrpc.HistoryRecord) string {
+	cmdStr := strings.ReplaceAll(record.Cmd, "\n", `\n`)
+	if record.Count > 1 {
+		cmdStr += fmt.Sprintf("  (x%d)", record.Count)
+	}
+	return cmdStr
+}
+
+func historyImportRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("history:import", rtnErr == nil)
+	}()
+	shellTypes := shellutil.HistFileShells
+	if len(args) > 0 {
+		if !slices.Contains(shellutil.HistFileShells, args[0]) {
+			OutputHelpMessage(cmd)
+			return fmt.Errorf("unsupported shell %q (supported shells: %v)", args[0], shellutil.HistFileShells)
+		}
+		shellTypes = args[:1]
+	} else if historyImportFile != "" {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--file needs a shell (e.g. wsh history import bash --file ~/old_history)")
+	}
+	var numFiles int
+	for _, shellType := range shellTypes {
+		fileName := historyImportFile
+		if fileName == "" {
+			fileName = shellutil.GetDefaultHistFile(shellType)
+		}
+		fileData, err := os.ReadFile(wavebase.ExpandHomeDirSafe(fileName))
+		if os.IsNotExist(err) && len(args) == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s history: %w", shellType, err)
+		}
+		num, err := importHistFile(shellType, shellutil.ParseHistFile(shellType, fileData))
+		if err != nil {
+			return fmt.Errorf("importing %s: %w", fileName, err)
+		}
+		numFiles++
+		WriteStdout("imported %d commands from %s (%s)\n", num, fileName, shellType)
+	}
+	if numFiles == 0 {
+		return fmt.Errorf("no history files found (%s or %s)", shellutil.GetDefaultHistFile(shellutil.ShellType_bash), shellutil.GetDefaultHistFile(shellutil.ShellType_zsh))
+	}
+	return nil
+}
+
+// sent in batches, the first one replaces the commands imported from the shell before
+func importHistFile(shellType string, entries []shellutil.HistFileEntry) (int, error) {
+	var numAdded int
+	for start := 0; start == 0 || start < len(entries); start += historyImportBatchSize {
+		data := wshrpc.CommandImportHistoryData{
+			Shell:      shellType,
+			Connection: RpcContext.Conn,
+			Replace:    start == 0,
+		}
+		for _, entry := range entries[start:min(start+historyImportBatchSize, len(entries))] {
+			data.Records = append(data.Records, wshrpc.HistoryRecord{Cmd: entry.Cmd, Ts: entry.Ts})
+		}
+		num, err := wshclient.ImportHistoryCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 10000})
+		if err != nil {
+			return numAdded, err
+		}
+		numAdded += num
+	}
+	return numAdded, nil
+}
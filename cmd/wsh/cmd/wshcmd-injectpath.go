@@ -0,0 +1,55 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var injectPathCmd = &cobra.Command{
+	Use:   "inject-path path [--block blockid]",
+	Short: "type a path into a terminal block (quoted for its shell)",
+	Long: `type a path into a terminal block, quoted for the block's shell (posix shells, fish, powershell, and cmd), without pressing enter.
+the path goes to the focused block of the current tab, use --block to send it to another block.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    injectPathRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	rootCmd.AddCommand(injectPathCmd)
+}
+
+func injectPathRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("inject-path", rtnErr == nil)
+	}()
+	var data wshrpc.CommandInjectPathData
+	data.Path = args[0]
+	if blockArg != "" {
+		blockId, err := resolveTermBlockArg(blockArg)
+		if err != nil {
+			return err
+		}
+		data.BlockId = blockId
+	} else {
+		tabORef, err := resolveSimpleId("tab")
+		if err != nil {
+			return fmt.Errorf("resolving tab id: %w", err)
+		}
+		data.TabId = tabORef.OID
+	}
+	_, err := wshclient.InjectPathCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil && blockArg == "" && wshrpc.GetErrorCode(err) == wshrpc.ErrorCode_InvalidArg {
+		return fmt.Errorf("injecting path: %w (use --block to send it to a terminal block)", err)
+	}
+	if err != nil {
+		return fmt.Errorf("injecting path: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const launchConnectTimeout = 500 * time.Millisecond
+const launchArgPrefix = "--wave-launch=" // the app opens the window when it starts (see emain)
+
+var launchWorkspace string
+var launchAppPath string
+var launchLayoutFile string
+var launchTabName string
+
+var launchCmd = &cobra.Command{
+	Use:   "launch [--workspace name] [--layout file] [--tab-name name] [file|directory|URL]",
+	Short: "open a Wave window (works from any terminal)",
+	Long: `open a new Wave window, optionally with a block for a file, directory, or URL (like wsh view).  with
+--workspace the window shows that workspace (if it is already open, its window is focused).  prints the new
+window's id.
+
+--layout creates the window's tab with the blocks from a layout file (a json array of
+{"blockdef": {...}, "indexarr": [...], "size": n, "focused": bool} entries, like starter-layout.json).  the
+window, its tab, and the blocks are created at once (if the workspace is already open, a new tab is added to it).
+
+wsh launch works outside of Wave too: if the Wave server isn't running, Wave is started and opens the window
+itself (no window id is printed then).  use --app if Wave isn't installed in the default location.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: launchRun,
+}
+
+func init() {
+	launchCmd.Flags().StringVar(&launchWorkspace, "workspace", "", "open the window on this workspace (name or id)")
+	launchCmd.Flags().StringVar(&launchLayoutFile, "layout", "", "a layout file with the blocks for the window's tab")
+	launchCmd.Flags().StringVar(&launchTabName, "tab-name", "", "the name of the window's tab")
+	launchCmd.Flags().StringVar(&launchAppPath, "app", "", "path to the Wave app (used when the server isn't running)")
+	rootCmd.AddCommand(launchCmd)
+}
+
+// the data directory the app uses (see getWaveDataDir in emain/platform.ts)
+func getLocalWaveDataDir() string {
+	if dataHome := os.Getenv(wavebase.WaveDataHomeEnvVar); dataHome != "" {
+		return dataHome
+	}
+	homeDir := wavebase.GetHomeDir()
+	oldHomeDir := filepath.Join(homeDir, wavebase.RemoteWaveHomeDirName)
+	if _, err := os.Stat(filepath.Join(oldHomeDir, wavebase.WaveLockFile)); err == nil {
+		return oldHomeDir
+	}
+	if xdgDataHome := os.Getenv("XDG_DATA_HOME"); xdgDataHome != "" {
+		return filepath.Join(xdgDataHome, "waveterm")
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "waveterm")
+	case "windows":
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), "waveterm", "Data")
+	default:
+		return filepath.Join(homeDir, ".local", "share", "waveterm")
+	}
+}
+
+// connects to the local wave server (returns an error quickly if it isn't running)
+func setupLaunchRpcClient() error {
+	if os.Getenv(wshutil.WaveJwtTokenVarName) != "" {
+		// running inside of wave
+		return setupRpcClient(nil)
+	}
+	sockName := filepath.Join(getLocalWaveDataDir(), wavebase.DomainSocketBaseName)
+	client, err := wshutil.SetupDomainSocketRpcClientWithTimeout(sockName, nil, launchConnectTimeout)
+	if err != nil {
+		return err
+	}
+	jwtToken, err := wshutil.MakeClientJWTToken(wshrpc.RpcContext{}, sockName)
+	if err != nil {
+		return err
+	}
+	RpcClient = client
+	wshclient.AuthenticateCommand(RpcClient, jwtToken, &wshrpc.RpcOpts{NoResponse: true})
+	applyRpcTimeoutFlag()
+	return nil
+}
+
+func makeLaunchBlockDef(args []string) (*wshrpc.CommandLaunchWindowData, error) {
+	launchData := &wshrpc.CommandLaunchWindowData{Workspace: launchWorkspace, TabName: launchTabName}
+	if launchLayoutFile != "" {
+		barr, err := os.ReadFile(launchLayoutFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading layout file: %w", err)
+		}
+		err = json.Unmarshal(barr, &launchData.Layout)
+		if err != nil {
+			return nil, fmt.Errorf("parsing layout file: %w", err)
+		}
+		if len(launchData.Layout) == 0 {
+			return nil, fmt.Errorf("layout file has no blocks")
+		}
+	}
+	if len(args) == 0 {
+		return launchData, nil
+	}
+	blockData, err := makeViewBlockData("view", args[0], "", "")
+	if err != nil {
+		return nil, err
+	}
+	launchData.BlockDef = blockData.BlockDef
+	return launchData, nil
+}
+
+func getWaveAppCommand(launchArg string) (*exec.Cmd, error) {
+	switch {
+	case launchAppPath != "" && runtime.GOOS == "darwin" && filepath.Ext(launchAppPath) == ".app":
+		return exec.Command("open", "-a", launchAppPath, "--args", launchArg), nil
+	case launchAppPath != "":
+		return exec.Command(launchAppPath, launchArg), nil
+	case runtime.GOOS == "darwin":
+		return exec.Command("open", "-a", "Wave", "--args", launchArg), nil
+	case runtime.GOOS == "windows":
+		return exec.Command(filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "waveterm", "Wave.exe"), launchArg), nil
+	}
+	appPath, err := exec.LookPath("waveterm")
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the Wave app (use --app): %w", err)
+	}
+	return exec.Command(appPath, launchArg), nil
+}
+
+// starts the app (not waiting for it), it makes the same launchwindow call once the server is up
+func startWaveApp(launchData *wshrpc.CommandLaunchWindowData) error {
+	launchJson, err := json.Marshal(launchData)
+	if err != nil {
+		return fmt.Errorf("formatting launch data: %w", err)
+	}
+	appCmd, err := getWaveAppCommand(launchArgPrefix + string(launchJson))
+	if err != nil {
+		return err
+	}
+	err = appCmd.Start()
+	if err != nil {
+		return fmt.Errorf("starting Wave: %w", err)
+	}
+	return appCmd.Process.Release()
+}
+
+func launchRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("launch", rtnErr == nil)
+	}()
+	serverErr := setupLaunchRpcClient()
+	if serverErr != nil {
+		RpcClient = nil
+		if len(args) > 0 && args[0] == "-" {
+			return fmt.Errorf("the Wave server isn't running, cannot open stdin (%v)", serverErr)
+		}
+		launchData, err := makeLaunchBlockDef(args)
+		if err != nil {
+			return err
+		}
+		err = startWaveApp(launchData)
+		if err != nil {
+			return err
+		}
+		WriteStderr("the Wave server isn't running, starting Wave\n")
+		return nil
+	}
+	launchData, err := makeLaunchBlockDef(args)
+	if err != nil {
+		return err
+	}
+	rtn, err := wshclient.LaunchWindowCommand(RpcClient, *launchData, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("launching window: %w", err)
+	}
+	WriteStdout("%s\n", rtn.WindowId)
+	return nil
+}
@@ -0,0 +1,297 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var layoutTabId string
+var layoutApplyClear bool
+var layoutApplyForce bool
+
+var layoutCmd = &cobra.Command{
+	Use:   "layout",
+	Short: "manage tab layouts and layout presets",
+}
+
+var layoutApplyCmd = &cobra.Command{
+	Use:   "apply {name|file|-}",
+	Short: "add the blocks from a layout preset or a layout file to a tab (use - for stdin)",
+	Long: `add the blocks from a layout preset (see "wsh layout save") or a layout file to a tab (defaults to the current tab).
+if the argument is an existing file (or - for stdin) it is read as a layout file, otherwise it is the name of a preset.
+the file is a json array of {"blockdef": {...}, "indexarr": [...], "size": n, "focused": bool} entries (the same format as starter-layout.json).
+the blocks are created all at once, if one of them fails none of them are created.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    layoutApplyRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var layoutSaveCmd = &cobra.Command{
+	Use:     "save {name}",
+	Short:   "save the layout of a tab as a preset (replaces an existing preset with the same name)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    layoutSaveRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var layoutListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "list the layout presets",
+	Args:    cobra.NoArgs,
+	RunE:    layoutListRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var layoutDeleteCmd = &cobra.Command{
+	Use:     "delete {name}",
+	Short:   "delete a layout preset",
+	Args:    cobra.ExactArgs(1),
+	RunE:    layoutDeleteRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var layoutResizeCmd = &cobra.Command{
+	Use:     "resize {blockid} {fraction}",
+	Short:   "set a block's share of its split (e.g. 0.6)",
+	Long:    `set a block's share of its split, a fraction between 0 and 1 (e.g. 0.6).  the other blocks in the split are scaled to fit.`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    layoutResizeRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var layoutSwapCmd = &cobra.Command{
+	Use:     "swap {blockid} {blockid}",
+	Short:   "swap the positions of two blocks in the same tab",
+	Args:    cobra.ExactArgs(2),
+	RunE:    layoutSwapRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var layoutMagnifyCmd = &cobra.Command{
+	Use:     "magnify {blockid}",
+	Short:   "magnify a block",
+	Args:    cobra.ExactArgs(1),
+	RunE:    layoutMagnifyRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var layoutDemagnifyCmd = &cobra.Command{
+	Use:     "demagnify {blockid}",
+	Short:   "un-magnify a block",
+	Args:    cobra.ExactArgs(1),
+	RunE:    layoutMagnifyRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{layoutApplyCmd, layoutSaveCmd} {
+		cmd.Flags().StringVar(&layoutTabId, "tab", "", "the tab to use (defaults to the current tab)")
+	}
+	layoutApplyCmd.Flags().BoolVar(&layoutApplyClear, "clear", false, "for presets, close the tab's current blocks first (they can be restored from the block trash)")
+	layoutApplyCmd.Flags().BoolVarP(&layoutApplyForce, "force", "f", false, "with --clear, also close pinned blocks")
+	layoutCmd.AddCommand(layoutApplyCmd)
+	layoutCmd.AddCommand(layoutSaveCmd)
+	layoutCmd.AddCommand(layoutListCmd)
+	layoutCmd.AddCommand(layoutDeleteCmd)
+	layoutCmd.AddCommand(layoutResizeCmd)
+	layoutCmd.AddCommand(layoutSwapCmd)
+	layoutCmd.AddCommand(layoutMagnifyCmd)
+	layoutCmd.AddCommand(layoutDemagnifyCmd)
+	rootCmd.AddCommand(layoutCmd)
+}
+
+func resolveLayoutTabArg() (string, error) {
+	tabArg := layoutTabId
+	if tabArg == "" {
+		tabArg = "tab"
+	}
+	tabORef, err := resolveSimpleId(tabArg)
+	if err != nil {
+		return "", fmt.Errorf("resolving tab id: %w", err)
+	}
+	if tabORef.OType != waveobj.OType_Tab {
+		return "", fmt.Errorf("%q is not a tab", tabArg)
+	}
+	return tabORef.OID, nil
+}
+
+func layoutApplyRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("layout", rtnErr == nil)
+	}()
+	tabId, err := resolveLayoutTabArg()
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(args[0]); args[0] != "-" && statErr != nil {
+		presetData := wshrpc.CommandLayoutPresetData{
+			TabId:         tabId,
+			Name:          args[0],
+			ClearExisting: layoutApplyClear,
+			Force:         layoutApplyForce,
+		}
+		blockIds, err := wshclient.ApplyLayoutPresetCommand(RpcClient, presetData, &wshrpc.RpcOpts{Timeout: 5000})
+		if err != nil {
+			return fmt.Errorf("applying layout preset: %w", err)
+		}
+		WriteStdout("created %d block(s)\n", len(blockIds))
+		return nil
+	}
+	if layoutApplyClear {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--clear can only be used with layout presets")
+	}
+	var barr []byte
+	if args[0] == "-" {
+		barr, err = io.ReadAll(WrappedStdin)
+	} else {
+		barr, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("reading layout file: %w", err)
+	}
+	var blocks []wshrpc.BlockDefWithLayout
+	err = json.Unmarshal(barr, &blocks)
+	if err != nil {
+		return fmt.Errorf("parsing layout file: %w", err)
+	}
+	if len(blocks) == 0 {
+		return fmt.Errorf("layout file has no blocks")
+	}
+	createData := wshrpc.CommandCreateBlocksData{
+		TabId:  tabId,
+		Blocks: blocks,
+	}
+	blockIds, err := wshclient.CreateBlocksCommand(RpcClient, createData, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("applying layout: %w", err)
+	}
+	WriteStdout("created %d block(s)\n", len(blockIds))
+	return nil
+}
+
+func layoutSaveRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("layout", rtnErr == nil)
+	}()
+	tabId, err := resolveLayoutTabArg()
+	if err != nil {
+		return err
+	}
+	presetData := wshrpc.CommandLayoutPresetData{TabId: tabId, Name: args[0]}
+	err = wshclient.SaveLayoutPresetCommand(RpcClient, presetData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("saving layout preset: %w", err)
+	}
+	WriteStdout("saved layout preset %q\n", args[0])
+	return nil
+}
+
+func layoutListRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("layout", rtnErr == nil)
+	}()
+	presets, err := wshclient.ListLayoutPresetsCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing layout presets: %w", err)
+	}
+	if len(presets) == 0 {
+		WriteStdout("no layout presets\n")
+		return nil
+	}
+	w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tBLOCKS\tSAVED\n")
+	for _, preset := range presets {
+		savedTime := time.UnixMilli(preset.CreatedTs).Format("2006-01-02 15:04")
+		fmt.Fprintf(w, "%s\t%d\t%s\n", preset.Name, len(preset.Layout), savedTime)
+	}
+	w.Flush()
+	return nil
+}
+
+func layoutDeleteRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("layout", rtnErr == nil)
+	}()
+	err := wshclient.DeleteLayoutPresetCommand(RpcClient, args[0], &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("deleting layout preset: %w", err)
+	}
+	return nil
+}
+
+func runLayoutAction(data wshrpc.CommandLayoutActionData) error {
+	_, err := wshclient.LayoutActionCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("%s: %w", data.ActionType, err)
+	}
+	return nil
+}
+
+func layoutResizeRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("layout", rtnErr == nil)
+	}()
+	size, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || size <= 0 || size >= 1 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid size %q (must be a fraction between 0 and 1)", args[1])
+	}
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	return runLayoutAction(wshrpc.CommandLayoutActionData{
+		ActionType: wshrpc.LayoutAction_Resize,
+		BlockId:    blockId,
+		Size:       size,
+	})
+}
+
+func layoutSwapRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("layout", rtnErr == nil)
+	}()
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	otherBlockId, err := resolveTermBlockArg(args[1])
+	if err != nil {
+		return err
+	}
+	return runLayoutAction(wshrpc.CommandLayoutActionData{
+		ActionType:   wshrpc.LayoutAction_Swap,
+		BlockId:      blockId,
+		OtherBlockId: otherBlockId,
+	})
+}
+
+// for both magnify and demagnify
+func layoutMagnifyRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("layout", rtnErr == nil)
+	}()
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	actionType := wshrpc.LayoutAction_Magnify
+	if cmd.Name() == "demagnify" {
+		actionType = wshrpc.LayoutAction_Demagnify
+	}
+	return runLayoutAction(wshrpc.CommandLayoutActionData{ActionType: actionType, BlockId: blockId})
+}
@@ -0,0 +1,140 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var linkRegex string
+var linkList bool
+var linkRemove bool
+var linkJson bool
+
+var linkCmd = &cobra.Command{
+	Use:   "link srcblockid dstblockid --regex regex",
+	Short: "link a terminal's output to a preview or web block",
+	Long: `link a terminal's output to a preview or web block.  when a line of the terminal's output matches the
+regex, the target block's file (preview) or url (web) is set to the regex's first capture group (or the whole
+match), e.g. wsh link this <previewblockid> --regex 'wrote (\S+\.png)'.  if the target already shows that file,
+it is reloaded.  links are removed when either block is closed.
+
+  wsh link --list [blockid]   lists the links (from or to the block)
+  wsh link --remove linkid    removes a link`,
+	Args:    cobra.MaximumNArgs(2),
+	RunE:    linkRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	linkCmd.Flags().StringVar(&linkRegex, "regex", "", "the regex the output lines are matched against")
+	linkCmd.Flags().BoolVar(&linkList, "list", false, "list the links")
+	linkCmd.Flags().BoolVar(&linkRemove, "remove", false, "remove a link (by link id)")
+	linkCmd.Flags().BoolVar(&linkJson, "json", false, "with --list, output as json")
+	rootCmd.AddCommand(linkCmd)
+}
+
+func resolveLinkBlockArg(blockArg string) (string, error) {
+	oref, err := resolveSimpleId(blockArg)
+	if err != nil {
+		return "", fmt.Errorf("resolving block id: %w", err)
+	}
+	if oref.OType != waveobj.OType_Block {
+		return "", fmt.Errorf("%q is not a block", blockArg)
+	}
+	return oref.OID, nil
+}
+
+func linkListRun(args []string) error {
+	var blockId string
+	if len(args) > 0 {
+		var err error
+		blockId, err = resolveLinkBlockArg(args[0])
+		if err != nil {
+			return err
+		}
+	}
+	links, err := wshclient.ListBlockLinksCommand(RpcClient, blockId, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing links: %w", err)
+	}
+	if linkJson {
+		outBArr, err := json.MarshalIndent(links, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting output: %w", err)
+		}
+		WriteStdout("%s\n", string(outBArr))
+		return nil
+	}
+	if len(links) == 0 {
+		WriteStdout("no links\n")
+		return nil
+	}
+	w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "LINKID\tSRCBLOCKID\tDSTBLOCKID\tRULE\n")
+	for _, link := range links {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s %q\n", link.OID, link.SrcBlockId, link.DstBlockId, link.RuleType, link.Rule)
+	}
+	return w.Flush()
+}
+
+func linkRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("link", rtnErr == nil)
+	}()
+	if linkList && linkRemove {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("cannot use --list and --remove together")
+	}
+	if linkList {
+		if len(args) > 1 {
+			OutputHelpMessage(cmd)
+			return fmt.Errorf("--list takes at most one block id")
+		}
+		return linkListRun(args)
+	}
+	if linkRemove {
+		if len(args) != 1 {
+			OutputHelpMessage(cmd)
+			return fmt.Errorf("--remove takes a link id")
+		}
+		err := wshclient.UnlinkBlocksCommand(RpcClient, args[0], &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("removing link: %w", err)
+		}
+		WriteStdout("link removed\n")
+		return nil
+	}
+	if len(args) != 2 || linkRegex == "" {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("a source block, a target block, and --regex are required")
+	}
+	srcBlockId, err := resolveLinkBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	dstBlockId, err := resolveLinkBlockArg(args[1])
+	if err != nil {
+		return err
+	}
+	linkData := wshrpc.CommandLinkBlocksData{
+		SrcBlockId: srcBlockId,
+		DstBlockId: dstBlockId,
+		RuleType:   waveobj.BlockLinkRule_Regex,
+		Rule:       linkRegex,
+	}
+	link, err := wshclient.LinkBlocksCommand(RpcClient, linkData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("linking blocks: %w", err)
+	}
+	WriteStdout("%s\n", link.OID)
+	return nil
+}
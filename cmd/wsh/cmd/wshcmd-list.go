@@ -0,0 +1,315 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var listJson bool
+var listWindowId string
+var listTabId string
+var listView string
+var listDeleted bool
+var listPinned bool
+var listSizes bool
+var listMatch []string
+
+var listCmd = &cobra.Command{
+	Use:   "list {windows|tabs|blocks}",
+	Short: "list windows, tabs, or blocks",
+	Long: `list windows, tabs, or blocks (in open windows).
+output is a table by default, use --json for machine readable output.
+
+--match filters blocks by their metadata (it can be repeated, all of the matches must match).  with --match the
+blocks in workspaces that aren't open are listed too.
+  view=term          the block's view
+  conn=user@host     the block's connection (conn=local for local blocks)
+  url^=prefix        the url starts with prefix (url*=text for urls that contain text)
+  file^=prefix       the file starts with prefix (file*=text for files that contain text)
+  created>2h         created in the last 2 hours (or after a date, e.g. created>2025-01-31)
+quote the created> matches, the shell treats > as a redirect.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"windows", "tabs", "blocks"},
+	RunE:      listRun,
+	PreRunE:   preRunSetupRpcClient,
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listJson, "json", false, "output as json")
+	listCmd.Flags().StringVar(&listWindowId, "window", "", "only list tabs/blocks in the given window")
+	listCmd.Flags().StringVar(&listTabId, "tab", "", "only list blocks in the given tab (e.g. a tab id, or 'tab' for the current tab)")
+	listCmd.Flags().StringVar(&listView, "view", "", "only list blocks with the given view type (e.g. term, preview, web)")
+	listCmd.Flags().BoolVar(&listDeleted, "deleted", false, "list the deleted blocks that can still be restored (blocks only)")
+	listCmd.Flags().BoolVar(&listPinned, "pinned", false, "only list pinned blocks (blocks only)")
+	listCmd.Flags().BoolVar(&listSizes, "sizes", false, "show the size of each block's scrollback (blocks only)")
+	listCmd.Flags().StringArrayVar(&listMatch, "match", nil, "only list the blocks that match (e.g. url^=https://github.com), can be repeated")
+	rootCmd.AddCommand(listCmd)
+}
+
+func listRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("list", rtnErr == nil)
+	}()
+	if listDeleted && args[0] != "blocks" {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--deleted can only be used with blocks")
+	}
+	if listPinned && args[0] != "blocks" {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--pinned can only be used with blocks")
+	}
+	if listSizes && (args[0] != "blocks" || listDeleted) {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--sizes can only be used with blocks (and not with --deleted)")
+	}
+	if len(listMatch) > 0 && (args[0] != "blocks" || listDeleted) {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--match can only be used with blocks (and not with --deleted)")
+	}
+	if listPinned && listDeleted {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--pinned and --deleted cannot be used together")
+	}
+	listData, err := makeListData()
+	if err != nil {
+		return err
+	}
+	var output any
+	switch args[0] {
+	case "windows":
+		output, err = wshclient.ListWindowsCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	case "tabs":
+		output, err = wshclient.ListTabsCommand(RpcClient, listData, &wshrpc.RpcOpts{Timeout: 2000})
+	case "blocks":
+		if len(listMatch) > 0 {
+			var queryData wshrpc.CommandQueryBlocksData
+			queryData, err = makeBlockQueryData(listData, listMatch, time.Now())
+			if err != nil {
+				OutputHelpMessage(cmd)
+				return err
+			}
+			output, err = wshclient.QueryBlocksCommand(RpcClient, queryData, &wshrpc.RpcOpts{Timeout: 2000})
+			break
+		}
+		output, err = wshclient.ListBlocksCommand(RpcClient, listData, &wshrpc.RpcOpts{Timeout: 2000})
+	default:
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid list type %q (must be windows, tabs, or blocks)", args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("listing %s: %w", args[0], err)
+	}
+	if listJson {
+		outBArr, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting output: %w", err)
+		}
+		WriteStdout("%s\n", string(outBArr))
+		return nil
+	}
+	w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+	switch rtn := output.(type) {
+	case []wshrpc.WindowListEntry:
+		fmt.Fprintf(w, "WINDOWID\tWORKSPACEID\tWORKSPACE\tTABS\tACTIVETABID\tCREATED\n")
+		for _, entry := range rtn {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", entry.WindowId, entry.WorkspaceId, entry.WorkspaceName, entry.NumTabs, entry.ActiveTabId, formatCreatedTs(entry.CreatedTs))
+		}
+	case []wshrpc.TabListEntry:
+		fmt.Fprintf(w, "TABID\tWINDOWID\tNAME\tGROUP\tBLOCKS\tCREATED\tFLAGS\n")
+		for _, entry := range rtn {
+			var flags []string
+			if entry.Active {
+				flags = append(flags, "active")
+			}
+			if entry.Pinned {
+				flags = append(flags, "pinned")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", entry.TabId, entry.WindowId, entry.Name, entry.GroupName, entry.NumBlocks, formatCreatedTs(entry.CreatedTs), strings.Join(flags, ","))
+		}
+	case []wshrpc.BlockListEntry:
+		if listDeleted {
+			fmt.Fprintf(w, "BLOCKID\tTABID\tVIEW\tDELETED\tDETAILS\n")
+			for _, entry := range rtn {
+				deletedTime := time.UnixMilli(entry.DeletedTs).Format("2006-01-02 15:04:05")
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.BlockId, entry.TabId, entry.View, deletedTime, getBlockListDetails(entry.Meta))
+			}
+			break
+		}
+		if listSizes {
+			fmt.Fprintf(w, "BLOCKID\tTABID\tVIEW\tSTATUS\tCREATED\tSCROLLBACK\tDETAILS\n")
+			for _, entry := range rtn {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.BlockId, entry.TabId, entry.View, formatControllerStatus(entry), formatCreatedTs(entry.CreatedTs), formatScrollbackSize(entry.ScrollbackSize), getBlockListDetails(entry.Meta))
+			}
+			break
+		}
+		fmt.Fprintf(w, "BLOCKID\tTABID\tVIEW\tSTATUS\tCREATED\tDETAILS\n")
+		for _, entry := range rtn {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", entry.BlockId, entry.TabId, entry.View, formatControllerStatus(entry), formatCreatedTs(entry.CreatedTs), getBlockListDetails(entry.Meta))
+		}
+	}
+	return w.Flush()
+}
+
+func makeListData() (wshrpc.CommandListData, error) {
+	listData := wshrpc.CommandListData{View: listView, Deleted: listDeleted, Pinned: listPinned, Sizes: listSizes}
+	if listWindowId != "" {
+		oref, err := resolveSimpleId(listWindowId)
+		if err != nil {
+			return listData, fmt.Errorf("resolving window id: %w", err)
+		}
+		if oref.OType != waveobj.OType_Window {
+			return listData, fmt.Errorf("%q is not a window", listWindowId)
+		}
+		listData.WindowId = oref.OID
+	}
+	if listTabId != "" {
+		oref, err := resolveSimpleId(listTabId)
+		if err != nil {
+			return listData, fmt.Errorf("resolving tab id: %w", err)
+		}
+		if oref.OType != waveobj.OType_Tab {
+			return listData, fmt.Errorf("%q is not a tab", listTabId)
+		}
+		listData.TabId = oref.OID
+	}
+	return listData, nil
+}
+
+// the --match expressions (plus the --view, --tab, --window, --pinned, and --sizes flags)
+func makeBlockQueryData(listData wshrpc.CommandListData, matches []string, now time.Time) (wshrpc.CommandQueryBlocksData, error) {
+	queryData := wshrpc.CommandQueryBlocksData{
+		View:     listData.View,
+		Pinned:   listData.Pinned,
+		TabId:    listData.TabId,
+		WindowId: listData.WindowId,
+		Sizes:    listData.Sizes,
+	}
+	for _, match := range matches {
+		key, op, value, ok := splitBlockMatch(match)
+		if !ok || value == "" {
+			return queryData, fmt.Errorf("invalid match %q (expected e.g. view=term or url^=https://)", match)
+		}
+		switch key + op {
+		case "view=":
+			queryData.View = value
+		case "conn=", "connection=":
+			queryData.Connection = value
+		case "url^=":
+			queryData.UrlPrefix = value
+		case "url*=":
+			queryData.UrlContains = value
+		case "file^=":
+			queryData.FilePrefix = value
+		case "file*=":
+			queryData.FileContains = value
+		case "created>":
+			createdAfter, err := parseCreatedAfter(value, now)
+			if err != nil {
+				return queryData, fmt.Errorf("invalid match %q: %w", match, err)
+			}
+			queryData.CreatedAfter = createdAfter
+		default:
+			return queryData, fmt.Errorf("invalid match %q (unknown key or operator %q)", match, key+op)
+		}
+	}
+	return queryData, nil
+}
+
+// splits key^=value, key*=value, key>value, and key=value
+func splitBlockMatch(match string) (string, string, string, bool) {
+	idx := strings.IndexAny(match, "^*=>")
+	if idx <= 0 {
+		return "", "", "", false
+	}
+	key, rest := match[:idx], match[idx:]
+	for _, op := range []string{"^=", "*=", "=", ">"} {
+		if strings.HasPrefix(rest, op) {
+			return key, op, rest[len(op):], true
+		}
+	}
+	return "", "", "", false
+}
+
+// a duration (created in the last 2h) or a date/time
+func parseCreatedAfter(value string, now time.Time) (int64, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration).UnixMilli(), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if ts, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return ts.UnixMilli(), nil
+		}
+	}
+	return 0, fmt.Errorf("expected a duration (e.g. 2h) or a date (e.g. 2025-01-31)")
+}
+
+func formatControllerStatus(entry wshrpc.BlockListEntry) string {
+	switch entry.ControllerStatus {
+	case "":
+		return "-"
+	case "done":
+		return fmt.Sprintf("exited(%d)", entry.ControllerExitCode)
+	default:
+		return entry.ControllerStatus
+	}
+}
+
+// objects created by older versions don't have a created time
+func formatCreatedTs(createdTs int64) string {
+	if createdTs == 0 {
+		return "-"
+	}
+	return time.UnixMilli(createdTs).Format("2006-01-02 15:04:05")
+}
+
+func formatScrollbackSize(size *int64) string {
+	if size == nil || *size == 0 {
+		return "-"
+	}
+	switch {
+	case *size >= 1024*1024:
+		return fmt.Sprintf("%.1fM", float64(*size)/(1024*1024))
+	case *size >= 1024:
+		return fmt.Sprintf("%.1fK", float64(*size)/1024)
+	default:
+		return fmt.Sprintf("%dB", *size)
+	}
+}
+
+// a short human readable summary of the important meta keys for the block
+func getBlockListDetails(meta waveobj.MetaMapType) string {
+	var details []string
+	if meta.GetBool(waveobj.MetaKey_Pinned, false) {
+		details = append(details, "pinned")
+	}
+	if title := meta.GetString(waveobj.MetaKey_FrameTitle, ""); title != "" {
+		details = append(details, fmt.Sprintf("title=%q", title))
+	}
+	if conn := meta.GetString(waveobj.MetaKey_Connection, ""); conn != "" {
+		details = append(details, "conn="+conn)
+	}
+	if cmdStr := meta.GetString(waveobj.MetaKey_Cmd, ""); cmdStr != "" {
+		details = append(details, fmt.Sprintf("cmd=%q", cmdStr))
+	}
+	if cwd := meta.GetString(waveobj.MetaKey_CmdCwd, ""); cwd != "" {
+		details = append(details, "cwd="+cwd)
+	}
+	if file := meta.GetString(waveobj.MetaKey_File, ""); file != "" {
+		details = append(details, "file="+file)
+	}
+	if url := meta.GetString(waveobj.MetaKey_Url, ""); url != "" {
+		details = append(details, "url="+url)
+	}
+	return strings.Join(details, " ")
+}
@@ -0,0 +1,41 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestMakeBlockQueryData(t *testing.T) {
+	now := time.UnixMilli(10_000_000)
+	tests := []struct {
+		name     string
+		matches  []string
+		expected wshrpc.CommandQueryBlocksData
+	}{
+		{name: "view", matches: []string{"view=term"}, expected: wshrpc.CommandQueryBlocksData{View: "term"}},
+		{name: "conn", matches: []string{"conn=local"}, expected: wshrpc.CommandQueryBlocksData{Connection: "local"}},
+		{name: "url prefix", matches: []string{"url^=https://a=b"}, expected: wshrpc.CommandQueryBlocksData{UrlPrefix: "https://a=b"}},
+		{name: "file contains", matches: []string{"file*=src/"}, expected: wshrpc.CommandQueryBlocksData{FileContains: "src/"}},
+		{name: "created duration", matches: []string{"created>1h"}, expected: wshrpc.CommandQueryBlocksData{CreatedAfter: 10_000_000 - 3_600_000}},
+		{name: "all", matches: []string{"view=web", "url*=github"}, expected: wshrpc.CommandQueryBlocksData{View: "web", UrlContains: "github"}},
+	}
+	for _, tc := range tests {
+		queryData, err := makeBlockQueryData(wshrpc.CommandListData{}, tc.matches, now)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if queryData != tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.expected, queryData)
+		}
+	}
+	for _, badMatch := range []string{"view", "=term", "view=", "url=x", "tab^=x", "created>soon"} {
+		if _, err := makeBlockQueryData(wshrpc.CommandListData{}, []string{badMatch}, now); err == nil {
+			t.Errorf("expected an error for %q", badMatch)
+		}
+	}
+}
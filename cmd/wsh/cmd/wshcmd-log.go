@@ -0,0 +1,120 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var logTailLevel string
+var logTailSubsystems []string
+var logTailReqId string
+var logTailJson bool
+var logLevelSubsystem string
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "show and configure the wave backend log",
+}
+
+var logTailCmd = &cobra.Command{
+	Use:   "tail [--level level] [--subsystem name] [--reqid id]",
+	Short: "print the backend's log records as they are logged, until interrupted",
+	Long: `print the backend's log records as they are logged (until interrupted).
+records at --level (default info) and above are printed, even if the level isn't written to the log file.
+--subsystem only prints the records of the given subsystems (e.g. wstore, eventbus, rpc, clientservice, objectservice, wcore).
+--reqid only prints the records logged for an rpc request (the rpc subsystem logs each request at debug level).`,
+	Args:    cobra.NoArgs,
+	RunE:    logTailRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var logLevelCmd = &cobra.Command{
+	Use:   "level [--subsystem name] {debug|info|warn|error|default}",
+	Short: "set the level of the records that are written to the backend log",
+	Long: `set the level of the records that are written to the backend log, for every subsystem or (with --subsystem) one.
+the levels are saved in the client metadata (client:loglevels).  "default" removes the subsystem's level (the default level is info).`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    logLevelRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	logTailCmd.Flags().StringVar(&logTailLevel, "level", "info", "the lowest level to print (debug, info, warn, or error)")
+	logTailCmd.Flags().StringSliceVar(&logTailSubsystems, "subsystem", nil, "only print records from these subsystems (can be repeated)")
+	logTailCmd.Flags().StringVar(&logTailReqId, "reqid", "", "only print records for this rpc request")
+	logTailCmd.Flags().BoolVar(&logTailJson, "json", false, "print the records as json lines")
+	logLevelCmd.Flags().StringVar(&logLevelSubsystem, "subsystem", "", "the subsystem to set the level of (defaults to all)")
+	logCmd.AddCommand(logTailCmd)
+	logCmd.AddCommand(logLevelCmd)
+	rootCmd.AddCommand(logCmd)
+}
+
+func formatLogRecord(rec wlog.LogRecord) string {
+	return time.UnixMilli(rec.Ts).Format("15:04:05.000") + " " + rec.String()
+}
+
+func logTailRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("log:tail", rtnErr == nil)
+	}()
+	if _, err := wlog.ParseLevel(logTailLevel); err != nil {
+		OutputHelpMessage(cmd)
+		return err
+	}
+	data := wshrpc.CommandLogTailData{Level: logTailLevel, Subsystems: logTailSubsystems, ReqId: logTailReqId}
+	// streams until wsh is killed (the server stops streaming when our route goes away)
+	respCh := wshclient.LogTailCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: math.MaxInt32})
+	for resp := range respCh {
+		if resp.Error != nil {
+			return fmt.Errorf("streaming log: %w", resp.Error)
+		}
+		if logTailJson {
+			barr, err := json.Marshal(resp.Response)
+			if err != nil {
+				return fmt.Errorf("formatting log record: %w", err)
+			}
+			WriteStdout("%s\n", string(barr))
+			continue
+		}
+		WriteStdout("%s\n", formatLogRecord(resp.Response))
+	}
+	return nil
+}
+
+func logLevelRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("log:level", rtnErr == nil)
+	}()
+	level := args[0]
+	if level == "default" {
+		level = ""
+	} else if _, err := wlog.ParseLevel(level); err != nil {
+		OutputHelpMessage(cmd)
+		return err
+	}
+	data := wshrpc.CommandSetLogLevelData{Subsystem: logLevelSubsystem, Level: level}
+	err := wshclient.SetLogLevelCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting log level: %w", err)
+	}
+	target := "the default log level"
+	if logLevelSubsystem != "" && logLevelSubsystem != wlog.DefaultSubsystem {
+		target = fmt.Sprintf("the %s log level", logLevelSubsystem)
+	}
+	if level == "" {
+		WriteStdout("%s was reset\n", target)
+	} else {
+		WriteStdout("%s is now %s\n", target, level)
+	}
+	return nil
+}
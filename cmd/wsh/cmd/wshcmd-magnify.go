@@ -0,0 +1,60 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var magnifyOff bool
+var magnifyToggle bool
+var magnifyNoFocus bool
+
+var magnifyCmd = &cobra.Command{
+	Use:   "magnify {blockid}",
+	Short: "magnify a block (or un-magnify it with --off)",
+	Long: `magnify a block (or un-magnify it with --off, or flip its state with --toggle).
+only one block in a tab can be magnified, so magnifying a block un-magnifies the one that was magnified before.
+magnifying a block in another tab switches to that tab (unless --no-focus is set).`,
+	Example: "  wsh magnify this\n  wsh magnify --toggle 8a3b2d1c",
+	Args:    cobra.ExactArgs(1),
+	RunE:    magnifyRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	magnifyCmd.Flags().BoolVar(&magnifyOff, "off", false, "un-magnify the block")
+	magnifyCmd.Flags().BoolVar(&magnifyToggle, "toggle", false, "magnify the block, or un-magnify it if it is magnified")
+	magnifyCmd.Flags().BoolVar(&magnifyNoFocus, "no-focus", false, "don't switch to the block's tab")
+	rootCmd.AddCommand(magnifyCmd)
+}
+
+func magnifyRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("magnify", rtnErr == nil)
+	}()
+	if magnifyOff && magnifyToggle {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--off and --toggle can't be used together")
+	}
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandSetBlockMagnifiedData{
+		BlockId:   blockId,
+		Magnified: !magnifyOff,
+		Toggle:    magnifyToggle,
+		NoFocus:   magnifyNoFocus,
+	}
+	_, err = wshclient.SetBlockMagnifiedCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("magnifying block: %w", err)
+	}
+	return nil
+}
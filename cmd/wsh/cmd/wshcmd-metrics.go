@@ -0,0 +1,98 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/sysmetrics"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var metricsConn string
+var metricsCount int
+var metricsInterval time.Duration
+var metricsJson bool
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "system metrics (the data shown by the sysinfo plots)",
+}
+
+var metricsGetCmd = &cobra.Command{
+	Use:   "get [metric...]",
+	Short: "print the latest (or recent) samples of the system metrics",
+	Long: `print the latest sample of the system metrics of a connection (defaults to the current connection).
+metrics are cpu, mem, load, disk, and net.  a metric also selects the metrics under it (e.g. cpu selects
+cpu:0, cpu:1, etc.), no metrics selects all of them.  use --json with --count for the recent samples (from the
+history the wave server keeps for the plots).`,
+	Example: "  wsh metrics get cpu mem\n  wsh metrics get --json --count 60 --interval 5s load:1",
+	RunE:    metricsGetRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	metricsGetCmd.Flags().StringVarP(&metricsConn, "conn", "c", "", "the connection (defaults to the current connection)")
+	metricsGetCmd.Flags().IntVar(&metricsCount, "count", 1, "number of samples (0 for the whole history, needs --json)")
+	metricsGetCmd.Flags().DurationVar(&metricsInterval, "interval", 0, "time between the samples (min 1s)")
+	metricsGetCmd.Flags().BoolVar(&metricsJson, "json", false, "output as json")
+	metricsCmd.AddCommand(metricsGetCmd)
+	rootCmd.AddCommand(metricsCmd)
+}
+
+func metricsGetRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("metrics:get", rtnErr == nil)
+	}()
+	if metricsCount < 0 || metricsInterval < 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--count and --interval can't be negative")
+	}
+	if metricsCount != 1 && !metricsJson {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--count needs --json")
+	}
+	connName := metricsConn
+	if connName == "" {
+		connName = RpcContext.Conn
+	}
+	data := wshrpc.CommandGetMetricsData{
+		Connection: connName,
+		Metrics:    args,
+		Count:      metricsCount,
+		IntervalMs: int(metricsInterval.Milliseconds()),
+	}
+	samples, err := wshclient.GetMetricsCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("getting metrics: %w", err)
+	}
+	if metricsJson {
+		barr, err := json.MarshalIndent(samples, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting output: %w", err)
+		}
+		WriteStdout("%s\n", string(barr))
+		return nil
+	}
+	if len(samples) == 0 || len(samples[0].Values) == 0 {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "no matching metrics")
+	}
+	values := samples[len(samples)-1].Values
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sysmetrics.SortMetricNames(names)
+	w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "METRIC\tVALUE\tUNIT\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%.2f\t%s\n", name, values[name], sysmetrics.GetMetricUnit(name))
+	}
+	return w.Flush()
+}
@@ -0,0 +1,59 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var moveTabId string
+
+var moveCmd = &cobra.Command{
+	Use:     "move {blockid} --tab {tabid}",
+	Short:   "move a block to another tab",
+	Long:    "move a block to another tab (the tab can be in another window). the block keeps running, including its terminal history.",
+	Args:    cobra.ExactArgs(1),
+	RunE:    moveRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	moveCmd.Flags().StringVar(&moveTabId, "tab", "", "the tab to move the block to (required)")
+	moveCmd.MarkFlagRequired("tab")
+	rootCmd.AddCommand(moveCmd)
+}
+
+func moveRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("move", rtnErr == nil)
+	}()
+	blockORef, err := resolveSimpleId(args[0])
+	if err != nil {
+		return fmt.Errorf("resolving block id: %w", err)
+	}
+	if blockORef.OType != waveobj.OType_Block {
+		return fmt.Errorf("%q is not a block", args[0])
+	}
+	tabORef, err := resolveSimpleId(moveTabId)
+	if err != nil {
+		return fmt.Errorf("resolving tab id: %w", err)
+	}
+	if tabORef.OType != waveobj.OType_Tab {
+		return fmt.Errorf("%q is not a tab", moveTabId)
+	}
+	moveData := wshrpc.CommandMoveBlockData{
+		BlockId:   blockORef.OID,
+		DestTabId: tabORef.OID,
+	}
+	err = wshclient.MoveBlockCommand(RpcClient, moveData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("moving block: %w", err)
+	}
+	return nil
+}
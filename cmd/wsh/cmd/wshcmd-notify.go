@@ -8,22 +8,26 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
-	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 )
 
 var notifyTitle string
 var notifySilent bool
 
 var setNotifyCmd = &cobra.Command{
-	Use:     "notify <message> [-t <title>] [-s]",
-	Short:   "create a notification",
-	Args:    cobra.ExactArgs(1),
+	Use:   "notify title [body] [-s]",
+	Short: "create a notification",
+	Long: `create a desktop notification, clicking on it focuses the block that sent it.
+notifications are limited to 10 per minute for each block.`,
+	Example: "  make build; wsh notify \"build finished\" \"exit code $?\"",
+	Args:    cobra.RangeArgs(1, 2),
 	RunE:    notifyRun,
 	PreRunE: preRunSetupRpcClient,
 }
 
 func init() {
-	setNotifyCmd.Flags().StringVarP(&notifyTitle, "title", "t", "Wsh Notify", "the notification title")
+	setNotifyCmd.Flags().StringVarP(&notifyTitle, "title", "t", "", "the notification title")
+	setNotifyCmd.Flags().MarkDeprecated("title", "pass the title as the first argument (wsh notify title [body])")
 	setNotifyCmd.Flags().BoolVarP(&notifySilent, "silent", "s", false, "whether or not the notification sound is silenced")
 	rootCmd.AddCommand(setNotifyCmd)
 }
@@ -32,13 +36,26 @@ func notifyRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	defer func() {
 		sendActivity("notify", rtnErr == nil)
 	}()
-	message := args[0]
-	notificationOptions := &wshrpc.WaveNotificationOptions{
-		Title:  notifyTitle,
-		Body:   message,
-		Silent: notifySilent,
+	title := args[0]
+	var body string
+	if len(args) > 1 {
+		body = args[1]
 	}
-	_, err := RpcClient.SendRpcRequest(wshrpc.Command_Notify, notificationOptions, &wshrpc.RpcOpts{Timeout: 2000, Route: wshutil.ElectronRoute})
+	if cmd.Flags().Changed("title") {
+		// old form: wsh notify -t title message
+		if len(args) > 1 {
+			OutputHelpMessage(cmd)
+			return fmt.Errorf("--title can't be used with a body argument")
+		}
+		title, body = notifyTitle, args[0]
+	}
+	notificationOptions := wshrpc.WaveNotificationOptions{
+		Title:   title,
+		Body:    body,
+		Silent:  notifySilent,
+		BlockId: RpcContext.BlockId,
+	}
+	err := wshclient.NotifyCommand(RpcClient, notificationOptions, &wshrpc.RpcOpts{Timeout: 2000})
 	if err != nil {
 		return fmt.Errorf("sending notification: %w", err)
 	}
@@ -0,0 +1,72 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [blockid]",
+	Short: "pin a block (defaults to the current block)",
+	Long: `pin a block (defaults to the current block).
+pinned blocks are kept when all the blocks in a tab are closed (wsh close --all-blocks) unless --force is used.
+use "wsh list blocks --pinned" to list them.`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    pinRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var unpinCmd = &cobra.Command{
+	Use:     "unpin [blockid]",
+	Short:   "unpin a block (defaults to the current block)",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    pinRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
+
+func pinRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	cmdName := cmd.Name()
+	defer func() {
+		sendActivity(cmdName, rtnErr == nil)
+	}()
+	var blockORef *waveobj.ORef
+	var err error
+	if len(args) > 0 {
+		blockORef, err = resolveSimpleId(args[0])
+	} else {
+		blockORef, err = resolveBlockArg()
+	}
+	if err != nil {
+		return fmt.Errorf("resolving block id: %w", err)
+	}
+	if blockORef.OType != waveobj.OType_Block {
+		return fmt.Errorf("%q is not a block", blockORef)
+	}
+	// nil removes the key (unpinned blocks don't have it)
+	var pinnedVal any
+	if cmdName == "pin" {
+		pinnedVal = true
+	}
+	setMetaData := wshrpc.CommandSetMetaData{
+		ORef: *blockORef,
+		Meta: waveobj.MetaMapType{waveobj.MetaKey_Pinned: pinnedVal},
+	}
+	err = wshclient.SetMetaCommand(RpcClient, setMetaData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting pinned: %w", err)
+	}
+	WriteStdout("block %sned\n", cmdName)
+	return nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+// html is passed to the web block as a data url (stored in the block's meta), so it gets a smaller limit
+const DefaultRenderMdMaxSize = 5 * 1024 * 1024
+const DefaultRenderHtmlMaxSize = 1024 * 1024
+
+var renderMd bool
+var renderHtml bool
+var renderBlockId string
+var renderTabId string
+var renderPosition string
+var renderMagnified bool
+var renderMaxSize int64
+
+var renderCmd = &cobra.Command{
+	Use:   "render {--md|--html} [file|-]",
+	Short: "render markdown or html in a block",
+	Long: `render markdown (--md, in a preview block) or html (--html, in a web block).  the content is read from
+stdin (or a file), e.g. "./report.sh | wsh render --md".  use --block to replace the content of a block
+opened by an earlier "wsh render" instead of opening a new block.`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    renderRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	renderCmd.Flags().BoolVar(&renderMd, "md", false, "render the content as markdown")
+	renderCmd.Flags().BoolVar(&renderHtml, "html", false, "render the content as html")
+	renderCmd.Flags().StringVarP(&renderBlockId, "block", "b", "", "update the content of an existing render block")
+	renderCmd.Flags().StringVar(&renderTabId, "tab", "", "open the block in the given tab (defaults to the current tab)")
+	renderCmd.Flags().StringVar(&renderPosition, "position", "", blockPositionFlagHelp)
+	renderCmd.Flags().BoolVarP(&renderMagnified, "magnified", "m", false, "open the block magnified")
+	renderCmd.Flags().Int64Var(&renderMaxSize, "max-size", 0, fmt.Sprintf("max number of bytes to read (defaults to %d for --md, %d for --html)", DefaultRenderMdMaxSize, DefaultRenderHtmlMaxSize))
+	rootCmd.AddCommand(renderCmd)
+}
+
+func readRenderContent(fileArg string, maxSize int64) ([]byte, error) {
+	input := WrappedStdin
+	if fileArg != "" && fileArg != "-" {
+		fd, err := os.Open(fileArg)
+		if err != nil {
+			return nil, fmt.Errorf("opening file: %w", err)
+		}
+		defer fd.Close()
+		input = fd
+	}
+	data, err := io.ReadAll(io.LimitReader(input, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading input: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no content to render")
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("content exceeds max size of %d bytes (use --max-size to increase)", maxSize)
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) != -1 {
+		return nil, fmt.Errorf("content looks like binary data (not markdown or html)")
+	}
+	return data, nil
+}
+
+// the markdown goes in a temp file (removed by the backend when the block is closed), like "wsh view -"
+func writeRenderTempFile(data []byte) (string, error) {
+	fd, err := os.CreateTemp("", wshrpc.WshTempFilePrefix+"render-*.md")
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	defer fd.Close()
+	_, err = fd.Write(data)
+	if err != nil {
+		os.Remove(fd.Name())
+		return "", fmt.Errorf("writing temp file: %w", err)
+	}
+	return fd.Name(), nil
+}
+
+func makeRenderMeta(data []byte) (waveobj.MetaMapType, error) {
+	tempFile, err := writeRenderTempFile(data)
+	if err != nil {
+		return nil, err
+	}
+	return waveobj.MetaMapType{
+		waveobj.MetaKey_View:     "preview",
+		waveobj.MetaKey_File:     tempFile,
+		waveobj.MetaKey_FileTemp: true,
+		// the temp file is on the filesystem wsh is running on
+		waveobj.MetaKey_Connection: RpcContext.Conn,
+	}, nil
+}
+
+func resolveRenderBlockArg(blockArg string) (*waveobj.ORef, error) {
+	blockORef, err := resolveSimpleId(blockArg)
+	if err != nil {
+		return nil, err
+	}
+	if blockORef.OType != waveobj.OType_Block {
+		return nil, fmt.Errorf("%s is not a block", blockArg)
+	}
+	return blockORef, nil
+}
+
+// replaces the content of a block opened by "wsh render --md"
+func updateRenderBlock(blockArg string, data []byte) (string, error) {
+	blockORef, err := resolveRenderBlockArg(blockArg)
+	if err != nil {
+		return "", err
+	}
+	oldMeta, err := wshclient.GetMetaCommand(RpcClient, wshrpc.CommandGetMetaData{ORef: *blockORef}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return "", fmt.Errorf("getting block metadata: %w", err)
+	}
+	oldFile := oldMeta.GetString(waveobj.MetaKey_File, "")
+	if oldMeta.GetString(waveobj.MetaKey_View, "") != "preview" || !oldMeta.GetBool(waveobj.MetaKey_FileTemp, false) || !strings.HasPrefix(filepath.Base(oldFile), wshrpc.WshTempFilePrefix) {
+		return "", fmt.Errorf("block %s was not opened by wsh render --md", blockORef.OID)
+	}
+	if oldMeta.GetString(waveobj.MetaKey_Connection, "") != RpcContext.Conn {
+		return "", fmt.Errorf("block %s was opened on a different connection", blockORef.OID)
+	}
+	meta, err := makeRenderMeta(data)
+	if err != nil {
+		return "", err
+	}
+	err = wshclient.SetMetaCommand(RpcClient, wshrpc.CommandSetMetaData{ORef: *blockORef, Meta: meta}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		if newFile := meta.GetString(waveobj.MetaKey_File, ""); newFile != "" {
+			os.Remove(newFile)
+		}
+		return "", fmt.Errorf("updating block: %w", err)
+	}
+	// the block now points at the new temp file, so the old one won't be removed when the block is closed
+	os.Remove(oldFile)
+	return blockORef.OID, nil
+}
+
+// the backend makes the data url (only it can open a web block with a data url)
+func renderHtmlBlock(data []byte) (string, error) {
+	renderData := wshrpc.CommandRenderHtmlData{Html: string(data), Magnified: renderMagnified}
+	if renderBlockId != "" {
+		blockORef, err := resolveRenderBlockArg(renderBlockId)
+		if err != nil {
+			return "", err
+		}
+		renderData.BlockId = blockORef.OID
+	} else {
+		tabId, position, err := resolveBlockPlacementArgs(renderTabId, renderPosition)
+		if err != nil {
+			return "", err
+		}
+		renderData.TabId = tabId
+		renderData.Position = position
+	}
+	rtnData, err := wshclient.RenderHtmlCommand(RpcClient, renderData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return "", fmt.Errorf("rendering html: %w", err)
+	}
+	return rtnData.BlockId, nil
+}
+
+func renderRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("render", rtnErr == nil)
+	}()
+	if renderMd == renderHtml {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("exactly one of --md or --html is required")
+	}
+	if renderBlockId != "" && (renderTabId != "" || renderPosition != "" || renderMagnified) {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--block cannot be used with --tab, --position, or --magnified")
+	}
+	maxSize := renderMaxSize
+	if maxSize == 0 {
+		maxSize = DefaultRenderMdMaxSize
+		if renderHtml {
+			maxSize = DefaultRenderHtmlMaxSize
+		}
+	}
+	if maxSize < 0 {
+		return fmt.Errorf("invalid --max-size %d", maxSize)
+	}
+	var fileArg string
+	if len(args) > 0 {
+		fileArg = args[0]
+	}
+	data, err := readRenderContent(fileArg, maxSize)
+	if err != nil {
+		return err
+	}
+	if renderHtml {
+		blockId, err := renderHtmlBlock(data)
+		if err != nil {
+			return err
+		}
+		WriteStdout("%s\n", blockId)
+		return nil
+	}
+	if renderBlockId != "" {
+		blockId, err := updateRenderBlock(renderBlockId, data)
+		if err != nil {
+			return err
+		}
+		WriteStdout("%s\n", blockId)
+		return nil
+	}
+	tabId, position, err := resolveBlockPlacementArgs(renderTabId, renderPosition)
+	if err != nil {
+		return err
+	}
+	meta, err := makeRenderMeta(data)
+	if err != nil {
+		return err
+	}
+	createData := wshrpc.CommandCreateBlockData{
+		TabId:     tabId,
+		BlockDef:  &waveobj.BlockDef{Meta: meta},
+		Magnified: renderMagnified,
+		Position:  position,
+	}
+	rtnData, err := wshclient.CreateBlockCommand(RpcClient, createData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		if tempFile := meta.GetString(waveobj.MetaKey_File, ""); tempFile != "" {
+			os.Remove(tempFile)
+		}
+		return fmt.Errorf("creating block: %w", err)
+	}
+	WriteStdout("%s\n", rtnData.BlockId)
+	return nil
+}
@@ -4,12 +4,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
@@ -22,6 +28,11 @@ var (
 		Short:        "CLI tool to control Wave Terminal",
 		Long:         `wsh is a small utility that lets you do cool things with Wave Terminal, right from the command line`,
 		SilenceUsage: true,
+		// printed by Execute (see formatCmdError)
+		SilenceErrors: true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			cmdArgsParsed = true
+		},
 	}
 )
 
@@ -32,7 +43,9 @@ var RpcClient *wshutil.WshRpc
 var RpcContext wshrpc.RpcContext
 var UsingTermWshMode bool
 var blockArg string
+var rpcTimeout time.Duration // --timeout, only used when it is set (otherwise each command uses its own timeouts)
 var WshExitCode int
+var wshStartTime = time.Now()
 
 type WrappedWriter struct {
 	dest io.Writer
@@ -75,6 +88,7 @@ func WriteStdout(fmtStr string, args ...interface{}) {
 }
 
 func OutputHelpMessage(cmd *cobra.Command) {
+	usageErrorShown = true
 	cmd.SetOutput(WrappedStderr)
 	cmd.Help()
 	WriteStderr("\n")
@@ -85,9 +99,33 @@ func preRunSetupRpcClient(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if !UsingTermWshMode {
+		installCancelSignalHandler()
+	}
 	return nil
 }
 
+// on ctrl-c the requests that are still running are canceled on the other side (e.g. a stat on a hung
+// connection), before wsh exits
+func installCancelSignalHandler() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer func() {
+			panichandler.PanicHandlerNoTelemetry("installCancelSignalHandler", recover())
+		}()
+		sig := <-sigCh
+		restoreNoEchoTerm()
+		RpcClient.CancelAllRequests()
+		RpcClient.WaitForOutputFlush(200 * time.Millisecond)
+		exitCode := 130
+		if sig == syscall.SIGTERM {
+			exitCode = 143
+		}
+		wshutil.DoShutdown("", exitCode, true)
+	}()
+}
+
 func getIsTty() bool {
 	if fileInfo, _ := os.Stdout.Stat(); (fileInfo.Mode() & os.ModeCharDevice) != 0 {
 		return true
@@ -134,6 +172,7 @@ func setupRpcClient(serverImpl wshutil.ServerImpl) error {
 		wshutil.SetTermRawModeAndInstallShutdownHandlers(true)
 		UsingTermWshMode = true
 		RpcClient, WrappedStdin = wshutil.SetupTerminalRpcClient(serverImpl)
+		applyRpcTimeoutFlag()
 		return nil
 	}
 	rpcCtx, err := wshutil.ExtractUnverifiedRpcContext(jwtToken)
@@ -147,13 +186,42 @@ func setupRpcClient(serverImpl wshutil.ServerImpl) error {
 	}
 	RpcClient, err = wshutil.SetupDomainSocketRpcClient(sockName, serverImpl)
 	if err != nil {
-		return fmt.Errorf("error setting up domain socket rpc client: %v", err)
+		return cmdErrorf(errCatConnRefused, "cannot connect to the wave server: %v", err)
 	}
 	wshclient.AuthenticateCommand(RpcClient, jwtToken, &wshrpc.RpcOpts{NoResponse: true})
+	applyRpcTimeoutFlag()
 	// note we don't modify WrappedStdin here (just use os.Stdin)
 	return nil
 }
 
+// commands that have their own --timeout flag (e.g. wsh file) shadow the global one, so it isn't changed for them
+func applyRpcTimeoutFlag() {
+	if !rootCmd.PersistentFlags().Changed("timeout") {
+		return
+	}
+	if rpcTimeout <= 0 {
+		RpcClient.SetTimeoutOverride(wshutil.MaxTimeoutMs)
+		return
+	}
+	RpcClient.SetTimeoutOverride(max(int(rpcTimeout.Milliseconds()), 1))
+}
+
+var connRoutePrefix = wshutil.MakeConnectionRouteId("")
+
+func formatCmdError(err error) string {
+	var timeoutErr *wshutil.RpcTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		return err.Error()
+	}
+	target := "wave server"
+	if strings.HasPrefix(timeoutErr.Route, connRoutePrefix) {
+		target = "connection " + strings.TrimPrefix(timeoutErr.Route, connRoutePrefix)
+	} else if timeoutErr.Route != "" {
+		target = timeoutErr.Route
+	}
+	return fmt.Sprintf("request timed out after %v talking to %s (use --timeout to wait longer)", timeoutErr.Timeout, target)
+}
+
 func isFullORef(orefStr string) bool {
 	_, err := waveobj.ParseORef(orefStr)
 	return err == nil
@@ -169,30 +237,31 @@ func resolveSimpleId(id string) (*waveobj.ORef, error) {
 	}
 	rtnData, err := wshclient.ResolveIdsCommand(RpcClient, wshrpc.CommandResolveIdsData{Ids: []string{id}}, &wshrpc.RpcOpts{Timeout: 2000})
 	if err != nil {
-		return nil, fmt.Errorf("error resolving ids: %v", err)
+		return nil, fmt.Errorf("error resolving ids: %w", err)
 	}
 	oref, ok := rtnData.ResolvedIds[id]
 	if !ok {
-		return nil, fmt.Errorf("id not found: %q", id)
+		return nil, cmdErrorf(errCatNotFound, "id not found: %q", id)
 	}
 	return &oref, nil
 }
 
 // this will send wsh activity to the client running on *your* local machine (it does not contact any wave cloud infrastructure)
 // if you've turned off telemetry in your local client, this data never gets sent to us
-// no parameters or timestamps are sent, as you can see below, it just sends the name of the command (and if there was an error)
-// (e.g. "wsh ai ..." would send "ai")
+// no parameters are sent, as you can see below, it just sends the name of the command, if there was an error, and how long it ran
+// (e.g. "wsh ai ..." would send "wsh:ai")
+// the local client keeps daily counts (see `wsh stats`), only the command name and error are included in the uploaded activity
 // this helps us understand which commands are actually being used so we know where to concentrate our effort
 func sendActivity(wshCmdName string, success bool) {
 	if RpcClient == nil || wshCmdName == "" {
 		return
 	}
-	dataMap := make(map[string]int)
-	dataMap[wshCmdName] = 1
-	if !success {
-		dataMap[wshCmdName+"#"+"error"] = 1
+	record := wshrpc.ActivityRecord{
+		Feature:    "wsh:" + wshCmdName,
+		Success:    success,
+		DurationMs: time.Since(wshStartTime).Milliseconds(),
 	}
-	wshclient.WshActivityCommand(RpcClient, dataMap, nil)
+	wshclient.RecordActivityCommand(RpcClient, record, nil)
 }
 
 // Execute executes the root command.
@@ -208,9 +277,14 @@ func Execute() {
 		}
 	}()
 	rootCmd.PersistentFlags().StringVarP(&blockArg, "block", "b", "", "for commands which require a block id")
+	rootCmd.PersistentFlags().DurationVar(&rpcTimeout, "timeout", 0, "timeout for the requests to wave (e.g. 30s, 0 waits forever), replaces the default timeouts")
+	// so the root's PersistentPreRun also runs for the commands that have their own
+	cobra.EnableTraverseRunHooks = true
 	err := rootCmd.Execute()
 	if err != nil {
-		wshutil.DoShutdown("", 1, true)
+		category := getErrorCategory(err)
+		WriteStderr("wsh: %s: %s\n", category.Name, formatCmdError(err))
+		wshutil.DoShutdown("", category.ExitCode, true)
 		return
 	}
 }
@@ -8,10 +8,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 )
@@ -34,9 +37,61 @@ func init() {
 	flags.BoolP("paused", "p", false, "create block in paused state")
 	flags.String("cwd", "", "set working directory for command")
 	flags.BoolP("append", "a", false, "append output on restart instead of clearing")
+	flags.StringArray("env", nil, "set an environment variable for the command, KEY=VAL (can be repeated)")
+	flags.BoolP("wait", "w", false, "wait for the command to exit and exit with its exit code")
+	flags.Bool("close-on-exit", false, "same as --exit")
+	flags.String("tab", "", "create the block in the given tab (defaults to the current tab)")
+	flags.String("position", "", blockPositionFlagHelp)
+	flags.Bool("local", false, "run the command locally (instead of on the current block's connection)")
 	rootCmd.AddCommand(runCmd)
 }
 
+// variables that only make sense in the current shell (or hold the current block's credentials)
+var inheritEnvSkip = map[string]bool{
+	"PWD":             true,
+	"OLDPWD":          true,
+	"SHLVL":           true,
+	"_":               true,
+	"TERM_SESSION_ID": true,
+	"WINDOWID":        true,
+	"COLUMNS":         true,
+	"LINES":           true,
+}
+
+// variables that point at local sockets/sessions which won't resolve for a block on a connection
+var inheritEnvConnSkip = map[string]bool{
+	"SSH_AUTH_SOCK":            true,
+	"SSH_AGENT_PID":            true,
+	"SSH_CONNECTION":           true,
+	"SSH_CLIENT":               true,
+	"SSH_TTY":                  true,
+	"DISPLAY":                  true,
+	"XAUTHORITY":               true,
+	"XDG_RUNTIME_DIR":          true,
+	"XDG_SESSION_ID":           true,
+	"DBUS_SESSION_BUS_ADDRESS": true,
+}
+
+// converts environ (os.Environ() format) to the env map for a new block on conn
+func getInheritEnv(environ []string, conn string) map[string]string {
+	isConn := conn != "" && !strings.HasPrefix(conn, "local")
+	envMap := make(map[string]string)
+	for _, envStr := range environ {
+		key, val, ok := strings.Cut(envStr, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if strings.HasPrefix(key, "WAVETERM_") || inheritEnvSkip[key] {
+			continue
+		}
+		if isConn && inheritEnvConnSkip[key] {
+			continue
+		}
+		envMap[key] = val
+	}
+	return envMap
+}
+
 func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	defer func() {
 		sendActivity("run", rtnErr == nil)
@@ -46,11 +101,18 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	magnified, _ := flags.GetBool("magnified")
 	commandArg, _ := flags.GetString("command")
 	exit, _ := flags.GetBool("exit")
+	closeOnExit, _ := flags.GetBool("close-on-exit")
+	exit = exit || closeOnExit
 	forceExit, _ := flags.GetBool("forceexit")
 	paused, _ := flags.GetBool("paused")
 	cwd, _ := flags.GetString("cwd")
 	delayMs, _ := flags.GetInt("delay")
 	appendOutput, _ := flags.GetBool("append")
+	envSets, _ := flags.GetStringArray("env")
+	wait, _ := flags.GetBool("wait")
+	tabArg, _ := flags.GetString("tab")
+	positionArg, _ := flags.GetString("position")
+	local, _ := flags.GetBool("local")
 	var cmdArgs []string
 	var useShell bool
 	var shellCmd string
@@ -79,35 +141,50 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		useShell = true
 	}
 
-	// Get current working directory
-	if cwd == "" {
+	conn := RpcContext.Conn
+	if local {
+		conn = ""
+	}
+	// running locally from a remote block, the current directory and environment are the remote's
+	// (so only --cwd, as given, and --env are used)
+	switchedConn := conn != RpcContext.Conn
+	envMap := make(map[string]string)
+	if !switchedConn {
+		// Get current working directory
+		if cwd == "" {
+			var err error
+			cwd, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("getting current directory: %w", err)
+			}
+		}
 		var err error
-		cwd, err = os.Getwd()
+		cwd, err = filepath.Abs(cwd)
 		if err != nil {
-			return fmt.Errorf("getting current directory: %w", err)
+			return fmt.Errorf("getting absolute path: %w", err)
 		}
+		envMap = getInheritEnv(os.Environ(), conn)
 	}
-	cwd, err := filepath.Abs(cwd)
-	if err != nil {
-		return fmt.Errorf("getting absolute path: %w", err)
-	}
-
-	// Get current environment and convert to map
-	envMap := make(map[string]string)
-	for _, envStr := range os.Environ() {
-		env := strings.SplitN(envStr, "=", 2)
-		if len(env) == 2 {
-			envMap[env[0]] = env[1]
+	for _, envSet := range envSets {
+		key, val, ok := strings.Cut(envSet, "=")
+		if !ok || key == "" {
+			OutputHelpMessage(cmd)
+			return fmt.Errorf("invalid --env value %q (must be KEY=VAL)", envSet)
 		}
+		envMap[key] = val
 	}
 
 	// Convert to null-terminated format
 	envContent := envutil.MapToEnv(envMap)
 	createMeta := map[string]any{
 		waveobj.MetaKey_View:            "term",
-		waveobj.MetaKey_CmdCwd:          cwd,
 		waveobj.MetaKey_Controller:      "cmd",
 		waveobj.MetaKey_CmdClearOnStart: true,
+		// always set, so the block doesn't get the tab's default connection (the cwd and env are for this connection)
+		waveobj.MetaKey_Connection: conn,
+	}
+	if cwd != "" {
+		createMeta[waveobj.MetaKey_CmdCwd] = cwd
 	}
 	createMeta[waveobj.MetaKey_Cmd] = shellCmd
 	createMeta[waveobj.MetaKey_CmdArgs] = cmdArgs
@@ -128,11 +205,12 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		createMeta[waveobj.MetaKey_CmdClearOnStart] = false
 	}
 
-	if RpcContext.Conn != "" {
-		createMeta[waveobj.MetaKey_Connection] = RpcContext.Conn
+	tabId, position, err := resolveBlockPlacementArgs(tabArg, positionArg)
+	if err != nil {
+		return err
 	}
-
 	createBlockData := wshrpc.CommandCreateBlockData{
+		TabId: tabId,
 		BlockDef: &waveobj.BlockDef{
 			Meta: createMeta,
 			Files: map[string]*waveobj.FileDef{
@@ -142,13 +220,101 @@ func runRun(cmd *cobra.Command, args []string) (rtnErr error) {
 			},
 		},
 		Magnified: magnified,
+		Position:  position,
 	}
 
-	oref, err := wshclient.CreateBlockCommand(RpcClient, createBlockData, nil)
+	var exitWaiter *controllerExitWaiter
+	if wait {
+		// subscribe before creating the block so we can't miss the exit event
+		exitWaiter, err = makeControllerExitWaiter()
+		if err != nil {
+			return err
+		}
+	}
+
+	rtnData, err := wshclient.CreateBlockCommand(RpcClient, createBlockData, nil)
 	if err != nil {
 		return fmt.Errorf("creating new run block: %w", err)
 	}
+	oref := rtnData.BlockORef
 
 	WriteStdout("run block created: %s\n", oref)
+	if exitWaiter != nil {
+		exitCode, err := exitWaiter.wait(oref.OID)
+		if err != nil {
+			return err
+		}
+		WshExitCode = exitCode
+	}
 	return nil
 }
+
+type controllerExitWaiter struct {
+	lock      *sync.Mutex
+	exitCodes map[string]int
+	closed    map[string]bool
+	notifyCh  chan struct{}
+}
+
+func makeControllerExitWaiter() (*controllerExitWaiter, error) {
+	w := &controllerExitWaiter{
+		lock:      &sync.Mutex{},
+		exitCodes: make(map[string]int),
+		closed:    make(map[string]bool),
+		notifyCh:  make(chan struct{}, 1),
+	}
+	RpcClient.EventListener.On(wps.Event_ControllerExit, func(event *wps.WaveEvent) {
+		var exitData wps.ControllerExitEventData
+		err := utilfn.ReUnmarshal(&exitData, event.Data)
+		if err != nil {
+			return
+		}
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		w.exitCodes[exitData.BlockId] = exitData.ExitCode
+		w.notify()
+	})
+	RpcClient.EventListener.On(wps.Event_BlockClose, func(event *wps.WaveEvent) {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		for _, scope := range event.Scopes {
+			oref, err := waveobj.ParseORef(scope)
+			if err == nil && oref.OType == waveobj.OType_Block {
+				w.closed[oref.OID] = true
+			}
+		}
+		w.notify()
+	})
+	for _, eventName := range []string{wps.Event_ControllerExit, wps.Event_BlockClose} {
+		err := wshclient.EventSubCommand(RpcClient, wps.SubscriptionRequest{Event: eventName, AllScopes: true}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("subscribing to %s events: %w", eventName, err)
+		}
+	}
+	return w, nil
+}
+
+// must hold lock
+func (w *controllerExitWaiter) notify() {
+	select {
+	case w.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// waits for the command in the given block to exit, returns its exit code
+func (w *controllerExitWaiter) wait(blockId string) (int, error) {
+	for {
+		w.lock.Lock()
+		exitCode, exited := w.exitCodes[blockId]
+		closed := w.closed[blockId]
+		w.lock.Unlock()
+		if exited {
+			return exitCode, nil
+		}
+		if closed {
+			return 0, fmt.Errorf("block was closed before the command exited")
+		}
+		<-w.notifyCh
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import "testing"
+
+func TestGetInheritEnv(t *testing.T) {
+	environ := []string{"PATH=/usr/bin", "FOO=a=b", "PWD=/tmp", "WAVETERM_JWT=secret", "SSH_AUTH_SOCK=/tmp/agent.sock", "BADENTRY"}
+	localEnv := getInheritEnv(environ, "")
+	if localEnv["PATH"] != "/usr/bin" || localEnv["FOO"] != "a=b" || localEnv["SSH_AUTH_SOCK"] != "/tmp/agent.sock" {
+		t.Errorf("unexpected local env %v", localEnv)
+	}
+	if _, ok := localEnv["PWD"]; ok {
+		t.Errorf("PWD should not be inherited")
+	}
+	if _, ok := localEnv["WAVETERM_JWT"]; ok {
+		t.Errorf("WAVETERM_ variables should not be inherited")
+	}
+	if len(localEnv) != 3 {
+		t.Errorf("expected 3 variables, got %v", localEnv)
+	}
+	connEnv := getInheritEnv(environ, "user@host")
+	if _, ok := connEnv["SSH_AUTH_SOCK"]; ok {
+		t.Errorf("SSH_AUTH_SOCK should not be inherited on a connection")
+	}
+	if connEnv["PATH"] != "/usr/bin" {
+		t.Errorf("unexpected conn env %v", connEnv)
+	}
+}
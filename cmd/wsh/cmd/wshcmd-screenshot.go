@@ -0,0 +1,98 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"golang.org/x/term"
+)
+
+const ScreenshotMaxSize = 32 * 1024 * 1024
+
+var screenshotOutFile string
+var screenshotTab bool
+var screenshotTimeout int
+
+var screenshotCmd = &cobra.Command{
+	Use:   "screenshot [blockid]",
+	Short: "save a screenshot of a block (as a png)",
+	Long: `save a screenshot of a block (defaults to the current block) as a png.  use --tab to capture the
+whole tab the block is in.  the block's tab must be loaded in a window (it does not need to be the active tab).`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    screenshotRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	screenshotCmd.Flags().StringVarP(&screenshotOutFile, "output", "o", "", "write the png to a file (required unless stdout is redirected)")
+	screenshotCmd.Flags().BoolVar(&screenshotTab, "tab", false, "capture the whole tab")
+	screenshotCmd.Flags().IntVar(&screenshotTimeout, "timeout", 10000, "timeout in milliseconds")
+	rootCmd.AddCommand(screenshotCmd)
+}
+
+func screenshotRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("screenshot", rtnErr == nil)
+	}()
+	if screenshotTimeout <= 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid --timeout %d", screenshotTimeout)
+	}
+	if screenshotOutFile == "" && term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("not writing a png to a terminal, use -o to write to a file")
+	}
+	data := wshrpc.CommandCaptureBlockData{Tab: screenshotTab, MaxSize: ScreenshotMaxSize}
+	if len(args) > 0 || !screenshotTab {
+		blockArg := "this"
+		if len(args) > 0 {
+			blockArg = args[0]
+		}
+		blockId, err := resolveTermBlockArg(blockArg)
+		if err != nil {
+			return err
+		}
+		data.BlockId = blockId
+	} else if RpcContext.TabId == "" {
+		return fmt.Errorf("no current tab, pass a block id")
+	}
+	var buf bytes.Buffer
+	respCh := wshclient.CaptureBlockCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: screenshotTimeout})
+	for resp := range respCh {
+		if resp.Error != nil {
+			return fmt.Errorf("taking screenshot: %w", resp.Error)
+		}
+		chunk, err := base64.StdEncoding.DecodeString(resp.Response)
+		if err != nil {
+			return fmt.Errorf("decoding screenshot: %w", err)
+		}
+		if buf.Len()+len(chunk) > ScreenshotMaxSize {
+			return fmt.Errorf("screenshot is larger than the max size (%d bytes)", ScreenshotMaxSize)
+		}
+		buf.Write(chunk)
+	}
+	if buf.Len() == 0 {
+		return fmt.Errorf("taking screenshot: no image data")
+	}
+	// written once the whole image has been received, so a failed capture doesn't leave a partial file
+	if screenshotOutFile == "" {
+		_, err := WrappedStdout.Write(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+		return nil
+	}
+	err := os.WriteFile(screenshotOutFile, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+	WriteStdout("screenshot saved to %s (%d bytes)\n", screenshotOutFile, buf.Len())
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"golang.org/x/term"
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "manage the secrets that wave uses (e.g. ai api keys)",
+	Long: `manage the secrets that wave uses (e.g. ai api keys).  secrets are stored by the wave server (readable only by you)
+and referenced by name, e.g. "wsh setmeta ai:apikeyref=openai" uses the secret named openai as the block's api key.
+the values of the secrets can't be read back.`,
+}
+
+var secretSetCmd = &cobra.Command{
+	Use:   "set name [value]",
+	Short: "set a secret (the value is read from stdin if it isn't given)",
+	Long: `set a secret.  if the value isn't given it is prompted for (or read from stdin if stdin isn't a terminal), so it
+isn't saved in your shell history.`,
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    secretSetRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var secretListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "list the names of the secrets",
+	Args:    cobra.NoArgs,
+	RunE:    secretListRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var secretDeleteCmd = &cobra.Command{
+	Use:     "delete name",
+	Short:   "delete a secret",
+	Args:    cobra.ExactArgs(1),
+	RunE:    secretDeleteRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretDeleteCmd)
+	rootCmd.AddCommand(secretCmd)
+}
+
+func readSecretValue(name string) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		WriteStderr("value for %s: ", name)
+		barr, err := term.ReadPassword(int(os.Stdin.Fd()))
+		WriteStderr("\n")
+		if err != nil {
+			return "", fmt.Errorf("reading secret value: %w", err)
+		}
+		return string(barr), nil
+	}
+	barr, err := io.ReadAll(WrappedStdin)
+	if err != nil {
+		return "", fmt.Errorf("reading secret value: %w", err)
+	}
+	return strings.TrimRight(string(barr), "\r\n"), nil
+}
+
+func secretSetRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("secret:set", rtnErr == nil)
+	}()
+	var value string
+	if len(args) > 1 {
+		value = args[1]
+	} else {
+		var err error
+		value, err = readSecretValue(args[0])
+		if err != nil {
+			return err
+		}
+	}
+	if value == "" {
+		return fmt.Errorf("no value for secret %q (use wsh secret delete to remove it)", args[0])
+	}
+	err := wshclient.SetSecretCommand(RpcClient, wshrpc.CommandSetSecretData{Name: args[0], Value: value}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting secret: %w", err)
+	}
+	WriteStdout("secret %s set\n", args[0])
+	return nil
+}
+
+func secretListRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("secret:list", rtnErr == nil)
+	}()
+	names, err := wshclient.GetSecretNamesCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("listing secrets: %w", err)
+	}
+	for _, name := range names {
+		WriteStdout("%s\n", name)
+	}
+	return nil
+}
+
+func secretDeleteRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("secret:delete", rtnErr == nil)
+	}()
+	found, err := wshclient.DeleteSecretCommand(RpcClient, args[0], &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("deleting secret: %w", err)
+	}
+	if !found {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "secret %q not found", args[0])
+	}
+	WriteStdout("secret %s deleted\n", args[0])
+	return nil
+}
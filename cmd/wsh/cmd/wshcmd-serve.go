@@ -0,0 +1,258 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const ServeMaxLineSize = 16 * 1024 * 1024
+
+var serveJson bool
+
+var serveCmd = &cobra.Command{
+	Use:   "serve --json",
+	Short: "run rpc commands read from stdin (for programs that drive wave)",
+	Long: `keeps one rpc connection open and runs the newline-delimited json requests read from stdin, e.g.
+  {"command":"createblock","data":{"blockdef":{"meta":{"view":"preview","file":"/tmp/a.txt"}}},"reqid":"1"}
+the command is any wsh rpc command name (with optional "route" and "timeout" (ms) fields).  a response is
+written to stdout for each request, with the request's reqid:
+  {"reqid":"1","data":{...}}
+streaming commands write one response per item with "cont":true, followed by a final response without data.
+errors (including malformed requests) are written as {"reqid":"1","error":"..."}, with "errorcode" set to the
+error's category if it has one (e.g. "not-found", see wsh help exit-codes).  after an "eventsub"
+request, events are written as {"event":{...}}.  on SIGTERM (or when stdin is closed) the requests that are
+still running are finished before wsh exits.`,
+	Args:    cobra.NoArgs,
+	RunE:    serveRun,
+	PreRunE: preRunSetupServeRpcClient,
+}
+
+func init() {
+	serveCmd.Flags().BoolVar(&serveJson, "json", false, "read json requests from stdin and write json responses to stdout")
+	rootCmd.AddCommand(serveCmd)
+}
+
+type serveRequest struct {
+	Command string          `json:"command"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	ReqId   string          `json:"reqid"`
+	Route   string          `json:"route,omitempty"`
+	Timeout int             `json:"timeout,omitempty"`
+}
+
+type serveResponse struct {
+	ReqId     string         `json:"reqid,omitempty"`
+	Data      any            `json:"data,omitempty"`
+	Cont      bool           `json:"cont,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	ErrorCode string         `json:"errorcode,omitempty"` // the error's category (see "wsh help exit-codes")
+	Event     *wps.WaveEvent `json:"event,omitempty"`
+}
+
+type serveState struct {
+	outLock    sync.Mutex
+	out        *json.Encoder
+	eventLock  sync.Mutex
+	eventNames map[string]bool // the events we've registered a listener for
+}
+
+func (s *serveState) writeResponse(resp serveResponse) {
+	s.outLock.Lock()
+	defer s.outLock.Unlock()
+	err := s.out.Encode(resp)
+	if err != nil {
+		WriteStderr("[error] writing response: %v\n", err)
+	}
+}
+
+func (s *serveState) writeError(reqId string, err error) {
+	resp := serveResponse{ReqId: reqId, Error: err.Error()}
+	if category := getErrorCategory(err); category != errCatGeneral {
+		resp.ErrorCode = category.Name
+	}
+	s.writeResponse(resp)
+}
+
+// events are sent to the process's rpc client, so one listener per event name forwards them all to stdout
+func (s *serveState) listenForEvent(eventName string) {
+	s.eventLock.Lock()
+	defer s.eventLock.Unlock()
+	if eventName == "" || s.eventNames[eventName] {
+		return
+	}
+	s.eventNames[eventName] = true
+	RpcClient.EventListener.On(eventName, func(event *wps.WaveEvent) {
+		s.writeResponse(serveResponse{Event: event})
+	})
+}
+
+func (s *serveState) runRequest(req serveRequest) {
+	var data any
+	if len(req.Data) > 0 {
+		err := json.Unmarshal(req.Data, &data)
+		if err != nil {
+			s.writeError(req.ReqId, fmt.Errorf("invalid data: %w", err))
+			return
+		}
+	}
+	if req.Command == wshrpc.Command_EventSub {
+		var subReq wps.SubscriptionRequest
+		err := json.Unmarshal(req.Data, &subReq)
+		if err != nil {
+			s.writeError(req.ReqId, fmt.Errorf("invalid eventsub data: %w", err))
+			return
+		}
+		s.listenForEvent(subReq.Event)
+	}
+	handler, err := RpcClient.SendComplexRequest(req.Command, data, &wshrpc.RpcOpts{Route: req.Route, Timeout: req.Timeout})
+	if err != nil {
+		s.writeError(req.ReqId, err)
+		return
+	}
+	defer handler.Finalize()
+	for {
+		respData, cont, err := handler.NextResponseWithCont()
+		if err != nil {
+			s.writeError(req.ReqId, err)
+			return
+		}
+		s.writeResponse(serveResponse{ReqId: req.ReqId, Data: respData, Cont: cont})
+		if !cont {
+			return
+		}
+	}
+}
+
+func parseServeRequest(line []byte) (serveRequest, error) {
+	var req serveRequest
+	err := json.Unmarshal(line, &req)
+	if err != nil {
+		return req, fmt.Errorf("invalid request: %w", err)
+	}
+	if req.ReqId == "" {
+		return req, fmt.Errorf("invalid request: reqid is required")
+	}
+	if req.Command == "" {
+		return req, fmt.Errorf("invalid request: command is required")
+	}
+	if req.Timeout < 0 {
+		return req, fmt.Errorf("invalid request: invalid timeout %d", req.Timeout)
+	}
+	return req, nil
+}
+
+type serveLine struct {
+	line []byte
+	err  error
+}
+
+// reads lines from stdin (the last line doesn't need a newline), lines that are too long are sent as errors (so
+// they get an error response, the next line is read normally)
+func readServeLines(input io.Reader, lineCh chan serveLine) {
+	defer func() {
+		panichandler.PanicHandlerNoTelemetry("readServeLines", recover())
+	}()
+	defer close(lineCh)
+	reader := bufio.NewReaderSize(input, 64*1024)
+	for {
+		var line []byte
+		var tooLong bool
+		for {
+			chunk, isPrefix, err := reader.ReadLine()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					WriteStderr("[error] reading stdin: %v\n", err)
+				}
+				return
+			}
+			if !tooLong {
+				line = append(line, chunk...)
+				if len(line) > ServeMaxLineSize {
+					tooLong = true
+					line = nil
+				}
+			}
+			if !isPrefix {
+				break
+			}
+		}
+		if tooLong {
+			lineCh <- serveLine{err: fmt.Errorf("invalid request: line exceeds max size of %d bytes", ServeMaxLineSize)}
+			continue
+		}
+		lineCh <- serveLine{line: line}
+	}
+}
+
+// like preRunSetupRpcClient, but SIGTERM doesn't exit right away (serveRun lets the running requests finish)
+func preRunSetupServeRpcClient(cmd *cobra.Command, args []string) error {
+	return setupRpcClient(nil)
+}
+
+func serveRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("serve", rtnErr == nil)
+	}()
+	if !serveJson {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--json is required (it is the only mode)")
+	}
+	state := &serveState{out: json.NewEncoder(WrappedStdout), eventNames: make(map[string]bool)}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	lineCh := make(chan serveLine)
+	go readServeLines(WrappedStdin, lineCh)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case sig := <-sigCh:
+			if sig == syscall.SIGINT {
+				// ctrl-c doesn't wait for the running requests
+				RpcClient.CancelAllRequests()
+				return fmt.Errorf("interrupted")
+			}
+			return nil
+		case line, ok := <-lineCh:
+			if !ok {
+				return nil
+			}
+			if line.err != nil {
+				state.writeError("", line.err)
+				continue
+			}
+			if len(line.line) == 0 {
+				continue
+			}
+			req, err := parseServeRequest(line.line)
+			if err != nil {
+				state.writeError(req.ReqId, err)
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					panichandler.PanicHandlerNoTelemetry("serveRun:runRequest", recover())
+					wg.Done()
+				}()
+				state.runRequest(req)
+			}()
+		}
+	}
+}
@@ -24,10 +24,12 @@ var setMetaCmd = &cobra.Command{
 }
 
 var setMetaJsonFilePath string
+var setMetaString bool
 
 func init() {
 	rootCmd.AddCommand(setMetaCmd)
 	setMetaCmd.Flags().StringVar(&setMetaJsonFilePath, "json", "", "JSON file containing metadata to apply (use '-' for stdin)")
+	setMetaCmd.Flags().BoolVar(&setMetaString, "string", false, "set all values as strings (do not parse numbers, booleans, null, or JSON)")
 }
 
 func loadJSONFile(filepath string) (map[string]interface{}, error) {
@@ -96,6 +98,19 @@ func parseMetaSets(metaSets []string) (map[string]interface{}, error) {
 	return meta, nil
 }
 
+// like parseMetaSets, but all values are set as literal strings
+func parseMetaSetsAsStrings(metaSets []string) (map[string]interface{}, error) {
+	meta := make(map[string]interface{})
+	for _, metaSet := range metaSets {
+		fields := strings.SplitN(metaSet, "=", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid meta set: %q", metaSet)
+		}
+		meta[fields[0]] = fields[1]
+	}
+	return meta, nil
+}
+
 func simpleMergeMeta(meta map[string]interface{}, metaUpdate map[string]interface{}) map[string]interface{} {
 	for k, v := range metaUpdate {
 		if v == nil {
@@ -120,7 +135,13 @@ func setMetaRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		}
 	}
 
-	cmdMeta, err := parseMetaSets(args)
+	var cmdMeta map[string]interface{}
+	var err error
+	if setMetaString {
+		cmdMeta, err = parseMetaSetsAsStrings(args)
+	} else {
+		cmdMeta, err = parseMetaSets(args)
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,44 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+)
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init [bash|zsh|fish]",
+	Short: "print the shell integration for your shell's rc file",
+	Long: `print the shell integration for your shell's rc file.  it reports the commands you run (command line, cwd, start and end time, and exit status) to the terminal block, which keeps the last 200 commands.
+the shell defaults to $SHELL.
+
+  bash (~/.bashrc):                 eval "$(wsh shell-init bash)"
+  zsh (~/.zshrc):                   eval "$(wsh shell-init zsh)"
+  fish (~/.config/fish/config.fish): wsh shell-init fish | source`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: shellInitRun,
+}
+
+func init() {
+	rootCmd.AddCommand(shellInitCmd)
+}
+
+func shellInitRun(cmd *cobra.Command, args []string) error {
+	var shellType string
+	if len(args) > 0 {
+		shellType = args[0]
+	} else {
+		shellType = shellutil.GetShellTypeFromShellPath(os.Getenv("SHELL"))
+	}
+	script, err := shellutil.GetShellIntegScript(shellType)
+	if err != nil {
+		OutputHelpMessage(cmd)
+		return err
+	}
+	WriteStdout("%s", script)
+	return nil
+}
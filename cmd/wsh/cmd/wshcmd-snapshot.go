@@ -0,0 +1,97 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+const snapshotRpcTimeout = 30000
+
+var snapshotImportKeepIds bool
+var snapshotImportNewWindow bool
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "export or import a snapshot of your workspaces, tabs, and blocks",
+}
+
+var snapshotExportCmd = &cobra.Command{
+	Use:     "export {file|-}",
+	Short:   "export a snapshot to a json file (use - for stdout)",
+	Args:    cobra.ExactArgs(1),
+	RunE:    snapshotExportRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var snapshotImportCmd = &cobra.Command{
+	Use:   "import {file|-}",
+	Short: "import the workspaces from a snapshot file (use - for stdin)",
+	Long: `import the workspaces (with their tabs and blocks) from a snapshot file.
+the imported objects get new ids unless --keep-ids is given.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    snapshotImportRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	snapshotImportCmd.Flags().BoolVar(&snapshotImportKeepIds, "keep-ids", false, "keep the ids from the snapshot (fails if the objects already exist)")
+	snapshotImportCmd.Flags().BoolVar(&snapshotImportNewWindow, "new-window", false, "open the first imported workspace in a new window")
+	snapshotCmd.AddCommand(snapshotExportCmd)
+	snapshotCmd.AddCommand(snapshotImportCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+func snapshotExportRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("snapshot", rtnErr == nil)
+	}()
+	data, err := wshclient.SnapshotExportCommand(RpcClient, &wshrpc.RpcOpts{Timeout: snapshotRpcTimeout})
+	if err != nil {
+		return fmt.Errorf("exporting snapshot: %w", err)
+	}
+	if args[0] == "-" {
+		WriteStdout("%s", data)
+		return nil
+	}
+	err = os.WriteFile(args[0], []byte(data), 0600)
+	if err != nil {
+		return fmt.Errorf("writing snapshot file: %w", err)
+	}
+	WriteStdout("snapshot written to %s\n", args[0])
+	return nil
+}
+
+func snapshotImportRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("snapshot", rtnErr == nil)
+	}()
+	var data []byte
+	var err error
+	if args[0] == "-" {
+		data, err = io.ReadAll(WrappedStdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("reading snapshot file: %w", err)
+	}
+	importData := wshrpc.CommandSnapshotImportData{
+		Data:      string(data),
+		KeepIds:   snapshotImportKeepIds,
+		NewWindow: snapshotImportNewWindow,
+	}
+	rtn, err := wshclient.SnapshotImportCommand(RpcClient, importData, &wshrpc.RpcOpts{Timeout: snapshotRpcTimeout})
+	if err != nil {
+		return fmt.Errorf("importing snapshot: %w", err)
+	}
+	WriteStdout("imported %d workspace(s), %d tab(s), %d block(s)\n", len(rtn.WorkspaceIds), rtn.NumTabs, rtn.NumBlocks)
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var splitLeft bool
+var splitRight bool
+var splitTop bool
+var splitBottom bool
+var splitView string
+
+var splitCmd = &cobra.Command{
+	Use:   "split {blockid} [--left|--right|--top|--bottom] [--view viewname] [key=value ...]",
+	Short: "split a block, putting a new block next to it",
+	Long: `split a block (e.g. "this"), putting a new block next to it (on the right by default).
+the new block is a terminal unless --view is given, key=value arguments are set in its metadata (like "wsh setmeta").
+prints the id of the new block.`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    splitRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	splitCmd.Flags().BoolVar(&splitLeft, "left", false, "put the new block on the left")
+	splitCmd.Flags().BoolVar(&splitRight, "right", false, "put the new block on the right (the default)")
+	splitCmd.Flags().BoolVar(&splitTop, "top", false, "put the new block above")
+	splitCmd.Flags().BoolVar(&splitBottom, "bottom", false, "put the new block below")
+	splitCmd.MarkFlagsMutuallyExclusive("left", "right", "top", "bottom")
+	splitCmd.Flags().StringVar(&splitView, "view", "term", "the view of the new block (e.g. term, preview, web)")
+	rootCmd.AddCommand(splitCmd)
+}
+
+func getSplitDirection() string {
+	switch {
+	case splitLeft:
+		return wshrpc.SplitDirection_Left
+	case splitTop:
+		return wshrpc.SplitDirection_Top
+	case splitBottom:
+		return wshrpc.SplitDirection_Bottom
+	default:
+		return wshrpc.SplitDirection_Right
+	}
+}
+
+func splitRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("split", rtnErr == nil)
+	}()
+	if splitView == "" {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--view cannot be empty")
+	}
+	meta, err := parseMetaSets(args[1:])
+	if err != nil {
+		OutputHelpMessage(cmd)
+		return err
+	}
+	meta[waveobj.MetaKey_View] = splitView
+	if splitView == "term" {
+		if _, ok := meta[waveobj.MetaKey_Controller]; !ok {
+			meta[waveobj.MetaKey_Controller] = "shell"
+		}
+	}
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	data := wshrpc.CommandLayoutActionData{
+		ActionType: wshrpc.LayoutAction_Split,
+		BlockId:    blockId,
+		Direction:  getSplitDirection(),
+		BlockDef:   &waveobj.BlockDef{Meta: meta},
+	}
+	rtnData, err := wshclient.LayoutActionCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("splitting block: %w", err)
+	}
+	WriteStdout("%s\n", rtnData.BlockId)
+	return nil
+}
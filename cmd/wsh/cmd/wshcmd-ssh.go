@@ -37,15 +37,18 @@ func sshRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	if blockId == "" {
 		return fmt.Errorf("cannot determine blockid (not in JWT)")
 	}
-	// first, make a connection independent of the block
-	connOpts := wshrpc.ConnRequest{
-		Host:       sshArg,
+	// first, make a connection independent of the block (the auth prompts are answered in this terminal)
+	connOpts := wshrpc.CommandConnConnectInteractiveData{
+		ConnName:   sshArg,
 		LogBlockId: blockId,
 		Keywords: wshrpc.ConnKeywords{
 			SshIdentityFile: identityFiles,
 		},
 	}
-	wshclient.ConnConnectCommand(RpcClient, connOpts, nil)
+	err := connectInteractive(connOpts)
+	if err != nil {
+		return fmt.Errorf("connecting to %q: %w", sshArg, err)
+	}
 
 	// now, with that made, it will be straightforward to connect
 	data := wshrpc.CommandSetMetaData{
@@ -54,7 +57,7 @@ func sshRun(cmd *cobra.Command, args []string) (rtnErr error) {
 			waveobj.MetaKey_Connection: sshArg,
 		},
 	}
-	err := wshclient.SetMetaCommand(RpcClient, data, nil)
+	err = wshclient.SetMetaCommand(RpcClient, data, nil)
 	if err != nil {
 		return fmt.Errorf("setting connection in block: %w", err)
 	}
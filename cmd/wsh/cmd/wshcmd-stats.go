@@ -0,0 +1,72 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var statsDays int
+var statsJson bool
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "show your local feature usage",
+	Long: `show how often you've used wave features (wsh commands, etc.) over the last n days.
+this is stored locally by your client and is never uploaded.`,
+	Args:    cobra.NoArgs,
+	RunE:    statsRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	statsCmd.Flags().IntVarP(&statsDays, "days", "d", 30, "number of days to include (including today)")
+	statsCmd.Flags().BoolVar(&statsJson, "json", false, "output as json")
+	rootCmd.AddCommand(statsCmd)
+}
+
+func statsRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("stats", rtnErr == nil)
+	}()
+	if statsDays <= 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--days must be greater than 0")
+	}
+	stats, err := wshclient.GetActivityStatsCommand(RpcClient, statsDays, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("getting stats: %w", err)
+	}
+	if statsJson {
+		outBArr, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting output: %w", err)
+		}
+		WriteStdout("%s\n", string(outBArr))
+		return nil
+	}
+	if len(stats) == 0 {
+		WriteStdout("no activity in the last %d days\n", statsDays)
+		return nil
+	}
+	w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "FEATURE\tCOUNT\tERRORS\tAVG TIME\tLAST USED\n")
+	for _, stat := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", stat.Feature, stat.Count, stat.Errors, formatStatsDuration(stat.AvgDurationMs), stat.LastDay)
+	}
+	return w.Flush()
+}
+
+func formatStatsDuration(durationMs int64) string {
+	if durationMs < 1000 {
+		return fmt.Sprintf("%dms", durationMs)
+	}
+	return fmt.Sprintf("%.1fs", float64(durationMs)/1000)
+}
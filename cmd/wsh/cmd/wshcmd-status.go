@@ -0,0 +1,118 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var statusJson bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the status of the wave server",
+	Long: `show the status of the wave server (version, uptime, databases, connected windows, running blocks, event queues,
+and the progress of restarting the shells that were running before the server restarted).
+exits with an error if the server doesn't respond or reports that it is unhealthy (a database is not open or is locked).`,
+	Args:    cobra.NoArgs,
+	RunE:    statusRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func init() {
+	statusCmd.Flags().BoolVar(&statusJson, "json", false, "output as json")
+	rootCmd.AddCommand(statusCmd)
+}
+
+func formatDBState(dbStatus wshrpc.DBStatus) string {
+	if !dbStatus.Open {
+		return "not open"
+	}
+	if dbStatus.Locked {
+		return "locked"
+	}
+	return "ok"
+}
+
+func getUnhealthyReason(status *wshrpc.ServerStatusData) string {
+	var reasons []string
+	for _, dbStatus := range status.DBs {
+		if state := formatDBState(dbStatus); state != "ok" {
+			reasons = append(reasons, fmt.Sprintf("%s db is %s", dbStatus.Name, state))
+		}
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func formatRestoreStatus(restore *wshrpc.SessionRestoreStatus) string {
+	var rtn string
+	if restore.Running {
+		rtn = fmt.Sprintf("restoring %d/%d blocks", restore.Restored+restore.Failed, restore.Total)
+	} else {
+		rtn = fmt.Sprintf("restored %d/%d blocks", restore.Restored, restore.Total)
+	}
+	if restore.Failed > 0 {
+		rtn += fmt.Sprintf(", %d failed (see controller:restoreerror)", restore.Failed)
+	}
+	if restore.Deferred > 0 {
+		rtn += fmt.Sprintf(", %d waiting for their connection", restore.Deferred)
+	}
+	return rtn
+}
+
+func statusRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("status", rtnErr == nil)
+	}()
+	status, err := wshclient.GetServerStatusCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("getting server status: %w", err)
+	}
+	if statusJson {
+		outBArr, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting output: %w", err)
+		}
+		WriteStdout("%s\n", string(outBArr))
+	} else {
+		w := tabwriter.NewWriter(WrappedStdout, 0, 0, 2, ' ', 0)
+		healthStr := "healthy"
+		if !status.Healthy {
+			healthStr = "unhealthy"
+		}
+		fmt.Fprintf(w, "status:\t%s\n", healthStr)
+		fmt.Fprintf(w, "version:\t%s (%s)\n", status.Version, status.BuildTime)
+		fmt.Fprintf(w, "uptime:\t%v\n", (time.Duration(status.UptimeMs) * time.Millisecond).Round(time.Second))
+		for _, dbStatus := range status.DBs {
+			dbLine := fmt.Sprintf("%s (%s)", formatDBState(dbStatus), dbStatus.Path)
+			if dbStatus.InFlight > 0 {
+				dbLine += fmt.Sprintf(", %d running", dbStatus.InFlight)
+			}
+			if dbStatus.LastErr != "" {
+				dbLine += fmt.Sprintf(", last error %s: %s", time.UnixMilli(dbStatus.LastErrTs).Format("15:04:05"), dbStatus.LastErr)
+			}
+			fmt.Fprintf(w, "%s db:\t%s\n", dbStatus.Name, dbLine)
+		}
+		fmt.Fprintf(w, "windows:\t%d connected (%d websockets)\n", status.NumConnectedWindows, status.NumWebSockets)
+		fmt.Fprintf(w, "running blocks:\t%d\n", status.NumRunningControllers)
+		if status.Restore != nil {
+			fmt.Fprintf(w, "session restore:\t%s\n", formatRestoreStatus(status.Restore))
+		}
+		fmt.Fprintf(w, "event listeners:\t%d (%d events queued)\n", status.NumEventListeners, status.ListenerQueuedEvents)
+		fmt.Fprintf(w, "window queues:\t%d (%d events queued)\n", status.NumWindowQueues, status.WindowQueuedEvents)
+		w.Flush()
+	}
+	if !status.Healthy {
+		return fmt.Errorf("wave server is unhealthy: %s", getUnhealthyReason(status))
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var tabCommand = &cobra.Command{
+	Use:   "tab",
+	Short: "Manage tabs",
+}
+
+var tabNewName string
+var tabNewPinned bool
+var tabNewWindow string
+var tabNewBackground bool
+var tabCloseForce bool
+var tabTitleTemplate string
+
+func init() {
+	tabNewCommand.Flags().StringVar(&tabNewName, "name", "", "tab name (defaults to T<n>)")
+	tabNewCommand.Flags().BoolVar(&tabNewPinned, "pinned", false, "create a pinned tab")
+	tabNewCommand.Flags().StringVar(&tabNewWindow, "window", "", "create the tab in the given window (defaults to the current window)")
+	tabNewCommand.Flags().BoolVar(&tabNewBackground, "background", false, "don't switch to the new tab")
+	tabCloseCommand.Flags().BoolVarP(&tabCloseForce, "force", "f", false, "allow closing a pinned tab or the last tab in a window (closes the window)")
+	tabTitleCommand.Flags().StringVar(&tabTitleTemplate, "template", "", "title template, e.g. \"{conn}:{cwd}\" (\"\" clears it)")
+	tabCommand.AddCommand(tabNewCommand)
+	tabCommand.AddCommand(tabRenameCommand)
+	tabCommand.AddCommand(tabCloseCommand)
+	tabCommand.AddCommand(tabMoveCommand)
+	tabCommand.AddCommand(tabTitleCommand)
+	tabCommand.AddCommand(tabPinCommand)
+	tabCommand.AddCommand(tabUnpinCommand)
+	tabCommand.AddCommand(tabGroupCommand)
+	rootCmd.AddCommand(tabCommand)
+}
+
+var tabNewCommand = &cobra.Command{
+	Use:     "new",
+	Short:   "Create a tab (prints the new tab id)",
+	Args:    cobra.NoArgs,
+	RunE:    tabNewRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var tabRenameCommand = &cobra.Command{
+	Use:     "rename {tabid|current} newname",
+	Short:   "Rename a tab",
+	Args:    cobra.ExactArgs(2),
+	RunE:    tabRenameRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var tabCloseCommand = &cobra.Command{
+	Use:   "close {tabid|current}",
+	Short: "Close a tab",
+	Long: `close a tab (and all of its blocks).  if it was the active tab, the tab to its left is activated
+(or the tab to its right if it was the first tab).  pinned tabs are only closed with --force.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    tabCloseRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var tabMoveCommand = &cobra.Command{
+	Use:   "move {tabid|current} index",
+	Short: "Move a tab to a new position",
+	Long: `move a tab to a new position (0 is the first tab).  pinned tabs are always shown first, so the index is
+the position among the pinned tabs for a pinned tab, and among the unpinned tabs otherwise.`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    tabMoveRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var tabTitleCommand = &cobra.Command{
+	Use:   "title [tabid|current] [--template template]",
+	Short: "Show a tab's title, or set its title template",
+	Long: `show a tab's title (defaults to the current tab), or set its title template with --template.  the template's
+variables are filled in from the tab's focused block and the title updates as they change:
+
+  {cwd}       the current directory
+  {conn}      the connection ("local" for local blocks)
+  {cmd}       the running command (cmd blocks)
+  {exitcode}  the exit code of the last command (once it is done)
+  {name}      the tab's name
+
+unknown variables are shown as they are.  a template without braces is a static title (the tab is renamed),
+and --template "" clears the template.`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    tabTitleRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var tabPinCommand = &cobra.Command{
+	Use:     "pin [tabid|current]",
+	Short:   "Pin a tab (pinned tabs are shown first, and aren't closed without --force)",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    tabPinRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var tabUnpinCommand = &cobra.Command{
+	Use:     "unpin [tabid|current]",
+	Short:   "Unpin a tab",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    tabPinRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var tabGroupCommand = &cobra.Command{
+	Use:   "group name [tabid|current]",
+	Short: "Put a tab in a group",
+	Long: `put a tab (defaults to the current tab) in a group.  the tabs in a group are kept next to each other, so the
+tab is moved next to the group's other tabs.  a name of "" removes the tab from its group.`,
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    tabGroupRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+// resolves a tab id ("current" is the current tab)
+func resolveTabArg(arg string) (string, error) {
+	if arg == "current" {
+		arg = "tab"
+	}
+	oref, err := resolveSimpleId(arg)
+	if err != nil {
+		return "", fmt.Errorf("resolving tab: %w", err)
+	}
+	if oref.OType != waveobj.OType_Tab {
+		return "", fmt.Errorf("%q is a %s, not a tab", arg, oref.OType)
+	}
+	return oref.OID, nil
+}
+
+func tabNewRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("tab", rtnErr == nil)
+	}()
+	createData := wshrpc.CommandCreateTabData{
+		TabName:    tabNewName,
+		Pinned:     tabNewPinned,
+		NoActivate: tabNewBackground,
+	}
+	if tabNewWindow != "" {
+		oref, err := resolveSimpleId(tabNewWindow)
+		if err != nil {
+			return fmt.Errorf("resolving window: %w", err)
+		}
+		if oref.OType != waveobj.OType_Window {
+			return fmt.Errorf("%q is not a window", tabNewWindow)
+		}
+		createData.WindowId = oref.OID
+	} else if RpcContext.TabId == "" {
+		return fmt.Errorf("no current window, use --window")
+	}
+	tabId, err := wshclient.CreateTabCommand(RpcClient, createData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("creating tab: %w", err)
+	}
+	WriteStdout("%s\n", tabId)
+	return nil
+}
+
+func tabRenameRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("tab", rtnErr == nil)
+	}()
+	tabId, err := resolveTabArg(args[0])
+	if err != nil {
+		return err
+	}
+	err = wshclient.RenameTabCommand(RpcClient, wshrpc.CommandRenameTabData{TabId: tabId, Name: args[1]}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("renaming tab: %w", err)
+	}
+	WriteStdout("tab renamed\n")
+	return nil
+}
+
+func tabCloseRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("tab", rtnErr == nil)
+	}()
+	tabId, err := resolveTabArg(args[0])
+	if err != nil {
+		return err
+	}
+	err = wshclient.CloseTabCommand(RpcClient, wshrpc.CommandCloseTabData{TabId: tabId, Force: tabCloseForce}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("closing tab: %w", err)
+	}
+	WriteStdout("tab closed\n")
+	return nil
+}
+
+func tabMoveRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("tab", rtnErr == nil)
+	}()
+	tabId, err := resolveTabArg(args[0])
+	if err != nil {
+		return err
+	}
+	index, err := strconv.Atoi(args[1])
+	if err != nil || index < 0 {
+		return fmt.Errorf("invalid index %q (must be a number >= 0)", args[1])
+	}
+	err = wshclient.MoveTabCommand(RpcClient, wshrpc.CommandMoveTabData{TabId: tabId, Index: index}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("moving tab: %w", err)
+	}
+	WriteStdout("tab moved\n")
+	return nil
+}
+
+func tabTitleRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("tab", rtnErr == nil)
+	}()
+	tabArg := "current"
+	if len(args) > 0 {
+		tabArg = args[0]
+	}
+	tabId, err := resolveTabArg(tabArg)
+	if err != nil {
+		return err
+	}
+	if !cmd.Flags().Changed("template") {
+		title, err := wshclient.GetTabTitleCommand(RpcClient, tabId, &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("getting tab title: %w", err)
+		}
+		WriteStdout("%s\n", title)
+		return nil
+	}
+	templateData := wshrpc.CommandSetTabTitleTemplateData{TabId: tabId, Template: tabTitleTemplate}
+	err = wshclient.SetTabTitleTemplateCommand(RpcClient, templateData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting tab title template: %w", err)
+	}
+	WriteStdout("tab title set\n")
+	return nil
+}
+
+func tabPinRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("tab", rtnErr == nil)
+	}()
+	tabArg := "current"
+	if len(args) > 0 {
+		tabArg = args[0]
+	}
+	tabId, err := resolveTabArg(tabArg)
+	if err != nil {
+		return err
+	}
+	pinned := cmd.Name() == "pin"
+	err = wshclient.SetTabPinnedCommand(RpcClient, wshrpc.CommandSetTabPinnedData{TabId: tabId, Pinned: pinned}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting tab pinning: %w", err)
+	}
+	if pinned {
+		WriteStdout("tab pinned\n")
+	} else {
+		WriteStdout("tab unpinned\n")
+	}
+	return nil
+}
+
+func tabGroupRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("tab", rtnErr == nil)
+	}()
+	tabArg := "current"
+	if len(args) > 1 {
+		tabArg = args[1]
+	}
+	tabId, err := resolveTabArg(tabArg)
+	if err != nil {
+		return err
+	}
+	err = wshclient.SetTabGroupCommand(RpcClient, wshrpc.CommandSetTabGroupData{TabId: tabId, GroupName: args[0]}, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting tab group: %w", err)
+	}
+	if args[0] == "" {
+		WriteStdout("tab removed from its group\n")
+	} else {
+		WriteStdout("tab added to group %q\n", args[0])
+	}
+	return nil
+}
@@ -4,11 +4,16 @@
 package cmd
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
@@ -16,52 +21,377 @@ import (
 )
 
 var termMagnified bool
+var termHere bool
+var termLocal bool
+var termSendEnter bool
+var termSendRaw bool
+var termSendStart bool
+var termRestartForce bool
+var termTrimKeep int64
+var termHookClear bool
 
 var termCmd = &cobra.Command{
-	Use:     "term",
-	Short:   "open a terminal in directory",
+	Use:   "term [dir]",
+	Short: "open a terminal in directory",
+	Long: `open a terminal in directory (defaults to the current directory).
+use --here to also start the terminal with the current environment (variables that won't work on a connection, like SSH_AUTH_SOCK, are skipped).
+the terminal uses the current block's connection, use --local to open a local terminal instead.`,
 	Args:    cobra.RangeArgs(0, 1),
 	RunE:    termRun,
 	PreRunE: preRunSetupRpcClient,
 }
 
+var termSendCmd = &cobra.Command{
+	Use:   "send {blockid} text",
+	Short: "send input to a terminal block (as if it were typed)",
+	Long: `send input to a terminal block (as if it were typed).
+use --enter to press enter after the text, and --raw to interpret escape sequences in the text (e.g. \e or \x1b for escape, \r for enter, \x03 for ctrl-c).`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    termSendRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var termResizeCmd = &cobra.Command{
+	Use:     "resize {blockid} cols rows",
+	Short:   "resize the pty of a terminal block",
+	Args:    cobra.ExactArgs(3),
+	RunE:    termResizeRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var termClearCmd = &cobra.Command{
+	Use:     "clear {blockid}",
+	Short:   "clear the scrollback of a terminal block",
+	Args:    cobra.ExactArgs(1),
+	RunE:    termClearRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var termTrimCmd = &cobra.Command{
+	Use:   "trim {blockid} [--keep bytes]",
+	Short: "trim the scrollback of a terminal block",
+	Long: `drop the oldest output of a terminal block, keeping (about) the last --keep bytes (the cut is at a line boundary).
+the terminal keeps showing its current scrollback until it is reloaded.  to limit the scrollback of every block, set
+client:scrollbackbytes or client:scrollbacklines (or term:scrollbackbytes and term:scrollbacklines on a block).`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    termTrimRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var termRestartCmd = &cobra.Command{
+	Use:   "restart {blockid}",
+	Short: "restart the shell (or command) of a terminal block",
+	Long: `restart the shell (or command) of a terminal block with the block's current settings (cwd, connection, cmd), keeping its scrollback.
+if the shell keeps exiting with an error right after it starts, restarts are delayed (up to 30s), and after 5 crashes in a row
+the block is marked failed and isn't restarted.  use --force to restart right away.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    termRestartRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var termOnExitCmd = &cobra.Command{
+	Use:   "onexit {blockid} [--clear] -- command [args...]",
+	Short: "run a command when a terminal block's shell (or command) exits",
+	Long: `run a command (on this machine, with sh -c, or cmd.exe on windows) every time a terminal block's shell (or command) exits.
+the command gets WAVETERM_BLOCKID, WAVETERM_TABID, WAVETERM_HOOK, WAVETERM_EXITCODE, and WAVETERM_DURATIONMS in its environment.
+it's killed if it runs for more than 30s (set cmd:hooktimeoutms on the block to change that).  hooks don't run when wave
+stops the shell (closing or restarting the block).  set client:onexit with setmeta for a hook for every block.
+use --clear to remove the block's hook.`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    termHookRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var termOnFailCmd = &cobra.Command{
+	Use:   "onfail {blockid} [--clear] -- command [args...]",
+	Short: "run a command when a terminal block's shell (or command) exits with an error",
+	Long: `like onexit, but the command only runs when the shell (or command) exits with a non-zero exit code.
+set client:onfail with setmeta for a hook for every block.  use --clear to remove the block's hook.`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    termHookRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
 func init() {
 	termCmd.Flags().BoolVarP(&termMagnified, "magnified", "m", false, "open view in magnified mode")
+	termCmd.Flags().BoolVar(&termHere, "here", false, "open the terminal in the current directory with the current environment")
+	termCmd.Flags().BoolVar(&termLocal, "local", false, "open a local terminal (instead of using the current block's connection)")
+	termSendCmd.Flags().BoolVar(&termSendEnter, "enter", false, "press enter after sending the text")
+	termSendCmd.Flags().BoolVar(&termSendRaw, "raw", false, "interpret escape sequences in the text")
+	termSendCmd.Flags().BoolVar(&termSendStart, "start", false, "start the block's controller if it isn't running")
+	termRestartCmd.Flags().BoolVarP(&termRestartForce, "force", "f", false, "restart right away (even if the shell keeps crashing)")
+	termCmd.AddCommand(termSendCmd)
+	termCmd.AddCommand(termResizeCmd)
+	termTrimCmd.Flags().Int64Var(&termTrimKeep, "keep", 64*1024, "bytes of output to keep")
+	termCmd.AddCommand(termClearCmd)
+	termCmd.AddCommand(termTrimCmd)
+	termCmd.AddCommand(termRestartCmd)
+	termOnExitCmd.Flags().BoolVar(&termHookClear, "clear", false, "remove the hook")
+	termOnFailCmd.Flags().BoolVar(&termHookClear, "clear", false, "remove the hook")
+	termCmd.AddCommand(termOnExitCmd)
+	termCmd.AddCommand(termOnFailCmd)
 	rootCmd.AddCommand(termCmd)
 }
 
-func termRun(cmd *cobra.Command, args []string) (rtnErr error) {
+func resolveTermBlockArg(blockArg string) (string, error) {
+	blockORef, err := resolveSimpleId(blockArg)
+	if err != nil {
+		return "", fmt.Errorf("resolving block id: %w", err)
+	}
+	if blockORef.OType != waveobj.OType_Block {
+		return "", fmt.Errorf("%q is not a block", blockArg)
+	}
+	return blockORef.OID, nil
+}
+
+// interprets go-style escapes (\n, \r, \t, \xNN, \uNNNN, \NNN octal) plus \e for escape
+func parseTermEscapes(text string) (string, error) {
+	var buf strings.Builder
+	for len(text) > 0 {
+		if strings.HasPrefix(text, `\e`) {
+			buf.WriteByte(0x1b)
+			text = text[2:]
+			continue
+		}
+		value, multibyte, tail, err := strconv.UnquoteChar(text, 0)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape sequence in %q", text)
+		}
+		if value < 0x100 && !multibyte {
+			buf.WriteByte(byte(value))
+		} else {
+			buf.WriteRune(value)
+		}
+		text = tail
+	}
+	return buf.String(), nil
+}
+
+func termSendRun(cmd *cobra.Command, args []string) (rtnErr error) {
 	defer func() {
-		sendActivity("term", rtnErr == nil)
+		sendActivity("term:send", rtnErr == nil)
+	}()
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	text := args[1]
+	if termSendRaw {
+		text, err = parseTermEscapes(text)
+		if err != nil {
+			return err
+		}
+	}
+	if termSendEnter {
+		text += "\r"
+	}
+	sendData := wshrpc.CommandTermSendData{
+		BlockId: blockId,
+		Data64:  base64.StdEncoding.EncodeToString([]byte(text)),
+		Start:   termSendStart,
+	}
+	err = wshclient.TermSendCommand(RpcClient, sendData, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("sending input: %w", err)
+	}
+	return nil
+}
+
+func termResizeRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("term:resize", rtnErr == nil)
+	}()
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	cols, err := strconv.Atoi(args[1])
+	if err != nil || cols <= 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid cols %q", args[1])
+	}
+	rows, err := strconv.Atoi(args[2])
+	if err != nil || rows <= 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("invalid rows %q", args[2])
+	}
+	resizeData := wshrpc.CommandTermResizeData{
+		BlockId:  blockId,
+		TermSize: waveobj.TermSize{Rows: rows, Cols: cols},
+	}
+	err = wshclient.TermResizeCommand(RpcClient, resizeData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("resizing terminal: %w", err)
+	}
+	return nil
+}
+
+func termClearRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("term:clear", rtnErr == nil)
 	}()
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	err = wshclient.TermClearCommand(RpcClient, blockId, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("clearing terminal: %w", err)
+	}
+	return nil
+}
+
+func termTrimRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("term:trim", rtnErr == nil)
+	}()
+	if termTrimKeep < 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--keep must be non-negative")
+	}
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	trimData := wshrpc.CommandTermTrimData{BlockId: blockId, KeepBytes: termTrimKeep}
+	scrollbackSize, err := wshclient.TermTrimCommand(RpcClient, trimData, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("trimming scrollback: %w", err)
+	}
+	WriteStdout("scrollback trimmed to %d bytes\n", scrollbackSize)
+	return nil
+}
+
+func termRestartRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("term:restart", rtnErr == nil)
+	}()
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	restartData := wshrpc.CommandControllerRestartData{BlockId: blockId, Force: termRestartForce}
+	// the restart can wait for the crash backoff (max 30s) before starting the shell
+	err = wshclient.ControllerRestartCommand(RpcClient, restartData, &wshrpc.RpcOpts{Timeout: 40000})
+	if err != nil {
+		return fmt.Errorf("restarting terminal: %w", err)
+	}
+	WriteStdout("terminal restarted\n")
+	return nil
+}
+
+// one arg is a shell command (e.g. "notify-send 'build done'"), more args are quoted so each one is passed as it is
+func makeTermHookCmd(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, utilfn.ShellQuote(arg, false, -1))
+	}
+	return strings.Join(quoted, " ")
+}
+
+func termHookRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	hookName := cmd.Name()
+	defer func() {
+		sendActivity("term:"+hookName, rtnErr == nil)
+	}()
+	metaKey := waveobj.MetaKey_CmdOnExit
+	if hookName == "onfail" {
+		metaKey = waveobj.MetaKey_CmdOnFail
+	}
+	if termHookClear && len(args) > 1 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("cannot specify a command with --clear")
+	}
+	if !termHookClear && len(args) < 2 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("no command given (use --clear to remove the hook)")
+	}
+	blockId, err := resolveTermBlockArg(args[0])
+	if err != nil {
+		return err
+	}
+	var hookCmd any
+	if !termHookClear {
+		hookCmd = makeTermHookCmd(args[1:])
+	}
+	setMetaData := wshrpc.CommandSetMetaData{
+		ORef: waveobj.MakeORef(waveobj.OType_Block, blockId),
+		Meta: map[string]any{metaKey: hookCmd},
+	}
+	err = wshclient.SetMetaCommand(RpcClient, setMetaData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting %s hook: %w", hookName, err)
+	}
+	if termHookClear {
+		WriteStdout("%s hook removed\n", hookName)
+	} else {
+		WriteStdout("%s hook set\n", hookName)
+	}
+	return nil
+}
 
+// the cwd for a new terminal.  when a local terminal is opened from a remote block (switchedConn), the current
+// directory is on the remote, so the terminal starts in the home directory (a dir argument is passed as is).
+func getTermCwd(args []string, switchedConn bool) (string, error) {
+	if switchedConn {
+		if len(args) > 0 {
+			return args[0], nil
+		}
+		return "", nil
+	}
 	var cwd string
 	if len(args) > 0 {
-		cwd = args[0]
-		cwdExpanded, err := wavebase.ExpandHomeDir(cwd)
+		cwdExpanded, err := wavebase.ExpandHomeDir(args[0])
 		if err != nil {
-			return err
+			return "", err
 		}
 		cwd = cwdExpanded
 	} else {
 		var err error
 		cwd, err = os.Getwd()
 		if err != nil {
-			return fmt.Errorf("getting current directory: %w", err)
+			return "", fmt.Errorf("getting current directory: %w", err)
 		}
 	}
-	var err error
-	cwd, err = filepath.Abs(cwd)
+	cwd, err := filepath.Abs(cwd)
 	if err != nil {
-		return fmt.Errorf("getting absolute path: %w", err)
+		return "", fmt.Errorf("getting absolute path: %w", err)
+	}
+	return cwd, nil
+}
+
+func termRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("term", rtnErr == nil)
+	}()
+
+	if termHere && len(args) > 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("cannot specify a directory with --here")
+	}
+	conn := RpcContext.Conn
+	if termLocal {
+		conn = ""
+	}
+	if termHere && conn != RpcContext.Conn {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("cannot use --here with --local from a remote block")
+	}
+	cwd, err := getTermCwd(args, conn != RpcContext.Conn)
+	if err != nil {
+		return err
 	}
 	createMeta := map[string]any{
 		waveobj.MetaKey_View:       "term",
-		waveobj.MetaKey_CmdCwd:     cwd,
 		waveobj.MetaKey_Controller: "shell",
+		// always set, so the block doesn't get the tab's default connection (the cwd is for this connection)
+		waveobj.MetaKey_Connection: conn,
 	}
-	if RpcContext.Conn != "" {
-		createMeta[waveobj.MetaKey_Connection] = RpcContext.Conn
+	if cwd != "" {
+		createMeta[waveobj.MetaKey_CmdCwd] = cwd
 	}
 	createBlockData := wshrpc.CommandCreateBlockData{
 		BlockDef: &waveobj.BlockDef{
@@ -69,10 +399,18 @@ func termRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		},
 		Magnified: termMagnified,
 	}
-	oref, err := wshclient.CreateBlockCommand(RpcClient, createBlockData, nil)
+	if termHere {
+		envMap := getInheritEnv(os.Environ(), conn)
+		createBlockData.BlockDef.Files = map[string]*waveobj.FileDef{
+			"env": {
+				Content: envutil.MapToEnv(envMap),
+			},
+		}
+	}
+	rtnData, err := wshclient.CreateBlockCommand(RpcClient, createBlockData, nil)
 	if err != nil {
 		return fmt.Errorf("creating new terminal block: %w", err)
 	}
-	WriteStdout("terminal block created: %s\n", oref)
+	WriteStdout("terminal block created: %s\n", rtnData.BlockORef)
 	return nil
 }
@@ -4,58 +4,119 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"mime"
+	"net/http"
 	"os"
+	"os/user"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
 )
 
 var viewMagnified bool
+var viewNewTab bool
+var viewTabName string
+var viewTabId string
+var viewPosition string
+var editWait bool
+var editWaitTimeout int
+var viewStdinMime string
+var viewStdinLang string
+var viewStdinMaxSize int64
+var viewWaitCreate string
+var viewNoExpand bool
+var viewQuiet bool
+var viewJson bool
+var viewLocal bool
+var viewConn string
+var viewNew bool
+
+const DefaultViewStdinMaxSize = 5 * 1024 * 1024
+
+// maps common language names to a file extension (so the preview picks the right mode)
+var viewLangExtMap = map[string]string{
+	"bash":       ".sh",
+	"golang":     ".go",
+	"javascript": ".js",
+	"markdown":   ".md",
+	"python":     ".py",
+	"ruby":       ".rb",
+	"rust":       ".rs",
+	"shell":      ".sh",
+	"text":       ".txt",
+	"typescript": ".ts",
+}
 
 var viewCmd = &cobra.Command{
-	Use:     "view {file|directory|URL}",
-	Short:   "preview/edit a file or directory",
+	Use:     "view {file|directory|URL|-}...",
+	Short:   "preview/edit files, directories, or URLs",
 	RunE:    viewRun,
 	PreRunE: preRunSetupRpcClient,
 }
 
 var editCmd = &cobra.Command{
-	Use:     "edit {file}",
-	Short:   "edit a file",
+	Use:     "edit {file}...",
+	Short:   "edit files",
 	RunE:    viewRun,
 	PreRunE: preRunSetupRpcClient,
 }
 
 func init() {
-	viewCmd.Flags().BoolVarP(&viewMagnified, "magnified", "m", false, "open view in magnified mode")
-	rootCmd.AddCommand(viewCmd)
-	editCmd.Flags().BoolVarP(&viewMagnified, "magnified", "m", false, "open view in magnified mode")
-	rootCmd.AddCommand(editCmd)
+	for _, cmd := range []*cobra.Command{viewCmd, editCmd} {
+		cmd.Flags().BoolVarP(&viewMagnified, "magnified", "m", false, "open view in magnified mode (single argument only)")
+		cmd.Flags().BoolVar(&viewNewTab, "new-tab", false, "open the block(s) in a new tab")
+		cmd.Flags().StringVar(&viewTabName, "tab-name", "", "name for the new tab (requires --new-tab)")
+		cmd.Flags().StringVar(&viewTabId, "tab", "", "open the block(s) in the given tab (defaults to the current tab)")
+		cmd.Flags().StringVar(&viewPosition, "position", "", blockPositionFlagHelp)
+		cmd.Flags().StringVar(&viewStdinMime, "mime", "", "for stdin (-), the mimetype of the content (default is to guess)")
+		cmd.Flags().StringVar(&viewStdinLang, "lang", "", "for stdin (-), the language of the content, e.g. json, python (default is to guess)")
+		cmd.Flags().Int64Var(&viewStdinMaxSize, "max-size", DefaultViewStdinMaxSize, "for stdin (-), max number of bytes to read")
+		cmd.Flags().StringVar(&viewWaitCreate, "wait-create", "", "if the file doesn't exist yet, open the block and wait for it to be created (optional timeout, e.g. --wait-create=5m)")
+		cmd.Flags().Lookup("wait-create").NoOptDefVal = "0"
+		cmd.Flags().BoolVarP(&viewQuiet, "quiet", "q", false, "don't print the ids of the new tab and blocks")
+		cmd.Flags().BoolVar(&viewJson, "json", false, "print a json object for each new block (one per line, with blockid, tabid, windowid)")
+		cmd.Flags().BoolVar(&viewLocal, "local", false, "open local files (instead of files on the current block's connection), from a remote block the paths must be absolute")
+		cmd.Flags().StringVar(&viewConn, "conn", "", "open files on the given connection (e.g. a host from ~/.ssh/config), the paths must be absolute")
+		cmd.Flags().BoolVar(&viewNoExpand, "no-expand", false, "don't expand ~ and $VAR in the arguments (for paths that contain them literally)")
+		cmd.Flags().BoolVar(&viewNew, "new", false, "always open a new block (by default a block in the tab that already shows the file is focused instead)")
+		rootCmd.AddCommand(cmd)
+	}
+	editCmd.Flags().BoolVarP(&editWait, "wait", "w", false, "wait until the editor block(s) are closed before exiting (for use as $EDITOR)")
+	editCmd.Flags().IntVar(&editWaitTimeout, "timeout", 0, "with --wait, max seconds to wait for the editor to close (0 waits forever)")
 }
 
-func viewRun(cmd *cobra.Command, args []string) (rtnErr error) {
-	cmdName := cmd.Name()
-	defer func() {
-		sendActivity(cmdName, rtnErr == nil)
-	}()
-	if len(args) == 0 {
-		OutputHelpMessage(cmd)
-		return fmt.Errorf("no arguments.  wsh %s requires a file or URL as an argument argument", cmdName)
-	}
-	if len(args) > 1 {
-		OutputHelpMessage(cmd)
-		return fmt.Errorf("too many arguments.  wsh %s requires exactly one argument", cmdName)
+func makeViewBlockData(cmdName string, fileArg string, tabId string, position string) (*wshrpc.CommandCreateBlockData, error) {
+	isTemp := false
+	if fileArg == "-" {
+		tempFile, err := writeStdinToTempFile()
+		if err != nil {
+			return nil, err
+		}
+		fileArg = tempFile
+		isTemp = true
 	}
-	fileArg := args[0]
-	conn := RpcContext.Conn
-	var wshCmd *wshrpc.CommandCreateBlockData
 	if strings.HasPrefix(fileArg, "http://") || strings.HasPrefix(fileArg, "https://") {
-		wshCmd = &wshrpc.CommandCreateBlockData{
+		return &wshrpc.CommandCreateBlockData{
+			TabId: tabId,
 			BlockDef: &waveobj.BlockDef{
 				Meta: map[string]any{
 					waveobj.MetaKey_View: "web",
@@ -63,42 +124,502 @@ func viewRun(cmd *cobra.Command, args []string) (rtnErr error) {
 				},
 			},
 			Magnified: viewMagnified,
+			Position:  position,
+		}, nil
+	}
+	// the connection the block reads from, and the one the path is checked on ("" is the filesystem wsh is running on)
+	conn := RpcContext.Conn
+	if !isTemp {
+		conn = getViewTargetConn()
+	}
+	fileConn := conn
+	var absFile string
+	var err error
+	switch {
+	case conn != RpcContext.Conn:
+		// --conn, or --local from a remote block.  there is no cwd on the other connection, and the path is checked
+		// through that connection (the local connection when wsh is running on a remote)
+		fileConn = conn
+		if conn == "" {
+			fileConn = wshrpc.LocalConnName
+		}
+		if !path.IsAbs(fileArg) && fileArg != "~" && !strings.HasPrefix(fileArg, "~/") {
+			return nil, fmt.Errorf("the path must be absolute (or start with ~) for a file on another connection")
+		}
+		absFile, err = resolveRemoteViewFile(fileConn, fileArg)
+	case conn != "" && !isTemp:
+		absFile, err = resolveRemoteViewFile(conn, fileArg)
+	default:
+		fileConn = ""
+		absFile, err = resolveLocalViewFile(fileArg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var waitCreate bool
+	if viewWaitCreate != "" && !isTemp {
+		waitCreate, err = viewFileIsMissing(fileConn, absFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	wshCmd := &wshrpc.CommandCreateBlockData{
+		TabId: tabId,
+		BlockDef: &waveobj.BlockDef{
+			Meta: map[string]interface{}{
+				waveobj.MetaKey_View: "preview",
+				waveobj.MetaKey_File: absFile,
+			},
+		},
+		Magnified: viewMagnified,
+		Position:  position,
+		// two blocks on the same file would overwrite each other's saves
+		ReuseExisting: !viewNew && !isTemp,
+	}
+	if cmdName == "edit" {
+		wshCmd.BlockDef.Meta[waveobj.MetaKey_Edit] = true
+	}
+	if isTemp {
+		wshCmd.BlockDef.Meta[waveobj.MetaKey_FileTemp] = true
+	}
+	if waitCreate {
+		timeout, _ := parseWaitCreateTimeout(viewWaitCreate)
+		wshCmd.BlockDef.Meta[waveobj.MetaKey_FileWaitCreate] = true
+		if timeout > 0 {
+			wshCmd.BlockDef.Meta[waveobj.MetaKey_FileWaitCreateUntil] = time.Now().Add(timeout).UnixMilli()
+		}
+	}
+	// always set, so the block doesn't get the tab's default connection (the path was resolved for this connection)
+	wshCmd.BlockDef.Meta[waveobj.MetaKey_Connection] = conn
+	return wshCmd, nil
+}
+
+// the connection the files are opened on ("" is local), from --conn or --local (or the current block's connection)
+func getViewTargetConn() string {
+	switch {
+	case viewLocal || viewConn == wshrpc.LocalConnName:
+		return ""
+	case viewConn != "":
+		return viewConn
+	default:
+		return RpcContext.Conn
+	}
+}
+
+func resolveLocalViewFile(fileArg string) (string, error) {
+	absFile, err := filepath.Abs(fileArg)
+	if err != nil {
+		return "", fmt.Errorf("getting absolute path: %w", err)
+	}
+	absParent, err := filepath.Abs(filepath.Dir(fileArg))
+	if err != nil {
+		return "", fmt.Errorf("getting absolute path of parent dir: %w", err)
+	}
+	_, err = os.Stat(absParent)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", cmdErrorf(errCatNotFound, "parent directory does not exist: %q", absParent)
+	}
+	if err != nil {
+		return "", fmt.Errorf("getting file info: %w", err)
+	}
+	return absFile, nil
+}
+
+// expands a leading ~ (or ~user) and $VAR / ${VAR} in a file argument (for arguments the shell didn't expand,
+// e.g. because they were quoted).  undefined variables are an error.  on a connection "~" and "~/" are left for
+// the remote resolution (wsh is running on the remote, so ~user is looked up there).
+func expandViewFileArg(fileArg string, isRemote bool) (string, error) {
+	if fileArg == "-" || strings.HasPrefix(fileArg, "http://") || strings.HasPrefix(fileArg, "https://") {
+		return fileArg, nil
+	}
+	var expandErr error
+	fileArg = os.Expand(fileArg, func(varName string) string {
+		val, ok := os.LookupEnv(varName)
+		if !ok && expandErr == nil {
+			expandErr = fmt.Errorf("environment variable $%s is not set (use --no-expand to open the path as is)", varName)
+		}
+		return val
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	if !strings.HasPrefix(fileArg, "~") {
+		return fileArg, nil
+	}
+	userName, rest, _ := strings.Cut(fileArg[1:], "/")
+	var homeDir string
+	if userName == "" {
+		if isRemote {
+			return fileArg, nil
+		}
+		var err error
+		homeDir, err = os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("getting home directory: %w", err)
 		}
 	} else {
-		absFile, err := filepath.Abs(fileArg)
+		lookupUser, err := user.Lookup(userName)
 		if err != nil {
-			return fmt.Errorf("getting absolute path: %w", err)
+			return "", fmt.Errorf("unknown user %q in %q", userName, fileArg)
 		}
-		absParent, err := filepath.Abs(filepath.Dir(fileArg))
+		homeDir = lookupUser.HomeDir
+	}
+	if rest == "" {
+		return homeDir, nil
+	}
+	return filepath.Join(homeDir, rest), nil
+}
+
+// the --wait-create timeout is a duration (e.g. 30s, 5m) or a number of seconds, 0 is no timeout
+func parseWaitCreateTimeout(timeoutStr string) (time.Duration, error) {
+	if secs, err := strconv.Atoi(timeoutStr); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid --wait-create timeout %q (e.g. 30s, 5m)", timeoutStr)
+	}
+	return timeout, nil
+}
+
+// conn is the connection to check the file on ("" checks the filesystem wsh is running on)
+func viewFileIsMissing(conn string, absFile string) (bool, error) {
+	if conn != "" {
+		finfo, err := remoteFileInfoFn(conn, absFile)
 		if err != nil {
-			return fmt.Errorf("getting absolute path of parent dir: %w", err)
+			return false, fmt.Errorf("getting file info on %s: %w", conn, err)
 		}
-		_, err = os.Stat(absParent)
-		if err == fs.ErrNotExist {
-			return fmt.Errorf("parent directory does not exist: %q", absParent)
+		return finfo.NotFound, nil
+	}
+	_, err := os.Stat(absFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("getting file info: %w", err)
+	}
+	return false, nil
+}
+
+// stats a path on the connection (through the connection's file service).  replaced in tests.
+var remoteFileInfoFn = func(conn string, filePath string) (*wshrpc.FileInfo, error) {
+	return wshclient.RemoteFileInfoCommand(RpcClient, filePath, &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(conn), Timeout: 5000})
+}
+
+// for a block on a connection the local filesystem is the wrong one, so the path is made absolute against the
+// block's cwd on the remote and its parent is checked on the remote.  "~" is left in the path (it is expanded
+// against the remote home directory by the connection).
+func resolveRemoteViewFile(conn string, fileArg string) (string, error) {
+	remoteFile := makeRemoteAbsPath(fileArg)
+	remoteParent := path.Dir(remoteFile)
+	if remoteFile == "~" {
+		remoteParent = remoteFile
+	}
+	finfo, err := remoteFileInfoFn(conn, remoteParent)
+	if err != nil {
+		return "", fmt.Errorf("getting file info on %s: %w", conn, err)
+	}
+	if finfo.NotFound {
+		return "", cmdErrorf(errCatNotFound, "parent directory does not exist on %s: %q", conn, remoteParent)
+	}
+	if !finfo.IsDir {
+		return "", fmt.Errorf("parent is not a directory on %s: %q", conn, remoteParent)
+	}
+	return remoteFile, nil
+}
+
+// makes a path absolute against the block's cwd on the remote (paths starting with "~" are left as is)
+func makeRemoteAbsPath(fileArg string) string {
+	remoteFile := path.Clean(fileArg)
+	if !path.IsAbs(remoteFile) && remoteFile != "~" && !strings.HasPrefix(remoteFile, "~/") {
+		remoteFile = path.Join(getRemoteViewCwd(), remoteFile)
+	}
+	return remoteFile
+}
+
+// the block's cwd on the remote (from the shell integration), falls back to the cwd of wsh (which is the remote
+// cwd when wsh was started in the block's shell)
+func getRemoteViewCwd() string {
+	if RpcContext.BlockId != "" {
+		meta, err := getThisBlockMeta()
+		if err == nil {
+			if cwd := meta.GetString(waveobj.MetaKey_CmdCwd, ""); path.IsAbs(cwd) || strings.HasPrefix(cwd, "~") {
+				return cwd
+			}
 		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "~"
+	}
+	return filepath.ToSlash(cwd)
+}
+
+// reads stdin and writes it to a temp file (removed by the backend when the block is closed)
+func writeStdinToTempFile() (string, error) {
+	if viewStdinMaxSize <= 0 {
+		return "", fmt.Errorf("invalid --max-size %d", viewStdinMaxSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(WrappedStdin, viewStdinMaxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("reading stdin: %w", err)
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("no data received on stdin")
+	}
+	if int64(len(data)) > viewStdinMaxSize {
+		return "", fmt.Errorf("stdin exceeds max size of %d bytes (use --max-size to increase)", viewStdinMaxSize)
+	}
+	ext, err := getStdinFileExt(data)
+	if err != nil {
+		return "", err
+	}
+	fd, err := os.CreateTemp("", wshrpc.WshTempFilePrefix+"*"+ext)
+	if err != nil {
+		return "", fmt.Errorf("creating temp file: %w", err)
+	}
+	defer fd.Close()
+	_, err = fd.Write(data)
+	if err != nil {
+		os.Remove(fd.Name())
+		return "", fmt.Errorf("writing temp file: %w", err)
+	}
+	return fd.Name(), nil
+}
+
+// the preview block determines the mimetype from the file extension
+func getStdinFileExt(data []byte) (string, error) {
+	if viewStdinLang != "" {
+		lang := strings.ToLower(viewStdinLang)
+		if ext, ok := viewLangExtMap[lang]; ok {
+			return ext, nil
+		}
+		return "." + strings.TrimPrefix(lang, "."), nil
+	}
+	if viewStdinMime != "" {
+		return getExtForMimeType(viewStdinMime)
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) != -1 {
+		return ".bin", nil
+	}
+	if json.Valid(data) {
+		return ".json", nil
+	}
+	mimeType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if mimeType == "text/html" {
+		return ".html", nil
+	}
+	return ".txt", nil
+}
+
+func getExtForMimeType(mimeType string) (string, error) {
+	var exts []string
+	for ext, extMimeType := range utilfn.StaticMimeTypeMap {
+		if extMimeType == mimeType {
+			exts = append(exts, ext)
+		}
+	}
+	if len(exts) == 0 {
+		exts, _ = mime.ExtensionsByType(mimeType)
+	}
+	if len(exts) == 0 {
+		return "", fmt.Errorf("unknown mimetype %q", mimeType)
+	}
+	sort.Strings(exts)
+	return exts[0], nil
+}
+
+func viewOpenArg(cmdName string, fileArg string, tabId string, position string) (*wshrpc.CommandCreateBlockRtnData, error) {
+	wshCmd, err := makeViewBlockData(cmdName, fileArg, tabId, position)
+	if err != nil {
+		return nil, err
+	}
+	rtnData, err := wshclient.CreateBlockCommand(RpcClient, *wshCmd, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return nil, fmt.Errorf("running %s command: %w", cmdName, err)
+	}
+	if wshCmd.BlockDef.Meta.GetBool(waveobj.MetaKey_FileWaitCreate, false) {
+		// the preview also starts the watch when it is shown, this starts it for blocks in background tabs
+		err = wshclient.WatchFileCreateCommand(RpcClient, rtnData.BlockORef.OID, &wshrpc.RpcOpts{Timeout: 2000})
 		if err != nil {
-			return fmt.Errorf("getting file info: %w", err)
+			WriteStderr("[warning] %s: waiting for the file to be created: %v\n", fileArg, err)
 		}
-		wshCmd = &wshrpc.CommandCreateBlockData{
-			BlockDef: &waveobj.BlockDef{
-				Meta: map[string]interface{}{
-					waveobj.MetaKey_View: "preview",
-					waveobj.MetaKey_File: absFile,
-				},
-			},
-			Magnified: viewMagnified,
+	}
+	return &rtnData, nil
+}
+
+func viewRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	cmdName := cmd.Name()
+	defer func() {
+		sendActivity(cmdName, rtnErr == nil)
+	}()
+	if len(args) == 0 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("no arguments.  wsh %s requires at least one file or URL as an argument", cmdName)
+	}
+	if viewMagnified && len(args) > 1 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--magnified can only be used with a single argument")
+	}
+	stdinCount := 0
+	for _, arg := range args {
+		if arg == "-" {
+			stdinCount++
 		}
-		if cmdName == "edit" {
-			wshCmd.BlockDef.Meta[waveobj.MetaKey_Edit] = true
+	}
+	if stdinCount > 1 {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("stdin (-) can only be used once")
+	}
+	if viewTabName != "" && !viewNewTab {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--tab-name requires --new-tab")
+	}
+	if viewTabId != "" && viewNewTab {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--tab and --new-tab cannot be used together")
+	}
+	if viewQuiet && viewJson {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--quiet and --json cannot be used together")
+	}
+	if _, err := parseWaitCreateTimeout(viewWaitCreate); viewWaitCreate != "" && err != nil {
+		OutputHelpMessage(cmd)
+		return err
+	}
+	if viewLocal && viewConn != "" {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("--local and --conn cannot be used together")
+	}
+	if viewConn != "" && viewConn != wshrpc.LocalConnName {
+		if err := validateConnectionName(viewConn); err != nil {
+			return err
 		}
-		if conn != "" {
-			wshCmd.BlockDef.Meta[waveobj.MetaKey_Connection] = conn
+		// connects if needed (the connection can be a host that is only in ~/.ssh/config)
+		data := wshrpc.CommandConnConnectInteractiveData{ConnName: viewConn, LogBlockId: RpcContext.BlockId, Ensure: true}
+		err := connectInteractive(data)
+		if err != nil {
+			return fmt.Errorf("connecting to %s: %w", viewConn, err)
 		}
 	}
-	_, err := RpcClient.SendRpcRequest(wshrpc.Command_CreateBlock, wshCmd, &wshrpc.RpcOpts{Timeout: 2000})
+	targetTabId, position, err := resolveBlockPlacementArgs(viewTabId, viewPosition)
 	if err != nil {
-		return fmt.Errorf("running view command: %w", err)
+		return err
+	}
+	if viewNewTab {
+		tabId, err := wshclient.CreateTabCommand(RpcClient, wshrpc.CommandCreateTabData{TabName: viewTabName}, &wshrpc.RpcOpts{Timeout: 2000})
+		if err != nil {
+			return fmt.Errorf("creating new tab: %w", err)
+		}
+		targetTabId = tabId
+		if !viewQuiet && !viewJson {
+			WriteStdout("%s\n", tabId)
+		}
+	}
+	var closeWaiter *blockCloseWaiter
+	if cmdName == "edit" && editWait {
+		closeWaiter, err = startBlockCloseWaiter()
+		if err != nil {
+			return err
+		}
+	}
+	var numFailed int
+	var blockRefs []*waveobj.ORef
+	for _, fileArg := range args {
+		openArg := fileArg
+		if !viewNoExpand {
+			openArg, err = expandViewFileArg(fileArg, RpcContext.Conn != "" || getViewTargetConn() != "")
+			if err != nil {
+				numFailed++
+				WriteStderr("[error] %s: %v\n", fileArg, err)
+				continue
+			}
+		}
+		rtnData, err := viewOpenArg(cmdName, openArg, targetTabId, position)
+		if err != nil {
+			numFailed++
+			WriteStderr("[error] %s: %v\n", fileArg, err)
+			continue
+		}
+		if viewJson {
+			outBArr, err := json.Marshal(rtnData)
+			if err != nil {
+				return fmt.Errorf("formatting output: %w", err)
+			}
+			WriteStdout("%s\n", string(outBArr))
+		} else if !viewQuiet {
+			WriteStdout("%s\n", rtnData.BlockId)
+		}
+		blockRefs = append(blockRefs, &rtnData.BlockORef)
+	}
+	if numFailed == len(args) {
+		return fmt.Errorf("unable to open any of the %d argument(s)", len(args))
+	}
+	if closeWaiter != nil {
+		return closeWaiter.wait(blockRefs, time.Duration(editWaitTimeout)*time.Second)
 	}
 	return nil
 }
+
+// waits for blocks to be closed.  it subscribes to the block close events before the blocks are created (their ids
+// aren't known yet), so a block that is closed right after it is created isn't missed.
+type blockCloseWaiter struct {
+	lock    sync.Mutex
+	closed  map[string]bool // the blocks closed before wait is called
+	pending map[string]bool // set by wait
+	doneCh  chan struct{}
+}
+
+func startBlockCloseWaiter() (*blockCloseWaiter, error) {
+	waiter := &blockCloseWaiter{closed: make(map[string]bool), doneCh: make(chan struct{})}
+	RpcClient.EventListener.On(wps.Event_BlockClose, waiter.handleEvent)
+	err := wshclient.EventSubCommand(RpcClient, wps.SubscriptionRequest{Event: wps.Event_BlockClose, AllScopes: true}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("subscribing to block close events: %w", err)
+	}
+	return waiter, nil
+}
+
+func (waiter *blockCloseWaiter) handleEvent(event *wps.WaveEvent) {
+	waiter.lock.Lock()
+	defer waiter.lock.Unlock()
+	for _, scope := range event.Scopes {
+		if waiter.pending == nil {
+			waiter.closed[scope] = true
+			continue
+		}
+		if !waiter.pending[scope] {
+			continue
+		}
+		delete(waiter.pending, scope)
+		if len(waiter.pending) == 0 {
+			close(waiter.doneCh)
+		}
+	}
+}
+
+// blocks until all of the given blocks have been closed (or the timeout expires, timeout <= 0 means wait forever)
+func (waiter *blockCloseWaiter) wait(blockRefs []*waveobj.ORef, timeout time.Duration) error {
+	waiter.lock.Lock()
+	waiter.pending = make(map[string]bool)
+	for _, blockRef := range blockRefs {
+		if !waiter.closed[blockRef.String()] {
+			waiter.pending[blockRef.String()] = true
+		}
+	}
+	waiter.closed = nil
+	if len(waiter.pending) == 0 {
+		close(waiter.doneCh)
+	}
+	waiter.lock.Unlock()
+	if timeout <= 0 {
+		<-waiter.doneCh
+		return nil
+	}
+	select {
+	case <-waiter.doneCh:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out waiting for editor to close")
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const testRemoteConn = "user@remotehost"
+
+// fakes a remote filesystem with the given directories (nothing here exists locally)
+func setTestRemoteDirs(t *testing.T, dirs ...string) {
+	origFn := remoteFileInfoFn
+	origCtx := RpcContext
+	t.Cleanup(func() {
+		remoteFileInfoFn = origFn
+		RpcContext = origCtx
+	})
+	RpcContext = wshrpc.RpcContext{Conn: testRemoteConn}
+	remoteFileInfoFn = func(conn string, filePath string) (*wshrpc.FileInfo, error) {
+		if conn != testRemoteConn {
+			t.Fatalf("stat sent to connection %q", conn)
+		}
+		for _, dir := range dirs {
+			if filePath == dir {
+				return &wshrpc.FileInfo{Path: filePath, IsDir: true}, nil
+			}
+		}
+		return &wshrpc.FileInfo{Path: filePath, NotFound: true}, nil
+	}
+}
+
+func TestResolveRemoteViewFile(t *testing.T) {
+	localCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("error getting cwd: %v", err)
+	}
+	localCwd = filepath.ToSlash(localCwd)
+	setTestRemoteDirs(t, "/srv/remote-only", "/srv/remote-only/logs", "~/remote-only", "~", path.Join(localCwd, "remote-only"))
+
+	tests := []struct {
+		name     string
+		fileArg  string
+		expected string
+		errMsg   string
+	}{
+		{name: "absolute", fileArg: "/srv/remote-only/logs/app.log", expected: "/srv/remote-only/logs/app.log"},
+		{name: "absolute dir", fileArg: "/srv/remote-only/logs/", expected: "/srv/remote-only/logs"},
+		{name: "tilde", fileArg: "~/remote-only/notes.md", expected: "~/remote-only/notes.md"},
+		{name: "home", fileArg: "~", expected: "~"},
+		{name: "relative", fileArg: "./remote-only/app.log", expected: path.Join(localCwd, "remote-only/app.log")},
+		{name: "missing parent", fileArg: "/srv/missing/app.log", errMsg: "parent directory does not exist on " + testRemoteConn},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := os.Stat(tc.fileArg); err == nil {
+				t.Fatalf("test path %q should not exist locally", tc.fileArg)
+			}
+			rtn, err := resolveRemoteViewFile(testRemoteConn, tc.fileArg)
+			if tc.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+					t.Fatalf("expected error %q, got %v", tc.errMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if rtn != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, rtn)
+			}
+		})
+	}
+}
+
+func TestMakeViewBlockDataRemote(t *testing.T) {
+	setTestRemoteDirs(t, "/srv/remote-only")
+	wshCmd, err := makeViewBlockData("view", "/srv/remote-only/app.log", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	meta := waveobj.MetaMapType(wshCmd.BlockDef.Meta)
+	if file := meta.GetString(waveobj.MetaKey_File, ""); file != "/srv/remote-only/app.log" {
+		t.Errorf("expected file %q, got %q", "/srv/remote-only/app.log", file)
+	}
+	if conn := meta.GetString(waveobj.MetaKey_Connection, ""); conn != testRemoteConn {
+		t.Errorf("expected connection %q, got %q", testRemoteConn, conn)
+	}
+}
+
+func TestMakeViewBlockDataWaitCreate(t *testing.T) {
+	setTestRemoteDirs(t, "/srv", "/srv/remote-only")
+	origWaitCreate := viewWaitCreate
+	t.Cleanup(func() { viewWaitCreate = origWaitCreate })
+	viewWaitCreate = "5m"
+	wshCmd, err := makeViewBlockData("view", "/srv/remote-only/build.log", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	meta := waveobj.MetaMapType(wshCmd.BlockDef.Meta)
+	if !meta.GetBool(waveobj.MetaKey_FileWaitCreate, false) {
+		t.Errorf("expected file:waitcreate to be set for a missing file")
+	}
+	if _, ok := meta[waveobj.MetaKey_FileWaitCreateUntil]; !ok {
+		t.Errorf("expected file:waitcreateuntil to be set with a timeout")
+	}
+	wshCmd, err = makeViewBlockData("view", "/srv/remote-only", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if waveobj.MetaMapType(wshCmd.BlockDef.Meta).GetBool(waveobj.MetaKey_FileWaitCreate, false) {
+		t.Errorf("file:waitcreate should not be set for an existing path")
+	}
+	for _, timeoutStr := range []string{"0", "30", "5m"} {
+		if _, err := parseWaitCreateTimeout(timeoutStr); err != nil {
+			t.Errorf("expected %q to be a valid timeout, got %v", timeoutStr, err)
+		}
+	}
+	if _, err := parseWaitCreateTimeout("soon"); err == nil {
+		t.Errorf("expected an error for an invalid timeout")
+	}
+}
+
+func TestExpandViewFileArg(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+	t.Setenv("WSH_TEST_DIR", "/tmp/wsh-test")
+	tests := []struct {
+		arg      string
+		isRemote bool
+		expected string
+	}{
+		{"~", false, homeDir},
+		{"~/", false, homeDir},
+		{"~/notes.md", false, filepath.Join(homeDir, "notes.md")},
+		{"$WSH_TEST_DIR/x", false, "/tmp/wsh-test/x"},
+		{"${WSH_TEST_DIR}/x", false, "/tmp/wsh-test/x"},
+		{"~", true, "~"},
+		{"~/notes.md", true, "~/notes.md"},
+		{"https://example.com/$x", false, "https://example.com/$x"},
+	}
+	for _, test := range tests {
+		rtn, err := expandViewFileArg(test.arg, test.isRemote)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.arg, err)
+			continue
+		}
+		if rtn != test.expected {
+			t.Errorf("%q (remote=%v): expected %q, got %q", test.arg, test.isRemote, test.expected, rtn)
+		}
+	}
+	for _, arg := range []string{"~nonexistentuser/x", "$UNDEFINED/x"} {
+		if _, err := expandViewFileArg(arg, false); err == nil {
+			t.Errorf("%q: expected an error", arg)
+		}
+	}
+}
+
+func TestMakeViewBlockDataLocalFromRemote(t *testing.T) {
+	setTestRemoteDirs(t)
+	origLocal := viewLocal
+	t.Cleanup(func() { viewLocal = origLocal })
+	viewLocal = true
+	remoteFileInfoFn = func(conn string, filePath string) (*wshrpc.FileInfo, error) {
+		if conn != wshrpc.LocalConnName {
+			t.Fatalf("stat sent to connection %q", conn)
+		}
+		return &wshrpc.FileInfo{Path: filePath, IsDir: filePath == "/srv/local-only"}, nil
+	}
+	wshCmd, err := makeViewBlockData("view", "/srv/local-only/app.log", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	meta := waveobj.MetaMapType(wshCmd.BlockDef.Meta)
+	if conn, found := meta[waveobj.MetaKey_Connection]; !found || conn != "" {
+		t.Errorf("expected an explicit local connection, got %v", conn)
+	}
+	if _, err := makeViewBlockData("view", "local-only/app.log", "", ""); err == nil {
+		t.Errorf("expected an error for a relative path with --local from a remote block")
+	}
+}
+
+func TestMakeViewBlockDataOtherConn(t *testing.T) {
+	setTestRemoteDirs(t)
+	origConn := viewConn
+	t.Cleanup(func() { viewConn = origConn })
+	RpcContext = wshrpc.RpcContext{}
+	viewConn = "myhost"
+	remoteFileInfoFn = func(conn string, filePath string) (*wshrpc.FileInfo, error) {
+		if conn != "myhost" {
+			t.Fatalf("stat sent to connection %q", conn)
+		}
+		return &wshrpc.FileInfo{Path: filePath, IsDir: filePath == "/var/log"}, nil
+	}
+	wshCmd, err := makeViewBlockData("view", "/var/log/syslog", "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	meta := waveobj.MetaMapType(wshCmd.BlockDef.Meta)
+	if meta.GetString(waveobj.MetaKey_Connection, "") != "myhost" || meta.GetString(waveobj.MetaKey_File, "") != "/var/log/syslog" {
+		t.Errorf("expected /var/log/syslog on myhost, got %v", meta)
+	}
+	if _, err := makeViewBlockData("view", "log/syslog", "", ""); err == nil {
+		t.Errorf("expected an error for a relative path with --conn")
+	}
+}
+
+func TestBlockCloseWaiter(t *testing.T) {
+	closeEvent := func(blockId string) *wps.WaveEvent {
+		return &wps.WaveEvent{Event: wps.Event_BlockClose, Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, blockId).String()}}
+	}
+	block1 := waveobj.MakeORef(waveobj.OType_Block, "block1")
+	block2 := waveobj.MakeORef(waveobj.OType_Block, "block2")
+	waiter := &blockCloseWaiter{closed: make(map[string]bool), doneCh: make(chan struct{})}
+	// closed before wait is called (right after it was created)
+	waiter.handleEvent(closeEvent("block1"))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- waiter.wait([]*waveobj.ORef{&block1, &block2}, 2*time.Second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case err := <-errCh:
+		t.Fatalf("expected wait to block until block2 is closed, got %v", err)
+	default:
+	}
+	waiter.handleEvent(closeEvent("other"))
+	waiter.handleEvent(closeEvent("block2"))
+	if err := <-errCh; err != nil {
+		t.Errorf("expected wait to return once both blocks are closed, got %v", err)
+	}
+}
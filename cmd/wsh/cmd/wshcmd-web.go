@@ -15,7 +15,7 @@ import (
 )
 
 var webCmd = &cobra.Command{
-	Use:               "web [open|get|set]",
+	Use:               "web [open|nav|reload|back|forward|get]",
 	Short:             "web commands",
 	PersistentPreRunE: preRunSetupRpcClient,
 }
@@ -27,6 +27,34 @@ var webOpenCmd = &cobra.Command{
 	RunE:  webOpenRun,
 }
 
+var webNavCmd = &cobra.Command{
+	Use:   "nav blockid url",
+	Short: "navigate a web block to a url",
+	Args:  cobra.ExactArgs(2),
+	RunE:  webNavRun,
+}
+
+var webReloadCmd = &cobra.Command{
+	Use:   "reload blockid",
+	Short: "reload the page in a web block",
+	Args:  cobra.ExactArgs(1),
+	RunE:  makeWebNavigateRun(wshrpc.WebNavigateAction_Reload),
+}
+
+var webBackCmd = &cobra.Command{
+	Use:   "back blockid",
+	Short: "go back in a web block's history",
+	Args:  cobra.ExactArgs(1),
+	RunE:  makeWebNavigateRun(wshrpc.WebNavigateAction_Back),
+}
+
+var webForwardCmd = &cobra.Command{
+	Use:   "forward blockid",
+	Short: "go forward in a web block's history",
+	Args:  cobra.ExactArgs(1),
+	RunE:  makeWebNavigateRun(wshrpc.WebNavigateAction_Forward),
+}
+
 var webGetCmd = &cobra.Command{
 	Use:    "get [--inner] [--all] [--json] css-selector",
 	Short:  "get the html for a css selector",
@@ -43,6 +71,10 @@ var webOpenMagnified bool
 func init() {
 	webOpenCmd.Flags().BoolVarP(&webOpenMagnified, "magnified", "m", false, "open view in magnified mode")
 	webCmd.AddCommand(webOpenCmd)
+	webCmd.AddCommand(webNavCmd)
+	webCmd.AddCommand(webReloadCmd)
+	webCmd.AddCommand(webBackCmd)
+	webCmd.AddCommand(webForwardCmd)
 	webGetCmd.Flags().BoolVarP(&webGetInner, "inner", "", false, "get inner html (instead of outer)")
 	webGetCmd.Flags().BoolVarP(&webGetAll, "all", "", false, "get all matches (querySelectorAll)")
 	webGetCmd.Flags().BoolVarP(&webGetJson, "json", "", false, "output as json")
@@ -50,17 +82,86 @@ func init() {
 	rootCmd.AddCommand(webCmd)
 }
 
+// returns the block info, errors if the block is not a web block
+func getWebBlockInfo(fullORef *waveobj.ORef) (*wshrpc.BlockInfoData, error) {
+	if fullORef.OType != waveobj.OType_Block {
+		return nil, fmt.Errorf("%s is not a block", fullORef)
+	}
+	blockInfo, err := wshclient.BlockInfoCommand(RpcClient, fullORef.OID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting block info: %w", err)
+	}
+	view := blockInfo.Block.Meta.GetString(waveobj.MetaKey_View, "")
+	if view != "web" {
+		return nil, fmt.Errorf("block %s is not a web block (view is %q)", fullORef.OID, view)
+	}
+	return blockInfo, nil
+}
+
+func resolveWebBlockId(blockId string) (*waveobj.ORef, error) {
+	fullORef, err := resolveSimpleId(blockId)
+	if err != nil {
+		return nil, fmt.Errorf("resolving blockid: %w", err)
+	}
+	_, err = getWebBlockInfo(fullORef)
+	if err != nil {
+		return nil, err
+	}
+	return fullORef, nil
+}
+
+func webNavRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("web", rtnErr == nil)
+	}()
+	fullORef, err := resolveWebBlockId(args[0])
+	if err != nil {
+		return err
+	}
+	// the web view loads the new url when the meta url changes
+	setMetaData := wshrpc.CommandSetMetaData{
+		ORef: *fullORef,
+		Meta: map[string]any{waveobj.MetaKey_Url: args[1]},
+	}
+	err = wshclient.SetMetaCommand(RpcClient, setMetaData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("setting url: %w", err)
+	}
+	return nil
+}
+
+func makeWebNavigateRun(action string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) (rtnErr error) {
+		defer func() {
+			sendActivity("web", rtnErr == nil)
+		}()
+		fullORef, err := resolveWebBlockId(args[0])
+		if err != nil {
+			return err
+		}
+		data := wshrpc.CommandWebNavigateData{
+			BlockId: fullORef.OID,
+			Action:  action,
+		}
+		err = wshclient.WebNavigateCommand(RpcClient, data, &wshrpc.RpcOpts{
+			Route:   wshutil.MakeFeBlockRouteId(fullORef.OID),
+			Timeout: 2000,
+		})
+		if err != nil {
+			return fmt.Errorf("web %s: %w", action, err)
+		}
+		return nil
+	}
+}
+
 func webGetRun(cmd *cobra.Command, args []string) error {
 	fullORef, err := resolveBlockArg()
 	if err != nil {
 		return fmt.Errorf("resolving blockid: %w", err)
 	}
-	blockInfo, err := wshclient.BlockInfoCommand(RpcClient, fullORef.OID, nil)
+	blockInfo, err := getWebBlockInfo(fullORef)
 	if err != nil {
-		return fmt.Errorf("getting block info: %w", err)
-	}
-	if blockInfo.Block.Meta.GetString(waveobj.MetaKey_View, "") != "web" {
-		return fmt.Errorf("block %s is not a web block", fullORef.OID)
+		return err
 	}
 	data := wshrpc.CommandWebSelectorData{
 		WorkspaceId: blockInfo.WorkspaceId,
@@ -107,10 +208,10 @@ func webOpenRun(cmd *cobra.Command, args []string) (rtnErr error) {
 		},
 		Magnified: webOpenMagnified,
 	}
-	oref, err := wshclient.CreateBlockCommand(RpcClient, wshCmd, nil)
+	rtnData, err := wshclient.CreateBlockCommand(RpcClient, wshCmd, nil)
 	if err != nil {
 		return fmt.Errorf("creating block: %w", err)
 	}
-	WriteStdout("created block %s\n", oref)
+	WriteStdout("created block %s\n", rtnData.BlockORef)
 	return nil
 }
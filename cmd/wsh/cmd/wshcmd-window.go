@@ -0,0 +1,85 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+)
+
+var windowCommand = &cobra.Command{
+	Use:   "window",
+	Short: "Manage windows",
+}
+
+var windowMoveX int
+var windowMoveY int
+var windowMoveWidth int
+var windowMoveHeight int
+
+func init() {
+	windowMoveCommand.Flags().IntVar(&windowMoveX, "x", 0, "the x position of the window (in screen coordinates)")
+	windowMoveCommand.Flags().IntVar(&windowMoveY, "y", 0, "the y position of the window (in screen coordinates)")
+	windowMoveCommand.Flags().IntVar(&windowMoveWidth, "w", 0, "the width of the window")
+	windowMoveCommand.Flags().IntVar(&windowMoveHeight, "h", 0, "the height of the window")
+	windowCommand.AddCommand(windowMoveCommand)
+	rootCmd.AddCommand(windowCommand)
+}
+
+var windowMoveCommand = &cobra.Command{
+	Use:   "move [windowid]",
+	Short: "Move and/or resize a window",
+	Long: `move and/or resize a window (defaults to the current window).  only the given values are changed,
+e.g. --w and --h resize the window in place.  the window is kept on a visible display.`,
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    windowMoveRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func windowMoveRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("window", rtnErr == nil)
+	}()
+	var geom waveobj.WindowGeometry
+	flags := cmd.Flags()
+	if flags.Changed("x") {
+		geom.X = &windowMoveX
+	}
+	if flags.Changed("y") {
+		geom.Y = &windowMoveY
+	}
+	if flags.Changed("w") {
+		geom.Width = &windowMoveWidth
+	}
+	if flags.Changed("h") {
+		geom.Height = &windowMoveHeight
+	}
+	if geom.X == nil && geom.Y == nil && geom.Width == nil && geom.Height == nil {
+		OutputHelpMessage(cmd)
+		return fmt.Errorf("at least one of --x, --y, --w, or --h is required")
+	}
+	data := wshrpc.CommandSetWindowGeometryData{Geometry: geom}
+	if len(args) > 0 {
+		oref, err := resolveSimpleId(args[0])
+		if err != nil {
+			return fmt.Errorf("resolving window: %w", err)
+		}
+		if oref.OType != waveobj.OType_Window {
+			return fmt.Errorf("%q is not a window", args[0])
+		}
+		data.WindowId = oref.OID
+	} else if RpcContext.TabId == "" {
+		return fmt.Errorf("no current window, pass a window id")
+	}
+	window, err := wshclient.SetWindowGeometryCommand(RpcClient, data, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("moving window: %w", err)
+	}
+	WriteStdout("window moved to %d,%d (%dx%d)\n", window.Pos.X, window.Pos.Y, window.WinSize.Width, window.WinSize.Height)
+	return nil
+}
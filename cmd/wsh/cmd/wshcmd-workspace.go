@@ -4,7 +4,10 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 )
@@ -15,8 +18,23 @@ var workspaceCommand = &cobra.Command{
 	// Args:    cobra.MinimumNArgs(1),
 }
 
+var workspaceCreateIcon string
+var workspaceCreateColor string
+var workspaceDeleteForce bool
+var workspaceDeleteMoveTabsTo string
+var workspaceSwitchWindow string
+
 func init() {
+	workspaceCreateCommand.Flags().StringVar(&workspaceCreateIcon, "icon", "", "workspace icon (defaults to the first workspace icon)")
+	workspaceCreateCommand.Flags().StringVar(&workspaceCreateColor, "color", "", "workspace color (defaults to the next workspace color)")
+	workspaceDeleteCommand.Flags().BoolVarP(&workspaceDeleteForce, "force", "f", false, "delete the workspace even if it has tabs (the tabs are deleted)")
+	workspaceDeleteCommand.Flags().StringVar(&workspaceDeleteMoveTabsTo, "move-tabs-to", "", "move the workspace's tabs to this workspace (name or id) before deleting")
+	workspaceSwitchCommand.Flags().StringVar(&workspaceSwitchWindow, "window", "", "switch the given window (defaults to the current window)")
 	workspaceCommand.AddCommand(workspaceListCommand)
+	workspaceCommand.AddCommand(workspaceCreateCommand)
+	workspaceCommand.AddCommand(workspaceRenameCommand)
+	workspaceCommand.AddCommand(workspaceDeleteCommand)
+	workspaceCommand.AddCommand(workspaceSwitchCommand)
 	rootCmd.AddCommand(workspaceCommand)
 }
 
@@ -49,3 +67,150 @@ func workspaceListRun(cmd *cobra.Command, args []string) {
 	}
 	WriteStdout("]\n")
 }
+
+var workspaceCreateCommand = &cobra.Command{
+	Use:     "create name",
+	Short:   "Create a workspace",
+	Args:    cobra.ExactArgs(1),
+	RunE:    workspaceCreateRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var workspaceRenameCommand = &cobra.Command{
+	Use:     "rename {workspace} newname",
+	Short:   "Rename a workspace (by name or id)",
+	Args:    cobra.ExactArgs(2),
+	RunE:    workspaceRenameRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var workspaceDeleteCommand = &cobra.Command{
+	Use:   "delete {workspace}",
+	Short: "Delete a workspace (by name or id)",
+	Long: `delete a workspace (by name or id). the workspace must not be open in a window.
+workspaces with tabs are only deleted with --force (deletes the tabs) or --move-tabs-to.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    workspaceDeleteRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+var workspaceSwitchCommand = &cobra.Command{
+	Use:   "switch {workspace}",
+	Short: "Switch the window to a workspace (by name or id)",
+	Long: `switch the current window (or the one given with --window) to a workspace (by name or id).
+the blocks in the workspace that is switched away from keep running.  if the workspace is open in another
+window, that window is focused instead.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    workspaceSwitchRun,
+	PreRunE: preRunSetupRpcClient,
+}
+
+func workspaceCreateRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("workspace", rtnErr == nil)
+	}()
+	createData := wshrpc.CommandWorkspaceCreateData{
+		Name:  args[0],
+		Icon:  workspaceCreateIcon,
+		Color: workspaceCreateColor,
+	}
+	ws, err := wshclient.WorkspaceCreateCommand(RpcClient, createData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("creating workspace: %w", err)
+	}
+	WriteStdout("created workspace %q (%s)\n", ws.Name, ws.OID)
+	return nil
+}
+
+func workspaceRenameRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("workspace", rtnErr == nil)
+	}()
+	workspaceId, err := resolveWorkspaceArg(args[0])
+	if err != nil {
+		return err
+	}
+	renameData := wshrpc.CommandWorkspaceRenameData{WorkspaceId: workspaceId, Name: args[1]}
+	err = wshclient.WorkspaceRenameCommand(RpcClient, renameData, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return fmt.Errorf("renaming workspace: %w", err)
+	}
+	WriteStdout("workspace renamed\n")
+	return nil
+}
+
+func workspaceDeleteRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("workspace", rtnErr == nil)
+	}()
+	workspaceId, err := resolveWorkspaceArg(args[0])
+	if err != nil {
+		return err
+	}
+	deleteData := wshrpc.CommandWorkspaceDeleteData{WorkspaceId: workspaceId, Force: workspaceDeleteForce}
+	if workspaceDeleteMoveTabsTo != "" {
+		deleteData.MoveToWorkspaceId, err = resolveWorkspaceArg(workspaceDeleteMoveTabsTo)
+		if err != nil {
+			return err
+		}
+	}
+	err = wshclient.WorkspaceDeleteCommand(RpcClient, deleteData, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		return fmt.Errorf("deleting workspace: %w", err)
+	}
+	WriteStdout("workspace deleted\n")
+	return nil
+}
+
+func workspaceSwitchRun(cmd *cobra.Command, args []string) (rtnErr error) {
+	defer func() {
+		sendActivity("workspace", rtnErr == nil)
+	}()
+	workspaceId, err := resolveWorkspaceArg(args[0])
+	if err != nil {
+		return err
+	}
+	switchData := wshrpc.CommandWorkspaceSwitchData{WorkspaceId: workspaceId}
+	if workspaceSwitchWindow != "" {
+		oref, err := resolveSimpleId(workspaceSwitchWindow)
+		if err != nil {
+			return fmt.Errorf("resolving window: %w", err)
+		}
+		if oref.OType != waveobj.OType_Window {
+			return fmt.Errorf("%q is not a window", workspaceSwitchWindow)
+		}
+		switchData.WindowId = oref.OID
+	} else {
+		if RpcContext.TabId == "" {
+			return fmt.Errorf("no current window, use --window")
+		}
+		switchData.TabId = RpcContext.TabId
+	}
+	err = wshclient.WorkspaceSwitchCommand(RpcClient, switchData, &wshrpc.RpcOpts{Timeout: 10000})
+	if err != nil {
+		return fmt.Errorf("switching workspace: %w", err)
+	}
+	WriteStdout("switched to workspace %s\n", workspaceId)
+	return nil
+}
+
+// resolves a workspace name (saved workspaces) or a workspace id
+func resolveWorkspaceArg(arg string) (string, error) {
+	workspaces, err := wshclient.WorkspaceListCommand(RpcClient, &wshrpc.RpcOpts{Timeout: 2000})
+	if err != nil {
+		return "", fmt.Errorf("listing workspaces: %w", err)
+	}
+	for _, w := range workspaces {
+		if w.WorkspaceData != nil && w.WorkspaceData.Name == arg {
+			return w.WorkspaceData.OID, nil
+		}
+	}
+	oref, err := resolveSimpleId(arg)
+	if err != nil {
+		return "", fmt.Errorf("workspace %q not found: %w", arg, err)
+	}
+	if oref.OType != waveobj.OType_Workspace {
+		return "", fmt.Errorf("%q is not a workspace", arg)
+	}
+	return oref.OID, nil
+}
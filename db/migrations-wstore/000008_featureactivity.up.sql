@@ -0,0 +1,9 @@
+CREATE TABLE db_featureactivity (
+    day varchar(20) NOT NULL,
+    feature varchar(50) NOT NULL,
+    count int NOT NULL,
+    errors int NOT NULL,
+    durationms int NOT NULL,
+    metacounts json NOT NULL,
+    PRIMARY KEY (day, feature)
+);
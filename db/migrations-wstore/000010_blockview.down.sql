@@ -0,0 +1 @@
+DROP INDEX idx_block_view;
@@ -0,0 +1 @@
+CREATE INDEX idx_block_view ON db_block (json_extract(data, '$.meta.view'));
@@ -0,0 +1,10 @@
+CREATE TABLE db_aimessage (
+    blockid varchar(36) NOT NULL,
+    seq int NOT NULL,
+    role varchar(20) NOT NULL,
+    content text NOT NULL,
+    ts bigint NOT NULL,
+    prompttokens int NOT NULL,
+    completiontokens int NOT NULL,
+    PRIMARY KEY (blockid, seq)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE db_cmdhistory (
+    blockid varchar(36) NOT NULL,
+    seq int NOT NULL,
+    cmd text NOT NULL,
+    cwd text NOT NULL,
+    startts bigint NOT NULL,
+    endts bigint NOT NULL,
+    exitcode int NULL DEFAULT NULL,
+    PRIMARY KEY (blockid, seq)
+);
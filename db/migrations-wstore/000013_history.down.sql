@@ -0,0 +1,3 @@
+DROP INDEX idx_history_block;
+DROP INDEX idx_history_ts;
+DROP TABLE db_history;
@@ -0,0 +1,14 @@
+CREATE TABLE db_history (
+    historyid integer PRIMARY KEY AUTOINCREMENT,
+    ts bigint NOT NULL,
+    cmd text NOT NULL,
+    cwd text NOT NULL,
+    connection varchar(200) NOT NULL,
+    exitcode int NULL DEFAULT NULL,
+    durationms bigint NOT NULL,
+    blockid varchar(36) NOT NULL,
+    source varchar(50) NOT NULL,
+    count int NOT NULL
+);
+CREATE INDEX idx_history_ts ON db_history (ts, historyid);
+CREATE INDEX idx_history_block ON db_history (blockid, source, historyid);
@@ -617,7 +617,7 @@ export function getAllWaveWindows(): WaveBrowserWindow[] {
 }
 
 export async function createWindowForWorkspace(workspaceId: string) {
-    const newWin = await WindowService.CreateWindow(null, workspaceId);
+    const newWin = await WindowService.CreateWindow(null, null, workspaceId);
     if (!newWin) {
         console.log("error creating new window", this.waveWindowId);
     }
@@ -636,13 +636,13 @@ export async function createBrowserWindow(
 ): Promise<WaveBrowserWindow> {
     if (!waveWindow) {
         console.log("createBrowserWindow: no waveWindow");
-        waveWindow = await WindowService.CreateWindow(null, "");
+        waveWindow = await WindowService.CreateWindow(null, null, "");
     }
     let workspace = await WorkspaceService.GetWorkspace(waveWindow.workspaceid);
     if (!workspace) {
         console.log("createBrowserWindow: no workspace, creating new window");
         await WindowService.CloseWindow(waveWindow.oid, true);
-        waveWindow = await WindowService.CreateWindow(null, "");
+        waveWindow = await WindowService.CreateWindow(null, null, "");
         workspace = await WorkspaceService.GetWorkspace(waveWindow.workspaceid);
     }
     console.log("createBrowserWindow", waveWindow.oid, workspace.oid, workspace);
@@ -2,13 +2,18 @@
 // SPDX-License-Identifier: Apache-2.0
 
 import { FileService, WindowService } from "@/app/store/services";
+import { RpcApi } from "@/app/store/wshclientapi";
+import { fireAndForget } from "@/util/util";
 import { Notification } from "electron";
 import { getResolvedUpdateChannel } from "emain/updater";
 import { RpcResponseHelper, WshClient } from "../frontend/app/store/wshclient";
+import { getWaveTabView } from "./emain-tabview";
 import { getWebContentsByBlockId, webGetSelector } from "./emain-web";
 import { createBrowserWindow, getWaveWindowById, getWaveWindowByWorkspaceId } from "./emain-window";
 import { unamePlatform } from "./platform";
 
+const CaptureChunkSize = 192 * 1024; // a multiple of 3, so the base64 chunks can be decoded separately
+
 export class ElectronWshClientType extends WshClient {
     constructor() {
         super("electron");
@@ -30,12 +35,19 @@ export class ElectronWshClientType extends WshClient {
         return rtn;
     }
 
+    // used by wavesrv for notifications from blocks whose tab isn't loaded (or that have no block)
     async handle_notify(rh: RpcResponseHelper, notificationOptions: WaveNotificationOptions) {
-        new Notification({
+        const notif = new Notification({
             title: notificationOptions.title,
             body: notificationOptions.body,
             silent: notificationOptions.silent,
-        }).show();
+        });
+        if (notificationOptions.blockid) {
+            notif.on("click", () => {
+                fireAndForget(() => RpcApi.FocusBlockCommand(ElectronWshClient, notificationOptions.blockid));
+            });
+        }
+        notif.show();
     }
 
     async handle_getupdatechannel(rh: RpcResponseHelper): Promise<string> {
@@ -56,6 +68,60 @@ export class ElectronWshClientType extends WshClient {
         ww.focus();
     }
 
+    // forwarded by wavesrv (which resolves the window)
+    async handle_workspaceswitch(rh: RpcResponseHelper, data: CommandWorkspaceSwitchData) {
+        const ww = getWaveWindowById(data.windowid);
+        if (ww == null) {
+            throw new Error(`window ${data.windowid} not found`);
+        }
+        await ww.switchWorkspace(data.workspaceid);
+    }
+
+    // forwarded by wavesrv (which resolves the window, and checks that the tab is loaded).
+    // streams back the png (base64 encoded) in chunks.
+    async handle_captureblock(rh: RpcResponseHelper, data: CommandCaptureBlockData) {
+        const ww = getWaveWindowById(data.windowid);
+        if (ww == null) {
+            throw new Error(`window ${data.windowid} not found`);
+        }
+        const tabView = getWaveTabView(data.tabid);
+        if (tabView == null || tabView.webContents.isDestroyed()) {
+            throw new Error(`tab ${data.tabid} is not loaded`);
+        }
+        const wc = tabView.webContents;
+        let rect: Electron.Rectangle;
+        if (data.blockid) {
+            const selector = JSON.stringify(`.block[data-blockid="${data.blockid}"]`);
+            const domRect: { left: number; top: number; width: number; height: number } = await wc.executeJavaScript(`(() => {
+                const elem = document.querySelector(${selector});
+                if (elem == null) return null;
+                const r = elem.getBoundingClientRect();
+                return { left: r.left, top: r.top, width: r.width, height: r.height };
+            })()`);
+            if (domRect == null || domRect.width == 0 || domRect.height == 0) {
+                throw new Error(`block ${data.blockid} is not shown in its tab`);
+            }
+            const zoomFactor = wc.getZoomFactor();
+            rect = {
+                x: Math.round(domRect.left * zoomFactor),
+                y: Math.round(domRect.top * zoomFactor),
+                width: Math.round(domRect.width * zoomFactor),
+                height: Math.round(domRect.height * zoomFactor),
+            };
+        }
+        const image = await wc.capturePage(rect, { stayHidden: true });
+        if (image.isEmpty()) {
+            throw new Error("the capture is empty (the tab may not be visible)");
+        }
+        const png = image.toPNG();
+        if (data.maxsize > 0 && png.length > data.maxsize) {
+            throw new Error(`the image is too large (${png.length} bytes, the max is ${data.maxsize})`);
+        }
+        for (let pos = 0; pos < png.length; pos += CaptureChunkSize) {
+            rh.sendResponse({ data: png.subarray(pos, pos + CaptureChunkSize).toString("base64"), cont: true });
+        }
+    }
+
     // async handle_workspaceupdate(rh: RpcResponseHelper) {
     //     console.log("workspaceupdate");
     //     fireAndForget(async () => {
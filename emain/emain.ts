@@ -28,7 +28,7 @@ import {
     setWasInFg,
 } from "./emain-activity";
 import { ensureHotSpareTab, getWaveTabViewByWebContentsId, setMaxTabCacheSize } from "./emain-tabview";
-import { handleCtrlShiftState } from "./emain-util";
+import { ensureBoundsAreVisible, handleCtrlShiftState } from "./emain-util";
 import { getIsWaveSrvDead, getWaveSrvProc, getWaveSrvReady, getWaveVersion, runWaveSrv } from "./emain-wavesrv";
 import {
     createBrowserWindow,
@@ -104,6 +104,35 @@ function handleWSEvent(evtMsg: WSEventType) {
             if (ww != null) {
                 ww.destroy(); // bypass the "are you sure?" dialog
             }
+        } else if (evtMsg.eventtype == "electron:focuswindow") {
+            console.log("electron:focuswindow", evtMsg.data);
+            if (evtMsg.data === undefined) return;
+            const ww = getWaveWindowById(evtMsg.data);
+            if (ww != null && !ww.isDestroyed() && !ww.isFocused()) {
+                ww.focus();
+            }
+        } else if (evtMsg.eventtype == "electron:setwindowbounds") {
+            const boundsUpdate: { windowid: string; pos: Point; winsize: WinSize } = evtMsg.data;
+            console.log("electron:setwindowbounds", boundsUpdate);
+            const ww = getWaveWindowById(boundsUpdate?.windowid);
+            if (ww == null || ww.isDestroyed()) {
+                return;
+            }
+            if (ww.isFullScreen()) {
+                ww.setFullScreen(false);
+            }
+            if (ww.isMaximized()) {
+                ww.unmaximize();
+            }
+            // the resize handler stores the bounds electron ends up with
+            ww.setBounds(
+                ensureBoundsAreVisible({
+                    x: boundsUpdate.pos.x,
+                    y: boundsUpdate.pos.y,
+                    width: boundsUpdate.winsize.width,
+                    height: boundsUpdate.winsize.height,
+                })
+            );
         } else if (evtMsg.eventtype == "electron:updateactivetab") {
             const activeTabUpdate: { workspaceid: string; newactivetabid: string } = evtMsg.data;
             console.log("electron:updateactivetab", activeTabUpdate);
@@ -565,6 +594,27 @@ process.on("uncaughtException", (error) => {
     electronApp.quit();
 });
 
+const WaveLaunchArgPrefix = "--wave-launch=";
+
+// wsh launch starts the app with --wave-launch={json} when the server isn't running (see wshcmd-launch.go)
+function handleWaveLaunchArgs(argv: string[]) {
+    const launchArg = argv.find((arg) => arg.startsWith(WaveLaunchArgPrefix));
+    if (launchArg == null) {
+        return;
+    }
+    let launchData: CommandLaunchWindowData;
+    try {
+        launchData = JSON.parse(launchArg.substring(WaveLaunchArgPrefix.length));
+    } catch (e) {
+        console.log("invalid --wave-launch arg", e);
+        return;
+    }
+    fireAndForget(async () => {
+        const rtn = await RpcApi.LaunchWindowCommand(ElectronWshClient, launchData);
+        console.log("wave-launch window", rtn?.windowid);
+    });
+}
+
 async function appMain() {
     // Set disableHardwareAcceleration as early as possible, if required.
     const launchSettings = getLaunchSettings();
@@ -579,6 +629,9 @@ async function appMain() {
         electronApp.quit();
         return;
     }
+    electronApp.on("second-instance", (_event, argv) => {
+        handleWaveLaunchArgs(argv);
+    });
     try {
         await runWaveSrv(handleWSEvent);
     } catch (e) {
@@ -600,6 +653,7 @@ async function appMain() {
     } catch (e) {
         console.log("error initializing wshrpc", e);
     }
+    handleWaveLaunchArgs(process.argv);
     makeAppMenu();
     await configureAutoUpdater();
     setGlobalIsStarting(false);
@@ -3,15 +3,7 @@
 
 import { Workspace } from "@/app/workspace/workspace";
 import { ContextMenuModel } from "@/store/contextmenu";
-import {
-    atoms,
-    createBlock,
-    getSettingsPrefixAtom,
-    globalStore,
-    isDev,
-    PLATFORM,
-    removeFlashError,
-} from "@/store/global";
+import { atoms, createBlock, getSettingsPrefixAtom, globalStore, PLATFORM, removeFlashError } from "@/store/global";
 import { appHandleKeyDown } from "@/store/keymodel";
 import { getElemAsStr } from "@/util/focusutil";
 import * as keyutil from "@/util/keyutil";
@@ -304,7 +296,7 @@ const AppInner = () => {
                 <Workspace />
             </DndProvider>
             <FlashError />
-            {isDev() ? <NotificationBubbles></NotificationBubbles> : null}
+            <NotificationBubbles></NotificationBubbles>
         </div>
     );
 };
@@ -391,8 +391,16 @@ const ConnStatusOverlay = React.memo(
 
         let statusText = `Disconnected from "${connName}"`;
         let showReconnect = true;
+        if (connStatus.reconnecting && connStatus.status != "connecting") {
+            const nextTry = connStatus.nextreconnectts
+                ? ` at ${new Date(connStatus.nextreconnectts).toLocaleTimeString()}`
+                : "";
+            statusText = `Disconnected from "${connName}", reconnecting (attempt ${connStatus.reconnectattempt})${nextTry}`;
+        }
         if (connStatus.status == "connecting") {
-            statusText = `Connecting to "${connName}"...`;
+            statusText = connStatus.reconnecting
+                ? `Reconnecting to "${connName}" (attempt ${connStatus.reconnectattempt})...`
+                : `Connecting to "${connName}"...`;
             showReconnect = false;
         }
         if (connStatus.status == "connected") {
@@ -431,7 +439,12 @@ const ConnStatusOverlay = React.memo(
                         {showIcon && <i className="fa-solid fa-triangle-exclamation"></i>}
                         <div className="connstatus-status">
                             <div className="connstatus-status-text">{statusText}</div>
-                            {showError ? <div className="connstatus-error">error: {connStatus.error}</div> : null}
+                            {showError ? (
+                                <div className="connstatus-error">
+                                    {connStatus.errorhop ? `error at jump host ${connStatus.errorhop}` : "error"}:{" "}
+                                    {connStatus.error}
+                                </div>
+                            ) : null}
                             {showWshError ? (
                                 <div className="connstatus-error">unable to use wsh: {connStatus.wsherror}</div>
                             ) : null}
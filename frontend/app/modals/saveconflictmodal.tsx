@@ -0,0 +1,84 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+import { Button } from "@/app/element/button";
+import { Modal } from "@/app/modals/modal";
+import { modalsModel } from "@/app/store/modalmodel";
+import * as keyutil from "@/util/keyutil";
+import { fireAndForget } from "@/util/util";
+import { useState } from "react";
+import "./saveconflictmodal.scss";
+
+type SaveConflictModalProps = {
+    conflict: FileConflictData;
+    onOverwrite: () => Promise<void>;
+    onReload: () => Promise<void>;
+    onSaveAs: (newPath: string) => Promise<void>;
+};
+
+// shown by the edit view when the file changed on disk (or was removed) after it was opened, see FileSaveCommand
+const SaveConflictModal = ({ conflict, onOverwrite, onReload, onSaveAs }: SaveConflictModalProps) => {
+    const [saveAsPath, setSaveAsPath] = useState<string>(null);
+
+    function runAndClose(fn: () => Promise<void>) {
+        modalsModel.popModal();
+        fireAndForget(fn);
+    }
+
+    function handleSaveAs() {
+        if (!saveAsPath) {
+            return;
+        }
+        runAndClose(() => onSaveAs(saveAsPath));
+    }
+
+    const what = conflict.removed ? "removed" : "changed on disk";
+    return (
+        <Modal className="saveconflict-modal" onClose={() => modalsModel.popModal()}>
+            <div className="saveconflict-title">File {what}</div>
+            <div className="saveconflict-text">
+                <span className="saveconflict-path">{conflict.path}</span> was {what} since it was opened.{" "}
+                {conflict.removed ? "Overwrite will create it again." : "Overwrite will discard the other changes."}
+            </div>
+            {saveAsPath != null && (
+                <input
+                    className="saveconflict-inputbox"
+                    value={saveAsPath}
+                    autoFocus={true}
+                    onChange={(e) => setSaveAsPath(e.target.value)}
+                    onKeyDown={keyutil.keydownWrapper((waveEvent) => {
+                        if (keyutil.checkKeyPressed(waveEvent, "Enter")) {
+                            handleSaveAs();
+                            return true;
+                        }
+                        return false;
+                    })}
+                />
+            )}
+            <footer className="saveconflict-footer">
+                <Button className="grey ghost" onClick={() => modalsModel.popModal()}>
+                    Cancel
+                </Button>
+                {saveAsPath == null ? (
+                    <Button className="grey" onClick={() => setSaveAsPath(conflict.path)}>
+                        Save As...
+                    </Button>
+                ) : (
+                    <Button className="grey" onClick={handleSaveAs}>
+                        Save
+                    </Button>
+                )}
+                {!conflict.removed && (
+                    <Button className="grey" onClick={() => runAndClose(onReload)}>
+                        Reload
+                    </Button>
+                )}
+                <Button onClick={() => runAndClose(onOverwrite)}>Overwrite</Button>
+            </footer>
+        </Modal>
+    );
+};
+
+SaveConflictModal.displayName = "SaveConflictModal";
+
+export { SaveConflictModal };
@@ -23,12 +23,22 @@ const ModalPage1 = () => {
     const clientData = useAtomValue(atoms.client);
     const [telemetryEnabled, setTelemetryEnabled] = useState<boolean>(!!settings["telemetry:enabled"]);
     const setPageNum = useSetAtom(pageNumAtom);
+    const setTosOpen = useSetAtom(modalsModel.tosOpen);
 
     const acceptTos = () => {
-        if (!clientData.tosagreed) {
-            fireAndForget(services.ClientService.AgreeTos);
+        if (clientData.tosagreed) {
+            setPageNum(2);
+            return;
         }
-        setPageNum(2);
+        fireAndForget(async () => {
+            const rtn = await services.ClientService.AgreeTos();
+            // the tips are for the starter layout, they are skipped if it wasn't created (the tab already had blocks)
+            if (rtn?.bootstrapped) {
+                setPageNum(2);
+            } else {
+                setTosOpen(false);
+            }
+        });
     };
 
     const setTelemetry = (value: boolean) => {
@@ -0,0 +1,52 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+import { pushNotification } from "@/store/global";
+import { RpcApi } from "@/store/wshclientapi";
+import { registerWSEventHandler, TabRpcClient } from "@/store/wshrpcutil";
+import { fireAndForget } from "@/util/util";
+
+const InAppNotificationTimeout = 10000;
+
+export function focusNotificationBlock(blockId: string) {
+    if (!blockId) {
+        return;
+    }
+    fireAndForget(() => RpcApi.FocusBlockCommand(TabRpcClient, blockId));
+}
+
+// used when OS notifications are denied (or fail to show)
+function pushInAppNotification(opts: WaveNotificationOptions) {
+    pushNotification({
+        icon: "bell",
+        title: opts.title,
+        message: opts.body ?? "",
+        timestamp: new Date().toLocaleString(),
+        expiration: Date.now() + InAppNotificationTimeout,
+        type: "info",
+        blockid: opts.blockid,
+        actions: opts.blockid ? [{ label: "Show Block", actionKey: "focusBlock", color: "grey" }] : null,
+    });
+}
+
+function showBlockNotification(opts: WaveNotificationOptions) {
+    if (typeof Notification === "undefined" || Notification.permission === "denied") {
+        pushInAppNotification(opts);
+        return;
+    }
+    try {
+        const notif = new Notification(opts.title, { body: opts.body, silent: opts.silent });
+        notif.onclick = () => focusNotificationBlock(opts.blockid);
+        notif.onerror = () => pushInAppNotification(opts);
+    } catch (e) {
+        console.log("error showing notification", e);
+        pushInAppNotification(opts);
+    }
+}
+
+// notifications sent by `wsh notify` (wavesrv sends them to the tab that owns the block)
+export function registerBlockNotificationHandler() {
+    registerWSEventHandler("notify", (event) => {
+        showBlockNotification(event.data as WaveNotificationOptions);
+    });
+}
@@ -1,14 +1,18 @@
 // Copyright 2025, Command Line Inc.
 // SPDX-License-Identifier: Apache-2.0
 
+import { focusNotificationBlock } from "@/app/notification/blocknotification";
 import { atoms, getApi } from "@/store/global";
 import { useAtom, useAtomValue } from "jotai";
 import { useCallback, useEffect, useState } from "react";
 
-const notificationActions: { [key: string]: () => void } = {
+const notificationActions: { [key: string]: (notif: NotificationType) => void } = {
     installUpdate: () => {
         getApi().installAppUpdate();
     },
+    focusBlock: (notif) => {
+        focusNotificationBlock(notif?.blockid);
+    },
     // Add other action functions here
 };
 
@@ -67,13 +71,13 @@ export function useNotification() {
             e.stopPropagation();
             const actionFn = notificationActions[action.actionKey];
             if (actionFn) {
-                actionFn();
+                actionFn(notifications.find((n) => n.id === id));
                 removeNotification(id);
             } else {
                 console.warn(`No action found for key: ${action.actionKey}`);
             }
         },
-        [removeNotification]
+        [notifications, removeNotification]
     );
 
     useEffect(() => {
@@ -10,13 +10,14 @@ import {
 import { getLayoutModelForStaticTab } from "@/layout/lib/layoutModelHooks";
 import { getWebServerEndpoint } from "@/util/endpoints";
 import { fetch } from "@/util/fetchutil";
-import { deepCompareReturnPrev, getPrefixedSettings, isBlank } from "@/util/util";
+import { deepCompareReturnPrev, fireAndForget, getPrefixedSettings, isBlank } from "@/util/util";
 import { atom, Atom, PrimitiveAtom, useAtomValue } from "jotai";
 import { globalStore } from "./jotaiStore";
 import { modalsModel } from "./modalmodel";
 import { ClientService, ObjectService } from "./services";
 import * as WOS from "./wos";
 import { getFileSubject, waveEventSubscribe } from "./wps";
+import { registerWSEventHandler } from "./wshrpcutil";
 
 let PLATFORM: NodeJS.Platform = "darwin";
 let atoms: GlobalAtomsType;
@@ -178,14 +179,6 @@ function initGlobalWaveEventSubs() {
                 WOS.updateWaveObject(update);
             },
         },
-        {
-            eventType: "config",
-            handler: (event) => {
-                // console.log("config wave event handler", event);
-                const fullConfig = (event.data as WatcherUpdate).fullconfig;
-                globalStore.set(atoms.fullConfigAtom, fullConfig);
-            },
-        },
         {
             eventType: "userinput",
             handler: (event) => {
@@ -206,6 +199,14 @@ function initGlobalWaveEventSubs() {
             },
         }
     );
+    // sent by wavesrv to every window (not through wps)
+    registerWSEventHandler("config", (event) => {
+        const fullConfig = (event.data as WatcherUpdate).fullconfig;
+        globalStore.set(atoms.fullConfigAtom, fullConfig);
+    });
+    registerWSEventHandler("clientupdate", (event) => {
+        fireAndForget(() => WOS.reloadWaveObject(event.oref));
+    });
 }
 
 const blockCache = new Map<string, Map<string, any>>();
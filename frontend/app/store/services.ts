@@ -7,15 +7,27 @@ import * as WOS from "./wos";
 
 // blockservice.BlockService (block)
 class BlockServiceType {
+    // adds messages to the end of the block's ai conversation
+    AppendWaveAiData(blockId: string, msgs: WaveAIConversationMessage[]): Promise<void> {
+        return WOS.callBackendService("block", "AppendWaveAiData", Array.from(arguments))
+    }
     GetControllerStatus(arg2: string): Promise<BlockControllerRuntimeStatus> {
         return WOS.callBackendService("block", "GetControllerStatus", Array.from(arguments))
     }
 
+    // returns the last maxMessages messages of the block's ai conversation (all of them if maxMessages is 0)
+    // @returns history
+    GetWaveAiData(blockId: string, maxMessages: number): Promise<WaveAIPromptMessageType[]> {
+        return WOS.callBackendService("block", "GetWaveAiData", Array.from(arguments))
+    }
+
     // save the terminal state to a blockfile
     SaveTerminalState(blockId: string, state: string, stateType: string, ptyOffset: number, termSize: TermSize): Promise<void> {
         return WOS.callBackendService("block", "SaveTerminalState", Array.from(arguments))
     }
-    SaveWaveAiData(arg2: string, arg3: WaveAIPromptMessageType[]): Promise<void> {
+
+    // replaces the block's ai conversation (an empty history clears it)
+    SaveWaveAiData(blockId: string, history: WaveAIPromptMessageType[]): Promise<void> {
         return WOS.callBackendService("block", "SaveWaveAiData", Array.from(arguments))
     }
 }
@@ -24,25 +36,55 @@ export const BlockService = new BlockServiceType();
 
 // clientservice.ClientService (client)
 class ClientServiceType {
-    // @returns object updates
-    AgreeTos(): Promise<void> {
+    // @returns AgreeTosRtn (and object updates)
+    AgreeTos(): Promise<AgreeTosRtnType> {
         return WOS.callBackendService("client", "AgreeTos", Array.from(arguments))
     }
+
+    // close a window (and its workspace if it is not saved), sweeps orphaned tabs and blocks
+    // @returns object updates
+    CloseWindow(windowId: string, force: boolean): Promise<void> {
+        return WOS.callBackendService("client", "CloseWindow", Array.from(arguments))
+    }
     FocusWindow(arg2: string): Promise<void> {
         return WOS.callBackendService("client", "FocusWindow", Array.from(arguments))
     }
     GetAllConnStatus(): Promise<ConnStatus[]> {
         return WOS.callBackendService("client", "GetAllConnStatus", Array.from(arguments))
     }
+    GetAllWindows(): Promise<WindowInfoType[]> {
+        return WOS.callBackendService("client", "GetAllWindows", Array.from(arguments))
+    }
     GetClientData(): Promise<Client> {
         return WOS.callBackendService("client", "GetClientData", Array.from(arguments))
     }
+    GetClientSetting(key: string): Promise<any> {
+        return WOS.callBackendService("client", "GetClientSetting", Array.from(arguments))
+    }
     GetTab(arg1: string): Promise<Tab> {
         return WOS.callBackendService("client", "GetTab", Array.from(arguments))
     }
+    GetTabsForWindow(windowId: string): Promise<Tab[]> {
+        return WOS.callBackendService("client", "GetTabsForWindow", Array.from(arguments))
+    }
+    PreviewStarterLayout(): Promise<PortableLayoutEntry[]> {
+        return WOS.callBackendService("client", "PreviewStarterLayout", Array.from(arguments))
+    }
+
+    // move and/or resize a window (only the fields set in geom are changed)
+    // @returns object updates
+    SetWindowGeometry(windowId: string, geom: WindowGeometry): Promise<void> {
+        return WOS.callBackendService("client", "SetWindowGeometry", Array.from(arguments))
+    }
     TelemetryUpdate(arg2: boolean): Promise<void> {
         return WOS.callBackendService("client", "TelemetryUpdate", Array.from(arguments))
     }
+
+    // merge settings into the client meta (keys must be known client keys or start with "user:")
+    // @returns object updates
+    UpdateClientMeta(meta: MetaType): Promise<void> {
+        return WOS.callBackendService("client", "UpdateClientMeta", Array.from(arguments))
+    }
 }
 
 export const ClientService = new ClientServiceType();
@@ -89,16 +131,40 @@ export const FileService = new FileServiceType();
 
 // objectservice.ObjectService (object)
 class ObjectServiceType {
+    // recreates the blocks of a layout preset in the tab (clearExisting closes the tab's current blocks first)
+    // @returns blockIds (and object updates)
+    ApplyLayoutPreset(tabId: string, name: string, clearExisting: boolean): Promise<string[]> {
+        return WOS.callBackendService("object", "ApplyLayoutPreset", Array.from(arguments))
+    }
+
     // @returns blockId (and object updates)
     CreateBlock(blockDef: BlockDef, rtOpts: RuntimeOpts): Promise<string> {
         return WOS.callBackendService("object", "CreateBlock", Array.from(arguments))
     }
 
+    // creates all of the blocks in a single transaction (nothing is created if one of them fails)
+    // @returns blockIds (and object updates)
+    CreateBlocks(tabId: string, defs: BlockDefWithLayout[]): Promise<string[]> {
+        return WOS.callBackendService("object", "CreateBlocks", Array.from(arguments))
+    }
+
     // @returns object updates
     DeleteBlock(blockId: string): Promise<void> {
         return WOS.callBackendService("object", "DeleteBlock", Array.from(arguments))
     }
 
+    // creates a copy of the block (its meta and runtime opts) next to it in the same tab, returns the new block id
+    // @returns object updates
+    DuplicateBlock(blockId: string): Promise<string> {
+        return WOS.callBackendService("object", "DuplicateBlock", Array.from(arguments))
+    }
+
+    // exports the block's ai conversation as markdown or json (wsh ai export streams it to a file instead)
+    // @returns conversation
+    ExportConversation(blockId: string, format: string): Promise<string> {
+        return WOS.callBackendService("object", "ExportConversation", Array.from(arguments))
+    }
+
     // get wave object by oref
     GetObject(oref: string): Promise<WaveObj> {
         return WOS.callBackendService("object", "GetObject", Array.from(arguments))
@@ -109,6 +175,78 @@ class ObjectServiceType {
         return WOS.callBackendService("object", "GetObjects", Array.from(arguments))
     }
 
+    // returns the tab's title (the tab's title template rendered, or the tab's name)
+    // @returns title
+    GetTabTitle(tabId: string): Promise<string> {
+        return WOS.callBackendService("object", "GetTabTitle", Array.from(arguments))
+    }
+
+    // types the path (quoted for the block's shell, without a newline) into a terminal block, blockId defaults to the focused block of the active tab
+    // @returns blockId
+    InjectPath(blockId: string, path: string): Promise<string> {
+        return WOS.callBackendService("object", "InjectPath", Array.from(arguments))
+    }
+
+    // links a terminal's output to a preview or web block (the regex's first capture group sets the target's file or url)
+    // @returns linkId (and object updates)
+    LinkBlocks(srcBlockId: string, dstBlockId: string, rule: string): Promise<string> {
+        return WOS.callBackendService("object", "LinkBlocks", Array.from(arguments))
+    }
+
+    // moves a block to another tab (indexArr is the position in the destination layout, optional)
+    // @returns object updates
+    MoveBlock(blockId: string, destTabId: string, indexArr: number[]): Promise<void> {
+        return WOS.callBackendService("object", "MoveBlock", Array.from(arguments))
+    }
+
+    // restarts a block's shell (keeping the scrollback), restarts after crashes are delayed unless force is set
+    RestartController(blockId: string, force: boolean): Promise<void> {
+        return WOS.callBackendService("object", "RestartController", Array.from(arguments))
+    }
+
+    // restores a deleted block (from the trash) into a tab, tabId defaults to the block's original tab
+    // @returns object updates
+    RestoreBlock(blockId: string, tabId: string): Promise<void> {
+        return WOS.callBackendService("object", "RestoreBlock", Array.from(arguments))
+    }
+
+    // saves the tab's layout as a named preset (replaces an existing preset with the same name)
+    // @returns object updates
+    SaveLayoutPreset(tabId: string, name: string): Promise<void> {
+        return WOS.callBackendService("object", "SaveLayoutPreset", Array.from(arguments))
+    }
+
+    // pinned blocks are skipped when all the blocks in a tab are closed (unless forced)
+    // @returns object updates
+    SetBlockPinned(blockId: string, pinned: boolean): Promise<void> {
+        return WOS.callBackendService("object", "SetBlockPinned", Array.from(arguments))
+    }
+
+    // sets the default connection for new blocks in the tab (empty clears it, existing blocks are not changed)
+    // @returns object updates
+    SetTabConnection(tabId: string, conn: string): Promise<void> {
+        return WOS.callBackendService("object", "SetTabConnection", Array.from(arguments))
+    }
+
+    // sets the tab's title template, e.g. "{conn}:{cwd}" (without braces it is a static title, empty clears it)
+    // @returns object updates
+    SetTabTitleTemplate(tabId: string, template: string): Promise<void> {
+        return WOS.callBackendService("object", "SetTabTitleTemplate", Array.from(arguments))
+    }
+
+    // trims the block's scrollback to (about) the last keepBytes bytes (at a line boundary), returns the size that is left
+    // @returns scrollbackSize
+    TrimScrollback(blockId: string, keepBytes: number): Promise<number> {
+        return WOS.callBackendService("object", "TrimScrollback", Array.from(arguments))
+    }
+
+    // merge a meta patch into a block (null values delete keys), fails with a version mismatch if expectedVersion is stale
+    // @returns newVersion (and object updates)
+    UpdateBlockMeta(blockId: string, patch: MetaType, expectedVersion: number): Promise<number> {
+        return WOS.callBackendService("object", "UpdateBlockMeta", Array.from(arguments))
+    }
+
+    // internal use: overwrites the whole object (last writer wins), use UpdateBlockMeta to change block meta
     // @returns object updates
     UpdateObject(waveObj: WaveObj, returnUpdates: boolean): Promise<void> {
         return WOS.callBackendService("object", "UpdateObject", Array.from(arguments))
@@ -127,6 +265,21 @@ class ObjectServiceType {
 
 export const ObjectService = new ObjectServiceType();
 
+// telemetryservice.TelemetryService (telemetry)
+class TelemetryServiceType {
+    // get per-feature usage counts for the last sinceDays days
+    GetActivityStats(sinceDays: number): Promise<ActivityStat[]> {
+        return WOS.callBackendService("telemetry", "GetActivityStats", Array.from(arguments))
+    }
+
+    // record a use of a feature (stored locally in daily rollups, never uploaded)
+    RecordActivity(record: ActivityRecord): Promise<void> {
+        return WOS.callBackendService("telemetry", "RecordActivity", Array.from(arguments))
+    }
+}
+
+export const TelemetryService = new TelemetryServiceType();
+
 // userinputservice.UserInputService (userinput)
 class UserInputServiceType {
     SendUserInputResponse(arg1: UserInputResponse): Promise<void> {
@@ -141,7 +294,7 @@ class WindowServiceType {
     CloseWindow(windowId: string, fromElectron: boolean): Promise<void> {
         return WOS.callBackendService("window", "CloseWindow", Array.from(arguments))
     }
-    CreateWindow(winSize: WinSize, workspaceId: string): Promise<WaveWindow> {
+    CreateWindow(pos: Point, winSize: WinSize, workspaceId: string): Promise<WaveWindow> {
         return WOS.callBackendService("window", "CreateWindow", Array.from(arguments))
     }
     GetWindow(windowId: string): Promise<WaveWindow> {
@@ -211,6 +364,11 @@ class WorkspaceServiceType {
         return WOS.callBackendService("workspace", "ListWorkspaces", Array.from(arguments))
     }
 
+    // @returns object updates
+    RenameWorkspace(workspaceId: string, name: string): Promise<void> {
+        return WOS.callBackendService("workspace", "RenameWorkspace", Array.from(arguments))
+    }
+
     // @returns object updates
     SetActiveTab(workspaceId: string, tabId: string): Promise<void> {
         return WOS.callBackendService("workspace", "SetActiveTab", Array.from(arguments))
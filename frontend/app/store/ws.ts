@@ -47,6 +47,7 @@ class WSControl {
     eoOpts: ElectronOverrideOpts;
     noReconnect: boolean = false;
     onOpenTimeoutId: NodeJS.Timeout = null;
+    lastEventSeq: number = 0;
 
     constructor(
         baseHostPort: string,
@@ -187,6 +188,12 @@ class WSControl {
             // nothing
             return;
         }
+        if (eventData.seq != null) {
+            if (this.lastEventSeq > 0 && eventData.seq != this.lastEventSeq + 1) {
+                console.log("[ws] missed events", this.lastEventSeq + 1, "to", eventData.seq - 1);
+            }
+            this.lastEventSeq = eventData.seq;
+        }
         if (this.messageCallback) {
             try {
                 this.messageCallback(eventData);
@@ -17,21 +17,56 @@ class RpcApiType {
         return client.wshRpcCall("aisendmessage", data, opts);
     }
 
+    // command "aistreammessage" [responsestream]
+	AiStreamMessageCommand(client: WshClient, data: AiMessageData, opts?: RpcOpts): AsyncGenerator<WaveAIPacketType, void, boolean> {
+        return client.wshRpcStream("aistreammessage", data, opts);
+    }
+
+    // command "applylayoutpreset" [call]
+    ApplyLayoutPresetCommand(client: WshClient, data: CommandLayoutPresetData, opts?: RpcOpts): Promise<string[]> {
+        return client.wshRpcCall("applylayoutpreset", data, opts);
+    }
+
     // command "authenticate" [call]
     AuthenticateCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<CommandAuthenticateRtnData> {
         return client.wshRpcCall("authenticate", data, opts);
     }
 
+    // command "authpromptresponse" [call]
+    AuthPromptResponseCommand(client: WshClient, data: CommandAuthPromptResponseData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("authpromptresponse", data, opts);
+    }
+
     // command "blockinfo" [call]
     BlockInfoCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<BlockInfoData> {
         return client.wshRpcCall("blockinfo", data, opts);
     }
 
+    // command "captureblock" [responsestream]
+	CaptureBlockCommand(client: WshClient, data: CommandCaptureBlockData, opts?: RpcOpts): AsyncGenerator<string, void, boolean> {
+        return client.wshRpcStream("captureblock", data, opts);
+    }
+
+    // command "closetab" [call]
+    CloseTabCommand(client: WshClient, data: CommandCloseTabData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("closetab", data, opts);
+    }
+
+    // command "closewindow" [call]
+    CloseWindowCommand(client: WshClient, data: CommandCloseWindowData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("closewindow", data, opts);
+    }
+
     // command "connconnect" [call]
     ConnConnectCommand(client: WshClient, data: ConnRequest, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("connconnect", data, opts);
     }
 
+    // command "connconnectinteractive" [responsestream]
+	ConnConnectInteractiveCommand(client: WshClient, data: CommandConnConnectInteractiveData, opts?: RpcOpts): AsyncGenerator<ConnAuthPrompt, void, boolean> {
+        return client.wshRpcStream("connconnectinteractive", data, opts);
+    }
+
     // command "conndisconnect" [call]
     ConnDisconnectCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("conndisconnect", data, opts);
@@ -47,6 +82,11 @@ class RpcApiType {
         return client.wshRpcCall("connlist", null, opts);
     }
 
+    // command "connliststatus" [call]
+    ConnListStatusCommand(client: WshClient, opts?: RpcOpts): Promise<ConnStatus[]> {
+        return client.wshRpcCall("connliststatus", null, opts);
+    }
+
     // command "connreinstallwsh" [call]
     ConnReinstallWshCommand(client: WshClient, data: ConnExtData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("connreinstallwsh", data, opts);
@@ -57,6 +97,16 @@ class RpcApiType {
         return client.wshRpcCall("connstatus", null, opts);
     }
 
+    // command "conntest" [call]
+    ConnTestCommand(client: WshClient, data: CommandConnTestData, opts?: RpcOpts): Promise<CommandConnTestRtnData> {
+        return client.wshRpcCall("conntest", data, opts);
+    }
+
+    // command "conntrusthostkey" [call]
+    ConnTrustHostKeyCommand(client: WshClient, data: CommandConnTrustHostKeyData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("conntrusthostkey", data, opts);
+    }
+
     // command "connupdatewsh" [call]
     ConnUpdateWshCommand(client: WshClient, data: RemoteInfo, opts?: RpcOpts): Promise<boolean> {
         return client.wshRpcCall("connupdatewsh", data, opts);
@@ -72,6 +122,11 @@ class RpcApiType {
         return client.wshRpcCall("controllerinput", data, opts);
     }
 
+    // command "controllerrestart" [call]
+    ControllerRestartCommand(client: WshClient, data: CommandControllerRestartData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("controllerrestart", data, opts);
+    }
+
     // command "controllerresync" [call]
     ControllerResyncCommand(client: WshClient, data: CommandControllerResyncData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("controllerresync", data, opts);
@@ -83,20 +138,60 @@ class RpcApiType {
     }
 
     // command "createblock" [call]
-    CreateBlockCommand(client: WshClient, data: CommandCreateBlockData, opts?: RpcOpts): Promise<ORef> {
+    CreateBlockCommand(client: WshClient, data: CommandCreateBlockData, opts?: RpcOpts): Promise<CommandCreateBlockRtnData> {
         return client.wshRpcCall("createblock", data, opts);
     }
 
+    // command "createblocks" [call]
+    CreateBlocksCommand(client: WshClient, data: CommandCreateBlocksData, opts?: RpcOpts): Promise<string[]> {
+        return client.wshRpcCall("createblocks", data, opts);
+    }
+
     // command "createsubblock" [call]
     CreateSubBlockCommand(client: WshClient, data: CommandCreateSubBlockData, opts?: RpcOpts): Promise<ORef> {
         return client.wshRpcCall("createsubblock", data, opts);
     }
 
+    // command "createtab" [call]
+    CreateTabCommand(client: WshClient, data: CommandCreateTabData, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("createtab", data, opts);
+    }
+
+    // command "debugcachestats" [call]
+    DebugCacheStatsCommand(client: WshClient, opts?: RpcOpts): Promise<DebugCacheStatsData> {
+        return client.wshRpcCall("debugcachestats", null, opts);
+    }
+
+    // command "debugdbversion" [call]
+    DebugDBVersionCommand(client: WshClient, opts?: RpcOpts): Promise<DebugDBVersionRtnData> {
+        return client.wshRpcCall("debugdbversion", null, opts);
+    }
+
+    // command "debugintegrity" [call]
+    DebugIntegrityCommand(client: WshClient, data: CommandDebugIntegrityData, opts?: RpcOpts): Promise<DebugIntegrityRtnData> {
+        return client.wshRpcCall("debugintegrity", data, opts);
+    }
+
     // command "deleteblock" [call]
-    DeleteBlockCommand(client: WshClient, data: CommandDeleteBlockData, opts?: RpcOpts): Promise<void> {
+    DeleteBlockCommand(client: WshClient, data: CommandDeleteBlockData, opts?: RpcOpts): Promise<CommandDeleteBlockRtnData> {
         return client.wshRpcCall("deleteblock", data, opts);
     }
 
+    // command "deleteblocks" [call]
+    DeleteBlocksCommand(client: WshClient, data: CommandDeleteBlocksData, opts?: RpcOpts): Promise<DeleteBlocksRtnData> {
+        return client.wshRpcCall("deleteblocks", data, opts);
+    }
+
+    // command "deletelayoutpreset" [call]
+    DeleteLayoutPresetCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("deletelayoutpreset", data, opts);
+    }
+
+    // command "deletesecret" [call]
+    DeleteSecretCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<boolean> {
+        return client.wshRpcCall("deletesecret", data, opts);
+    }
+
     // command "deletesubblock" [call]
     DeleteSubBlockCommand(client: WshClient, data: CommandDeleteBlockData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("deletesubblock", data, opts);
@@ -112,6 +207,11 @@ class RpcApiType {
         return client.wshRpcCall("dispose", data, opts);
     }
 
+    // command "duplicateblock" [call]
+    DuplicateBlockCommand(client: WshClient, data: CommandDuplicateBlockData, opts?: RpcOpts): Promise<ORef> {
+        return client.wshRpcCall("duplicateblock", data, opts);
+    }
+
     // command "eventpublish" [call]
     EventPublishCommand(client: WshClient, data: WaveEvent, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("eventpublish", data, opts);
@@ -142,6 +242,11 @@ class RpcApiType {
         return client.wshRpcCall("eventunsuball", null, opts);
     }
 
+    // command "exportaiconversation" [responsestream]
+	ExportAIConversationCommand(client: WshClient, data: CommandExportAIConversationData, opts?: RpcOpts): AsyncGenerator<string, void, boolean> {
+        return client.wshRpcStream("exportaiconversation", data, opts);
+    }
+
     // command "fileappend" [call]
     FileAppendCommand(client: WshClient, data: CommandFileData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("fileappend", data, opts);
@@ -172,26 +277,111 @@ class RpcApiType {
         return client.wshRpcCall("filelist", data, opts);
     }
 
+    // command "filemkdir" [call]
+    FileMkdirCommand(client: WshClient, data: CommandFileOpData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("filemkdir", data, opts);
+    }
+
     // command "fileread" [call]
     FileReadCommand(client: WshClient, data: CommandFileData, opts?: RpcOpts): Promise<string> {
         return client.wshRpcCall("fileread", data, opts);
     }
 
+    // command "fileremove" [call]
+    FileRemoveCommand(client: WshClient, data: CommandFileOpData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("fileremove", data, opts);
+    }
+
+    // command "filerename" [call]
+    FileRenameCommand(client: WshClient, data: CommandFileOpData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("filerename", data, opts);
+    }
+
+    // command "filesave" [call]
+    FileSaveCommand(client: WshClient, data: CommandFileSaveData, opts?: RpcOpts): Promise<FileVersion> {
+        return client.wshRpcCall("filesave", data, opts);
+    }
+
+    // command "filetouch" [call]
+    FileTouchCommand(client: WshClient, data: CommandFileOpData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("filetouch", data, opts);
+    }
+
+    // command "filetransfer" [responsestream]
+	FileTransferCommand(client: WshClient, data: CommandFileTransferData, opts?: RpcOpts): AsyncGenerator<FileTransferProgress, void, boolean> {
+        return client.wshRpcStream("filetransfer", data, opts);
+    }
+
     // command "filewrite" [call]
     FileWriteCommand(client: WshClient, data: CommandFileData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("filewrite", data, opts);
     }
 
+    // command "focusblock" [call]
+    FocusBlockCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("focusblock", data, opts);
+    }
+
+    // command "focustab" [call]
+    FocusTabCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("focustab", data, opts);
+    }
+
     // command "focuswindow" [call]
     FocusWindowCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("focuswindow", data, opts);
     }
 
+    // command "getactivitystats" [call]
+    GetActivityStatsCommand(client: WshClient, data: number, opts?: RpcOpts): Promise<ActivityStat[]> {
+        return client.wshRpcCall("getactivitystats", data, opts);
+    }
+
+    // command "getcmdhistoryforblock" [call]
+    GetCmdHistoryForBlockCommand(client: WshClient, data: CommandGetCmdHistoryData, opts?: RpcOpts): Promise<CmdRecord[]> {
+        return client.wshRpcCall("getcmdhistoryforblock", data, opts);
+    }
+
+    // command "getconfigpath" [call]
+    GetConfigPathCommand(client: WshClient, data: CommandConfigPathData, opts?: RpcOpts): Promise<ConfigPathRtnData> {
+        return client.wshRpcCall("getconfigpath", data, opts);
+    }
+
+    // command "getfocusedblock" [call]
+    GetFocusedBlockCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("getfocusedblock", data, opts);
+    }
+
     // command "getmeta" [call]
     GetMetaCommand(client: WshClient, data: CommandGetMetaData, opts?: RpcOpts): Promise<MetaType> {
         return client.wshRpcCall("getmeta", data, opts);
     }
 
+    // command "getmetrics" [call]
+    GetMetricsCommand(client: WshClient, data: CommandGetMetricsData, opts?: RpcOpts): Promise<TimeSeriesData[]> {
+        return client.wshRpcCall("getmetrics", data, opts);
+    }
+
+    // command "getscrollback" [responsestream]
+	GetScrollbackCommand(client: WshClient, data: CommandGetScrollbackData, opts?: RpcOpts): AsyncGenerator<CommandGetScrollbackRtnData, void, boolean> {
+        return client.wshRpcStream("getscrollback", data, opts);
+    }
+
+    // command "getsecretnames" [call]
+    GetSecretNamesCommand(client: WshClient, opts?: RpcOpts): Promise<string[]> {
+        return client.wshRpcCall("getsecretnames", null, opts);
+    }
+
+    // command "getserverstatus" [call]
+    GetServerStatusCommand(client: WshClient, opts?: RpcOpts): Promise<ServerStatusData> {
+        return client.wshRpcCall("getserverstatus", null, opts);
+    }
+
+    // command "gettabtitle" [call]
+    GetTabTitleCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("gettabtitle", data, opts);
+    }
+
     // command "getupdatechannel" [call]
     GetUpdateChannelCommand(client: WshClient, opts?: RpcOpts): Promise<string> {
         return client.wshRpcCall("getupdatechannel", null, opts);
@@ -202,11 +392,76 @@ class RpcApiType {
         return client.wshRpcCall("getvar", data, opts);
     }
 
+    // command "importhistory" [call]
+    ImportHistoryCommand(client: WshClient, data: CommandImportHistoryData, opts?: RpcOpts): Promise<number> {
+        return client.wshRpcCall("importhistory", data, opts);
+    }
+
+    // command "injectpath" [call]
+    InjectPathCommand(client: WshClient, data: CommandInjectPathData, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("injectpath", data, opts);
+    }
+
+    // command "launchwindow" [call]
+    LaunchWindowCommand(client: WshClient, data: CommandLaunchWindowData, opts?: RpcOpts): Promise<LaunchWindowRtnData> {
+        return client.wshRpcCall("launchwindow", data, opts);
+    }
+
+    // command "layoutaction" [call]
+    LayoutActionCommand(client: WshClient, data: CommandLayoutActionData, opts?: RpcOpts): Promise<CommandLayoutActionRtnData> {
+        return client.wshRpcCall("layoutaction", data, opts);
+    }
+
+    // command "linkblocks" [call]
+    LinkBlocksCommand(client: WshClient, data: CommandLinkBlocksData, opts?: RpcOpts): Promise<BlockLink> {
+        return client.wshRpcCall("linkblocks", data, opts);
+    }
+
+    // command "listblocklinks" [call]
+    ListBlockLinksCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<BlockLink[]> {
+        return client.wshRpcCall("listblocklinks", data, opts);
+    }
+
+    // command "listblocks" [call]
+    ListBlocksCommand(client: WshClient, data: CommandListData, opts?: RpcOpts): Promise<BlockListEntry[]> {
+        return client.wshRpcCall("listblocks", data, opts);
+    }
+
+    // command "listlayoutpresets" [call]
+    ListLayoutPresetsCommand(client: WshClient, opts?: RpcOpts): Promise<LayoutPreset[]> {
+        return client.wshRpcCall("listlayoutpresets", null, opts);
+    }
+
+    // command "listtabs" [call]
+    ListTabsCommand(client: WshClient, data: CommandListData, opts?: RpcOpts): Promise<TabListEntry[]> {
+        return client.wshRpcCall("listtabs", data, opts);
+    }
+
+    // command "listwindows" [call]
+    ListWindowsCommand(client: WshClient, opts?: RpcOpts): Promise<WindowListEntry[]> {
+        return client.wshRpcCall("listwindows", null, opts);
+    }
+
+    // command "logtail" [responsestream]
+	LogTailCommand(client: WshClient, data: CommandLogTailData, opts?: RpcOpts): AsyncGenerator<LogRecord, void, boolean> {
+        return client.wshRpcStream("logtail", data, opts);
+    }
+
     // command "message" [call]
     MessageCommand(client: WshClient, data: CommandMessageData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("message", data, opts);
     }
 
+    // command "moveblock" [call]
+    MoveBlockCommand(client: WshClient, data: CommandMoveBlockData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("moveblock", data, opts);
+    }
+
+    // command "movetab" [call]
+    MoveTabCommand(client: WshClient, data: CommandMoveTabData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("movetab", data, opts);
+    }
+
     // command "notify" [call]
     NotifyCommand(client: WshClient, data: WaveNotificationOptions, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("notify", data, opts);
@@ -217,6 +472,41 @@ class RpcApiType {
         return client.wshRpcCall("path", data, opts);
     }
 
+    // command "previewunwatch" [call]
+    PreviewUnwatchCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("previewunwatch", data, opts);
+    }
+
+    // command "previewwatch" [call]
+    PreviewWatchCommand(client: WshClient, data: CommandPreviewWatchData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("previewwatch", data, opts);
+    }
+
+    // command "queryblocks" [call]
+    QueryBlocksCommand(client: WshClient, data: CommandQueryBlocksData, opts?: RpcOpts): Promise<BlockListEntry[]> {
+        return client.wshRpcCall("queryblocks", data, opts);
+    }
+
+    // command "recordactivity" [call]
+    RecordActivityCommand(client: WshClient, data: ActivityRecord, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("recordactivity", data, opts);
+    }
+
+    // command "remoteexec" [responsestream]
+	RemoteExecCommand(client: WshClient, data: CommandRemoteExecData, opts?: RpcOpts): AsyncGenerator<RemoteExecPacket, void, boolean> {
+        return client.wshRpcStream("remoteexec", data, opts);
+    }
+
+    // command "remoteexecinput" [call]
+    RemoteExecInputCommand(client: WshClient, data: CommandRemoteExecInputData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remoteexecinput", data, opts);
+    }
+
+    // command "remotefilechecksum" [call]
+    RemoteFileChecksumCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("remotefilechecksum", data, opts);
+    }
+
     // command "remotefiledelete" [call]
     RemoteFileDeleteCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("remotefiledelete", data, opts);
@@ -232,6 +522,21 @@ class RpcApiType {
         return client.wshRpcCall("remotefilejoin", data, opts);
     }
 
+    // command "remotefilereadat" [call]
+    RemoteFileReadAtCommand(client: WshClient, data: CommandRemoteFileReadAtData, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("remotefilereadat", data, opts);
+    }
+
+    // command "remotefilereadrange" [call]
+    RemoteFileReadRangeCommand(client: WshClient, data: CommandRemoteFileReadRangeData, opts?: RpcOpts): Promise<FileReadRangeRtnData> {
+        return client.wshRpcCall("remotefilereadrange", data, opts);
+    }
+
+    // command "remotefileremove" [call]
+    RemoteFileRemoveCommand(client: WshClient, data: CommandRemoteFileRemoveData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remotefileremove", data, opts);
+    }
+
     // command "remotefilerename" [call]
     RemoteFileRenameCommand(client: WshClient, data: string[], opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("remotefilerename", data, opts);
@@ -242,6 +547,11 @@ class RpcApiType {
         return client.wshRpcCall("remotefiletouch", data, opts);
     }
 
+    // command "remotefilewriteat" [call]
+    RemoteFileWriteAtCommand(client: WshClient, data: CommandRemoteFileWriteAtData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remotefilewriteat", data, opts);
+    }
+
     // command "remotegetinfo" [call]
     RemoteGetInfoCommand(client: WshClient, opts?: RpcOpts): Promise<RemoteInfo> {
         return client.wshRpcCall("remotegetinfo", null, opts);
@@ -252,11 +562,21 @@ class RpcApiType {
         return client.wshRpcCall("remoteinstallrcfiles", null, opts);
     }
 
+    // command "remotelistentries" [call]
+    RemoteListEntriesCommand(client: WshClient, data: CommandRemoteListEntriesData, opts?: RpcOpts): Promise<RemoteListEntriesRtnData> {
+        return client.wshRpcCall("remotelistentries", data, opts);
+    }
+
     // command "remotemkdir" [call]
     RemoteMkdirCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("remotemkdir", data, opts);
     }
 
+    // command "remoteremovetempfile" [call]
+    RemoteRemoveTempFileCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remoteremovetempfile", data, opts);
+    }
+
     // command "remotestreamcpudata" [responsestream]
 	RemoteStreamCpuDataCommand(client: WshClient, opts?: RpcOpts): AsyncGenerator<TimeSeriesData, void, boolean> {
         return client.wshRpcStream("remotestreamcpudata", null, opts);
@@ -267,11 +587,41 @@ class RpcApiType {
         return client.wshRpcStream("remotestreamfile", data, opts);
     }
 
+    // command "remotetardir" [call]
+    RemoteTarDirCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("remotetardir", data, opts);
+    }
+
+    // command "remotethumbnail" [call]
+    RemoteThumbnailCommand(client: WshClient, data: CommandRemoteThumbnailData, opts?: RpcOpts): Promise<ThumbnailRtnData> {
+        return client.wshRpcCall("remotethumbnail", data, opts);
+    }
+
+    // command "remoteuntar" [call]
+    RemoteUntarCommand(client: WshClient, data: CommandRemoteUntarData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("remoteuntar", data, opts);
+    }
+
     // command "remotewritefile" [call]
     RemoteWriteFileCommand(client: WshClient, data: CommandRemoteWriteFileData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("remotewritefile", data, opts);
     }
 
+    // command "renametab" [call]
+    RenameTabCommand(client: WshClient, data: CommandRenameTabData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("renametab", data, opts);
+    }
+
+    // command "renderhtml" [call]
+    RenderHtmlCommand(client: WshClient, data: CommandRenderHtmlData, opts?: RpcOpts): Promise<CommandCreateBlockRtnData> {
+        return client.wshRpcCall("renderhtml", data, opts);
+    }
+
+    // command "resetconfig" [call]
+    ResetConfigCommand(client: WshClient, data: CommandConfigPathData, opts?: RpcOpts): Promise<ConfigPathRtnData> {
+        return client.wshRpcCall("resetconfig", data, opts);
+    }
+
     // command "resolveids" [call]
     ResolveIdsCommand(client: WshClient, data: CommandResolveIdsData, opts?: RpcOpts): Promise<CommandResolveIdsRtnData> {
         return client.wshRpcCall("resolveids", data, opts);
@@ -287,6 +637,26 @@ class RpcApiType {
         return client.wshRpcCall("routeunannounce", null, opts);
     }
 
+    // command "savelayoutpreset" [call]
+    SaveLayoutPresetCommand(client: WshClient, data: CommandLayoutPresetData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("savelayoutpreset", data, opts);
+    }
+
+    // command "searchhistory" [call]
+    SearchHistoryCommand(client: WshClient, data: CommandSearchHistoryData, opts?: RpcOpts): Promise<CommandSearchHistoryRtnData> {
+        return client.wshRpcCall("searchhistory", data, opts);
+    }
+
+    // command "searchscrollback" [call]
+    SearchScrollbackCommand(client: WshClient, data: CommandSearchScrollbackData, opts?: RpcOpts): Promise<CommandSearchScrollbackRtnData> {
+        return client.wshRpcCall("searchscrollback", data, opts);
+    }
+
+    // command "setblockmagnified" [call]
+    SetBlockMagnifiedCommand(client: WshClient, data: CommandSetBlockMagnifiedData, opts?: RpcOpts): Promise<CommandSetBlockMagnifiedRtnData> {
+        return client.wshRpcCall("setblockmagnified", data, opts);
+    }
+
     // command "setconfig" [call]
     SetConfigCommand(client: WshClient, data: SettingsType, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("setconfig", data, opts);
@@ -297,11 +667,36 @@ class RpcApiType {
         return client.wshRpcCall("setconnectionsconfig", data, opts);
     }
 
+    // command "setloglevel" [call]
+    SetLogLevelCommand(client: WshClient, data: CommandSetLogLevelData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("setloglevel", data, opts);
+    }
+
     // command "setmeta" [call]
     SetMetaCommand(client: WshClient, data: CommandSetMetaData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("setmeta", data, opts);
     }
 
+    // command "setsecret" [call]
+    SetSecretCommand(client: WshClient, data: CommandSetSecretData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("setsecret", data, opts);
+    }
+
+    // command "settabgroup" [call]
+    SetTabGroupCommand(client: WshClient, data: CommandSetTabGroupData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("settabgroup", data, opts);
+    }
+
+    // command "settabpinned" [call]
+    SetTabPinnedCommand(client: WshClient, data: CommandSetTabPinnedData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("settabpinned", data, opts);
+    }
+
+    // command "settabtitletemplate" [call]
+    SetTabTitleTemplateCommand(client: WshClient, data: CommandSetTabTitleTemplateData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("settabtitletemplate", data, opts);
+    }
+
     // command "setvar" [call]
     SetVarCommand(client: WshClient, data: CommandVarData, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("setvar", data, opts);
@@ -312,11 +707,31 @@ class RpcApiType {
         return client.wshRpcCall("setview", data, opts);
     }
 
+    // command "setwindowgeometry" [call]
+    SetWindowGeometryCommand(client: WshClient, data: CommandSetWindowGeometryData, opts?: RpcOpts): Promise<WaveWindow> {
+        return client.wshRpcCall("setwindowgeometry", data, opts);
+    }
+
+    // command "snapshotexport" [call]
+    SnapshotExportCommand(client: WshClient, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("snapshotexport", null, opts);
+    }
+
+    // command "snapshotimport" [call]
+    SnapshotImportCommand(client: WshClient, data: CommandSnapshotImportData, opts?: RpcOpts): Promise<SnapshotImportRtnData> {
+        return client.wshRpcCall("snapshotimport", data, opts);
+    }
+
     // command "streamcpudata" [responsestream]
 	StreamCpuDataCommand(client: WshClient, data: CpuDataRequest, opts?: RpcOpts): AsyncGenerator<TimeSeriesData, void, boolean> {
         return client.wshRpcStream("streamcpudata", data, opts);
     }
 
+    // command "streamevents" [responsestream]
+	StreamEventsCommand(client: WshClient, data: CommandStreamEventsData, opts?: RpcOpts): AsyncGenerator<WSEventType, void, boolean> {
+        return client.wshRpcStream("streamevents", data, opts);
+    }
+
     // command "streamtest" [responsestream]
 	StreamTestCommand(client: WshClient, opts?: RpcOpts): AsyncGenerator<number, void, boolean> {
         return client.wshRpcStream("streamtest", null, opts);
@@ -327,11 +742,36 @@ class RpcApiType {
         return client.wshRpcStream("streamwaveai", data, opts);
     }
 
+    // command "termclear" [call]
+    TermClearCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("termclear", data, opts);
+    }
+
+    // command "termresize" [call]
+    TermResizeCommand(client: WshClient, data: CommandTermResizeData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("termresize", data, opts);
+    }
+
+    // command "termsend" [call]
+    TermSendCommand(client: WshClient, data: CommandTermSendData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("termsend", data, opts);
+    }
+
+    // command "termtrim" [call]
+    TermTrimCommand(client: WshClient, data: CommandTermTrimData, opts?: RpcOpts): Promise<number> {
+        return client.wshRpcCall("termtrim", data, opts);
+    }
+
     // command "test" [call]
     TestCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("test", data, opts);
     }
 
+    // command "unlinkblocks" [call]
+    UnlinkBlocksCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("unlinkblocks", data, opts);
+    }
+
     // command "vdomasyncinitiation" [call]
     VDomAsyncInitiationCommand(client: WshClient, data: VDomAsyncInitiationRequest, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("vdomasyncinitiation", data, opts);
@@ -357,21 +797,56 @@ class RpcApiType {
         return client.wshRpcCall("waitforroute", data, opts);
     }
 
+    // command "watchfilecreate" [call]
+    WatchFileCreateCommand(client: WshClient, data: string, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("watchfilecreate", data, opts);
+    }
+
     // command "waveinfo" [call]
     WaveInfoCommand(client: WshClient, opts?: RpcOpts): Promise<WaveInfoData> {
         return client.wshRpcCall("waveinfo", null, opts);
     }
 
+    // command "webcheckurl" [call]
+    WebCheckUrlCommand(client: WshClient, data: CommandWebCheckUrlData, opts?: RpcOpts): Promise<string> {
+        return client.wshRpcCall("webcheckurl", data, opts);
+    }
+
+    // command "webnavigate" [call]
+    WebNavigateCommand(client: WshClient, data: CommandWebNavigateData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("webnavigate", data, opts);
+    }
+
     // command "webselector" [call]
     WebSelectorCommand(client: WshClient, data: CommandWebSelectorData, opts?: RpcOpts): Promise<string[]> {
         return client.wshRpcCall("webselector", data, opts);
     }
 
+    // command "workspacecreate" [call]
+    WorkspaceCreateCommand(client: WshClient, data: CommandWorkspaceCreateData, opts?: RpcOpts): Promise<Workspace> {
+        return client.wshRpcCall("workspacecreate", data, opts);
+    }
+
+    // command "workspacedelete" [call]
+    WorkspaceDeleteCommand(client: WshClient, data: CommandWorkspaceDeleteData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("workspacedelete", data, opts);
+    }
+
     // command "workspacelist" [call]
     WorkspaceListCommand(client: WshClient, opts?: RpcOpts): Promise<WorkspaceInfoData[]> {
         return client.wshRpcCall("workspacelist", null, opts);
     }
 
+    // command "workspacerename" [call]
+    WorkspaceRenameCommand(client: WshClient, data: CommandWorkspaceRenameData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("workspacerename", data, opts);
+    }
+
+    // command "workspaceswitch" [call]
+    WorkspaceSwitchCommand(client: WshClient, data: CommandWorkspaceSwitchData, opts?: RpcOpts): Promise<void> {
+        return client.wshRpcCall("workspaceswitch", data, opts);
+    }
+
     // command "wshactivity" [call]
     WshActivityCommand(client: WshClient, data: {[key: string]: number}, opts?: RpcOpts): Promise<void> {
         return client.wshRpcCall("wshactivity", data, opts);
@@ -10,6 +10,19 @@ import { addWSReconnectHandler, ElectronOverrideOpts, globalWS, initGlobalWS, WS
 let DefaultRouter: WshRouter;
 let TabRpcClient: WshClient;
 
+// an rpc error response, errorCode is set for the errors that have a code (see wshrpc.ErrorCode_* in pkg/wshrpc)
+// and errorData for the ones that have details (e.g. a FileConflictData for "conflict")
+class RpcError extends Error {
+    errorCode: string;
+    errorData: any;
+
+    constructor(message: string, errorCode?: string, errorData?: any) {
+        super(message);
+        this.errorCode = errorCode;
+        this.errorData = errorData;
+    }
+}
+
 async function* rpcResponseGenerator(
     openRpcs: Map<string, ClientRpcEntry>,
     command: string,
@@ -44,7 +57,7 @@ async function* rpcResponseGenerator(
             while (msgQueue.length > 0) {
                 const msg = msgQueue.shift()!;
                 if (msg.error != null) {
-                    throw new Error(msg.error);
+                    throw new RpcError(msg.error, msg.errorcode, msg.errordata);
                 }
                 if (!msg.cont && msg.data == null) {
                     return;
@@ -111,6 +124,9 @@ if (globalThis.window != null) {
 function initElectronWshrpc(electronClient: WshClient, eoOpts: ElectronOverrideOpts) {
     DefaultRouter = new WshRouter(new UpstreamWshRpcProxy());
     const handleFn = (event: WSEventType) => {
+        if (event.eventtype != "rpc") {
+            return;
+        }
         DefaultRouter.recvRpcMessage(event.data);
     };
     initGlobalWS(getWSServerEndpoint(), "electron", handleFn, eoOpts);
@@ -126,9 +142,20 @@ function shutdownWshrpc() {
     globalWS?.shutdown();
 }
 
+// handlers for the (non-rpc) events that wavesrv sends directly to a tab
+const wsEventHandlers = new Map<string, (event: WSEventType) => void>();
+
+function registerWSEventHandler(eventType: string, handler: (event: WSEventType) => void) {
+    wsEventHandlers.set(eventType, handler);
+}
+
 function initWshrpc(tabId: string): WSControl {
     DefaultRouter = new WshRouter(new UpstreamWshRpcProxy());
     const handleFn = (event: WSEventType) => {
+        if (event.eventtype != "rpc") {
+            wsEventHandlers.get(event.eventtype)?.(event);
+            return;
+        }
         DefaultRouter.recvRpcMessage(event.data);
     };
     initGlobalWS(getWSServerEndpoint(), tabId, handleFn);
@@ -149,4 +176,14 @@ class UpstreamWshRpcProxy implements AbstractWshClient {
     }
 }
 
-export { DefaultRouter, initElectronWshrpc, initWshrpc, sendRpcCommand, sendRpcResponse, shutdownWshrpc, TabRpcClient };
+export {
+    DefaultRouter,
+    initElectronWshrpc,
+    initWshrpc,
+    registerWSEventHandler,
+    RpcError,
+    sendRpcCommand,
+    sendRpcResponse,
+    shutdownWshrpc,
+    TabRpcClient,
+};
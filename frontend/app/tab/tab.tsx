@@ -3,16 +3,34 @@
 
 import { atoms, globalStore, refocusNode } from "@/app/store/global";
 import { RpcApi } from "@/app/store/wshclientapi";
-import { TabRpcClient } from "@/app/store/wshrpcutil";
+import { registerWSEventHandler, TabRpcClient } from "@/app/store/wshrpcutil";
 import { Button } from "@/element/button";
 import { ContextMenuModel } from "@/store/contextmenu";
 import { fireAndForget } from "@/util/util";
 import { clsx } from "clsx";
+import { atom, PrimitiveAtom, useAtomValue } from "jotai";
 import { forwardRef, memo, useCallback, useEffect, useImperativeHandle, useRef, useState } from "react";
 import { ObjectService } from "../store/services";
 import { makeORef, useWaveObjectValue } from "../store/wos";
 import "./tab.scss";
 
+// the titles of the tabs with a title template (rendered by the backend, updates are sent as tabtitle events)
+const tabTitleAtoms = new Map<string, PrimitiveAtom<string>>();
+
+function getTabTitleAtom(tabId: string): PrimitiveAtom<string> {
+    let titleAtom = tabTitleAtoms.get(tabId);
+    if (titleAtom == null) {
+        titleAtom = atom(null) as PrimitiveAtom<string>;
+        tabTitleAtoms.set(tabId, titleAtom);
+    }
+    return titleAtom;
+}
+
+registerWSEventHandler("tabtitle", (event) => {
+    const data: TabTitleData = event.data;
+    globalStore.set(getTabTitleAtom(data.tabid), data.title);
+});
+
 interface TabProps {
     id: string;
     active: boolean;
@@ -49,6 +67,10 @@ const Tab = memo(
             ref
         ) => {
             const [tabData, _] = useWaveObjectValue<Tab>(makeORef("tab", id));
+            const tabConn = tabData?.meta?.connection;
+            const titleTemplate = tabData?.meta?.["tab:titletemplate"];
+            const computedTitle = useAtomValue(getTabTitleAtom(id));
+            const tabTitle = titleTemplate ? (computedTitle ?? tabData?.name) : tabData?.name;
             const [originalName, setOriginalName] = useState("");
             const [isEditable, setIsEditable] = useState(false);
 
@@ -60,10 +82,26 @@ const Tab = memo(
             useImperativeHandle(ref, () => tabRef.current as HTMLDivElement);
 
             useEffect(() => {
-                if (tabData?.name) {
-                    setOriginalName(tabData.name);
+                if (tabTitle) {
+                    setOriginalName(tabTitle);
+                }
+            }, [tabTitle]);
+
+            useEffect(() => {
+                if (!titleTemplate) {
+                    return;
                 }
-            }, [tabData]);
+                fireAndForget(async () => {
+                    const title = await ObjectService.GetTabTitle(id);
+                    globalStore.set(getTabTitleAtom(id), title);
+                });
+            }, [id, titleTemplate]);
+
+            useEffect(() => {
+                if (active && tabTitle) {
+                    document.title = `Wave Terminal - ${tabTitle}`;
+                }
+            }, [active, tabTitle]);
 
             useEffect(() => {
                 return () => {
@@ -96,7 +134,14 @@ const Tab = memo(
                 newText = newText || originalName;
                 editableRef.current.innerText = newText;
                 setIsEditable(false);
-                fireAndForget(() => ObjectService.UpdateTabName(id, newText));
+                if (titleTemplate) {
+                    // renaming a tab with a template replaces the template (unless the title wasn't changed)
+                    if (newText != originalName) {
+                        fireAndForget(() => ObjectService.SetTabTitleTemplate(id, newText));
+                    }
+                } else {
+                    fireAndForget(() => ObjectService.UpdateTabName(id, newText));
+                }
                 setTimeout(() => refocusNode(null), 10);
             };
 
@@ -159,6 +204,31 @@ const Tab = memo(
                         { type: "separator" },
                     ];
                     const fullConfig = globalStore.get(atoms.fullConfigAtom);
+                    // new blocks in the tab use this connection (blocks that are already open keep theirs)
+                    const connSubmenu: ContextMenuItem[] = [
+                        {
+                            label: "None (Local)",
+                            type: "checkbox",
+                            checked: !tabConn,
+                            click: () => fireAndForget(() => ObjectService.SetTabConnection(id, "")),
+                        },
+                    ];
+                    const connNames = Object.keys(fullConfig?.connections ?? {}).sort();
+                    if (tabConn && !connNames.includes(tabConn)) {
+                        connNames.unshift(tabConn);
+                    }
+                    for (const connName of connNames) {
+                        connSubmenu.push({
+                            label: connName,
+                            type: "checkbox",
+                            checked: connName == tabConn,
+                            click: () => fireAndForget(() => ObjectService.SetTabConnection(id, connName)),
+                        });
+                    }
+                    menu.push(
+                        { label: "Default Connection", type: "submenu", submenu: connSubmenu },
+                        { type: "separator" }
+                    );
                     const bgPresets: string[] = [];
                     for (const key in fullConfig?.presets ?? {}) {
                         if (key.startsWith("bg@")) {
@@ -192,7 +262,7 @@ const Tab = memo(
                     menu.push({ label: "Close Tab", click: () => onClose(null) });
                     ContextMenuModel.showContextMenu(menu, e);
                 },
-                [onPinChange, handleRenameTab, id, onClose, isPinned]
+                [onPinChange, handleRenameTab, id, onClose, isPinned, tabConn]
             );
 
             return (
@@ -210,6 +280,12 @@ const Tab = memo(
                     data-tab-id={id}
                 >
                     <div className="tab-inner">
+                        {tabConn && (
+                            <i
+                                className="fa fa-solid fa-arrow-right-arrow-left conn-indicator"
+                                title={`New blocks connect to ${tabConn}`}
+                            />
+                        )}
                         <div
                             ref={editableRef}
                             className={clsx("name", { focused: isEditable })}
@@ -219,7 +295,7 @@ const Tab = memo(
                             onKeyDown={handleKeyDown}
                             suppressContentEditableWarning={true}
                         >
-                            {tabData?.name}
+                            {tabTitle}
                         </div>
                         {isPinned ? (
                             <Button
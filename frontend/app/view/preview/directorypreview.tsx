@@ -5,15 +5,20 @@ import { Button } from "@/app/element/button";
 import { Input } from "@/app/element/input";
 import { ContextMenuModel } from "@/app/store/contextmenu";
 import { PLATFORM, atoms, createBlock, getApi, globalStore } from "@/app/store/global";
-import { FileService } from "@/app/store/services";
+import { ObjectService } from "@/app/store/services";
+import { waveEventSubscribe } from "@/app/store/wps";
+import { RpcApi } from "@/app/store/wshclientapi";
+import { RpcError, TabRpcClient } from "@/app/store/wshrpcutil";
 import type { PreviewModel } from "@/app/view/preview/preview";
+import * as WOS from "@/store/wos";
 import { checkKeyPressed, isCharacterKeyEvent } from "@/util/keyutil";
-import { base64ToString, fireAndForget, isBlank } from "@/util/util";
+import { fireAndForget, isBlank, makeConnRoute } from "@/util/util";
 import { offset, useDismiss, useFloating, useInteractions } from "@floating-ui/react";
 import {
     Column,
     Row,
     RowData,
+    SortingState,
     Table,
     createColumnHelper,
     flexRender,
@@ -50,10 +55,22 @@ interface DirectoryTableProps {
     entryManagerOverlayPropsAtom: PrimitiveAtom<EntryManagerOverlayProps>;
     newFile: () => void;
     newDirectory: () => void;
+    sortKey: string;
+    sortDesc: boolean;
+    setSort: (sortKey: string, sortDesc: boolean) => void;
 }
 
 const columnHelper = createColumnHelper<FileInfo>();
 
+// listings are sorted (and paged) by the backend, these are the columns it can sort by
+const SortKeyByColumn: { [columnId: string]: string } = {
+    name: "name",
+    modtime: "mtime",
+    size: "size",
+};
+
+const DefaultListLimit = 500;
+
 const displaySuffixes = {
     B: "b",
     kB: "k",
@@ -152,6 +169,104 @@ type EntryManagerOverlayProps = {
     getReferenceProps?: () => any;
 };
 
+// the image types the backend makes thumbnails of (it can't decode webp, those keep the icon)
+const ThumbnailMimeTypes = new Set(["image/jpeg", "image/png", "image/gif"]);
+const ThumbnailMaxDim = 64;
+const ThumbnailTimeoutMs = 30000;
+const MaxCachedThumbnails = 1000;
+
+// the thumbnail data urls by connection, path, modtime, and size, so scrolling back or re-rendering the table
+// doesn't fetch them again.  files without a thumbnail are cached as null (they show the icon).
+const thumbnailCache = new Map<string, Promise<string>>();
+
+function getThumbnailUrl(conn: string, finfo: FileInfo): Promise<string> {
+    const key = `${conn}|${finfo.path}|${finfo.modtime}|${finfo.size}`;
+    let rtn = thumbnailCache.get(key);
+    if (rtn != null) {
+        return rtn;
+    }
+    rtn = RpcApi.RemoteThumbnailCommand(
+        TabRpcClient,
+        { path: finfo.path, maxdim: ThumbnailMaxDim },
+        { route: makeConnRoute(conn), timeout: ThumbnailTimeoutMs }
+    )
+        .then((data) => `data:image/jpeg;base64,${data.data64}`)
+        .catch((e) => {
+            if (!(e instanceof RpcError) || e.errorCode != "nothumbnail") {
+                // not cached, so it is fetched again next time (e.g. after a timeout)
+                console.log("error getting thumbnail", finfo.path, e);
+                thumbnailCache.delete(key);
+            }
+            return null;
+        });
+    if (thumbnailCache.size >= MaxCachedThumbnails) {
+        thumbnailCache.delete(thumbnailCache.keys().next().value);
+    }
+    thumbnailCache.set(key, rtn);
+    return rtn;
+}
+
+// the file operations run in wavesrv (the same as wsh file rm/mv/mkdir), they can't change anything outside of the
+// directory the block is showing (the root)
+function makeFileOpData(conn: string, dirPath: string, path: string): CommandFileOpData {
+    return { connection: conn, root: dirPath, path: path };
+}
+
+// the scope of the dirchange events (wavesrv sends one after a file operation changes a directory)
+function makeDirChangeScope(conn: string, dirPath: string): string {
+    let dir = dirPath;
+    if (dir.length > 1 && dir.endsWith("/")) {
+        dir = dir.slice(0, -1);
+    }
+    return `${isBlank(conn) ? "local" : conn}:${dir}`;
+}
+
+interface DirEntryIconProps {
+    finfo: FileInfo;
+    conn: string;
+    iconClass: string;
+    iconColor: string;
+}
+
+// the file's icon, or a thumbnail for images (fetched once the row is scrolled into view)
+const DirEntryIcon = memo(({ finfo, conn, iconClass, iconColor }: DirEntryIconProps) => {
+    const iconRef = useRef<HTMLSpanElement>(null);
+    const [thumbUrl, setThumbUrl] = useState<string>(null);
+    const canThumbnail = ThumbnailMimeTypes.has(finfo.mimetype);
+    useEffect(() => {
+        setThumbUrl(null);
+        if (!canThumbnail || iconRef.current == null) {
+            return;
+        }
+        let canceled = false;
+        const observer = new IntersectionObserver((entries) => {
+            if (!entries.some((entry) => entry.isIntersecting)) {
+                return;
+            }
+            observer.disconnect();
+            getThumbnailUrl(conn, finfo).then((url) => {
+                if (!canceled) {
+                    setThumbUrl(url);
+                }
+            });
+        });
+        observer.observe(iconRef.current);
+        return () => {
+            canceled = true;
+            observer.disconnect();
+        };
+    }, [conn, finfo.path, finfo.modtime, finfo.size, canThumbnail]);
+    return (
+        <span ref={iconRef} className="dir-table-icon">
+            {thumbUrl != null ? (
+                <img className="dir-table-thumbnail" src={thumbUrl} />
+            ) : (
+                <i className={iconClass} style={{ color: iconColor }}></i>
+            )}
+        </span>
+    );
+});
+
 const EntryManagerOverlay = memo(
     ({
         entryManagerType,
@@ -205,8 +320,12 @@ function DirectoryTable({
     entryManagerOverlayPropsAtom,
     newFile,
     newDirectory,
+    sortKey,
+    sortDesc,
+    setSort,
 }: DirectoryTableProps) {
     const fullConfig = useAtomValue(atoms.fullConfigAtom);
+    const conn = useAtomValue(model.connection);
     const getIconFromMimeType = useCallback(
         (mimeType: string): string => {
             while (mimeType.length > 0) {
@@ -228,10 +347,12 @@ function DirectoryTable({
         () => [
             columnHelper.accessor("mimetype", {
                 cell: (info) => (
-                    <i
-                        className={getIconFromMimeType(info.getValue() ?? "")}
-                        style={{ color: getIconColor(info.getValue() ?? "") }}
-                    ></i>
+                    <DirEntryIcon
+                        finfo={info.row.original}
+                        conn={conn}
+                        iconClass={getIconFromMimeType(info.getValue() ?? "")}
+                        iconColor={getIconColor(info.getValue() ?? "")}
+                    />
                 ),
                 header: () => <span></span>,
                 id: "logo",
@@ -250,7 +371,7 @@ function DirectoryTable({
                 header: () => <span>Perm</span>,
                 size: 91,
                 minSize: 90,
-                sortingFn: "alphanumeric",
+                enableSorting: false,
             }),
             columnHelper.accessor("modtime", {
                 cell: (info) => (
@@ -273,11 +394,11 @@ function DirectoryTable({
                 header: () => <span className="dir-table-head-type">Type</span>,
                 size: 97,
                 minSize: 97,
-                sortingFn: "alphanumeric",
+                enableSorting: false,
             }),
             columnHelper.accessor("path", {}),
         ],
-        [fullConfig]
+        [fullConfig, conn]
     );
 
     const setEntryManagerProps = useSetAtom(entryManagerOverlayPropsAtom);
@@ -288,14 +409,16 @@ function DirectoryTable({
             entryManagerType: EntryManagerType.EditName,
             startingValue: fileName,
             onSave: (newName: string) => {
-                let newPath: string;
                 if (newName !== fileName) {
-                    newPath = path.replace(fileName, newName);
+                    const newPath = path.slice(0, path.length - fileName.length) + newName;
                     console.log(`replacing ${fileName} with ${newName}: ${path}`);
                     fireAndForget(async () => {
                         const connection = await globalStore.get(model.connection);
-                        await FileService.Rename(connection, path, newPath);
-                        model.refreshCallback();
+                        const dirPath = await globalStore.get(model.normFilePath);
+                        await RpcApi.FileRenameCommand(TabRpcClient, {
+                            ...makeFileOpData(connection, dirPath, path),
+                            newpath: newPath,
+                        }).catch((e) => console.log("error renaming file", path, e));
                     });
                 }
                 setEntryManagerProps(undefined);
@@ -303,20 +426,30 @@ function DirectoryTable({
         });
     }, []);
 
+    const sorting: SortingState = useMemo(() => {
+        const columnId = Object.keys(SortKeyByColumn).find((id) => SortKeyByColumn[id] == sortKey) ?? "name";
+        return [{ id: columnId, desc: sortDesc }];
+    }, [sortKey, sortDesc]);
+
     const table = useReactTable({
         data,
         columns,
         columnResizeMode: "onChange",
         getSortedRowModel: getSortedRowModel(),
         getCoreRowModel: getCoreRowModel(),
+        manualSorting: true,
+        state: {
+            sorting,
+        },
+        onSortingChange: (updater) => {
+            const newSorting = typeof updater == "function" ? updater(sorting) : updater;
+            if (newSorting.length == 0) {
+                return;
+            }
+            setSort(SortKeyByColumn[newSorting[0].id] ?? "name", newSorting[0].desc);
+        },
 
         initialState: {
-            sorting: [
-                {
-                    id: "name",
-                    desc: false,
-                },
-            ],
             columnVisibility: {
                 path: false,
             },
@@ -476,6 +609,7 @@ function TableBody({
     const warningBoxRef = useRef<HTMLDivElement>();
     const rowRefs = useRef<HTMLDivElement[]>([]);
     const conn = useAtomValue(model.connection);
+    const dirPath = useAtomValue(model.normFilePath);
 
     useEffect(() => {
         if (focusIndex !== null && rowRefs.current[focusIndex] && bodyRef.current && osRef) {
@@ -614,19 +748,32 @@ function TableBody({
                 {
                     type: "separator",
                 },
+                {
+                    label: PLATFORM == "win32" && isBlank(conn) ? "Move to Recycle Bin" : "Move to Trash",
+                    click: () => {
+                        fireAndForget(async () => {
+                            await RpcApi.FileRemoveCommand(TabRpcClient, {
+                                ...makeFileOpData(conn, dirPath, finfo.path),
+                                trash: true,
+                            }).catch((e) => console.log("error moving file to the trash", finfo.path, e));
+                        });
+                    },
+                },
                 {
                     label: "Delete",
                     click: () => {
                         fireAndForget(async () => {
-                            await FileService.DeleteFile(conn, finfo.path).catch((e) => console.log(e));
-                            setRefreshVersion((current) => current + 1);
+                            await RpcApi.FileRemoveCommand(
+                                TabRpcClient,
+                                makeFileOpData(conn, dirPath, finfo.path)
+                            ).catch((e) => console.log("error deleting file", finfo.path, e));
                         });
                     },
                 }
             );
             ContextMenuModel.showContextMenu(menu, e);
         },
-        [setRefreshVersion, conn]
+        [setRefreshVersion, conn, dirPath]
     );
 
     const displayRow = useCallback(
@@ -691,8 +838,10 @@ interface DirectoryPreviewProps {
 function DirectoryPreview({ model }: DirectoryPreviewProps) {
     const [searchText, setSearchText] = useState("");
     const [focusIndex, setFocusIndex] = useState(0);
-    const [unfilteredData, setUnfilteredData] = useState<FileInfo[]>([]);
     const [filteredData, setFilteredData] = useState<FileInfo[]>([]);
+    const [listTotal, setListTotal] = useState(0);
+    const [listOffset, setListOffset] = useState(0);
+    const [searchOffset, setSearchOffset] = useState(0);
     const showHiddenFiles = useAtomValue(model.showHiddenFiles);
     const [selectedPath, setSelectedPath] = useState("");
     const [refreshVersion, setRefreshVersion] = useAtom(model.refreshVersion);
@@ -708,26 +857,87 @@ function DirectoryPreview({ model }: DirectoryPreviewProps) {
             model.refreshCallback = null;
         };
     }, [setRefreshVersion]);
+    useEffect(() => {
+        if (dirPath == null) {
+            return;
+        }
+        // after a file operation in this directory (from any block or wsh)
+        return waveEventSubscribe({
+            eventType: "dirchange",
+            scope: makeDirChangeScope(conn, dirPath),
+            handler: () => setRefreshVersion((refreshVersion) => refreshVersion + 1),
+        });
+    }, [conn, dirPath]);
+
+    const sortKey = blockData?.meta?.["file:sort"] || "name";
+    const sortDesc = blockData?.meta?.["file:sortdesc"] ?? false;
+    const listLimit = blockData?.meta?.["file:limit"] || DefaultListLimit;
+    // searches start at the first page (the paging while searching isn't saved to the block meta)
+    const offset = searchText == "" ? (blockData?.meta?.["file:offset"] ?? 0) : searchOffset;
+
+    const updateListMeta = useCallback(
+        (meta: MetaType) => {
+            fireAndForget(() => ObjectService.UpdateObjectMeta(WOS.makeORef("block", model.blockId), meta));
+        },
+        [model.blockId]
+    );
+    const setSort = useCallback(
+        (sortKey: string, sortDesc: boolean) => {
+            updateListMeta({
+                "file:sort": sortKey == "name" ? null : sortKey,
+                "file:sortdesc": sortDesc || null,
+                "file:offset": null,
+            });
+        },
+        [updateListMeta]
+    );
+    const setPageOffset = useCallback(
+        (newOffset: number) => {
+            setFocusIndex(0);
+            if (searchText != "") {
+                setSearchOffset(newOffset);
+                return;
+            }
+            updateListMeta({ "file:offset": newOffset || null });
+        },
+        [searchText, updateListMeta]
+    );
 
     useEffect(() => {
-        const getContent = async () => {
-            const file = await FileService.ReadFile(conn, dirPath);
-            const serializedContent = base64ToString(file?.data64);
-            const content: FileInfo[] = JSON.parse(serializedContent);
-            setUnfilteredData(content);
-        };
-        getContent();
-    }, [conn, dirPath, refreshVersion]);
+        setSearchOffset(0);
+    }, [searchText]);
 
     useEffect(() => {
-        const filtered = unfilteredData.filter((fileInfo) => {
-            if (!showHiddenFiles && fileInfo.name.startsWith(".") && fileInfo.name != "..") {
-                return false;
+        let canceled = false;
+        const getContent = async () => {
+            const rtn = await RpcApi.RemoteListEntriesCommand(
+                TabRpcClient,
+                {
+                    path: dirPath,
+                    opts: {
+                        sort: sortKey,
+                        sortdesc: sortDesc,
+                        showhidden: showHiddenFiles,
+                        filter: searchText,
+                        offset: offset,
+                        limit: listLimit,
+                    },
+                },
+                { route: makeConnRoute(conn) }
+            );
+            if (canceled) {
+                return;
             }
-            return fileInfo.name.toLowerCase().includes(searchText);
-        });
-        setFilteredData(filtered);
-    }, [unfilteredData, showHiddenFiles, searchText]);
+            const entries = rtn.entries ?? [];
+            setFilteredData(rtn.parent != null && searchText == "" ? [rtn.parent, ...entries] : entries);
+            setListTotal(rtn.total);
+            setListOffset(rtn.offset);
+        };
+        fireAndForget(getContent);
+        return () => {
+            canceled = true;
+        };
+    }, [conn, dirPath, refreshVersion, sortKey, sortDesc, showHiddenFiles, searchText, offset, listLimit]);
 
     useEffect(() => {
         model.directoryKeyDownHandler = (waveEvent: WaveKeyboardEvent): boolean => {
@@ -806,8 +1016,10 @@ function DirectoryPreview({ model }: DirectoryPreviewProps) {
                 console.log(`newFile: ${newName}`);
                 fireAndForget(async () => {
                     const connection = await globalStore.get(model.connection);
-                    await FileService.TouchFile(connection, `${dirPath}/${newName}`);
-                    model.refreshCallback();
+                    await RpcApi.FileTouchCommand(
+                        TabRpcClient,
+                        makeFileOpData(connection, dirPath, `${dirPath}/${newName}`)
+                    ).catch((e) => console.log("error creating file", newName, e));
                 });
                 setEntryManagerProps(undefined);
             },
@@ -820,8 +1032,10 @@ function DirectoryPreview({ model }: DirectoryPreviewProps) {
                 console.log(`newDirectory: ${newName}`);
                 fireAndForget(async () => {
                     const connection = await globalStore.get(model.connection);
-                    await FileService.Mkdir(connection, `${dirPath}/${newName}`);
-                    model.refreshCallback();
+                    await RpcApi.FileMkdirCommand(
+                        TabRpcClient,
+                        makeFileOpData(connection, dirPath, `${dirPath}/${newName}`)
+                    ).catch((e) => console.log("error creating directory", newName, e));
                 });
                 setEntryManagerProps(undefined);
             },
@@ -909,7 +1123,31 @@ function DirectoryPreview({ model }: DirectoryPreviewProps) {
                     entryManagerOverlayPropsAtom={entryManagerPropsAtom}
                     newFile={newFile}
                     newDirectory={newDirectory}
+                    sortKey={sortKey}
+                    sortDesc={sortDesc}
+                    setSort={setSort}
                 />
+                {listTotal > listLimit && (
+                    <div className="dir-table-pager">
+                        <span>
+                            {listOffset + 1}-{Math.min(listOffset + listLimit, listTotal)} of {listTotal}
+                        </span>
+                        <Button
+                            className="ghost grey"
+                            disabled={listOffset == 0}
+                            onClick={() => setPageOffset(Math.max(listOffset - listLimit, 0))}
+                        >
+                            Prev
+                        </Button>
+                        <Button
+                            className="ghost grey"
+                            disabled={listOffset + listLimit >= listTotal}
+                            onClick={() => setPageOffset(listOffset + listLimit)}
+                        >
+                            Next
+                        </Button>
+                    </div>
+                )}
             </div>
             {entryManagerProps && (
                 <EntryManagerOverlay
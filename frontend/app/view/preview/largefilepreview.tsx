@@ -0,0 +1,214 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+import { Button } from "@/app/element/button";
+import { CenteredDiv } from "@/app/element/quickelems";
+import { ObjectService } from "@/app/store/services";
+import { RpcApi } from "@/app/store/wshclientapi";
+import { TabRpcClient } from "@/app/store/wshrpcutil";
+import type { PreviewModel } from "@/app/view/preview/preview";
+import * as WOS from "@/store/wos";
+import { base64ToArray, fireAndForget, makeConnRoute } from "@/util/util";
+import { useAtomValue } from "jotai";
+import { memo, useCallback, useEffect, useLayoutEffect, useMemo, useRef, useState } from "react";
+
+// large files are read in ranges as the user scrolls (the backend default, and max 1MB per read)
+const ReadRangeSize = 256 * 1024;
+// the most bytes kept in the view, the far end is dropped when more is loaded
+const MaxWindowSize = 16 * ReadRangeSize;
+const LoadThresholdPx = 400;
+
+type FileWindow = {
+    start: number;
+    data: Uint8Array;
+};
+
+function concatBytes(a: Uint8Array, b: Uint8Array): Uint8Array {
+    const rtn = new Uint8Array(a.length + b.length);
+    rtn.set(a, 0);
+    rtn.set(b, a.length);
+    return rtn;
+}
+
+function formatBytes(size: number): string {
+    if (size < 1024 * 1024) {
+        return `${Math.round(size / 1024)}k`;
+    }
+    if (size < 1024 * 1024 * 1024) {
+        return `${(size / (1024 * 1024)).toFixed(1)}M`;
+    }
+    return `${(size / (1024 * 1024 * 1024)).toFixed(2)}G`;
+}
+
+// a read-only view of a text file that is too large to load into the editor
+export const LargeFilePreview = memo(({ model }: { model: PreviewModel }) => {
+    const conn = useAtomValue(model.connection);
+    const fileInfo = useAtomValue(model.statFile);
+    const blockData = useAtomValue(model.blockAtom);
+    const metaFileSize: number = blockData?.meta?.["file:size"];
+    const [fileWindow, setFileWindow] = useState<FileWindow>(null);
+    const [fileSize, setFileSize] = useState<number>(metaFileSize ?? fileInfo.size);
+    const [errorStr, setErrorStr] = useState<string>(null);
+    const scrollRef = useRef<HTMLDivElement>(null);
+    const loadingRef = useRef(false);
+    const scrollAdjustRef = useRef<{ scrollHeight: number; scrollTop: number }>(null);
+    const scrollToEndRef = useRef(false);
+
+    const readRange = useCallback(
+        async (offset: number, size: number): Promise<FileReadRangeRtnData> => {
+            const rtn = await RpcApi.RemoteFileReadRangeCommand(
+                TabRpcClient,
+                { path: fileInfo.path, offset: offset, size: size },
+                { route: makeConnRoute(conn) }
+            );
+            setFileSize(rtn.filesize);
+            if (rtn.filesize != metaFileSize) {
+                // recorded so the next open knows the size (and where the tail is) before reading
+                await ObjectService.UpdateObjectMeta(WOS.makeORef("block", model.blockId), {
+                    "file:size": rtn.filesize,
+                });
+            }
+            return rtn;
+        },
+        [conn, fileInfo.path, metaFileSize, model.blockId]
+    );
+
+    const loadRange = useCallback(
+        (loadFn: () => Promise<void>) => {
+            if (loadingRef.current) {
+                return;
+            }
+            loadingRef.current = true;
+            fireAndForget(async () => {
+                try {
+                    await loadFn();
+                    setErrorStr(null);
+                } catch (e) {
+                    setErrorStr(`Error reading file: ${e}`);
+                } finally {
+                    loadingRef.current = false;
+                }
+            });
+        },
+        []
+    );
+
+    const jumpTo = useCallback(
+        (tail: boolean) => {
+            loadRange(async () => {
+                const rtn = await readRange(tail ? -ReadRangeSize : 0, ReadRangeSize);
+                scrollToEndRef.current = tail;
+                setFileWindow({ start: rtn.offset, data: base64ToArray(rtn.data64 ?? "") });
+            });
+        },
+        [loadRange, readRange]
+    );
+
+    useEffect(() => {
+        jumpTo(false);
+    }, [fileInfo.path, conn]);
+
+    const loadNext = useCallback(() => {
+        if (fileWindow == null || fileWindow.start + fileWindow.data.length >= fileSize) {
+            return;
+        }
+        loadRange(async () => {
+            const rtn = await readRange(fileWindow.start + fileWindow.data.length, ReadRangeSize);
+            let start = fileWindow.start;
+            let data = concatBytes(fileWindow.data, base64ToArray(rtn.data64 ?? ""));
+            if (data.length > MaxWindowSize) {
+                const dropSize = data.length - MaxWindowSize;
+                const el = scrollRef.current;
+                if (el != null) {
+                    scrollAdjustRef.current = { scrollHeight: el.scrollHeight, scrollTop: el.scrollTop };
+                }
+                start += dropSize;
+                data = data.slice(dropSize);
+            }
+            setFileWindow({ start, data });
+        });
+    }, [fileWindow, fileSize, loadRange, readRange]);
+
+    const loadPrev = useCallback(() => {
+        if (fileWindow == null || fileWindow.start <= 0) {
+            return;
+        }
+        loadRange(async () => {
+            const readStart = Math.max(fileWindow.start - ReadRangeSize, 0);
+            const rtn = await readRange(readStart, fileWindow.start - readStart);
+            let data = concatBytes(base64ToArray(rtn.data64 ?? ""), fileWindow.data);
+            if (data.length > MaxWindowSize) {
+                data = data.slice(0, MaxWindowSize);
+            }
+            const el = scrollRef.current;
+            if (el != null) {
+                scrollAdjustRef.current = { scrollHeight: el.scrollHeight, scrollTop: el.scrollTop };
+            }
+            setFileWindow({ start: readStart, data });
+        });
+    }, [fileWindow, loadRange, readRange]);
+
+    // keeps the visible lines in place when data is added (or dropped) above them
+    useLayoutEffect(() => {
+        const el = scrollRef.current;
+        if (el == null) {
+            return;
+        }
+        if (scrollToEndRef.current) {
+            scrollToEndRef.current = false;
+            el.scrollTop = el.scrollHeight;
+            return;
+        }
+        const adjust = scrollAdjustRef.current;
+        scrollAdjustRef.current = null;
+        if (adjust != null) {
+            el.scrollTop = adjust.scrollTop + (el.scrollHeight - adjust.scrollHeight);
+        }
+    }, [fileWindow]);
+
+    const handleScroll = useCallback(() => {
+        const el = scrollRef.current;
+        if (el == null) {
+            return;
+        }
+        if (el.scrollHeight - el.scrollTop - el.clientHeight < LoadThresholdPx) {
+            loadNext();
+        } else if (el.scrollTop < LoadThresholdPx) {
+            loadPrev();
+        }
+    }, [loadNext, loadPrev]);
+
+    const text = useMemo(() => {
+        if (fileWindow == null) {
+            return null;
+        }
+        return new TextDecoder().decode(fileWindow.data);
+    }, [fileWindow]);
+
+    if (text == null) {
+        return <CenteredDiv>{errorStr ?? "Loading..."}</CenteredDiv>;
+    }
+    const end = fileWindow.start + fileWindow.data.length;
+    return (
+        <div className="view-preview view-preview-largefile">
+            <div className="largefile-header">
+                <span>
+                    Showing {formatBytes(fileWindow.start)} - {formatBytes(end)} of {formatBytes(fileSize)} (read-only,
+                    the file is too large to edit)
+                </span>
+                {errorStr && <span className="largefile-error">{errorStr}</span>}
+                <div className="largefile-buttons">
+                    <Button className="ghost grey" onClick={() => jumpTo(false)} disabled={fileWindow.start == 0}>
+                        Start
+                    </Button>
+                    <Button className="ghost grey" onClick={() => jumpTo(true)} disabled={end >= fileSize}>
+                        End
+                    </Button>
+                </div>
+            </div>
+            <div className="largefile-content" ref={scrollRef} onScroll={handleScroll}>
+                <pre>{text}</pre>
+            </div>
+        </div>
+    );
+});
@@ -6,13 +6,16 @@ import { CenteredDiv } from "@/app/element/quickelems";
 import { TypeAheadModal } from "@/app/modals/typeaheadmodal";
 import { ContextMenuModel } from "@/app/store/contextmenu";
 import { tryReinjectKey } from "@/app/store/keymodel";
+import { waveEventSubscribe } from "@/app/store/wps";
+import { modalsModel } from "@/app/store/modalmodel";
 import { RpcApi } from "@/app/store/wshclientapi";
-import { TabRpcClient } from "@/app/store/wshrpcutil";
+import { registerWSEventHandler, RpcError, TabRpcClient } from "@/app/store/wshrpcutil";
 import { CodeEditor } from "@/app/view/codeeditor/codeeditor";
 import { Markdown } from "@/element/markdown";
 import {
     atoms,
     createBlock,
+    getBlockComponentModel,
     getConnStatusAtom,
     getOverrideConfigAtom,
     getSettingsKeyAtom,
@@ -30,9 +33,10 @@ import clsx from "clsx";
 import { Atom, atom, Getter, PrimitiveAtom, useAtomValue, useSetAtom, WritableAtom } from "jotai";
 import { loadable } from "jotai/utils";
 import type * as MonacoTypes from "monaco-editor/esm/vs/editor/editor.api";
-import { createRef, memo, useCallback, useEffect, useMemo, useState } from "react";
+import { createRef, memo, useCallback, useEffect, useMemo, useRef, useState } from "react";
 import { CSVView } from "./csvview";
 import { DirectoryPreview } from "./directorypreview";
+import { LargeFilePreview } from "./largefilepreview";
 import "./preview.scss";
 
 const MaxFileSize = 1024 * 1024 * 10; // 10MB
@@ -49,6 +53,8 @@ const SpecializedViewMap: { [view: string]: ({ model }: SpecializedViewProps) =>
     codeedit: CodeEditPreview,
     csv: CSVViewPreview,
     directory: DirectoryPreview,
+    waitcreate: WaitCreatePreview,
+    largefile: LargeFilePreview,
 };
 
 const textApplicationMimetypes = [
@@ -134,6 +140,7 @@ export class PreviewModel implements ViewModel {
     fileMimeType: Atom<Promise<string>>;
     fileMimeTypeLoadable: Atom<Loadable<string>>;
     fileContentSaved: PrimitiveAtom<string | null>;
+    fileVersion: PrimitiveAtom<FileVersion | null>; // the version on disk when it was read (or last saved)
     fileContent: WritableAtom<Promise<string>, [string], void>;
     newFileContent: PrimitiveAtom<string | null>;
     connectionError: PrimitiveAtom<string>;
@@ -146,8 +153,10 @@ export class PreviewModel implements ViewModel {
 
     monacoRef: React.MutableRefObject<MonacoTypes.editor.IStandaloneCodeEditor>;
 
-    showHiddenFiles: PrimitiveAtom<boolean>;
+    showHiddenFiles: Atom<boolean>;
     refreshVersion: PrimitiveAtom<number>;
+    waitCreate: Atom<boolean>;
+    fileCreateVersion: PrimitiveAtom<number>;
     refreshCallback: () => void;
     directoryKeyDownHandler: (waveEvent: WaveKeyboardEvent) => boolean;
     codeEditKeyDownHandler: (waveEvent: WaveKeyboardEvent) => boolean;
@@ -156,16 +165,24 @@ export class PreviewModel implements ViewModel {
         this.viewType = "preview";
         this.blockId = blockId;
         this.nodeModel = nodeModel;
-        let showHiddenFiles = globalStore.get(getSettingsKeyAtom("preview:showhiddenfiles")) ?? true;
-        this.showHiddenFiles = atom<boolean>(showHiddenFiles);
         this.refreshVersion = atom(0);
+        this.fileCreateVersion = atom(0);
+        this.fileVersion = atom(null) as PrimitiveAtom<FileVersion | null>;
         this.previewTextRef = createRef();
         this.openFileModal = atom(false);
         this.openFileError = atom(null) as PrimitiveAtom<string>;
         this.openFileModalGiveFocusRef = createRef();
         this.manageConnection = atom(true);
         this.blockAtom = WOS.getWaveObjectAtom<Block>(`block:${blockId}`);
+        this.showHiddenFiles = atom<boolean>((get) => {
+            return (
+                get(this.blockAtom)?.meta?.["file:showhidden"] ??
+                get(getSettingsKeyAtom("preview:showhiddenfiles")) ??
+                true
+            );
+        });
         this.markdownShowToc = atom(false);
+        this.waitCreate = atom((get) => get(this.blockAtom)?.meta?.["file:waitcreate"] ?? false);
         this.filterOutNowsh = atom(true);
         this.monacoRef = createRef();
         this.connectionError = atom("");
@@ -318,7 +335,12 @@ export class PreviewModel implements ViewModel {
                         elemtype: "iconbutton",
                         icon: showHiddenFiles ? "eye" : "eye-slash",
                         click: () => {
-                            globalStore.set(this.showHiddenFiles, (prev) => !prev);
+                            fireAndForget(() =>
+                                services.ObjectService.UpdateObjectMeta(WOS.makeORef("block", this.blockId), {
+                                    "file:showhidden": !showHiddenFiles,
+                                    "file:offset": null,
+                                })
+                            );
                         },
                     },
                     {
@@ -372,6 +394,8 @@ export class PreviewModel implements ViewModel {
             return connName;
         });
         this.statFile = atom<Promise<FileInfo>>(async (get) => {
+            get(this.fileCreateVersion);
+            get(this.waitCreate);
             const fileName = get(this.metaFilePath);
             if (fileName == null) {
                 return null;
@@ -389,12 +413,15 @@ export class PreviewModel implements ViewModel {
         this.goParentDirectory = this.goParentDirectory.bind(this);
 
         const fullFileAtom = atom<Promise<FullFile>>(async (get) => {
+            get(this.fileCreateVersion);
+            get(this.waitCreate);
             const fileName = get(this.metaFilePath);
             if (fileName == null) {
                 return null;
             }
             const conn = (await get(this.connection)) ?? "";
             const file = await services.FileService.ReadFile(conn, fileName);
+            globalStore.set(this.fileVersion, file?.version ?? null);
             return file;
         });
 
@@ -451,6 +478,9 @@ export class PreviewModel implements ViewModel {
         if (connErr != "") {
             return { errorStr: `Connection Error: ${connErr}` };
         }
+        if (getFn(this.waitCreate)) {
+            return { specializedView: "waitcreate" };
+        }
         if (parentFileInfo?.notfound ?? false) {
             return { errorStr: `Parent Directory Not Found: ${fileInfo.path}` };
         }
@@ -468,6 +498,10 @@ export class PreviewModel implements ViewModel {
             return { errorStr: "File Not Found" + fileNameStr };
         }
         if (fileInfo.size > MaxFileSize) {
+            if (isTextFile(mimeType) && !mimeType.startsWith("text/csv")) {
+                // read in ranges, the whole file is never loaded
+                return { specializedView: "largefile" };
+            }
             return { errorStr: "File Too Large to Preiview (10 MB Max)" };
         }
         if (mimeType == "text/csv" && fileInfo.size > MaxCSVSize) {
@@ -509,6 +543,7 @@ export class PreviewModel implements ViewModel {
         if (updateMeta == null) {
             return;
         }
+        updateMeta["file:offset"] = null;
         const blockOref = WOS.makeORef("block", this.blockId);
         await services.ObjectService.UpdateObjectMeta(blockOref, updateMeta);
 
@@ -567,6 +602,7 @@ export class PreviewModel implements ViewModel {
             return;
         }
         updateMeta.edit = false;
+        updateMeta["file:offset"] = null;
         const blockOref = WOS.makeORef("block", this.blockId);
         await services.ObjectService.UpdateObjectMeta(blockOref, updateMeta);
     }
@@ -579,6 +615,7 @@ export class PreviewModel implements ViewModel {
             return;
         }
         updateMeta.edit = false;
+        updateMeta["file:offset"] = null;
         const blockOref = WOS.makeORef("block", this.blockId);
         await services.ObjectService.UpdateObjectMeta(blockOref, updateMeta);
     }
@@ -599,17 +636,71 @@ export class PreviewModel implements ViewModel {
             console.log("not saving file, newFileContent is null");
             return;
         }
-        const conn = (await globalStore.get(this.connection)) ?? "";
         try {
-            await services.FileService.SaveFile(conn, filePath, stringToBase64(newFileContent));
-            globalStore.set(this.fileContent, newFileContent);
-            globalStore.set(this.newFileContent, null);
-            console.log("saved file", filePath);
+            await this.saveFile(filePath, newFileContent, false);
         } catch (error) {
+            if (error instanceof RpcError && error.errorCode == "conflict") {
+                this.showSaveConflict(error.errorData as FileConflictData, newFileContent);
+                return;
+            }
             console.error("Error saving file:", error);
         }
     }
 
+    // the save fails with a "conflict" error if the file changed on disk since it was read (unless force is set)
+    async saveFile(filePath: string, content: string, force: boolean) {
+        const conn = (await globalStore.get(this.connection)) ?? "";
+        const newVersion = await RpcApi.FileSaveCommand(TabRpcClient, {
+            connection: conn,
+            path: filePath,
+            data64: stringToBase64(content),
+            version: globalStore.get(this.fileVersion),
+            force: force,
+        });
+        globalStore.set(this.fileVersion, newVersion);
+        globalStore.set(this.fileContent, content);
+        globalStore.set(this.newFileContent, null);
+        console.log("saved file", filePath);
+    }
+
+    showSaveConflict(conflict: FileConflictData, content: string) {
+        modalsModel.pushModal("SaveConflictModal", {
+            conflict,
+            onOverwrite: async () => {
+                const filePath = await globalStore.get(this.statFilePath);
+                await this.saveFile(filePath, content, true);
+            },
+            onReload: async () => {
+                globalStore.set(this.fileContentSaved, null);
+                globalStore.set(this.newFileContent, null);
+                globalStore.set(this.fileCreateVersion, (version) => version + 1);
+            },
+            onSaveAs: async (newPath: string) => {
+                const conn = (await globalStore.get(this.connection)) ?? "";
+                await RpcApi.FileSaveCommand(TabRpcClient, {
+                    connection: conn,
+                    path: newPath,
+                    data64: stringToBase64(content),
+                    force: true,
+                });
+                await this.goHistory(newPath);
+            },
+        });
+    }
+
+    // a directory reloads its listing, a file is read again (unless it has unsaved edits)
+    handleFileChanged() {
+        if (this.isSpecializedView("directory")) {
+            globalStore.set(this.refreshVersion, (version) => version + 1);
+            return;
+        }
+        if (globalStore.get(this.newFileContent) != null) {
+            return;
+        }
+        globalStore.set(this.fileContentSaved, null);
+        globalStore.set(this.fileCreateVersion, (version) => version + 1);
+    }
+
     async handleFileRevert() {
         const fileContent = await globalStore.get(this.fileContent);
         this.monacoRef.current?.setValue(fileContent);
@@ -854,6 +945,7 @@ function CodeEditPreview({ model }: SpecializedViewProps) {
     const setNewFileContent = useSetAtom(model.newFileContent);
     const fileName = useAtomValue(model.statFilePath);
     const blockMeta = useAtomValue(model.blockAtom)?.meta;
+    const monacoApiRef = useRef<Monaco>(null);
 
     function codeEditKeyDownHandler(e: WaveKeyboardEvent): boolean {
         if (checkKeyPressed(e, "Cmd:e")) {
@@ -879,8 +971,43 @@ function CodeEditPreview({ model }: SpecializedViewProps) {
         };
     }, []);
 
+    useEffect(() => {
+        if (fileName == null) {
+            return;
+        }
+        // the backend validates wave's config files when they are saved (an invalid file isn't written)
+        const unsubFn = waveEventSubscribe({
+            eventType: "configvalidation",
+            scope: fileName,
+            handler: (event) => {
+                const data: ConfigValidationEventData = event.data;
+                const monaco = monacoApiRef.current;
+                const textModel = model.monacoRef.current?.getModel();
+                if (monaco == null || textModel == null) {
+                    return;
+                }
+                const markers = (data?.errors ?? []).map((verr) => {
+                    const line = Math.min(Math.max(verr.line ?? 1, 1), textModel.getLineCount());
+                    return {
+                        severity: monaco.MarkerSeverity.Error,
+                        message: verr.key ? `${verr.key}: ${verr.message}` : verr.message,
+                        startLineNumber: line,
+                        startColumn: textModel.getLineFirstNonWhitespaceColumn(line) || 1,
+                        endLineNumber: line,
+                        endColumn: textModel.getLineMaxColumn(line),
+                    };
+                });
+                monaco.editor.setModelMarkers(textModel, "waveconfig", markers);
+            },
+        });
+        return () => {
+            unsubFn();
+        };
+    }, [fileName]);
+
     function onMount(editor: MonacoTypes.editor.IStandaloneCodeEditor, monaco: Monaco): () => void {
         model.monacoRef.current = editor;
+        monacoApiRef.current = monaco;
 
         editor.onKeyDown((e: MonacoTypes.IKeyboardEvent) => {
             const waveEvent = adaptFromReactOrNativeKeyEvent(e.browserEvent);
@@ -911,6 +1038,15 @@ function CodeEditPreview({ model }: SpecializedViewProps) {
     );
 }
 
+function WaitCreatePreview({ model }: SpecializedViewProps) {
+    const fileName = useAtomValue(model.metaFilePath);
+    useEffect(() => {
+        // starts the backend watch (a no-op if it is already waiting)
+        fireAndForget(() => RpcApi.WatchFileCreateCommand(TabRpcClient, model.blockId));
+    }, [model.blockId, fileName]);
+    return <CenteredDiv>Waiting for {fileName} to be created...</CenteredDiv>;
+}
+
 function CSVViewPreview({ model, parentRef }: SpecializedViewProps) {
     const fileContent = useAtomValue(model.fileContent);
     const fileName = useAtomValue(model.statFilePath);
@@ -976,6 +1112,7 @@ function PreviewView({
     model: PreviewModel;
 }) {
     const connStatus = useAtomValue(model.connStatus);
+    usePreviewFileWatch(model);
     if (connStatus?.status != "connected") {
         return null;
     }
@@ -991,6 +1128,29 @@ function PreviewView({
     );
 }
 
+// the backend watches the file (or directory) while it is shown and sends a blockrefresh event when it changes
+function usePreviewFileWatch(model: PreviewModel) {
+    const filePath = useAtomValue(model.metaFilePath);
+    const conn = useAtomValue(model.blockAtom)?.meta?.connection ?? "";
+    useEffect(() => {
+        if (isBlank(filePath)) {
+            return;
+        }
+        fireAndForget(() =>
+            RpcApi.PreviewWatchCommand(TabRpcClient, { blockid: model.blockId, connection: conn, path: filePath })
+        );
+        const unsubFn = waveEventSubscribe({
+            eventType: "blockrefresh",
+            scope: WOS.makeORef("block", model.blockId),
+            handler: () => model.handleFileChanged(),
+        });
+        return () => {
+            unsubFn();
+            fireAndForget(() => RpcApi.PreviewUnwatchCommand(TabRpcClient, model.blockId));
+        };
+    }, [conn, filePath]);
+}
+
 const OpenFileModal = memo(
     ({
         model,
@@ -1057,4 +1217,24 @@ const OpenFileModal = memo(
     }
 );
 
-export { makePreviewModel, PreviewView };
+export { makePreviewModel, PreviewView, registerPreviewFileCreatedHandler };
+
+// wavesrv sends "filecreated" when a preview that was waiting for its file (wsh view --wait-create) stops waiting,
+// and "previewreload" when a linked terminal reports that the preview's file was rewritten (see wsh link)
+function registerPreviewFileCreatedHandler() {
+    registerWSEventHandler("filecreated", (event) => {
+        const data: FileCreatedData = event.data;
+        const viewModel = getBlockComponentModel(data?.blockid)?.viewModel;
+        if (viewModel instanceof PreviewModel) {
+            globalStore.set(viewModel.fileCreateVersion, (version) => version + 1);
+        }
+    });
+    registerWSEventHandler("previewreload", (event) => {
+        const data: PreviewReloadData = event.data;
+        const viewModel = getBlockComponentModel(data?.blockid)?.viewModel;
+        if (viewModel instanceof PreviewModel) {
+            globalStore.set(viewModel.fileContentSaved, null);
+            globalStore.set(viewModel.fileCreateVersion, (version) => version + 1);
+        }
+    });
+}
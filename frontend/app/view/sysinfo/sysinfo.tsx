@@ -19,6 +19,8 @@ import { OverlayScrollbarsComponent, OverlayScrollbarsComponentRef } from "overl
 import "./sysinfo.scss";
 
 const DefaultNumPoints = 120;
+// the connection is sampled once a second (the points of a plot:interval are picked from the samples)
+const MinIntervalMs = 1000;
 
 type DataItem = {
     ts: number;
@@ -47,6 +49,60 @@ function defaultMemMeta(name: string, maxY: string): TimeSeriesMeta {
     };
 }
 
+function defaultRateMeta(name: string): TimeSeriesMeta {
+    return {
+        name: name,
+        label: "MB/s",
+        miny: 0,
+        color: "var(--sysinfo-mem-color)",
+        decimalPlaces: 2,
+    };
+}
+
+function defaultLoadMeta(name: string): TimeSeriesMeta {
+    return {
+        name: name,
+        label: "",
+        miny: 0,
+        color: "var(--sysinfo-cpu-color)",
+        decimalPlaces: 2,
+    };
+}
+
+// the series shown for a metric group in plot:metrics (other names are shown as is, e.g. "cpu:3" or "disk:sda:read")
+const MetricGroups: { [group: string]: Array<string> } = {
+    cpu: ["cpu"],
+    mem: ["mem:used"],
+    load: ["load:1", "load:5", "load:15"],
+    disk: ["disk:read", "disk:write"],
+    net: ["net:recv", "net:sent"],
+};
+
+function expandPlotMetrics(plotMetrics: Array<string>, dataItem: DataItem): Array<string> {
+    const rtn: Array<string> = [];
+    for (const metric of plotMetrics) {
+        if (MetricGroups[metric] != null) {
+            rtn.push(...MetricGroups[metric]);
+        } else if (dataItem == null || metric in dataItem) {
+            rtn.push(metric);
+        }
+    }
+    return rtn;
+}
+
+// plot:interval is a duration (e.g. "2s", "500ms", "1m") or a number of seconds
+function parsePlotInterval(interval: string | number): number {
+    if (typeof interval == "number") {
+        return Math.max(interval * 1000, MinIntervalMs);
+    }
+    const match = /^\s*(\d+(?:\.\d+)?)\s*(ms|s|m)?\s*$/.exec(interval ?? "");
+    if (match == null) {
+        return MinIntervalMs;
+    }
+    const multiplier = { ms: 1, s: 1000, m: 60000 }[match[2] ?? "s"];
+    return Math.max(parseFloat(match[1]) * multiplier, MinIntervalMs);
+}
+
 const PlotTypes: Object = {
     CPU: function (dataItem: DataItem): Array<string> {
         return ["cpu"];
@@ -57,6 +113,15 @@ const PlotTypes: Object = {
     "CPU + Mem": function (dataItem: DataItem): Array<string> {
         return ["cpu", "mem:used"];
     },
+    Load: function (dataItem: DataItem): Array<string> {
+        return MetricGroups.load;
+    },
+    "Disk IO": function (dataItem: DataItem): Array<string> {
+        return MetricGroups.disk;
+    },
+    Network: function (dataItem: DataItem): Array<string> {
+        return MetricGroups.net;
+    },
     "All CPU": function (dataItem: DataItem): Array<string> {
         return Object.keys(dataItem)
             .filter((item) => item.startsWith("cpu") && item != "cpu")
@@ -74,13 +139,30 @@ const DefaultPlotMeta = {
     "mem:used": defaultMemMeta("Memory Used", "mem:total"),
     "mem:free": defaultMemMeta("Memory Free", "mem:total"),
     "mem:available": defaultMemMeta("Memory Available", "mem:total"),
+    "load:1": defaultLoadMeta("Load (1m)"),
+    "load:5": defaultLoadMeta("Load (5m)"),
+    "load:15": defaultLoadMeta("Load (15m)"),
+    "disk:read": defaultRateMeta("Disk Read"),
+    "disk:write": defaultRateMeta("Disk Write"),
+    "net:recv": defaultRateMeta("Network Received"),
+    "net:sent": defaultRateMeta("Network Sent"),
 };
 for (let i = 0; i < 32; i++) {
     DefaultPlotMeta[`cpu:${i}`] = defaultCpuMeta(`Core ${i}`);
 }
 
-function convertWaveEventToDataItem(event: WaveEvent): DataItem {
-    const eventData: TimeSeriesData = event.data;
+function getPlotMeta(plotMeta: Map<string, TimeSeriesMeta>, metric: string): TimeSeriesMeta {
+    const meta = plotMeta.get(metric);
+    if (meta != null) {
+        return meta;
+    }
+    if (metric.startsWith("disk:") || metric.startsWith("net:")) {
+        return defaultRateMeta(metric);
+    }
+    return defaultLoadMeta(metric);
+}
+
+function convertTimeSeriesToDataItem(eventData: TimeSeriesData): DataItem {
     if (eventData == null || eventData.ts == null || eventData.values == null) {
         return null;
     }
@@ -91,6 +173,10 @@ function convertWaveEventToDataItem(event: WaveEvent): DataItem {
     return dataItem;
 }
 
+function convertWaveEventToDataItem(event: WaveEvent): DataItem {
+    return convertTimeSeriesToDataItem(event.data);
+}
+
 class SysinfoViewModel implements ViewModel {
     viewType: string;
     blockAtom: jotai.Atom<Block>;
@@ -106,6 +192,7 @@ class SysinfoViewModel implements ViewModel {
     incrementCount: jotai.WritableAtom<unknown, [], Promise<void>>;
     loadingAtom: jotai.PrimitiveAtom<boolean>;
     numPoints: jotai.Atom<number>;
+    intervalMs: jotai.Atom<number>;
     metrics: jotai.Atom<string[]>;
     connection: jotai.Atom<string>;
     manageConnection: jotai.Atom<boolean>;
@@ -121,13 +208,14 @@ class SysinfoViewModel implements ViewModel {
         this.blockAtom = WOS.getWaveObjectAtom<Block>(`block:${blockId}`);
         this.addInitialDataAtom = jotai.atom(null, (get, set, points) => {
             const targetLen = get(this.numPoints) + 1;
+            const intervalMs = get(this.intervalMs);
             try {
                 const newDataRaw = [...points];
                 if (newDataRaw.length == 0) {
                     return;
                 }
                 const latestItemTs = newDataRaw[newDataRaw.length - 1]?.ts ?? 0;
-                const cutoffTs = latestItemTs - 1000 * targetLen;
+                const cutoffTs = latestItemTs - intervalMs * targetLen;
                 const blankItemTemplate = { ...newDataRaw[newDataRaw.length - 1] };
                 for (const key in blankItemTemplate) {
                     blankItemTemplate[key] = NaN;
@@ -149,7 +237,7 @@ class SysinfoViewModel implements ViewModel {
                     const prevIdxItem = newDataFiltered[i - 1];
                     const curIdxItem = newDataFiltered[i];
                     const timeDiff = curIdxItem.ts - prevIdxItem.ts;
-                    if (timeDiff > 2000) {
+                    if (timeDiff > intervalMs + 2000) {
                         const blankItemStart = { ...blankItemTemplate, ts: prevIdxItem.ts + 1, blank: 1 };
                         const blankItemEnd = { ...blankItemTemplate, ts: curIdxItem.ts - 1, blank: 1 };
                         newDataWithGaps.push(blankItemStart);
@@ -164,10 +252,16 @@ class SysinfoViewModel implements ViewModel {
         });
         this.addContinuousDataAtom = jotai.atom(null, (get, set, newPoint) => {
             const targetLen = get(this.numPoints) + 1;
+            const intervalMs = get(this.intervalMs);
             let data = get(this.dataAtom);
             try {
                 const latestItemTs = newPoint?.ts ?? 0;
-                const cutoffTs = latestItemTs - 1000 * targetLen;
+                const prevTs = data[data.length - 1]?.ts ?? 0;
+                if (latestItemTs - prevTs < intervalMs - MinIntervalMs / 2) {
+                    // not time for the next point yet
+                    return;
+                }
+                const cutoffTs = latestItemTs - intervalMs * targetLen;
                 data.push(newPoint);
                 const newData = data.filter((dataItem) => dataItem.ts >= cutoffTs);
                 set(this.dataAtom, newData);
@@ -177,7 +271,8 @@ class SysinfoViewModel implements ViewModel {
         });
         this.plotMetaAtom = jotai.atom(new Map(Object.entries(DefaultPlotMeta)));
         this.manageConnection = jotai.atom(true);
-        this.filterOutNowsh = jotai.atom(true);
+        // connections without wsh are sampled over ssh (see runProcSysInfoLoop)
+        this.filterOutNowsh = jotai.atom(false);
         this.loadingAtom = jotai.atom(true);
         this.numPoints = jotai.atom((get) => {
             const blockData = get(this.blockAtom);
@@ -187,9 +282,17 @@ class SysinfoViewModel implements ViewModel {
             }
             return metaNumPoints;
         });
+        this.intervalMs = jotai.atom((get) => {
+            const blockData = get(this.blockAtom);
+            return parsePlotInterval(blockData?.meta?.["plot:interval"]);
+        });
         this.metrics = jotai.atom((get) => {
             let plotType = get(this.plotTypeSelectedAtom);
             const plotData = get(this.dataAtom);
+            const plotMetrics = get(this.blockAtom)?.meta?.["plot:metrics"];
+            if (Array.isArray(plotMetrics) && plotMetrics.length > 0) {
+                return expandPlotMetrics(plotMetrics, plotData[plotData.length - 1]);
+            }
             try {
                 const metrics = PlotTypes[plotType](plotData[plotData.length - 1]);
                 if (metrics == null || !Array.isArray(metrics)) {
@@ -245,18 +348,16 @@ class SysinfoViewModel implements ViewModel {
         try {
             const numPoints = globalStore.get(this.numPoints);
             const connName = globalStore.get(this.connection);
-            const initialData = await RpcApi.EventReadHistoryCommand(TabRpcClient, {
-                event: "sysinfo",
-                scope: connName,
-                maxitems: numPoints,
+            // the history is kept by the backend (per connection), so a new block doesn't start with an empty chart
+            const initialData = await RpcApi.GetMetricsCommand(TabRpcClient, {
+                connection: connName,
+                count: numPoints + 1,
+                intervalms: globalStore.get(this.intervalMs),
             });
             if (initialData == null) {
                 return;
             }
-            const newData = this.getDefaultData();
-            const initialDataItems: DataItem[] = initialData.map(convertWaveEventToDataItem);
-            // splice the initial data into the default data (replacing the newest points)
-            //newData.splice(newData.length - initialDataItems.length, initialDataItems.length, ...initialDataItems);
+            const initialDataItems: DataItem[] = initialData.map(convertTimeSeriesToDataItem);
             globalStore.set(this.addInitialDataAtom, initialDataItems);
         } catch (e) {
             console.log("Error loading initial data for sysinfo", e);
@@ -289,7 +390,7 @@ class SysinfoViewModel implements ViewModel {
                     click: async () => {
                         await RpcApi.SetMetaCommand(TabRpcClient, {
                             oref: WOS.makeORef("block", this.blockId),
-                            meta: { "graph:metrics": dataTypes, "sysinfo:type": plotType },
+                            meta: { "graph:metrics": dataTypes, "sysinfo:type": plotType, "plot:metrics": null },
                         });
                     },
                 };
@@ -308,10 +409,11 @@ class SysinfoViewModel implements ViewModel {
     getDefaultData(): DataItem[] {
         // set it back one to avoid backwards line being possible
         const numPoints = globalStore.get(this.numPoints);
-        const currentTime = Date.now() - 1000;
+        const intervalMs = globalStore.get(this.intervalMs);
+        const currentTime = Date.now() - intervalMs;
         const points: DataItem[] = [];
         for (let i = numPoints; i > -1; i--) {
-            points.push({ ts: currentTime - i * 1000 });
+            points.push({ ts: currentTime - i * intervalMs });
         }
         return points;
     }
@@ -339,22 +441,50 @@ function resolveDomainBound(value: number | string, dataItem: DataItem): number
     }
 }
 
+// for the metrics without a fixed max (rates and load), the max is a bit above the largest value shown
+function getDataMaxY(plotData: Array<DataItem>, yval: string): number | undefined {
+    let maxVal: number = undefined;
+    for (const dataItem of plotData) {
+        const val = dataItem[yval];
+        if (Number.isFinite(val) && (maxVal == null || val > maxVal)) {
+            maxVal = val;
+        }
+    }
+    if (maxVal == null) {
+        return undefined;
+    }
+    return Math.max(maxVal * 1.2, 1);
+}
+
 function SysinfoView({ model, blockId }: SysinfoViewProps) {
     const connName = jotai.useAtomValue(model.connection);
     const lastConnName = React.useRef(connName);
     const connStatus = jotai.useAtomValue(model.connStatus);
     const addContinuousData = jotai.useSetAtom(model.addContinuousDataAtom);
     const loading = jotai.useAtomValue(model.loadingAtom);
+    const intervalMs = jotai.useAtomValue(model.intervalMs);
+    const lastIntervalMs = React.useRef(intervalMs);
 
     React.useEffect(() => {
-        if (connStatus?.status != "connected") {
+        if (lastIntervalMs.current !== intervalMs) {
+            lastIntervalMs.current = intervalMs;
+            model.loadInitialData();
+        }
+    }, [intervalMs]);
+    const connected = connStatus?.status == "connected";
+    const wasConnected = React.useRef(connected);
+    React.useEffect(() => {
+        if (!connected) {
+            wasConnected.current = false;
             return;
         }
-        if (lastConnName.current !== connName) {
+        if (lastConnName.current !== connName || !wasConnected.current) {
+            // after a reconnect the history has a gap for the time the connection was down
             lastConnName.current = connName;
+            wasConnected.current = true;
             model.loadInitialData();
         }
-    }, [connStatus.status, connName]);
+    }, [connected, connName]);
     React.useEffect(() => {
         const unsubFn = waveEventSubscribe({
             eventType: "sysinfo",
@@ -367,7 +497,7 @@ function SysinfoView({ model, blockId }: SysinfoViewProps) {
                 const dataItem = convertWaveEventToDataItem(event);
                 const prevData = globalStore.get(model.dataAtom);
                 const prevLastTs = prevData[prevData.length - 1]?.ts ?? 0;
-                if (dataItem.ts - prevLastTs > 2000) {
+                if (dataItem.ts - prevLastTs > globalStore.get(model.intervalMs) + 2000) {
                     model.loadInitialData();
                 } else {
                     addContinuousData(dataItem);
@@ -379,12 +509,21 @@ function SysinfoView({ model, blockId }: SysinfoViewProps) {
             unsubFn();
         };
     }, [connName]);
-    if (connStatus?.status != "connected") {
-        return null;
-    }
     if (loading) {
         return null;
     }
+    if (!connected) {
+        // the plot is paused (no new points) until the connection is back
+        if (globalStore.get(model.dataAtom).length == 0 || lastConnName.current !== connName) {
+            return null;
+        }
+        return (
+            <div className="sysinfo-paused">
+                <div className="sysinfo-paused-label">disconnected</div>
+                <SysinfoViewInner key={connStatus?.connection ?? "local"} blockId={blockId} model={model} />
+            </div>
+        );
+    }
     return <SysinfoViewInner key={connStatus?.connection ?? "local"} blockId={blockId} model={model} />;
 }
 
@@ -397,6 +536,7 @@ type SingleLinePlotProps = {
     title?: boolean;
     sparkline?: boolean;
     targetLen: number;
+    intervalMs: number;
 };
 
 function SingleLinePlot({
@@ -408,6 +548,7 @@ function SingleLinePlot({
     title = false,
     sparkline = false,
     targetLen,
+    intervalMs,
 }: SingleLinePlotProps) {
     const containerRef = React.useRef<HTMLInputElement>();
     const domRect = useDimensionsWithExistingRef(containerRef, 300);
@@ -488,10 +629,10 @@ function SingleLinePlot({
             Plot.pointerX({ x: "ts", y: yval, fill: color, r: 3, stroke: "var(--main-text-color)", strokeWidth: 1 })
         )
     );
-    let maxY = resolveDomainBound(yvalMeta?.maxy, plotData[plotData.length - 1]) ?? 100;
+    let maxY = resolveDomainBound(yvalMeta?.maxy, plotData[plotData.length - 1]) ?? getDataMaxY(plotData, yval) ?? 100;
     let minY = resolveDomainBound(yvalMeta?.miny, plotData[plotData.length - 1]) ?? 0;
     let maxX = plotData[plotData.length - 1].ts;
-    let minX = maxX - targetLen * 1000;
+    let minX = maxX - targetLen * intervalMs;
     const plot = Plot.plot({
         axis: !sparkline,
         x: {
@@ -523,6 +664,7 @@ const SysinfoViewInner = React.memo(({ model }: SysinfoViewProps) => {
     const plotMeta = jotai.useAtomValue(model.plotMetaAtom);
     const osRef = React.useRef<OverlayScrollbarsComponentRef>();
     const targetLen = jotai.useAtomValue(model.numPoints) + 1;
+    const intervalMs = jotai.useAtomValue(model.intervalMs);
     let title = false;
     let cols2 = false;
     if (yvals.length > 1) {
@@ -545,11 +687,12 @@ const SysinfoViewInner = React.memo(({ model }: SysinfoViewProps) => {
                             key={`plot-${model.blockId}-${yval}`}
                             plotData={plotData}
                             yval={yval}
-                            yvalMeta={plotMeta.get(yval)}
+                            yvalMeta={getPlotMeta(plotMeta, yval)}
                             blockId={model.blockId}
                             defaultColor={"var(--accent-color)"}
                             title={title}
                             targetLen={targetLen}
+                            intervalMs={intervalMs}
                         />
                     );
                 })}
@@ -30,7 +30,7 @@ export class TermWshClient extends WshClient {
         console.log("vdom-create", source, data);
         const tabId = globalStore.get(atoms.staticTabId);
         if (data.target?.newblock) {
-            const oref = await RpcApi.CreateBlockCommand(this, {
+            const rtn = await RpcApi.CreateBlockCommand(this, {
                 tabid: tabId,
                 blockdef: {
                     meta: {
@@ -40,7 +40,7 @@ export class TermWshClient extends WshClient {
                 },
                 magnified: data.target?.magnified,
             });
-            return oref;
+            return rtn.blockoref;
         } else if (data.target?.toolbar?.toolbar) {
             const oldVDomBlockId = globalStore.get(this.model.vdomToolbarBlockId);
             console.log("vdom:toolbar", data.target.toolbar);
@@ -264,7 +264,7 @@ class TermViewModel implements ViewModel {
             const shellProcStatus = get(this.shellProcStatus);
             const connStatus = get(this.connStatus);
             const isCmd = get(this.isCmdController);
-            if (blockData?.meta?.["controller"] != "cmd" && shellProcStatus != "done") {
+            if (blockData?.meta?.["controller"] != "cmd" && shellProcStatus != "done" && shellProcStatus != "failed") {
                 return [];
             }
             if (connStatus?.status != "connected") {
@@ -282,6 +282,9 @@ class TermViewModel implements ViewModel {
             } else if (shellProcStatus == "done") {
                 iconName = "refresh";
                 title = noun + " Exited. Click to Restart";
+            } else if (shellProcStatus == "failed") {
+                iconName = "triangle-exclamation";
+                title = noun + " Keeps Exiting Right After Starting. Click to Restart";
             }
             if (iconName == null) {
                 return [];
@@ -289,7 +292,7 @@ class TermViewModel implements ViewModel {
             const buttonDecl: IconButtonDecl = {
                 elemtype: "iconbutton",
                 icon: iconName,
-                click: this.forceRestartController.bind(this),
+                click: () => this.forceRestartController(),
                 title: title,
             };
             const rtn = [buttonDecl];
@@ -473,7 +476,10 @@ class TermViewModel implements ViewModel {
             return false;
         }
         const shellProcStatus = globalStore.get(this.shellProcStatus);
-        if ((shellProcStatus == "done" || shellProcStatus == "init") && keyutil.checkKeyPressed(waveEvent, "Enter")) {
+        if (
+            (shellProcStatus == "done" || shellProcStatus == "init" || shellProcStatus == "failed") &&
+            keyutil.checkKeyPressed(waveEvent, "Enter")
+        ) {
             this.forceRestartController();
             return false;
         }
@@ -493,11 +499,18 @@ class TermViewModel implements ViewModel {
         });
     }
 
-    forceRestartController() {
+    // force restarts right away (otherwise restarts are delayed if the shell keeps crashing, unless it has failed)
+    forceRestartController(force?: boolean) {
         if (globalStore.get(this.isRestarting)) {
             return;
         }
         this.triggerRestartAtom();
+        const shellProcStatus = globalStore.get(this.shellProcStatus);
+        if (shellProcStatus != "init") {
+            const prtn = services.ObjectService.RestartController(this.blockId, force || shellProcStatus == "failed");
+            prtn.catch((e) => console.log("error restarting controller", e));
+            return;
+        }
         const termsize = {
             rows: this.termRef.current?.terminal?.rows,
             cols: this.termRef.current?.terminal?.cols,
@@ -615,7 +628,7 @@ class TermViewModel implements ViewModel {
         fullMenu.push({ type: "separator" });
         fullMenu.push({
             label: "Force Restart Controller",
-            click: this.forceRestartController.bind(this),
+            click: () => this.forceRestartController(true),
         });
         const isClearOnStart = blockData?.meta?.["cmd:clearonstart"];
         fullMenu.push({
@@ -5,7 +5,7 @@ import { getFileSubject } from "@/app/store/wps";
 import { sendWSCommand } from "@/app/store/ws";
 import { RpcApi } from "@/app/store/wshclientapi";
 import { TabRpcClient } from "@/app/store/wshrpcutil";
-import { PLATFORM, WOS, atoms, fetchWaveFile, getSettingsKeyAtom, globalStore, openLink } from "@/store/global";
+import { PLATFORM, atoms, fetchWaveFile, getSettingsKeyAtom, globalStore, openLink } from "@/store/global";
 import * as services from "@/store/services";
 import { base64ToArray, fireAndForget } from "@/util/util";
 import { SearchAddon } from "@xterm/addon-search";
@@ -55,6 +55,7 @@ export class TermWrap {
     serializeAddon: SerializeAddon;
     mainFileSubject: SubjectWithRef<WSFileEventData>;
     loaded: boolean;
+    reloadAfterLoad: boolean;
     heldData: Uint8Array[];
     handleResize_debounced: () => void;
     hasResized: boolean;
@@ -70,6 +71,7 @@ export class TermWrap {
         waveOptions: TermWrapOptions
     ) {
         this.loaded = false;
+        this.reloadAfterLoad = false;
         this.blockId = blockId;
         this.sendDataHandler = waveOptions.sendDataHandler;
         this.ptyOffset = 0;
@@ -113,30 +115,7 @@ export class TermWrap {
                 loggedWebGL = true;
             }
         }
-        this.terminal.parser.registerOscHandler(7, (data: string) => {
-            if (!this.loaded) {
-                return false;
-            }
-            if (data == null || data.length == 0) {
-                return false;
-            }
-            if (data.startsWith("file://")) {
-                data = data.substring(7);
-                const nextSlashIdx = data.indexOf("/");
-                if (nextSlashIdx == -1) {
-                    return false;
-                }
-                data = data.substring(nextSlashIdx);
-            }
-            setTimeout(() => {
-                fireAndForget(() =>
-                    services.ObjectService.UpdateObjectMeta(WOS.makeORef("block", this.blockId), {
-                        "cmd:cwd": data,
-                    })
-                );
-            }, 0);
-            return true;
-        });
+        // OSC 7 (cwd) is handled by the block controller (it sets cmd:cwd even when the block is not shown)
         this.terminal.attachCustomKeyEventHandler(waveOptions.keydownHandler);
         this.connectElem = connectElem;
         this.mainFileSubject = null;
@@ -170,6 +149,12 @@ export class TermWrap {
         this.mainFileSubject.subscribe(this.handleNewFileSubjectData.bind(this));
         try {
             await this.loadInitialTerminalData();
+            while (this.reloadAfterLoad) {
+                // the scrollback was trimmed while it was loading (the cache file may have been stale)
+                this.reloadAfterLoad = false;
+                this.terminal.reset();
+                await this.loadInitialTerminalData();
+            }
         } finally {
             this.loaded = true;
         }
@@ -214,6 +199,11 @@ export class TermWrap {
             } else {
                 this.heldData.push(decodedData);
             }
+        } else if (msg.fileop == "reset") {
+            // the head of the scrollback was trimmed, the offsets of what is already shown don't change
+            if (!this.loaded) {
+                this.reloadAfterLoad = true;
+            }
         } else {
             console.log("bad fileop for terminal", msg);
             return;
@@ -8,7 +8,7 @@ import { RpcResponseHelper, WshClient } from "@/app/store/wshclient";
 import { RpcApi } from "@/app/store/wshclientapi";
 import { makeFeBlockRouteId } from "@/app/store/wshrouter";
 import { DefaultRouter, TabRpcClient } from "@/app/store/wshrpcutil";
-import { atoms, createBlock, fetchWaveFile, getApi, globalStore, useOverrideConfigAtom, WOS } from "@/store/global";
+import { atoms, createBlock, getApi, globalStore, useOverrideConfigAtom, WOS } from "@/store/global";
 import { BlockService, ObjectService } from "@/store/services";
 import { adaptFromReactOrNativeKeyEvent, checkKeyPressed } from "@/util/keyutil";
 import { fireAndForget, isBlank, makeIconClass } from "@/util/util";
@@ -59,6 +59,16 @@ class AiWshClient extends WshClient {
         }
         this.model.sendMessage(data.message);
     }
+
+    // used by `wsh ai --stream`, the response is shown in the block and streamed back to the caller
+    async handle_aistreammessage(rh: RpcResponseHelper, data: AiMessageData) {
+        if (isBlank(data.message)) {
+            throw new Error("message is empty");
+        }
+        await this.model.sendMessage(data.message, "user", (packet) => {
+            rh.sendResponse({ data: packet, cont: true });
+        });
+    }
 }
 
 export class WaveAiModel implements ViewModel {
@@ -169,9 +179,11 @@ export class WaveAiModel implements ViewModel {
             };
             const opts: WaveAIOptsType = {
                 model: settings["ai:model"] ?? null,
+                provider: settings["ai:provider"] ?? null,
                 apitype: settings["ai:apitype"] ?? null,
                 orgid: settings["ai:orgid"] ?? null,
                 apitoken: settings["ai:apitoken"] ?? null,
+                apikeyref: settings["ai:apikeyref"] ?? null,
                 apiversion: settings["ai:apiversion"] ?? null,
                 maxtokens: settings["ai:maxtokens"] ?? null,
                 timeoutms: settings["ai:timeoutms"] ?? 60000,
@@ -186,10 +198,12 @@ export class WaveAiModel implements ViewModel {
             const presets = get(this.presetMap);
             const presetKey = get(this.presetKey);
             const presetName = presets[presetKey]?.["display:name"] ?? "";
-            const isCloud = isBlank(aiOpts.apitoken) && isBlank(aiOpts.baseurl);
+            const isCloud = isBlank(aiOpts.provider)
+                ? isBlank(aiOpts.apitoken) && isBlank(aiOpts.baseurl) && isBlank(aiOpts.apikeyref)
+                : aiOpts.provider == "wave";
 
             // Handle known API providers
-            switch (aiOpts?.apitype) {
+            switch (aiOpts?.provider ?? aiOpts?.apitype) {
                 case "anthropic":
                     viewTextChildren.push({
                         elemtype: "iconbutton",
@@ -206,6 +220,14 @@ export class WaveAiModel implements ViewModel {
                         noAction: true,
                     });
                     break;
+                case "ollama":
+                    viewTextChildren.push({
+                        elemtype: "iconbutton",
+                        icon: "location-dot",
+                        title: `Using Ollama @ ${aiOpts.baseurl ?? "http://localhost:11434"} (${aiOpts.model})`,
+                        noAction: true,
+                    });
+                    break;
                 default:
                     if (isCloud) {
                         viewTextChildren.push({
@@ -294,12 +316,8 @@ export class WaveAiModel implements ViewModel {
     }
 
     async fetchAiData(): Promise<Array<WaveAIPromptMessageType>> {
-        const { data } = await fetchWaveFile(this.blockId, "aidata");
-        if (!data) {
-            return [];
-        }
-        const history: Array<WaveAIPromptMessageType> = JSON.parse(new TextDecoder().decode(data));
-        return history.slice(Math.max(history.length - slidingWindowSize, 0));
+        const history = await BlockService.GetWaveAiData(this.blockId, slidingWindowSize);
+        return history ?? [];
     }
 
     giveFocus(): boolean {
@@ -321,7 +339,8 @@ export class WaveAiModel implements ViewModel {
         globalStore.set(this.locked, locked);
     }
 
-    sendMessage(text: string, user: string = "user") {
+    // streamFn (if given) is called with every response packet, and errors are also thrown (after they are shown)
+    sendMessage(text: string, user: string = "user", streamFn?: (packet: WaveAIPacketType) => void): Promise<void> {
         const clientId = globalStore.get(atoms.clientId);
         this.setLocked(true);
 
@@ -352,12 +371,23 @@ export class WaveAiModel implements ViewModel {
                 opts: opts,
                 prompt: [...history, newPrompt],
             };
+            const userMsg: WaveAIConversationMessage = { role: "user", content: text, ts: Date.now() };
             let fullMsg = "";
+            let usage: WaveAIUsageType = null;
+            let streamErr: Error = null;
+            const makeResponseMsg = (): WaveAIConversationMessage => ({
+                role: "assistant",
+                content: fullMsg,
+                prompttokens: usage?.prompt_tokens,
+                completiontokens: usage?.completion_tokens,
+            });
             try {
                 const aiGen = RpcApi.StreamWaveAiCommand(TabRpcClient, beMsg, { timeout: opts.timeoutms });
                 for await (const msg of aiGen) {
                     fullMsg += msg.text ?? "";
+                    usage = msg.usage ?? usage;
                     globalStore.set(this.updateLastMessageAtom, msg.text ?? "", true);
+                    streamFn?.(msg);
                     if (this.cancel) {
                         break;
                     }
@@ -366,28 +396,20 @@ export class WaveAiModel implements ViewModel {
                     // remove a message if empty
                     globalStore.set(this.removeLastMessageAtom);
                     // only save the author's prompt
-                    await BlockService.SaveWaveAiData(this.blockId, [...history, newPrompt]);
+                    await BlockService.AppendWaveAiData(this.blockId, [userMsg]);
                 } else {
-                    const responsePrompt: WaveAIPromptMessageType = {
-                        role: "assistant",
-                        content: fullMsg,
-                    };
                     //mark message as complete
                     globalStore.set(this.updateLastMessageAtom, "", false);
                     // save a complete message prompt and response
-                    await BlockService.SaveWaveAiData(this.blockId, [...history, newPrompt, responsePrompt]);
+                    await BlockService.AppendWaveAiData(this.blockId, [userMsg, makeResponseMsg()]);
                 }
             } catch (error) {
-                const updatedHist = [...history, newPrompt];
+                const newMsgs = [userMsg];
                 if (fullMsg == "") {
                     globalStore.set(this.removeLastMessageAtom);
                 } else {
                     globalStore.set(this.updateLastMessageAtom, "", false);
-                    const responsePrompt: WaveAIPromptMessageType = {
-                        role: "assistant",
-                        content: fullMsg,
-                    };
-                    updatedHist.push(responsePrompt);
+                    newMsgs.push(makeResponseMsg());
                 }
                 const errMsg: string = (error as Error).message;
                 const errorMessage: ChatMessageType = {
@@ -397,17 +419,21 @@ export class WaveAiModel implements ViewModel {
                 };
                 globalStore.set(this.addMessageAtom, errorMessage);
                 globalStore.set(this.updateLastMessageAtom, "", false);
-                const errorPrompt: WaveAIPromptMessageType = {
-                    role: "error",
-                    content: errMsg,
-                };
-                updatedHist.push(errorPrompt);
-                await BlockService.SaveWaveAiData(this.blockId, updatedHist);
+                newMsgs.push({ role: "error", content: errMsg });
+                await BlockService.AppendWaveAiData(this.blockId, newMsgs);
+                streamErr = error as Error;
             }
             this.setLocked(false);
             this.cancel = false;
+            if (streamErr != null && streamFn != null) {
+                throw streamErr;
+            }
         };
-        fireAndForget(handleAiStreamingResponse);
+        const rtnPromise = handleAiStreamingResponse();
+        if (streamFn == null) {
+            fireAndForget(() => rtnPromise);
+        }
+        return rtnPromise;
     }
 
     useWaveAi() {
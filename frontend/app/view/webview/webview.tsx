@@ -6,8 +6,11 @@ import { Search, useSearch } from "@/app/element/search";
 import { getApi, getBlockMetaKeyAtom, getSettingsKeyAtom, openLink } from "@/app/store/global";
 import { getSimpleControlShiftAtom } from "@/app/store/keymodel";
 import { ObjectService } from "@/app/store/services";
+import { waveEventSubscribe } from "@/app/store/wps";
+import { RpcResponseHelper, WshClient } from "@/app/store/wshclient";
 import { RpcApi } from "@/app/store/wshclientapi";
-import { TabRpcClient } from "@/app/store/wshrpcutil";
+import { makeFeBlockRouteId } from "@/app/store/wshrouter";
+import { DefaultRouter, TabRpcClient } from "@/app/store/wshrpcutil";
 import { WOS, globalStore } from "@/store/global";
 import { adaptFromReactOrNativeKeyEvent, checkKeyPressed } from "@/util/keyutil";
 import { fireAndForget } from "@/util/util";
@@ -30,6 +33,37 @@ function getWebviewPreloadUrl() {
     return "file://" + webviewPreloadUrl;
 }
 
+class WebViewWshClient extends WshClient {
+    blockId: string;
+    model: WebViewModel;
+
+    constructor(blockId: string, model: WebViewModel) {
+        super(makeFeBlockRouteId(blockId));
+        this.blockId = blockId;
+        this.model = model;
+    }
+
+    handle_webnavigate(rh: RpcResponseHelper, data: CommandWebNavigateData) {
+        const webview = this.model.webviewRef.current;
+        if (webview == null || !globalStore.get(this.model.domReady)) {
+            throw new Error("web view is not ready");
+        }
+        switch (data.action) {
+            case "reload":
+                webview.reload();
+                break;
+            case "back":
+                webview.goBack();
+                break;
+            case "forward":
+                webview.goForward();
+                break;
+            default:
+                throw new Error(`invalid web navigate action: ${data.action}`);
+        }
+    }
+}
+
 export class WebViewModel implements ViewModel {
     viewType: string;
     blockId: string;
@@ -53,6 +87,7 @@ export class WebViewModel implements ViewModel {
     domReady: PrimitiveAtom<boolean>;
     hideNav: Atom<boolean>;
     searchAtoms?: SearchAtoms;
+    webWshClient: WebViewWshClient;
 
     constructor(blockId: string, nodeModel: BlockNodeModel) {
         this.nodeModel = nodeModel;
@@ -79,6 +114,8 @@ export class WebViewModel implements ViewModel {
 
         this.mediaPlaying = atom(false);
         this.mediaMuted = atom(false);
+        this.webWshClient = new WebViewWshClient(blockId, this);
+        DefaultRouter.registerRoute(makeFeBlockRouteId(blockId), this.webWshClient);
 
         this.viewText = atom((get) => {
             const homepageUrl = get(this.homepageUrl);
@@ -241,6 +278,10 @@ export class WebViewModel implements ViewModel {
         }
     }
 
+    dispose() {
+        DefaultRouter.unregisterRoute(makeFeBlockRouteId(this.blockId));
+    }
+
     handleBack(e?: React.MouseEvent<HTMLDivElement, MouseEvent>) {
         if (e) {
             e.preventDefault();
@@ -319,8 +360,8 @@ export class WebViewModel implements ViewModel {
             url = "";
         }
 
-        if (/^(http|https|file):/.test(url)) {
-            // If the URL starts with http: or https:, return it as is
+        if (/^(http|https|file|data):/.test(url)) {
+            // If the URL starts with http:, https:, file:, or data: (e.g. from "wsh render --html"), return it as is
             return url;
         }
 
@@ -361,13 +402,32 @@ export class WebViewModel implements ViewModel {
             return;
         }
         if (this.webviewRef.current.getURL() != nextUrl) {
-            fireAndForget(() => this.webviewRef.current.loadURL(nextUrl));
+            fireAndForget(async () => {
+                const checkedUrl = await this.checkUrl(nextUrl);
+                if (checkedUrl != null) {
+                    await this.webviewRef.current?.loadURL(checkedUrl);
+                }
+            });
         }
         if (newUrl != nextUrl) {
             globalStore.set(this.url, nextUrl);
         }
     }
 
+    /**
+     * Check a URL with the backend before the webview navigates to it (see web:alloweddomains).
+     * @param url The URL the webview is about to load.
+     * @returns The canonical URL, or null if it is not allowed (the block gets a webnavblocked event with the reason).
+     */
+    async checkUrl(url: string): Promise<string> {
+        try {
+            return await RpcApi.WebCheckUrlCommand(TabRpcClient, { blockid: this.blockId, url });
+        } catch (e) {
+            console.warn("web navigation blocked", url, e);
+            return null;
+        }
+    }
+
     /**
      * Get the current URL from the state.
      * @returns The URL from the state.
@@ -675,6 +735,18 @@ const WebView = memo(({ model, onFailLoad }: WebViewProps) => {
         }
     }, [metaUrl]);
 
+    // the backend rejected a url for this block (typed, from a link, or set with "wsh web nav")
+    useEffect(() => {
+        return waveEventSubscribe({
+            eventType: "webnavblocked",
+            scope: WOS.makeORef("block", model.blockId),
+            handler: (event) => {
+                const data: WebNavBlockedData = event.data;
+                setErrorText(`Blocked ${data.url}: ${data.reason}`);
+            },
+        });
+    }, [model.blockId]);
+
     useEffect(() => {
         const webview = model.webviewRef.current;
         if (!webview) {
@@ -686,6 +758,15 @@ const WebView = memo(({ model, onFailLoad }: WebViewProps) => {
                 model.handleNavigate(e.url);
             }
         };
+        const willNavigateHandler = (e: any) => {
+            // e.g. a link in the page (this can't be cancelled, so a url that isn't allowed stops the navigation)
+            fireAndForget(async () => {
+                const checkedUrl = await model.checkUrl(e.url);
+                if (checkedUrl == null) {
+                    webview.stop();
+                }
+            });
+        };
         const newWindowHandler = (e: any) => {
             e.preventDefault();
             const newUrl = e.detail.url;
@@ -735,6 +816,7 @@ const WebView = memo(({ model, onFailLoad }: WebViewProps) => {
         webview.addEventListener("did-frame-navigate", navigateListener);
         webview.addEventListener("did-navigate-in-page", navigateListener);
         webview.addEventListener("did-navigate", navigateListener);
+        webview.addEventListener("will-navigate", willNavigateHandler);
         webview.addEventListener("did-start-loading", startLoadingHandler);
         webview.addEventListener("did-stop-loading", stopLoadingHandler);
         webview.addEventListener("new-window", newWindowHandler);
@@ -751,6 +833,7 @@ const WebView = memo(({ model, onFailLoad }: WebViewProps) => {
             webview.removeEventListener("did-frame-navigate", navigateListener);
             webview.removeEventListener("did-navigate", navigateListener);
             webview.removeEventListener("did-navigate-in-page", navigateListener);
+            webview.removeEventListener("will-navigate", willNavigateHandler);
             webview.removeEventListener("new-window", newWindowHandler);
             webview.removeEventListener("did-fail-load", failLoadHandler);
             webview.removeEventListener("did-start-loading", startLoadingHandler);
@@ -7,7 +7,7 @@ import { Atom, atom, Getter, PrimitiveAtom, Setter } from "jotai";
 import { splitAtom } from "jotai/utils";
 import { createRef, CSSProperties } from "react";
 import { debounce } from "throttle-debounce";
-import { balanceNode, findNode, newLayoutNode, walkNodes } from "./layoutNode";
+import { balanceNode, findNode, findParent, newLayoutNode, totalChildrenSize, walkNodes } from "./layoutNode";
 import {
     clearTree,
     computeMoveNode,
@@ -22,6 +22,7 @@ import {
 } from "./layoutTree";
 import {
     ContentRenderer,
+    DropDirection,
     FlexDirection,
     LayoutNode,
     LayoutNodeAdditionalProps,
@@ -63,6 +64,14 @@ const DefaultGapSizePx = 3;
 const MinNodeSizePx = 40;
 const DefaultAnimationTimeS = 0.15;
 
+// the directions of a "split" backend action (SplitDirection_* in wshrpctypes.go)
+const SplitDirectionMap: Record<string, DropDirection> = {
+    left: DropDirection.Left,
+    right: DropDirection.Right,
+    top: DropDirection.Top,
+    bottom: DropDirection.Bottom,
+};
+
 export class LayoutModel {
     /**
      * The jotai atom for persisting the tree state to the backend and retrieving updates from the backend.
@@ -472,6 +481,125 @@ export class LayoutModel {
                             );
                             break;
                         }
+                        case LayoutTreeActionType.FocusNode: {
+                            const leaf = this?.getNodeByBlockId(action.blockid);
+                            if (leaf) {
+                                this.treeReducer(
+                                    {
+                                        type: LayoutTreeActionType.FocusNode,
+                                        nodeId: leaf.id,
+                                    } as LayoutTreeFocusNodeAction,
+                                    false
+                                );
+                                // e.g. "wsh view -m" reusing an existing block
+                                if (action.magnified && this.treeState.magnifiedNodeId !== leaf.id) {
+                                    this.magnifyNodeToggle(leaf.id, false);
+                                }
+                            } else {
+                                console.error(
+                                    "Cannot apply eventbus layout action FocusNode, could not find leaf node with blockId",
+                                    action.blockid
+                                );
+                            }
+                            break;
+                        }
+                        case "split": {
+                            // "wsh split", the new block goes next to the target block
+                            const targetLeaf = this.getNodeByBlockId(action.targetblockid);
+                            const direction = SplitDirectionMap[action.direction];
+                            if (!targetLeaf || direction == null) {
+                                console.error(
+                                    "Cannot apply eventbus layout action Split, invalid target block or direction",
+                                    action.targetblockid,
+                                    action.direction
+                                );
+                                break;
+                            }
+                            const newNode = newLayoutNode(undefined, undefined, undefined, {
+                                blockId: action.blockid,
+                            });
+                            this.treeReducer(
+                                {
+                                    type: LayoutTreeActionType.InsertNode,
+                                    node: newNode,
+                                    focused: action.focused,
+                                } as LayoutTreeInsertNodeAction,
+                                false
+                            );
+                            const moveAction = computeMoveNode(this.treeState, {
+                                type: LayoutTreeActionType.ComputeMove,
+                                nodeId: targetLeaf.id,
+                                nodeToMoveId: newNode.id,
+                                direction,
+                            });
+                            if (moveAction) {
+                                this.treeReducer(moveAction, false);
+                            }
+                            break;
+                        }
+                        case LayoutTreeActionType.ResizeNode: {
+                            const leaf = this.getNodeByBlockId(action.blockid);
+                            const parent = leaf ? findParent(this.treeState.rootNode, leaf.id) : undefined;
+                            if (!parent || !(action.sizefraction > 0 && action.sizefraction < 1)) {
+                                console.error(
+                                    "Cannot apply eventbus layout action ResizeNode, invalid block or size",
+                                    action.blockid,
+                                    action.sizefraction
+                                );
+                                break;
+                            }
+                            // the siblings are scaled so the total size of the children stays the same
+                            const totalSize = totalChildrenSize(parent);
+                            const otherSize = totalSize - leaf.size;
+                            const resizeOperations = parent.children.map((child) => {
+                                if (child.id === leaf.id) {
+                                    return { nodeId: child.id, size: totalSize * action.sizefraction };
+                                }
+                                const share = otherSize > 0 ? child.size / otherSize : 1 / (parent.children.length - 1);
+                                return { nodeId: child.id, size: totalSize * (1 - action.sizefraction) * share };
+                            });
+                            this.treeReducer(
+                                { type: LayoutTreeActionType.ResizeNode, resizeOperations } as LayoutTreeResizeNodeAction,
+                                false
+                            );
+                            break;
+                        }
+                        case LayoutTreeActionType.Swap: {
+                            const leaf1 = this.getNodeByBlockId(action.blockid);
+                            const leaf2 = this.getNodeByBlockId(action.targetblockid);
+                            if (!leaf1 || !leaf2) {
+                                console.error(
+                                    "Cannot apply eventbus layout action Swap, could not find leaf nodes with blockIds",
+                                    action.blockid,
+                                    action.targetblockid
+                                );
+                                break;
+                            }
+                            this.treeReducer(
+                                {
+                                    type: LayoutTreeActionType.Swap,
+                                    node1Id: leaf1.id,
+                                    node2Id: leaf2.id,
+                                } as LayoutTreeSwapNodeAction,
+                                false
+                            );
+                            break;
+                        }
+                        case LayoutTreeActionType.MagnifyNodeToggle: {
+                            const leaf = this.getNodeByBlockId(action.blockid);
+                            if (!leaf) {
+                                console.error(
+                                    "Cannot apply eventbus layout action MagnifyNodeToggle, could not find leaf node with blockId",
+                                    action.blockid
+                                );
+                                break;
+                            }
+                            // the action has the new state, so it does nothing if the block is already (de)magnified
+                            if (action.magnified !== (this.treeState.magnifiedNodeId === leaf.id)) {
+                                this.magnifyNodeToggle(leaf.id, false);
+                            }
+                            break;
+                        }
                         default:
                             console.warn("unsupported layout action", action);
                             break;
@@ -351,6 +351,7 @@ declare global {
         actions?: NotificationActionType[];
         persistent?: boolean;
         type?: "error" | "update" | "info" | "warning";
+        blockid?: string;
     };
 
     interface AbstractWshClient {
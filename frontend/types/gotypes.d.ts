@@ -13,6 +13,24 @@ declare global {
         internal?: boolean;
     };
 
+    // wshrpc.ActivityRecord
+    type ActivityRecord = {
+        feature: string;
+        success: boolean;
+        durationms?: number;
+        meta?: {[key: string]: any};
+    };
+
+    // wshrpc.ActivityStat
+    type ActivityStat = {
+        feature: string;
+        count: number;
+        errors: number;
+        avgdurationms: number;
+        lastday: string;
+        metacounts?: {[key: string]: number};
+    };
+
     // wshrpc.ActivityUpdate
     type ActivityUpdate = {
         fgminutes?: number;
@@ -40,6 +58,11 @@ declare global {
         conn?: {[key: string]: number};
     };
 
+    // clientservice.AgreeTosRtnType
+    type AgreeTosRtnType = {
+        bootstrapped?: boolean;
+    };
+
     // wshrpc.AiMessageData
     type AiMessageData = {
         message?: string;
@@ -51,6 +74,8 @@ declare global {
         runtimeopts?: RuntimeOpts;
         stickers?: StickerType[];
         subblockids?: string[];
+        deletedts?: number;
+        createdts?: number;
     };
 
     // blockcontroller.BlockControllerRuntimeStatus
@@ -60,6 +85,7 @@ declare global {
         shellprocstatus?: string;
         shellprocconnname?: string;
         shellprocexitcode: number;
+        crashcount?: number;
     };
 
     // waveobj.BlockDef
@@ -68,6 +94,14 @@ declare global {
         meta?: MetaType;
     };
 
+    // wshrpc.BlockDefWithLayout
+    type BlockDefWithLayout = {
+        blockdef: BlockDef;
+        indexarr?: number[];
+        size?: number;
+        focused?: boolean;
+    };
+
     // wshrpc.BlockInfoData
     type BlockInfoData = {
         blockid: string;
@@ -84,6 +118,38 @@ declare global {
         inputdata64: string;
     };
 
+    // waveobj.BlockLink
+    type BlockLink = WaveObj & {
+        srcblockid: string;
+        dstblockid: string;
+        ruletype: string;
+        rule: string;
+        createdts: number;
+    };
+
+    // wshrpc.BlockListEntry
+    type BlockListEntry = {
+        blockid: string;
+        tabid: string;
+        windowid: string;
+        workspaceid: string;
+        view?: string;
+        meta: MetaType;
+        deletedts?: number;
+        createdts?: number;
+        cwd?: string;
+        controllerstatus?: string;
+        controllerexitcode?: number;
+        scrollbacksize?: number;
+    };
+
+    // waveobj.BlockMetaUpdateData
+    type BlockMetaUpdateData = {
+        blockid: string;
+        version: number;
+        meta: MetaType;
+    };
+
     // waveobj.Client
     type Client = WaveObj & {
         windowids: string[];
@@ -98,6 +164,16 @@ declare global {
         newactivetabid?: string;
     };
 
+    // wshrpc.CmdRecord
+    type CmdRecord = {
+        seq: number;
+        cmd: string;
+        cwd?: string;
+        startts: number;
+        endts: number;
+        exitcode?: number;
+    };
+
     // wshrpc.CommandAppendIJsonData
     type CommandAppendIJsonData = {
         zoneid: string;
@@ -105,6 +181,14 @@ declare global {
         data: {[key: string]: any};
     };
 
+    // wshrpc.CommandAuthPromptResponseData
+    type CommandAuthPromptResponseData = {
+        promptid: string;
+        text?: string;
+        confirm?: boolean;
+        cancel?: boolean;
+    };
+
     // wshrpc.CommandAuthenticateRtnData
     type CommandAuthenticateRtnData = {
         routeid: string;
@@ -125,12 +209,73 @@ declare global {
         view: string;
     };
 
+    // wshrpc.CommandCaptureBlockData
+    type CommandCaptureBlockData = {
+        blockid?: string;
+        tabid: string;
+        tab?: boolean;
+        windowid?: string;
+        maxsize?: number;
+    };
+
+    // wshrpc.CommandCloseTabData
+    type CommandCloseTabData = {
+        tabid: string;
+        force?: boolean;
+        allblocks?: boolean;
+    };
+
+    // wshrpc.CommandCloseWindowData
+    type CommandCloseWindowData = {
+        windowid?: string;
+        tabid: string;
+    };
+
+    // wshrpc.CommandConfigPathData
+    type CommandConfigPathData = {
+        section?: string;
+    };
+
+    // wshrpc.CommandConnConnectInteractiveData
+    type CommandConnConnectInteractiveData = {
+        connname: string;
+        keywords?: ConnKeywords;
+        logblockid?: string;
+        ensure?: boolean;
+    };
+
+    // wshrpc.CommandConnTestData
+    type CommandConnTestData = {
+        connname: string;
+        timeout?: number;
+    };
+
+    // wshrpc.CommandConnTestRtnData
+    type CommandConnTestRtnData = {
+        connection: string;
+        authmethod: string;
+        connecttimems: number;
+        latencyms: number;
+    };
+
+    // wshrpc.CommandConnTrustHostKeyData
+    type CommandConnTrustHostKeyData = {
+        connname: string;
+        fingerprint: string;
+    };
+
     // wshrpc.CommandControllerAppendOutputData
     type CommandControllerAppendOutputData = {
         blockid: string;
         data64: string;
     };
 
+    // wshrpc.CommandControllerRestartData
+    type CommandControllerRestartData = {
+        blockid: string;
+        force?: boolean;
+    };
+
     // wshrpc.CommandControllerResyncData
     type CommandControllerResyncData = {
         forcerestart?: boolean;
@@ -142,10 +287,30 @@ declare global {
     // wshrpc.CommandCreateBlockData
     type CommandCreateBlockData = {
         tabid: string;
+        windowid?: string;
         blockdef: BlockDef;
         rtopts?: RuntimeOpts;
         magnified?: boolean;
         ephemeral?: boolean;
+        indexarr?: number[];
+        position?: string;
+        reuseexisting?: boolean;
+    };
+
+    // wshrpc.CommandCreateBlockRtnData
+    type CommandCreateBlockRtnData = {
+        blockoref: ORef;
+        blockid: string;
+        tabid: string;
+        windowid: string;
+        indexarr?: number[];
+        reused?: boolean;
+    };
+
+    // wshrpc.CommandCreateBlocksData
+    type CommandCreateBlocksData = {
+        tabid: string;
+        blocks: BlockDefWithLayout[];
     };
 
     // wshrpc.CommandCreateSubBlockData
@@ -154,9 +319,38 @@ declare global {
         blockdef: BlockDef;
     };
 
+    // wshrpc.CommandCreateTabData
+    type CommandCreateTabData = {
+        workspaceid?: string;
+        windowid?: string;
+        tabid: string;
+        tabname?: string;
+        noactivate?: boolean;
+        pinned?: boolean;
+    };
+
+    // wshrpc.CommandDebugIntegrityData
+    type CommandDebugIntegrityData = {
+        repair?: boolean;
+        deleteorphans?: boolean;
+    };
+
     // wshrpc.CommandDeleteBlockData
     type CommandDeleteBlockData = {
         blockid: string;
+        kill?: boolean;
+        gracems?: number;
+    };
+
+    // wshrpc.CommandDeleteBlockRtnData
+    type CommandDeleteBlockRtnData = {
+        numkilled?: number;
+    };
+
+    // wshrpc.CommandDeleteBlocksData
+    type CommandDeleteBlocksData = {
+        blockids: string[];
+        includepinned?: boolean;
     };
 
     // wshrpc.CommandDisposeData
@@ -164,6 +358,12 @@ declare global {
         routeid: string;
     };
 
+    // wshrpc.CommandDuplicateBlockData
+    type CommandDuplicateBlockData = {
+        blockid: string;
+        meta?: MetaType;
+    };
+
     // wshrpc.CommandEventReadHistoryData
     type CommandEventReadHistoryData = {
         event: string;
@@ -171,6 +371,12 @@ declare global {
         maxitems: number;
     };
 
+    // wshrpc.CommandExportAIConversationData
+    type CommandExportAIConversationData = {
+        blockid: string;
+        format: string;
+    };
+
     // wshrpc.CommandFileCreateData
     type CommandFileCreateData = {
         zoneid: string;
@@ -202,17 +408,233 @@ declare global {
         limit?: number;
     };
 
+    // wshrpc.CommandFileOpData
+    type CommandFileOpData = {
+        connection?: string;
+        root?: string;
+        norootcheck?: boolean;
+        path: string;
+        newpath?: string;
+        recursive?: boolean;
+        trash?: boolean;
+    };
+
+    // wshrpc.CommandFileSaveData
+    type CommandFileSaveData = {
+        connection?: string;
+        path: string;
+        data64: string;
+        version?: FileVersion;
+        force?: boolean;
+    };
+
+    // wshrpc.CommandFileTransferData
+    type CommandFileTransferData = {
+        srcconn?: string;
+        srcpath: string;
+        destconn?: string;
+        destpath: string;
+        resume?: boolean;
+        recursive?: boolean;
+        maxsize?: number;
+    };
+
+    // wshrpc.CommandGetCmdHistoryData
+    type CommandGetCmdHistoryData = {
+        blockid: string;
+        limit?: number;
+    };
+
     // wshrpc.CommandGetMetaData
     type CommandGetMetaData = {
         oref: ORef;
     };
 
+    // wshrpc.CommandGetMetricsData
+    type CommandGetMetricsData = {
+        connection?: string;
+        metrics?: string[];
+        count?: number;
+        intervalms?: number;
+    };
+
+    // wshrpc.CommandGetScrollbackData
+    type CommandGetScrollbackData = {
+        blockid: string;
+        lines?: number;
+        raw?: boolean;
+        follow?: boolean;
+    };
+
+    // wshrpc.CommandGetScrollbackRtnData
+    type CommandGetScrollbackRtnData = {
+        data64: string;
+    };
+
+    // wshrpc.CommandImportHistoryData
+    type CommandImportHistoryData = {
+        shell: string;
+        connection?: string;
+        replace?: boolean;
+        records: HistoryRecord[];
+    };
+
+    // wshrpc.CommandInjectPathData
+    type CommandInjectPathData = {
+        tabid: string;
+        blockid?: string;
+        path: string;
+    };
+
+    // wshrpc.CommandLaunchWindowData
+    type CommandLaunchWindowData = {
+        workspace?: string;
+        blockdef?: BlockDef;
+        tabname?: string;
+        layout?: PortableLayoutEntry[];
+    };
+
+    // wshrpc.CommandLayoutActionData
+    type CommandLayoutActionData = {
+        actiontype: string;
+        blockid: string;
+        otherblockid?: string;
+        direction?: string;
+        blockdef?: BlockDef;
+        size?: number;
+    };
+
+    // wshrpc.CommandLayoutActionRtnData
+    type CommandLayoutActionRtnData = {
+        blockid?: string;
+    };
+
+    // wshrpc.CommandLayoutPresetData
+    type CommandLayoutPresetData = {
+        tabid: string;
+        name: string;
+        clearexisting?: boolean;
+        force?: boolean;
+    };
+
+    // wshrpc.CommandLinkBlocksData
+    type CommandLinkBlocksData = {
+        srcblockid: string;
+        dstblockid: string;
+        ruletype: string;
+        rule: string;
+    };
+
+    // wshrpc.CommandListData
+    type CommandListData = {
+        windowid?: string;
+        tabid?: string;
+        view?: string;
+        deleted?: boolean;
+        pinned?: boolean;
+        sizes?: boolean;
+    };
+
+    // wshrpc.CommandLogTailData
+    type CommandLogTailData = {
+        level?: string;
+        subsystems?: string[];
+        reqid?: string;
+    };
+
     // wshrpc.CommandMessageData
     type CommandMessageData = {
         oref: ORef;
         message: string;
     };
 
+    // wshrpc.CommandMoveBlockData
+    type CommandMoveBlockData = {
+        blockid: string;
+        desttabid: string;
+        indexarr?: number[];
+    };
+
+    // wshrpc.CommandMoveTabData
+    type CommandMoveTabData = {
+        tabid: string;
+        index: number;
+    };
+
+    // wshrpc.CommandPreviewWatchData
+    type CommandPreviewWatchData = {
+        blockid: string;
+        connection?: string;
+        path: string;
+    };
+
+    // wshrpc.CommandQueryBlocksData
+    type CommandQueryBlocksData = {
+        view?: string;
+        urlprefix?: string;
+        urlcontains?: string;
+        fileprefix?: string;
+        filecontains?: string;
+        connection?: string;
+        pinned?: boolean;
+        tabid?: string;
+        windowid?: string;
+        createdafter?: number;
+        limit?: number;
+        sizes?: boolean;
+    };
+
+    // wshrpc.CommandRemoteExecData
+    type CommandRemoteExecData = {
+        connname: string;
+        cmd: string[];
+        input?: boolean;
+        noconnect?: boolean;
+    };
+
+    // wshrpc.CommandRemoteExecInputData
+    type CommandRemoteExecInputData = {
+        execid: string;
+        input64?: string;
+        eof?: boolean;
+    };
+
+    // wshrpc.CommandRemoteFileReadAtData
+    type CommandRemoteFileReadAtData = {
+        path: string;
+        offset: number;
+        size: number;
+    };
+
+    // wshrpc.CommandRemoteFileReadRangeData
+    type CommandRemoteFileReadRangeData = {
+        path: string;
+        offset: number;
+        size?: number;
+    };
+
+    // wshrpc.CommandRemoteFileRemoveData
+    type CommandRemoteFileRemoveData = {
+        path: string;
+        recursive?: boolean;
+        trash?: boolean;
+    };
+
+    // wshrpc.CommandRemoteFileWriteAtData
+    type CommandRemoteFileWriteAtData = {
+        path: string;
+        offset: number;
+        data64?: string;
+        truncate?: boolean;
+        createmode?: number;
+    };
+
+    // wshrpc.CommandRemoteListEntriesData
+    type CommandRemoteListEntriesData = {
+        path: string;
+        opts?: FileListOpts;
+    };
+
     // wshrpc.CommandRemoteStreamFileData
     type CommandRemoteStreamFileData = {
         path: string;
@@ -225,11 +647,40 @@ declare global {
         data64?: string;
     };
 
+    // wshrpc.CommandRemoteThumbnailData
+    type CommandRemoteThumbnailData = {
+        path: string;
+        maxdim?: number;
+        metaonly?: boolean;
+    };
+
+    // wshrpc.CommandRemoteUntarData
+    type CommandRemoteUntarData = {
+        tarpath: string;
+        destpath: string;
+    };
+
     // wshrpc.CommandRemoteWriteFileData
     type CommandRemoteWriteFileData = {
         path: string;
         data64: string;
         createmode?: number;
+        version?: FileVersion;
+    };
+
+    // wshrpc.CommandRenameTabData
+    type CommandRenameTabData = {
+        tabid: string;
+        name: string;
+    };
+
+    // wshrpc.CommandRenderHtmlData
+    type CommandRenderHtmlData = {
+        html: string;
+        blockid?: string;
+        tabid: string;
+        position?: string;
+        magnified?: boolean;
     };
 
     // wshrpc.CommandResolveIdsData
@@ -243,12 +694,130 @@ declare global {
         resolvedids: {[key: string]: ORef};
     };
 
+    // wshrpc.CommandSearchHistoryData
+    type CommandSearchHistoryData = {
+        query?: string;
+        prefix?: boolean;
+        cwd?: string;
+        connection?: string;
+        failed?: boolean;
+        offset?: number;
+        limit?: number;
+    };
+
+    // wshrpc.CommandSearchHistoryRtnData
+    type CommandSearchHistoryRtnData = {
+        records: HistoryRecord[];
+        hasmore?: boolean;
+    };
+
+    // wshrpc.CommandSearchScrollbackData
+    type CommandSearchScrollbackData = {
+        blockid: string;
+        pattern: string;
+        casesensitive?: boolean;
+        maxresults?: number;
+    };
+
+    // wshrpc.CommandSearchScrollbackRtnData
+    type CommandSearchScrollbackRtnData = {
+        matches: ScrollbackMatch[];
+        scannedbytes: number;
+        truncated?: boolean;
+        incomplete?: boolean;
+    };
+
+    // wshrpc.CommandSetBlockMagnifiedData
+    type CommandSetBlockMagnifiedData = {
+        blockid: string;
+        magnified: boolean;
+        toggle?: boolean;
+        nofocus?: boolean;
+    };
+
+    // wshrpc.CommandSetBlockMagnifiedRtnData
+    type CommandSetBlockMagnifiedRtnData = {
+        tabid: string;
+        magnified: boolean;
+    };
+
+    // wshrpc.CommandSetLogLevelData
+    type CommandSetLogLevelData = {
+        subsystem?: string;
+        level?: string;
+    };
+
     // wshrpc.CommandSetMetaData
     type CommandSetMetaData = {
         oref: ORef;
         meta: MetaType;
     };
 
+    // wshrpc.CommandSetSecretData
+    type CommandSetSecretData = {
+        name: string;
+        value: string;
+    };
+
+    // wshrpc.CommandSetTabGroupData
+    type CommandSetTabGroupData = {
+        tabid: string;
+        groupname: string;
+    };
+
+    // wshrpc.CommandSetTabPinnedData
+    type CommandSetTabPinnedData = {
+        tabid: string;
+        pinned: boolean;
+    };
+
+    // wshrpc.CommandSetTabTitleTemplateData
+    type CommandSetTabTitleTemplateData = {
+        tabid: string;
+        template: string;
+    };
+
+    // wshrpc.CommandSetWindowGeometryData
+    type CommandSetWindowGeometryData = {
+        windowid?: string;
+        tabid: string;
+        geometry: WindowGeometry;
+    };
+
+    // wshrpc.CommandSnapshotImportData
+    type CommandSnapshotImportData = {
+        data: string;
+        keepids?: boolean;
+        newwindow?: boolean;
+    };
+
+    // wshrpc.CommandStreamEventsData
+    type CommandStreamEventsData = {
+        eventtypes: string[];
+        tabid?: string;
+        blockid?: string;
+        buffersize?: number;
+    };
+
+    // wshrpc.CommandTermResizeData
+    type CommandTermResizeData = {
+        blockid: string;
+        termsize: TermSize;
+    };
+
+    // wshrpc.CommandTermSendData
+    type CommandTermSendData = {
+        blockid: string;
+        data64: string;
+        start?: boolean;
+    };
+
+    // wshrpc.CommandTermTrimData
+    type CommandTermTrimData = {
+        blockid: string;
+        keepbytes: number;
+    };
+
     // wshrpc.CommandVarData
     type CommandVarData = {
         key: string;
@@ -271,6 +840,18 @@ declare global {
         waitms: number;
     };
 
+    // wshrpc.CommandWebCheckUrlData
+    type CommandWebCheckUrlData = {
+        blockid: string;
+        url: string;
+    };
+
+    // wshrpc.CommandWebNavigateData
+    type CommandWebNavigateData = {
+        blockid: string;
+        action: string;
+    };
+
     // wshrpc.CommandWebSelectorData
     type CommandWebSelectorData = {
         workspaceid: string;
@@ -280,12 +861,70 @@ declare global {
         opts?: WebSelectorOpts;
     };
 
+    // wshrpc.CommandWorkspaceCreateData
+    type CommandWorkspaceCreateData = {
+        name: string;
+        icon?: string;
+        color?: string;
+    };
+
+    // wshrpc.CommandWorkspaceDeleteData
+    type CommandWorkspaceDeleteData = {
+        workspaceid: string;
+        force?: boolean;
+        movetoworkspaceid?: string;
+    };
+
+    // wshrpc.CommandWorkspaceRenameData
+    type CommandWorkspaceRenameData = {
+        workspaceid: string;
+        name: string;
+    };
+
+    // wshrpc.CommandWorkspaceSwitchData
+    type CommandWorkspaceSwitchData = {
+        windowid?: string;
+        tabid?: string;
+        workspaceid: string;
+    };
+
     // wconfig.ConfigError
     type ConfigError = {
         file: string;
         err: string;
     };
 
+    // wshrpc.ConfigPathRtnData
+    type ConfigPathRtnData = {
+        section: string;
+        path: string;
+        exists: boolean;
+        backuppath?: string;
+    };
+
+    // wconfig.ConfigValidationError
+    type ConfigValidationError = {
+        key?: string;
+        line?: number;
+        message: string;
+    };
+
+    // wconfig.ConfigValidationEventData
+    type ConfigValidationEventData = {
+        path: string;
+        errors: ConfigValidationError[];
+    };
+
+    // wshrpc.ConnAuthPrompt
+    type ConnAuthPrompt = {
+        promptid: string;
+        responsetype: string;
+        title: string;
+        querytext: string;
+        echo?: boolean;
+        timeoutms: number;
+    };
+
     // wshrpc.ConnConfigRequest
     type ConnConfigRequest = {
         host: string;
@@ -304,6 +943,7 @@ declare global {
         "conn:askbeforewshinstall"?: boolean;
         "conn:overrideconfig"?: boolean;
         "conn:wshpath"?: string;
+        "conn:autoreconnect"?: boolean;
         "display:hidden"?: boolean;
         "display:order"?: number;
         "term:*"?: boolean;
@@ -342,9 +982,20 @@ declare global {
         hasconnected: boolean;
         activeconnnum: number;
         error?: string;
+        errorhop?: string;
         wsherror?: string;
         nowshreason?: string;
         wshversion?: string;
+        source?: string;
+        reconnecting?: boolean;
+        reconnectattempt?: number;
+        nextreconnectts?: number;
+    };
+
+    // wps.ControllerExitEventData
+    type ControllerExitEventData = {
+        blockid: string;
+        exitcode: number;
     };
 
     // wshrpc.CpuDataRequest
@@ -353,6 +1004,53 @@ declare global {
         count: number;
     };
 
+    // wshrpc.DBStatus
+    type DBStatus = {
+        name: string;
+        path: string;
+        open: boolean;
+        locked: boolean;
+        inflight: number;
+        lastokts?: number;
+        lasterr?: string;
+        lasterrts?: number;
+    };
+
+    // wshrpc.DBVersionInfo
+    type DBVersionInfo = {
+        store: string;
+        version: number;
+        expected: number;
+        dirty?: boolean;
+    };
+
+    // wshrpc.DebugCacheStatsData
+    type DebugCacheStatsData = {
+        hits: number;
+        misses: number;
+        entries: number;
+    };
+
+    // wshrpc.DebugDBVersionRtnData
+    type DebugDBVersionRtnData = {
+        stores: DBVersionInfo[];
+    };
+
+    // wshrpc.DebugIntegrityRtnData
+    type DebugIntegrityRtnData = {
+        dangling: IntegrityIssueData[];
+        orphans: IntegrityIssueData[];
+        repaired?: boolean;
+        numupdated?: number;
+        numdeleted?: number;
+    };
+
+    // wshrpc.DeleteBlocksRtnData
+    type DeleteBlocksRtnData = {
+        deleted: string[];
+        skipped?: string[];
+    };
+
     // vdom.DomRect
     type DomRect = {
         top: number;
@@ -363,6 +1061,13 @@ declare global {
         height: number;
     };
 
+    // wshrpc.FileCreatedData
+    type FileCreatedData = {
+        blockid: string;
+        path: string;
+        created: boolean;
+    };
+
     // waveobj.FileDef
     type FileDef = {
         content?: string;
@@ -382,6 +1087,17 @@ declare global {
         isdir?: boolean;
         mimetype?: string;
         readonly?: boolean;
+        realpath?: string;
+    };
+
+    // wshrpc.FileListOpts
+    type FileListOpts = {
+        sort?: string;
+        sortdesc?: boolean;
+        showhidden?: boolean;
+        filter?: string;
+        offset?: number;
+        limit?: number;
     };
 
     // filestore.FileOptsType
@@ -392,6 +1108,32 @@ declare global {
         ijsonbudget?: number;
     };
 
+    // wshrpc.FileReadRangeRtnData
+    type FileReadRangeRtnData = {
+        data64?: string;
+        offset: number;
+        filesize: number;
+        eof?: boolean;
+    };
+
+    // wshrpc.FileTransferProgress
+    type FileTransferProgress = {
+        destpath: string;
+        size: number;
+        transferred: number;
+        resumedat?: number;
+        isdir?: boolean;
+        done?: boolean;
+        sha256?: string;
+    };
+
+    // wshrpc.FileVersion
+    type FileVersion = {
+        modtime: number;
+        size: number;
+        sha256?: string;
+    };
+
     // wconfig.FullConfigType
     type FullConfigType = {
         settings: SettingsType;
@@ -408,6 +1150,45 @@ declare global {
     type FullFile = {
         info: FileInfo;
         data64: string;
+        version?: FileVersion;
+    };
+
+    // wshrpc.HistoryRecord
+    type HistoryRecord = {
+        historyid: number;
+        ts: number;
+        cmd: string;
+        cwd?: string;
+        connection?: string;
+        exitcode?: number;
+        durationms?: number;
+        blockid?: string;
+        source: string;
+        count: number;
+    };
+
+    // wshrpc.HostKeyChangedData
+    type HostKeyChangedData = {
+        connname: string;
+        hostname: string;
+        keytype: string;
+        fingerprint: string;
+        knownkeys: string[];
+    };
+
+    // wshrpc.IntegrityIssueData
+    type IntegrityIssueData = {
+        oref: string;
+        field?: string;
+        refid?: string;
+        reason: string;
+    };
+
+    // wshrpc.LaunchWindowRtnData
+    type LaunchWindowRtnData = {
+        windowid: string;
+        tabid: string;
+        blockid?: string;
     };
 
     // waveobj.LayoutActionData
@@ -419,6 +1200,16 @@ declare global {
         focused: boolean;
         magnified: boolean;
         ephemeral: boolean;
+        targetblockid?: string;
+        direction?: string;
+        sizefraction?: number;
+    };
+
+    // waveobj.LayoutPreset
+    type LayoutPreset = WaveObj & {
+        name: string;
+        createdts: number;
+        layout: PortableLayoutEntry[];
     };
 
     // waveobj.LayoutState
@@ -436,17 +1227,48 @@ declare global {
         blockid: string;
     };
 
+    // wlog.LogField
+    type LogField = {
+        key: string;
+        value: string;
+    };
+
+    // wlog.LogRecord
+    type LogRecord = {
+        ts: number;
+        level: string;
+        subsystem: string;
+        msg: string;
+        reqid?: string;
+        fields?: LogField[];
+    };
+
     // waveobj.MetaTSType
     type MetaType = {
         view?: string;
         controller?: string;
         file?: string;
+        "file:temp"?: boolean;
+        "file:waitcreate"?: boolean;
+        "file:waitcreateuntil"?: number;
+        "file:size"?: number;
+        "file:sort"?: string;
+        "file:sortdesc"?: boolean;
+        "file:showhidden"?: boolean;
+        "file:offset"?: number;
+        "file:limit"?: number;
         url?: string;
         pinnedurl?: string;
         connection?: string;
         edit?: boolean;
+        pinned?: boolean;
+        magnified?: boolean;
         history?: string[];
         "history:forward"?: string[];
+        "controller:running"?: boolean;
+        "controller:restore"?: boolean;
+        "controller:restoreerror"?: string;
+        "controller:shelltype"?: string;
         "display:name"?: string;
         "display:order"?: number;
         icon?: string;
@@ -468,6 +1290,9 @@ declare global {
         "cmd:closeonexit"?: boolean;
         "cmd:closeonexitforce"?: boolean;
         "cmd:closeonexitdelay"?: number;
+        "cmd:onexit"?: string;
+        "cmd:onfail"?: string;
+        "cmd:hooktimeoutms"?: number;
         "cmd:env"?: {[key: string]: string};
         "cmd:cwd"?: string;
         "cmd:nowsh"?: boolean;
@@ -475,9 +1300,11 @@ declare global {
         "cmd:shell"?: boolean;
         "ai:*"?: boolean;
         "ai:preset"?: string;
+        "ai:provider"?: string;
         "ai:apitype"?: string;
         "ai:baseurl"?: string;
         "ai:apitoken"?: string;
+        "ai:apikeyref"?: string;
         "ai:name"?: string;
         "ai:model"?: string;
         "ai:orgid"?: string;
@@ -492,12 +1319,16 @@ declare global {
         "graph:numpoints"?: number;
         "graph:metrics"?: string[];
         "sysinfo:type"?: string;
+        "plot:*"?: boolean;
+        "plot:metrics"?: string[];
+        "plot:interval"?: string;
         "bg:*"?: boolean;
         bg?: string;
         "bg:opacity"?: number;
         "bg:blendmode"?: string;
         "bg:bordercolor"?: string;
         "bg:activebordercolor"?: string;
+        "tab:titletemplate"?: string;
         "term:*"?: boolean;
         "term:fontsize"?: number;
         "term:fontfamily"?: string;
@@ -506,6 +1337,8 @@ declare global {
         "term:localshellpath"?: string;
         "term:localshellopts"?: string[];
         "term:scrollback"?: number;
+        "term:scrollbackbytes"?: number;
+        "term:scrollbacklines"?: number;
         "term:vdomblockid"?: string;
         "term:vdomtoolbarblockid"?: string;
         "term:transparency"?: number;
@@ -513,6 +1346,7 @@ declare global {
         "term:conndebug"?: string;
         "web:zoom"?: number;
         "web:hidenav"?: boolean;
+        "web:rendered"?: boolean;
         "markdown:fontsize"?: number;
         "markdown:fixedfontsize"?: number;
         "vdom:*"?: boolean;
@@ -520,6 +1354,18 @@ declare global {
         "vdom:correlationid"?: string;
         "vdom:route"?: string;
         "vdom:persist"?: boolean;
+        "client:dbtimeoutms"?: number;
+        "client:theme"?: string;
+        "client:defaultshell"?: string;
+        "client:defaultview"?: string;
+        "client:telemetryoptout"?: boolean;
+        "client:scrollbackbytes"?: number;
+        "client:scrollbacklines"?: number;
+        "client:onexit"?: string;
+        "client:onfail"?: string;
+        "client:aimaxmessages"?: number;
+        "client:airetentiondays"?: number;
+        "client:loglevels"?: {[key: string]: string};
         count?: number;
     };
 
@@ -553,6 +1399,29 @@ declare global {
         y: number;
     };
 
+    // waveobj.PortableLayoutEntry
+    type PortableLayoutEntry = {
+        indexarr: number[];
+        size?: number;
+        blockdef: BlockDef;
+        focused: boolean;
+    };
+
+    // wshrpc.PreviewReloadData
+    type PreviewReloadData = {
+        blockid: string;
+    };
+
+    // wshrpc.RemoteExecPacket
+    type RemoteExecPacket = {
+        execid?: string;
+        stream?: string;
+        data64?: string;
+        done?: boolean;
+        exitcode: number;
+        exitsignal?: string;
+    };
+
     // wshrpc.RemoteInfo
     type RemoteInfo = {
         clientarch: string;
@@ -561,6 +1430,14 @@ declare global {
         shell: string;
     };
 
+    // wshrpc.RemoteListEntriesRtnData
+    type RemoteListEntriesRtnData = {
+        entries: FileInfo[];
+        parent?: FileInfo;
+        offset: number;
+        total: number;
+    };
+
     // wshutil.RpcMessage
     type RpcMessage = {
         command?: string;
@@ -572,7 +1449,11 @@ declare global {
         source?: string;
         cont?: boolean;
         cancel?: boolean;
+        streamwindow?: number;
+        streamack?: number;
         error?: string;
+        errorcode?: string;
+        errordata?: any;
         datatype?: string;
         data?: any;
     };
@@ -590,6 +1471,45 @@ declare global {
         winsize?: WinSize;
     };
 
+    // wshrpc.ScrollbackMatch
+    type ScrollbackMatch = {
+        line: number;
+        offset: number;
+        length: number;
+        snippet: string;
+        snippetoffset: number;
+        snippetlength: number;
+    };
+
+    // wshrpc.ServerStatusData
+    type ServerStatusData = {
+        version: string;
+        buildtime?: string;
+        startts: number;
+        uptimems: number;
+        healthy: boolean;
+        dbs: DBStatus[];
+        numwebsockets: number;
+        numconnectedwindows: number;
+        numrunningcontrollers: number;
+        numeventlisteners: number;
+        listenerqueuedevents: number;
+        numwindowqueues: number;
+        windowqueuedevents: number;
+        restore?: SessionRestoreStatus;
+    };
+
+    // wshrpc.SessionRestoreStatus
+    type SessionRestoreStatus = {
+        running: boolean;
+        total: number;
+        restored: number;
+        failed: number;
+        deferred: number;
+        startts: number;
+        donets?: number;
+    };
+
     // webcmd.SetBlockTermSizeWSCommand
     type SetBlockTermSizeWSCommand = {
         wscommand: "setblocktermsize";
@@ -602,11 +1522,16 @@ declare global {
         "app:*"?: boolean;
         "app:globalhotkey"?: string;
         "app:dismissarchitecturewarning"?: boolean;
+        "app:blocktrashretentionhours"?: number;
+        "app:metricshistorylen"?: number;
         "ai:*"?: boolean;
         "ai:preset"?: string;
+        "ai:provider"?: string;
         "ai:apitype"?: string;
         "ai:baseurl"?: string;
         "ai:apitoken"?: string;
+        "ai:apikeyref"?: string;
+        "ai:apikeybaseurls"?: string[];
         "ai:name"?: string;
         "ai:model"?: string;
         "ai:orgid"?: string;
@@ -634,6 +1559,9 @@ declare global {
         "web:openlinksinternally"?: boolean;
         "web:defaulturl"?: string;
         "web:defaultsearch"?: string;
+        "web:allowfileurls"?: boolean;
+        "web:alloweddomains"?: string[];
+        "web:blockeddomains"?: string[];
         "blockheader:*"?: boolean;
         "blockheader:showblockids"?: boolean;
         "autoupdate:*"?: boolean;
@@ -644,6 +1572,7 @@ declare global {
         "markdown:fontsize"?: number;
         "markdown:fixedfontsize"?: number;
         "preview:showhiddenfiles"?: boolean;
+        "preview:pollintervalms"?: number;
         "tab:preset"?: string;
         "widget:*"?: boolean;
         "widget:showhelp"?: boolean;
@@ -671,6 +1600,19 @@ declare global {
         "conn:*"?: boolean;
         "conn:askbeforewshinstall"?: boolean;
         "conn:wshenabled"?: boolean;
+        "conn:autoreconnect"?: boolean;
+        "history:*"?: boolean;
+        "history:disabled"?: boolean;
+        "history:maxrows"?: number;
+        "history:ignorepatterns"?: string[];
+    };
+
+    // wshrpc.SnapshotImportRtnData
+    type SnapshotImportRtnData = {
+        workspaceids: string[];
+        numtabs: number;
+        numblocks: number;
+        windowid?: string;
     };
 
     // waveobj.StickerClickOptsType
@@ -704,8 +1646,31 @@ declare global {
     // waveobj.Tab
     type Tab = WaveObj & {
         name: string;
+        groupname?: string;
         layoutstate: string;
         blockids: string[];
+        pinned?: boolean;
+        createdts?: number;
+    };
+
+    // wshrpc.TabListEntry
+    type TabListEntry = {
+        tabid: string;
+        windowid: string;
+        workspaceid: string;
+        name: string;
+        pinned?: boolean;
+        groupname?: string;
+        active?: boolean;
+        numblocks: number;
+        cwd?: string;
+        createdts?: number;
+    };
+
+    // wshrpc.TabTitleData
+    type TabTitleData = {
+        tabid: string;
+        title: string;
     };
 
     // waveobj.TermSize
@@ -742,6 +1707,17 @@ declare global {
         cursor: string;
     };
 
+    // wshrpc.ThumbnailRtnData
+    type ThumbnailRtnData = {
+        mimetype: string;
+        width: number;
+        height: number;
+        orientation?: number;
+        data64?: string;
+        thumbwidth?: number;
+        thumbheight?: number;
+    };
+
     // wshrpc.TimeSeriesData
     type TimeSeriesData = {
         ts: number;
@@ -979,6 +1955,8 @@ declare global {
     type WSEventType = {
         eventtype: string;
         oref?: string;
+        scopes?: string[];
+        seq?: number;
         data: any;
     };
 
@@ -1001,11 +1979,23 @@ declare global {
         fullconfig: FullConfigType;
     };
 
+    // wshrpc.WaveAIConversationMessage
+    type WaveAIConversationMessage = {
+        seq?: number;
+        role: string;
+        content: string;
+        ts?: number;
+        prompttokens?: number;
+        completiontokens?: number;
+    };
+
     // wshrpc.WaveAIOptsType
     type WaveAIOptsType = {
         model: string;
+        provider?: string;
         apitype?: string;
         apitoken: string;
+        apikeyref?: string;
         orgid?: string;
         apiversion?: string;
         baseurl?: string;
@@ -1108,6 +2098,7 @@ declare global {
         title?: string;
         body?: string;
         silent?: boolean;
+        blockid?: string;
     };
 
     // waveobj.WaveObj
@@ -1153,6 +2144,7 @@ declare global {
         pos: Point;
         winsize: WinSize;
         lastfocusts: number;
+        createdts?: number;
     };
 
     // service.WebCallType
@@ -1163,6 +2155,13 @@ declare global {
         args: any[];
     };
 
+    // wshrpc.WebNavBlockedData
+    type WebNavBlockedData = {
+        blockid: string;
+        url: string;
+        reason: string;
+    };
+
     // service.WebReturnType
     type WebReturnType = {
         success?: boolean;
@@ -1194,6 +2193,31 @@ declare global {
         height: number;
     };
 
+    // waveobj.WindowGeometry
+    type WindowGeometry = {
+        x?: number;
+        y?: number;
+        width?: number;
+        height?: number;
+    };
+
+    // clientservice.WindowInfoType
+    type WindowInfoType = {
+        window: WaveWindow;
+        activetabid?: string;
+    };
+
+    // wshrpc.WindowListEntry
+    type WindowListEntry = {
+        windowid: string;
+        workspaceid: string;
+        workspacename?: string;
+        activetabid?: string;
+        numtabs: number;
+        lastfocusts?: number;
+        createdts?: number;
+    };
+
     // waveobj.Workspace
     type Workspace = WaveObj & {
         name?: string;
@@ -2,6 +2,8 @@
 // SPDX-License-Identifier: Apache-2.0
 
 import { App } from "@/app/app";
+import { registerBlockNotificationHandler } from "@/app/notification/blocknotification";
+import { registerPreviewFileCreatedHandler } from "@/app/view/preview/preview";
 import {
     globalRefocus,
     registerControlShiftStateUpdateHandler,
@@ -160,6 +162,8 @@ async function initWave(initOpts: WaveInitOpts) {
 
     // Init WPS event handlers
     const globalWS = initWshrpc(initOpts.tabId);
+    registerBlockNotificationHandler();
+    registerPreviewFileCreatedHandler();
     (window as any).globalWS = globalWS;
     (window as any).TabRpcClient = TabRpcClient;
     await loadConnStatus();
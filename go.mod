@@ -27,6 +27,7 @@ require (
 	github.com/wavetermdev/htmltoken v0.2.0
 	golang.org/x/crypto v0.32.0
 	golang.org/x/mod v0.22.0
+	golang.org/x/net v0.33.0
 	golang.org/x/sys v0.29.0
 	golang.org/x/term v0.28.0
 	google.golang.org/api v0.214.0
@@ -64,7 +65,6 @@ require (
 	go.opentelemetry.io/otel/metric v1.29.0 // indirect
 	go.opentelemetry.io/otel/trace v1.29.0 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
-	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/oauth2 v0.24.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -47,9 +48,11 @@ const (
 )
 
 const (
-	Status_Running = "running"
-	Status_Done    = "done"
-	Status_Init    = "init"
+	Status_Running  = "running"
+	Status_Done     = "done" // the shell exited (see the exit code)
+	Status_Init     = "init"
+	Status_Starting = "starting"
+	Status_Failed   = "failed" // the shell kept exiting right after being restarted (see RestartController)
 )
 
 const (
@@ -58,9 +61,15 @@ const (
 )
 
 const DefaultTimeout = 2 * time.Second
+const StartControllerTimeout = 5 * time.Second
+const SurvivingProcsCheckDelay = 500 * time.Millisecond
+
+var ErrControllerNotRunning = errors.New("controller not running")
 
 var globalLock = &sync.Mutex{}
 var blockControllerMap = make(map[string]*BlockController)
+var numRunningControllers atomic.Int32 // updated by UpdateControllerAndSendUpdate (see NumRunningControllers)
+var bgWorkWg = &sync.WaitGroup{}       // controller goroutines that use the stores after the shell is done (tests wait for them)
 
 type BlockInputUnion struct {
 	InputData []byte            `json:"inputdata,omitempty"`
@@ -80,7 +89,14 @@ type BlockController struct {
 	ShellProcStatus   string
 	ShellProcExitCode int
 	RunLock           *atomic.Bool
+	RestartLock       *atomic.Bool
 	StatusVersion     int
+	StartTs           int64 // when the shell process started (unix ms)
+	DoneTs            int64 // when the shell process exited (unix ms)
+	CrashCount        int   // restarts in a row after the shell exited right after starting
+	stopRequested     bool  // wave is stopping the shell (so the exit hooks don't run)
+	countedRunning    bool  // counted in numRunningControllers
+	startErr          error // why the last start failed (see waitForControllerStart)
 }
 
 type BlockControllerRuntimeStatus struct {
@@ -89,6 +105,7 @@ type BlockControllerRuntimeStatus struct {
 	ShellProcStatus   string `json:"shellprocstatus,omitempty"`
 	ShellProcConnName string `json:"shellprocconnname,omitempty"`
 	ShellProcExitCode int    `json:"shellprocexitcode"`
+	CrashCount        int    `json:"crashcount,omitempty"`
 }
 
 func (bc *BlockController) WithLock(f func()) {
@@ -108,6 +125,7 @@ func (bc *BlockController) GetRuntimeStatus() *BlockControllerRuntimeStatus {
 			rtn.ShellProcConnName = bc.ShellProc.ConnName
 		}
 		rtn.ShellProcExitCode = bc.ShellProcExitCode
+		rtn.CrashCount = bc.CrashCount
 	})
 	return &rtn
 }
@@ -124,9 +142,26 @@ type RunShellOpts struct {
 
 func (bc *BlockController) UpdateControllerAndSendUpdate(updateFn func() bool) {
 	var sendUpdate bool
+	var runningChanged, running bool
+	var shellType string
 	bc.WithLock(func() {
 		sendUpdate = updateFn()
+		if running = bc.ShellProcStatus == Status_Running; running != bc.countedRunning {
+			bc.countedRunning = running
+			runningChanged = true
+			if running && bc.ShellProc != nil {
+				shellType = bc.ShellProc.ShellType
+			}
+			if running {
+				numRunningControllers.Add(1)
+			} else {
+				numRunningControllers.Add(-1)
+			}
+		}
 	})
+	if runningChanged {
+		queueRunningMetaUpdate(bc.BlockId, running, shellType)
+	}
 	if sendUpdate {
 		rtStatus := bc.GetRuntimeStatus()
 		log.Printf("sending blockcontroller update %#v\n", rtStatus)
@@ -242,7 +277,15 @@ func createCmdStrAndOpts(blockId string, blockMeta waveobj.MetaMapType) (string,
 		}
 	}
 
-	// get the "env" file
+	err := addBlockEnv(blockId, blockMeta, cmdOpts.Env)
+	if err != nil {
+		return "", nil, err
+	}
+	return cmdStr, &cmdOpts, nil
+}
+
+// adds the block's "env" file and cmd:env meta (which takes precedence) to env
+func addBlockEnv(blockId string, blockMeta waveobj.MetaMapType, env map[string]string) error {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancelFn()
 	_, envFileData, err := filestore.WFS.ReadFile(ctx, blockId, "env")
@@ -250,12 +293,12 @@ func createCmdStrAndOpts(blockId string, blockMeta waveobj.MetaMapType) (string,
 		err = nil
 	}
 	if err != nil {
-		return "", nil, fmt.Errorf("error reading command env file: %w", err)
+		return fmt.Errorf("error reading command env file: %w", err)
 	}
 	if len(envFileData) > 0 {
 		envMap := envutil.EnvToMap(string(envFileData))
 		for k, v := range envMap {
-			cmdOpts.Env[k] = v
+			env[k] = v
 		}
 	}
 	cmdEnv := blockMeta.GetMap(waveobj.MetaKey_CmdEnv)
@@ -264,13 +307,13 @@ func createCmdStrAndOpts(blockId string, blockMeta waveobj.MetaMapType) (string,
 			continue
 		}
 		if _, ok := v.(string); ok {
-			cmdOpts.Env[k] = v.(string)
+			env[k] = v.(string)
 		}
 		if _, ok := v.(float64); ok {
-			cmdOpts.Env[k] = fmt.Sprintf("%v", v)
+			env[k] = fmt.Sprintf("%v", v)
 		}
 	}
-	return cmdStr, &cmdOpts, nil
+	return nil
 }
 
 func (bc *BlockController) DoRunShellCommand(rc *RunShellOpts, blockMeta waveobj.MetaMapType) error {
@@ -285,7 +328,8 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 	// create a circular blockfile for the output
 	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancelFn()
-	fsErr := filestore.WFS.MakeFile(ctx, bc.BlockId, BlockFile_Term, nil, filestore.FileOptsType{MaxSize: DefaultTermMaxFileSize, Circular: true})
+	termFileSize := getTermFileMaxSize(GetScrollbackLimits(ctx, blockMeta))
+	fsErr := filestore.WFS.MakeFile(ctx, bc.BlockId, BlockFile_Term, nil, filestore.FileOptsType{MaxSize: termFileSize, Circular: true})
 	if fsErr != nil && fsErr != fs.ErrExist {
 		return nil, fmt.Errorf("error creating blockfile: %w", fsErr)
 	}
@@ -307,13 +351,19 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 		cmdOpts.Interactive = true
 		cmdOpts.Login = true
 		cmdOpts.Cwd = blockMeta.GetString(waveobj.MetaKey_CmdCwd, "")
-		if cmdOpts.Cwd != "" {
+		// remote cwds are resolved by the remote shell (~ is the remote home directory)
+		if cmdOpts.Cwd != "" && remoteName == "" {
 			cwdPath, err := wavebase.ExpandHomeDir(cmdOpts.Cwd)
 			if err != nil {
 				return nil, err
 			}
 			cmdOpts.Cwd = cwdPath
 		}
+		// the environment inherited from `wsh term --here` (or set with cmd:env)
+		err = addBlockEnv(bc.BlockId, blockMeta, cmdOpts.Env)
+		if err != nil {
+			return nil, err
+		}
 	} else if bc.ControllerType == BlockController_Cmd {
 		var cmdOptsPtr *shellexec.CommandOptsType
 		cmdStr, cmdOptsPtr, err = createCmdStrAndOpts(bc.BlockId, blockMeta)
@@ -399,6 +449,9 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 		if settings.TermLocalShellPath != "" {
 			cmdOpts.ShellPath = settings.TermLocalShellPath
 		}
+		if client, _ := wstore.DBGetSingletonCached[*waveobj.Client](ctx); client != nil && client.Meta.GetString(waveobj.MetaKey_ClientDefaultShell, "") != "" {
+			cmdOpts.ShellPath = client.Meta.GetString(waveobj.MetaKey_ClientDefaultShell, "")
+		}
 		if blockMeta.GetString(waveobj.MetaKey_TermLocalShellPath, "") != "" {
 			cmdOpts.ShellPath = blockMeta.GetString(waveobj.MetaKey_TermLocalShellPath, "")
 		}
@@ -416,6 +469,8 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 	bc.UpdateControllerAndSendUpdate(func() bool {
 		bc.ShellProc = shellProc
 		bc.ShellProcStatus = Status_Running
+		bc.StartTs = time.Now().UnixMilli()
+		bc.stopRequested = false
 		return true
 	})
 	return shellProc, nil
@@ -423,21 +478,41 @@ func (bc *BlockController) setupAndStartShellProcess(rc *RunShellOpts, blockMeta
 
 func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellProc, rc *RunShellOpts, blockMeta waveobj.MetaMapType) error {
 	shellInputCh := make(chan *BlockInputUnion, 32)
-	bc.ShellInputCh = shellInputCh
+	var tabId string
+	bc.WithLock(func() {
+		bc.ShellInputCh = shellInputCh
+		tabId = bc.TabId
+	})
 
 	// make esc sequence wshclient wshProxy
 	// we don't need to authenticate this wshProxy since it is coming direct
 	wshProxy := wshutil.MakeRpcProxy()
-	wshProxy.SetRpcContext(&wshrpc.RpcContext{TabId: bc.TabId, BlockId: bc.BlockId})
+	wshProxy.SetRpcContext(&wshrpc.RpcContext{TabId: tabId, BlockId: bc.BlockId})
 	wshutil.DefaultRouter.RegisterRoute(wshutil.MakeControllerRouteId(bc.BlockId), wshProxy, true)
 	ptyBuffer := wshutil.MakePtyBuffer(wshutil.WaveOSCPrefix, shellProc.Cmd, wshProxy.FromRemoteCh)
+	cwdTracker := makeCwdTracker(bc.BlockId)
+	cmdTracker := makeCmdTracker(bc.BlockId, blockMeta.GetString(waveobj.MetaKey_Connection, ""))
+	linkWatcher := makeBlockLinkWatcher(bc.BlockId)
+	scrollbackLimiter := makeScrollbackLimiter(bc.BlockId)
+	if shellPid := shellProc.LocalPid(); shellPid > 0 {
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("blockcontroller:shellproc-cwd-poll", recover())
+			}()
+			cwdTracker.pollProcessCwd(shellProc, shellPid)
+		}()
+	}
+	bgWorkWg.Add(2) // the pty-read and wait loops
 	go func() {
 		// handles regular output from the pty (goes to the blockfile and xterm)
+		defer bgWorkWg.Done()
 		defer func() {
 			panichandler.PanicHandler("blockcontroller:shellproc-pty-read-loop", recover())
 		}()
 		defer func() {
 			log.Printf("[shellproc] pty-read loop done\n")
+			linkWatcher.close()
+			cmdTracker.close()
 			shellProc.Close()
 			bc.WithLock(func() {
 				// so no other events are sent
@@ -452,12 +527,18 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 			close(shellInputCh) // don't use bc.ShellInputCh (it's nil)
 		}()
 		buf := make([]byte, 4096)
+		var oscScanner osc7Scanner
 		for {
 			nr, err := ptyBuffer.Read(buf)
 			if nr > 0 {
+				cwdTracker.handlePtyOutput(&oscScanner, buf[:nr])
+				cmdTracker.handlePtyOutput(buf[:nr])
+				linkWatcher.handlePtyOutput(buf[:nr])
 				err := HandleAppendBlockFile(bc.BlockId, BlockFile_Term, buf[:nr])
 				if err != nil {
 					log.Printf("error appending to blockfile: %v\n", err)
+				} else {
+					scrollbackLimiter.handleAppend(nr)
 				}
 			}
 			if err == io.EOF {
@@ -498,6 +579,7 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 		}
 	}()
 	go func() {
+		defer bgWorkWg.Done()
 		defer func() {
 			panichandler.PanicHandler("blockcontroller:shellproc-wait-loop", recover())
 		}()
@@ -510,18 +592,53 @@ func (bc *BlockController) manageRunningShellProcess(shellProc *shellexec.ShellP
 					bc.ShellProcStatus = Status_Done
 				}
 				bc.ShellProcExitCode = exitCode
+				bc.DoneTs = time.Now().UnixMilli()
 				return true
 			})
 			log.Printf("[shellproc] shell process wait loop done\n")
 		}()
 		waitErr := shellProc.Cmd.Wait()
 		exitCode = shellProc.Cmd.ExitCode()
+		var startTs int64
+		var stopRequested bool
+		var tabId string
+		bc.WithLock(func() {
+			startTs = bc.StartTs
+			stopRequested = bc.stopRequested
+			tabId = bc.TabId
+		})
 		shellProc.SetWaitErrorAndSignalDone(waitErr)
-		go checkCloseOnExit(bc.BlockId, exitCode)
+		bc.sendControllerExitEvent(exitCode)
+		bgWorkWg.Add(1)
+		go func() {
+			defer bgWorkWg.Done()
+			defer func() {
+				panichandler.PanicHandler("blockcontroller:shellproc-exit", recover())
+			}()
+			if !stopRequested {
+				// before checkCloseOnExit (which can delete the block)
+				startExitHooks(tabId, bc.BlockId, exitCode, time.Since(time.UnixMilli(startTs)))
+			}
+			checkCloseOnExit(bc.BlockId, exitCode)
+		}()
 	}()
 	return nil
 }
 
+func (bc *BlockController) sendControllerExitEvent(exitCode int) {
+	wps.Broker.Publish(wps.WaveEvent{
+		Event: wps.Event_ControllerExit,
+		Scopes: []string{
+			waveobj.MakeORef(waveobj.OType_Tab, bc.TabId).String(),
+			waveobj.MakeORef(waveobj.OType_Block, bc.BlockId).String(),
+		},
+		Data: &wps.ControllerExitEventData{
+			BlockId:  bc.BlockId,
+			ExitCode: exitCode,
+		},
+	})
+}
+
 func updateTermSize(shellProc *shellexec.ShellProc, blockId string, termSize waveobj.TermSize) {
 	err := setTermSizeInDB(blockId, termSize)
 	if err != nil {
@@ -552,7 +669,7 @@ func checkCloseOnExit(blockId string, exitCode int) {
 	}
 	time.Sleep(time.Duration(delayMs) * time.Millisecond)
 	rpcClient := wshclient.GetBareRpcClient()
-	err = wshclient.DeleteBlockCommand(rpcClient, wshrpc.CommandDeleteBlockData{BlockId: blockId}, nil)
+	_, err = wshclient.DeleteBlockCommand(rpcClient, wshrpc.CommandDeleteBlockData{BlockId: blockId}, nil)
 	if err != nil {
 		log.Printf("error deleting block data (close on exit): %v\n", err)
 	}
@@ -666,9 +783,27 @@ func (bc *BlockController) run(bdata *waveobj.Block, blockMeta map[string]any, r
 			} else {
 				termSize = getTermSize(bdata)
 			}
+			var prevStatus string
+			bc.UpdateControllerAndSendUpdate(func() bool {
+				prevStatus = bc.ShellProcStatus
+				bc.ShellProcStatus = Status_Starting
+				bc.startErr = nil
+				return true
+			})
 			err := bc.DoRunShellCommand(&RunShellOpts{TermSize: termSize}, bdata.Meta)
 			if err != nil {
 				log.Printf("error running shell: %v\n", err)
+				bc.UpdateControllerAndSendUpdate(func() bool {
+					bc.startErr = err
+					if bc.ShellProcStatus != Status_Starting {
+						return false
+					}
+					bc.ShellProcStatus = prevStatus
+					if prevStatus == Status_Starting {
+						bc.ShellProcStatus = Status_Done
+					}
+					return true
+				})
 			}
 		}()
 	}
@@ -686,6 +821,76 @@ func (bc *BlockController) SendInput(inputUnion *BlockInputUnion) error {
 	return nil
 }
 
+func (bc *BlockController) isRunning() bool {
+	var running bool
+	bc.WithLock(func() {
+		running = bc.ShellProcStatus == Status_Running && bc.ShellInputCh != nil
+	})
+	return running
+}
+
+// returns ErrControllerNotRunning (wrapped) if the block does not have a running shell process
+func getRunningBlockController(blockId string) (*BlockController, error) {
+	bc := GetBlockController(blockId)
+	if bc == nil || !bc.isRunning() {
+		return nil, fmt.Errorf("block %s: %w", blockId, ErrControllerNotRunning)
+	}
+	return bc, nil
+}
+
+// starts the block's controller (if it isn't already running) and waits for its shell process to start
+func StartControllerAndWait(ctx context.Context, blockId string) error {
+	if _, err := getRunningBlockController(blockId); err == nil {
+		return nil
+	}
+	tabId, err := wstore.DBFindTabForBlockId(ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error finding tab for block: %w", err)
+	}
+	// force so the controller starts even if the block isn't set to run on start (or has already exited)
+	err = ResyncController(ctx, tabId, blockId, nil, true)
+	if err != nil {
+		return err
+	}
+	waitCtx, cancelFn := context.WithTimeout(ctx, StartControllerTimeout)
+	defer cancelFn()
+	for {
+		if _, err := getRunningBlockController(blockId); err == nil {
+			return nil
+		}
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("block %s: timeout waiting for controller to start", blockId)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// writes data to the pty of the block's shell process (as if it were typed)
+func SendTermInput(blockId string, data []byte) error {
+	bc, err := getRunningBlockController(blockId)
+	if err != nil {
+		return err
+	}
+	return bc.SendInput(&BlockInputUnion{InputData: data})
+}
+
+func ResizeTerm(blockId string, termSize waveobj.TermSize) error {
+	if termSize.Rows <= 0 || termSize.Cols <= 0 {
+		return fmt.Errorf("invalid term size %dx%d", termSize.Cols, termSize.Rows)
+	}
+	bc, err := getRunningBlockController(blockId)
+	if err != nil {
+		return err
+	}
+	return bc.SendInput(&BlockInputUnion{TermSize: &termSize})
+}
+
+// clears the block's scrollback (the terminal view is cleared by the truncate event)
+func ClearTerm(blockId string) error {
+	return HandleTruncateBlockFile(blockId)
+}
+
 func CheckConnStatus(blockId string) error {
 	bdata, err := wstore.DBMustGet[*waveobj.Block](context.Background(), blockId)
 	if err != nil {
@@ -716,16 +921,22 @@ func CheckConnStatus(blockId string) error {
 	return nil
 }
 
+// the lock is not held while the shell is stopped (the wait loop takes it before it signals DoneCh)
 func (bc *BlockController) StopShellProc(shouldWait bool) {
-	bc.Lock.Lock()
-	defer bc.Lock.Unlock()
-	if bc.ShellProc == nil || bc.ShellProcStatus == Status_Done || bc.ShellProcStatus == Status_Init {
+	var shellProc *shellexec.ShellProc
+	bc.WithLock(func() {
+		if bc.ShellProc == nil || bc.ShellProcStatus == Status_Done || bc.ShellProcStatus == Status_Init {
+			return
+		}
+		bc.stopRequested = true
+		shellProc = bc.ShellProc
+	})
+	if shellProc == nil {
 		return
 	}
-	bc.ShellProc.Close()
+	shellProc.Close()
 	if shouldWait {
-		doneCh := bc.ShellProc.DoneCh
-		<-doneCh
+		<-shellProc.DoneCh
 	}
 }
 
@@ -751,6 +962,7 @@ func getOrCreateBlockController(tabId string, blockId string, controllerName str
 			BlockId:         blockId,
 			ShellProcStatus: Status_Init,
 			RunLock:         &atomic.Bool{},
+			RestartLock:     &atomic.Bool{},
 		}
 		blockControllerMap[blockId] = bc
 		createdController = true
@@ -797,6 +1009,11 @@ func ResyncController(ctx context.Context, tabId string, blockId string, rtOpts
 			time.Sleep(100 * time.Millisecond) // TODO see if we can remove this (the "process finished with exit code" message comes out after we start reconnecting otherwise)
 		}
 	}
+	// a restored block on a connection connects the first time it is used (see RestoreSession)
+	if connName != "" && takeDeferredRestore(blockId) {
+		go restoreConnBlock(tabId, blockId, connName, rtOpts, force)
+		return nil
+	}
 	// now if there is a conn, ensure it is connected
 	if connName != "" {
 		err = CheckConnStatus(blockId)
@@ -846,17 +1063,72 @@ func StopBlockControllerAndSetStatus(blockId string, newStatus string) {
 	if bc == nil {
 		return
 	}
-	if bc.getShellProc() != nil {
-		bc.ShellProc.Close()
-		<-bc.ShellProc.DoneCh
+	if shellProc := bc.getShellProc(); shellProc != nil {
+		shellPid := shellProc.LocalPid()
+		bc.WithLock(func() {
+			bc.stopRequested = true
+		})
+		shellProc.Close()
+		<-shellProc.DoneCh
 		bc.UpdateControllerAndSendUpdate(func() bool {
 			bc.ShellProcStatus = newStatus
 			return true
 		})
+		if shellPid > 0 {
+			go logSurvivingProcs(blockId, shellPid)
+		}
 	}
 
 }
 
+// stops the block's controller and kills the processes its shell started (see shellexec.KillProcessTree).
+// the processes get grace to exit before they are force killed.  returns the number of processes that exited.
+func StopBlockControllerAndKillProcs(blockId string, grace time.Duration) int {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return 0
+	}
+	shellProc := bc.getShellProc()
+	if shellProc == nil {
+		return 0
+	}
+	var numKilled int
+	if shellPid := shellProc.LocalPid(); shellPid > 0 {
+		numKilled = shellexec.KillProcessTree(shellPid, grace)
+		log.Printf("block %s: killed %d process(es) started by the shell\n", blockId, numKilled)
+	}
+	StopBlockController(blockId)
+	return numKilled
+}
+
+// processes that ignore the hangup from the closed pty keep running after the shell exits
+func logSurvivingProcs(blockId string, shellPid int) {
+	defer func() {
+		panichandler.PanicHandler("blockcontroller:logSurvivingProcs", recover())
+	}()
+	time.Sleep(SurvivingProcsCheckDelay)
+	pids := shellexec.ListProcessTree(shellPid)
+	if len(pids) > 0 {
+		log.Printf("warning: block %s: processes started by the shell are still running after it exited: %v\n", blockId, pids)
+	}
+}
+
+// updates the tab of a running controller (after its block was moved to another tab).
+// the controller (and its shell process) keeps running.
+func SetBlockControllerTabId(blockId string, tabId string) {
+	bc := GetBlockController(blockId)
+	if bc == nil {
+		return
+	}
+	bc.UpdateControllerAndSendUpdate(func() bool {
+		if bc.TabId == tabId {
+			return false
+		}
+		bc.TabId = tabId
+		return true
+	})
+}
+
 func StopBlockController(blockId string) {
 	StopBlockControllerAndSetStatus(blockId, Status_Done)
 }
@@ -872,6 +1144,7 @@ func getControllerList() []*BlockController {
 }
 
 func StopAllBlockControllers() {
+	controllersStopping.Store(true)
 	clist := getControllerList()
 	for _, bc := range clist {
 		if bc.ShellProcStatus == Status_Running {
@@ -880,6 +1153,11 @@ func StopAllBlockControllers() {
 	}
 }
 
+// the number of controllers with a running shell (doesn't lock the controllers)
+func NumRunningControllers() int {
+	return int(numRunningControllers.Load())
+}
+
 func GetBlockController(blockId string) *BlockController {
 	globalLock.Lock()
 	defer globalLock.Unlock()
@@ -0,0 +1,286 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/shellexec"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const testShellPath = "/bin/sh"
+
+func initTestStores(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires a posix shell")
+	}
+	if _, err := os.Stat(testShellPath); err != nil {
+		t.Skipf("test requires %s", testShellPath)
+	}
+	wavebase.DataHome_VarCache = t.TempDir()
+	wavebase.ConfigHome_VarCache = t.TempDir()
+	err := os.MkdirAll(wavebase.GetWaveDataDir()+"/"+wavebase.WaveDBDir, 0700)
+	if err != nil {
+		t.Fatalf("error creating db dir: %v", err)
+	}
+	err = filestore.InitFilestore()
+	if err != nil {
+		t.Fatalf("error initializing filestore: %v", err)
+	}
+	err = wstore.InitWStore()
+	if err != nil {
+		t.Fatalf("error initializing wstore: %v", err)
+	}
+	t.Cleanup(func() {
+		// runs after the blocks' controllers are stopped, the next test replaces the stores
+		waitDone := make(chan struct{})
+		go func() {
+			bgWorkWg.Wait()
+			close(waitDone)
+		}()
+		select {
+		case <-waitDone:
+		case <-time.After(10 * time.Second):
+			t.Errorf("timeout waiting for the controllers' background work")
+		}
+	})
+}
+
+func makeTestShellBlock(t *testing.T, ctx context.Context) string {
+	block := &waveobj.Block{
+		OID:        uuid.NewString(),
+		ParentORef: waveobj.MakeORef(waveobj.OType_Tab, uuid.NewString()).String(),
+		Meta: waveobj.MetaMapType{
+			waveobj.MetaKey_View:               "term",
+			waveobj.MetaKey_Controller:         BlockController_Cmd,
+			waveobj.MetaKey_Cmd:                testShellPath,
+			waveobj.MetaKey_CmdCwd:             t.TempDir(),
+			waveobj.MetaKey_CmdNoWsh:           true,
+			waveobj.MetaKey_CmdRunOnStart:      false,
+			waveobj.MetaKey_TermLocalShellPath: testShellPath,
+		},
+	}
+	err := wstore.DBInsert(ctx, block)
+	if err != nil {
+		t.Fatalf("error inserting block: %v", err)
+	}
+	t.Cleanup(func() {
+		StopBlockController(block.OID)
+	})
+	return block.OID
+}
+
+func TestSendTermInput(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	blockId := makeTestShellBlock(t, ctx)
+
+	err := SendTermInput(blockId, []byte("echo hi\r"))
+	if !errors.Is(err, ErrControllerNotRunning) {
+		t.Fatalf("expected controller not running error, got %v", err)
+	}
+	err = StartControllerAndWait(ctx, blockId)
+	if err != nil {
+		t.Fatalf("error starting controller: %v", err)
+	}
+	err = SendTermInput(blockId, []byte("echo hi\r"))
+	if err != nil {
+		t.Fatalf("error sending input: %v", err)
+	}
+	var scrollback string
+	for ctx.Err() == nil {
+		_, data, err := filestore.WFS.ReadFile(ctx, blockId, BlockFile_Term)
+		if err != nil {
+			t.Fatalf("error reading scrollback: %v", err)
+		}
+		scrollback = string(data)
+		// the pty echoes "echo hi", the output is "hi" on its own line
+		if strings.Contains(scrollback, "\nhi\r\n") {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("output not found in scrollback: %q", scrollback)
+}
+
+func TestStopBlockControllerAndKillProcs(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	blockId := makeTestShellBlock(t, ctx)
+	// the script's sleep is a grandchild of the shell that ignores the hangup (so it survives a normal stop)
+	scriptDir := t.TempDir()
+	pidFile := filepath.Join(scriptDir, "sleep.pid")
+	scriptFile := filepath.Join(scriptDir, "spawn.sh")
+	script := fmt.Sprintf("trap '' HUP\nsleep 300 &\necho $! > %s\nwait\n", pidFile)
+	err := os.WriteFile(scriptFile, []byte(script), 0700)
+	if err != nil {
+		t.Fatalf("error writing script: %v", err)
+	}
+	err = wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Block, blockId), waveobj.MetaMapType{
+		waveobj.MetaKey_Cmd: testShellPath + " " + scriptFile,
+	}, false)
+	if err != nil {
+		t.Fatalf("error updating block: %v", err)
+	}
+	err = StartControllerAndWait(ctx, blockId)
+	if err != nil {
+		t.Fatalf("error starting controller: %v", err)
+	}
+	var sleepPid int
+	for ctx.Err() == nil {
+		data, err := os.ReadFile(pidFile)
+		if err == nil && len(strings.TrimSpace(string(data))) > 0 {
+			sleepPid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatalf("bad pid file: %q", data)
+			}
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	shellPid := GetBlockController(blockId).getShellProc().LocalPid()
+	if !slices.Contains(shellexec.ListProcessTree(shellPid), sleepPid) {
+		t.Fatalf("sleep (pid %d) not found in the process tree of the shell (pid %d)", sleepPid, shellPid)
+	}
+	numKilled := StopBlockControllerAndKillProcs(blockId, 2*time.Second)
+	if numKilled < 2 {
+		t.Errorf("expected the script and sleep to be killed, got %d", numKilled)
+	}
+	if survivors := shellexec.ListProcessTree(shellPid); len(survivors) > 0 {
+		t.Fatalf("processes still running after kill: %v (sleep pid %d)", survivors, sleepPid)
+	}
+}
+
+func TestStopShellProcWait(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	blockId := makeTestShellBlock(t, ctx)
+	err := StartControllerAndWait(ctx, blockId)
+	if err != nil {
+		t.Fatalf("error starting controller: %v", err)
+	}
+	bc := GetBlockController(blockId)
+	stopped := make(chan struct{})
+	go func() {
+		bc.StopShellProc(true)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		t.Fatalf("timeout stopping the shell")
+	}
+	waitForControllerStatus(t, ctx, blockId, Status_Done)
+}
+
+func TestRestartBackoff(t *testing.T) {
+	expected := []time.Duration{0, 500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second}
+	for numCrashes, delay := range expected {
+		if got := restartBackoff(numCrashes); got != delay {
+			t.Errorf("restartBackoff(%d): expected %v, got %v", numCrashes, delay, got)
+		}
+	}
+	if got := restartBackoff(100); got != RestartBackoffMax {
+		t.Errorf("restartBackoff(100): expected %v, got %v", RestartBackoffMax, got)
+	}
+}
+
+func waitForControllerStatus(t *testing.T, ctx context.Context, blockId string, status string) *BlockControllerRuntimeStatus {
+	for ctx.Err() == nil {
+		rtStatus := GetBlockController(blockId).GetRuntimeStatus()
+		if rtStatus.ShellProcStatus == status {
+			return rtStatus
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for controller status %q", status)
+	return nil
+}
+
+func TestRestartController(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancelFn()
+	blockId := makeTestShellBlock(t, ctx)
+	tabId := uuid.NewString()
+	tab := &waveobj.Tab{OID: tabId, BlockIds: []string{blockId}}
+	err := wstore.DBInsert(ctx, tab)
+	if err != nil {
+		t.Fatalf("error inserting tab: %v", err)
+	}
+	scriptFile := filepath.Join(t.TempDir(), "crash.sh")
+	err = os.WriteFile(scriptFile, []byte("echo started\nexit 3\n"), 0700)
+	if err != nil {
+		t.Fatalf("error writing script: %v", err)
+	}
+	err = wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Block, blockId), waveobj.MetaMapType{
+		waveobj.MetaKey_Cmd: testShellPath + " " + scriptFile,
+	}, false)
+	if err != nil {
+		t.Fatalf("error updating block: %v", err)
+	}
+
+	err = RestartController(ctx, blockId, false)
+	if err != nil {
+		t.Fatalf("error starting controller: %v", err)
+	}
+	waitForControllerStatus(t, ctx, blockId, Status_Done)
+	// the shell crashed, so the restart is delayed (and counted)
+	startTime := time.Now()
+	err = RestartController(ctx, blockId, false)
+	if err != nil {
+		t.Fatalf("error restarting controller: %v", err)
+	}
+	if elapsed := time.Since(startTime); elapsed < RestartBackoffBase {
+		t.Errorf("expected the restart to wait for the backoff (%v), took %v", RestartBackoffBase, elapsed)
+	}
+	rtStatus := waitForControllerStatus(t, ctx, blockId, Status_Done)
+	if rtStatus.CrashCount != 1 || rtStatus.ShellProcExitCode != 3 {
+		t.Errorf("expected crash count 1 and exit code 3, got %d and %d", rtStatus.CrashCount, rtStatus.ShellProcExitCode)
+	}
+	_, data, err := filestore.WFS.ReadFile(ctx, blockId, BlockFile_Term)
+	if err != nil {
+		t.Fatalf("error reading scrollback: %v", err)
+	}
+	if strings.Count(string(data), "started") != 2 {
+		t.Errorf("expected the scrollback of both runs, got %q", data)
+	}
+
+	bc := GetBlockController(blockId)
+	bc.WithLock(func() {
+		bc.CrashCount = RestartMaxAttempts - 1
+	})
+	err = RestartController(ctx, blockId, false)
+	if err == nil {
+		t.Fatalf("expected the restart to fail after %d crashes", RestartMaxAttempts)
+	}
+	if status := bc.GetRuntimeStatus().ShellProcStatus; status != Status_Failed {
+		t.Errorf("expected status %q, got %q", Status_Failed, status)
+	}
+	err = RestartController(ctx, blockId, true)
+	if err != nil {
+		t.Fatalf("error force restarting controller: %v", err)
+	}
+	if crashCount := bc.GetRuntimeStatus().CrashCount; crashCount != 0 {
+		t.Errorf("expected force to reset the crash count, got %d", crashCount)
+	}
+}
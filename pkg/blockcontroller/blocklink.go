@@ -0,0 +1,252 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	blockLinkMaxLineLen    = 4096 // longer lines are truncated before matching
+	blockLinkActionQueueSz = 16   // matches that haven't been applied yet (more are dropped)
+)
+
+type compiledBlockLink struct {
+	link *waveobj.BlockLink
+	re   *regexp.Regexp
+}
+
+// the links by source block id, loaded from the db in the background (see InvalidateBlockLinks).  links to blocks
+// that were deleted can be in the cache for a moment, applyBlockLink checks that the target still exists.
+var blockLinkLock = &sync.Mutex{}
+var blockLinkCache map[string][]compiledBlockLink
+var blockLinkLoadOnce sync.Once
+var blockLinkReloadCh = make(chan struct{}, 1)
+
+// reloads the link cache (call after links are added or removed)
+func InvalidateBlockLinks() {
+	blockLinkLoadOnce.Do(startBlockLinkLoader)
+	select {
+	case blockLinkReloadCh <- struct{}{}:
+	default:
+		// a reload is already pending
+	}
+}
+
+func startBlockLinkLoader() {
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("blockcontroller:blocklink-loader", recover())
+		}()
+		for range blockLinkReloadCh {
+			err := loadBlockLinks()
+			if err != nil {
+				log.Printf("error loading block links: %v\n", err)
+			}
+		}
+	}()
+	blockLinkReloadCh <- struct{}{}
+}
+
+func loadBlockLinks() error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	links, err := wstore.DBGetAllObjsByType[*waveobj.BlockLink](ctx, waveobj.OType_BlockLink)
+	if err != nil {
+		return err
+	}
+	newCache := make(map[string][]compiledBlockLink)
+	for _, link := range links {
+		if link.RuleType != waveobj.BlockLinkRule_Regex {
+			continue
+		}
+		re, err := regexp.Compile(link.Rule)
+		if err != nil {
+			log.Printf("invalid regex for block link %s: %v\n", link.OID, err)
+			continue
+		}
+		newCache[link.SrcBlockId] = append(newCache[link.SrcBlockId], compiledBlockLink{link: link, re: re})
+	}
+	blockLinkLock.Lock()
+	defer blockLinkLock.Unlock()
+	blockLinkCache = newCache
+	return nil
+}
+
+func getBlockLinks(srcBlockId string) []compiledBlockLink {
+	blockLinkLock.Lock()
+	defer blockLinkLock.Unlock()
+	return blockLinkCache[srcBlockId]
+}
+
+// returns the first capture group (or the whole match if the regex has no groups)
+func matchBlockLink(re *regexp.Regexp, line string) (string, bool) {
+	match := re.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+	value := match[0]
+	if len(match) > 1 {
+		value = match[1]
+	}
+	value = strings.TrimSpace(value)
+	return value, value != ""
+}
+
+type blockLinkAction struct {
+	link  *waveobj.BlockLink
+	value string
+}
+
+// matches the lines of a block's output against the block's links.  the matched values are applied in order, in
+// their own goroutine (so the pty read loop never waits for the db).
+type blockLinkWatcher struct {
+	blockId  string
+	stripper utilfn.AnsiStripper
+	lineBuf  []byte
+	actionCh chan blockLinkAction
+}
+
+func makeBlockLinkWatcher(blockId string) *blockLinkWatcher {
+	blockLinkLoadOnce.Do(startBlockLinkLoader)
+	w := &blockLinkWatcher{blockId: blockId, actionCh: make(chan blockLinkAction, blockLinkActionQueueSz)}
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("blockcontroller:blocklink-actions", recover())
+		}()
+		for action := range w.actionCh {
+			err := applyBlockLink(action.link, action.value)
+			if err != nil {
+				log.Printf("error applying block link %s: %v\n", action.link.OID, err)
+			}
+		}
+	}()
+	return w
+}
+
+func (w *blockLinkWatcher) handlePtyOutput(data []byte) {
+	links := getBlockLinks(w.blockId)
+	if len(links) == 0 {
+		w.lineBuf = nil
+		return
+	}
+	for _, ch := range w.stripper.Strip(data) {
+		if ch != '\n' {
+			if len(w.lineBuf) < blockLinkMaxLineLen {
+				w.lineBuf = append(w.lineBuf, ch)
+			}
+			continue
+		}
+		line := string(w.lineBuf)
+		w.lineBuf = w.lineBuf[:0]
+		for _, link := range links {
+			value, ok := matchBlockLink(link.re, line)
+			if !ok {
+				continue
+			}
+			select {
+			case w.actionCh <- blockLinkAction{link: link.link, value: value}:
+			default:
+				log.Printf("block link %s: too many pending matches, dropping %q\n", link.link.OID, value)
+			}
+		}
+	}
+}
+
+func (w *blockLinkWatcher) close() {
+	close(w.actionCh)
+}
+
+// relative paths are relative to the source block's cwd (on the source block's connection)
+func resolveBlockLinkPath(value string, srcBlock *waveobj.Block) string {
+	cwd := srcBlock.Meta.GetString(waveobj.MetaKey_CmdCwd, "")
+	if cwd == "" || strings.HasPrefix(value, "~") || path.IsAbs(value) || filepath.IsAbs(value) {
+		return value
+	}
+	if srcBlock.Meta.GetString(waveobj.MetaKey_Connection, "") == "" {
+		return filepath.Join(cwd, value)
+	}
+	return path.Join(cwd, value)
+}
+
+// sets the target block's file (preview) or url (web) to value.  if it is already set, the target is reloaded
+// instead (the file was rewritten).
+func applyBlockLink(link *waveobj.BlockLink, value string) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	dstBlock, err := wstore.DBGet[*waveobj.Block](ctx, link.DstBlockId)
+	if err != nil {
+		return fmt.Errorf("error getting target block: %w", err)
+	}
+	if dstBlock == nil || dstBlock.DeletedTs != 0 {
+		return nil
+	}
+	srcBlock, err := wstore.DBGet[*waveobj.Block](ctx, link.SrcBlockId)
+	if err != nil || srcBlock == nil {
+		return fmt.Errorf("error getting source block: %v", err)
+	}
+	patch := waveobj.MetaMapType{}
+	switch dstBlock.Meta.GetString(waveobj.MetaKey_View, "") {
+	case "preview":
+		patch[waveobj.MetaKey_File] = resolveBlockLinkPath(value, srcBlock)
+		patch[waveobj.MetaKey_Connection] = srcBlock.Meta.GetString(waveobj.MetaKey_Connection, "")
+	case "web":
+		patch[waveobj.MetaKey_Url] = value
+	default:
+		return fmt.Errorf("target block %s is not a preview or web block", link.DstBlockId)
+	}
+	changes := waveobj.GetMetaChanges(dstBlock.Meta, waveobj.MergeMeta(dstBlock.Meta, patch, false))
+	if len(changes) == 0 {
+		reloadLinkedBlock(dstBlock)
+		return nil
+	}
+	dstBlock.Meta = waveobj.MergeMeta(dstBlock.Meta, patch, false)
+	err = wstore.DBUpdate(ctx, dstBlock)
+	if err != nil {
+		return fmt.Errorf("error updating target block: %w", err)
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	return nil
+}
+
+func reloadLinkedBlock(block *waveobj.Block) {
+	if block.Meta.GetString(waveobj.MetaKey_View, "") == "web" {
+		navData := wshrpc.CommandWebNavigateData{BlockId: block.OID, Action: wshrpc.WebNavigateAction_Reload}
+		err := wshclient.WebNavigateCommand(wshclient.GetBareRpcClient(), navData, &wshrpc.RpcOpts{Route: wshutil.MakeFeBlockRouteId(block.OID), NoResponse: true})
+		if err != nil {
+			log.Printf("error reloading linked block %s: %v\n", block.OID, err)
+		}
+		return
+	}
+	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+	if parentORef == nil || parentORef.OType != waveobj.OType_Tab || !eventbus.IsWindowConnected(parentORef.OID) {
+		return
+	}
+	blockORef := waveobj.MakeORef(waveobj.OType_Block, block.OID)
+	eventbus.SendEventToTab(parentORef.OID, eventbus.WSEventType{
+		EventType: eventbus.WSEvent_PreviewReload,
+		ORef:      blockORef.String(),
+		Data:      wshrpc.PreviewReloadData{BlockId: block.OID},
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestMatchBlockLink(t *testing.T) {
+	tests := []struct {
+		regex    string
+		line     string
+		expected string
+		ok       bool
+	}{
+		{regex: `wrote (\S+)`, line: "wrote /tmp/out.png", expected: "/tmp/out.png", ok: true},
+		{regex: `wrote (\S+)`, line: "[build] wrote out/report.html in 20ms", expected: "out/report.html", ok: true},
+		{regex: `https?://\S+`, line: "listening on http://localhost:3000 ", expected: "http://localhost:3000", ok: true},
+		{regex: `wrote (\S+)`, line: "nothing to write", ok: false},
+		{regex: `wrote(\s*)`, line: "wrote ", ok: false},
+	}
+	for _, tc := range tests {
+		value, ok := matchBlockLink(regexp.MustCompile(tc.regex), tc.line)
+		if ok != tc.ok || value != tc.expected {
+			t.Errorf("%q on %q: expected %q (%v), got %q (%v)", tc.regex, tc.line, tc.expected, tc.ok, value, ok)
+		}
+	}
+}
+
+func TestResolveBlockLinkPath(t *testing.T) {
+	cwd := t.TempDir()
+	localBlock := &waveobj.Block{Meta: waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: cwd}}
+	remoteBlock := &waveobj.Block{Meta: waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: "/home/user", waveobj.MetaKey_Connection: "user@host"}}
+	if rtn := resolveBlockLinkPath("out/a.png", localBlock); rtn != filepath.Join(cwd, "out", "a.png") {
+		t.Errorf("expected the path to be relative to the cwd, got %q", rtn)
+	}
+	if rtn := resolveBlockLinkPath("out/a.png", remoteBlock); rtn != "/home/user/out/a.png" {
+		t.Errorf("expected a remote path, got %q", rtn)
+	}
+	if rtn := resolveBlockLinkPath("~/a.png", remoteBlock); rtn != "~/a.png" {
+		t.Errorf("expected ~ paths to be kept, got %q", rtn)
+	}
+	if rtn := resolveBlockLinkPath("a.png", &waveobj.Block{Meta: waveobj.MetaMapType{}}); rtn != "a.png" {
+		t.Errorf("expected the path to be kept without a cwd, got %q", rtn)
+	}
+}
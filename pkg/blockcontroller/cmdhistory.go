@@ -0,0 +1,199 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/cmdhistory"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the shell integration from "wsh shell-init" sends OSC 16162 sequences from its preexec and precmd hooks:
+// "C;<command line>" when a command starts and "D;<exit status>" when it is done (and OSC 7 with the cwd).  they
+// are read from the pty output here, so a prompt costs a printf (no wsh process or rpc).  the commands are timed
+// when the sequences are read, and the last CmdHistoryMaxRecords per block are kept in db_cmdhistory (written in
+// batches, at most one write per CmdHistoryWriteInterval).
+const (
+	CmdHistoryMaxRecords    = 200
+	CmdHistoryWriteInterval = time.Second
+	CmdHistoryMaxCmdLen     = cmdhistory.MaxCmdLen // longer command lines are truncated
+	maxOscLen               = 16 * 1024
+)
+
+// all OSC sequences (the OSC 7 cwd comes before the command it applies to)
+var oscPrefix = []byte("\x1b]")
+
+type cmdRecordRow struct {
+	BlockId  string `db:"blockid"`
+	Seq      int    `db:"seq"`
+	Cmd      string `db:"cmd"`
+	Cwd      string `db:"cwd"`
+	StartTs  int64  `db:"startts"`
+	EndTs    int64  `db:"endts"`
+	ExitCode *int   `db:"exitcode"`
+}
+
+type cmdTracker struct {
+	blockId  string
+	connName string
+	partial  []byte
+	cwd      string
+	running  *wshrpc.CmdRecord // started, not done yet
+
+	lock      sync.Mutex
+	pending   []wshrpc.CmdRecord
+	lastWrite time.Time
+	timer     *time.Timer
+}
+
+func makeCmdTracker(blockId string, connName string) *cmdTracker {
+	return &cmdTracker{blockId: blockId, connName: connName}
+}
+
+// returns the commands that finished in data
+func (t *cmdTracker) processOutput(data []byte, now int64) []wshrpc.CmdRecord {
+	var rtn []wshrpc.CmdRecord
+	for _, payload := range scanOscPayloads(&t.partial, oscPrefix, maxOscLen, data) {
+		oscNum, oscData, _ := strings.Cut(payload, ";")
+		if oscNum == "7" {
+			if cwd, ok := parseOsc7Cwd(oscData); ok {
+				t.cwd = cwd
+			}
+			continue
+		}
+		if oscNum != "16162" {
+			continue
+		}
+		cmdType, cmdData, _ := strings.Cut(oscData, ";")
+		switch cmdType {
+		case "C":
+			if t.running != nil {
+				// the shell didn't report the end of the last command
+				t.running.EndTs = now
+				rtn = append(rtn, *t.running)
+			}
+			// leading spaces are kept (for history:ignorepatterns)
+			cmd := strings.TrimRight(cmdData, " \t\r\n")
+			if len(cmd) > CmdHistoryMaxCmdLen {
+				cmd = cmd[:CmdHistoryMaxCmdLen]
+			}
+			t.running = &wshrpc.CmdRecord{Cmd: cmd, Cwd: t.cwd, StartTs: now}
+		case "D":
+			if t.running == nil {
+				continue
+			}
+			t.running.EndTs = now
+			if exitCode, err := strconv.Atoi(strings.TrimSpace(cmdData)); err == nil {
+				t.running.ExitCode = &exitCode
+			}
+			rtn = append(rtn, *t.running)
+			t.running = nil
+		}
+	}
+	return rtn
+}
+
+func (t *cmdTracker) handlePtyOutput(data []byte) {
+	records := t.processOutput(data, time.Now().UnixMilli())
+	if len(records) == 0 {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pending = append(t.pending, records...)
+	if t.timer != nil {
+		return
+	}
+	delay := max(CmdHistoryWriteInterval-time.Since(t.lastWrite), 0)
+	t.timer = time.AfterFunc(delay, t.flush)
+}
+
+func (t *cmdTracker) flush() {
+	t.lock.Lock()
+	records := t.pending
+	t.pending = nil
+	t.timer = nil
+	t.lastWrite = time.Now()
+	t.lock.Unlock()
+	if len(records) == 0 {
+		return
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	err := AppendCmdHistory(ctx, t.blockId, records)
+	if err != nil {
+		log.Printf("error saving command history for block %s: %v\n", t.blockId, err)
+	}
+	err = cmdhistory.AddBlockCmds(ctx, t.blockId, t.connName, records)
+	if err != nil {
+		log.Printf("error adding block %s commands to the history: %v\n", t.blockId, err)
+	}
+}
+
+// when the shell exits, the pending records are written now
+func (t *cmdTracker) close() {
+	t.lock.Lock()
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.lock.Unlock()
+	t.flush()
+}
+
+func AppendCmdHistory(ctx context.Context, blockId string, records []wshrpc.CmdRecord) error {
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		seq := tx.GetInt(`SELECT COALESCE(max(seq), 0) FROM db_cmdhistory WHERE blockid = ?`, blockId)
+		for _, record := range records {
+			seq++
+			query := `INSERT INTO db_cmdhistory (blockid, seq, cmd, cwd, startts, endts, exitcode) VALUES (?, ?, ?, ?, ?, ?, ?)`
+			tx.Exec(query, blockId, seq, record.Cmd, record.Cwd, record.StartTs, record.EndTs, record.ExitCode)
+		}
+		tx.Exec(`DELETE FROM db_cmdhistory WHERE blockid = ? AND seq <= ?`, blockId, seq-CmdHistoryMaxRecords)
+		return nil
+	})
+}
+
+// returns the block's last limit commands (all of them when limit is 0), oldest first
+func GetCmdHistory(ctx context.Context, blockId string, limit int) ([]wshrpc.CmdRecord, error) {
+	rows, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*cmdRecordRow, error) {
+		var rows []*cmdRecordRow
+		if limit > 0 {
+			query := `SELECT * FROM (SELECT * FROM db_cmdhistory WHERE blockid = ? ORDER BY seq DESC LIMIT ?) ORDER BY seq`
+			tx.Select(&rows, query, blockId, limit)
+		} else {
+			tx.Select(&rows, `SELECT * FROM db_cmdhistory WHERE blockid = ? ORDER BY seq`, blockId)
+		}
+		return rows, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]wshrpc.CmdRecord, 0, len(rows))
+	for _, row := range rows {
+		rtn = append(rtn, wshrpc.CmdRecord{
+			Seq:      row.Seq,
+			Cmd:      row.Cmd,
+			Cwd:      row.Cwd,
+			StartTs:  row.StartTs,
+			EndTs:    row.EndTs,
+			ExitCode: row.ExitCode,
+		})
+	}
+	return rtn, nil
+}
+
+// called when the block is purged
+func DeleteCmdHistory(ctx context.Context, blockId string) error {
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		tx.Exec(`DELETE FROM db_cmdhistory WHERE blockid = ?`, blockId)
+		return nil
+	})
+}
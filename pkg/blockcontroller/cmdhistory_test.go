@@ -0,0 +1,91 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func formatCmdRecord(record wshrpc.CmdRecord) string {
+	exitCode := "-"
+	if record.ExitCode != nil {
+		exitCode = fmt.Sprint(*record.ExitCode)
+	}
+	return fmt.Sprintf("%s|%s|%d|%d|%s", record.Cmd, record.Cwd, record.StartTs, record.EndTs, exitCode)
+}
+
+func TestCmdTrackerProcessOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		expected []string
+	}{
+		{name: "command", chunks: []string{"\x1b]7;/home/user\x07$ ls\r\n\x1b]16162;C;ls\x07a b\r\n", "\x1b]16162;D;0\x07\x1b]7;/home/user\x07$ "}, expected: []string{"ls|/home/user|0|1|0"}},
+		{name: "cwd change", chunks: []string{"\x1b]7;/a\x07\x1b]16162;C;cd /b\x07", "\x1b]16162;D;0\x07\x1b]7;/b\x07", "\x1b]16162;C;false\x07", "\x1b]16162;D;1\x07"}, expected: []string{"cd /b|/a|0|1|0", "false|/b|2|3|1"}},
+		{name: "split", chunks: []string{"\x1b]161", "62;C;make te", "st\x1b", "\\out\x1b]16162;D;", "2\x07"}, expected: []string{"make test||3|4|2"}},
+		{name: "no end", chunks: []string{"\x1b]16162;C;vi\x07", "\x1b]16162;C;ls\x07", "\x1b]16162;D;0\x07"}, expected: []string{"vi||0|1|-", "ls||1|2|0"}},
+		{name: "end without start", chunks: []string{"\x1b]16162;D;0\x07\x1b]0;title\x07"}, expected: nil},
+		{name: "running", chunks: []string{"\x1b]16162;C;sleep 100\x07"}, expected: nil},
+	}
+	for _, tc := range tests {
+		tracker := makeCmdTracker("test", "")
+		var records []string
+		for idx, chunk := range tc.chunks {
+			for _, record := range tracker.processOutput([]byte(chunk), int64(idx)) {
+				records = append(records, formatCmdRecord(record))
+			}
+		}
+		if !slices.Equal(records, tc.expected) {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, records)
+		}
+	}
+}
+
+func TestCmdHistoryStore(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	var records []wshrpc.CmdRecord
+	for idx := range CmdHistoryMaxRecords + 5 {
+		records = append(records, wshrpc.CmdRecord{Cmd: fmt.Sprintf("cmd%d", idx), StartTs: int64(idx), EndTs: int64(idx)})
+	}
+	// in two batches, the seqs continue
+	err := AppendCmdHistory(ctx, "block1", records[:10])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = AppendCmdHistory(ctx, "block1", records[10:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := GetCmdHistory(ctx, "block1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != CmdHistoryMaxRecords || history[0].Cmd != "cmd5" || history[0].Seq != 6 {
+		t.Fatalf("expected the oldest commands to be dropped, got %d records starting with %#v", len(history), history[0])
+	}
+	history, err = GetCmdHistory(ctx, "block1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastCmd := fmt.Sprintf("cmd%d", CmdHistoryMaxRecords+4)
+	if len(history) != 2 || history[1].Cmd != lastCmd || history[0].Seq+1 != history[1].Seq {
+		t.Fatalf("expected the last 2 commands (oldest first), got %#v", history)
+	}
+	err = DeleteCmdHistory(ctx, "block1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err = GetCmdHistory(ctx, "block1", 0)
+	if err != nil || len(history) != 0 {
+		t.Fatalf("expected no history after delete, got %#v (err %v)", history, err)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/shellexec"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	CwdWriteInterval = time.Second     // at most one db write per block per interval
+	CwdPollInterval  = 2 * time.Second // for local shells that don't send OSC 7
+	maxOsc7Len       = 4096            // longer sequences are dropped
+)
+
+var osc7Prefix = []byte("\x1b]7;")
+var windowsDrivePathRe = regexp.MustCompile(`^/[A-Za-z]:`)
+
+// finds the OSC 7 sequences (ESC ] 7 ; file://host/path, terminated by BEL or ESC \) in the pty output.
+// sequences can be split across reads.
+type osc7Scanner struct {
+	partial []byte
+}
+
+// the bytes at the end of buf that could be the start of prefix
+func oscPrefixSuffixLen(buf []byte, prefix []byte) int {
+	for n := min(len(prefix)-1, len(buf)); n > 0; n-- {
+		if bytes.HasPrefix(prefix, buf[len(buf)-n:]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// returns the payloads (after the prefix) of the OSC sequences in data, in order.  the end of data that could be
+// the start of a sequence is kept in partial for the next call, sequences longer than maxLen are dropped.
+func scanOscPayloads(partial *[]byte, prefix []byte, maxLen int, data []byte) []string {
+	buf := data
+	if len(*partial) > 0 {
+		buf = append(*partial, data...)
+		*partial = nil
+	}
+	var rtn []string
+	for {
+		startIdx := bytes.Index(buf, prefix)
+		if startIdx == -1 {
+			if n := oscPrefixSuffixLen(buf, prefix); n > 0 {
+				*partial = append([]byte(nil), buf[len(buf)-n:]...)
+			}
+			return rtn
+		}
+		payload := buf[startIdx+len(prefix):]
+		endIdx, termLen := bytes.IndexByte(payload, '\x07'), 1
+		if stIdx := bytes.Index(payload, []byte("\x1b\\")); stIdx != -1 && (endIdx == -1 || stIdx < endIdx) {
+			endIdx, termLen = stIdx, 2
+		}
+		if endIdx == -1 {
+			if len(payload) <= maxLen {
+				*partial = append([]byte(nil), buf[startIdx:]...)
+			}
+			return rtn
+		}
+		if endIdx <= maxLen {
+			rtn = append(rtn, string(payload[:endIdx]))
+		}
+		buf = payload[endIdx+termLen:]
+	}
+}
+
+// returns the cwds found in data (in order)
+func (s *osc7Scanner) scan(data []byte) []string {
+	var rtn []string
+	for _, payload := range scanOscPayloads(&s.partial, osc7Prefix, maxOsc7Len, data) {
+		if cwd, ok := parseOsc7Cwd(payload); ok {
+			rtn = append(rtn, cwd)
+		}
+	}
+	return rtn
+}
+
+// the payload is a file url (file://host/path, the path is percent encoded), or just a path
+func parseOsc7Cwd(data string) (string, bool) {
+	cwd := data
+	if strings.HasPrefix(data, "file://") {
+		rest := data[len("file://"):]
+		slashIdx := strings.Index(rest, "/")
+		if slashIdx == -1 {
+			return "", false
+		}
+		unescaped, err := url.PathUnescape(rest[slashIdx:])
+		if err != nil {
+			return "", false
+		}
+		cwd = unescaped
+	}
+	if !strings.HasPrefix(cwd, "/") {
+		return "", false
+	}
+	if windowsDrivePathRe.MatchString(cwd) {
+		// file:///C:/Users/...
+		cwd = cwd[1:]
+	}
+	return cwd, true
+}
+
+// keeps cmd:cwd up to date for a running shell.  writes are debounced (see CwdWriteInterval), the last cwd wins.
+type cwdTracker struct {
+	blockId    string
+	seenOsc7   atomic.Bool // once the shell sends OSC 7, polling stops
+	lock       sync.Mutex
+	pendingCwd string
+	lastCwd    string // the last cwd that was written
+	lastWrite  time.Time
+	timer      *time.Timer
+}
+
+func makeCwdTracker(blockId string) *cwdTracker {
+	return &cwdTracker{blockId: blockId}
+}
+
+func (t *cwdTracker) setCwd(cwd string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pendingCwd = cwd
+	if t.timer != nil {
+		return
+	}
+	delay := max(CwdWriteInterval-time.Since(t.lastWrite), 0)
+	t.timer = time.AfterFunc(delay, t.flush)
+}
+
+func (t *cwdTracker) flush() {
+	t.lock.Lock()
+	cwd := t.pendingCwd
+	t.timer = nil
+	if cwd == t.lastCwd {
+		t.lock.Unlock()
+		return
+	}
+	t.lastCwd = cwd
+	t.lastWrite = time.Now()
+	t.lock.Unlock()
+	err := setBlockCwdInDB(t.blockId, cwd)
+	if err != nil {
+		log.Printf("error setting cwd for block %s: %v\n", t.blockId, err)
+	}
+}
+
+func (t *cwdTracker) handlePtyOutput(scanner *osc7Scanner, data []byte) {
+	for _, cwd := range scanner.scan(data) {
+		t.seenOsc7.Store(true)
+		t.setCwd(cwd)
+	}
+}
+
+// for shells without OSC 7 integration (local shells only, remote shells must send OSC 7)
+func (t *cwdTracker) pollProcessCwd(shellProc *shellexec.ShellProc, pid int) {
+	ticker := time.NewTicker(CwdPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-shellProc.DoneCh:
+			return
+		case <-ticker.C:
+		}
+		if t.seenOsc7.Load() {
+			return
+		}
+		cwd, err := shellexec.GetProcessCwd(pid)
+		if err != nil || cwd == "" {
+			continue
+		}
+		t.setCwd(cwd)
+	}
+}
+
+func setBlockCwdInDB(blockId string, cwd string) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	bdata, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error getting block data: %v", err)
+	}
+	if bdata.Meta.GetString(waveobj.MetaKey_CmdCwd, "") == cwd {
+		return nil
+	}
+	err = wstore.UpdateObjectMetaAsync(ctx, waveobj.MakeORef(waveobj.OType_Block, blockId), waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: cwd})
+	if err != nil {
+		return fmt.Errorf("error updating block data: %v", err)
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	return nil
+}
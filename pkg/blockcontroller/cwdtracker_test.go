@@ -0,0 +1,40 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOsc7Scanner(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		expected []string
+	}{
+		{name: "bel", chunks: []string{"ls\r\n\x1b]7;file://myhost/home/user\x07$ "}, expected: []string{"/home/user"}},
+		{name: "st", chunks: []string{"\x1b]7;file://myhost/tmp\x1b\\"}, expected: []string{"/tmp"}},
+		{name: "escaped", chunks: []string{"\x1b]7;file://myhost/home/user/my%20dir\x07"}, expected: []string{"/home/user/my dir"}},
+		{name: "plain path", chunks: []string{"\x1b]7;/var/log\x07"}, expected: []string{"/var/log"}},
+		{name: "windows", chunks: []string{"\x1b]7;file://pc/C:/Users/me\x07"}, expected: []string{"C:/Users/me"}},
+		{name: "multiple", chunks: []string{"\x1b]7;file://h/a\x07out\x1b]7;file://h/b\x07"}, expected: []string{"/a", "/b"}},
+		{name: "split payload", chunks: []string{"x\x1b]7;file://h/ho", "me/us", "er\x07y"}, expected: []string{"/home/user"}},
+		{name: "split prefix", chunks: []string{"x\x1b]", "7;file://h/srv\x1b", "\\"}, expected: []string{"/srv"}},
+		{name: "no host path", chunks: []string{"\x1b]7;file://host\x07"}, expected: nil},
+		{name: "other osc", chunks: []string{"\x1b]0;title\x07\x1b]8;;http://x\x07"}, expected: nil},
+		{name: "too long", chunks: []string{"\x1b]7;file://h/" + strings.Repeat("a", maxOsc7Len), strings.Repeat("a", 10) + "\x07\x1b]7;file://h/ok\x07"}, expected: []string{"/ok"}},
+	}
+	for _, tc := range tests {
+		var scanner osc7Scanner
+		var cwds []string
+		for _, chunk := range tc.chunks {
+			cwds = append(cwds, scanner.scan([]byte(chunk))...)
+		}
+		if !slices.Equal(cwds, tc.expected) {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, cwds)
+		}
+	}
+}
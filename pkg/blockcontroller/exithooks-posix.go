@@ -0,0 +1,19 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package blockcontroller
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// setsid, so the timeout can kill the hook's session (see shellexec.ListProcessTree)
+func makeExitHookCmd(ctx context.Context, cmdStr string) *exec.Cmd {
+	ecmd := exec.CommandContext(ctx, "/bin/sh", "-c", cmdStr)
+	ecmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	return ecmd
+}
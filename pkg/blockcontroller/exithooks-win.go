@@ -0,0 +1,22 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package blockcontroller
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// the command line is passed to cmd.exe as is (go's argument quoting doesn't match cmd.exe's)
+func makeExitHookCmd(ctx context.Context, cmdStr string) *exec.Cmd {
+	ecmd := exec.CommandContext(ctx, "cmd.exe")
+	ecmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine:       "cmd.exe /C " + cmdStr,
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+	return ecmd
+}
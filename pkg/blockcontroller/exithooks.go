@@ -0,0 +1,165 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/shellexec"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	ExitHook_OnExit = "onexit" // runs every time the controller exits
+	ExitHook_OnFail = "onfail" // runs when the controller exits with a non-zero exit code
+)
+
+const (
+	DefaultExitHookTimeout = 30 * time.Second
+	exitHookKillGrace      = 2 * time.Second
+	exitHookMaxErrOutput   = 1024 // bytes of a failed hook's stderr that are logged
+)
+
+const (
+	ExitHookEnv_BlockId    = "WAVETERM_BLOCKID"
+	ExitHookEnv_TabId      = "WAVETERM_TABID"
+	ExitHookEnv_Hook       = "WAVETERM_HOOK" // onexit or onfail
+	ExitHookEnv_ExitCode   = "WAVETERM_EXITCODE"
+	ExitHookEnv_DurationMs = "WAVETERM_DURATIONMS" // how long the shell (or cmd) ran
+)
+
+type exitHook struct {
+	Name string // ExitHook_OnExit or ExitHook_OnFail
+	Cmd  string
+}
+
+// the block's cmd:onexit and cmd:onfail override the client's client:onexit and client:onfail.  when the controller
+// fails both hooks run (at the same time).
+func getExitHooks(blockMeta waveobj.MetaMapType, clientMeta waveobj.MetaMapType, exitCode int) []exitHook {
+	var rtn []exitHook
+	addHook := func(name string, blockKey string, clientKey string) {
+		cmdStr := blockMeta.GetString(blockKey, "")
+		if cmdStr == "" {
+			cmdStr = clientMeta.GetString(clientKey, "")
+		}
+		if strings.TrimSpace(cmdStr) == "" {
+			return
+		}
+		rtn = append(rtn, exitHook{Name: name, Cmd: cmdStr})
+	}
+	addHook(ExitHook_OnExit, waveobj.MetaKey_CmdOnExit, waveobj.MetaKey_ClientOnExit)
+	if exitCode != 0 {
+		addHook(ExitHook_OnFail, waveobj.MetaKey_CmdOnFail, waveobj.MetaKey_ClientOnFail)
+	}
+	return rtn
+}
+
+func getExitHookTimeout(blockMeta waveobj.MetaMapType) time.Duration {
+	timeoutMs := blockMeta.GetFloat(waveobj.MetaKey_CmdHookTimeoutMs, 0)
+	if timeoutMs <= 0 {
+		return DefaultExitHookTimeout
+	}
+	return time.Duration(timeoutMs) * time.Millisecond
+}
+
+func makeExitHookEnv(tabId string, blockId string, hookName string, exitCode int, duration time.Duration) []string {
+	return append(os.Environ(),
+		ExitHookEnv_BlockId+"="+blockId,
+		ExitHookEnv_TabId+"="+tabId,
+		ExitHookEnv_Hook+"="+hookName,
+		ExitHookEnv_ExitCode+"="+strconv.Itoa(exitCode),
+		ExitHookEnv_DurationMs+"="+strconv.FormatInt(duration.Milliseconds(), 10),
+	)
+}
+
+// starts the block's exit hooks (on this machine, even for remote blocks).  the hooks run in the background, so the
+// controller's teardown never waits for them.  hooks don't run when wave stops the controller (closing the block,
+// restarting it, shutting down).
+func startExitHooks(tabId string, blockId string, exitCode int, duration time.Duration) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	blockData, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		log.Printf("block %s: error getting block for exit hooks: %v\n", blockId, err)
+		return
+	}
+	if blockData == nil {
+		// deleted
+		return
+	}
+	var clientMeta waveobj.MetaMapType
+	client, err := wstore.DBGetSingletonCached[*waveobj.Client](ctx)
+	if err == nil && client != nil {
+		clientMeta = client.Meta
+	}
+	timeout := getExitHookTimeout(blockData.Meta)
+	for _, hook := range getExitHooks(blockData.Meta, clientMeta, exitCode) {
+		env := makeExitHookEnv(tabId, blockId, hook.Name, exitCode, duration)
+		go func() {
+			defer func() {
+				panichandler.PanicHandler("blockcontroller:exithook", recover())
+			}()
+			err := runExitHook(hook, env, timeout)
+			if err != nil {
+				log.Printf("block %s: %s hook: %v\n", blockId, hook.Name, err)
+			}
+		}()
+	}
+}
+
+// keeps the first max bytes written to it
+type headWriter struct {
+	buf []byte
+	max int
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if room := w.max - len(w.buf); room > 0 {
+		w.buf = append(w.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// runs the hook with the local shell (sh -c, or cmd.exe /C on windows), in its own session so it isn't tied to
+// wave's.  after timeout the hook and the processes it started are killed.
+func runExitHook(hook exitHook, env []string, timeout time.Duration) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), timeout)
+	defer cancelFn()
+	ecmd := makeExitHookCmd(ctx, hook.Cmd)
+	ecmd.Env = env
+	ecmd.Dir = wavebase.GetHomeDir()
+	errOutput := &headWriter{max: exitHookMaxErrOutput}
+	ecmd.Stderr = errOutput
+	ecmd.Cancel = func() error {
+		shellexec.KillProcessTree(ecmd.Process.Pid, exitHookKillGrace)
+		return nil
+	}
+	// background processes the hook started can hold stderr open
+	ecmd.WaitDelay = exitHookKillGrace
+	err := ecmd.Start()
+	if err != nil {
+		return fmt.Errorf("error starting hook: %w", err)
+	}
+	err = ecmd.Wait()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("hook timed out after %v (killed)", timeout)
+	}
+	if err != nil {
+		if output := strings.TrimSpace(string(errOutput.buf)); output != "" {
+			return fmt.Errorf("hook failed: %w: %s", err, output)
+		}
+		return fmt.Errorf("hook failed: %w", err)
+	}
+	return nil
+}
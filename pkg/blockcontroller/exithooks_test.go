@@ -0,0 +1,76 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestGetExitHooks(t *testing.T) {
+	clientMeta := waveobj.MetaMapType{waveobj.MetaKey_ClientOnExit: "client-exit", waveobj.MetaKey_ClientOnFail: "client-fail"}
+	blockMeta := waveobj.MetaMapType{waveobj.MetaKey_CmdOnFail: "block-fail"}
+	tests := []struct {
+		name       string
+		blockMeta  waveobj.MetaMapType
+		clientMeta waveobj.MetaMapType
+		exitCode   int
+		expected   []exitHook
+	}{
+		{name: "no hooks", exitCode: 1},
+		{name: "client", clientMeta: clientMeta, exitCode: 0,
+			expected: []exitHook{{Name: ExitHook_OnExit, Cmd: "client-exit"}}},
+		{name: "block overrides client", blockMeta: blockMeta, clientMeta: clientMeta, exitCode: 2,
+			expected: []exitHook{{Name: ExitHook_OnExit, Cmd: "client-exit"}, {Name: ExitHook_OnFail, Cmd: "block-fail"}}},
+		{name: "onfail only on error", blockMeta: blockMeta, exitCode: 0},
+		{name: "blank", blockMeta: waveobj.MetaMapType{waveobj.MetaKey_CmdOnExit: "  "}, exitCode: 0},
+	}
+	for _, tc := range tests {
+		hooks := getExitHooks(tc.blockMeta, tc.clientMeta, tc.exitCode)
+		if len(hooks) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, hooks)
+			continue
+		}
+		for idx, hook := range hooks {
+			if hook != tc.expected[idx] {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.expected[idx], hook)
+			}
+		}
+	}
+}
+
+func TestRunExitHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	outFile := filepath.Join(t.TempDir(), "out")
+	env := makeExitHookEnv("tab1", "block1", ExitHook_OnFail, 3, 1500*time.Millisecond)
+	hook := exitHook{Name: ExitHook_OnFail, Cmd: `echo "$WAVETERM_BLOCKID $WAVETERM_HOOK $WAVETERM_EXITCODE $WAVETERM_DURATIONMS" > ` + outFile}
+	if err := runExitHook(hook, env, time.Second); err != nil {
+		t.Fatalf("error running hook: %v", err)
+	}
+	if out, _ := os.ReadFile(outFile); strings.TrimSpace(string(out)) != "block1 onfail 3 1500" {
+		t.Errorf("unexpected hook env: %q", out)
+	}
+
+	err := runExitHook(exitHook{Name: ExitHook_OnExit, Cmd: "echo oops >&2; exit 4"}, env, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected the hook's error output in the error, got %v", err)
+	}
+
+	start := time.Now()
+	err = runExitHook(exitHook{Name: ExitHook_OnExit, Cmd: "sleep 30 & sleep 30"}, env, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the hook to be killed, took %v", elapsed)
+	}
+}
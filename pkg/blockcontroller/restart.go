@@ -0,0 +1,142 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	RestartBackoffBase = 500 * time.Millisecond
+	RestartBackoffMax  = 30 * time.Second
+	RestartMaxAttempts = 5                // crashes in a row before the controller is marked failed
+	RapidExitThreshold = 10 * time.Second // a shell that exits with an error sooner than this after starting has crashed
+)
+
+// the max time RestartController can take (the backoff plus starting the shell)
+const RestartControllerTimeout = RestartBackoffMax + StartControllerTimeout
+
+// the delay before restarting a shell that has crashed numCrashes times in a row
+func restartBackoff(numCrashes int) time.Duration {
+	if numCrashes <= 0 {
+		return 0
+	}
+	if numCrashes > 16 {
+		return RestartBackoffMax
+	}
+	return min(RestartBackoffBase<<(numCrashes-1), RestartBackoffMax)
+}
+
+func isRapidCrash(status string, exitCode int, startTs int64, doneTs int64) bool {
+	if status != Status_Done || exitCode == 0 || startTs == 0 || doneTs < startTs {
+		return false
+	}
+	return time.Duration(doneTs-startTs)*time.Millisecond < RapidExitThreshold
+}
+
+// stops the block's controller (if it is running) and starts it again with the block's current meta (cwd, connection,
+// cmd, etc.).  the scrollback is kept (unless cmd:clearonstart is set).  returns once the new shell has started.
+//
+// if the shell crashed (exited with an error right after starting), the restart is delayed with an exponential backoff,
+// and after RestartMaxAttempts crashes in a row the controller is marked failed and isn't restarted.  force restarts
+// right away (and resets the crash count).
+func RestartController(ctx context.Context, blockId string, force bool) error {
+	tabId, err := wstore.DBFindTabForBlockId(ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error finding tab for block: %w", err)
+	}
+	if tabId == "" {
+		return fmt.Errorf("block %s is not in a tab", blockId)
+	}
+	blockData, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error getting block: %w", err)
+	}
+	controllerName := blockData.Meta.GetString(waveobj.MetaKey_Controller, "")
+	if controllerName != BlockController_Shell && controllerName != BlockController_Cmd {
+		return fmt.Errorf("block %s is not a terminal (no shell or cmd controller)", blockId)
+	}
+	if blockData.Meta.GetString(waveobj.MetaKey_Connection, "") != "" {
+		err = CheckConnStatus(blockId)
+		if err != nil {
+			return fmt.Errorf("cannot restart shellproc: %w", err)
+		}
+	}
+	bc := getOrCreateBlockController(tabId, blockId, controllerName)
+	if !bc.RestartLock.CompareAndSwap(false, true) {
+		return fmt.Errorf("block %s is already restarting", blockId)
+	}
+	defer bc.RestartLock.Store(false)
+	var numCrashes int
+	bc.WithLock(func() {
+		if force {
+			bc.CrashCount = 0
+		} else if isRapidCrash(bc.ShellProcStatus, bc.ShellProcExitCode, bc.StartTs, bc.DoneTs) {
+			bc.CrashCount++
+		} else if bc.ShellProcStatus != Status_Failed {
+			bc.CrashCount = 0
+		}
+		numCrashes = bc.CrashCount
+	})
+	if numCrashes >= RestartMaxAttempts {
+		bc.UpdateControllerAndSendUpdate(func() bool {
+			if bc.ShellProcStatus == Status_Failed {
+				return false
+			}
+			bc.ShellProcStatus = Status_Failed
+			return true
+		})
+		return fmt.Errorf("block %s: the shell exited right after starting %d times in a row, not restarting it (use force to restart anyway)", blockId, numCrashes)
+	}
+	StopBlockController(blockId)
+	time.Sleep(100 * time.Millisecond) // so the "process finished" message comes before the new shell's output (see ResyncController)
+	if delay := restartBackoff(numCrashes); delay > 0 {
+		log.Printf("block %s: shell crashed %d time(s) in a row, restarting in %v\n", blockId, numCrashes, delay)
+		bc.UpdateControllerAndSendUpdate(func() bool {
+			bc.ShellProcStatus = Status_Starting
+			return true
+		})
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			bc.UpdateControllerAndSendUpdate(func() bool {
+				bc.ShellProcStatus = Status_Done
+				return true
+			})
+			return ctx.Err()
+		}
+	}
+	var prevStartTs int64
+	bc.WithLock(func() {
+		prevStartTs = bc.StartTs
+	})
+	// run() starts the shell in a goroutine (holding the run lock until the shell has started, or failed to start)
+	bc.run(blockData, blockData.Meta, nil, true)
+	waitCtx, cancelFn := context.WithTimeout(ctx, StartControllerTimeout)
+	defer cancelFn()
+	for {
+		var started bool
+		bc.WithLock(func() {
+			// the shell might have exited already (that is counted as a crash on the next restart)
+			started = bc.StartTs != prevStartTs
+		})
+		if started {
+			return nil
+		}
+		if !bc.RunLock.Load() {
+			return fmt.Errorf("block %s: error starting the shell (see the log for details)", blockId)
+		}
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("block %s: timeout waiting for the shell to start", blockId)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
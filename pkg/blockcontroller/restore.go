@@ -0,0 +1,344 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wsl"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const RestoreConnTimeout = 60 * time.Second // connecting (and starting the shell) for a deferred block
+
+var restoreLock = &sync.Mutex{}
+var restoreStatus *wshrpc.SessionRestoreStatus // nil until RestoreSession runs
+var deferredRestores = make(map[string]bool)   // blockid => restored when the block is first used (see ResyncController)
+
+// set by StopAllBlockControllers, the shells exiting during the shutdown keep controller:running
+var controllersStopping atomic.Bool
+
+type runningMetaUpdate struct {
+	BlockId      string
+	Running      bool
+	ShellType    string // controller:shelltype (when running)
+	RestoreError string // sets controller:restoreerror (and clears controller:running)
+}
+
+// controller:running (and controller:restoreerror) are written in order by one goroutine (the controller can be
+// stopped inside of a db transaction)
+var runningMetaCh = make(chan runningMetaUpdate, 256)
+var runningMetaOnce = &sync.Once{}
+
+type restoreBlock struct {
+	TabId    string
+	BlockId  string
+	ConnName string
+}
+
+func queueRunningMetaUpdate(blockId string, running bool, shellType string) {
+	if !running && controllersStopping.Load() {
+		return
+	}
+	sendRunningMetaUpdate(runningMetaUpdate{BlockId: blockId, Running: running, ShellType: shellType})
+}
+
+func sendRunningMetaUpdate(update runningMetaUpdate) {
+	runningMetaOnce.Do(func() {
+		go runningMetaLoop()
+	})
+	bgWorkWg.Add(1)
+	runningMetaCh <- update
+}
+
+func runningMetaLoop() {
+	defer func() {
+		panichandler.PanicHandler("blockcontroller:runningMetaLoop", recover())
+	}()
+	for update := range runningMetaCh {
+		var err error
+		if update.RestoreError != "" {
+			err = setRestoreError(update.BlockId, update.RestoreError)
+		} else {
+			err = setRunningMeta(update.BlockId, update.Running, update.ShellType)
+		}
+		if err != nil {
+			log.Printf("error setting controller meta for block %s: %v\n", update.BlockId, err)
+		}
+		bgWorkWg.Done()
+	}
+}
+
+// a shell that starts clears the error from a previous restore
+func setRunningMeta(blockId string, running bool, shellType string) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	bdata, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return err
+	}
+	if bdata == nil {
+		// the block was deleted
+		return nil
+	}
+	hasError := bdata.Meta.GetString(waveobj.MetaKey_ControllerRestoreError, "") != ""
+	if !running {
+		shellType = ""
+	}
+	sameShellType := bdata.Meta.GetString(waveobj.MetaKey_ControllerShellType, "") == shellType
+	if bdata.Meta.GetBool(waveobj.MetaKey_ControllerRunning, false) == running && sameShellType && !(running && hasError) {
+		return nil
+	}
+	metaUpdate := waveobj.MetaMapType{waveobj.MetaKey_ControllerRunning: nil, waveobj.MetaKey_ControllerShellType: nil}
+	if running {
+		metaUpdate[waveobj.MetaKey_ControllerRunning] = true
+		metaUpdate[waveobj.MetaKey_ControllerRestoreError] = nil
+		if shellType != "" {
+			metaUpdate[waveobj.MetaKey_ControllerShellType] = shellType
+		}
+	}
+	return updateBlockMetaAndSend(blockId, metaUpdate)
+}
+
+func setRestoreError(blockId string, restoreErr string) error {
+	return updateBlockMetaAndSend(blockId, waveobj.MetaMapType{
+		waveobj.MetaKey_ControllerRunning:      nil,
+		waveobj.MetaKey_ControllerShellType:    nil,
+		waveobj.MetaKey_ControllerRestoreError: restoreErr,
+	})
+}
+
+func updateBlockMetaAndSend(blockId string, metaUpdate waveobj.MetaMapType) error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wstore.UpdateObjectMetaAsync(ctx, waveobj.MakeORef(waveobj.OType_Block, blockId), metaUpdate)
+	if errors.Is(err, wstore.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+// returns a copy of the restore progress (nil if RestoreSession hasn't run)
+func GetRestoreStatus() *wshrpc.SessionRestoreStatus {
+	restoreLock.Lock()
+	defer restoreLock.Unlock()
+	if restoreStatus == nil {
+		return nil
+	}
+	rtn := *restoreStatus
+	return &rtn
+}
+
+// updates the restore progress and publishes it (the event is persisted, so a frontend that attaches later can read it)
+func updateRestoreStatus(updateFn func(status *wshrpc.SessionRestoreStatus)) {
+	restoreLock.Lock()
+	if restoreStatus == nil {
+		restoreStatus = &wshrpc.SessionRestoreStatus{}
+	}
+	updateFn(restoreStatus)
+	statusCopy := *restoreStatus
+	restoreLock.Unlock()
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:   wps.Event_SessionRestore,
+		Persist: 1,
+		Data:    &statusCopy,
+	})
+}
+
+func takeDeferredRestore(blockId string) bool {
+	restoreLock.Lock()
+	defer restoreLock.Unlock()
+	if !deferredRestores[blockId] {
+		return false
+	}
+	delete(deferredRestores, blockId)
+	return true
+}
+
+func finishBlockRestore(blockId string, restoreErr error, deferred bool) {
+	if restoreErr != nil {
+		log.Printf("error restoring block %s: %v\n", blockId, restoreErr)
+		sendRunningMetaUpdate(runningMetaUpdate{BlockId: blockId, RestoreError: restoreErr.Error()})
+	}
+	updateRestoreStatus(func(status *wshrpc.SessionRestoreStatus) {
+		if deferred {
+			status.Deferred--
+		}
+		if restoreErr != nil {
+			status.Failed++
+		} else {
+			status.Restored++
+		}
+	})
+}
+
+// the blocks in the windows' tabs that had a running shell when the server shut down (in tab order)
+func getRestoreBlocks(ctx context.Context) ([]restoreBlock, error) {
+	windows, err := wstore.DBGetAllObjsByType[*waveobj.Window](ctx, waveobj.OType_Window)
+	if err != nil {
+		return nil, fmt.Errorf("error getting windows: %w", err)
+	}
+	var rtn []restoreBlock
+	for _, window := range windows {
+		workspace, err := wstore.DBGet[*waveobj.Workspace](ctx, window.WorkspaceId)
+		if err != nil {
+			return nil, fmt.Errorf("error getting workspace %s: %w", window.WorkspaceId, err)
+		}
+		if workspace == nil {
+			continue
+		}
+		for _, tabId := range append(append([]string{}, workspace.PinnedTabIds...), workspace.TabIds...) {
+			tab, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+			if err != nil {
+				return nil, fmt.Errorf("error getting tab %s: %w", tabId, err)
+			}
+			if tab == nil {
+				continue
+			}
+			for _, blockId := range tab.BlockIds {
+				block, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+				if err != nil {
+					return nil, fmt.Errorf("error getting block %s: %w", blockId, err)
+				}
+				if block == nil || !shouldRestoreBlock(block.Meta) {
+					continue
+				}
+				connName := block.Meta.GetString(waveobj.MetaKey_Connection, "")
+				if connName == "local" {
+					connName = ""
+				}
+				rtn = append(rtn, restoreBlock{TabId: tabId, BlockId: blockId, ConnName: connName})
+			}
+		}
+	}
+	return rtn, nil
+}
+
+func shouldRestoreBlock(meta waveobj.MetaMapType) bool {
+	controllerName := meta.GetString(waveobj.MetaKey_Controller, "")
+	if controllerName != BlockController_Shell && controllerName != BlockController_Cmd {
+		return false
+	}
+	if !meta.GetBool(waveobj.MetaKey_ControllerRunning, false) || !meta.GetBool(waveobj.MetaKey_ControllerRestore, true) {
+		return false
+	}
+	// the controller wouldn't start (see run)
+	return meta.GetBool(waveobj.MetaKey_CmdRunOnStart, true) || meta.GetBool(waveobj.MetaKey_CmdRunOnce, false)
+}
+
+func waitForControllerStart(ctx context.Context, blockId string) error {
+	waitCtx, cancelFn := context.WithTimeout(ctx, StartControllerTimeout)
+	defer cancelFn()
+	for {
+		if bc := GetBlockController(blockId); bc != nil {
+			var status string
+			var exitCode int
+			var startErr error
+			bc.WithLock(func() {
+				status = bc.ShellProcStatus
+				exitCode = bc.ShellProcExitCode
+				startErr = bc.startErr
+			})
+			if status == Status_Running {
+				return nil
+			}
+			if startErr != nil {
+				return startErr
+			}
+			if status == Status_Done || status == Status_Failed {
+				return fmt.Errorf("shell exited right after starting (exit code %d)", exitCode)
+			}
+		}
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("timeout waiting for the shell to start")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func ensureConnection(ctx context.Context, connName string) error {
+	if strings.HasPrefix(connName, "wsl://") {
+		return wsl.EnsureConnection(ctx, strings.TrimPrefix(connName, "wsl://"))
+	}
+	return conncontroller.EnsureConnection(ctx, connName)
+}
+
+// restores a block on a connection the first time it is used, so restoring doesn't connect to every remote
+// at startup (the connection can need a password, etc.)
+func restoreConnBlock(tabId string, blockId string, connName string, rtOpts *waveobj.RuntimeOpts, force bool) {
+	defer func() {
+		panichandler.PanicHandler("blockcontroller:restoreConnBlock", recover())
+	}()
+	ctx, cancelFn := context.WithTimeout(context.Background(), RestoreConnTimeout)
+	defer cancelFn()
+	err := ensureConnection(ctx, connName)
+	if err != nil {
+		err = fmt.Errorf("error connecting to %s: %w", connName, err)
+	} else {
+		err = ResyncController(ctx, tabId, blockId, rtOpts, force)
+	}
+	if err == nil {
+		err = waitForControllerStart(ctx, blockId)
+	}
+	finishBlockRestore(blockId, err, true)
+}
+
+// restarts the shells that were running when the server last shut down (the blocks in the windows' tabs with
+// controller:running, unless controller:restore is false).  the local shells are started one at a time, the
+// shells on a connection are deferred until the block is first used (see ResyncController).  a block that can't
+// be restored gets controller:restoreerror and the other blocks are still restored.  the progress is returned
+// by GetRestoreStatus (and published as a sessionrestore event).
+func RestoreSession(ctx context.Context) {
+	blocks, err := getRestoreBlocks(ctx)
+	if err != nil {
+		log.Printf("error getting blocks to restore: %v\n", err)
+		return
+	}
+	var localBlocks []restoreBlock
+	restoreLock.Lock()
+	for _, rb := range blocks {
+		if rb.ConnName != "" {
+			deferredRestores[rb.BlockId] = true
+		} else {
+			localBlocks = append(localBlocks, rb)
+		}
+	}
+	restoreLock.Unlock()
+	updateRestoreStatus(func(status *wshrpc.SessionRestoreStatus) {
+		status.Running = true
+		status.Total = len(blocks)
+		status.Deferred = len(blocks) - len(localBlocks)
+		status.StartTs = time.Now().UnixMilli()
+	})
+	log.Printf("restoring %d block(s) (%d deferred until first use)\n", len(blocks), len(blocks)-len(localBlocks))
+	for _, rb := range localBlocks {
+		err := ResyncController(ctx, rb.TabId, rb.BlockId, nil, false)
+		if err == nil {
+			err = waitForControllerStart(ctx, rb.BlockId)
+		}
+		finishBlockRestore(rb.BlockId, err, false)
+	}
+	updateRestoreStatus(func(status *wshrpc.SessionRestoreStatus) {
+		status.Running = false
+		status.DoneTs = time.Now().UnixMilli()
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func TestRestoreSession(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancelFn()
+	metaUpdates := map[string]waveobj.MetaMapType{
+		"running": {waveobj.MetaKey_Cmd: "sleep 30"},
+		"failing": {waveobj.MetaKey_Cmd: testShellPath + " -c 'exit 5'"},
+		"optout":  {waveobj.MetaKey_Cmd: "sleep 30", waveobj.MetaKey_ControllerRestore: false},
+		"remote":  {waveobj.MetaKey_Cmd: "sleep 30", waveobj.MetaKey_Connection: "user@restore.invalid"},
+		"exited":  {waveobj.MetaKey_Cmd: "sleep 30", waveobj.MetaKey_ControllerRunning: nil},
+	}
+	blockIds := make(map[string]string)
+	var tabBlockIds []string
+	for name, metaUpdate := range metaUpdates {
+		blockId := makeTestShellBlock(t, ctx)
+		metaUpdate[waveobj.MetaKey_CmdRunOnStart] = true
+		if _, found := metaUpdate[waveobj.MetaKey_ControllerRunning]; !found {
+			metaUpdate[waveobj.MetaKey_ControllerRunning] = true
+		}
+		err := wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Block, blockId), metaUpdate, false)
+		if err != nil {
+			t.Fatalf("error updating block: %v", err)
+		}
+		blockIds[name] = blockId
+		tabBlockIds = append(tabBlockIds, blockId)
+	}
+	tab := &waveobj.Tab{OID: uuid.NewString(), BlockIds: tabBlockIds}
+	workspace := &waveobj.Workspace{OID: uuid.NewString(), TabIds: []string{tab.OID}}
+	window := &waveobj.Window{OID: uuid.NewString(), WorkspaceId: workspace.OID}
+	for _, obj := range []waveobj.WaveObj{tab, workspace, window} {
+		if err := wstore.DBInsert(ctx, obj); err != nil {
+			t.Fatalf("error inserting %s: %v", obj.GetOType(), err)
+		}
+	}
+
+	RestoreSession(ctx)
+	status := GetRestoreStatus()
+	if status.Running || status.Total != 3 || status.Restored != 1 || status.Failed != 1 || status.Deferred != 1 {
+		t.Errorf("unexpected restore status %+v", status)
+	}
+	if rtStatus := GetBlockController(blockIds["running"]).GetRuntimeStatus(); rtStatus.ShellProcStatus != Status_Running {
+		t.Errorf("expected the running block to be restarted, got status %q", rtStatus.ShellProcStatus)
+	}
+	for _, name := range []string{"optout", "exited", "remote"} {
+		if GetBlockController(blockIds[name]) != nil {
+			t.Errorf("expected the %s block not to be started", name)
+		}
+	}
+	for ctx.Err() == nil {
+		block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockIds["failing"])
+		if err != nil {
+			t.Fatalf("error getting block: %v", err)
+		}
+		if block.Meta.GetString(waveobj.MetaKey_ControllerRestoreError, "") != "" {
+			if block.Meta.GetBool(waveobj.MetaKey_ControllerRunning, false) {
+				t.Errorf("expected controller:running to be cleared for the failed block")
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("expected controller:restoreerror to be set for the failing block")
+}
@@ -0,0 +1,372 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	MaxScrollbackBytes      = 64 * 1024 * 1024 // the largest byte limit (the term file's ring buffer is sized to fit it)
+	scrollbackCheckBytes    = 32 * 1024        // the limits are checked after this much output
+	scrollbackLimitsRefresh = 5 * time.Second  // how often the limits are re-read from the block and client meta
+	scrollbackScanChunkSize = 64 * 1024
+)
+
+// the scrollback limits for a block's term file (0 is no limit).  the block's term:scrollbackbytes and
+// term:scrollbacklines override the client's client:scrollbackbytes and client:scrollbacklines.
+type ScrollbackLimits struct {
+	MaxBytes int64
+	MaxLines int
+}
+
+func (limits ScrollbackLimits) IsSet() bool {
+	return limits.MaxBytes > 0 || limits.MaxLines > 0
+}
+
+func GetScrollbackLimits(ctx context.Context, blockMeta waveobj.MetaMapType) ScrollbackLimits {
+	var limits ScrollbackLimits
+	client, err := wstore.DBGetSingletonCached[*waveobj.Client](ctx)
+	if err == nil && client != nil {
+		limits.MaxBytes = int64(client.Meta.GetInt(waveobj.MetaKey_ClientScrollbackBytes, 0))
+		limits.MaxLines = client.Meta.GetInt(waveobj.MetaKey_ClientScrollbackLines, 0)
+	}
+	if maxBytes := blockMeta.GetInt(waveobj.MetaKey_TermScrollbackBytes, 0); maxBytes > 0 {
+		limits.MaxBytes = int64(maxBytes)
+	}
+	if maxLines := blockMeta.GetInt(waveobj.MetaKey_TermScrollbackLines, 0); maxLines > 0 {
+		limits.MaxLines = maxLines
+	}
+	limits.MaxBytes = min(max(limits.MaxBytes, 0), MaxScrollbackBytes)
+	limits.MaxLines = max(limits.MaxLines, 0)
+	return limits
+}
+
+// the size of the ring buffer for a new term file (the limiter trims at 3/4 of it, see getRingByteLimit)
+func getTermFileMaxSize(limits ScrollbackLimits) int64 {
+	return max(DefaultTermMaxFileSize, limits.MaxBytes*4/3+scrollbackCheckBytes)
+}
+
+// the byte limit is lowered so the file is trimmed (the limit plus the slack plus one check interval) before the
+// ring buffer wraps
+func getRingByteLimit(file *filestore.WaveFile) int64 {
+	return file.Opts.MaxSize * 3 / 4
+}
+
+// the limits are exceeded by this much before the file is trimmed (so it isn't trimmed on every write)
+func getTrimSlack[T int | int64](limit T) T {
+	return limit/8 + 1
+}
+
+const (
+	escState_Ground   = iota
+	escState_Esc      // after ESC
+	escState_EscInter // ESC + intermediate bytes (e.g. ESC ( B)
+	escState_Csi
+	escState_Str    // OSC, DCS, APC, PM, and SOS (until BEL or ST)
+	escState_StrEsc // ESC in a string (ESC \ is ST)
+)
+
+// finds where the scrollback can be cut between two lines without splitting an escape sequence.  the scan state
+// carries over between chunks, the data must be scanned from the start of the scrollback (which is always a cut).
+type scrollbackScanner struct {
+	state    int
+	numLines int // the newlines that were scanned
+}
+
+func (s *scrollbackScanner) step(ch byte) {
+	if ch == 0x18 || ch == 0x1a {
+		// CAN and SUB cancel any sequence
+		s.state = escState_Ground
+		return
+	}
+	switch s.state {
+	case escState_Ground:
+		if ch == 0x1b {
+			s.state = escState_Esc
+		}
+	case escState_Esc:
+		switch {
+		case ch == '[':
+			s.state = escState_Csi
+		case ch == ']' || ch == 'P' || ch == '_' || ch == '^' || ch == 'X':
+			s.state = escState_Str
+		case ch >= 0x20 && ch <= 0x2f:
+			s.state = escState_EscInter
+		case ch == 0x1b:
+			// stays in escState_Esc
+		default:
+			s.state = escState_Ground
+		}
+	case escState_EscInter:
+		if ch == 0x1b {
+			s.state = escState_Esc
+		} else if ch >= 0x30 {
+			s.state = escState_Ground
+		}
+	case escState_Csi:
+		if ch == 0x1b {
+			s.state = escState_Esc
+		} else if ch >= 0x40 && ch <= 0x7e {
+			s.state = escState_Ground
+		}
+	case escState_Str:
+		if ch == 0x07 {
+			s.state = escState_Ground
+		} else if ch == 0x1b {
+			s.state = escState_StrEsc
+		}
+	case escState_StrEsc:
+		if ch == '\\' {
+			s.state = escState_Ground
+		} else {
+			// ESC aborts the string and starts a new sequence
+			s.state = escState_Esc
+			s.step(ch)
+		}
+	}
+}
+
+// scans data (which starts at offset in the file).  returns the offset just past the first newline that is outside
+// of an escape sequence, ends at or after minOffset, and has at least minLines newlines before it (including itself).
+func (s *scrollbackScanner) findCut(data []byte, offset int64, minOffset int64, minLines int) (int64, bool) {
+	for idx, ch := range data {
+		if ch == '\n' {
+			s.numLines++
+		}
+		if ch == '\n' && s.state == escState_Ground {
+			cutOffset := offset + int64(idx) + 1
+			if cutOffset >= minOffset && s.numLines >= minLines {
+				return cutOffset, true
+			}
+			continue
+		}
+		s.step(ch)
+	}
+	return 0, false
+}
+
+// trims the head of the block's term file at the first line boundary at or after minOffset that drops at least
+// minLines lines (without a boundary nothing is trimmed).  returns the new start and the number of lines dropped.
+func trimScrollbackHead(ctx context.Context, blockId string, file *filestore.WaveFile, minOffset int64, minLines int) (int64, int, error) {
+	startIdx := file.DataStartIdx()
+	var scanner scrollbackScanner
+	var cutOffset int64
+	found := false
+	for offset := startIdx; offset < file.Size && !found; offset += scrollbackScanChunkSize {
+		readOffset, data, err := filestore.WFS.ReadAt(ctx, blockId, BlockFile_Term, offset, scrollbackScanChunkSize)
+		if err != nil {
+			return startIdx, 0, fmt.Errorf("error reading scrollback: %w", err)
+		}
+		if readOffset != offset {
+			// trimmed while we were scanning, the next check will retry
+			return startIdx, 0, nil
+		}
+		cutOffset, found = scanner.findCut(data, offset, minOffset, minLines)
+	}
+	if !found && minOffset >= file.Size && scanner.state == escState_Ground {
+		// dropping everything (cuts at the end of the output)
+		cutOffset, found = file.Size, true
+	}
+	if !found {
+		return startIdx, 0, nil
+	}
+	newStartIdx, err := filestore.WFS.TrimHead(ctx, blockId, BlockFile_Term, cutOffset)
+	if err != nil {
+		return startIdx, 0, fmt.Errorf("error trimming scrollback: %w", err)
+	}
+	removeStaleTermCache(ctx, blockId, newStartIdx)
+	// frontends that are streaming the file keep their offsets (a trim doesn't move the end), the event is for
+	// frontends that are loading the file
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_BlockFile,
+		Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, blockId).String()},
+		Data: &wps.WSFileEventData{
+			ZoneId:   blockId,
+			FileName: BlockFile_Term,
+			FileOp:   wps.FileOp_Reset,
+		},
+	})
+	return newStartIdx, scanner.numLines, nil
+}
+
+// the cached terminal state is restored by reading the term file from its ptyoffset, it can't be used once that
+// data was trimmed
+func removeStaleTermCache(ctx context.Context, blockId string, startIdx int64) {
+	cacheFile, err := filestore.WFS.Stat(ctx, blockId, BlockFile_Cache)
+	if err != nil {
+		return
+	}
+	if filestore.MetaInt64(cacheFile.Meta, "ptyoffset") >= startIdx {
+		return
+	}
+	err = filestore.WFS.DeleteFile(ctx, blockId, BlockFile_Cache)
+	if err != nil && err != fs.ErrNotExist {
+		log.Printf("error deleting stale cache file (continuing): %v\n", err)
+	}
+}
+
+// trims the block's scrollback to (about) the last keepBytes bytes, cutting at the next line boundary.  returns the
+// size of the scrollback that is left.
+func TrimScrollback(ctx context.Context, blockId string, keepBytes int64) (int64, error) {
+	if keepBytes < 0 {
+		return 0, fmt.Errorf("keep bytes must be non-negative")
+	}
+	file, err := filestore.WFS.Stat(ctx, blockId, BlockFile_Term)
+	if err == fs.ErrNotExist {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting scrollback file: %w", err)
+	}
+	minOffset := file.Size - keepBytes
+	if minOffset <= file.DataStartIdx() {
+		return file.DataLength(), nil
+	}
+	newStartIdx, _, err := trimScrollbackHead(ctx, blockId, file, minOffset, 0)
+	if err != nil {
+		return 0, err
+	}
+	return file.Size - newStartIdx, nil
+}
+
+// returns the size of the block's scrollback (0 if it doesn't have a term file)
+func GetScrollbackSize(ctx context.Context, blockId string) (int64, error) {
+	file, err := filestore.WFS.Stat(ctx, blockId, BlockFile_Term)
+	if err == fs.ErrNotExist {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return file.DataLength(), nil
+}
+
+// enforces a block's scrollback limits as the pty output is appended to the term file (only used by the pty
+// read loop).  the ring buffer drops the oldest output by itself, but it can cut an escape sequence in half, so when
+// limits are set the file is trimmed (at a line boundary) before it wraps.
+type scrollbackLimiter struct {
+	blockId      string
+	limits       ScrollbackLimits
+	limitsTs     time.Time
+	pendingBytes int
+	// the newlines in [lineStart, lineEnd) of the file (only counted when there is a line limit)
+	numLines   int
+	lineStart  int64
+	lineEnd    int64
+	linesValid bool
+}
+
+func makeScrollbackLimiter(blockId string) *scrollbackLimiter {
+	return &scrollbackLimiter{blockId: blockId}
+}
+
+// called after output was appended to the term file
+func (sl *scrollbackLimiter) handleAppend(numBytes int) {
+	sl.pendingBytes += numBytes
+	if sl.pendingBytes < scrollbackCheckBytes {
+		return
+	}
+	sl.pendingBytes = 0
+	err := sl.enforceLimits()
+	if err != nil {
+		log.Printf("error enforcing scrollback limits for block %s: %v\n", sl.blockId, err)
+	}
+}
+
+func (sl *scrollbackLimiter) enforceLimits() error {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	if time.Since(sl.limitsTs) > scrollbackLimitsRefresh {
+		block, err := wstore.DBGetCached[*waveobj.Block](ctx, sl.blockId)
+		if err != nil {
+			return fmt.Errorf("error getting block: %w", err)
+		}
+		if block == nil {
+			return nil
+		}
+		sl.limits = GetScrollbackLimits(ctx, block.Meta)
+		sl.limitsTs = time.Now()
+	}
+	if !sl.limits.IsSet() {
+		sl.linesValid = false
+		return nil
+	}
+	file, err := filestore.WFS.Stat(ctx, sl.blockId, BlockFile_Term)
+	if err == fs.ErrNotExist {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting scrollback file: %w", err)
+	}
+	startIdx := file.DataStartIdx()
+	// a file made before the limit was raised can be smaller than the limit
+	maxBytes := sl.limits.MaxBytes
+	if ringLimit := getRingByteLimit(file); file.Opts.Circular && (maxBytes == 0 || maxBytes > ringLimit) {
+		maxBytes = ringLimit
+	}
+	minOffset := startIdx
+	if maxBytes > 0 && file.DataLength() > maxBytes+getTrimSlack(maxBytes) {
+		minOffset = file.Size - maxBytes
+	}
+	minLines := 0
+	if sl.limits.MaxLines > 0 {
+		err = sl.countLines(ctx, file)
+		if err != nil {
+			return err
+		}
+		if sl.numLines > sl.limits.MaxLines+getTrimSlack(sl.limits.MaxLines) {
+			minLines = sl.numLines - sl.limits.MaxLines
+		}
+	}
+	if minOffset == startIdx && minLines == 0 {
+		return nil
+	}
+	newStartIdx, numDropped, err := trimScrollbackHead(ctx, sl.blockId, file, minOffset, minLines)
+	if err != nil {
+		return err
+	}
+	if sl.limits.MaxLines > 0 && sl.linesValid && newStartIdx != startIdx {
+		sl.numLines -= numDropped
+		sl.lineStart = newStartIdx
+	}
+	return nil
+}
+
+// updates numLines for the new output (recounts everything if the start moved without us, e.g. the ring buffer
+// wrapped, or the scrollback was cleared)
+func (sl *scrollbackLimiter) countLines(ctx context.Context, file *filestore.WaveFile) error {
+	startIdx := file.DataStartIdx()
+	if !sl.linesValid || sl.lineStart != startIdx || sl.lineEnd > file.Size {
+		sl.numLines = 0
+		sl.lineStart = startIdx
+		sl.lineEnd = startIdx
+		sl.linesValid = true
+	}
+	for sl.lineEnd < file.Size {
+		readOffset, data, err := filestore.WFS.ReadAt(ctx, sl.blockId, BlockFile_Term, sl.lineEnd, min(file.Size-sl.lineEnd, scrollbackScanChunkSize))
+		if err != nil {
+			sl.linesValid = false
+			return fmt.Errorf("error reading scrollback: %w", err)
+		}
+		if readOffset != sl.lineEnd || len(data) == 0 {
+			// trimmed while we were counting
+			sl.linesValid = false
+			return nil
+		}
+		sl.numLines += bytes.Count(data, []byte{'\n'})
+		sl.lineEnd += int64(len(data))
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package blockcontroller
+
+import "testing"
+
+func TestScrollbackFindCut(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		minOffset int64
+		minLines  int
+		expected  int64
+		ok        bool
+	}{
+		{name: "first line", data: "abc\ndef\n", minOffset: 1, expected: 4, ok: true},
+		{name: "min offset", data: "abc\ndef\nghi\n", minOffset: 5, expected: 8, ok: true},
+		{name: "min lines", data: "a\nb\nc\n", minLines: 2, expected: 4, ok: true},
+		{name: "csi", data: "\x1b[31mred\n", minOffset: 1, expected: 9, ok: true},
+		{name: "osc with newline", data: "\x1b]0;a\nb\x07c\nd\n", minOffset: 1, expected: 10, ok: true},
+		{name: "osc with st", data: "\x1b]8;;file\n\x1b\\link\n", minOffset: 1, expected: 17, ok: true},
+		{name: "dcs without end", data: "\x1bPq\nabc\n", minOffset: 1, ok: false},
+		{name: "dcs canceled", data: "\x1bPq\x18abc\n", minOffset: 1, expected: 8, ok: true},
+		{name: "no newline", data: "abcdef", minOffset: 1, ok: false},
+	}
+	for _, tc := range tests {
+		var scanner scrollbackScanner
+		cut, ok := scanner.findCut([]byte(tc.data), 0, tc.minOffset, tc.minLines)
+		if ok != tc.ok || cut != tc.expected {
+			t.Errorf("%s: expected %d (%v), got %d (%v)", tc.name, tc.expected, tc.ok, cut, ok)
+		}
+	}
+	// the state carries over between chunks
+	var scanner scrollbackScanner
+	if _, ok := scanner.findCut([]byte("ab\x1b]0;title\n"), 0, 1, 0); ok {
+		t.Errorf("expected no cut in the middle of an escape sequence")
+	}
+	if cut, ok := scanner.findCut([]byte("x\x07done\n"), 12, 1, 0); !ok || cut != 19 {
+		t.Errorf("expected a cut at 19, got %d (%v)", cut, ok)
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// the global command history (db_history): the commands reported by the shell integration in all of the blocks
+// (see blockcontroller/cmdhistory.go, which also keeps the last commands of each block), and the commands imported
+// from shell history files.  consecutive runs of the same command (in a block, or in an imported file) are merged
+// into one record, and the oldest records are dropped once there are more than history:maxrows.
+package cmdhistory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	DefaultMaxRows     = 10000
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 1000
+	MaxImportRecords   = 5000 // per ImportHistory call
+	MaxCmdLen          = 4096 // longer command lines are truncated
+
+	Source_Wave        = "wave"
+	SourcePrefixImport = "import:"
+)
+
+const localConnName = "local"
+
+type historyRow struct {
+	HistoryId  int64  `db:"historyid"`
+	Ts         int64  `db:"ts"`
+	Cmd        string `db:"cmd"`
+	Cwd        string `db:"cwd"`
+	Connection string `db:"connection"`
+	ExitCode   *int   `db:"exitcode"`
+	DurationMs int64  `db:"durationms"`
+	BlockId    string `db:"blockid"`
+	Source     string `db:"source"`
+	Count      int    `db:"count"`
+}
+
+func (row *historyRow) toRecord() wshrpc.HistoryRecord {
+	return wshrpc.HistoryRecord{
+		HistoryId:  row.HistoryId,
+		Ts:         row.Ts,
+		Cmd:        row.Cmd,
+		Cwd:        row.Cwd,
+		Connection: row.Connection,
+		ExitCode:   row.ExitCode,
+		DurationMs: row.DurationMs,
+		BlockId:    row.BlockId,
+		Source:     row.Source,
+		Count:      row.Count,
+	}
+}
+
+var ignoreLock = &sync.Mutex{}
+var ignoreCacheKey string
+var ignoreCache []*regexp.Regexp
+
+// compiled once per change of history:ignorepatterns (the invalid patterns are logged and skipped)
+func getIgnoreRegexps(patterns []string) []*regexp.Regexp {
+	ignoreLock.Lock()
+	defer ignoreLock.Unlock()
+	key := strings.Join(patterns, "\x00")
+	if ignoreCache != nil && key == ignoreCacheKey {
+		return ignoreCache
+	}
+	rtn := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Printf("invalid history:ignorepatterns pattern %q: %v\n", pattern, err)
+			continue
+		}
+		rtn = append(rtn, re)
+	}
+	ignoreCacheKey = key
+	ignoreCache = rtn
+	return rtn
+}
+
+type historySettings struct {
+	disabled bool
+	maxRows  int
+	ignore   []*regexp.Regexp
+}
+
+func getSettings() historySettings {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	maxRows := settings.HistoryMaxRows
+	if maxRows <= 0 {
+		maxRows = DefaultMaxRows
+	}
+	return historySettings{
+		disabled: settings.HistoryDisabled,
+		maxRows:  maxRows,
+		ignore:   getIgnoreRegexps(settings.HistoryIgnorePatterns),
+	}
+}
+
+// the patterns are matched against the command line as typed (e.g. "^\s" for a leading space)
+func (s historySettings) isIgnored(cmd string) bool {
+	if strings.TrimSpace(cmd) == "" {
+		return true
+	}
+	for _, re := range s.ignore {
+		if re.MatchString(cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+// adds the record, or merges it into the last record of the same block and source if it is the same command
+func addRecordTx(tx *wstore.TxWrap, record wshrpc.HistoryRecord) {
+	record.Cmd = strings.TrimSpace(record.Cmd)
+	if len(record.Cmd) > MaxCmdLen {
+		record.Cmd = record.Cmd[:MaxCmdLen]
+	}
+	var lastRow historyRow
+	found := tx.Get(&lastRow, `SELECT * FROM db_history WHERE blockid = ? AND source = ? ORDER BY historyid DESC LIMIT 1`, record.BlockId, record.Source)
+	if found && lastRow.Cmd == record.Cmd {
+		query := `UPDATE db_history SET ts = ?, cwd = ?, connection = ?, exitcode = ?, durationms = ?, count = count + 1 WHERE historyid = ?`
+		tx.Exec(query, max(record.Ts, lastRow.Ts), record.Cwd, record.Connection, record.ExitCode, record.DurationMs, lastRow.HistoryId)
+		return
+	}
+	query := `INSERT INTO db_history (ts, cmd, cwd, connection, exitcode, durationms, blockid, source, count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1)`
+	tx.Exec(query, record.Ts, record.Cmd, record.Cwd, record.Connection, record.ExitCode, record.DurationMs, record.BlockId, record.Source)
+}
+
+// drops the oldest records (the imported commands without a timestamp first)
+func evictTx(tx *wstore.TxWrap, maxRows int) {
+	tx.Exec(`DELETE FROM db_history WHERE historyid IN (SELECT historyid FROM db_history ORDER BY ts DESC, historyid DESC LIMIT -1 OFFSET ?)`, maxRows)
+}
+
+// called by the block controller with the commands that finished in a block
+func AddBlockCmds(ctx context.Context, blockId string, connName string, cmds []wshrpc.CmdRecord) error {
+	return addBlockCmds(ctx, getSettings(), blockId, connName, cmds)
+}
+
+func addBlockCmds(ctx context.Context, settings historySettings, blockId string, connName string, cmds []wshrpc.CmdRecord) error {
+	if settings.disabled {
+		return nil
+	}
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		for _, cmd := range cmds {
+			if settings.isIgnored(cmd.Cmd) {
+				continue
+			}
+			addRecordTx(tx, wshrpc.HistoryRecord{
+				Ts:         cmd.StartTs,
+				Cmd:        cmd.Cmd,
+				Cwd:        cmd.Cwd,
+				Connection: connName,
+				ExitCode:   cmd.ExitCode,
+				DurationMs: max(cmd.EndTs-cmd.StartTs, 0),
+				BlockId:    blockId,
+				Source:     Source_Wave,
+			})
+		}
+		evictTx(tx, settings.maxRows)
+		return nil
+	})
+}
+
+// returns the number of records that were added (or merged into the previous one)
+func ImportHistory(ctx context.Context, data wshrpc.CommandImportHistoryData) (int, error) {
+	return importHistory(ctx, getSettings(), data)
+}
+
+func importHistory(ctx context.Context, settings historySettings, data wshrpc.CommandImportHistoryData) (int, error) {
+	if settings.disabled {
+		return 0, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "command history is disabled (history:disabled)")
+	}
+	if data.Shell == "" {
+		return 0, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "shell must be set")
+	}
+	if len(data.Records) > MaxImportRecords {
+		return 0, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "too many records (max %d per import call)", MaxImportRecords)
+	}
+	source := SourcePrefixImport + data.Shell
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (int, error) {
+		if data.Replace {
+			tx.Exec(`DELETE FROM db_history WHERE source = ?`, source)
+		}
+		var numAdded int
+		for _, record := range data.Records {
+			if settings.isIgnored(record.Cmd) {
+				continue
+			}
+			addRecordTx(tx, wshrpc.HistoryRecord{
+				Ts:         record.Ts,
+				Cmd:        record.Cmd,
+				Connection: data.Connection,
+				Source:     source,
+			})
+			numAdded++
+		}
+		evictTx(tx, settings.maxRows)
+		return numAdded, nil
+	})
+}
+
+func makeSearchQuery(data wshrpc.CommandSearchHistoryData, limit int) (string, []any) {
+	var conds []string
+	var args []any
+	// instr is case sensitive (unlike LIKE) and doesn't need the value to be escaped
+	if data.Query != "" && data.Prefix {
+		conds = append(conds, `instr(cmd, ?) = 1`)
+		args = append(args, data.Query)
+	} else if data.Query != "" {
+		conds = append(conds, `instr(cmd, ?) > 0`)
+		args = append(args, data.Query)
+	}
+	if data.Cwd != "" {
+		dirPrefix := data.Cwd
+		if !strings.HasSuffix(dirPrefix, "/") {
+			dirPrefix += "/"
+		}
+		conds = append(conds, `(cwd = ? OR instr(cwd, ?) = 1)`)
+		args = append(args, strings.TrimSuffix(data.Cwd, "/"), dirPrefix)
+	}
+	if data.Connection == localConnName {
+		conds = append(conds, `connection IN ('', ?)`)
+		args = append(args, localConnName)
+	} else if data.Connection != "" {
+		conds = append(conds, `connection = ?`)
+		args = append(args, data.Connection)
+	}
+	if data.Failed {
+		conds = append(conds, `exitcode IS NOT NULL AND exitcode <> 0`)
+	}
+	query := `SELECT * FROM db_history`
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += ` ORDER BY ts DESC, historyid DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, max(data.Offset, 0))
+	return query, args
+}
+
+// returns the matching commands, newest first
+func SearchHistory(ctx context.Context, data wshrpc.CommandSearchHistoryData) (*wshrpc.CommandSearchHistoryRtnData, error) {
+	limit := data.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "limit is too big (max %d)", MaxSearchLimit)
+	}
+	// one extra row, to know if there are more
+	query, args := makeSearchQuery(data, limit+1)
+	rows, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*historyRow, error) {
+		var rows []*historyRow
+		tx.Select(&rows, query, args...)
+		return rows, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching history: %w", err)
+	}
+	rtn := &wshrpc.CommandSearchHistoryRtnData{Records: make([]wshrpc.HistoryRecord, 0, min(len(rows), limit))}
+	for idx, row := range rows {
+		if idx == limit {
+			rtn.HasMore = true
+			break
+		}
+		rtn.Records = append(rtn.Records, row.toRecord())
+	}
+	return rtn, nil
+}
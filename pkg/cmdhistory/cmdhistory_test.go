@@ -0,0 +1,121 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmdhistory
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func initTestStore(t *testing.T) {
+	wavebase.DataHome_VarCache = t.TempDir()
+	err := os.MkdirAll(wavebase.GetWaveDataDir()+"/"+wavebase.WaveDBDir, 0700)
+	if err != nil {
+		t.Fatalf("error creating db dir: %v", err)
+	}
+	err = wstore.InitWStore()
+	if err != nil {
+		t.Fatalf("error initializing wstore: %v", err)
+	}
+}
+
+func searchCmds(t *testing.T, ctx context.Context, data wshrpc.CommandSearchHistoryData) []string {
+	rtn, err := SearchHistory(ctx, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cmds []string
+	for _, record := range rtn.Records {
+		cmds = append(cmds, record.Cmd)
+	}
+	return cmds
+}
+
+func TestHistory(t *testing.T) {
+	initTestStore(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFn()
+	settings := historySettings{maxRows: 5, ignore: getIgnoreRegexps([]string{`^\s`, `^secret`})}
+	exitCode := func(code int) *int { return &code }
+	cmds := []wshrpc.CmdRecord{
+		{Cmd: "ls", Cwd: "/home/user", StartTs: 1000, EndTs: 1010, ExitCode: exitCode(0)},
+		{Cmd: "ls", Cwd: "/home/user", StartTs: 2000, EndTs: 2010, ExitCode: exitCode(0)},
+		{Cmd: " export TOKEN=x", StartTs: 3000, EndTs: 3000, ExitCode: exitCode(0)},
+		{Cmd: "secret-tool lookup", StartTs: 3500, EndTs: 3500, ExitCode: exitCode(0)},
+		{Cmd: "kubectl get pods", Cwd: "/home/user/proj/sub", StartTs: 4000, EndTs: 4500, ExitCode: exitCode(1)},
+		{Cmd: "kubectl apply", Cwd: "/home/user/project", StartTs: 5000, EndTs: 5100, ExitCode: exitCode(0)},
+	}
+	err := addBlockCmds(ctx, settings, "block1", "", cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = addBlockCmds(ctx, settings, "block2", "user@host", []wshrpc.CmdRecord{{Cmd: "kubectl logs", Cwd: "/home/user/proj", StartTs: 6000, EndTs: 7000, ExitCode: exitCode(2)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rtn, err := SearchHistory(ctx, wshrpc.CommandSearchHistoryData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rtn.Records) != 4 || rtn.Records[3].Cmd != "ls" || rtn.Records[3].Count != 2 || rtn.Records[3].Ts != 2000 {
+		t.Fatalf("expected 4 records with the ls runs merged (and the ignored commands left out), got %#v", rtn.Records)
+	}
+	if rtn.Records[0].DurationMs != 1000 || rtn.Records[0].Connection != "user@host" {
+		t.Errorf("bad record %#v", rtn.Records[0])
+	}
+
+	tests := []struct {
+		name     string
+		data     wshrpc.CommandSearchHistoryData
+		expected []string
+	}{
+		{name: "substring", data: wshrpc.CommandSearchHistoryData{Query: "get"}, expected: []string{"kubectl get pods"}},
+		{name: "prefix", data: wshrpc.CommandSearchHistoryData{Query: "kubectl", Prefix: true}, expected: []string{"kubectl logs", "kubectl apply", "kubectl get pods"}},
+		{name: "not prefix", data: wshrpc.CommandSearchHistoryData{Query: "get", Prefix: true}, expected: nil},
+		{name: "cwd", data: wshrpc.CommandSearchHistoryData{Query: "kubectl", Cwd: "/home/user/proj"}, expected: []string{"kubectl logs", "kubectl get pods"}},
+		{name: "failed", data: wshrpc.CommandSearchHistoryData{Failed: true}, expected: []string{"kubectl logs", "kubectl get pods"}},
+		{name: "local", data: wshrpc.CommandSearchHistoryData{Query: "kubectl", Connection: "local"}, expected: []string{"kubectl apply", "kubectl get pods"}},
+		{name: "conn", data: wshrpc.CommandSearchHistoryData{Connection: "user@host"}, expected: []string{"kubectl logs"}},
+		{name: "page", data: wshrpc.CommandSearchHistoryData{Offset: 1, Limit: 2}, expected: []string{"kubectl apply", "kubectl get pods"}},
+	}
+	for _, tc := range tests {
+		if cmds := searchCmds(t, ctx, tc.data); !slices.Equal(cmds, tc.expected) {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, cmds)
+		}
+	}
+	rtn, err = SearchHistory(ctx, wshrpc.CommandSearchHistoryData{Limit: 3})
+	if err != nil || !rtn.HasMore {
+		t.Errorf("expected more records (err %v)", err)
+	}
+
+	// the imported commands (without timestamps) are the oldest, so they are dropped first
+	importData := wshrpc.CommandImportHistoryData{Shell: "bash", Replace: true, Records: []wshrpc.HistoryRecord{{Cmd: "make"}, {Cmd: "make"}, {Cmd: " hidden"}, {Cmd: "vi notes"}, {Cmd: "git status"}}}
+	num, err := importHistory(ctx, settings, importData)
+	if err != nil || num != 4 {
+		t.Fatalf("expected 4 imported commands, got %d (err %v)", num, err)
+	}
+	if cmds := searchCmds(t, ctx, wshrpc.CommandSearchHistoryData{}); !slices.Equal(cmds, []string{"kubectl logs", "kubectl apply", "kubectl get pods", "ls", "git status"}) {
+		t.Errorf("expected the oldest imported commands to be dropped, got %q", cmds)
+	}
+	// importing again replaces the commands imported before
+	importData.Records = []wshrpc.HistoryRecord{{Cmd: "htop", Ts: 8000}}
+	_, err = importHistory(ctx, settings, importData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rtn, err = SearchHistory(ctx, wshrpc.CommandSearchHistoryData{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rtn.Records) != 5 || rtn.Records[0].Cmd != "htop" || rtn.Records[0].Source != "import:bash" || rtn.Records[4].Cmd != "ls" {
+		t.Errorf("expected the re-import to replace the imported commands, got %#v", rtn.Records)
+	}
+}
@@ -4,25 +4,50 @@
 package eventbus
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
 )
 
+var logger = wlog.New("eventbus")
+
 const (
 	WSEvent_ElectronNewWindow       = "electron:newwindow"
 	WSEvent_ElectronCloseWindow     = "electron:closewindow"
 	WSEvent_ElectronUpdateActiveTab = "electron:updateactivetab"
+	WSEvent_ElectronFocusWindow     = "electron:focuswindow"
+	WSEvent_ElectronSetWindowBounds = "electron:setwindowbounds"
 	WSEvent_Rpc                     = "rpc"
+	WSEvent_BlockUpdate             = "blockupdate"     // a block object was updated or deleted (scopes include its parent)
+	WSEvent_BlockMetaUpdate         = "blockmetaupdate" // only the block meta keys that changed (data is waveobj.BlockMetaUpdateData)
+	WSEvent_LayoutAction            = "layoutaction"    // a layout action was queued for a tab (oref is the layout)
+	WSEvent_Dropped                 = "dropped"         // sent to a listener after it dropped events (data is the number of dropped events)
+	WSEvent_Notify                  = "notify"          // show a notification (data is wshrpc.WaveNotificationOptions)
+	WSEvent_FileCreated             = "filecreated"     // a preview stopped waiting for its file (data is wshrpc.FileCreatedData)
+	WSEvent_PreviewReload           = "previewreload"   // a preview's file was rewritten (data is wshrpc.PreviewReloadData)
+	WSEvent_TabTitle                = "tabtitle"        // a tab's computed title changed (data is wshrpc.TabTitleData)
+	WSEvent_ClientUpdate            = "clientupdate"    // the client meta changed (oref is the client, data is the client meta)
+	WSEvent_Config                  = "config"          // the config files changed (data is wconfig.WatcherUpdate)
 )
 
+const DefaultListenerBufferSize = 256
+const WindowEventQueueSize = 1000                // max events queued for a window that is not connected
+const WindowEventQueueTimeout = 30 * time.Second // queues for windows that never connect are dropped after this
+const windowEventSendTimeout = 5 * time.Second
+
 type WSEventType struct {
-	EventType string `json:"eventtype"`
-	ORef      string `json:"oref,omitempty"`
-	Data      any    `json:"data"`
+	EventType string   `json:"eventtype"`
+	ORef      string   `json:"oref,omitempty"`
+	Scopes    []string `json:"scopes,omitempty"`
+	Seq       int64    `json:"seq,omitempty"` // per window sequence number (set by SendEventToWindow), used to detect gaps
+	Data      any      `json:"data"`
 }
 
 type WindowWatchData struct {
@@ -30,9 +55,32 @@ type WindowWatchData struct {
 	TabId      string
 }
 
+// receives the events that match its event types and scopes (see SendEventToListeners)
+type EventListener struct {
+	Id         string
+	EventTypes map[string]bool // "*" matches all event types
+	Scopes     map[string]bool // matched against the event oref and scopes, empty matches everything
+	Ch         chan WSEventType
+	numDropped int
+}
+
+// events for a window that is not connected yet (or whose queue is still being flushed)
+type windowEventQueue struct {
+	Events    []WSEventType
+	Flushing  bool
+	CreatedTs time.Time
+}
+
 var globalLock = &sync.Mutex{}
-var wsMap = make(map[string]*WindowWatchData) // websocketid => WindowWatchData
+var wsMap = make(map[string]*WindowWatchData)           // websocketid => WindowWatchData
+var windowSeqMap = make(map[string]int64)               // windowid => last sequence number
+var windowQueueMap = make(map[string]*windowEventQueue) // windowid => queued events
+
+var listenerLock = &sync.Mutex{}
+var listenerMap = make(map[string]*EventListener) // listenerid => EventListener
+var numListeners atomic.Int32
 
+// registers the websocket channel, events that were queued for the window (see SendEventToWindow) are flushed to it in order
 func RegisterWSChannel(connId string, tabId string, ch chan any) {
 	globalLock.Lock()
 	defer globalLock.Unlock()
@@ -40,17 +88,36 @@ func RegisterWSChannel(connId string, tabId string, ch chan any) {
 		WindowWSCh: ch,
 		TabId:      tabId,
 	}
+	queue := windowQueueMap[tabId]
+	if queue != nil && !queue.Flushing {
+		queue.Flushing = true
+		go flushWindowQueue(tabId, ch)
+	}
 }
 
 func UnregisterWSChannel(connId string) {
 	globalLock.Lock()
 	defer globalLock.Unlock()
+	wdata := wsMap[connId]
 	delete(wsMap, connId)
+	if wdata != nil && windowQueueMap[wdata.TabId] == nil && len(getWindowWatchesForWindowId_nolock(wdata.TabId)) == 0 {
+		// last connection for the window is gone (and nothing is queued for it)
+		delete(windowSeqMap, wdata.TabId)
+	}
+}
+
+// returns true if the window (or tab) has at least one websocket connection
+func IsWindowConnected(windowId string) bool {
+	return len(getWindowWatchesForWindowId(windowId)) > 0
 }
 
 func getWindowWatchesForWindowId(windowId string) []*WindowWatchData {
 	globalLock.Lock()
 	defer globalLock.Unlock()
+	return getWindowWatchesForWindowId_nolock(windowId)
+}
+
+func getWindowWatchesForWindowId_nolock(windowId string) []*WindowWatchData {
 	var watches []*WindowWatchData
 	for _, wdata := range wsMap {
 		if wdata.TabId == windowId {
@@ -60,6 +127,164 @@ func getWindowWatchesForWindowId(windowId string) []*WindowWatchData {
 	return watches
 }
 
+// sends the event over the window's websocket connection(s).
+// if the window is not connected yet, the event is queued (up to WindowEventQueueSize events, the oldest are dropped)
+// and sent once the window connects. queues are dropped if the window doesn't connect within WindowEventQueueTimeout.
+func SendEventToWindow(windowId string, event WSEventType) {
+	globalLock.Lock()
+	windowSeqMap[windowId]++
+	event.Seq = windowSeqMap[windowId]
+	watches := getWindowWatchesForWindowId_nolock(windowId)
+	queue := windowQueueMap[windowId]
+	if len(watches) == 0 || queue != nil {
+		if queue == nil {
+			queue = &windowEventQueue{CreatedTs: time.Now()}
+			windowQueueMap[windowId] = queue
+			time.AfterFunc(WindowEventQueueTimeout, func() { expireWindowQueue(windowId, queue) })
+		}
+		if len(queue.Events) >= WindowEventQueueSize {
+			queue.Events = queue.Events[1:]
+		}
+		queue.Events = append(queue.Events, event)
+		globalLock.Unlock()
+		return
+	}
+	globalLock.Unlock()
+	for _, wdata := range watches {
+		sendToWSChannel(wdata.WindowWSCh, event)
+	}
+}
+
+// sends the event to every connected window (and to windows that are still connecting, see SendEventToWindow)
+func SendEventToAllWindows(event WSEventType) {
+	globalLock.Lock()
+	windowIds := make(map[string]bool)
+	for _, wdata := range wsMap {
+		windowIds[wdata.TabId] = true
+	}
+	for windowId := range windowQueueMap {
+		windowIds[windowId] = true
+	}
+	globalLock.Unlock()
+	for windowId := range windowIds {
+		SendEventToWindow(windowId, event)
+	}
+}
+
+// returns the id of the window that is showing the tab ("" if the tab isn't open in a window).
+// set by the server at startup (wstore.DBFindWindowForTabId), eventbus doesn't depend on the db.
+var FindWindowForTabId func(ctx context.Context, tabId string) (string, error)
+
+// adds the tab scope to the event and sends it to the tab's connection, or (if the tab has no connection of its own)
+// to the window that the tab is currently in. the window is looked up on every call, so the event follows the tab
+// when it is moved to another window.
+func SendEventToTab(tabId string, event WSEventType) error {
+	event.Scopes = append(append([]string(nil), event.Scopes...), waveobj.MakeORef(waveobj.OType_Tab, tabId).String())
+	if len(getWindowWatchesForWindowId(tabId)) > 0 {
+		SendEventToWindow(tabId, event)
+		return nil
+	}
+	if FindWindowForTabId == nil {
+		return fmt.Errorf("error finding window for tab %s: no window lookup set", tabId)
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	windowId, err := FindWindowForTabId(ctx, tabId)
+	if err != nil {
+		return fmt.Errorf("error finding window for tab %s: %w", tabId, err)
+	}
+	if windowId == "" {
+		windowId = tabId
+	}
+	SendEventToWindow(windowId, event)
+	return nil
+}
+
+func sendToWSChannel(ch chan any, event WSEventType) bool {
+	select {
+	case ch <- event:
+		return true
+	case <-time.After(windowEventSendTimeout):
+		logger.Warn(context.Background(), "timeout sending event to window, dropping event", "event", event.EventType)
+		return false
+	}
+}
+
+func flushWindowQueue(windowId string, ch chan any) {
+	for {
+		globalLock.Lock()
+		queue := windowQueueMap[windowId]
+		if queue == nil || len(queue.Events) == 0 {
+			delete(windowQueueMap, windowId)
+			if len(getWindowWatchesForWindowId_nolock(windowId)) == 0 {
+				// the connection was unregistered while the queue was being flushed
+				delete(windowSeqMap, windowId)
+			}
+			globalLock.Unlock()
+			return
+		}
+		events := queue.Events
+		queue.Events = nil
+		globalLock.Unlock()
+		for _, event := range events {
+			if !sendToWSChannel(ch, event) {
+				// the connection is gone (or stuck), drop the rest of the queue
+				globalLock.Lock()
+				delete(windowQueueMap, windowId)
+				if len(getWindowWatchesForWindowId_nolock(windowId)) == 0 {
+					delete(windowSeqMap, windowId)
+				}
+				globalLock.Unlock()
+				return
+			}
+		}
+	}
+}
+
+func expireWindowQueue(windowId string, queue *windowEventQueue) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	if windowQueueMap[windowId] != queue || queue.Flushing {
+		return
+	}
+	logger.Warn(context.Background(), "window never connected, dropping queued events", "windowid", windowId, "count", len(queue.Events))
+	delete(windowQueueMap, windowId)
+	delete(windowSeqMap, windowId)
+}
+
+type EventBusStats struct {
+	NumWebSockets        int `json:"numwebsockets"`
+	NumConnectedWindows  int `json:"numconnectedwindows"`
+	NumWindowQueues      int `json:"numwindowqueues"`    // windows that are not connected yet (or still being flushed)
+	WindowQueuedEvents   int `json:"windowqueuedevents"` // events waiting in the window queues
+	NumListeners         int `json:"numlisteners"`
+	ListenerQueuedEvents int `json:"listenerqueuedevents"` // events buffered in listener channels, not read yet
+}
+
+// the locks are only held for in memory updates (never while sending), so this doesn't block
+func GetStats() EventBusStats {
+	var rtn EventBusStats
+	globalLock.Lock()
+	windowIds := make(map[string]bool)
+	for _, wdata := range wsMap {
+		windowIds[wdata.TabId] = true
+	}
+	rtn.NumWebSockets = len(wsMap)
+	rtn.NumConnectedWindows = len(windowIds)
+	rtn.NumWindowQueues = len(windowQueueMap)
+	for _, queue := range windowQueueMap {
+		rtn.WindowQueuedEvents += len(queue.Events)
+	}
+	globalLock.Unlock()
+	listenerLock.Lock()
+	rtn.NumListeners = len(listenerMap)
+	for _, listener := range listenerMap {
+		rtn.ListenerQueuedEvents += len(listener.Ch)
+	}
+	listenerLock.Unlock()
+	return rtn
+}
+
 // TODO fix busy wait -- but we need to wait until a new window connects back with a websocket
 // returns true if the window is connected
 func BusyWaitForWindowId(windowId string, timeout time.Duration) bool {
@@ -75,13 +300,105 @@ func BusyWaitForWindowId(windowId string, timeout time.Duration) bool {
 	}
 }
 
+// registers a listener with a channel that buffers up to bufSize events (DefaultListenerBufferSize if bufSize <= 0).
+// the listener must be removed with UnregisterListener (which closes the channel).
+func RegisterListener(id string, eventTypes []string, scopes []string, bufSize int) *EventListener {
+	if bufSize <= 0 {
+		bufSize = DefaultListenerBufferSize
+	}
+	listener := &EventListener{
+		Id:         id,
+		EventTypes: make(map[string]bool),
+		Scopes:     make(map[string]bool),
+		Ch:         make(chan WSEventType, bufSize),
+	}
+	for _, eventType := range eventTypes {
+		listener.EventTypes[eventType] = true
+	}
+	for _, scope := range scopes {
+		listener.Scopes[scope] = true
+	}
+	listenerLock.Lock()
+	defer listenerLock.Unlock()
+	if oldListener := listenerMap[id]; oldListener != nil {
+		close(oldListener.Ch)
+	}
+	listenerMap[id] = listener
+	numListeners.Store(int32(len(listenerMap)))
+	return listener
+}
+
+func UnregisterListener(id string) {
+	listenerLock.Lock()
+	defer listenerLock.Unlock()
+	listener := listenerMap[id]
+	if listener == nil {
+		return
+	}
+	delete(listenerMap, id)
+	close(listener.Ch)
+	numListeners.Store(int32(len(listenerMap)))
+}
+
+func HasListeners() bool {
+	return numListeners.Load() > 0
+}
+
+func (listener *EventListener) matches(event WSEventType) bool {
+	if !listener.EventTypes["*"] && !listener.EventTypes[event.EventType] {
+		return false
+	}
+	if len(listener.Scopes) == 0 {
+		return true
+	}
+	if event.ORef != "" && listener.Scopes[event.ORef] {
+		return true
+	}
+	for _, scope := range event.Scopes {
+		if listener.Scopes[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// never blocks, if a listener's buffer is full the event is dropped for that listener
+// (the listener gets a "dropped" event once there is room in its buffer again)
+func SendEventToListeners(event WSEventType) {
+	if !HasListeners() {
+		return
+	}
+	listenerLock.Lock()
+	defer listenerLock.Unlock()
+	for _, listener := range listenerMap {
+		if !listener.matches(event) {
+			continue
+		}
+		if listener.numDropped > 0 {
+			select {
+			case listener.Ch <- WSEventType{EventType: WSEvent_Dropped, Data: listener.numDropped}:
+				listener.numDropped = 0
+			default:
+				listener.numDropped++
+				continue
+			}
+		}
+		select {
+		case listener.Ch <- event:
+		default:
+			listener.numDropped++
+		}
+	}
+}
+
 func SendEventToElectron(event WSEventType) {
+	SendEventToListeners(event)
 	barr, err := json.Marshal(event)
 	if err != nil {
-		log.Printf("cannot marshal electron message: %v\n", err)
+		logger.Error(context.Background(), "cannot marshal electron message", "err", err)
 		return
 	}
 	// send to electron
-	log.Printf("sending event to electron: %q\n", event.EventType)
+	logger.Debug(context.Background(), "sending event to electron", "event", event.EventType)
 	fmt.Fprintf(os.Stderr, "\nWAVESRV-EVENT:%s\n", string(barr))
 }
@@ -0,0 +1,150 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package eventbus
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func resetEventBus(t *testing.T) {
+	globalLock.Lock()
+	wsMap = make(map[string]*WindowWatchData)
+	windowSeqMap = make(map[string]int64)
+	windowQueueMap = make(map[string]*windowEventQueue)
+	globalLock.Unlock()
+	oldFindFn := FindWindowForTabId
+	t.Cleanup(func() {
+		FindWindowForTabId = oldFindFn
+	})
+}
+
+func recvEvent(t *testing.T, ch chan any) WSEventType {
+	t.Helper()
+	select {
+	case val := <-ch:
+		event, ok := val.(WSEventType)
+		if !ok {
+			t.Fatalf("expected WSEventType, got %T", val)
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("timeout waiting for event")
+	}
+	return WSEventType{}
+}
+
+func expectNoEvent(t *testing.T, ch chan any) {
+	t.Helper()
+	select {
+	case val := <-ch:
+		t.Fatalf("expected no event, got %v", val)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSendEventToWindow_MidRegistration(t *testing.T) {
+	resetEventBus(t)
+	SendEventToWindow("win1", WSEventType{EventType: "test", Data: 1})
+	SendEventToWindow("win1", WSEventType{EventType: "test", Data: 2})
+	ch := make(chan any, 10)
+	RegisterWSChannel("conn1", "win1", ch)
+	defer UnregisterWSChannel("conn1")
+	SendEventToWindow("win1", WSEventType{EventType: "test", Data: 3})
+	for i := 1; i <= 3; i++ {
+		event := recvEvent(t, ch)
+		if event.Data != i || event.Seq != int64(i) {
+			t.Errorf("expected event %d (seq %d), got data %v (seq %d)", i, i, event.Data, event.Seq)
+		}
+	}
+}
+
+func TestSendEventToAllWindows(t *testing.T) {
+	resetEventBus(t)
+	ch1 := make(chan any, 10)
+	RegisterWSChannel("conn1", "win1", ch1)
+	defer UnregisterWSChannel("conn1")
+	// win2 is still connecting (it has queued events)
+	SendEventToWindow("win2", WSEventType{EventType: "first"})
+	SendEventToAllWindows(WSEventType{EventType: "all"})
+	if event := recvEvent(t, ch1); event.EventType != "all" {
+		t.Errorf("expected all event for win1, got %q", event.EventType)
+	}
+	ch2 := make(chan any, 10)
+	RegisterWSChannel("conn2", "win2", ch2)
+	defer UnregisterWSChannel("conn2")
+	if event := recvEvent(t, ch2); event.EventType != "first" {
+		t.Errorf("expected first event for win2, got %q", event.EventType)
+	}
+	if event := recvEvent(t, ch2); event.EventType != "all" || event.Seq != 2 {
+		t.Errorf("expected all event (seq 2) for win2, got %q (seq %d)", event.EventType, event.Seq)
+	}
+}
+
+func TestSendEventToTab_MovedTab(t *testing.T) {
+	resetEventBus(t)
+	tabWindow := "win1"
+	FindWindowForTabId = func(ctx context.Context, tabId string) (string, error) {
+		return tabWindow, nil
+	}
+	ch1 := make(chan any, 10)
+	RegisterWSChannel("conn1", "win1", ch1)
+	defer UnregisterWSChannel("conn1")
+	ch2 := make(chan any, 10)
+	RegisterWSChannel("conn2", "win2", ch2)
+	defer UnregisterWSChannel("conn2")
+
+	err := SendEventToTab("tab1", WSEventType{EventType: "test"})
+	if err != nil {
+		t.Fatalf("error sending event to tab: %v", err)
+	}
+	event := recvEvent(t, ch1)
+	if len(event.Scopes) != 1 || event.Scopes[0] != "tab:tab1" {
+		t.Errorf("expected tab scope, got %v", event.Scopes)
+	}
+	expectNoEvent(t, ch2)
+
+	tabWindow = "win2"
+	err = SendEventToTab("tab1", WSEventType{EventType: "test"})
+	if err != nil {
+		t.Fatalf("error sending event to tab: %v", err)
+	}
+	recvEvent(t, ch2)
+	expectNoEvent(t, ch1)
+}
+
+func TestSendEventToTab_TabConnection(t *testing.T) {
+	resetEventBus(t)
+	FindWindowForTabId = func(ctx context.Context, tabId string) (string, error) {
+		return "win1", nil
+	}
+	winCh := make(chan any, 10)
+	RegisterWSChannel("conn1", "win1", winCh)
+	defer UnregisterWSChannel("conn1")
+	tabCh := make(chan any, 10)
+	RegisterWSChannel("conn2", "tab1", tabCh)
+	defer UnregisterWSChannel("conn2")
+	err := SendEventToTab("tab1", WSEventType{EventType: "test"})
+	if err != nil {
+		t.Fatalf("error sending event to tab: %v", err)
+	}
+	recvEvent(t, tabCh)
+	expectNoEvent(t, winCh)
+}
+
+func TestWindowSeqRemovedOnDisconnect(t *testing.T) {
+	resetEventBus(t)
+	ch := make(chan any, 10)
+	RegisterWSChannel("conn1", "win1", ch)
+	SendEventToWindow("win1", WSEventType{EventType: "test"})
+	recvEvent(t, ch)
+	UnregisterWSChannel("conn1")
+	globalLock.Lock()
+	_, found := windowSeqMap["win1"]
+	globalLock.Unlock()
+	if found {
+		t.Errorf("expected the window sequence to be removed with its last connection")
+	}
+}
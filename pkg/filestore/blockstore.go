@@ -58,6 +58,9 @@ type FileOptsType struct {
 
 type FileMeta = map[string]any
 
+// the offset of the first byte a circular file returns (set by TrimHead, the data before it is ignored)
+const FileMeta_HeadOffset = "headoffset"
+
 type WaveFile struct {
 	// these fields are static (not updated)
 	ZoneId    string       `json:"zoneid"`
@@ -72,19 +75,39 @@ type WaveFile struct {
 }
 
 // for regular files this is just Size
-// for circular files this is min(Size, MaxSize)
+// for circular files this is the amount of data after DataStartIdx (at most MaxSize)
 func (f WaveFile) DataLength() int64 {
 	if f.Opts.Circular {
-		return minInt64(f.Size, f.Opts.MaxSize)
+		return f.Size - f.DataStartIdx()
 	}
 	return f.Size
 }
 
 // for regular files this is just 0
-// for circular files this is the index of the first byte of data we have
+// for circular files this is the index of the first byte of data we have (or the head offset if it was trimmed)
 func (f WaveFile) DataStartIdx() int64 {
-	if f.Opts.Circular && f.Size > f.Opts.MaxSize {
-		return f.Size - f.Opts.MaxSize
+	if !f.Opts.Circular {
+		return 0
+	}
+	var startIdx int64
+	if f.Size > f.Opts.MaxSize {
+		startIdx = f.Size - f.Opts.MaxSize
+	}
+	if headOffset := MetaInt64(f.Meta, FileMeta_HeadOffset); headOffset > startIdx {
+		startIdx = minInt64(headOffset, f.Size)
+	}
+	return startIdx
+}
+
+// meta values are float64 once they are loaded from the db (and int64 when they were set by the server)
+func MetaInt64(meta FileMeta, key string) int64 {
+	switch val := meta[key].(type) {
+	case int64:
+		return val
+	case int:
+		return int64(val)
+	case float64:
+		return int64(val)
 	}
 	return 0
 }
@@ -287,6 +310,32 @@ func (s *FileStore) AppendData(ctx context.Context, zoneId string, name string,
 	})
 }
 
+// moves the start of a circular file forward to offset, reads no longer return the data before it.  offset is
+// clamped to the file's data, returns the new start.
+func (s *FileStore) TrimHead(ctx context.Context, zoneId string, name string, offset int64) (int64, error) {
+	return withLockRtn(s, zoneId, name, func(entry *CacheEntry) (int64, error) {
+		err := entry.loadFileIntoCache(ctx)
+		if err != nil {
+			return 0, err
+		}
+		file := entry.File
+		if !file.Opts.Circular {
+			return 0, fmt.Errorf("only circular files can be trimmed")
+		}
+		startIdx := file.DataStartIdx()
+		if offset <= startIdx {
+			return startIdx, nil
+		}
+		offset = minInt64(offset, file.Size)
+		if file.Meta == nil {
+			file.Meta = make(FileMeta)
+		}
+		file.Meta[FileMeta_HeadOffset] = offset
+		file.ModTs = time.Now().UnixMilli()
+		return offset, nil
+	})
+}
+
 func metaIncrement(file *WaveFile, key string, amount int) int {
 	if file.Meta == nil {
 		file.Meta = make(FileMeta)
@@ -159,6 +159,7 @@ func (dce *DataCacheEntry) writeToPart(offset int64, data []byte) (int64, *DataC
 func (entry *CacheEntry) writeAt(offset int64, data []byte, replace bool) {
 	if replace {
 		entry.File.Size = 0
+		delete(entry.File.Meta, FileMeta_HeadOffset)
 	}
 	if entry.File.Opts.Circular {
 		startCirFileOffset := entry.File.Size - entry.File.Opts.MaxSize
@@ -218,10 +219,7 @@ func (entry *CacheEntry) readAt(ctx context.Context, offset int64, size int64, r
 		size = file.Size - offset
 	}
 	if file.Opts.Circular {
-		realDataOffset := int64(0)
-		if file.Size > file.Opts.MaxSize {
-			realDataOffset = file.Size - file.Opts.MaxSize
-		}
+		realDataOffset := file.DataStartIdx()
 		if offset < realDataOffset {
 			truncateAmt := realDataOffset - offset
 			offset += truncateAmt
@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
 	"github.com/wavetermdev/waveterm/pkg/util/migrateutil"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 
@@ -28,6 +29,7 @@ const FilestoreDBName = "filestore.db"
 type TxWrap = txwrap.TxWrap
 
 var globalDB *sqlx.DB
+var dbHealth dbutil.HealthTracker
 var useTestingDb bool // just for testing (forces GetDB() to return an in-memory db)
 
 func InitFilestore() error {
@@ -49,6 +51,29 @@ func InitFilestore() error {
 	return nil
 }
 
+func GetDBVersionInfo() (*migrateutil.VersionInfo, error) {
+	if globalDB == nil {
+		return nil, fmt.Errorf("filestore not initialized")
+	}
+	return migrateutil.GetVersionInfo("filestore", globalDB.DB, dbfs.FilestoreMigrationFS, "migrations-filestore")
+}
+
+// reverts the most recent filestore migration (for downgrading Wave).  only for when the server is not running (see
+// "wavesrv --rollback-db"), it opens the db itself and fails if the filestore was initialized in this process.
+func RollbackLastMigration() (uint, error) {
+	if globalDB != nil {
+		return 0, fmt.Errorf("cannot roll back the filestore while it is open")
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	db, err := MakeDB(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	return migrateutil.MigrateDownOne("filestore", db.DB, dbfs.FilestoreMigrationFS, "migrations-filestore")
+}
+
 func GetDBName() string {
 	waveHome := wavebase.GetWaveDataDir()
 	return filepath.Join(waveHome, wavebase.WaveDBDir, FilestoreDBName)
@@ -73,10 +98,26 @@ func MakeDB(ctx context.Context) (*sqlx.DB, error) {
 	return rtn, nil
 }
 
-func WithTx(ctx context.Context, fn func(tx *TxWrap) error) error {
-	return txwrap.WithTx(ctx, globalDB, fn)
+func WithTx(ctx context.Context, fn func(tx *TxWrap) error) (rtnErr error) {
+	trackedFn, doneFn := dbHealth.TrackTx(fn)
+	defer func() { doneFn(rtnErr) }()
+	return txwrap.WithTx(ctx, globalDB, trackedFn)
+}
+
+func WithTxRtn[RT any](ctx context.Context, fn func(tx *TxWrap) (RT, error)) (rtnVal RT, rtnErr error) {
+	trackedFn, doneFn := dbHealth.TrackTx(func(tx *TxWrap) error {
+		val, err := fn(tx)
+		if err == nil {
+			rtnVal = val
+		}
+		return err
+	})
+	defer func() { doneFn(rtnErr) }()
+	rtnErr = txwrap.WithTx(ctx, globalDB, trackedFn)
+	return rtnVal, rtnErr
 }
 
-func WithTxRtn[RT any](ctx context.Context, fn func(tx *TxWrap) (RT, error)) (RT, error) {
-	return txwrap.WithTxRtn(ctx, globalDB, fn)
+// doesn't touch the db (see dbutil.HealthTracker), so it can't block when the db is locked
+func GetDBHealth() (bool, dbutil.HealthStatus) {
+	return globalDB != nil, dbHealth.GetStatus(time.Now())
 }
@@ -466,6 +466,61 @@ func TestCircularWrites(t *testing.T) {
 	}
 }
 
+func TestTrimHead(t *testing.T) {
+	initDb(t)
+	defer cleanupDb(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	zoneId := uuid.NewString()
+	err := WFS.MakeFile(ctx, zoneId, "c1", nil, FileOptsType{Circular: true, MaxSize: 50})
+	if err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	err = WFS.AppendData(ctx, zoneId, "c1", []byte("line1\nline2\nline3\n"))
+	if err != nil {
+		t.Fatalf("error appending data: %v", err)
+	}
+	startIdx, err := WFS.TrimHead(ctx, zoneId, "c1", 6)
+	if err != nil || startIdx != 6 {
+		t.Fatalf("error trimming file: %d %v", startIdx, err)
+	}
+	checkFileData(t, ctx, zoneId, "c1", "line2\nline3\n")
+	// can't move backwards
+	startIdx, _ = WFS.TrimHead(ctx, zoneId, "c1", 2)
+	if startIdx != 6 {
+		t.Errorf("start mismatch: expected 6, got %d", startIdx)
+	}
+	// the head offset is kept when the file is reloaded from the db
+	_, err = WFS.FlushCache(ctx)
+	if err != nil {
+		t.Fatalf("error flushing cache: %v", err)
+	}
+	WFS.clearCache()
+	file, err := WFS.Stat(ctx, zoneId, "c1")
+	if err != nil {
+		t.Fatalf("error stating file: %v", err)
+	}
+	if file.DataStartIdx() != 6 || file.DataLength() != 12 {
+		t.Errorf("start/length mismatch: expected 6/12, got %d/%d", file.DataStartIdx(), file.DataLength())
+	}
+	checkFileData(t, ctx, zoneId, "c1", "line2\nline3\n")
+	// the ring buffer moves the start past the head offset
+	err = WFS.AppendData(ctx, zoneId, "c1", []byte(makeText(40)))
+	if err != nil {
+		t.Fatalf("error appending data: %v", err)
+	}
+	offset, _, _ := WFS.ReadFile(ctx, zoneId, "c1")
+	if offset != 8 {
+		t.Errorf("offset mismatch: expected 8, got %d", offset)
+	}
+	// replacing the contents clears the head offset
+	err = WFS.WriteFile(ctx, zoneId, "c1", []byte("new"))
+	if err != nil {
+		t.Fatalf("error writing data: %v", err)
+	}
+	checkFileData(t, ctx, zoneId, "c1", "new")
+}
+
 func makeText(n int) string {
 	var buf bytes.Buffer
 	for i := 0; i < n; i++ {
@@ -0,0 +1,377 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// the files and directories shown by preview blocks are watched so the blocks refresh when they change.  local
+// paths share one fsnotify watcher, a file is watched through its directory (so editors that save by renaming a new
+// file over the old one still trigger a refresh).  after MaxFsWatches directories (or when the os limit is hit, e.g.
+// fs.inotify.max_user_watches) local paths are polled instead.  paths on a connection are always polled.
+package filewatch
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const (
+	// changes are sent at most once per DebounceTime (the first change starts the timer, so a file that is
+	// written continuously, like a log, still refreshes)
+	DebounceTime              = 250 * time.Millisecond
+	MaxFsWatches              = 256
+	LocalPollInterval         = 2 * time.Second
+	DefaultRemotePollInterval = 5 * time.Second
+	MinRemotePollInterval     = 500 * time.Millisecond
+)
+
+// what is compared when a path is polled
+type FileStat struct {
+	ModTime  int64
+	Size     int64
+	IsDir    bool
+	NotFound bool
+}
+
+type ManagerOpts struct {
+	MaxFsWatches       int
+	LocalPollInterval  time.Duration
+	RemotePollInterval func() time.Duration
+	RemoteStat         func(conn string, path string) (*FileStat, error)
+	Notify             func(blockIds []string, conn string, path string)
+}
+
+type watchKey struct {
+	conn string
+	path string // local paths are expanded and cleaned
+}
+
+type watch struct {
+	key      watchKey
+	blockIds map[string]bool
+	isDir    bool
+	fsDir    string        // the directory watched with fsnotify ("" if the path is polled)
+	stopCh   chan struct{} // stops the poll loop (nil if the path isn't polled)
+	pollCh   chan struct{} // polls the path now (see PollConn)
+	timer    *time.Timer   // the debounce timer (nil if no change is pending)
+}
+
+type Manager struct {
+	lock      sync.Mutex
+	opts      ManagerOpts
+	fsWatcher *fsnotify.Watcher // nil if it couldn't be created (then everything is polled)
+	fsDirs    map[string]int    // the number of watches that use each fsnotify directory
+	watches   map[watchKey]*watch
+	blocks    map[string]watchKey
+}
+
+func MakeManager(opts ManagerOpts) *Manager {
+	if opts.MaxFsWatches <= 0 {
+		opts.MaxFsWatches = MaxFsWatches
+	}
+	if opts.LocalPollInterval <= 0 {
+		opts.LocalPollInterval = LocalPollInterval
+	}
+	if opts.RemotePollInterval == nil {
+		opts.RemotePollInterval = func() time.Duration { return DefaultRemotePollInterval }
+	}
+	m := &Manager{
+		opts:    opts,
+		fsDirs:  make(map[string]int),
+		watches: make(map[watchKey]*watch),
+		blocks:  make(map[string]watchKey),
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("filewatch: cannot create watcher, files will be polled: %v\n", err)
+		return m
+	}
+	m.fsWatcher = fsWatcher
+	go m.runFsEvents(fsWatcher)
+	return m
+}
+
+func isLocalConn(conn string) bool {
+	return conn == "" || conn == wshrpc.LocalConnName
+}
+
+func makeWatchKey(conn string, filePath string) (watchKey, error) {
+	if isLocalConn(conn) {
+		localPath, err := wavebase.ExpandHomeDir(filePath)
+		if err != nil {
+			return watchKey{}, err
+		}
+		return watchKey{conn: wshrpc.LocalConnName, path: filepath.Clean(localPath)}, nil
+	}
+	return watchKey{conn: conn, path: path.Clean(filePath)}, nil
+}
+
+// watches the path for the block (a block watches one path, a new path replaces the old one)
+func (m *Manager) Watch(blockId string, conn string, filePath string) error {
+	key, err := makeWatchKey(conn, filePath)
+	if err != nil {
+		return err
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if oldKey, ok := m.blocks[blockId]; ok {
+		if oldKey == key {
+			return nil
+		}
+		m.unwatchLocked(blockId)
+	}
+	w := m.watches[key]
+	if w == nil {
+		w = m.startWatchLocked(key)
+		m.watches[key] = w
+	}
+	w.blockIds[blockId] = true
+	m.blocks[blockId] = key
+	return nil
+}
+
+// releases the block's watch (a no-op if the block isn't watching anything)
+func (m *Manager) Unwatch(blockId string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.unwatchLocked(blockId)
+}
+
+// the number of watched paths (blocks showing the same path share a watch)
+func (m *Manager) NumWatches() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.watches)
+}
+
+// the number of directories watched with fsnotify
+func (m *Manager) NumFsWatches() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.fsDirs)
+}
+
+// polls the paths on the connection now instead of waiting for the poll interval (used when a connection comes
+// back, the changes made while it was down are picked up right away)
+func (m *Manager) PollConn(conn string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, w := range m.watches {
+		if key.conn != conn || w.pollCh == nil {
+			continue
+		}
+		select {
+		case w.pollCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (m *Manager) Close() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for blockId := range m.blocks {
+		m.unwatchLocked(blockId)
+	}
+	if m.fsWatcher != nil {
+		m.fsWatcher.Close()
+		m.fsWatcher = nil
+	}
+}
+
+func (m *Manager) startWatchLocked(key watchKey) *watch {
+	w := &watch{key: key, blockIds: make(map[string]bool)}
+	if isLocalConn(key.conn) {
+		finfo, err := os.Stat(key.path)
+		w.isDir = err == nil && finfo.IsDir()
+		fsDir := filepath.Dir(key.path)
+		if w.isDir {
+			fsDir = key.path
+		}
+		if m.addFsDirLocked(fsDir) {
+			w.fsDir = fsDir
+			return w
+		}
+	}
+	w.stopCh = make(chan struct{})
+	w.pollCh = make(chan struct{}, 1)
+	pollInterval := m.opts.RemotePollInterval
+	if isLocalConn(key.conn) {
+		pollInterval = func() time.Duration { return m.opts.LocalPollInterval }
+	}
+	go m.runPoll(w, pollInterval)
+	return w
+}
+
+func (m *Manager) unwatchLocked(blockId string) {
+	key, ok := m.blocks[blockId]
+	if !ok {
+		return
+	}
+	delete(m.blocks, blockId)
+	w := m.watches[key]
+	if w == nil {
+		return
+	}
+	delete(w.blockIds, blockId)
+	if len(w.blockIds) > 0 {
+		return
+	}
+	delete(m.watches, key)
+	if w.fsDir != "" {
+		m.removeFsDirLocked(w.fsDir)
+	}
+	if w.stopCh != nil {
+		close(w.stopCh)
+	}
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// false if the directory can't be watched with fsnotify (the caller polls instead)
+func (m *Manager) addFsDirLocked(dir string) bool {
+	if m.fsWatcher == nil {
+		return false
+	}
+	if m.fsDirs[dir] > 0 {
+		m.fsDirs[dir]++
+		return true
+	}
+	if len(m.fsDirs) >= m.opts.MaxFsWatches {
+		return false
+	}
+	err := m.fsWatcher.Add(dir)
+	if err != nil {
+		if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) {
+			log.Printf("filewatch: out of watches (raise fs.inotify.max_user_watches), polling %q\n", dir)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("filewatch: cannot watch %q, polling it: %v\n", dir, err)
+		}
+		return false
+	}
+	m.fsDirs[dir] = 1
+	return true
+}
+
+func (m *Manager) removeFsDirLocked(dir string) {
+	m.fsDirs[dir]--
+	if m.fsDirs[dir] > 0 {
+		return
+	}
+	delete(m.fsDirs, dir)
+	if m.fsWatcher != nil {
+		// fails if the directory was removed (its watch is already gone)
+		m.fsWatcher.Remove(dir)
+	}
+}
+
+func (m *Manager) runFsEvents(fsWatcher *fsnotify.Watcher) {
+	defer func() {
+		panichandler.PanicHandler("filewatch:runFsEvents", recover())
+	}()
+	for {
+		select {
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			m.handleFsEvent(filepath.Clean(event.Name))
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("filewatch: watcher error: %v\n", err)
+		}
+	}
+}
+
+func (m *Manager) handleFsEvent(name string) {
+	dir := filepath.Dir(name)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, w := range m.watches {
+		if w.fsDir == "" {
+			continue
+		}
+		// a directory changes when one of its entries does (or it is removed itself)
+		if w.key.path == name || (w.isDir && w.key.path == dir) {
+			m.changedLocked(w)
+		}
+	}
+}
+
+func (m *Manager) stat(key watchKey) (*FileStat, error) {
+	if !isLocalConn(key.conn) {
+		return m.opts.RemoteStat(key.conn, key.path)
+	}
+	finfo, err := os.Stat(key.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &FileStat{NotFound: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &FileStat{ModTime: finfo.ModTime().UnixMilli(), Size: finfo.Size(), IsDir: finfo.IsDir()}, nil
+}
+
+func (m *Manager) runPoll(w *watch, pollInterval func() time.Duration) {
+	defer func() {
+		panichandler.PanicHandler("filewatch:runPoll", recover())
+	}()
+	// if the stat fails (e.g. the connection is down) the last stat is kept
+	lastStat, _ := m.stat(w.key)
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case <-w.pollCh:
+		case <-time.After(pollInterval()):
+		}
+		curStat, err := m.stat(w.key)
+		if err != nil {
+			continue
+		}
+		if lastStat != nil && *curStat != *lastStat {
+			m.lock.Lock()
+			if m.watches[w.key] == w {
+				m.changedLocked(w)
+			}
+			m.lock.Unlock()
+		}
+		lastStat = curStat
+	}
+}
+
+func (m *Manager) changedLocked(w *watch) {
+	if w.timer != nil {
+		return
+	}
+	w.timer = time.AfterFunc(DebounceTime, func() {
+		m.lock.Lock()
+		if m.watches[w.key] != w {
+			m.lock.Unlock()
+			return
+		}
+		w.timer = nil
+		blockIds := make([]string, 0, len(w.blockIds))
+		for blockId := range w.blockIds {
+			blockIds = append(blockIds, blockId)
+		}
+		m.lock.Unlock()
+		if m.opts.Notify != nil {
+			m.opts.Notify(blockIds, w.key.conn, w.key.path)
+		}
+	})
+}
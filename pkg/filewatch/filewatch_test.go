@@ -0,0 +1,91 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filewatch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func makeTestManager(t *testing.T, maxFsWatches int) (*Manager, chan []string) {
+	notifyCh := make(chan []string, 10)
+	m := MakeManager(ManagerOpts{
+		MaxFsWatches:      maxFsWatches,
+		LocalPollInterval: 50 * time.Millisecond,
+		Notify: func(blockIds []string, conn string, path string) {
+			sort.Strings(blockIds)
+			notifyCh <- blockIds
+		},
+	})
+	t.Cleanup(m.Close)
+	return m, notifyCh
+}
+
+func waitNotify(t *testing.T, notifyCh chan []string) []string {
+	select {
+	case blockIds := <-notifyCh:
+		return blockIds
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change notification")
+		return nil
+	}
+}
+
+func TestWatchRelease(t *testing.T) {
+	m, notifyCh := makeTestManager(t, 0)
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "a.log")
+	os.WriteFile(fileA, []byte("a"), 0644)
+	m.Watch("block1", "", fileA)
+	m.Watch("block2", "local", fileA)
+	m.Watch("block3", "", dir)
+	if m.NumWatches() != 2 {
+		t.Fatalf("expected 2 watches, got %d", m.NumWatches())
+	}
+	if m.NumFsWatches() != 1 {
+		t.Fatalf("expected 1 fsnotify watch, got %d", m.NumFsWatches())
+	}
+	os.WriteFile(fileA, []byte("ab"), 0644)
+	got := map[string]bool{}
+	for len(got) < 3 {
+		for _, blockId := range waitNotify(t, notifyCh) {
+			got[blockId] = true
+		}
+	}
+	m.Unwatch("block1")
+	if m.NumWatches() != 2 {
+		t.Fatalf("expected 2 watches after closing block1, got %d", m.NumWatches())
+	}
+	m.Unwatch("block2")
+	m.Unwatch("block3")
+	if m.NumWatches() != 0 || m.NumFsWatches() != 0 {
+		t.Fatalf("expected no watches after closing the blocks, got %d (%d fsnotify)", m.NumWatches(), m.NumFsWatches())
+	}
+}
+
+func TestWatchPollFallback(t *testing.T) {
+	m, notifyCh := makeTestManager(t, 1)
+	dir1 := t.TempDir()
+	dir2 := t.TempDir()
+	m.Watch("block1", "", filepath.Join(dir1, "a.txt"))
+	fileB := filepath.Join(dir2, "b.txt")
+	m.Watch("block2", "", fileB)
+	if m.NumWatches() != 2 || m.NumFsWatches() != 1 {
+		t.Fatalf("expected 2 watches (1 fsnotify), got %d (%d fsnotify)", m.NumWatches(), m.NumFsWatches())
+	}
+	time.Sleep(100 * time.Millisecond)
+	os.WriteFile(fileB, []byte("b"), 0644)
+	blockIds := waitNotify(t, notifyCh)
+	if len(blockIds) != 1 || blockIds[0] != "block2" {
+		t.Fatalf("expected a change for block2, got %v", blockIds)
+	}
+	m.Unwatch("block2")
+	m.Unwatch("block1")
+	if m.NumWatches() != 0 || m.NumFsWatches() != 0 {
+		t.Fatalf("expected no watches, got %d (%d fsnotify)", m.NumWatches(), m.NumFsWatches())
+	}
+}
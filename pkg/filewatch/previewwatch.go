@@ -0,0 +1,63 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package filewatch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const RemoteStatTimeout = 5000 // ms
+
+var previewManager *Manager
+var previewManagerOnce sync.Once
+
+// the manager for the preview blocks, a change is sent as a blockrefresh event to each block showing the path
+func GetPreviewManager() *Manager {
+	previewManagerOnce.Do(func() {
+		previewManager = MakeManager(ManagerOpts{
+			RemotePollInterval: getRemotePollInterval,
+			RemoteStat:         remoteStat,
+			Notify:             publishBlockRefresh,
+		})
+	})
+	return previewManager
+}
+
+func getRemotePollInterval() time.Duration {
+	intervalMs := wconfig.GetWatcher().GetFullConfig().Settings.PreviewPollIntervalMs
+	if intervalMs <= 0 {
+		return DefaultRemotePollInterval
+	}
+	return max(time.Duration(intervalMs)*time.Millisecond, MinRemotePollInterval)
+}
+
+func remoteStat(conn string, path string) (*FileStat, error) {
+	opts := &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(conn), Timeout: RemoteStatTimeout}
+	finfo, err := wshclient.RemoteFileInfoCommand(wshclient.GetBareRpcClient(), path, opts)
+	if err != nil {
+		return nil, err
+	}
+	if finfo.NotFound {
+		return &FileStat{NotFound: true}, nil
+	}
+	return &FileStat{ModTime: finfo.ModTime, Size: finfo.Size, IsDir: finfo.IsDir}, nil
+}
+
+func publishBlockRefresh(blockIds []string, conn string, path string) {
+	for _, blockId := range blockIds {
+		wps.Broker.Publish(wps.WaveEvent{
+			Event:  wps.Event_BlockRefresh,
+			Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, blockId).String()},
+			Data:   wshrpc.BlockRefreshData{BlockId: blockId, Connection: conn, Path: path},
+		})
+	}
+}
@@ -8,17 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/fs"
 	"log"
 	"net"
-	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/kevinburke/ssh_config"
 	"github.com/skeema/knownhosts"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
 	"github.com/wavetermdev/waveterm/pkg/genconn"
@@ -61,12 +57,16 @@ type SSHConn struct {
 	DomainSockListener net.Listener
 	ConnController     *ssh.Session
 	Error              string
+	ErrorHop           string // the jump host the last connect failed at ("" if it wasn't a jump host)
 	WshError           string
 	NoWshReason        string
 	WshVersion         string
 	HasWaiter          *atomic.Bool
 	LastConnectTime    int64
 	ActiveConnNum      int
+	ReconnectAttempt   int   // the current auto-reconnect attempt (0 if not reconnecting)
+	NextReconnectTime  int64 // when the next attempt starts (0 if none is scheduled)
+	reconnectCancelFn  context.CancelFunc
 }
 
 var ConnServerCmdTemplate = strings.TrimSpace(`
@@ -109,10 +109,15 @@ func (conn *SSHConn) DeriveConnStatus() wshrpc.ConnStatus {
 		HasConnected:  (conn.LastConnectTime > 0),
 		ActiveConnNum: conn.ActiveConnNum,
 		Error:         conn.Error,
+		ErrorHop:      conn.ErrorHop,
 		WshEnabled:    conn.WshEnabled.Load(),
 		WshError:      conn.WshError,
 		NoWshReason:   conn.NoWshReason,
 		WshVersion:    conn.WshVersion,
+
+		Reconnecting:     conn.reconnectCancelFn != nil,
+		ReconnectAttempt: conn.ReconnectAttempt,
+		NextReconnectTs:  conn.NextReconnectTime,
 	}
 }
 
@@ -141,6 +146,7 @@ func (conn *SSHConn) Close() error {
 			// if status is init, disconnected, or error don't change it
 			conn.Status = Status_Disconnected
 		}
+		conn.stopReconnect_nolock()
 		conn.close_nolock()
 	})
 	// we must wait for the waiter to complete
@@ -508,6 +514,7 @@ func (conn *SSHConn) Connect(ctx context.Context, connFlags *wshrpc.ConnKeywords
 		} else {
 			conn.Status = Status_Connecting
 			conn.Error = ""
+			conn.ErrorHop = ""
 			connectAllowed = true
 		}
 	})
@@ -518,11 +525,13 @@ func (conn *SSHConn) Connect(ctx context.Context, connFlags *wshrpc.ConnKeywords
 	conn.Infof(ctx, "trying to connect to %q...\n", conn.GetName())
 	conn.FireConnChangeEvent()
 	err := conn.connectInternal(ctx, connFlags)
+	var hadConnected bool
 	conn.WithLock(func() {
 		if err != nil {
 			conn.Infof(ctx, "ERROR %v\n\n", err)
 			conn.Status = Status_Error
 			conn.Error = err.Error()
+			conn.ErrorHop = getErrorHop(conn.Opts, err)
 			conn.close_nolock()
 			telemetry.GoUpdateActivityWrap(wshrpc.ActivityUpdate{
 				Conn: map[string]int{"ssh:connecterror": 1},
@@ -530,7 +539,9 @@ func (conn *SSHConn) Connect(ctx context.Context, connFlags *wshrpc.ConnKeywords
 		} else {
 			conn.Infof(ctx, "successfully connected (wsh:%v)\n\n", conn.WshEnabled.Load())
 			conn.Status = Status_Connected
+			hadConnected = conn.LastConnectTime > 0
 			conn.LastConnectTime = time.Now().UnixMilli()
+			conn.stopReconnect_nolock()
 			if conn.ActiveConnNum == 0 {
 				conn.ActiveConnNum = int(activeConnCounter.Add(1))
 			}
@@ -543,6 +554,9 @@ func (conn *SSHConn) Connect(ctx context.Context, connFlags *wshrpc.ConnKeywords
 	if err != nil {
 		return err
 	}
+	if hadConnected {
+		conn.resumeStreams()
+	}
 
 	// logic for saving connection and potential flags (we only save once a connection has been made successfully)
 	// at the moment, identity files is the only saved flag
@@ -575,6 +589,18 @@ func (conn *SSHConn) Connect(ctx context.Context, connFlags *wshrpc.ConnKeywords
 	return nil
 }
 
+// the jump host a connection error comes from (errors from the target host itself return "")
+func getErrorHop(opts *remote.SSHOpts, err error) string {
+	var connErr remote.ConnectionError
+	if !errors.As(err, &connErr) || connErr.ConnectionDebugInfo == nil || connErr.NextOpts == nil {
+		return ""
+	}
+	if *connErr.NextOpts == *opts {
+		return ""
+	}
+	return connErr.NextOpts.String()
+}
+
 func (conn *SSHConn) WithLock(fn func()) {
 	conn.Lock.Lock()
 	defer conn.Lock.Unlock()
@@ -718,6 +744,9 @@ func (conn *SSHConn) connectInternal(ctx context.Context, connFlags *wshrpc.Conn
 		}
 	}
 	conn.persistWshInstalled(ctx, wshResult)
+	if !wshResult.WshEnabled {
+		go conn.runProcSysInfoLoop(client)
+	}
 	return nil
 }
 
@@ -729,7 +758,10 @@ func (conn *SSHConn) waitForDisconnect() {
 		return
 	}
 	err := client.Wait()
+	var reconnect bool
 	conn.WithLock(func() {
+		// if the client was already closed (and cleared) this is an expected disconnect (Close or a failed connect)
+		reconnect = conn.Client == client && conn.Status == Status_Connected
 		// disconnects happen for a variety of reasons (like network, etc. and are typically transient)
 		// so we just set the status to "disconnected" here (not error)
 		// don't overwrite any existing error (or error status)
@@ -740,6 +772,10 @@ func (conn *SSHConn) waitForDisconnect() {
 			conn.Status = Status_Disconnected
 		}
 		conn.close_nolock()
+		if reconnect && conn.isAutoReconnectEnabled() {
+			log.Printf("[conn:%s] unexpected disconnect (%v), reconnecting\n", conn.GetName(), err)
+			conn.startReconnect_nolock()
+		}
 	})
 }
 
@@ -815,54 +851,6 @@ func DisconnectClient(opts *remote.SSHOpts) error {
 	return err
 }
 
-func resolveSshConfigPatterns(configFiles []string) ([]string, error) {
-	// using two separate containers to track order and have O(1) lookups
-	// since go does not have an ordered map primitive
-	var discoveredPatterns []string
-	alreadyUsed := make(map[string]bool)
-	alreadyUsed[""] = true // this excludes the empty string from potential alias
-	var openedFiles []fs.File
-
-	defer func() {
-		for _, openedFile := range openedFiles {
-			openedFile.Close()
-		}
-	}()
-
-	var errs []error
-	for _, configFile := range configFiles {
-		fd, openErr := os.Open(configFile)
-		openedFiles = append(openedFiles, fd)
-		if fd == nil {
-			errs = append(errs, openErr)
-			continue
-		}
-
-		cfg, _ := ssh_config.Decode(fd, true)
-		for _, host := range cfg.Hosts {
-			// for each host, find the first good alias
-			for _, hostPattern := range host.Patterns {
-				hostPatternStr := hostPattern.String()
-				normalized := remote.NormalizeConfigPattern(hostPatternStr)
-				if !strings.Contains(hostPatternStr, "*") && !strings.Contains(hostPatternStr, "?") && !strings.Contains(hostPatternStr, "!") && !alreadyUsed[normalized] {
-					discoveredPatterns = append(discoveredPatterns, normalized)
-					alreadyUsed[normalized] = true
-					break
-				}
-			}
-		}
-	}
-	if len(errs) == len(configFiles) {
-		errs = append([]error{fmt.Errorf("no ssh config files could be opened: ")}, errs...)
-		return nil, errors.Join(errs...)
-	}
-	if len(discoveredPatterns) == 0 {
-		return nil, fmt.Errorf("no compatible hostnames found in ssh config files")
-	}
-
-	return discoveredPatterns, nil
-}
-
 func GetConnectionsList() ([]string, error) {
 	existing := GetAllConnStatus()
 	var currentlyRunning []string
@@ -903,6 +891,37 @@ func GetConnectionsList() ([]string, error) {
 	return connList, nil
 }
 
+// returns the status of each connection in GetConnectionsList (connections that haven't been used since wave
+// started are reported as disconnected)
+func GetConnectionsListStatus() ([]wshrpc.ConnStatus, error) {
+	connList, err := GetConnectionsList()
+	if err != nil {
+		return nil, err
+	}
+	statusMap := make(map[string]wshrpc.ConnStatus)
+	for _, status := range GetAllConnStatus() {
+		statusMap[status.Connection] = status
+	}
+	sourceMap := make(map[string]string)
+	fromConfig, _ := GetConnectionsFromConfig()
+	for _, connName := range fromConfig {
+		sourceMap[connName] = wshrpc.ConnSource_SshConfig
+	}
+	for _, connName := range GetConnectionsFromInternalConfig() {
+		sourceMap[connName] = wshrpc.ConnSource_Connections
+	}
+	rtn := make([]wshrpc.ConnStatus, 0, len(connList))
+	for _, connName := range connList {
+		status, found := statusMap[connName]
+		if !found {
+			status = wshrpc.ConnStatus{Status: Status_Disconnected, Connection: connName}
+		}
+		status.Source = sourceMap[connName]
+		rtn = append(rtn, status)
+	}
+	return rtn, nil
+}
+
 func GetConnectionsFromInternalConfig() []string {
 	var internalNames []string
 	config := wconfig.GetWatcher().GetFullConfig()
@@ -915,13 +934,3 @@ func GetConnectionsFromInternalConfig() []string {
 	}
 	return internalNames
 }
-
-func GetConnectionsFromConfig() ([]string, error) {
-	home := wavebase.GetHomeDir()
-	localConfig := filepath.Join(home, ".ssh", "config")
-	systemConfig := filepath.Join("/etc", "ssh", "config")
-	sshConfigFiles := []string{localConfig, systemConfig}
-	remote.WaveSshConfigUserSettings().ReloadConfigs()
-
-	return resolveSshConfigPatterns(sshConfigFiles)
-}
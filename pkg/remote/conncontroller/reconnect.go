@@ -0,0 +1,168 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filewatch"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/userinput"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	xknownhosts "golang.org/x/crypto/ssh/knownhosts"
+)
+
+// when a connection drops without being closed (network change, laptop sleep, etc.) it is reconnected with an
+// exponential backoff.  each attempt sends a connchange event (with the attempt number and the time of the next
+// attempt) so the blocks can show it.  a connect or disconnect by the user stops the retries.  set
+// conn:autoreconnect to false (globally or for a connection) to turn this off.
+//
+// the retries never prompt the user.  an attempt that needs a password, a passphrase, or a host key confirmation
+// (or that fails auth or the host key check) ends the retries and leaves the connection in the error state, so
+// the user reconnects by hand.
+const (
+	ReconnectInitialDelay = 1 * time.Second
+	ReconnectMaxDelay     = 60 * time.Second
+	ReconnectJitter       = 0.2 // each delay is randomized by +/- 20%
+	ReconnectMaxAttempts  = 20  // about 15 minutes
+)
+
+var errReconnectNeedsInput = errors.New("reconnect needs user input")
+
+// jitter is between -1 and 1
+func getReconnectDelay(attempt int, jitter float64) time.Duration {
+	delay := ReconnectMaxDelay
+	if attempt <= 7 {
+		delay = min(ReconnectInitialDelay<<max(attempt-1, 0), ReconnectMaxDelay)
+	}
+	delay += time.Duration(float64(delay) * ReconnectJitter * jitter)
+	return min(delay, ReconnectMaxDelay)
+}
+
+func (conn *SSHConn) isAutoReconnectEnabled() bool {
+	config := wconfig.GetWatcher().GetFullConfig()
+	enabled := wconfig.DefaultBoolPtr(config.Settings.ConnAutoReconnect, true)
+	connSettings, ok := config.Connections[conn.GetName()]
+	if ok && connSettings.ConnAutoReconnect != nil {
+		enabled = *connSettings.ConnAutoReconnect
+	}
+	return enabled
+}
+
+func (conn *SSHConn) startReconnect_nolock() {
+	if conn.reconnectCancelFn != nil {
+		return
+	}
+	ctx, cancelFn := context.WithCancel(context.Background())
+	conn.reconnectCancelFn = cancelFn
+	go conn.runReconnectLoop(ctx)
+}
+
+func (conn *SSHConn) stopReconnect_nolock() {
+	if conn.reconnectCancelFn != nil {
+		conn.reconnectCancelFn()
+		conn.reconnectCancelFn = nil
+	}
+	conn.ReconnectAttempt = 0
+	conn.NextReconnectTime = 0
+}
+
+// auth and host key failures will not fix themselves, retrying them only fails again (or locks the account)
+func isReconnectFatalError(err error) bool {
+	if errors.Is(err, errReconnectNeedsInput) {
+		return true
+	}
+	var inputErr remote.UserInputCancelError
+	if errors.As(err, &inputErr) {
+		return true
+	}
+	var codedErr *wshrpc.CodedError
+	if errors.As(err, &codedErr) && codedErr.Code == wshrpc.ErrorCode_HostKeyChanged {
+		return true
+	}
+	var revokedErr *xknownhosts.RevokedError
+	if errors.As(err, &revokedErr) {
+		return true
+	}
+	return strings.Contains(err.Error(), "unable to authenticate")
+}
+
+func (conn *SSHConn) runReconnectLoop(ctx context.Context) {
+	defer func() {
+		panichandler.PanicHandler("conncontroller:runReconnectLoop", recover())
+	}()
+	defer func() {
+		// if ctx was canceled the retries were stopped (and possibly restarted) by someone else
+		stopped := WithLockRtn(conn, func() bool {
+			if ctx.Err() != nil {
+				return false
+			}
+			conn.stopReconnect_nolock()
+			return true
+		})
+		if stopped {
+			conn.FireConnChangeEvent()
+		}
+	}()
+	for attempt := 1; attempt <= ReconnectMaxAttempts; attempt++ {
+		delay := getReconnectDelay(attempt, rand.Float64()*2-1)
+		canceled := WithLockRtn(conn, func() bool {
+			if ctx.Err() != nil {
+				return true
+			}
+			conn.ReconnectAttempt = attempt
+			conn.NextReconnectTime = time.Now().Add(delay).UnixMilli()
+			return false
+		})
+		if canceled {
+			return
+		}
+		conn.FireConnChangeEvent()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		status := conn.GetStatus()
+		if status == Status_Connected || status == Status_Connecting {
+			// connected by the user (or a block) while we were waiting
+			return
+		}
+		conn.WithLock(func() {
+			conn.NextReconnectTime = 0
+		})
+		log.Printf("[conn:%s] reconnecting (attempt %d)\n", conn.GetName(), attempt)
+		var neededInput atomic.Bool
+		connectCtx, cancelFn := context.WithTimeout(ctx, DefaultConnectionTimeout)
+		connectCtx = userinput.ContextWithPrompter(connectCtx, func(request *userinput.UserInputRequest) error {
+			neededInput.Store(true)
+			return errReconnectNeedsInput
+		})
+		err := conn.Connect(connectCtx, &wshrpc.ConnKeywords{})
+		cancelFn()
+		if err == nil {
+			return
+		}
+		log.Printf("[conn:%s] reconnect attempt %d failed: %v\n", conn.GetName(), attempt, err)
+		if neededInput.Load() || isReconnectFatalError(err) {
+			log.Printf("[conn:%s] reconnect needs user input or failed auth, not retrying\n", conn.GetName())
+			return
+		}
+	}
+	log.Printf("[conn:%s] giving up reconnecting after %d attempts\n", conn.GetName(), ReconnectMaxAttempts)
+}
+
+// the wsh connserver (and the /proc sampler for connections without wsh) start with the new connection, so the
+// sysinfo metrics come back on their own.  the files watched by preview blocks are polled now.
+func (conn *SSHConn) resumeStreams() {
+	filewatch.GetPreviewManager().PollConn(conn.GetName())
+}
@@ -0,0 +1,56 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestGetReconnectDelay(t *testing.T) {
+	expected := []time.Duration{1, 2, 4, 8, 16, 32, 60, 60, 60}
+	for idx, delay := range expected {
+		attempt := idx + 1
+		if got := getReconnectDelay(attempt, 0); got != delay*time.Second {
+			t.Errorf("attempt %d: expected %v, got %v", attempt, delay*time.Second, got)
+		}
+	}
+	if got := getReconnectDelay(1, -1); got != 800*time.Millisecond {
+		t.Errorf("expected 800ms with full negative jitter, got %v", got)
+	}
+	if got := getReconnectDelay(3, 1); got != 4800*time.Millisecond {
+		t.Errorf("expected 4.8s with full positive jitter, got %v", got)
+	}
+	if got := getReconnectDelay(100, 1); got != ReconnectMaxDelay {
+		t.Errorf("expected the delay to be capped at %v, got %v", ReconnectMaxDelay, got)
+	}
+}
+
+func TestIsReconnectFatalError(t *testing.T) {
+	fatal := []error{
+		remote.ConnectionError{Err: remote.UserInputCancelError{Err: errReconnectNeedsInput}},
+		fmt.Errorf("ssh: handshake failed: %w", errReconnectNeedsInput),
+		fmt.Errorf("ssh: handshake failed: %w", wshrpc.MakeCodedError(wshrpc.ErrorCode_HostKeyChanged, errors.New("host key changed"))),
+		errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none publickey], no supported methods remain"),
+	}
+	for _, err := range fatal {
+		if !isReconnectFatalError(err) {
+			t.Errorf("expected %q to stop the retries", err)
+		}
+	}
+	retry := []error{
+		errors.New("dial tcp 10.0.0.1:22: connect: no route to host"),
+		fmt.Errorf("timed out connecting to host: %w", errors.New("i/o timeout")),
+	}
+	for _, err := range retry {
+		if isReconnectFatalError(err) {
+			t.Errorf("expected %q to be retried", err)
+		}
+	}
+}
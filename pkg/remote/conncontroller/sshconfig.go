@@ -0,0 +1,176 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kevinburke/ssh_config"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+)
+
+// the hosts in the ssh config files are listed as connections (without being copied into connections.json).  the
+// files are parsed again when the mtime of one of them (or of a file they Include) changes.  hosts with a wildcard
+// pattern (Host *.prod) are not listed, they still apply to a connection whose name matches them.
+const SshConfigMaxIncludeDepth = 5 // the same limit as ssh_config (and openssh)
+
+var sshConfigHosts = &sshConfigHostsCache{}
+
+type sshConfigHostsCache struct {
+	lock   sync.Mutex
+	mtimes map[string]int64 // the files (and Include directories) that were read, 0 if they didn't exist
+	hosts  []string
+	err    error
+}
+
+type sshConfigReader struct {
+	mtimes    map[string]int64
+	hosts     []string
+	seenHosts map[string]bool
+	numRead   int
+	errs      []error
+}
+
+func getSshConfigFiles() []string {
+	return []string{
+		filepath.Join(wavebase.GetHomeDir(), ".ssh", "config"),
+		filepath.Join("/etc", "ssh", "ssh_config"),
+	}
+}
+
+// the names of the hosts in the ssh config files (with the user and port from the config, see
+// remote.NormalizeConfigPattern)
+func GetConnectionsFromConfig() ([]string, error) {
+	return sshConfigHosts.getHosts()
+}
+
+func getFileMTime(fileName string) int64 {
+	finfo, err := os.Stat(fileName)
+	if err != nil {
+		return 0
+	}
+	return finfo.ModTime().UnixNano()
+}
+
+func (c *sshConfigHostsCache) getHosts() ([]string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.mtimes != nil && !c.isStale_nolock() {
+		return slices.Clone(c.hosts), c.err
+	}
+	remote.WaveSshConfigUserSettings().ReloadConfigs()
+	configFiles := getSshConfigFiles()
+	reader := &sshConfigReader{mtimes: make(map[string]int64), seenHosts: map[string]bool{"": true}}
+	for _, configFile := range configFiles {
+		reader.readFile(configFile, 0)
+	}
+	c.mtimes = reader.mtimes
+	c.hosts = reader.hosts
+	c.err = nil
+	if reader.numRead == 0 {
+		c.err = errors.Join(append([]error{fmt.Errorf("no ssh config files could be opened: ")}, reader.errs...)...)
+	} else if len(reader.hosts) == 0 {
+		c.err = fmt.Errorf("no compatible hostnames found in ssh config files")
+	}
+	return slices.Clone(c.hosts), c.err
+}
+
+func (c *sshConfigHostsCache) isStale_nolock() bool {
+	for fileName, mtime := range c.mtimes {
+		if getFileMTime(fileName) != mtime {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *sshConfigReader) readFile(fileName string, depth int) {
+	r.mtimes[fileName] = getFileMTime(fileName)
+	barr, err := os.ReadFile(fileName)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return
+	}
+	r.numRead++
+	// ssh_config parses the included files too (for the lookups), but doesn't expose their hosts
+	cfg, err := ssh_config.DecodeBytes(barr, true)
+	if cfg == nil {
+		r.errs = append(r.errs, fmt.Errorf("error parsing %s: %w", fileName, err))
+		return
+	}
+	isSystem := strings.HasPrefix(filepath.Clean(fileName), "/etc/ssh")
+	for _, host := range cfg.Hosts {
+		r.addHost(host)
+		for _, node := range host.Nodes {
+			include, ok := node.(*ssh_config.Include)
+			if !ok || depth >= SshConfigMaxIncludeDepth {
+				continue
+			}
+			for _, includeFile := range r.resolveInclude(include, isSystem) {
+				r.readFile(includeFile, depth+1)
+			}
+		}
+	}
+}
+
+// a host is listed by its first pattern without wildcards (or negation)
+func (r *sshConfigReader) addHost(host *ssh_config.Host) {
+	for _, hostPattern := range host.Patterns {
+		hostPatternStr := hostPattern.String()
+		// String() drops the "!" of a negated pattern, but a host never matches its negated names
+		if strings.ContainsAny(hostPatternStr, "*?!") || !host.Matches(hostPatternStr) {
+			continue
+		}
+		normalized := remote.NormalizeConfigPattern(hostPatternStr)
+		if !r.seenHosts[normalized] {
+			r.hosts = append(r.hosts, normalized)
+			r.seenHosts[normalized] = true
+		}
+		return
+	}
+}
+
+// returns the files matched by the Include directive.  relative paths are in ~/.ssh (or /etc/ssh for the system
+// config), the directory of a glob is also checked for changes (so new files are picked up).
+func (r *sshConfigReader) resolveInclude(include *ssh_config.Include, isSystem bool) []string {
+	// ssh_config doesn't export the directives, they are parsed back out of the Include line
+	line := strings.TrimSpace(include.String())
+	line, _, _ = strings.Cut(line, " #")
+	line = strings.TrimSpace(strings.TrimPrefix(line, "Include"))
+	line = strings.TrimPrefix(line, "=")
+	baseDir := filepath.Join(wavebase.GetHomeDir(), ".ssh")
+	if isSystem {
+		baseDir = filepath.Join("/etc", "ssh")
+	}
+	var rtn []string
+	for _, directive := range strings.Fields(line) {
+		if strings.HasPrefix(directive, "~/") {
+			directive = filepath.Join(wavebase.GetHomeDir(), directive[2:])
+		} else if !filepath.IsAbs(directive) {
+			directive = filepath.Join(baseDir, directive)
+		}
+		if strings.ContainsAny(directive, "*?[") {
+			globDir := filepath.Dir(directive)
+			r.mtimes[globDir] = getFileMTime(globDir)
+		}
+		matches, err := filepath.Glob(directive)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("invalid Include %q: %w", directive, err))
+			continue
+		}
+		for _, match := range matches {
+			if _, seen := r.mtimes[match]; !seen {
+				rtn = append(rtn, match)
+			}
+		}
+	}
+	return rtn
+}
@@ -0,0 +1,48 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadSshConfigHosts(t *testing.T) {
+	dir := t.TempDir()
+	includeDir := filepath.Join(dir, "conf.d")
+	os.Mkdir(includeDir, 0755)
+	mainConfig := filepath.Join(dir, "config")
+	os.WriteFile(mainConfig, []byte(fmt.Sprintf(`Include %s/*.conf
+Host alpha
+    HostName alpha.example.com
+Host *.prod
+    User deploy
+Host !bastion beta
+    ProxyJump bastion
+Host alpha
+    Port 2200
+`, includeDir)), 0644)
+	os.WriteFile(filepath.Join(includeDir, "work.conf"), []byte("Host gamma delta\n    HostName 10.0.0.5\n"), 0644)
+	reader := &sshConfigReader{mtimes: make(map[string]int64), seenHosts: map[string]bool{"": true}}
+	reader.readFile(mainConfig, 0)
+	var names []string
+	for _, host := range reader.hosts {
+		// the user (and port) come from the ssh config lookups, which use the real ~/.ssh/config
+		_, name, _ := strings.Cut(host, "@")
+		name, _, _ = strings.Cut(name, ":")
+		names = append(names, name)
+	}
+	expected := []string{"gamma", "alpha", "beta"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected hosts %v, got %v", expected, names)
+	}
+	for _, fileName := range []string{mainConfig, includeDir, filepath.Join(includeDir, "work.conf")} {
+		if _, ok := reader.mtimes[fileName]; !ok {
+			t.Errorf("expected %s to be checked for changes", fileName)
+		}
+	}
+}
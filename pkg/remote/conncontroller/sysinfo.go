@@ -0,0 +1,61 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package conncontroller
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/wavetermdev/waveterm/pkg/genconn"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/sysmetrics"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+)
+
+// with wsh enabled the connserver publishes the sysinfo events for the connection.  without it, the samples are
+// read from /proc over their own ssh session (see sysmetrics.ProcSampleScript).  the session ends with the ssh
+// connection, the plots show a gap until the next connect starts a new one.
+func (conn *SSHConn) runProcSysInfoLoop(client *ssh.Client) {
+	defer func() {
+		panichandler.PanicHandler("conncontroller:runProcSysInfoLoop", recover())
+	}()
+	connName := conn.GetName()
+	session, err := client.NewSession()
+	if err != nil {
+		log.Printf("[conn:%s] cannot start sysinfo session: %v\n", connName, err)
+		return
+	}
+	defer session.Close()
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		log.Printf("[conn:%s] cannot start sysinfo session: %v\n", connName, err)
+		return
+	}
+	err = session.Start(fmt.Sprintf("sh -c %s", genconn.HardQuote(sysmetrics.ProcSampleScript)))
+	if err != nil {
+		log.Printf("[conn:%s] cannot start sysinfo session: %v\n", connName, err)
+		return
+	}
+	sampler := sysmetrics.MakeProcSampler()
+	var numSamples int
+	sampler.ReadSamples(stdout, func(tsData wshrpc.TimeSeriesData) {
+		numSamples++
+		event := wps.WaveEvent{
+			Event:  wps.Event_SysInfo,
+			Scopes: []string{connName},
+			Data:   tsData,
+		}
+		sysmetrics.AddSysInfoEvent(event)
+		wps.Broker.Publish(event)
+	})
+	err = session.Wait()
+	if numSamples == 0 {
+		// not linux (no /proc), the plots for this connection stay empty
+		log.Printf("[conn:%s] no sysinfo metrics for connection (%v)\n", connName, err)
+		return
+	}
+	log.Printf("[conn:%s] sysinfo session ended: %v\n", connName, err)
+}
@@ -0,0 +1,119 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/blocklogger"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+)
+
+// the clients for jump hosts (ProxyJump) are shared by the connections that go through them, so connecting to
+// several hosts behind a bastion only logs in to the bastion once.  a jump client is released when the client that
+// was dialed through it closes, and closed when its last user is released.
+type jumpClientKey struct {
+	via  *ssh.Client // the client the jump host is dialed from (nil for the first hop)
+	opts SSHOpts
+}
+
+type jumpClientEntry struct {
+	dialLock sync.Mutex // held while the jump host is dialed (connections made at the same time wait and share it)
+	client   *ssh.Client
+	refCount int
+}
+
+var jumpClientsLock = &sync.Mutex{}
+var jumpClients = make(map[jumpClientKey]*jumpClientEntry)
+
+// returns a client for the jump host (an open one if there is one), the caller must call releaseJumpClient
+func acquireJumpClient(connCtx context.Context, opts *SSHOpts, via *ssh.Client, jumpNum int32) (*ssh.Client, int32, error) {
+	key := jumpClientKey{via: via, opts: *opts}
+	jumpClientsLock.Lock()
+	entry := jumpClients[key]
+	if entry == nil {
+		entry = &jumpClientEntry{}
+		jumpClients[key] = entry
+	}
+	jumpClientsLock.Unlock()
+
+	entry.dialLock.Lock()
+	defer entry.dialLock.Unlock()
+	jumpClientsLock.Lock()
+	if entry.client != nil && jumpClients[key] == entry {
+		entry.refCount++
+		jumpClientsLock.Unlock()
+		blocklogger.Infof(connCtx, "[conndebug] reusing open connection to jump host %s\n", opts.String())
+		return entry.client, jumpNum, nil
+	}
+	jumpClientsLock.Unlock()
+
+	// do not apply supplied keywords to proxies - ssh config must be used for that
+	client, jumpNum, err := ConnectToClient(connCtx, opts, via, jumpNum, &wshrpc.ConnKeywords{})
+	jumpClientsLock.Lock()
+	defer jumpClientsLock.Unlock()
+	if err != nil {
+		if jumpClients[key] == entry && entry.client == nil {
+			delete(jumpClients, key)
+		}
+		return nil, jumpNum, err
+	}
+	entry.client = client
+	entry.refCount = 1
+	jumpClients[key] = entry
+	go waitForJumpClientClose(key, entry, client)
+	return client, jumpNum, nil
+}
+
+func waitForJumpClientClose(key jumpClientKey, entry *jumpClientEntry, client *ssh.Client) {
+	defer func() {
+		panichandler.PanicHandler("remote:waitForJumpClientClose", recover())
+	}()
+	err := client.Wait()
+	jumpClientsLock.Lock()
+	defer jumpClientsLock.Unlock()
+	if jumpClients[key] == entry && entry.client == client {
+		// the jump host disconnected, the next connection dials it again
+		log.Printf("connection to jump host %s closed: %v\n", key.opts.String(), err)
+		delete(jumpClients, key)
+	}
+}
+
+func releaseJumpClient(client *ssh.Client) {
+	jumpClientsLock.Lock()
+	defer jumpClientsLock.Unlock()
+	for key, entry := range jumpClients {
+		if entry.client != client {
+			continue
+		}
+		entry.refCount--
+		if entry.refCount <= 0 {
+			delete(jumpClients, key)
+			client.Close()
+		}
+		return
+	}
+	// already removed (the jump host disconnected)
+	client.Close()
+}
+
+// releases the jump clients (last hop first) once the client dialed through them closes
+func releaseJumpClientsOnClose(client *ssh.Client, jumpClientArr []*ssh.Client) {
+	defer func() {
+		panichandler.PanicHandler("remote:releaseJumpClientsOnClose", recover())
+	}()
+	client.Wait()
+	releaseJumpClients(jumpClientArr)
+}
+
+func releaseJumpClients(jumpClientArr []*ssh.Client) {
+	for _, jumpClient := range slices.Backward(jumpClientArr) {
+		releaseJumpClient(jumpClient)
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skeema/knownhosts"
+	"github.com/wavetermdev/waveterm/pkg/blocklogger"
+	"github.com/wavetermdev/waveterm/pkg/userinput"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+	xknownhosts "golang.org/x/crypto/ssh/knownhosts"
+)
+
+// host keys are checked against the known_hosts files from the ssh config (~/.ssh/known_hosts by default, hashed
+// entries are supported) and Wave's own known_hosts file in the data dir.  an unknown key is confirmed by the user
+// (with the same prompt channel as password auth, so wsh can answer it) or pre-approved with "wsh conn trust", and
+// is then added to Wave's file (the ssh files are never written).  a changed key always fails the connection (with
+// ErrorCode_HostKeyChanged and a hostkeychanged event), the old key has to be removed by hand.
+const (
+	WaveKnownHostsFileName      = "known_hosts"
+	WaveTrustedHostKeysFileName = "known_hosts_trusted" // "host SHA256:..." lines from wsh conn trust
+)
+
+var trustedHostKeysLock = &sync.Mutex{}
+
+func GetWaveKnownHostsFile() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), WaveKnownHostsFileName)
+}
+
+func getWaveTrustedHostKeysFile() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), WaveTrustedHostKeysFileName)
+}
+
+// returns the fingerprint in the form ssh-keygen -l prints it ("SHA256:" and unpadded base64)
+func NormalizeHostKeyFingerprint(fingerprint string) (string, error) {
+	algo, hash, ok := strings.Cut(strings.TrimSpace(fingerprint), ":")
+	if !ok || !strings.EqualFold(algo, "sha256") {
+		return "", fmt.Errorf("invalid fingerprint %q (expected SHA256:...)", fingerprint)
+	}
+	hash = strings.TrimRight(hash, "=")
+	hashBytes, err := base64.RawStdEncoding.DecodeString(hash)
+	if err != nil || len(hashBytes) != 32 {
+		return "", fmt.Errorf("invalid fingerprint %q (expected SHA256:...)", fingerprint)
+	}
+	return "SHA256:" + hash, nil
+}
+
+// pre-approves the host key with this fingerprint for the host (the ssh host of a connection name, the user and
+// port don't matter).  the key is added to Wave's known_hosts file on the next connect without asking.
+func TrustHostKey(host string, fingerprint string) error {
+	fingerprint, err := NormalizeHostKeyFingerprint(fingerprint)
+	if err != nil {
+		return err
+	}
+	if host == "" || strings.ContainsAny(host, " \t\n") {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	trustedHostKeysLock.Lock()
+	defer trustedHostKeysLock.Unlock()
+	if isHostKeyTrusted_nolock(host, fingerprint) {
+		return nil
+	}
+	fileName := getWaveTrustedHostKeysFile()
+	err = os.MkdirAll(filepath.Dir(fileName), 0700)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "%s %s\n", host, fingerprint)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func isHostKeyTrusted(host string, fingerprint string) bool {
+	trustedHostKeysLock.Lock()
+	defer trustedHostKeysLock.Unlock()
+	return isHostKeyTrusted_nolock(host, fingerprint)
+}
+
+func isHostKeyTrusted_nolock(host string, fingerprint string) bool {
+	f, err := os.Open(getWaveTrustedHostKeysFile())
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == host && fields[1] == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+type HostKeyChangedError struct {
+	Hostname    string
+	KeyType     string
+	Fingerprint string
+	KnownKeys   []string // file:line of the old keys
+}
+
+func (e HostKeyChangedError) Error() string {
+	return fmt.Sprintf("REMOTE HOST IDENTIFICATION HAS CHANGED for %s: the %s key it sent (%s) does not match the known key "+
+		"(someone could be eavesdropping on you with a man-in-the-middle attack).  if the change is expected, remove "+
+		"the old key from %s and connect again", e.Hostname, e.KeyType, e.Fingerprint, strings.Join(e.KnownKeys, ", "))
+}
+
+func makeHostKeyChangedError(opts *SSHOpts, hostname string, key ssh.PublicKey, knownKeys []xknownhosts.KnownKey) error {
+	changedErr := HostKeyChangedError{
+		Hostname:    hostname,
+		KeyType:     key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+	}
+	for _, knownKey := range knownKeys {
+		changedErr.KnownKeys = append(changedErr.KnownKeys, fmt.Sprintf("%s:%d", knownKey.Filename, knownKey.Line))
+	}
+	log.Printf("%v\n", changedErr)
+	data := wshrpc.HostKeyChangedData{
+		ConnName:    opts.String(),
+		Hostname:    changedErr.Hostname,
+		KeyType:     changedErr.KeyType,
+		Fingerprint: changedErr.Fingerprint,
+		KnownKeys:   changedErr.KnownKeys,
+	}
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_HostKeyChanged,
+		Scopes: []string{fmt.Sprintf("connection:%s", data.ConnName)},
+		Data:   data,
+	})
+	return &wshrpc.CodedError{Code: wshrpc.ErrorCode_HostKeyChanged, Err: changedErr, Data: data}
+}
+
+func openKnownHostsForEdit(knownHostsFilename string) (*os.File, error) {
+	path, _ := filepath.Split(knownHostsFilename)
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(knownHostsFilename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+}
+
+func writeToKnownHosts(knownHostsFile string, newLine string, getUserVerification func() (*userinput.UserInputResponse, error)) error {
+	if getUserVerification == nil {
+		getUserVerification = func() (*userinput.UserInputResponse, error) {
+			return &userinput.UserInputResponse{
+				Type:    "confirm",
+				Confirm: true,
+			}, nil
+		}
+	}
+
+	f, err := openKnownHostsForEdit(knownHostsFile)
+	if err != nil {
+		return err
+	}
+	// do not close writeable files with defer
+
+	// this file works, so let's ask the user for permission
+	response, err := getUserVerification()
+	if err != nil {
+		f.Close()
+		return UserInputCancelError{Err: err}
+	}
+	if !response.Confirm {
+		f.Close()
+		return UserInputCancelError{Err: fmt.Errorf("canceled by the user")}
+	}
+
+	_, err = f.WriteString(newLine + "\n")
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func createUnknownKeyVerifier(connCtx context.Context, knownHostsFile string, hostname string, remote string, key ssh.PublicKey) func() (*userinput.UserInputResponse, error) {
+	queryText := fmt.Sprintf(
+		"The authenticity of host '%s (%s)' can't be established "+
+			"as it **does not exist in any checked known_hosts files**.  \n"+
+			"The %s key fingerprint is:  \n"+
+			"%s\n\n"+
+			"**Would you like to continue connecting?** If so, the key will be permanently "+
+			"added to the file %s "+
+			"to protect from future man-in-the-middle attacks.", hostname, remote, key.Type(), ssh.FingerprintSHA256(key), knownHostsFile)
+	request := &userinput.UserInputRequest{
+		ResponseType: "confirm",
+		QueryText:    queryText,
+		Markdown:     true,
+		Title:        "Known Hosts Key Missing",
+	}
+	return func() (*userinput.UserInputResponse, error) {
+		ctx, cancelFn := context.WithTimeout(connCtx, 60*time.Second)
+		defer cancelFn()
+		resp, err := userinput.GetUserInput(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		if !resp.Confirm {
+			return nil, fmt.Errorf("user selected no")
+		}
+		return resp, nil
+	}
+}
+
+// adds the key to Wave's known_hosts file if it was pre-approved (wsh conn trust) or the user accepts it
+func addUnknownHostKey(connCtx context.Context, opts *SSHOpts, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	waveKnownHostsFile := GetWaveKnownHostsFile()
+	fingerprint := ssh.FingerprintSHA256(key)
+	var getUserVerification func() (*userinput.UserInputResponse, error)
+	if isHostKeyTrusted(opts.SSHHost, fingerprint) {
+		blocklogger.Infof(connCtx, "[conndebug] %s key %s for %s was pre-approved with wsh conn trust\n", key.Type(), fingerprint, hostname)
+	} else {
+		getUserVerification = createUnknownKeyVerifier(connCtx, waveKnownHostsFile, hostname, remote.String(), key)
+	}
+	newLine := xknownhosts.Line([]string{xknownhosts.Normalize(hostname)}, key)
+	return writeToKnownHosts(waveKnownHostsFile, newLine, getUserVerification)
+}
+
+func createHostKeyCallback(connCtx context.Context, sshKeywords *wshrpc.ConnKeywords, opts *SSHOpts) (ssh.HostKeyCallback, HostKeyAlgorithms, error) {
+	globalKnownHostsFiles := sshKeywords.SshGlobalKnownHostsFile
+	userKnownHostsFiles := sshKeywords.SshUserKnownHostsFile
+
+	osUser, err := user.Current()
+	if err != nil {
+		return nil, nil, err
+	}
+	var unexpandedKnownHostsFiles []string
+	if osUser.Username == "root" {
+		unexpandedKnownHostsFiles = globalKnownHostsFiles
+	} else {
+		unexpandedKnownHostsFiles = append(userKnownHostsFiles, globalKnownHostsFiles...)
+	}
+
+	var knownHostsFiles []string
+	for _, filename := range unexpandedKnownHostsFiles {
+		filePath, err := wavebase.ExpandHomeDir(filename)
+		if err != nil {
+			continue
+		}
+		knownHostsFiles = append(knownHostsFiles, filePath)
+	}
+	waveKnownHostsFile := GetWaveKnownHostsFile()
+	if !slices.Contains(knownHostsFiles, waveKnownHostsFile) {
+		knownHostsFiles = append(knownHostsFiles, waveKnownHostsFile)
+	}
+
+	// the library we use isn't very forgiving about files that are formatted
+	// incorrectly. if a problem file is found, it is removed from our list
+	// and we try again
+	var basicCallback ssh.HostKeyCallback
+	var hostKeyAlgorithms HostKeyAlgorithms
+	for basicCallback == nil && len(knownHostsFiles) > 0 {
+		keyDb, err := knownhosts.NewDB(knownHostsFiles...)
+		if serr, ok := err.(*os.PathError); ok {
+			badFile := serr.Path
+			var okFiles []string
+			for _, filename := range knownHostsFiles {
+				if filename != badFile {
+					okFiles = append(okFiles, filename)
+				}
+			}
+			if len(okFiles) >= len(knownHostsFiles) {
+				return nil, nil, fmt.Errorf("problem file (%s) doesn't exist. this should not be possible", badFile)
+			}
+			knownHostsFiles = okFiles
+		} else if err != nil {
+			// TODO handle obscure problems if possible
+			return nil, nil, fmt.Errorf("known_hosts formatting error: %+v", err)
+		} else {
+			basicCallback = keyDb.HostKeyCallback()
+			hostKeyAlgorithms = keyDb.HostKeyAlgorithms
+		}
+	}
+
+	if basicCallback == nil {
+		basicCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return &xknownhosts.KeyError{}
+		}
+		// need to return nil here to avoid null pointer from attempting to call
+		// the one provided by the db if nothing was found
+		hostKeyAlgorithms = func(hostWithPort string) (algos []string) {
+			return nil
+		}
+	}
+
+	waveHostKeyCallback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := basicCallback(hostname, remote, key)
+		if err == nil {
+			// success
+			return nil
+		}
+		var revokedErr *xknownhosts.RevokedError
+		if errors.As(err, &revokedErr) {
+			// revoked credentials are refused outright
+			return err
+		}
+		var keyErr *xknownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			// the key changed, this is never accepted automatically
+			return makeHostKeyChangedError(opts, hostname, key, keyErr.Want)
+		}
+		err = addUnknownHostKey(connCtx, opts, hostname, remote, key)
+		if err != nil {
+			if _, ok := err.(UserInputCancelError); ok {
+				return err
+			}
+			return fmt.Errorf("unable to add the host key to %s: %w", waveKnownHostsFile, err)
+		}
+		if !slices.Contains(knownHostsFiles, waveKnownHostsFile) {
+			knownHostsFiles = append(knownHostsFiles, waveKnownHostsFile)
+		}
+		updatedCallback, err := xknownhosts.New(knownHostsFiles...)
+		if err != nil {
+			return err
+		}
+		// try one final time
+		return updatedCallback(hostname, remote, key)
+	}
+
+	return waveHostKeyCallback, hostKeyAlgorithms, nil
+}
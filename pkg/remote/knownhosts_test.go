@@ -0,0 +1,141 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/userinput"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+	xknownhosts "golang.org/x/crypto/ssh/knownhosts"
+)
+
+func makeTestHostKey(t *testing.T) ssh.PublicKey {
+	pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshKey, err := ssh.NewPublicKey(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sshKey
+}
+
+// the ssh known_hosts file has a hashed entry for example.com, Wave's file is in a temp data dir
+func setupTestKnownHosts(t *testing.T, exampleKey ssh.PublicKey) *wshrpc.ConnKeywords {
+	tempDir := t.TempDir()
+	oldDataDir := wavebase.DataHome_VarCache
+	wavebase.DataHome_VarCache = filepath.Join(tempDir, "data")
+	t.Cleanup(func() { wavebase.DataHome_VarCache = oldDataDir })
+	knownHostsFile := filepath.Join(tempDir, "known_hosts")
+	hashedLine := xknownhosts.Line([]string{xknownhosts.HashHostname(xknownhosts.Normalize("example.com:22"))}, exampleKey)
+	err := os.WriteFile(knownHostsFile, []byte(hashedLine+"\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &wshrpc.ConnKeywords{SshGlobalKnownHostsFile: []string{knownHostsFile}}
+}
+
+func runTestHostKeyCallback(ctx context.Context, t *testing.T, keywords *wshrpc.ConnKeywords, host string, key ssh.PublicKey) error {
+	opts := &SSHOpts{SSHHost: host}
+	callback, _, err := createHostKeyCallback(ctx, keywords, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callback(host+":22", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 22}, key)
+}
+
+// fails the test if the user is asked anything
+func noPromptCtx(t *testing.T) context.Context {
+	return userinput.ContextWithPrompter(context.Background(), func(request *userinput.UserInputRequest) error {
+		t.Errorf("unexpected prompt: %s", request.Title)
+		return fmt.Errorf("unexpected prompt")
+	})
+}
+
+func TestHostKeyKnownHashed(t *testing.T) {
+	exampleKey := makeTestHostKey(t)
+	keywords := setupTestKnownHosts(t, exampleKey)
+	err := runTestHostKeyCallback(noPromptCtx(t), t, keywords, "example.com", exampleKey)
+	if err != nil {
+		t.Fatalf("expected the hashed known_hosts entry to match, got %v", err)
+	}
+}
+
+func TestHostKeyChanged(t *testing.T) {
+	keywords := setupTestKnownHosts(t, makeTestHostKey(t))
+	newKey := makeTestHostKey(t)
+	// even a pre-approved fingerprint doesn't replace a known key
+	err := TrustHostKey("example.com", ssh.FingerprintSHA256(newKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = runTestHostKeyCallback(noPromptCtx(t), t, keywords, "example.com", newKey)
+	if wshrpc.GetErrorCode(err) != wshrpc.ErrorCode_HostKeyChanged {
+		t.Fatalf("expected a host key changed error, got %v", err)
+	}
+	if data, ok := wshrpc.GetErrorData(err).(wshrpc.HostKeyChangedData); !ok || data.Fingerprint != ssh.FingerprintSHA256(newKey) {
+		t.Errorf("bad error data %#v", wshrpc.GetErrorData(err))
+	}
+}
+
+func TestHostKeyTrusted(t *testing.T) {
+	keywords := setupTestKnownHosts(t, makeTestHostKey(t))
+	newKey := makeTestHostKey(t)
+	fingerprint := ssh.FingerprintSHA256(newKey)
+	err := TrustHostKey("new.example.com", "sha256:"+strings.TrimPrefix(fingerprint, "SHA256:")+"=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = runTestHostKeyCallback(noPromptCtx(t), t, keywords, "new.example.com", newKey)
+	if err != nil {
+		t.Fatalf("expected the pre-approved key to be accepted, got %v", err)
+	}
+	// now it is in Wave's known_hosts file
+	err = runTestHostKeyCallback(noPromptCtx(t), t, keywords, "new.example.com", newKey)
+	if err != nil {
+		t.Fatalf("expected the added key to match, got %v", err)
+	}
+}
+
+func TestHostKeyUnknownPrompt(t *testing.T) {
+	keywords := setupTestKnownHosts(t, makeTestHostKey(t))
+	newKey := makeTestHostKey(t)
+	for _, confirm := range []bool{false, true} {
+		var numPrompts int
+		ctx := userinput.ContextWithPrompter(context.Background(), func(request *userinput.UserInputRequest) error {
+			numPrompts++
+			if !strings.Contains(request.QueryText, ssh.FingerprintSHA256(newKey)) {
+				t.Errorf("the prompt doesn't show the fingerprint: %s", request.QueryText)
+			}
+			userinput.SendUserInputResponse(&userinput.UserInputResponse{RequestId: request.RequestId, Confirm: confirm})
+			return nil
+		})
+		err := runTestHostKeyCallback(ctx, t, keywords, "other.example.com", newKey)
+		if numPrompts != 1 {
+			t.Errorf("expected 1 prompt, got %d", numPrompts)
+		}
+		if confirm && err != nil {
+			t.Fatalf("expected the accepted key to be added, got %v", err)
+		}
+		if !confirm && err == nil {
+			t.Fatalf("expected the rejected key to fail")
+		}
+	}
+	_, err := os.Stat(GetWaveKnownHostsFile())
+	if err != nil {
+		t.Errorf("expected the key to be added to Wave's known_hosts file: %v", err)
+	}
+}
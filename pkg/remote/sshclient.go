@@ -4,11 +4,9 @@
 package remote
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
-	"encoding/base64"
 	"fmt"
 	"log"
 	"math"
@@ -16,13 +14,11 @@ import (
 	"os"
 	"os/exec"
 	"os/user"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/kevinburke/ssh_config"
-	"github.com/skeema/knownhosts"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/trimquotes"
@@ -56,6 +52,24 @@ type UserInputCancelError struct {
 
 type HostKeyAlgorithms = func(hostWithPort string) (algos []string)
 
+type authMethodRecorderKey struct{}
+
+// records the last auth method that was tried (which is the one that succeeded if the connection was made)
+type authMethodRecorder struct {
+	lock       sync.Mutex
+	authMethod string
+}
+
+func recordAuthMethod(ctx context.Context, authMethod string) {
+	recorder, ok := ctx.Value(authMethodRecorderKey{}).(*authMethodRecorder)
+	if !ok {
+		return
+	}
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.authMethod = authMethod
+}
+
 func (uice UserInputCancelError) Error() string {
 	return uice.Err.Error()
 }
@@ -75,7 +89,11 @@ func (ce ConnectionError) Error() string {
 	if ce.CurrentClient == nil {
 		return fmt.Sprintf("Connecting to %s, Error: %v", ce.NextOpts, ce.Err)
 	}
-	return fmt.Sprintf("Connecting from %v to %s (jump number %d), Error: %v", ce.CurrentClient, ce.NextOpts, ce.JumpNum, ce.Err)
+	return fmt.Sprintf("Connecting from %s to %s (jump number %d), Error: %v", ce.CurrentClient.RemoteAddr(), ce.NextOpts, ce.JumpNum, ce.Err)
+}
+
+func (ce ConnectionError) Unwrap() error {
+	return ce.Err
 }
 
 func SimpleMessageFromPossibleConnectionError(err error) string {
@@ -146,6 +164,7 @@ func createPublicKeyCallback(connCtx context.Context, sshKeywords *wshrpc.ConnKe
 		if len(*authSockSignersPtr) != 0 {
 			authSockSigner := (*authSockSignersPtr)[0]
 			*authSockSignersPtr = (*authSockSignersPtr)[1:]
+			recordAuthMethod(connCtx, "publickey (agent)")
 			return []ssh.Signer{authSockSigner}, nil
 		}
 
@@ -155,6 +174,7 @@ func createPublicKeyCallback(connCtx context.Context, sshKeywords *wshrpc.ConnKe
 		identityFile := (*identityFilesPtr)[0]
 		blocklogger.Infof(connCtx, "[conndebug] trying keyfile %q...\n", identityFile)
 		*identityFilesPtr = (*identityFilesPtr)[1:]
+		recordAuthMethod(connCtx, fmt.Sprintf("publickey (%s)", identityFile))
 		privateKey, ok := existingKeys[identityFile]
 		if !ok {
 			log.Printf("error with existingKeys, this should never happen")
@@ -221,6 +241,7 @@ func createPublicKeyCallback(connCtx context.Context, sshKeywords *wshrpc.ConnKe
 func createInteractivePasswordCallbackPrompt(connCtx context.Context, remoteDisplayName string, debugInfo *ConnectionDebugInfo) func() (secret string, err error) {
 	return func() (secret string, err error) {
 		blocklogger.Infof(connCtx, "[conndebug] Password Authentication requested from connection %s...\n", remoteDisplayName)
+		recordAuthMethod(connCtx, "password")
 		ctx, cancelFn := context.WithTimeout(connCtx, 60*time.Second)
 		defer cancelFn()
 		queryText := fmt.Sprintf(
@@ -248,6 +269,7 @@ func createInteractiveKbdInteractiveChallenge(connCtx context.Context, remoteNam
 		if len(questions) != len(echos) {
 			return nil, fmt.Errorf("bad response from server: questions has len %d, echos has len %d", len(questions), len(echos))
 		}
+		recordAuthMethod(connCtx, "keyboard-interactive")
 		for i, question := range questions {
 			echo := echos[i]
 			answer, err := promptChallengeQuestion(connCtx, question, echo, remoteName)
@@ -283,290 +305,8 @@ func promptChallengeQuestion(connCtx context.Context, question string, echo bool
 	return response.Text, nil
 }
 
-func openKnownHostsForEdit(knownHostsFilename string) (*os.File, error) {
-	path, _ := filepath.Split(knownHostsFilename)
-	err := os.MkdirAll(path, 0700)
-	if err != nil {
-		return nil, err
-	}
-	return os.OpenFile(knownHostsFilename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-}
-
-func writeToKnownHosts(knownHostsFile string, newLine string, getUserVerification func() (*userinput.UserInputResponse, error)) error {
-	if getUserVerification == nil {
-		getUserVerification = func() (*userinput.UserInputResponse, error) {
-			return &userinput.UserInputResponse{
-				Type:    "confirm",
-				Confirm: true,
-			}, nil
-		}
-	}
-
-	path, _ := filepath.Split(knownHostsFile)
-	err := os.MkdirAll(path, 0700)
-	if err != nil {
-		return err
-	}
-	f, err := os.OpenFile(knownHostsFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	// do not close writeable files with defer
-
-	// this file works, so let's ask the user for permission
-	response, err := getUserVerification()
-	if err != nil {
-		f.Close()
-		return UserInputCancelError{Err: err}
-	}
-	if !response.Confirm {
-		f.Close()
-		return UserInputCancelError{Err: fmt.Errorf("canceled by the user")}
-	}
-
-	_, err = f.WriteString(newLine + "\n")
-	if err != nil {
-		f.Close()
-		return err
-	}
-	return f.Close()
-}
-
-func createUnknownKeyVerifier(knownHostsFile string, hostname string, remote string, key ssh.PublicKey) func() (*userinput.UserInputResponse, error) {
-	base64Key := base64.StdEncoding.EncodeToString(key.Marshal())
-	queryText := fmt.Sprintf(
-		"The authenticity of host '%s (%s)' can't be established "+
-			"as it **does not exist in any checked known_hosts files**. "+
-			"The host you are attempting to connect to provides this %s key:  \n"+
-			"%s.\n\n"+
-			"**Would you like to continue connecting?** If so, the key will be permanently "+
-			"added to the file %s "+
-			"to protect from future man-in-the-middle attacks.", hostname, remote, key.Type(), base64Key, knownHostsFile)
-	request := &userinput.UserInputRequest{
-		ResponseType: "confirm",
-		QueryText:    queryText,
-		Markdown:     true,
-		Title:        "Known Hosts Key Missing",
-	}
-	return func() (*userinput.UserInputResponse, error) {
-		ctx, cancelFn := context.WithTimeout(context.Background(), 60*time.Second)
-		defer cancelFn()
-		resp, err := userinput.GetUserInput(ctx, request)
-		if err != nil {
-			return nil, err
-		}
-		if !resp.Confirm {
-			return nil, fmt.Errorf("user selected no")
-		}
-		return resp, nil
-	}
-}
-
-func createMissingKnownHostsVerifier(knownHostsFile string, hostname string, remote string, key ssh.PublicKey) func() (*userinput.UserInputResponse, error) {
-	base64Key := base64.StdEncoding.EncodeToString(key.Marshal())
-	queryText := fmt.Sprintf(
-		"The authenticity of host '%s (%s)' can't be established "+
-			"as **no known_hosts files could be found**. "+
-			"The host you are attempting to connect to provides this %s key:  \n"+
-			"%s.\n\n"+
-			"**Would you like to continue connecting?** If so:  \n"+
-			"- %s will be created  \n"+
-			"- the key will be added to %s\n\n"+
-			"This will protect from future man-in-the-middle attacks.", hostname, remote, key.Type(), base64Key, knownHostsFile, knownHostsFile)
-	request := &userinput.UserInputRequest{
-		ResponseType: "confirm",
-		QueryText:    queryText,
-		Markdown:     true,
-		Title:        "Known Hosts File Missing",
-	}
-	return func() (*userinput.UserInputResponse, error) {
-		ctx, cancelFn := context.WithTimeout(context.Background(), 60*time.Second)
-		defer cancelFn()
-		resp, err := userinput.GetUserInput(ctx, request)
-		if err != nil {
-			return nil, err
-		}
-		if !resp.Confirm {
-			return nil, fmt.Errorf("user selected no")
-		}
-		return resp, nil
-	}
-}
-
-func lineContainsMatch(line []byte, matches [][]byte) bool {
-	for _, match := range matches {
-		if bytes.Contains(line, match) {
-			return true
-		}
-	}
-	return false
-}
-
-func createHostKeyCallback(sshKeywords *wshrpc.ConnKeywords) (ssh.HostKeyCallback, HostKeyAlgorithms, error) {
-	globalKnownHostsFiles := sshKeywords.SshGlobalKnownHostsFile
-	userKnownHostsFiles := sshKeywords.SshUserKnownHostsFile
-
-	osUser, err := user.Current()
-	if err != nil {
-		return nil, nil, err
-	}
-	var unexpandedKnownHostsFiles []string
-	if osUser.Username == "root" {
-		unexpandedKnownHostsFiles = globalKnownHostsFiles
-	} else {
-		unexpandedKnownHostsFiles = append(userKnownHostsFiles, globalKnownHostsFiles...)
-	}
-
-	var knownHostsFiles []string
-	for _, filename := range unexpandedKnownHostsFiles {
-		filePath, err := wavebase.ExpandHomeDir(filename)
-		if err != nil {
-			continue
-		}
-		knownHostsFiles = append(knownHostsFiles, filePath)
-	}
-
-	// there are no good known hosts files
-	if len(knownHostsFiles) == 0 {
-		return nil, nil, fmt.Errorf("no known_hosts files provided by ssh. defaults are overridden")
-	}
-
-	var unreadableFiles []string
-
-	// the library we use isn't very forgiving about files that are formatted
-	// incorrectly. if a problem file is found, it is removed from our list
-	// and we try again
-	var basicCallback ssh.HostKeyCallback
-	var hostKeyAlgorithms HostKeyAlgorithms
-	for basicCallback == nil && len(knownHostsFiles) > 0 {
-		keyDb, err := knownhosts.NewDB(knownHostsFiles...)
-		if serr, ok := err.(*os.PathError); ok {
-			badFile := serr.Path
-			unreadableFiles = append(unreadableFiles, badFile)
-			var okFiles []string
-			for _, filename := range knownHostsFiles {
-				if filename != badFile {
-					okFiles = append(okFiles, filename)
-				}
-			}
-			if len(okFiles) >= len(knownHostsFiles) {
-				return nil, nil, fmt.Errorf("problem file (%s) doesn't exist. this should not be possible", badFile)
-			}
-			knownHostsFiles = okFiles
-		} else if err != nil {
-			// TODO handle obscure problems if possible
-			return nil, nil, fmt.Errorf("known_hosts formatting error: %+v", err)
-		} else {
-			basicCallback = keyDb.HostKeyCallback()
-			hostKeyAlgorithms = keyDb.HostKeyAlgorithms
-		}
-	}
-
-	if basicCallback == nil {
-		basicCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			return &xknownhosts.KeyError{}
-		}
-		// need to return nil here to avoid null pointer from attempting to call
-		// the one provided by the db if nothing was found
-		hostKeyAlgorithms = func(hostWithPort string) (algos []string) {
-			return nil
-		}
-	}
-
-	waveHostKeyCallback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-		err := basicCallback(hostname, remote, key)
-		if err == nil {
-			// success
-			return nil
-		} else if _, ok := err.(*xknownhosts.RevokedError); ok {
-			// revoked credentials are refused outright
-			return err
-		} else if _, ok := err.(*xknownhosts.KeyError); !ok {
-			// this is an unknown error (note the !ok is opposite of usual)
-			return err
-		}
-		serr, _ := err.(*xknownhosts.KeyError)
-		if len(serr.Want) == 0 {
-			// the key was not found
-
-			// try to write to a file that could be read
-			err := fmt.Errorf("placeholder, should not be returned") // a null value here can cause problems with empty slice
-			for _, filename := range knownHostsFiles {
-				newLine := xknownhosts.Line([]string{xknownhosts.Normalize(hostname)}, key)
-				getUserVerification := createUnknownKeyVerifier(filename, hostname, remote.String(), key)
-				err = writeToKnownHosts(filename, newLine, getUserVerification)
-				if err == nil {
-					break
-				}
-				if serr, ok := err.(UserInputCancelError); ok {
-					return serr
-				}
-			}
-
-			// try to write to a file that could not be read (file likely doesn't exist)
-			// should catch cases where there is no known_hosts file
-			if err != nil {
-				for _, filename := range unreadableFiles {
-					newLine := xknownhosts.Line([]string{xknownhosts.Normalize(hostname)}, key)
-					getUserVerification := createMissingKnownHostsVerifier(filename, hostname, remote.String(), key)
-					err = writeToKnownHosts(filename, newLine, getUserVerification)
-					if err == nil {
-						knownHostsFiles = []string{filename}
-						break
-					}
-					if serr, ok := err.(UserInputCancelError); ok {
-						return serr
-					}
-				}
-			}
-			if err != nil {
-				return fmt.Errorf("unable to create new knownhost key: %e", err)
-			}
-		} else {
-			// the key changed
-			correctKeyFingerprint := base64.StdEncoding.EncodeToString(key.Marshal())
-			var bulletListKnownHosts []string
-			for _, knownHostName := range knownHostsFiles {
-				withBulletPoint := "- " + knownHostName
-				bulletListKnownHosts = append(bulletListKnownHosts, withBulletPoint)
-			}
-			var offendingKeysFmt []string
-			for _, badKey := range serr.Want {
-				formattedKey := "- " + base64.StdEncoding.EncodeToString(badKey.Key.Marshal())
-				offendingKeysFmt = append(offendingKeysFmt, formattedKey)
-			}
-			// todo
-			errorMsg := fmt.Sprintf("**WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!**\n\n"+
-				"If this is not expected, it is possible that someone could be trying to "+
-				"eavesdrop on you via a man-in-the-middle attack. "+
-				"Alternatively, the host you are connecting to may have changed its key. "+
-				"The %s key sent by the remote hist has the fingerprint:  \n"+
-				"%s\n\n"+
-				"If you are sure this is correct, please update your known_hosts files to "+
-				"remove the lines with the offending before trying to connect again.  \n"+
-				"**Known Hosts Files**  \n"+
-				"%s\n\n"+
-				"**Offending Keys**  \n"+
-				"%s", key.Type(), correctKeyFingerprint, strings.Join(bulletListKnownHosts, "  \n"), strings.Join(offendingKeysFmt, "  \n"))
-
-			log.Print(errorMsg)
-			//update := scbus.MakeUpdatePacket()
-			// create update into alert message
-
-			//send update via bus?
-			return fmt.Errorf("remote host identification has changed")
-		}
-
-		updatedCallback, err := xknownhosts.New(knownHostsFiles...)
-		if err != nil {
-			return err
-		}
-		// try one final time
-		return updatedCallback(hostname, remote, key)
-	}
-
-	return waveHostKeyCallback, hostKeyAlgorithms, nil
-}
+// a wrong password (or keyboard-interactive answer) is asked again, like NumberOfPasswordPrompts in openssh
+const SshMaxAuthAttempts = 3
 
 func createClientConfig(connCtx context.Context, sshKeywords *wshrpc.ConnKeywords, debugInfo *ConnectionDebugInfo) (*ssh.ClientConfig, error) {
 	chosenUser := utilfn.SafeDeref(sshKeywords.SshUser)
@@ -594,8 +334,8 @@ func createClientConfig(connCtx context.Context, sshKeywords *wshrpc.ConnKeyword
 	// exclude gssapi-with-mic and hostbased until implemented
 	authMethodMap := map[string]ssh.AuthMethod{
 		"publickey":            ssh.RetryableAuthMethod(publicKeyCallback, len(sshKeywords.SshIdentityFile)+len(authSockSigners)),
-		"keyboard-interactive": ssh.RetryableAuthMethod(keyboardInteractive, 1),
-		"password":             ssh.RetryableAuthMethod(passwordCallback, 1),
+		"keyboard-interactive": ssh.RetryableAuthMethod(keyboardInteractive, SshMaxAuthAttempts),
+		"password":             ssh.RetryableAuthMethod(passwordCallback, SshMaxAuthAttempts),
 	}
 
 	// note: batch mode turns off interactive input
@@ -618,7 +358,7 @@ func createClientConfig(connCtx context.Context, sshKeywords *wshrpc.ConnKeyword
 		authMethods = append(authMethods, authMethod)
 	}
 
-	hostKeyCallback, hostKeyAlgorithms, err := createHostKeyCallback(sshKeywords)
+	hostKeyCallback, hostKeyAlgorithms, err := createHostKeyCallback(connCtx, sshKeywords, debugInfo.NextOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -651,11 +391,26 @@ func connectInternal(ctx context.Context, networkAddr string, clientConfig *ssh.
 			return nil, err
 		}
 	}
+	// the handshake doesn't take a context, so the deadline is set on the connection instead (otherwise a host that
+	// accepts the tcp connection but never answers would hang forever).  not supported for connections over a jump host.
+	if deadline, ok := ctx.Deadline(); ok {
+		clientConn.SetDeadline(deadline)
+	}
+	// a cancel (e.g. the rpc that started the connection was canceled) also stops the handshake
+	stopCancelDeadline := context.AfterFunc(ctx, func() {
+		clientConn.SetDeadline(time.Now())
+	})
 	c, chans, reqs, err := ssh.NewClientConn(clientConn, networkAddr, clientConfig)
+	if !stopCancelDeadline() && err == nil {
+		// canceled just as the handshake finished, the connection's deadline has already been set
+		c.Close()
+		return nil, ctx.Err()
+	}
 	if err != nil {
 		blocklogger.Infof(ctx, "[conndebug] ERROR ssh auth/negotiation: %s\n", SimpleMessageFromPossibleConnectionError(err))
 		return nil, err
 	}
+	clientConn.SetDeadline(time.Time{})
 	blocklogger.Infof(ctx, "[conndebug] successful ssh connection to %s\n", networkAddr)
 	return ssh.NewClient(c, chans, reqs), nil
 }
@@ -709,9 +464,16 @@ func ConnectToClient(connCtx context.Context, opts *SSHOpts, currentClient *ssh.
 	sshKeywords.SshIdentityFile = append(sshKeywords.SshIdentityFile, internalSshConfigKeywords.SshIdentityFile...)
 	sshKeywords.SshIdentityFile = append(sshKeywords.SshIdentityFile, sshConfigKeywords.SshIdentityFile...)
 
+	// the jump hosts from the ssh config take precedence, the ones in connections.json are used when it has none
+	if len(sshKeywords.SshProxyJump) == 0 && !internalSshConfigKeywords.ConnOverrideConfig {
+		sshKeywords.SshProxyJump = internalSshConfigKeywords.SshProxyJump
+	}
+
+	var jumpClientArr []*ssh.Client
 	for _, proxyName := range sshKeywords.SshProxyJump {
 		proxyOpts, err := ParseOpts(proxyName)
 		if err != nil {
+			releaseJumpClients(jumpClientArr)
 			return nil, debugInfo.JumpNum, ConnectionError{ConnectionDebugInfo: debugInfo, Err: err}
 		}
 
@@ -720,26 +482,78 @@ func ConnectToClient(connCtx context.Context, opts *SSHOpts, currentClient *ssh.
 			jumpNum += 1
 		}
 
-		// do not apply supplied keywords to proxies - ssh config must be used for that
-		debugInfo.CurrentClient, jumpNum, err = ConnectToClient(connCtx, proxyOpts, debugInfo.CurrentClient, jumpNum, &wshrpc.ConnKeywords{})
+		debugInfo.CurrentClient, jumpNum, err = acquireJumpClient(connCtx, proxyOpts, debugInfo.CurrentClient, jumpNum)
 		if err != nil {
+			releaseJumpClients(jumpClientArr)
 			// do not add a context on a recursive call
 			// (this can cause a recursive nested context that's arbitrarily deep)
 			return nil, jumpNum, err
 		}
+		jumpClientArr = append(jumpClientArr, debugInfo.CurrentClient)
 	}
 	clientConfig, err := createClientConfig(connCtx, sshKeywords, debugInfo)
 	if err != nil {
+		releaseJumpClients(jumpClientArr)
 		return nil, debugInfo.JumpNum, ConnectionError{ConnectionDebugInfo: debugInfo, Err: err}
 	}
 	networkAddr := utilfn.SafeDeref(sshKeywords.SshHostName) + ":" + utilfn.SafeDeref(sshKeywords.SshPort)
 	client, err := connectInternal(connCtx, networkAddr, clientConfig, debugInfo.CurrentClient)
 	if err != nil {
+		releaseJumpClients(jumpClientArr)
 		return client, debugInfo.JumpNum, ConnectionError{ConnectionDebugInfo: debugInfo, Err: err}
 	}
+	if len(jumpClientArr) > 0 {
+		go releaseJumpClientsOnClose(client, jumpClientArr)
+	}
 	return client, debugInfo.JumpNum, nil
 }
 
+type ConnTestResult struct {
+	AuthMethod  string
+	ConnectTime time.Duration // dial + handshake + auth (including any jump hosts)
+	Latency     time.Duration // round trip of a keepalive request over the connection
+}
+
+// dials the connection (separate from the connection's controller, so wsh is not started and an existing connection
+// is not affected), measures the latency, and closes it again.  the ctx deadline limits the whole test.
+func TestConnection(ctx context.Context, opts *SSHOpts, connFlags *wshrpc.ConnKeywords) (*ConnTestResult, error) {
+	recorder := &authMethodRecorder{authMethod: "none"}
+	ctx = context.WithValue(ctx, authMethodRecorderKey{}, recorder)
+	startTime := time.Now()
+	client, _, err := ConnectToClient(ctx, opts, nil, 0, connFlags)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out connecting to %s: %w", opts, err)
+		}
+		return nil, err
+	}
+	defer client.Close()
+	rtn := &ConnTestResult{ConnectTime: time.Since(startTime)}
+	recorder.lock.Lock()
+	rtn.AuthMethod = recorder.authMethod
+	recorder.lock.Unlock()
+	// the server replies to unknown global requests with a failure, which is enough for a round trip
+	pingStart := time.Now()
+	pingErr := make(chan error, 1)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("sshclient:test-connection-ping", recover())
+		}()
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		pingErr <- err
+	}()
+	select {
+	case err := <-pingErr:
+		if err != nil {
+			return nil, fmt.Errorf("error sending keepalive to %s: %w", opts, err)
+		}
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for keepalive from %s", opts)
+	}
+	rtn.Latency = time.Since(pingStart)
+	return rtn, nil
+}
+
 // note that a `var == "yes"` will default to false
 // but `var != "no"` will default to true
 // when given unexpected strings
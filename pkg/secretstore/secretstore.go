@@ -0,0 +1,151 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// client-level secrets (e.g. ai api keys), referenced by name from block meta (see ai:apikeyref) so the values are
+// never stored in wave objects or sent to the frontend.  the secrets are kept in secrets.json in the data directory,
+// readable only by the user (0600).  the file is plaintext (not encrypted, and not in the OS keychain), so the secrets
+// are only as safe as the user's account and backups of the data directory.
+package secretstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+)
+
+const SecretsFileName = "secrets.json"
+
+var secretNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,127}$`)
+
+var lock = &sync.Mutex{}
+var secrets map[string]string // nil until the file is read
+
+func ValidateSecretName(name string) error {
+	if !secretNameRe.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q (must start with a letter, and only have letters, numbers, '_', '.', or '-')", name)
+	}
+	return nil
+}
+
+func getSecretsFileName() string {
+	return filepath.Join(wavebase.GetWaveDataDir(), SecretsFileName)
+}
+
+// must hold lock
+func loadSecrets() error {
+	if secrets != nil {
+		return nil
+	}
+	barr, err := os.ReadFile(getSecretsFileName())
+	if errors.Is(err, os.ErrNotExist) {
+		secrets = make(map[string]string)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading secrets file: %w", err)
+	}
+	var fileSecrets map[string]string
+	err = json.Unmarshal(barr, &fileSecrets)
+	if err != nil {
+		return fmt.Errorf("error parsing secrets file: %w", err)
+	}
+	if fileSecrets == nil {
+		fileSecrets = make(map[string]string)
+	}
+	secrets = fileSecrets
+	return nil
+}
+
+// must hold lock.  the file is replaced (with a rename) so a crash can't leave it half written
+func saveSecrets(newSecrets map[string]string) error {
+	barr, err := json.MarshalIndent(newSecrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	fileName := getSecretsFileName()
+	tmpFileName := fileName + ".tmp"
+	// plaintext, the file mode is the only protection
+	err = os.WriteFile(tmpFileName, barr, 0600)
+	if err != nil {
+		return fmt.Errorf("error writing secrets file: %w", err)
+	}
+	err = os.Rename(tmpFileName, fileName)
+	if err != nil {
+		os.Remove(tmpFileName)
+		return fmt.Errorf("error writing secrets file: %w", err)
+	}
+	secrets = newSecrets
+	return nil
+}
+
+// returns false if the secret isn't set
+func GetSecret(name string) (string, bool, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if err := loadSecrets(); err != nil {
+		return "", false, err
+	}
+	val, ok := secrets[name]
+	return val, ok, nil
+}
+
+func SetSecret(name string, value string) error {
+	if err := ValidateSecretName(name); err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("secret %q has no value (use delete to remove it)", name)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	if err := loadSecrets(); err != nil {
+		return err
+	}
+	newSecrets := make(map[string]string, len(secrets)+1)
+	for key, val := range secrets {
+		newSecrets[key] = val
+	}
+	newSecrets[name] = value
+	return saveSecrets(newSecrets)
+}
+
+// returns false if the secret wasn't set
+func DeleteSecret(name string) (bool, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if err := loadSecrets(); err != nil {
+		return false, err
+	}
+	if _, ok := secrets[name]; !ok {
+		return false, nil
+	}
+	newSecrets := make(map[string]string, len(secrets))
+	for key, val := range secrets {
+		if key != name {
+			newSecrets[key] = val
+		}
+	}
+	return true, saveSecrets(newSecrets)
+}
+
+// the (sorted) names of the secrets, the values are never listed
+func GetSecretNames() ([]string, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if err := loadSecrets(); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/tsgen/tsgenmeta"
+	"github.com/wavetermdev/waveterm/pkg/waveai"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
@@ -53,6 +54,10 @@ func (bs *BlockService) SaveTerminalState(ctx context.Context, blockId string, s
 	if stateType != "full" && stateType != "preview" {
 		return fmt.Errorf("invalid state type: %q", stateType)
 	}
+	if termFile, err := filestore.WFS.Stat(ctx, blockId, blockcontroller.BlockFile_Term); err == nil && ptyOffset < termFile.DataStartIdx() {
+		// the scrollback was trimmed past this state, it can't be restored (see blockcontroller.TrimScrollback)
+		return nil
+	}
 	// ignore MakeFile error (already exists is ok)
 	filestore.WFS.MakeFile(ctx, blockId, "cache:term:"+stateType, nil, filestore.FileOptsType{})
 	err = filestore.WFS.WriteFile(ctx, blockId, "cache:term:"+stateType, []byte(state))
@@ -70,7 +75,10 @@ func (bs *BlockService) SaveTerminalState(ctx context.Context, blockId string, s
 	return nil
 }
 
-func (bs *BlockService) SaveWaveAiData(ctx context.Context, blockId string, history []wshrpc.WaveAIPromptMessageType) error {
+// the ai conversation used to be saved in this blockfile (it is moved to the db the first time it is read)
+const legacyAiDataFile = "aidata"
+
+func checkWaveAiBlock(ctx context.Context, blockId string) error {
 	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
 	if err != nil {
 		return err
@@ -79,15 +87,93 @@ func (bs *BlockService) SaveWaveAiData(ctx context.Context, blockId string, hist
 	if viewName != "waveai" {
 		return fmt.Errorf("invalid view type: %s", viewName)
 	}
-	historyBytes, err := json.Marshal(history)
+	return nil
+}
+
+func promptsToConversation(history []wshrpc.WaveAIPromptMessageType) []wshrpc.WaveAIConversationMessage {
+	msgs := make([]wshrpc.WaveAIConversationMessage, 0, len(history))
+	for _, prompt := range history {
+		msgs = append(msgs, wshrpc.WaveAIConversationMessage{Role: prompt.Role, Content: prompt.Content})
+	}
+	return msgs
+}
+
+func (*BlockService) SaveWaveAiData_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "replaces the block's ai conversation (an empty history clears it)",
+		ArgNames: []string{"ctx", "blockId", "history"},
+	}
+}
+
+func (bs *BlockService) SaveWaveAiData(ctx context.Context, blockId string, history []wshrpc.WaveAIPromptMessageType) error {
+	if err := checkWaveAiBlock(ctx, blockId); err != nil {
+		return err
+	}
+	err := waveai.ReplaceConversation(ctx, blockId, promptsToConversation(history))
 	if err != nil {
-		return fmt.Errorf("unable to serialize ai history: %v", err)
+		return fmt.Errorf("cannot save ai conversation: %w", err)
 	}
-	// ignore MakeFile error (already exists is ok)
-	filestore.WFS.MakeFile(ctx, blockId, "aidata", nil, filestore.FileOptsType{})
-	err = filestore.WFS.WriteFile(ctx, blockId, "aidata", historyBytes)
+	filestore.WFS.DeleteFile(ctx, blockId, legacyAiDataFile)
+	return nil
+}
+
+func (*BlockService) AppendWaveAiData_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "adds messages to the end of the block's ai conversation",
+		ArgNames: []string{"ctx", "blockId", "msgs"},
+	}
+}
+
+func (bs *BlockService) AppendWaveAiData(ctx context.Context, blockId string, msgs []wshrpc.WaveAIConversationMessage) error {
+	if err := checkWaveAiBlock(ctx, blockId); err != nil {
+		return err
+	}
+	err := waveai.AppendConversation(ctx, blockId, msgs)
 	if err != nil {
-		return fmt.Errorf("cannot save terminal state: %w", err)
+		return fmt.Errorf("cannot save ai conversation: %w", err)
 	}
 	return nil
 }
+
+func (*BlockService) GetWaveAiData_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "returns the last maxMessages messages of the block's ai conversation (all of them if maxMessages is 0)",
+		ArgNames:   []string{"ctx", "blockId", "maxMessages"},
+		ReturnDesc: "history",
+	}
+}
+
+func (bs *BlockService) GetWaveAiData(ctx context.Context, blockId string, maxMessages int) ([]wshrpc.WaveAIPromptMessageType, error) {
+	msgs, err := waveai.GetConversation(ctx, blockId, maxMessages)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get ai conversation: %w", err)
+	}
+	if len(msgs) == 0 {
+		msgs, err = migrateLegacyAiData(ctx, blockId, maxMessages)
+		if err != nil {
+			return nil, err
+		}
+	}
+	history := make([]wshrpc.WaveAIPromptMessageType, 0, len(msgs))
+	for _, msg := range msgs {
+		history = append(history, wshrpc.WaveAIPromptMessageType{Role: msg.Role, Content: msg.Content})
+	}
+	return history, nil
+}
+
+func migrateLegacyAiData(ctx context.Context, blockId string, maxMessages int) ([]wshrpc.WaveAIConversationMessage, error) {
+	_, data, err := filestore.WFS.ReadFile(ctx, blockId, legacyAiDataFile)
+	if err != nil || len(data) == 0 {
+		return nil, nil
+	}
+	var history []wshrpc.WaveAIPromptMessageType
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("unable to parse ai history: %v", err)
+	}
+	err = waveai.ReplaceConversation(ctx, blockId, promptsToConversation(history))
+	if err != nil {
+		return nil, fmt.Errorf("cannot save ai conversation: %w", err)
+	}
+	filestore.WFS.DeleteFile(ctx, blockId, legacyAiDataFile)
+	return waveai.GetConversation(ctx, blockId, maxMessages)
+}
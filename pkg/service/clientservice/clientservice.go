@@ -6,14 +6,19 @@ package clientservice
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/tsgen/tsgenmeta"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wcloud"
 	"github.com/wavetermdev/waveterm/pkg/wconfig"
 	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
+	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wsl"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
@@ -21,50 +26,250 @@ import (
 
 type ClientService struct{}
 
-const DefaultTimeout = 2 * time.Second
+var logger = wlog.New("clientservice")
+
+// DefaultTimeout is the default, use getTimeout() which returns the configured timeout ("client:dbtimeoutms")
+const DefaultTimeout = wcore.DefaultClientTimeout
+
+func getTimeout() time.Duration {
+	return wcore.GetClientTimeout()
+}
 
 func (cs *ClientService) GetClientData() (*waveobj.Client, error) {
-	log.Println("GetClientData")
-	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	ctx, cancelFn := context.WithTimeout(context.Background(), getTimeout())
 	defer cancelFn()
+	logger.Debug(ctx, "GetClientData")
 	return wcore.GetClientData(ctx)
 }
 
 func (cs *ClientService) GetTab(tabId string) (*waveobj.Tab, error) {
-	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	ctx, cancelFn := context.WithTimeout(context.Background(), getTimeout())
 	defer cancelFn()
-	tab, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+	tab, err := wstore.DBGetCached[*waveobj.Tab](ctx, tabId)
 	if err != nil {
 		return nil, fmt.Errorf("error getting tab: %w", err)
 	}
 	return tab, nil
 }
 
+type WindowInfoType struct {
+	Window      *waveobj.Window `json:"window"`
+	ActiveTabId string          `json:"activetabid,omitempty"`
+}
+
+// returns all windows (in client window order) along with the active tab of each window's workspace
+func (cs *ClientService) GetAllWindows() ([]*WindowInfoType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), getTimeout())
+	defer cancelFn()
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*WindowInfoType, error) {
+		ctx := tx.Context()
+		client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting client: %w", err)
+		}
+		windows, err := wstore.DBGetMultiple[*waveobj.Window](ctx, client.WindowIds)
+		if err != nil {
+			return nil, fmt.Errorf("error getting windows: %w", err)
+		}
+		workspaceIds := make([]string, 0, len(windows))
+		for _, window := range windows {
+			workspaceIds = append(workspaceIds, window.WorkspaceId)
+		}
+		workspaceMap, err := wstore.DBSelectMap[*waveobj.Workspace](ctx, workspaceIds)
+		if err != nil {
+			return nil, fmt.Errorf("error getting workspaces: %w", err)
+		}
+		rtn := make([]*WindowInfoType, 0, len(windows))
+		for _, window := range windows {
+			winInfo := &WindowInfoType{Window: window}
+			if ws, ok := workspaceMap[window.WorkspaceId]; ok {
+				winInfo.ActiveTabId = ws.ActiveTabId
+			}
+			rtn = append(rtn, winInfo)
+		}
+		return rtn, nil
+	})
+}
+
+func (cs *ClientService) GetTabsForWindow_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		ArgNames: []string{"windowId"},
+	}
+}
+
+// returns all tabs for the window's workspace (pinned tabs first, in workspace order)
+func (cs *ClientService) GetTabsForWindow(windowId string) ([]*waveobj.Tab, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), getTimeout())
+	defer cancelFn()
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*waveobj.Tab, error) {
+		ctx := tx.Context()
+		window, err := wstore.DBMustGet[*waveobj.Window](ctx, windowId)
+		if err != nil {
+			return nil, fmt.Errorf("error getting window: %w", err)
+		}
+		ws, err := wstore.DBMustGet[*waveobj.Workspace](ctx, window.WorkspaceId)
+		if err != nil {
+			return nil, fmt.Errorf("error getting workspace: %w", err)
+		}
+		tabIds := make([]string, 0, len(ws.PinnedTabIds)+len(ws.TabIds))
+		tabIds = append(tabIds, ws.PinnedTabIds...)
+		tabIds = append(tabIds, ws.TabIds...)
+		tabs, err := wstore.DBGetMultiple[*waveobj.Tab](ctx, tabIds)
+		if err != nil {
+			return nil, fmt.Errorf("error getting tabs: %w", err)
+		}
+		for _, tab := range tabs {
+			tab.Pinned = utilfn.FindStringInSlice(ws.PinnedTabIds, tab.OID) != -1
+		}
+		return tabs, nil
+	})
+}
+
 func (cs *ClientService) GetAllConnStatus(ctx context.Context) ([]wshrpc.ConnStatus, error) {
 	sshStatuses := conncontroller.GetAllConnStatus()
 	wslStatuses := wsl.GetAllConnStatus()
 	return append(sshStatuses, wslStatuses...), nil
 }
 
-// moves the window to the front of the windowId stack
+// returns the starter layout that will be used for new installs (from starter-layout.json if it exists).
+// returns an error if the template is invalid.
+func (cs *ClientService) PreviewStarterLayout(ctx context.Context) (wcore.PortableLayout, error) {
+	return wcore.LoadStarterLayout()
+}
+
+// moves the window to the front of the windowId stack (returns wstore.ErrWindowNotFound for an unknown window)
 func (cs *ClientService) FocusWindow(ctx context.Context, windowId string) error {
 	return wcore.FocusWindow(ctx, windowId)
 }
 
-func (cs *ClientService) AgreeTos(ctx context.Context) (waveobj.UpdatesRtnType, error) {
+func (cs *ClientService) CloseWindow_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "close a window (and its workspace if it is not saved), sweeps orphaned tabs and blocks",
+		ArgNames: []string{"ctx", "windowId", "force"},
+	}
+}
+
+// returns wcore.ErrLastWindow when closing the last window, unless force is set (then a new empty window is created)
+func (cs *ClientService) CloseWindow(ctx context.Context, windowId string, force bool) (waveobj.UpdatesRtnType, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	client, err := wcore.GetClientData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if utilfn.SliceIdx(client.WindowIds, windowId) == -1 {
+		return nil, fmt.Errorf("%w: %s", wstore.ErrWindowNotFound, windowId)
+	}
+	if len(client.WindowIds) == 1 {
+		if !force {
+			return nil, wcore.ErrLastWindow
+		}
+		newWindow, err := wcore.MakeWindow(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating window: %w", err)
+		}
+		eventbus.SendEventToElectron(eventbus.WSEventType{
+			EventType: eventbus.WSEvent_ElectronNewWindow,
+			Data:      newWindow.OID,
+		})
+	}
+	err = wcore.CloseWindow(ctx, windowId, false)
+	if err != nil {
+		return nil, fmt.Errorf("error closing window: %w", err)
+	}
+	numSwept, err := wcore.SweepOrphanedObjects(ctx)
+	if err != nil {
+		logger.Error(ctx, "error sweeping orphaned objects", "err", err)
+	} else if numSwept > 0 {
+		logger.Info(ctx, "swept orphaned objects", "count", numSwept)
+	}
+	return waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (cs *ClientService) SetWindowGeometry_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "move and/or resize a window (only the fields set in geom are changed)",
+		ArgNames: []string{"ctx", "windowId", "geom"},
+	}
+}
+
+func (cs *ClientService) SetWindowGeometry(ctx context.Context, windowId string, geom waveobj.WindowGeometry) (waveobj.UpdatesRtnType, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	_, err := wcore.SetWindowGeometry(ctx, windowId, geom)
+	if err != nil {
+		return nil, err
+	}
+	return waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (cs *ClientService) UpdateClientMeta_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "merge settings into the client meta (keys must be known client keys or start with \"user:\")",
+		ArgNames: []string{"ctx", "meta"},
+	}
+}
+
+func (cs *ClientService) UpdateClientMeta(ctx context.Context, meta waveobj.MetaMapType) (waveobj.UpdatesRtnType, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.UpdateClientMeta(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("ClientService:UpdateClientMeta:SendUpdateEvents", recover())
+		}()
+		wps.Broker.SendUpdateEvents(updates)
+	}()
+	return updates, nil
+}
+
+func (cs *ClientService) GetClientSetting_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		ArgNames: []string{"key"},
+	}
+}
+
+func (cs *ClientService) GetClientSetting(key string) (any, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), getTimeout())
+	defer cancelFn()
+	return wcore.GetClientSetting(ctx, key)
+}
+
+type AgreeTosRtnType struct {
+	Bootstrapped bool `json:"bootstrapped,omitempty"`
+}
+
+func (cs *ClientService) AgreeTos_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		ArgNames:   []string{"ctx"},
+		ReturnDesc: "AgreeTosRtn",
+	}
+}
+
+// sets the tos agreed timestamp, the starter layout is only bootstrapped the first time
+// (the frontend may retry the call if the first response is slow)
+func (cs *ClientService) AgreeTos(ctx context.Context) (*AgreeTosRtnType, waveobj.UpdatesRtnType, error) {
 	ctx = waveobj.ContextWithUpdates(ctx)
 	clientData, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error getting client data: %w", err)
+		return nil, nil, fmt.Errorf("error getting client data: %w", err)
 	}
+	alreadyAgreed := clientData.TosAgreed != 0
 	timestamp := time.Now().UnixMilli()
 	clientData.TosAgreed = timestamp
 	err = wstore.DBUpdate(ctx, clientData)
 	if err != nil {
-		return nil, fmt.Errorf("error updating client data: %w", err)
+		return nil, nil, fmt.Errorf("error updating client data: %w", err)
 	}
-	wcore.BootstrapStarterLayout(ctx)
-	return waveobj.ContextGetUpdatesRtn(ctx), nil
+	rtn := &AgreeTosRtnType{}
+	if !alreadyAgreed {
+		rtn.Bootstrapped, err = wcore.BootstrapStarterLayout(ctx, false)
+		if err != nil {
+			logger.Error(ctx, "error bootstrapping starter layout", "err", err)
+		}
+	}
+	return rtn, waveobj.ContextGetUpdatesRtn(ctx), nil
 }
 
 func sendNoTelemetryUpdate(telemetryEnabled bool) {
@@ -72,16 +277,16 @@ func sendNoTelemetryUpdate(telemetryEnabled bool) {
 	defer cancelFn()
 	clientData, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
 	if err != nil {
-		log.Printf("telemetry update: error getting client data: %v\n", err)
+		logger.Error(ctx, "telemetry update: error getting client data", "err", err)
 		return
 	}
 	if clientData == nil {
-		log.Printf("telemetry update: client data is nil\n")
+		logger.Error(ctx, "telemetry update: client data is nil")
 		return
 	}
 	err = wcloud.SendNoTelemetryUpdate(ctx, clientData.OID, !telemetryEnabled)
 	if err != nil {
-		log.Printf("[error] sending no-telemetry update: %v\n", err)
+		logger.Error(ctx, "error sending no-telemetry update", "err", err)
 		return
 	}
 }
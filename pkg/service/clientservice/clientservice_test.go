@@ -0,0 +1,154 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package clientservice
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func initTestStores(t *testing.T) {
+	wavebase.DataHome_VarCache = t.TempDir()
+	wavebase.ConfigHome_VarCache = t.TempDir()
+	err := os.MkdirAll(filepath.Join(wavebase.GetWaveDataDir(), wavebase.WaveDBDir), 0700)
+	if err != nil {
+		t.Fatalf("error creating db dir: %v", err)
+	}
+	err = filestore.InitFilestore()
+	if err != nil {
+		t.Fatalf("error initializing filestore: %v", err)
+	}
+	err = wstore.InitWStore()
+	if err != nil {
+		t.Fatalf("error initializing wstore: %v", err)
+	}
+	err = wcore.EnsureInitialData()
+	if err != nil {
+		t.Fatalf("error creating initial data: %v", err)
+	}
+}
+
+func getFirstTab(t *testing.T, ctx context.Context) *waveobj.Tab {
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil {
+		t.Fatalf("error getting client: %v", err)
+	}
+	window, err := wstore.DBMustGet[*waveobj.Window](ctx, client.WindowIds[0])
+	if err != nil {
+		t.Fatalf("error getting window: %v", err)
+	}
+	workspace, err := wstore.DBMustGet[*waveobj.Workspace](ctx, window.WorkspaceId)
+	if err != nil {
+		t.Fatalf("error getting workspace: %v", err)
+	}
+	tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, workspace.ActiveTabId)
+	if err != nil {
+		t.Fatalf("error getting tab: %v", err)
+	}
+	return tab
+}
+
+func TestAgreeTosTwice(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	cs := &ClientService{}
+	rtn, _, err := cs.AgreeTos(ctx)
+	if err != nil {
+		t.Fatalf("error agreeing to tos: %v", err)
+	}
+	if !rtn.Bootstrapped {
+		t.Fatalf("expected the first AgreeTos to bootstrap the starter layout")
+	}
+	numBlocks := len(getFirstTab(t, ctx).BlockIds)
+	if numBlocks != len(wcore.GetStarterLayout()) {
+		t.Fatalf("expected %d blocks after bootstrap, got %d", len(wcore.GetStarterLayout()), numBlocks)
+	}
+	rtn, _, err = cs.AgreeTos(ctx)
+	if err != nil {
+		t.Fatalf("error agreeing to tos again: %v", err)
+	}
+	if rtn.Bootstrapped {
+		t.Errorf("expected the second AgreeTos not to bootstrap")
+	}
+	if numBlocks = len(getFirstTab(t, ctx).BlockIds); numBlocks != len(wcore.GetStarterLayout()) {
+		t.Errorf("expected %d blocks after the second AgreeTos, got %d", len(wcore.GetStarterLayout()), numBlocks)
+	}
+	bootstrapped, err := wcore.BootstrapStarterLayout(ctx, false)
+	if err != nil || bootstrapped {
+		t.Errorf("expected BootstrapStarterLayout to skip a tab with blocks, got %v, %v", bootstrapped, err)
+	}
+}
+
+func TestSetWindowGeometry(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil {
+		t.Fatalf("error getting client: %v", err)
+	}
+	windowId := client.WindowIds[0]
+	cs := &ClientService{}
+	x, y, width, height := 1920, 40, 1600, 1000
+	_, err = cs.SetWindowGeometry(ctx, windowId, waveobj.WindowGeometry{X: &x, Y: &y, Width: &width, Height: &height})
+	if err != nil {
+		t.Fatalf("error setting window geometry: %v", err)
+	}
+	newWidth := 1200
+	_, err = cs.SetWindowGeometry(ctx, windowId, waveobj.WindowGeometry{Width: &newWidth})
+	if err != nil {
+		t.Fatalf("error setting window width: %v", err)
+	}
+	window, err := wstore.DBMustGet[*waveobj.Window](ctx, windowId)
+	if err != nil {
+		t.Fatalf("error getting window: %v", err)
+	}
+	expected := waveobj.Window{Pos: waveobj.Point{X: x, Y: y}, WinSize: waveobj.WinSize{Width: newWidth, Height: height}}
+	if window.Pos != expected.Pos || window.WinSize != expected.WinSize {
+		t.Errorf("expected %v %v, got %v %v", expected.Pos, expected.WinSize, window.Pos, window.WinSize)
+	}
+	tooSmall := 10
+	_, err = cs.SetWindowGeometry(ctx, windowId, waveobj.WindowGeometry{Height: &tooSmall})
+	if err == nil {
+		t.Errorf("expected an error for a window height of %d", tooSmall)
+	}
+}
+
+func TestGetTabsForWindowPinned(t *testing.T) {
+	initTestStores(t)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	// the initial tab is pinned
+	pinnedTab := getFirstTab(t, ctx)
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil {
+		t.Fatalf("error getting client: %v", err)
+	}
+	window, err := wstore.DBMustGet[*waveobj.Window](ctx, client.WindowIds[0])
+	if err != nil {
+		t.Fatalf("error getting window: %v", err)
+	}
+	tabId, err := wcore.CreateTab(ctx, window.WorkspaceId, "", false, false, false)
+	if err != nil {
+		t.Fatalf("error creating tab: %v", err)
+	}
+	cs := &ClientService{}
+	tabs, err := cs.GetTabsForWindow(window.OID)
+	if err != nil {
+		t.Fatalf("error getting tabs: %v", err)
+	}
+	if len(tabs) != 2 || tabs[0].OID != pinnedTab.OID || !tabs[0].Pinned || tabs[1].OID != tabId || tabs[1].Pinned {
+		t.Errorf("expected the pinned tab %s and then %s (not pinned), got %d tabs", pinnedTab.OID, tabId, len(tabs))
+	}
+}
@@ -3,7 +3,9 @@ package fileservice
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,8 +26,9 @@ const DefaultTimeout = 2 * time.Second
 type FileService struct{}
 
 type FullFile struct {
-	Info   *wshrpc.FileInfo `json:"info"`
-	Data64 string           `json:"data64"` // base64 encoded
+	Info    *wshrpc.FileInfo    `json:"info"`
+	Data64  string              `json:"data64"`            // base64 encoded
+	Version *wshrpc.FileVersion `json:"version,omitempty"` // for the conflict check when the file is saved (see FileSaveCommand)
 }
 
 func (fs *FileService) SaveFile_Meta() tsgenmeta.MethodMeta {
@@ -39,6 +42,12 @@ func (fs *FileService) SaveFile(connection string, path string, data64 string) e
 	if connection == "" {
 		connection = wshrpc.LocalConnName
 	}
+	if connection == wshrpc.LocalConnName {
+		err := wconfig.ValidateConfigSave(path, data64)
+		if err != nil {
+			return err
+		}
+	}
 	connRoute := wshutil.MakeConnectionRouteId(connection)
 	client := wshserver.GetMainRpcClient()
 	writeData := wshrpc.CommandRemoteWriteFileData{Path: path, Data64: data64}
@@ -150,10 +159,22 @@ func (fs *FileService) ReadFile(connection string, path string) (*FullFile, erro
 	} else {
 		// we can avoid this re-encoding if we ensure the remote side always encodes chunks of 3 bytes so we don't get padding chars
 		fullFile.Data64 = base64.StdEncoding.EncodeToString(fileBuf.Bytes())
+		if !fullFile.Info.NotFound {
+			fullFile.Version = makeFileVersion(fullFile.Info, fileBuf.Bytes())
+		}
 	}
 	return fullFile, nil
 }
 
+func makeFileVersion(finfo *wshrpc.FileInfo, data []byte) *wshrpc.FileVersion {
+	version := &wshrpc.FileVersion{ModTime: finfo.ModTime, Size: finfo.Size}
+	if len(data) <= wshrpc.FileVersionHashMaxSize {
+		hash := sha256.Sum256(data)
+		version.Sha256 = hex.EncodeToString(hash[:])
+	}
+	return version
+}
+
 func (fs *FileService) GetWaveFile(id string, path string) (any, error) {
 	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
 	defer cancelFn()
@@ -9,10 +9,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/tsgen/tsgenmeta"
+	"github.com/wavetermdev/waveterm/pkg/waveai"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
 	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
 )
 
@@ -21,6 +26,8 @@ type ObjectService struct{}
 const DefaultTimeout = 2 * time.Second
 const ConnContextTimeout = 60 * time.Second
 
+var logger = wlog.New("objectservice")
+
 func parseORef(oref string) (*waveobj.ORef, error) {
 	fields := strings.Split(oref, ":")
 	if len(fields) != 2 {
@@ -112,6 +119,71 @@ func (svc *ObjectService) CreateBlock(uiContext waveobj.UIContext, blockDef *wav
 	return blockData.OID, waveobj.ContextGetUpdatesRtn(ctx), nil
 }
 
+func (svc *ObjectService) CreateBlocks_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "creates all of the blocks in a single transaction (nothing is created if one of them fails)",
+		ArgNames:   []string{"uiContext", "tabId", "defs"},
+		ReturnDesc: "blockIds",
+	}
+}
+
+func (svc *ObjectService) CreateBlocks(uiContext waveobj.UIContext, tabId string, defs []wshrpc.BlockDefWithLayout) ([]string, waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if tabId == "" {
+		tabId = uiContext.ActiveTabId
+	}
+	blockIds, err := wcore.CreateBlocks(ctx, tabId, defs, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating blocks: %w", err)
+	}
+	return blockIds, waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (svc *ObjectService) SaveLayoutPreset_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "saves the tab's layout as a named preset (replaces an existing preset with the same name)",
+		ArgNames: []string{"uiContext", "tabId", "name"},
+	}
+}
+
+func (svc *ObjectService) SaveLayoutPreset(uiContext waveobj.UIContext, tabId string, name string) (waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if tabId == "" {
+		tabId = uiContext.ActiveTabId
+	}
+	_, err := wcore.SaveLayoutPreset(ctx, tabId, name)
+	if err != nil {
+		return nil, fmt.Errorf("error saving layout preset: %w", err)
+	}
+	return waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (svc *ObjectService) ApplyLayoutPreset_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "recreates the blocks of a layout preset in the tab (clearExisting closes the tab's current blocks first)",
+		ArgNames:   []string{"uiContext", "tabId", "name", "clearExisting"},
+		ReturnDesc: "blockIds",
+	}
+}
+
+func (svc *ObjectService) ApplyLayoutPreset(uiContext waveobj.UIContext, tabId string, name string, clearExisting bool) ([]string, waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if tabId == "" {
+		tabId = uiContext.ActiveTabId
+	}
+	blockIds, err := wcore.ApplyLayoutPreset(ctx, tabId, name, clearExisting, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error applying layout preset: %w", err)
+	}
+	return blockIds, waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
 func (svc *ObjectService) DeleteBlock_Meta() tsgenmeta.MethodMeta {
 	return tsgenmeta.MethodMeta{
 		ArgNames: []string{"uiContext", "blockId"},
@@ -126,9 +198,219 @@ func (svc *ObjectService) DeleteBlock(uiContext waveobj.UIContext, blockId strin
 	if err != nil {
 		return nil, fmt.Errorf("error deleting block: %w", err)
 	}
+	logger.Info(ctx, "deleted block", "blockid", blockId)
+	return waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (svc *ObjectService) LinkBlocks_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "links a terminal's output to a preview or web block (the regex's first capture group sets the target's file or url)",
+		ArgNames:   []string{"uiContext", "srcBlockId", "dstBlockId", "rule"},
+		ReturnDesc: "linkId",
+	}
+}
+
+func (svc *ObjectService) LinkBlocks(uiContext waveobj.UIContext, srcBlockId string, dstBlockId string, rule string) (string, waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	link, err := wcore.LinkBlocks(ctx, srcBlockId, dstBlockId, waveobj.BlockLinkRule_Regex, rule)
+	if err != nil {
+		return "", nil, fmt.Errorf("error linking blocks: %w", err)
+	}
+	return link.OID, waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (svc *ObjectService) SetBlockPinned_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "pinned blocks are skipped when all the blocks in a tab are closed (unless forced)",
+		ArgNames: []string{"uiContext", "blockId", "pinned"},
+	}
+}
+
+func (svc *ObjectService) SetBlockPinned(uiContext waveobj.UIContext, blockId string, pinned bool) (waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.SetBlockPinned(ctx, blockId, pinned)
+	if err != nil {
+		return nil, fmt.Errorf("error setting block pinned: %w", err)
+	}
+	return waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (svc *ObjectService) SetTabConnection_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "sets the default connection for new blocks in the tab (empty clears it, existing blocks are not changed)",
+		ArgNames: []string{"uiContext", "tabId", "conn"},
+	}
+}
+
+func (svc *ObjectService) SetTabConnection(uiContext waveobj.UIContext, tabId string, conn string) (waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.SetTabConnection(ctx, tabId, conn)
+	if err != nil {
+		return nil, fmt.Errorf("error setting tab connection: %w", err)
+	}
+	return waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (svc *ObjectService) SetTabTitleTemplate_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "sets the tab's title template, e.g. \"{conn}:{cwd}\" (without braces it is a static title, empty clears it)",
+		ArgNames: []string{"uiContext", "tabId", "template"},
+	}
+}
+
+func (svc *ObjectService) SetTabTitleTemplate(uiContext waveobj.UIContext, tabId string, template string) (waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.SetTabTitleTemplate(ctx, tabId, template)
+	if err != nil {
+		return nil, fmt.Errorf("error setting tab title template: %w", err)
+	}
+	return waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (svc *ObjectService) GetTabTitle_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "returns the tab's title (the tab's title template rendered, or the tab's name)",
+		ArgNames:   []string{"tabId"},
+		ReturnDesc: "title",
+	}
+}
+
+func (svc *ObjectService) GetTabTitle(tabId string) (string, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	return wcore.GetTabTitle(ctx, tabId)
+}
+
+func (svc *ObjectService) InjectPath_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "types the path (quoted for the block's shell, without a newline) into a terminal block, blockId defaults to the focused block of the active tab",
+		ArgNames:   []string{"uiContext", "blockId", "path"},
+		ReturnDesc: "blockId",
+	}
+}
+
+func (svc *ObjectService) InjectPath(uiContext waveobj.UIContext, blockId string, path string) (string, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	return wcore.InjectPath(ctx, uiContext.ActiveTabId, blockId, path)
+}
+
+func (svc *ObjectService) ExportConversation_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "exports the block's ai conversation as markdown or json (wsh ai export streams it to a file instead)",
+		ArgNames:   []string{"ctx", "blockId", "format"},
+		ReturnDesc: "conversation",
+	}
+}
+
+func (svc *ObjectService) ExportConversation(ctx context.Context, blockId string, format string) (string, error) {
+	ctx, cancelFn := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancelFn()
+	var buf strings.Builder
+	err := waveai.ExportConversation(ctx, blockId, format, &buf)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (svc *ObjectService) TrimScrollback_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "trims the block's scrollback to (about) the last keepBytes bytes (at a line boundary), returns the size that is left",
+		ArgNames:   []string{"blockId", "keepBytes"},
+		ReturnDesc: "scrollbackSize",
+	}
+}
+
+func (svc *ObjectService) TrimScrollback(blockId string, keepBytes int64) (int64, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	return blockcontroller.TrimScrollback(ctx, blockId, keepBytes)
+}
+
+func (svc *ObjectService) DuplicateBlock_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "creates a copy of the block (its meta and runtime opts) next to it in the same tab, returns the new block id",
+		ArgNames: []string{"uiContext", "blockId"},
+	}
+}
+
+func (svc *ObjectService) DuplicateBlock(uiContext waveobj.UIContext, blockId string) (string, waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	newBlock, err := wcore.DuplicateBlock(ctx, blockId, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("error duplicating block: %w", err)
+	}
+	return newBlock.OID, waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
+func (svc *ObjectService) MoveBlock_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "moves a block to another tab (indexArr is the position in the destination layout, optional)",
+		ArgNames: []string{"uiContext", "blockId", "destTabId", "indexArr"},
+	}
+}
+
+func (svc *ObjectService) MoveBlock(uiContext waveobj.UIContext, blockId string, destTabId string, indexArr []int) (waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.MoveBlock(ctx, blockId, destTabId, indexArr)
+	if err != nil {
+		return nil, fmt.Errorf("error moving block: %w", err)
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("ObjectService:MoveBlock:SendUpdateEvents", recover())
+		}()
+		// the destination tab can be in another window
+		wps.Broker.SendUpdateEvents(updates)
+	}()
+	return updates, nil
+}
+
+func (svc *ObjectService) RestoreBlock_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "restores a deleted block (from the trash) into a tab, tabId defaults to the block's original tab",
+		ArgNames: []string{"uiContext", "blockId", "tabId"},
+	}
+}
+
+func (svc *ObjectService) RestoreBlock(uiContext waveobj.UIContext, blockId string, tabId string) (waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.RestoreBlock(ctx, blockId, tabId)
+	if err != nil {
+		return nil, fmt.Errorf("error restoring block: %w", err)
+	}
 	return waveobj.ContextGetUpdatesRtn(ctx), nil
 }
 
+func (svc *ObjectService) RestartController_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "restarts a block's shell (keeping the scrollback), restarts after crashes are delayed unless force is set",
+		ArgNames: []string{"ctx", "blockId", "force"},
+	}
+}
+
+func (svc *ObjectService) RestartController(ctx context.Context, blockId string, force bool) error {
+	ctx, cancelFn := context.WithTimeout(ctx, blockcontroller.RestartControllerTimeout)
+	defer cancelFn()
+	return blockcontroller.RestartController(ctx, blockId, force)
+}
+
 func (svc *ObjectService) UpdateObjectMeta_Meta() tsgenmeta.MethodMeta {
 	return tsgenmeta.MethodMeta{
 		ArgNames: []string{"uiContext", "oref", "meta"},
@@ -143,19 +425,57 @@ func (svc *ObjectService) UpdateObjectMeta(uiContext waveobj.UIContext, orefStr
 	if err != nil {
 		return nil, fmt.Errorf("error parsing object reference: %w", err)
 	}
-	err = wstore.UpdateObjectMeta(ctx, *oref, meta, false)
+	logger.Debug(ctx, "UpdateObjectMeta", "oref", oref, "keys", len(meta))
+	if oref.OType == waveobj.OType_Client {
+		// validated (and reloads the client timeout)
+		err = wcore.UpdateClientMeta(ctx, meta)
+	} else {
+		err = wcore.NormalizeWebMetaUpdate(ctx, *oref, meta)
+		if err == nil {
+			err = wstore.UpdateObjectMeta(ctx, *oref, meta, false)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error updating %q meta: %w", orefStr, err)
 	}
 	return waveobj.ContextGetUpdatesRtn(ctx), nil
 }
 
+func (svc *ObjectService) UpdateBlockMeta_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:       "merge a meta patch into a block (null values delete keys), fails with a version mismatch if expectedVersion is stale",
+		ArgNames:   []string{"uiContext", "blockId", "patch", "expectedVersion"},
+		ReturnDesc: "newVersion",
+	}
+}
+
+// merges the patch into the block's meta, but only if the caller has seen the latest version of the block.
+// when the version is stale, the error wraps wstore.ErrVersionMismatch (re-read the block and retry).
+func (svc *ObjectService) UpdateBlockMeta(uiContext waveobj.UIContext, blockId string, patch waveobj.MetaMapType, expectedVersion int) (int, waveobj.UpdatesRtnType, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancelFn()
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.NormalizeWebMetaUpdate(ctx, waveobj.MakeORef(waveobj.OType_Block, blockId), patch)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error updating block %q meta: %w", blockId, err)
+	}
+	newVersion, err := wcore.UpdateBlockMeta(ctx, blockId, patch, expectedVersion)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error updating block %q meta: %w", blockId, err)
+	}
+	return newVersion, waveobj.ContextGetUpdatesRtn(ctx), nil
+}
+
 func (svc *ObjectService) UpdateObject_Meta() tsgenmeta.MethodMeta {
 	return tsgenmeta.MethodMeta{
+		Desc:     "internal use: overwrites the whole object (last writer wins), use UpdateBlockMeta to change block meta",
 		ArgNames: []string{"uiContext", "waveObj", "returnUpdates"},
 	}
 }
 
+// internal use, this replaces the whole object (including all of its meta) so concurrent changes are lost.
+// use UpdateBlockMeta (or UpdateObjectMeta) to change meta.
+
 func (svc *ObjectService) UpdateObject(uiContext waveobj.UIContext, waveObj waveobj.WaveObj, returnUpdates bool) (waveobj.UpdatesRtnType, error) {
 	ctx, cancelFn := context.WithTimeout(context.Background(), DefaultTimeout)
 	defer cancelFn()
@@ -164,13 +484,28 @@ func (svc *ObjectService) UpdateObject(uiContext waveobj.UIContext, waveObj wave
 		return nil, fmt.Errorf("update wavobj is nil")
 	}
 	oref := waveobj.ORefFromWaveObj(waveObj)
-	found, err := wstore.DBExistsORef(ctx, *oref)
+	curObj, err := wstore.DBGetORef(ctx, *oref)
 	if err != nil {
 		return nil, fmt.Errorf("error getting object: %w", err)
 	}
-	if !found {
+	if curObj == nil {
 		return nil, fmt.Errorf("object not found: %s", oref)
 	}
+	if block, ok := waveObj.(*waveobj.Block); ok {
+		// keeps the stored web:rendered (it is only set by "wsh render --html")
+		wcore.StripClientWebMeta(block.Meta)
+		if curObj.(*waveobj.Block).Meta.GetBool(waveobj.MetaKey_WebRendered, false) {
+			if block.Meta == nil {
+				block.Meta = make(waveobj.MetaMapType)
+			}
+			block.Meta[waveobj.MetaKey_WebRendered] = true
+		}
+		err = wcore.NormalizeWebBlockUpdate(block)
+		if err != nil {
+			return nil, fmt.Errorf("error updating block: %w", err)
+		}
+	}
+	logger.Debug(ctx, "UpdateObject", "oref", oref)
 	err = wstore.DBUpdate(ctx, waveObj)
 	if err != nil {
 		return nil, fmt.Errorf("error updating object: %w", err)
@@ -13,6 +13,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/service/clientservice"
 	"github.com/wavetermdev/waveterm/pkg/service/fileservice"
 	"github.com/wavetermdev/waveterm/pkg/service/objectservice"
+	"github.com/wavetermdev/waveterm/pkg/service/telemetryservice"
 	"github.com/wavetermdev/waveterm/pkg/service/userinputservice"
 	"github.com/wavetermdev/waveterm/pkg/service/windowservice"
 	"github.com/wavetermdev/waveterm/pkg/service/workspaceservice"
@@ -30,6 +31,7 @@ var ServiceMap = map[string]any{
 	"window":    &windowservice.WindowService{},
 	"workspace": &workspaceservice.WorkspaceService{},
 	"userinput": &userinputservice.UserInputService{},
+	"telemetry": &telemetryservice.TelemetryService{},
 }
 
 var contextRType = reflect.TypeOf((*context.Context)(nil)).Elem()
@@ -0,0 +1,36 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetryservice
+
+import (
+	"context"
+
+	"github.com/wavetermdev/waveterm/pkg/telemetry"
+	"github.com/wavetermdev/waveterm/pkg/tsgen/tsgenmeta"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+type TelemetryService struct{}
+
+func (ts *TelemetryService) RecordActivity_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "record a use of a feature (stored locally in daily rollups, never uploaded)",
+		ArgNames: []string{"ctx", "record"},
+	}
+}
+
+func (ts *TelemetryService) RecordActivity(ctx context.Context, rec wshrpc.ActivityRecord) error {
+	return telemetry.RecordActivity(ctx, rec)
+}
+
+func (ts *TelemetryService) GetActivityStats_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		Desc:     "get per-feature usage counts for the last sinceDays days",
+		ArgNames: []string{"ctx", "sinceDays"},
+	}
+}
+
+func (ts *TelemetryService) GetActivityStats(ctx context.Context, sinceDays int) ([]wshrpc.ActivityStat, error) {
+	return telemetry.GetActivityStats(ctx, sinceDays)
+}
@@ -11,8 +11,5 @@ type UserInputService struct {
 }
 
 func (uis *UserInputService) SendUserInputResponse(response *userinput.UserInputResponse) {
-	select {
-	case userinput.MainUserInputHandler.Channels[response.RequestId] <- response:
-	default:
-	}
+	userinput.SendUserInputResponse(response)
 }
@@ -40,12 +40,12 @@ func (svc *WindowService) GetWindow(windowId string) (*waveobj.Window, error) {
 
 func (svc *WindowService) CreateWindow_Meta() tsgenmeta.MethodMeta {
 	return tsgenmeta.MethodMeta{
-		ArgNames: []string{"ctx", "winSize", "workspaceId"},
+		ArgNames: []string{"ctx", "pos", "winSize", "workspaceId"},
 	}
 }
 
-func (svc *WindowService) CreateWindow(ctx context.Context, winSize *waveobj.WinSize, workspaceId string) (*waveobj.Window, error) {
-	window, err := wcore.CreateWindow(ctx, winSize, workspaceId)
+func (svc *WindowService) CreateWindow(ctx context.Context, pos *waveobj.Point, winSize *waveobj.WinSize, workspaceId string) (*waveobj.Window, error) {
+	window, err := wcore.MakeWindow(ctx, &wcore.MakeWindowOpts{Pos: pos, WinSize: winSize, WorkspaceId: workspaceId})
 	if err != nil {
 		return nil, fmt.Errorf("error creating window: %w", err)
 	}
@@ -107,7 +107,7 @@ func (svc *WindowService) MoveBlockToNewWindow(ctx context.Context, currentTabId
 	if !foundBlock {
 		return nil, fmt.Errorf("block not found in current tab")
 	}
-	newWindow, err := wcore.CreateWindow(ctx, nil, "")
+	newWindow, err := wcore.MakeWindow(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating window: %w", err)
 	}
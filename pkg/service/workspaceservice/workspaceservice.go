@@ -67,6 +67,19 @@ func (svc *WorkspaceService) UpdateWorkspace(ctx context.Context, workspaceId st
 	return updates, nil
 }
 
+func (svc *WorkspaceService) RenameWorkspace_Meta() tsgenmeta.MethodMeta {
+	return tsgenmeta.MethodMeta{
+		ArgNames: []string{"ctx", "workspaceId", "name"},
+	}
+}
+
+func (svc *WorkspaceService) RenameWorkspace(ctx context.Context, workspaceId string, name string) (waveobj.UpdatesRtnType, error) {
+	if name == "" {
+		return nil, fmt.Errorf("workspace name is required")
+	}
+	return svc.UpdateWorkspace(ctx, workspaceId, name, "", "", true)
+}
+
 func (svc *WorkspaceService) GetWorkspace_Meta() tsgenmeta.MethodMeta {
 	return tsgenmeta.MethodMeta{
 		ArgNames:   []string{"workspaceId"},
@@ -241,7 +254,7 @@ func (svc *WorkspaceService) SetActiveTab(workspaceId string, tabId string) (wav
 	extraUpdates = append(extraUpdates, updates...)
 	extraUpdates = append(extraUpdates, waveobj.MakeUpdate(tab))
 	extraUpdates = append(extraUpdates, waveobj.MakeUpdates(blocks)...)
-	return extraUpdates, nil
+	return waveobj.DeduplicateUpdates(extraUpdates), nil
 }
 
 type CloseTabRtnType struct {
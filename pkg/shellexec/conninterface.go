@@ -29,17 +29,23 @@ type ConnInterface interface {
 }
 
 type CmdWrap struct {
-	Cmd      *exec.Cmd
-	WaitOnce *sync.Once
-	WaitErr  error
+	Cmd *exec.Cmd
 	pty.Pty
+	wait *cmdWaitState // shared by the copies of the CmdWrap (the methods have value receivers)
+}
+
+// Cmd.ProcessState is written by Cmd.Wait, so other goroutines must check DoneCh instead of reading it
+type cmdWaitState struct {
+	Once   sync.Once
+	DoneCh chan struct{} // closed after Cmd.Wait returns (WaitErr is set before)
+	Err    error
 }
 
 func MakeCmdWrap(cmd *exec.Cmd, cmdPty pty.Pty) CmdWrap {
 	return CmdWrap{
-		Cmd:      cmd,
-		WaitOnce: &sync.Once{},
-		Pty:      cmdPty,
+		Cmd:  cmd,
+		Pty:  cmdPty,
+		wait: &cmdWaitState{DoneCh: make(chan struct{})},
 	}
 }
 
@@ -48,10 +54,20 @@ func (cw CmdWrap) Kill() {
 }
 
 func (cw CmdWrap) Wait() error {
-	cw.WaitOnce.Do(func() {
-		cw.WaitErr = cw.Cmd.Wait()
+	cw.wait.Once.Do(func() {
+		cw.wait.Err = cw.Cmd.Wait()
+		close(cw.wait.DoneCh)
 	})
-	return cw.WaitErr
+	return cw.wait.Err
+}
+
+func (cw CmdWrap) isDone() bool {
+	select {
+	case <-cw.wait.DoneCh:
+		return true
+	default:
+		return false
+	}
 }
 
 // only valid once Wait() has returned (or you know Cmd is done)
@@ -67,7 +83,7 @@ func (cw CmdWrap) KillGraceful(timeout time.Duration) {
 	if cw.Cmd.Process == nil {
 		return
 	}
-	if cw.Cmd.ProcessState != nil && cw.Cmd.ProcessState.Exited() {
+	if cw.isDone() {
 		return
 	}
 	if runtime.GOOS == "windows" {
@@ -79,8 +95,9 @@ func (cw CmdWrap) KillGraceful(timeout time.Duration) {
 		defer func() {
 			panichandler.PanicHandler("KillGraceful:Kill", recover())
 		}()
-		time.Sleep(timeout)
-		if cw.Cmd.ProcessState == nil || !cw.Cmd.ProcessState.Exited() {
+		select {
+		case <-cw.wait.DoneCh:
+		case <-time.After(timeout):
 			cw.Cmd.Process.Kill() // force kill if it is already not exited
 		}
 	}()
@@ -0,0 +1,48 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellexec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const procCwdLsofTimeout = 2 * time.Second
+
+// returns the current directory of a local process (from /proc on linux, lsof on macos)
+func GetProcessCwd(pid int) (string, error) {
+	if pid <= 0 {
+		return "", fmt.Errorf("invalid pid %d", pid)
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	case "darwin":
+		return getProcessCwdLsof(pid)
+	default:
+		return "", fmt.Errorf("getting the cwd of a process is not supported on %s", runtime.GOOS)
+	}
+}
+
+// "lsof -Fn" prints one field per line, the name field (the path) starts with "n"
+func getProcessCwdLsof(pid int) (string, error) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), procCwdLsofTimeout)
+	defer cancelFn()
+	output, err := exec.CommandContext(ctx, "lsof", "-a", "-p", strconv.Itoa(pid), "-d", "cwd", "-Fn").Output()
+	if err != nil {
+		return "", fmt.Errorf("running lsof: %w", err)
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "n/") {
+			return line[1:], nil
+		}
+	}
+	return "", fmt.Errorf("no cwd in lsof output for pid %d", pid)
+}
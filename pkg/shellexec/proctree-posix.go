@@ -0,0 +1,59 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package shellexec
+
+import (
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/sys/unix"
+)
+
+// returns the running processes in the session led by leaderPid (including the leader).  local shells are
+// started with setsid (by pty.Start), so this also finds the jobs the shell moved to their own process groups.
+func ListProcessTree(leaderPid int) []int {
+	if leaderPid <= 1 {
+		return nil
+	}
+	pids, err := process.Pids()
+	if err != nil {
+		return nil
+	}
+	var rtn []int
+	for _, pid := range pids {
+		sid, err := unix.Getsid(int(pid))
+		if err != nil || sid != leaderPid {
+			continue
+		}
+		if processIsRunning(int(pid)) {
+			rtn = append(rtn, int(pid))
+		}
+	}
+	return rtn
+}
+
+// signals every process group that the pids belong to (so processes forked since the list was made are included)
+func signalProcessTree(leaderPid int, pids []int, force bool) {
+	sig := unix.SIGTERM
+	if force {
+		sig = unix.SIGKILL
+	}
+	pgids := make(map[int]bool)
+	for _, pid := range pids {
+		pgid, err := unix.Getpgid(pid)
+		if err != nil {
+			continue
+		}
+		pgids[pgid] = true
+	}
+	for pgid := range pgids {
+		if pgid <= 1 {
+			continue
+		}
+		unix.Kill(-pgid, sig)
+	}
+	for _, pid := range pids {
+		unix.Kill(pid, sig)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package shellexec
+
+import (
+	"os/exec"
+	"strconv"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// returns the running processes started by leaderPid (including the leader and their descendants)
+func ListProcessTree(leaderPid int) []int {
+	if leaderPid <= 0 {
+		return nil
+	}
+	procs, err := process.Processes()
+	if err != nil {
+		return nil
+	}
+	children := make(map[int][]int)
+	for _, proc := range procs {
+		ppid, err := proc.Ppid()
+		if err != nil {
+			continue
+		}
+		children[int(ppid)] = append(children[int(ppid)], int(proc.Pid))
+	}
+	var rtn []int
+	seen := map[int]bool{leaderPid: true}
+	queue := []int{leaderPid}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		if processIsRunning(pid) {
+			rtn = append(rtn, pid)
+		}
+		for _, childPid := range children[pid] {
+			if !seen[childPid] {
+				seen[childPid] = true
+				queue = append(queue, childPid)
+			}
+		}
+	}
+	return rtn
+}
+
+// uses taskkill (/T works from the leader while it is running, the pids cover children that were re-parented)
+func signalProcessTree(leaderPid int, pids []int, force bool) {
+	taskKill := func(pid int, tree bool) {
+		args := []string{"/PID", strconv.Itoa(pid)}
+		if tree {
+			args = append(args, "/T")
+		}
+		if force {
+			args = append(args, "/F")
+		}
+		exec.Command("taskkill", args...).Run()
+	}
+	if processIsRunning(leaderPid) {
+		taskKill(leaderPid, true)
+	}
+	for _, pid := range pids {
+		if pid != leaderPid && processIsRunning(pid) {
+			taskKill(pid, false)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellexec
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+const ProcTreePollInterval = 50 * time.Millisecond
+const ProcTreeForceKillWait = time.Second
+
+// returns the pid of the shell process if it is running on this machine (0 for remote and wsl shells)
+func (sp *ShellProc) LocalPid() int {
+	cw, ok := sp.Cmd.(CmdWrap)
+	if !ok || cw.Cmd.Process == nil {
+		return 0
+	}
+	return cw.Cmd.Process.Pid
+}
+
+// sends the processes in the tree a graceful kill (SIGTERM), waits up to grace for them to exit,
+// and then force kills the survivors.  returns the number of processes that exited.
+func KillProcessTree(leaderPid int, grace time.Duration) int {
+	pids := ListProcessTree(leaderPid)
+	if len(pids) == 0 {
+		return 0
+	}
+	signalProcessTree(leaderPid, pids, false)
+	survivors := waitForProcsToExit(pids, grace)
+	if len(survivors) > 0 {
+		signalProcessTree(leaderPid, survivors, true)
+		survivors = waitForProcsToExit(survivors, ProcTreeForceKillWait)
+	}
+	return len(pids) - len(survivors)
+}
+
+// returns the pids that are still running after timeout
+func waitForProcsToExit(pids []int, timeout time.Duration) []int {
+	deadline := time.Now().Add(timeout)
+	for {
+		var running []int
+		for _, pid := range pids {
+			if processIsRunning(pid) {
+				running = append(running, pid)
+			}
+		}
+		if len(running) == 0 || time.Now().After(deadline) {
+			return running
+		}
+		time.Sleep(ProcTreePollInterval)
+	}
+}
+
+// zombies (exited but not yet reaped) are not running
+func processIsRunning(pid int) bool {
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return false
+	}
+	status, err := proc.Status()
+	if err != nil {
+		// the process exited between the two calls
+		return false
+	}
+	for _, st := range status {
+		if st == process.Zombie {
+			return false
+		}
+	}
+	return true
+}
@@ -12,7 +12,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -47,6 +49,7 @@ type CommandOptsType struct {
 
 type ShellProc struct {
 	ConnName  string
+	ShellType string // shellutil.ShellType_*, "" if the shell isn't known
 	Cmd       ConnInterface
 	CloseOnce *sync.Once
 	DoneCh    chan any // closed after proc.Wait() returns
@@ -163,6 +166,7 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 		}
 		shellPath = remoteShellPath
 	}
+	shellType := shellutil.GetShellTypeFromShellPath(shellPath)
 	var shellOpts []string
 	log.Printf("detected shell: %s", shellPath)
 
@@ -173,7 +177,11 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 	}
 
 	homeDir := wsl.GetHomeDir(utilCtx, client)
-	shellOpts = append(shellOpts, "~", "-d", client.Name())
+	if cmdOpts.Cwd != "" {
+		shellOpts = append(shellOpts, "--cd", cmdOpts.Cwd, "-d", client.Name())
+	} else {
+		shellOpts = append(shellOpts, "~", "-d", client.Name())
+	}
 
 	var subShellOpts []string
 
@@ -221,6 +229,13 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 	} else {
 		shellOpts = append(shellOpts, "--", fmt.Sprintf(`%s=%s`, wshutil.WaveJwtTokenVarName, jwtToken))
 	}
+	for _, envKey := range getRemoteEnvKeys(cmdOpts.Env) {
+		if remote.IsPowershell(shellPath) {
+			shellOpts = append(shellOpts, fmt.Sprintf(`$env:%s=%s;`, envKey, quotePowershellString(cmdOpts.Env[envKey])))
+		} else {
+			shellOpts = append(shellOpts, fmt.Sprintf(`%s=%s`, envKey, genconn.HardQuote(cmdOpts.Env[envKey])))
+		}
+	}
 
 	if isZshShell(shellPath) {
 		shellOpts = append(shellOpts, fmt.Sprintf(`ZDOTDIR=%s/.waveterm/%s`, homeDir, shellutil.ZshIntegrationDir))
@@ -242,7 +257,7 @@ func StartWslShellProc(ctx context.Context, termSize waveobj.TermSize, cmdStr st
 		return nil, err
 	}
 	cmdWrap := MakeCmdWrap(ecmd, cmdPty)
-	return &ShellProc{Cmd: cmdWrap, ConnName: conn.GetName(), CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
+	return &ShellProc{Cmd: cmdWrap, ConnName: conn.GetName(), ShellType: shellType, CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
 }
 
 func StartRemoteShellProcNoWsh(termSize waveobj.TermSize, cmdStr string, cmdOpts CommandOptsType, conn *conncontroller.SSHConn) (*ShellProc, error) {
@@ -301,6 +316,7 @@ func StartRemoteShellProc(termSize waveobj.TermSize, cmdStr string, cmdOpts Comm
 	if shellPath == "" {
 		shellPath = remoteInfo.Shell
 	}
+	shellType := shellutil.GetShellTypeFromShellPath(shellPath)
 	var shellOpts []string
 	var cmdCombined string
 	log.Printf("using shell: %s", shellPath)
@@ -396,6 +412,8 @@ func StartRemoteShellProc(termSize waveobj.TermSize, cmdStr string, cmdOpts Comm
 	} else {
 		cmdCombined = fmt.Sprintf(`%s=%s %s`, wshutil.WaveJwtTokenVarName, jwtToken, cmdCombined)
 	}
+	// most servers don't accept Setenv, so the cwd and env are also set in the command
+	cmdCombined = makeRemoteCwdEnvPrefix(shellPath, cmdOpts) + cmdCombined
 
 	session.RequestPty("xterm-256color", termSize.Rows, termSize.Cols, nil)
 	sessionWrap := MakeSessionWrap(session, cmdCombined, pipePty)
@@ -404,7 +422,59 @@ func StartRemoteShellProc(termSize waveobj.TermSize, cmdStr string, cmdOpts Comm
 		pipePty.Close()
 		return nil, err
 	}
-	return &ShellProc{Cmd: sessionWrap, ConnName: conn.GetName(), CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
+	return &ShellProc{Cmd: sessionWrap, ConnName: conn.GetName(), ShellType: shellType, CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
+}
+
+var envKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// returns the (sorted) env keys that are set in remote commands (the jwt token is set separately)
+func getRemoteEnvKeys(env map[string]string) []string {
+	var keys []string
+	for envKey := range env {
+		if envKey == wshutil.WaveJwtTokenVarName || !envKeyRe.MatchString(envKey) {
+			continue
+		}
+		keys = append(keys, envKey)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func quotePowershellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quotes the cwd for a posix shell, leaving a leading ~ unquoted so it still expands to the remote home directory
+func quoteRemoteCwd(cwd string) string {
+	if cwd == "~" {
+		return cwd
+	}
+	if strings.HasPrefix(cwd, "~/") {
+		return "~/" + genconn.HardQuote(cwd[2:])
+	}
+	return genconn.HardQuote(cwd)
+}
+
+// returns the commands that change to cmdOpts.Cwd and set cmdOpts.Env before the shell is started.
+// a cwd that doesn't exist prints an error and the shell starts in the home directory.
+func makeRemoteCwdEnvPrefix(shellPath string, cmdOpts CommandOptsType) string {
+	var buf strings.Builder
+	isPwsh := remote.IsPowershell(shellPath)
+	if cmdOpts.Cwd != "" {
+		if isPwsh {
+			buf.WriteString(fmt.Sprintf("Set-Location %s; ", quotePowershellString(cmdOpts.Cwd)))
+		} else {
+			buf.WriteString(fmt.Sprintf("cd %s; ", quoteRemoteCwd(cmdOpts.Cwd)))
+		}
+	}
+	for _, envKey := range getRemoteEnvKeys(cmdOpts.Env) {
+		if isPwsh {
+			buf.WriteString(fmt.Sprintf("$env:%s=%s; ", envKey, quotePowershellString(cmdOpts.Env[envKey])))
+		} else {
+			buf.WriteString(fmt.Sprintf("%s=%s ", envKey, genconn.HardQuote(cmdOpts.Env[envKey])))
+		}
+	}
+	return buf.String()
 }
 
 func isZshShell(shellPath string) bool {
@@ -509,7 +579,7 @@ func StartShellProc(termSize waveobj.TermSize, cmdStr string, cmdOpts CommandOpt
 		return nil, err
 	}
 	cmdWrap := MakeCmdWrap(ecmd, cmdPty)
-	return &ShellProc{Cmd: cmdWrap, CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
+	return &ShellProc{Cmd: cmdWrap, ShellType: shellutil.GetShellTypeFromShellPath(shellPath), CloseOnce: &sync.Once{}, DoneCh: make(chan any)}, nil
 }
 
 func RunSimpleCmdInPty(ecmd *exec.Cmd, termSize waveobj.TermSize) ([]byte, error) {
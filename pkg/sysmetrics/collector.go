@@ -0,0 +1,163 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysmetrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/load"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// every connection is sampled once per SampleInterval (by its connserver), the samples are shared by all of the
+// connection's plot blocks.  this is also the smallest plot:interval.
+const SampleInterval = time.Second
+
+const BytesPerGB = 1073741824
+const BytesPerMB = 1048576
+
+// samples the metrics of the machine it runs on.  disk and network metrics are rates, so they are only in the
+// samples after the first one.
+type Collector struct {
+	lastTs   time.Time
+	lastDisk map[string]disk.IOCountersStat
+	lastNet  map[string]net.IOCountersStat
+}
+
+func MakeCollector() *Collector {
+	return &Collector{}
+}
+
+func (c *Collector) Sample() wshrpc.TimeSeriesData {
+	now := time.Now()
+	values := make(map[string]float64)
+	getCpuData(values)
+	getMemData(values)
+	getLoadData(values)
+	var elapsedSecs float64
+	if !c.lastTs.IsZero() {
+		elapsedSecs = now.Sub(c.lastTs).Seconds()
+	}
+	c.lastDisk = getDiskData(values, c.lastDisk, elapsedSecs)
+	c.lastNet = getNetData(values, c.lastNet, elapsedSecs)
+	c.lastTs = now
+	return wshrpc.TimeSeriesData{Ts: now.UnixMilli(), Values: values}
+}
+
+func getCpuData(values map[string]float64) {
+	percentArr, err := cpu.Percent(0, false)
+	if err != nil {
+		return
+	}
+	if len(percentArr) > 0 {
+		values[wshrpc.TimeSeries_Cpu] = percentArr[0]
+	}
+	percentArr, err = cpu.Percent(0, true)
+	if err != nil {
+		return
+	}
+	for idx, percent := range percentArr {
+		values[wshrpc.TimeSeries_Cpu+":"+strconv.Itoa(idx)] = percent
+	}
+}
+
+func getMemData(values map[string]float64) {
+	memData, err := mem.VirtualMemory()
+	if err != nil {
+		return
+	}
+	values["mem:total"] = float64(memData.Total) / BytesPerGB
+	values["mem:available"] = float64(memData.Available) / BytesPerGB
+	values["mem:used"] = float64(memData.Used) / BytesPerGB
+	values["mem:free"] = float64(memData.Free) / BytesPerGB
+}
+
+// not available on windows
+func getLoadData(values map[string]float64) {
+	avg, err := load.Avg()
+	if err != nil {
+		return
+	}
+	values["load:1"] = avg.Load1
+	values["load:5"] = avg.Load5
+	values["load:15"] = avg.Load15
+}
+
+func getRate(cur uint64, last uint64, elapsedSecs float64) float64 {
+	if cur < last {
+		// the counter was reset
+		return 0
+	}
+	return float64(cur-last) / BytesPerMB / elapsedSecs
+}
+
+func isVirtualDisk(name string) bool {
+	return strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram")
+}
+
+// disk:[name]:read and disk:[name]:write (and disk:read and disk:write for all disks) in MB/s
+func getDiskData(values map[string]float64, last map[string]disk.IOCountersStat, elapsedSecs float64) map[string]disk.IOCountersStat {
+	counters, err := disk.IOCounters()
+	if err != nil {
+		return nil
+	}
+	if last == nil || elapsedSecs <= 0 {
+		return counters
+	}
+	var totalRead, totalWrite float64
+	for name, stat := range counters {
+		lastStat, ok := last[name]
+		if !ok || isVirtualDisk(name) {
+			continue
+		}
+		readRate := getRate(stat.ReadBytes, lastStat.ReadBytes, elapsedSecs)
+		writeRate := getRate(stat.WriteBytes, lastStat.WriteBytes, elapsedSecs)
+		values[wshrpc.TimeSeries_Disk+":"+name+":read"] = readRate
+		values[wshrpc.TimeSeries_Disk+":"+name+":write"] = writeRate
+		totalRead += readRate
+		totalWrite += writeRate
+	}
+	values[wshrpc.TimeSeries_Disk+":read"] = totalRead
+	values[wshrpc.TimeSeries_Disk+":write"] = totalWrite
+	return counters
+}
+
+func isLoopbackInterface(name string) bool {
+	return strings.HasPrefix(name, "lo") || strings.HasPrefix(strings.ToLower(name), "loopback")
+}
+
+// net:recv and net:sent in MB/s (for all of the interfaces except loopback)
+func getNetData(values map[string]float64, last map[string]net.IOCountersStat, elapsedSecs float64) map[string]net.IOCountersStat {
+	counterArr, err := net.IOCounters(true)
+	if err != nil {
+		return nil
+	}
+	counters := make(map[string]net.IOCountersStat)
+	for _, stat := range counterArr {
+		if !isLoopbackInterface(stat.Name) {
+			counters[stat.Name] = stat
+		}
+	}
+	if last == nil || elapsedSecs <= 0 {
+		return counters
+	}
+	var totalRecv, totalSent float64
+	for name, stat := range counters {
+		lastStat, ok := last[name]
+		if !ok {
+			continue
+		}
+		totalRecv += getRate(stat.BytesRecv, lastStat.BytesRecv, elapsedSecs)
+		totalSent += getRate(stat.BytesSent, lastStat.BytesSent, elapsedSecs)
+	}
+	values[wshrpc.TimeSeries_Net+":recv"] = totalRecv
+	values[wshrpc.TimeSeries_Net+":sent"] = totalSent
+	return counters
+}
@@ -0,0 +1,211 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysmetrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// the samples published by the connservers (sysinfo events) are kept in a ring buffer per connection and metric,
+// so a new plot block gets the history right away.
+
+const DefaultHistoryLen = 3600 // an hour of samples
+const MaxHistoryLen = 86400
+
+type sample struct {
+	ts  int64
+	val float64
+}
+
+type metricRing struct {
+	samples []sample
+	next    int // where the next sample goes
+	full    bool
+}
+
+func makeMetricRing(size int) *metricRing {
+	return &metricRing{samples: make([]sample, size)}
+}
+
+// oldest first
+func (r *metricRing) getAll() []sample {
+	if !r.full {
+		return append([]sample(nil), r.samples[:r.next]...)
+	}
+	rtn := make([]sample, 0, len(r.samples))
+	rtn = append(rtn, r.samples[r.next:]...)
+	return append(rtn, r.samples[:r.next]...)
+}
+
+func (r *metricRing) add(s sample) {
+	r.samples[r.next] = s
+	r.next++
+	if r.next == len(r.samples) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// keeps the newest samples
+func (r *metricRing) resize(size int) {
+	samples := r.getAll()
+	if len(samples) > size {
+		samples = samples[len(samples)-size:]
+	}
+	r.samples = make([]sample, size)
+	r.next = copy(r.samples, samples)
+	r.full = r.next == size
+	if r.full {
+		r.next = 0
+	}
+}
+
+var historyLock = &sync.Mutex{}
+var historyMap = make(map[string]map[string]*metricRing) // connName => metric => ring
+
+// the app:metricshistorylen setting (the number of samples kept per metric)
+func GetHistoryLen() int {
+	historyLen := wconfig.GetWatcher().GetFullConfig().Settings.AppMetricsHistoryLen
+	if historyLen <= 0 {
+		return DefaultHistoryLen
+	}
+	return min(historyLen, MaxHistoryLen)
+}
+
+func AddSample(connName string, data wshrpc.TimeSeriesData) {
+	historyLen := GetHistoryLen()
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	rings := historyMap[connName]
+	if rings == nil {
+		rings = make(map[string]*metricRing)
+		historyMap[connName] = rings
+	}
+	for metric, val := range data.Values {
+		ring := rings[metric]
+		if ring == nil {
+			ring = makeMetricRing(historyLen)
+			rings[metric] = ring
+		} else if len(ring.samples) != historyLen {
+			ring.resize(historyLen)
+		}
+		ring.add(sample{ts: data.Ts, val: val})
+	}
+}
+
+// adds the sample from a sysinfo event (the scope is the connection)
+func AddSysInfoEvent(event wps.WaveEvent) {
+	if len(event.Scopes) == 0 {
+		return
+	}
+	var data wshrpc.TimeSeriesData
+	if err := utilfn.ReUnmarshal(&data, event.Data); err != nil || data.Ts == 0 {
+		return
+	}
+	AddSample(event.Scopes[0], data)
+}
+
+func HasConnMetrics(connName string) bool {
+	historyLock.Lock()
+	defer historyLock.Unlock()
+	return historyMap[connName] != nil
+}
+
+// a metric selects itself and the metrics under it ("disk" selects disk:read, disk:sda:read, etc.), no metrics
+// selects all of them
+func MetricMatches(metric string, selectors []string) bool {
+	if len(selectors) == 0 {
+		return true
+	}
+	for _, sel := range selectors {
+		if metric == sel || strings.HasPrefix(metric, sel+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the last count samples (all of them if count is 0), oldest first, with the selected metrics.  when interval is
+// more than SampleInterval the samples are spaced (about) interval apart, counting back from the newest sample.
+func GetHistory(connName string, metrics []string, count int, interval time.Duration) []wshrpc.TimeSeriesData {
+	valuesByTs := make(map[int64]map[string]float64)
+	historyLock.Lock()
+	for metric, ring := range historyMap[connName] {
+		if !MetricMatches(metric, metrics) {
+			continue
+		}
+		for _, s := range ring.getAll() {
+			values := valuesByTs[s.ts]
+			if values == nil {
+				values = make(map[string]float64)
+				valuesByTs[s.ts] = values
+			}
+			values[metric] = s.val
+		}
+	}
+	historyLock.Unlock()
+	tsArr := make([]int64, 0, len(valuesByTs))
+	for ts := range valuesByTs {
+		tsArr = append(tsArr, ts)
+	}
+	sort.Slice(tsArr, func(i, j int) bool { return tsArr[i] > tsArr[j] })
+	// samples within half a sample interval of the next point are taken (the sampling is not exact)
+	spacingMs := (interval - SampleInterval/2).Milliseconds()
+	var rtn []wshrpc.TimeSeriesData
+	for _, ts := range tsArr {
+		if count > 0 && len(rtn) >= count {
+			break
+		}
+		if interval > SampleInterval && len(rtn) > 0 && rtn[len(rtn)-1].Ts-ts < spacingMs {
+			continue
+		}
+		rtn = append(rtn, wshrpc.TimeSeriesData{Ts: ts, Values: valuesByTs[ts]})
+	}
+	for i, j := 0, len(rtn)-1; i < j; i, j = i+1, j-1 {
+		rtn[i], rtn[j] = rtn[j], rtn[i]
+	}
+	return rtn
+}
+
+func GetMetricUnit(metric string) string {
+	group, _, _ := strings.Cut(metric, ":")
+	switch group {
+	case wshrpc.TimeSeries_Cpu:
+		return "%"
+	case wshrpc.TimeSeries_Mem:
+		return "GB"
+	case wshrpc.TimeSeries_Disk, wshrpc.TimeSeries_Net:
+		return "MB/s"
+	}
+	return ""
+}
+
+// sorts the parts of the names that are numbers by value (so cpu:2 is before cpu:10)
+func SortMetricNames(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		partsA := strings.Split(names[i], ":")
+		partsB := strings.Split(names[j], ":")
+		for idx := 0; idx < len(partsA) && idx < len(partsB); idx++ {
+			if partsA[idx] == partsB[idx] {
+				continue
+			}
+			numA, errA := strconv.Atoi(partsA[idx])
+			numB, errB := strconv.Atoi(partsB[idx])
+			if errA == nil && errB == nil {
+				return numA < numB
+			}
+			return partsA[idx] < partsB[idx]
+		}
+		return len(partsA) < len(partsB)
+	})
+}
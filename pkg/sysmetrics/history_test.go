@@ -0,0 +1,75 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysmetrics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetricRing(t *testing.T) {
+	ring := makeMetricRing(3)
+	for idx := 1; idx <= 5; idx++ {
+		ring.add(sample{ts: int64(idx), val: float64(idx)})
+	}
+	if got := ring.getAll(); len(got) != 3 || got[0].ts != 3 || got[2].ts != 5 {
+		t.Fatalf("expected the last 3 samples, got %v", got)
+	}
+	ring.resize(2)
+	if got := ring.getAll(); len(got) != 2 || got[0].ts != 4 || got[1].ts != 5 {
+		t.Errorf("expected the newest samples to be kept, got %v", got)
+	}
+	ring.resize(4)
+	ring.add(sample{ts: 6})
+	if got := ring.getAll(); len(got) != 3 || got[2].ts != 6 {
+		t.Errorf("expected 3 samples after growing, got %v", got)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	cpuRing := makeMetricRing(100)
+	cpu0Ring := makeMetricRing(100)
+	memRing := makeMetricRing(100)
+	for idx := 0; idx < 10; idx++ {
+		// about a second apart
+		ts := int64(idx*1000 + idx%3)
+		cpuRing.add(sample{ts: ts, val: float64(idx)})
+		cpu0Ring.add(sample{ts: ts, val: float64(idx)})
+		memRing.add(sample{ts: ts, val: 1})
+	}
+	historyLock.Lock()
+	historyMap["test-conn"] = map[string]*metricRing{"cpu": cpuRing, "cpu:0": cpu0Ring, "mem:used": memRing}
+	historyLock.Unlock()
+	t.Cleanup(func() {
+		historyLock.Lock()
+		delete(historyMap, "test-conn")
+		historyLock.Unlock()
+	})
+
+	samples := GetHistory("test-conn", []string{"cpu"}, 3, 0)
+	if len(samples) != 3 || samples[2].Values["cpu"] != 9 || len(samples[2].Values) != 2 {
+		t.Errorf("expected the last 3 samples with cpu and cpu:0, got %v", samples)
+	}
+	samples = GetHistory("test-conn", []string{"mem"}, 0, 3*time.Second)
+	var tsArr []int64
+	for _, s := range samples {
+		tsArr = append(tsArr, s.Ts)
+	}
+	if !reflect.DeepEqual(tsArr, []int64{0, 3000, 6000, 9000}) {
+		t.Errorf("expected samples 3s apart counting back from the newest, got %v", tsArr)
+	}
+	if len(GetHistory("test-conn", []string{"disk"}, 0, 0)) != 0 {
+		t.Errorf("expected no samples for an unknown metric")
+	}
+}
+
+func TestSortMetricNames(t *testing.T) {
+	names := []string{"cpu:10", "mem:used", "cpu", "cpu:2", "disk:sda:read", "disk:read"}
+	SortMetricNames(names)
+	expected := []string{"cpu", "cpu:2", "cpu:10", "disk:read", "disk:sda:read", "mem:used"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
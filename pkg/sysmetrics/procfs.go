@@ -0,0 +1,168 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysmetrics
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// for connections without wsh there is no connserver to sample the remote machine.  ProcSampleScript is run
+// over the ssh connection instead, it prints /proc/stat, /proc/meminfo, and /proc/loadavg once per SampleInterval
+// (so it only works on linux).  the samples have the same metrics as the Collector's cpu, mem, and load metrics.
+const ProcSampleScript = `test -r /proc/stat -a -r /proc/meminfo || exit 1
+while :; do
+echo "` + procSection_Stat + `"; cat /proc/stat
+echo "` + procSection_MemInfo + `"; cat /proc/meminfo
+echo "` + procSection_LoadAvg + `"; cat /proc/loadavg 2>/dev/null
+echo "` + procSection_End + `"
+sleep 1 || exit 1
+done`
+
+const (
+	procSection_Stat    = "--wave:stat--"
+	procSection_MemInfo = "--wave:meminfo--"
+	procSection_LoadAvg = "--wave:loadavg--"
+	procSection_End     = "--wave:end--"
+)
+
+type cpuTimes struct {
+	busy  uint64
+	total uint64
+}
+
+// parses the output of ProcSampleScript.  cpu percents are computed from the change in the cpu times, so they are
+// only in the samples after the first one.
+type ProcSampler struct {
+	lastCpu map[string]cpuTimes
+}
+
+func MakeProcSampler() *ProcSampler {
+	return &ProcSampler{}
+}
+
+// calls sampleFn for every sample read from r, returns when r is closed (when the script exits)
+func (p *ProcSampler) ReadSamples(r io.Reader, sampleFn func(wshrpc.TimeSeriesData)) error {
+	scanner := bufio.NewScanner(r)
+	var section string
+	var lines []string
+	sections := make(map[string][]string)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch line {
+		case procSection_Stat, procSection_MemInfo, procSection_LoadAvg:
+			section = line
+			lines = nil
+			continue
+		case procSection_End:
+			sampleFn(p.makeSample(time.Now(), sections))
+			section = ""
+			sections = make(map[string][]string)
+			continue
+		}
+		if section == "" {
+			continue
+		}
+		lines = append(lines, line)
+		sections[section] = lines
+	}
+	return scanner.Err()
+}
+
+func (p *ProcSampler) makeSample(ts time.Time, sections map[string][]string) wshrpc.TimeSeriesData {
+	values := make(map[string]float64)
+	p.parseStat(values, sections[procSection_Stat])
+	parseMemInfo(values, sections[procSection_MemInfo])
+	parseLoadAvg(values, sections[procSection_LoadAvg])
+	return wshrpc.TimeSeriesData{Ts: ts.UnixMilli(), Values: values}
+}
+
+// the "cpu" line is the total, the "cpuN" lines are the cores.  the fields are user, nice, system, idle, iowait,
+// irq, softirq, and steal (guest time is already in user).
+func (p *ProcSampler) parseStat(values map[string]float64, lines []string) {
+	curCpu := make(map[string]cpuTimes)
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		var times cpuTimes
+		for idx, field := range fields[1:min(len(fields), 9)] {
+			val, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				continue
+			}
+			times.total += val
+			// idle and iowait
+			if idx != 3 && idx != 4 {
+				times.busy += val
+			}
+		}
+		name := wshrpc.TimeSeries_Cpu
+		if coreNum := strings.TrimPrefix(fields[0], "cpu"); coreNum != "" {
+			name += ":" + coreNum
+		}
+		curCpu[name] = times
+		lastTimes, ok := p.lastCpu[name]
+		if !ok || times.total <= lastTimes.total || times.busy < lastTimes.busy {
+			continue
+		}
+		values[name] = float64(times.busy-lastTimes.busy) / float64(times.total-lastTimes.total) * 100
+	}
+	p.lastCpu = curCpu
+}
+
+// the values are in kB.  used is computed the same way as the Collector's (gopsutil's) used.
+func parseMemInfo(values map[string]float64, lines []string) {
+	memInfo := make(map[string]float64)
+	for _, line := range lines {
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		val, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		memInfo[name] = val * 1024 / BytesPerGB
+	}
+	total, ok := memInfo["MemTotal"]
+	if !ok {
+		return
+	}
+	free := memInfo["MemFree"]
+	cached := memInfo["Buffers"] + memInfo["Cached"] + memInfo["SReclaimable"]
+	available, ok := memInfo["MemAvailable"]
+	if !ok {
+		available = free + cached
+	}
+	values["mem:total"] = total
+	values["mem:available"] = available
+	values["mem:used"] = max(total-free-cached, 0)
+	values["mem:free"] = free
+}
+
+func parseLoadAvg(values map[string]float64, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fields := strings.Fields(lines[0])
+	if len(fields) < 3 {
+		return
+	}
+	for idx, name := range []string{"load:1", "load:5", "load:15"} {
+		if val, err := strconv.ParseFloat(fields[idx], 64); err == nil {
+			values[name] = val
+		}
+	}
+}
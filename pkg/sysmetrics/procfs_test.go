@@ -0,0 +1,66 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysmetrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func makeProcOutput(cpuLine string, cpu0Line string) string {
+	return strings.Join([]string{
+		procSection_Stat,
+		cpuLine,
+		cpu0Line,
+		"intr 12345 0 0",
+		"ctxt 67890",
+		procSection_MemInfo,
+		"MemTotal:        8388608 kB",
+		"MemFree:         2097152 kB",
+		"MemAvailable:    4194304 kB",
+		"Buffers:          524288 kB",
+		"Cached:          1048576 kB",
+		"SReclaimable:     524288 kB",
+		procSection_LoadAvg,
+		"0.50 0.25 0.10 1/123 4567",
+		procSection_End,
+		"",
+	}, "\n")
+}
+
+func TestProcSampler(t *testing.T) {
+	output := makeProcOutput("cpu  100 0 100 800 0 0 0 0 0 0", "cpu0 50 0 50 400 0 0 0 0 0 0") +
+		makeProcOutput("cpu  150 0 150 850 50 0 0 0 0 0", "cpu0 100 0 100 400 0 0 0 0 0 0")
+	var samples []wshrpc.TimeSeriesData
+	err := MakeProcSampler().ReadSamples(strings.NewReader(output), func(tsData wshrpc.TimeSeriesData) {
+		samples = append(samples, tsData)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(samples))
+	}
+	if _, ok := samples[0].Values["cpu"]; ok {
+		t.Errorf("expected no cpu percent in the first sample")
+	}
+	expected := map[string]float64{
+		"cpu":           50,
+		"cpu:0":         100,
+		"mem:total":     8,
+		"mem:free":      2,
+		"mem:available": 4,
+		"mem:used":      4,
+		"load:1":        0.5,
+		"load:15":       0.1,
+	}
+	for name, val := range expected {
+		if got, ok := samples[1].Values[name]; !ok || math.Abs(got-val) > 0.001 {
+			t.Errorf("expected %s to be %v, got %v", name, val, got)
+		}
+	}
+}
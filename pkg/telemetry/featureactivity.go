@@ -0,0 +1,181 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/util/daystr"
+	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// feature activity is only stored locally (db_featureactivity is never uploaded), so it is
+// recorded even when telemetry is disabled
+
+const MaxFeatureNameLen = 50
+const MaxMetaValueLen = 50
+const MaxMetaCountsPerDay = 50
+const MaxStatsDays = 365
+
+var featureNameRe = regexp.MustCompile(`^[a-z][a-z0-9:#_-]*$`)
+
+type MetaCounts map[string]int
+
+func (mc MetaCounts) Value() (driver.Value, error) {
+	return dbutil.QuickValueJson(mc)
+}
+
+func (mc *MetaCounts) Scan(val interface{}) error {
+	return dbutil.QuickScanJson(mc, val)
+}
+
+type featureActivityRow struct {
+	Day        string     `db:"day"`
+	Feature    string     `db:"feature"`
+	Count      int        `db:"count"`
+	Errors     int        `db:"errors"`
+	DurationMs int64      `db:"durationms"`
+	MetaCounts MetaCounts `db:"metacounts"`
+}
+
+func ValidateActivityRecord(rec wshrpc.ActivityRecord) error {
+	if len(rec.Feature) > MaxFeatureNameLen || !featureNameRe.MatchString(rec.Feature) {
+		return fmt.Errorf("invalid feature name %q", rec.Feature)
+	}
+	if rec.DurationMs < 0 {
+		return fmt.Errorf("invalid duration %d", rec.DurationMs)
+	}
+	return nil
+}
+
+// meta values are counted as "key:value" (only scalar values are kept)
+func addMetaCounts(counts MetaCounts, meta map[string]any) {
+	for key, val := range meta {
+		var valStr string
+		switch v := val.(type) {
+		case string:
+			valStr = v
+		case bool, float64, int, int64:
+			valStr = fmt.Sprintf("%v", v)
+		default:
+			continue
+		}
+		if len(valStr) > MaxMetaValueLen {
+			valStr = valStr[0:MaxMetaValueLen]
+		}
+		countKey := key + ":" + valStr
+		if _, found := counts[countKey]; !found && len(counts) >= MaxMetaCountsPerDay {
+			continue
+		}
+		counts[countKey]++
+	}
+}
+
+// Wraps RecordActivity, spawns goroutine, and logs errors
+func GoRecordActivityWrap(rec wshrpc.ActivityRecord) {
+	go func() {
+		defer panichandler.PanicHandlerNoTelemetry("GoRecordActivityWrap", recover())
+		ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelFn()
+		err := RecordActivity(ctx, rec)
+		if err != nil {
+			log.Printf("error recording activity (%s): %v\n", rec.Feature, err)
+		}
+	}()
+}
+
+// adds the record to today's rollup for the feature
+func RecordActivity(ctx context.Context, rec wshrpc.ActivityRecord) error {
+	if err := ValidateActivityRecord(rec); err != nil {
+		return err
+	}
+	dayStr := daystr.GetCurDayStr()
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		var row featureActivityRow
+		query := `SELECT * FROM db_featureactivity WHERE day = ? AND feature = ?`
+		found := tx.Get(&row, query, dayStr, rec.Feature)
+		if row.MetaCounts == nil {
+			row.MetaCounts = make(MetaCounts)
+		}
+		row.Count++
+		if !rec.Success {
+			row.Errors++
+		}
+		row.DurationMs += rec.DurationMs
+		addMetaCounts(row.MetaCounts, rec.Meta)
+		if !found {
+			query = `INSERT INTO db_featureactivity (day, feature, count, errors, durationms, metacounts) VALUES (?, ?, ?, ?, ?, ?)`
+			tx.Exec(query, dayStr, rec.Feature, row.Count, row.Errors, row.DurationMs, row.MetaCounts)
+			return nil
+		}
+		query = `UPDATE db_featureactivity SET count = ?, errors = ?, durationms = ?, metacounts = ? WHERE day = ? AND feature = ?`
+		tx.Exec(query, row.Count, row.Errors, row.DurationMs, row.MetaCounts, dayStr, rec.Feature)
+		return nil
+	})
+}
+
+// returns per-feature totals for the last sinceDays days (including today), sorted by count
+func GetActivityStats(ctx context.Context, sinceDays int) ([]wshrpc.ActivityStat, error) {
+	if sinceDays <= 0 || sinceDays > MaxStatsDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", MaxStatsDays)
+	}
+	startDay := daystr.GetRelDayStr(-(sinceDays - 1))
+	rows, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*featureActivityRow, error) {
+		var rows []*featureActivityRow
+		query := `SELECT * FROM db_featureactivity WHERE day >= ?`
+		tx.Select(&rows, query, startDay)
+		return rows, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rollupActivityStats(rows), nil
+}
+
+func rollupActivityStats(rows []*featureActivityRow) []wshrpc.ActivityStat {
+	statMap := make(map[string]*wshrpc.ActivityStat)
+	durationMap := make(map[string]int64)
+	for _, row := range rows {
+		stat := statMap[row.Feature]
+		if stat == nil {
+			stat = &wshrpc.ActivityStat{Feature: row.Feature}
+			statMap[row.Feature] = stat
+		}
+		stat.Count += row.Count
+		stat.Errors += row.Errors
+		durationMap[row.Feature] += row.DurationMs
+		if row.Day > stat.LastDay {
+			stat.LastDay = row.Day
+		}
+		for key, val := range row.MetaCounts {
+			if stat.MetaCounts == nil {
+				stat.MetaCounts = make(map[string]int)
+			}
+			stat.MetaCounts[key] += val
+		}
+	}
+	var rtn []wshrpc.ActivityStat
+	for feature, stat := range statMap {
+		if stat.Count > 0 {
+			stat.AvgDurationMs = durationMap[feature] / int64(stat.Count)
+		}
+		rtn = append(rtn, *stat)
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		if rtn[i].Count != rtn[j].Count {
+			return rtn[i].Count > rtn[j].Count
+		}
+		return rtn[i].Feature < rtn[j].Feature
+	})
+	return rtn
+}
@@ -0,0 +1,45 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestRollupActivityStats(t *testing.T) {
+	rows := []*featureActivityRow{
+		{Day: "2025-01-01", Feature: "wsh:view", Count: 2, Errors: 1, DurationMs: 100, MetaCounts: MetaCounts{"view:preview": 2}},
+		{Day: "2025-01-03", Feature: "wsh:view", Count: 2, DurationMs: 300, MetaCounts: MetaCounts{"view:preview": 1, "view:web": 1}},
+		{Day: "2025-01-02", Feature: "wsh:ai", Count: 1, DurationMs: 50},
+	}
+	stats := rollupActivityStats(rows)
+	if len(stats) != 2 || stats[0].Feature != "wsh:view" || stats[1].Feature != "wsh:ai" {
+		t.Fatalf("unexpected stats %v", stats)
+	}
+	view := stats[0]
+	if view.Count != 4 || view.Errors != 1 || view.AvgDurationMs != 100 || view.LastDay != "2025-01-03" {
+		t.Errorf("unexpected view stats %+v", view)
+	}
+	if view.MetaCounts["view:preview"] != 3 || view.MetaCounts["view:web"] != 1 {
+		t.Errorf("unexpected meta counts %v", view.MetaCounts)
+	}
+}
+
+func TestValidateActivityRecord(t *testing.T) {
+	if err := ValidateActivityRecord(wshrpc.ActivityRecord{Feature: "wsh:term:send"}); err != nil {
+		t.Errorf("expected valid feature, got %v", err)
+	}
+	for _, feature := range []string{"", "Wsh", "wsh view", "1abc"} {
+		if err := ValidateActivityRecord(wshrpc.ActivityRecord{Feature: feature}); err == nil {
+			t.Errorf("expected %q to be invalid", feature)
+		}
+	}
+	counts := make(MetaCounts)
+	addMetaCounts(counts, map[string]any{"conn": "local", "n": float64(2), "obj": map[string]any{}})
+	if len(counts) != 2 || counts["conn:local"] != 1 || counts["n:2"] != 1 {
+		t.Errorf("unexpected meta counts %v", counts)
+	}
+}
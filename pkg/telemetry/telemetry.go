@@ -13,6 +13,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/util/daystr"
 	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wconfig"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
@@ -65,9 +66,19 @@ func (tdata *TelemetryData) Scan(val interface{}) error {
 	return dbutil.QuickScanJson(tdata, val)
 }
 
+// telemetry:enabled is set and the client hasn't opted out (client:telemetryoptout)
 func IsTelemetryEnabled() bool {
 	settings := wconfig.GetWatcher().GetFullConfig()
-	return settings.Settings.TelemetryEnabled
+	if !settings.Settings.TelemetryEnabled {
+		return false
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	client, err := wstore.DBGetSingletonCached[*waveobj.Client](ctx)
+	if err != nil || client == nil {
+		return true
+	}
+	return !client.Meta.GetBool(waveobj.MetaKey_ClientTelemetryOptOut, false)
 }
 
 func IsAutoUpdateEnabled() bool {
@@ -35,10 +35,18 @@ var ExtraTypes = []any{
 	waveobj.UIContext{},
 	eventbus.WSEventType{},
 	wps.WSFileEventData{},
+	wps.ControllerExitEventData{},
 	waveobj.LayoutActionData{},
+	waveobj.BlockMetaUpdateData{},
+	wshrpc.FileCreatedData{},
+	wshrpc.PreviewReloadData{},
+	wshrpc.TabTitleData{},
+	wshrpc.WebNavBlockedData{},
+	wshrpc.HostKeyChangedData{},
 	filestore.WaveFile{},
 	wconfig.FullConfigType{},
 	wconfig.WatcherUpdate{},
+	wconfig.ConfigValidationEventData{},
 	wshutil.RpcMessage{},
 	wshrpc.WshServerCommandMeta{},
 	userinput.UserInputRequest{},
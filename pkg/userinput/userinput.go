@@ -68,13 +68,44 @@ func (ui *UserInputHandler) sendRequestToFrontend(request *UserInputRequest) {
 	})
 }
 
+// delivers a response (from the frontend or wsh) to the GetUserInput call waiting for it
+func SendUserInputResponse(response *UserInputResponse) {
+	MainUserInputHandler.Lock.Lock()
+	uiCh := MainUserInputHandler.Channels[response.RequestId]
+	MainUserInputHandler.Lock.Unlock()
+	if uiCh == nil {
+		return
+	}
+	select {
+	case uiCh <- response:
+	default:
+	}
+}
+
+type prompterContextKey struct{}
+
+// a prompter sends the requests somewhere other than the frontend (e.g. to the wsh command that started a
+// connection), the response still comes back through SendUserInputResponse
+type Prompter func(request *UserInputRequest) error
+
+func ContextWithPrompter(ctx context.Context, prompter Prompter) context.Context {
+	return context.WithValue(ctx, prompterContextKey{}, prompter)
+}
+
 func GetUserInput(ctx context.Context, request *UserInputRequest) (*UserInputResponse, error) {
 	id, uiCh := MainUserInputHandler.registerChannel()
 	defer MainUserInputHandler.unregisterChannel(id)
 	request.RequestId = id
 	deadline, _ := ctx.Deadline()
 	request.TimeoutMs = int(time.Until(deadline).Milliseconds()) - 500
-	MainUserInputHandler.sendRequestToFrontend(request)
+	if prompter, ok := ctx.Value(prompterContextKey{}).(Prompter); ok {
+		err := prompter(request)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		MainUserInputHandler.sendRequestToFrontend(request)
+	}
 
 	var response *UserInputResponse
 	var err error
@@ -0,0 +1,102 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package dbutil
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sawka/txwrap"
+)
+
+// a db is reported as locked when calls are waiting and none of them has finished for this long (twice the sqlite
+// busy timeout, so a single slow call doesn't count)
+const DBLockedThreshold = 10 * time.Second
+
+// tracks the db calls as they run, so the health of a db can be reported without touching the db (which
+// could block if it is locked)
+type HealthTracker struct {
+	lock        sync.Mutex
+	inFlight    int
+	waitStartTs time.Time // when the oldest waiting call started, or the last call finished (if calls are waiting)
+	lastOkTs    time.Time
+	lastErr     string
+	lastErrTs   time.Time
+	lockedErr   bool // the last call failed because the db was locked
+}
+
+type HealthStatus struct {
+	InFlight  int    `json:"inflight"`
+	Locked    bool   `json:"locked"`
+	LastOkTs  int64  `json:"lastokts,omitempty"`
+	LastErr   string `json:"lasterr,omitempty"`
+	LastErrTs int64  `json:"lasterrts,omitempty"`
+}
+
+func isLockedErr(err error) bool {
+	errStr := err.Error()
+	return strings.Contains(errStr, "database is locked") || strings.Contains(errStr, "database table is locked")
+}
+
+// call before a db call, and call the returned func with the call's error when it is done
+func (h *HealthTracker) Begin() func(error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.inFlight == 0 {
+		h.waitStartTs = time.Now()
+	}
+	h.inFlight++
+	return h.done
+}
+
+// like Begin, for a txwrap transaction.  run the returned fn in the transaction and call the returned done func with
+// the transaction's error.  errors returned by fn don't count as db errors (the db itself worked).
+func (h *HealthTracker) TrackTx(fn func(tx *txwrap.TxWrap) error) (func(tx *txwrap.TxWrap) error, func(error)) {
+	doneFn := h.Begin()
+	var fnErr error
+	trackedFn := func(tx *txwrap.TxWrap) error {
+		fnErr = fn(tx)
+		return fnErr
+	}
+	return trackedFn, func(err error) {
+		if fnErr != nil {
+			err = nil
+		}
+		doneFn(err)
+	}
+}
+
+func (h *HealthTracker) done(err error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	now := time.Now()
+	h.inFlight--
+	h.waitStartTs = now
+	if err == nil {
+		h.lastOkTs = now
+		h.lockedErr = false
+		return
+	}
+	h.lastErr = err.Error()
+	h.lastErrTs = now
+	h.lockedErr = isLockedErr(err)
+}
+
+func (h *HealthTracker) GetStatus(now time.Time) HealthStatus {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	rtn := HealthStatus{
+		InFlight: h.inFlight,
+		LastErr:  h.lastErr,
+		Locked:   h.lockedErr || (h.inFlight > 0 && now.Sub(h.waitStartTs) > DBLockedThreshold),
+	}
+	if !h.lastOkTs.IsZero() {
+		rtn.LastOkTs = h.lastOkTs.UnixMilli()
+	}
+	if !h.lastErrTs.IsZero() {
+		rtn.LastErrTs = h.lastErrTs.UnixMilli()
+	}
+	return rtn
+}
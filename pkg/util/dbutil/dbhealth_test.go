@@ -0,0 +1,43 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package dbutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sawka/txwrap"
+)
+
+func TestHealthTracker(t *testing.T) {
+	var h HealthTracker
+	done := h.Begin()
+	if status := h.GetStatus(time.Now()); status.InFlight != 1 || status.Locked {
+		t.Fatalf("expected 1 call running (not locked), got %+v", status)
+	}
+	// a call that has been waiting too long means the db is stuck
+	if status := h.GetStatus(time.Now().Add(DBLockedThreshold + time.Second)); !status.Locked {
+		t.Errorf("expected locked after %v, got %+v", DBLockedThreshold, status)
+	}
+	done(nil)
+	if status := h.GetStatus(time.Now().Add(DBLockedThreshold + time.Second)); status.InFlight != 0 || status.Locked || status.LastOkTs == 0 {
+		t.Errorf("expected no calls running, got %+v", status)
+	}
+	h.Begin()(errors.New("database is locked (5) (SQLITE_BUSY)"))
+	if status := h.GetStatus(time.Now()); !status.Locked || status.LastErr == "" {
+		t.Errorf("expected locked after a busy error, got %+v", status)
+	}
+	h.Begin()(nil)
+	if status := h.GetStatus(time.Now()); status.Locked {
+		t.Errorf("expected not locked after a successful call, got %+v", status)
+	}
+	// errors from the transaction fn are not db errors
+	fn, txDone := h.TrackTx(func(tx *txwrap.TxWrap) error { return errors.New("not found") })
+	txErr := fn(nil)
+	txDone(txErr)
+	if status := h.GetStatus(time.Now()); status.LastErr != "database is locked (5) (SQLITE_BUSY)" {
+		t.Errorf("expected the fn error to be ignored, got %+v", status)
+	}
+}
@@ -5,9 +5,11 @@ package migrateutil
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"os"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -23,6 +25,32 @@ func GetMigrateVersion(m *migrate.Migrate) (uint, bool, error) {
 	return curVersion, dirty, err
 }
 
+// the version the migrations in migrationFS bring a database up to (the highest migration version)
+func GetLatestVersion(migrationFS fs.FS, migrationsName string) (uint, error) {
+	fsVar, err := iofs.New(migrationFS, migrationsName)
+	if err != nil {
+		return 0, fmt.Errorf("opening fs: %w", err)
+	}
+	defer fsVar.Close()
+	version, err := fsVar.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading migrations: %w", err)
+	}
+	for {
+		next, err := fsVar.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("reading migrations: %w", err)
+		}
+		version = next
+	}
+}
+
 func MakeMigrate(storeName string, db *sql.DB, migrationFS fs.FS, migrationsName string) (*migrate.Migrate, error) {
 	fsVar, err := iofs.New(migrationFS, migrationsName)
 	if err != nil {
@@ -52,6 +80,14 @@ func Migrate(storeName string, db *sql.DB, migrationFS fs.FS, migrationsName str
 	if err != nil {
 		return fmt.Errorf("%s, cannot get current migration version: %v", storeName, err)
 	}
+	latestVersion, err := GetLatestVersion(migrationFS, migrationsName)
+	if err != nil {
+		return fmt.Errorf("%s, cannot get latest migration version: %v", storeName, err)
+	}
+	if curVersion > latestVersion {
+		// migrating would fail (or worse, the newer schema would be used as if it were ours)
+		return fmt.Errorf("the %s database (version %d) was created by a newer version of Wave (this version supports up to version %d), please upgrade Wave", storeName, curVersion, latestVersion)
+	}
 	err = m.Up()
 	if err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("migrating %s: %w", storeName, err)
@@ -65,3 +101,55 @@ func Migrate(storeName string, db *sql.DB, migrationFS fs.FS, migrationsName str
 	}
 	return nil
 }
+
+// the current and latest versions of a database (see GetLatestVersion), and whether the last migration failed part way
+type VersionInfo struct {
+	Version uint
+	Latest  uint
+	Dirty   bool
+}
+
+func GetVersionInfo(storeName string, db *sql.DB, migrationFS fs.FS, migrationsName string) (*VersionInfo, error) {
+	m, err := MakeMigrate(storeName, db, migrationFS, migrationsName)
+	if err != nil {
+		return nil, err
+	}
+	curVersion, dirty, err := GetMigrateVersion(m)
+	if err != nil {
+		return nil, fmt.Errorf("%s, cannot get current migration version: %v", storeName, err)
+	}
+	latestVersion, err := GetLatestVersion(migrationFS, migrationsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s, cannot get latest migration version: %v", storeName, err)
+	}
+	return &VersionInfo{Version: curVersion, Latest: latestVersion, Dirty: dirty}, nil
+}
+
+// reverts the most recently applied migration (runs its down migration), so the database can be opened by the
+// previous version of Wave.  returns the new version.
+func MigrateDownOne(storeName string, db *sql.DB, migrationFS fs.FS, migrationsName string) (uint, error) {
+	m, err := MakeMigrate(storeName, db, migrationFS, migrationsName)
+	if err != nil {
+		return 0, err
+	}
+	curVersion, dirty, err := GetMigrateVersion(m)
+	if dirty {
+		return 0, fmt.Errorf("%s, migrate down, database is dirty", storeName)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s, cannot get current migration version: %v", storeName, err)
+	}
+	if curVersion == 0 {
+		return 0, fmt.Errorf("%s, no migrations to revert", storeName)
+	}
+	err = m.Steps(-1)
+	if err != nil {
+		return 0, fmt.Errorf("reverting %s migration %d: %w", storeName, curVersion, err)
+	}
+	newVersion, _, err := GetMigrateVersion(m)
+	if err != nil {
+		return 0, fmt.Errorf("%s, cannot get new migration version: %v", storeName, err)
+	}
+	log.Printf("[db] %s migration reverted, version %d -> %d\n", storeName, curVersion, newVersion)
+	return newVersion, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package migrateutil
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var testMigrationFS = fstest.MapFS{
+	"migrations/000001_init.up.sql":     {Data: []byte("CREATE TABLE t1 (id text);")},
+	"migrations/000001_init.down.sql":   {Data: []byte("DROP TABLE t1;")},
+	"migrations/000002_second.up.sql":   {Data: []byte("CREATE TABLE t2 (id text);")},
+	"migrations/000002_second.down.sql": {Data: []byte("DROP TABLE t2;")},
+}
+
+func TestMigrateVersions(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db")+"?mode=rwc")
+	if err != nil {
+		t.Fatalf("error opening db: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	latest, err := GetLatestVersion(testMigrationFS, "migrations")
+	if err != nil || latest != 2 {
+		t.Fatalf("expected latest version 2, got %d (err %v)", latest, err)
+	}
+	err = Migrate("test", db, testMigrationFS, "migrations")
+	if err != nil {
+		t.Fatalf("error migrating: %v", err)
+	}
+	info, err := GetVersionInfo("test", db, testMigrationFS, "migrations")
+	if err != nil || info.Version != 2 || info.Latest != 2 {
+		t.Fatalf("expected version 2/2, got %+v (err %v)", info, err)
+	}
+	newVersion, err := MigrateDownOne("test", db, testMigrationFS, "migrations")
+	if err != nil || newVersion != 1 {
+		t.Fatalf("expected rollback to version 1, got %d (err %v)", newVersion, err)
+	}
+	if _, err := db.Exec("SELECT * FROM t2"); err == nil {
+		t.Errorf("expected t2 to be dropped by the rollback")
+	}
+	err = Migrate("test", db, testMigrationFS, "migrations")
+	if err != nil {
+		t.Fatalf("error migrating after rollback: %v", err)
+	}
+	// a database from a newer build
+	_, err = db.Exec("UPDATE schema_migrations SET version = 3")
+	if err != nil {
+		t.Fatalf("error setting version: %v", err)
+	}
+	err = Migrate("test", db, testMigrationFS, "migrations")
+	if err == nil || !strings.Contains(err.Error(), "please upgrade") {
+		t.Errorf("expected a newer-database error, got %v", err)
+	}
+}
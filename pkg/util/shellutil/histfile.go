@@ -0,0 +1,110 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellutil
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// a command from a shell history file (for "wsh history import")
+type HistFileEntry struct {
+	Cmd string
+	Ts  int64 // unix ms (0 if the file doesn't have timestamps)
+}
+
+var HistFileShells = []string{ShellType_bash, ShellType_zsh}
+
+var bashHistTsRe = regexp.MustCompile(`^#(\d{9,11})$`)
+var zshExtHistRe = regexp.MustCompile(`^: *(\d+):\d+;`)
+
+// the default history file for the shell (relative to the home directory)
+func GetDefaultHistFile(shellType string) string {
+	switch shellType {
+	case ShellType_bash:
+		return "~/.bash_history"
+	case ShellType_zsh:
+		return "~/.zsh_history"
+	}
+	return ""
+}
+
+// returns the commands in the file, oldest first
+func ParseHistFile(shellType string, data []byte) []HistFileEntry {
+	if shellType == ShellType_zsh {
+		return parseZshHistory(data)
+	}
+	return parseBashHistory(data)
+}
+
+// one command per line (multi-line commands are split, unless lithist is set).  with HISTTIMEFORMAT set, each
+// command is after a "#<unix time>" line.
+func parseBashHistory(data []byte) []HistFileEntry {
+	var rtn []HistFileEntry
+	var ts int64
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if m := bashHistTsRe.FindStringSubmatch(line); m != nil {
+			secs, _ := strconv.ParseInt(m[1], 10, 64)
+			ts = secs * 1000
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rtn = append(rtn, HistFileEntry{Cmd: line, Ts: ts})
+	}
+	return rtn
+}
+
+// zsh "metafies" the bytes >= 0x83 in its history file (0x83, then the byte xor 32)
+func unmetafyZsh(data []byte) []byte {
+	if bytes.IndexByte(data, 0x83) == -1 {
+		return data
+	}
+	rtn := make([]byte, 0, len(data))
+	for idx := 0; idx < len(data); idx++ {
+		if data[idx] == 0x83 && idx+1 < len(data) {
+			idx++
+			rtn = append(rtn, data[idx]^32)
+			continue
+		}
+		rtn = append(rtn, data[idx])
+	}
+	return rtn
+}
+
+// plain lines, or ": <unix time>:<duration>;<command>" with EXTENDED_HISTORY.  lines of multi-line commands end
+// with a backslash.
+func parseZshHistory(data []byte) []HistFileEntry {
+	var rtn []HistFileEntry
+	var cur *HistFileEntry
+	for _, line := range strings.Split(string(unmetafyZsh(data)), "\n") {
+		if cur == nil {
+			cur = &HistFileEntry{}
+			if m := zshExtHistRe.FindStringSubmatch(line); m != nil {
+				secs, _ := strconv.ParseInt(m[1], 10, 64)
+				cur.Ts = secs * 1000
+				line = line[len(m[0]):]
+			}
+		} else {
+			cur.Cmd += "\n"
+		}
+		if strings.HasSuffix(line, "\\") {
+			cur.Cmd += line[:len(line)-1]
+			continue
+		}
+		cur.Cmd += line
+		if strings.TrimSpace(cur.Cmd) != "" {
+			rtn = append(rtn, *cur)
+		}
+		cur = nil
+	}
+	if cur != nil && strings.TrimSpace(cur.Cmd) != "" {
+		rtn = append(rtn, *cur)
+	}
+	return rtn
+}
@@ -0,0 +1,31 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellutil
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseHistFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		shellType string
+		data      string
+		expected  []HistFileEntry
+	}{
+		{name: "bash", shellType: ShellType_bash, data: "ls -l\n\ncd /tmp\r\n", expected: []HistFileEntry{{Cmd: "ls -l"}, {Cmd: "cd /tmp"}}},
+		{name: "bash timestamps", shellType: ShellType_bash, data: "#1700000000\nmake\n#1700000060\ngit status\n", expected: []HistFileEntry{{Cmd: "make", Ts: 1700000000000}, {Cmd: "git status", Ts: 1700000060000}}},
+		{name: "zsh", shellType: ShellType_zsh, data: "ls\necho 'a\\\nb'\n", expected: []HistFileEntry{{Cmd: "ls"}, {Cmd: "echo 'a\nb'"}}},
+		{name: "zsh extended", shellType: ShellType_zsh, data: ": 1700000000:0;ls\n: 1700000005:3;for x in 1 2; do\\\necho $x\\\ndone\n", expected: []HistFileEntry{{Cmd: "ls", Ts: 1700000000000}, {Cmd: "for x in 1 2; do\necho $x\ndone", Ts: 1700000005000}}},
+		// "é" is 0xc3 0xa9, zsh writes 0xc3 0x83 0x89
+		{name: "zsh metafied", shellType: ShellType_zsh, data: "echo caf\xc3\x83\x89\n", expected: []HistFileEntry{{Cmd: "echo café"}}},
+	}
+	for _, tc := range tests {
+		entries := ParseHistFile(tc.shellType, []byte(tc.data))
+		if !slices.Equal(entries, tc.expected) {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, entries)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellutil
+
+import (
+	"fmt"
+)
+
+// the rc snippets printed by "wsh shell-init".  the preexec and precmd hooks write OSC sequences with the shell's
+// builtin printf (no wsh process): OSC 16162 "C;<command line>" when a command starts, "D;<exit status>" when it is
+// done, and OSC 7 with the cwd at each prompt.  they are read from the pty output by the block controller (see
+// blockcontroller/cmdhistory.go).  bash gets the whole command line from its history (BASH_COMMAND is just the first
+// command), zsh and fish pass the command line as typed (so a leading space is kept).
+const (
+	ShellInteg_Bash = `# Wave Terminal shell integration (eval "$(wsh shell-init bash)")
+if [[ "$TERM_PROGRAM" == "waveterm" && -z "$_WAVE_SHELL_INTEG" ]]; then
+_WAVE_SHELL_INTEG=1
+_wave_at_prompt=
+_wave_cmd_running=
+_wave_last_histnum=
+
+_wave_preexec() {
+    [[ -n "$_wave_at_prompt" && -z "$COMP_LINE" && "$BASH_COMMAND" != "_wave_precmd" ]] || return
+    _wave_at_prompt=
+    _wave_cmd_running=1
+    # a command that isn't added to the history (e.g. with HISTCONTROL=ignorespace) is sent with a leading space
+    local cmd=" $BASH_COMMAND" histline
+    histline="$(HISTTIMEFORMAT= builtin history 1)"
+    if [[ "$histline" =~ ^\ *([0-9]+)\*?\ +(.*)$ ]]; then
+        if [[ "${BASH_REMATCH[1]}" != "$_wave_last_histnum" || "${BASH_REMATCH[2]}" == "$BASH_COMMAND"* ]]; then
+            # a new history entry, or the same command again (HISTCONTROL=ignoredups)
+            cmd="${BASH_REMATCH[2]}"
+        fi
+        _wave_last_histnum="${BASH_REMATCH[1]}"
+    fi
+    builtin printf '\e]16162;C;%s\a' "${cmd//[$'\a\e']/}"
+}
+
+_wave_precmd() {
+    local cmd_status=$?
+    if [[ -n "$_wave_cmd_running" ]]; then
+        builtin printf '\e]16162;D;%s\a' "$cmd_status"
+        _wave_cmd_running=
+    fi
+    builtin printf '\e]7;%s\a' "$PWD"
+    _wave_at_prompt=
+    return $cmd_status
+}
+
+_wave_prompt_ready() {
+    if [[ -z "$_wave_last_histnum" && "$(HISTTIMEFORMAT= builtin history 1)" =~ ^\ *([0-9]+) ]]; then
+        # the history is loaded after the rc files
+        _wave_last_histnum="${BASH_REMATCH[1]}"
+    fi
+    _wave_at_prompt=1
+}
+
+if [[ "$(declare -p PROMPT_COMMAND 2>/dev/null)" == "declare -a"* ]]; then
+    PROMPT_COMMAND=(_wave_precmd "${PROMPT_COMMAND[@]}" _wave_prompt_ready)
+else
+    PROMPT_COMMAND="_wave_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND};_wave_prompt_ready"
+fi
+trap '_wave_preexec' DEBUG
+fi
+`
+
+	ShellInteg_Zsh = `# Wave Terminal shell integration (eval "$(wsh shell-init zsh)")
+if [[ "$TERM_PROGRAM" == "waveterm" && -z "$_WAVE_SHELL_INTEG" ]]; then
+_WAVE_SHELL_INTEG=1
+_wave_cmd_running=
+
+_wave_preexec() {
+    _wave_cmd_running=1
+    builtin printf '\e]16162;C;%s\a' "${1//[$'\a\e']/}"
+}
+
+_wave_precmd() {
+    local cmd_status=$?
+    if [[ -n "$_wave_cmd_running" ]]; then
+        builtin printf '\e]16162;D;%s\a' "$cmd_status"
+        _wave_cmd_running=
+    fi
+    builtin printf '\e]7;%s\a' "$PWD"
+}
+
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec _wave_preexec
+# first, so it gets the exit status of the command
+precmd_functions=(_wave_precmd ${precmd_functions:#_wave_precmd})
+fi
+`
+
+	ShellInteg_Fish = `# Wave Terminal shell integration (wsh shell-init fish | source)
+if test "$TERM_PROGRAM" = "waveterm"; and not set -q _WAVE_SHELL_INTEG
+    set -g _WAVE_SHELL_INTEG 1
+
+    function _wave_preexec --on-event fish_preexec
+        builtin printf '\e]16162;C;%s\a' (string replace -ra '[\a\e]' '' -- $argv[1] | string collect)
+    end
+
+    function _wave_postexec --on-event fish_postexec
+        builtin printf '\e]16162;D;%s\a' $status
+    end
+
+    function _wave_prompt --on-event fish_prompt
+        builtin printf '\e]7;%s\a' $PWD
+    end
+end
+`
+)
+
+var ShellIntegShells = []string{ShellType_bash, ShellType_zsh, ShellType_fish}
+
+func GetShellIntegScript(shellType string) (string, error) {
+	switch shellType {
+	case ShellType_bash:
+		return ShellInteg_Bash, nil
+	case ShellType_zsh:
+		return ShellInteg_Zsh, nil
+	case ShellType_fish:
+		return ShellInteg_Fish, nil
+	}
+	return "", fmt.Errorf("shell integration is not supported for %q (supported shells: %v)", shellType, ShellIntegShells)
+}
@@ -0,0 +1,84 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellutil
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+)
+
+// the shell types saved in controller:shelltype
+const (
+	ShellType_bash = "bash"
+	ShellType_zsh  = "zsh"
+	ShellType_fish = "fish"
+	ShellType_pwsh = "pwsh" // powershell (and pwsh)
+	ShellType_cmd  = "cmd"
+	ShellType_sh   = "sh" // any other (posix) shell
+)
+
+// returns "" if shellPath is empty (the shell isn't known)
+func GetShellTypeFromShellPath(shellPath string) string {
+	if shellPath == "" {
+		return ""
+	}
+	// the path can be a windows path on another os (e.g. a windows ssh server), so backslashes are separators too
+	shellBase := strings.ToLower(filepath.Base(strings.ReplaceAll(shellPath, `\`, "/")))
+	switch {
+	case strings.Contains(shellBase, "powershell") || strings.Contains(shellBase, "pwsh"):
+		return ShellType_pwsh
+	case strings.Contains(shellBase, "zsh"):
+		return ShellType_zsh
+	case strings.Contains(shellBase, "bash"):
+		return ShellType_bash
+	case strings.Contains(shellBase, "fish"):
+		return ShellType_fish
+	case shellBase == "cmd" || shellBase == "cmd.exe":
+		return ShellType_cmd
+	default:
+		return ShellType_sh
+	}
+}
+
+// matches utilfn.ShellQuote
+var needsPosixQuoteRe = regexp.MustCompile(`[^\w@%:,./=+-]`)
+
+// backslashes are allowed for windows paths (and a comma would make an array)
+var needsPwshQuoteRe = regexp.MustCompile(`[^\w:./\\-]`)
+
+// powershell also treats the curly single quotes as quotes
+var pwshQuoteReplacer = strings.NewReplacer("'", "''", "‘", "‘‘", "’", "’’", "‚", "‚‚", "‛", "‛‛")
+
+var fishQuoteReplacer = strings.NewReplacer(`\`, `\\`, "'", `\'`)
+
+// quotes arg so the shell reads it as a single word (e.g. a path typed into a terminal).  arg is only quoted if it
+// needs to be.  an unknown shell type is quoted for a posix shell.
+func QuoteShellArg(shellType string, arg string) string {
+	switch shellType {
+	case ShellType_pwsh:
+		if arg != "" && !needsPwshQuoteRe.MatchString(arg) {
+			return arg
+		}
+		return "'" + pwshQuoteReplacer.Replace(arg) + "'"
+	case ShellType_cmd:
+		// cmd has no way to escape a double quote inside of quotes (and they can't be in a windows path)
+		if arg != "" && !strings.ContainsAny(arg, " \t&()[]{}^=;!'+,`~%<>|\"") {
+			return arg
+		}
+		return `"` + arg + `"`
+	case ShellType_fish:
+		if arg != "" && !needsPosixQuoteRe.MatchString(arg) {
+			return arg
+		}
+		return "'" + fishQuoteReplacer.Replace(arg) + "'"
+	default:
+		if arg == "" {
+			return "''"
+		}
+		return utilfn.ShellQuote(arg, false, -1)
+	}
+}
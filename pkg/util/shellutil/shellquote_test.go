@@ -0,0 +1,35 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package shellutil
+
+import "testing"
+
+func TestQuoteShellArg(t *testing.T) {
+	tests := []struct {
+		shellType string
+		arg       string
+		expected  string
+	}{
+		{ShellType_bash, "/home/user/file.txt", "/home/user/file.txt"},
+		{ShellType_zsh, "/home/user/my file's.txt", `'/home/user/my file'"'"'s.txt'`},
+		{"", "$HOME/a b", `'$HOME/a b'`},
+		{ShellType_fish, `/tmp/it's\here`, `'/tmp/it\'s\\here'`},
+		{ShellType_pwsh, `C:\Users\me\file.txt`, `C:\Users\me\file.txt`},
+		{ShellType_pwsh, `C:\Program Files\it's`, `'C:\Program Files\it''s'`},
+		{ShellType_pwsh, "C:\\a,b", "'C:\\a,b'"},
+		{ShellType_cmd, `C:\Program Files\app`, `"C:\Program Files\app"`},
+		{ShellType_sh, "", "''"},
+	}
+	for _, tc := range tests {
+		if rtn := QuoteShellArg(tc.shellType, tc.arg); rtn != tc.expected {
+			t.Errorf("QuoteShellArg(%q, %q): expected %s, got %s", tc.shellType, tc.arg, tc.expected, rtn)
+		}
+	}
+	if shellType := GetShellTypeFromShellPath(`C:\Program Files\PowerShell\7\pwsh.exe`); shellType != ShellType_pwsh {
+		t.Errorf("expected pwsh, got %q", shellType)
+	}
+	if shellType := GetShellTypeFromShellPath("/usr/bin/dash"); shellType != ShellType_sh {
+		t.Errorf("expected sh, got %q", shellType)
+	}
+}
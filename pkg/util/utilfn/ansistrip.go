@@ -0,0 +1,82 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package utilfn
+
+const (
+	ansiState_Text = iota
+	ansiState_Esc
+	ansiState_Csi       // ESC [ ... final byte (0x40-0x7e)
+	ansiState_String    // OSC, DCS, APC, PM, SOS ... terminated by BEL or ESC \
+	ansiState_StringEsc // ESC inside of a string
+	ansiState_Nf        // ESC followed by intermediate bytes (e.g. charset selection), then a final byte
+)
+
+// removes ANSI escape sequences (and control characters other than \n and \t) from terminal output.
+// the state is kept between calls, so sequences that are split across chunks are removed as well.
+type AnsiStripper struct {
+	state int
+}
+
+func (s *AnsiStripper) Strip(data []byte) []byte {
+	return s.strip(data, nil)
+}
+
+// like Strip, also returns the index in data of each byte that is kept (so matches in the stripped text can be
+// mapped back to the original bytes)
+func (s *AnsiStripper) StripWithIndexes(data []byte) ([]byte, []int) {
+	idxs := make([]int, 0, len(data))
+	return s.strip(data, &idxs), idxs
+}
+
+func (s *AnsiStripper) strip(data []byte, idxs *[]int) []byte {
+	rtn := make([]byte, 0, len(data))
+	for idx, ch := range data {
+		switch s.state {
+		case ansiState_Text:
+			if ch == 0x1b {
+				s.state = ansiState_Esc
+			} else if ch == '\n' || ch == '\t' || (ch >= 0x20 && ch != 0x7f) {
+				rtn = append(rtn, ch)
+				if idxs != nil {
+					*idxs = append(*idxs, idx)
+				}
+			}
+		case ansiState_Esc:
+			switch {
+			case ch == '[':
+				s.state = ansiState_Csi
+			case ch == ']' || ch == 'P' || ch == '_' || ch == '^' || ch == 'X':
+				s.state = ansiState_String
+			case ch >= 0x20 && ch <= 0x2f:
+				s.state = ansiState_Nf
+			case ch == 0x1b:
+				// stay in the escape state
+			default:
+				// two byte sequence (e.g. ESC 7, ESC =)
+				s.state = ansiState_Text
+			}
+		case ansiState_Csi:
+			if ch >= 0x40 && ch <= 0x7e {
+				s.state = ansiState_Text
+			}
+		case ansiState_String:
+			if ch == 0x07 {
+				s.state = ansiState_Text
+			} else if ch == 0x1b {
+				s.state = ansiState_StringEsc
+			}
+		case ansiState_StringEsc:
+			if ch == '\\' {
+				s.state = ansiState_Text
+			} else if ch != 0x1b {
+				s.state = ansiState_String
+			}
+		case ansiState_Nf:
+			if ch < 0x20 || ch > 0x2f {
+				s.state = ansiState_Text
+			}
+		}
+	}
+	return rtn
+}
@@ -621,6 +621,9 @@ func CopyToChannel(outputCh chan<- []byte, reader io.Reader) error {
 // does not return "application/octet-stream" as this is considered a detection failure
 // can pass an existing fileInfo to avoid re-statting the file
 // falls back to text/plain for 0 byte files
+// the max number of bytes read to detect the mime type (and whether the file is binary) of a file without a known extension
+const MimeSampleSize = 64 * 1024
+
 func DetectMimeType(path string, fileInfo fs.FileInfo, extended bool) string {
 	if fileInfo == nil {
 		statRtn, err := os.Stat(path)
@@ -660,9 +663,9 @@ func DetectMimeType(path string, fileInfo fs.FileInfo, extended bool) string {
 		return ""
 	}
 	defer fd.Close()
-	buf := make([]byte, 512)
+	buf := make([]byte, MimeSampleSize)
 	// ignore the error (EOF / UnexpectedEOF is fine, just process how much we got back)
-	n, _ := io.ReadAtLeast(fd, buf, 512)
+	n, _ := io.ReadAtLeast(fd, buf, MimeSampleSize)
 	if n == 0 {
 		return ""
 	}
@@ -671,6 +674,10 @@ func DetectMimeType(path string, fileInfo fs.FileInfo, extended bool) string {
 	if rtn == "application/octet-stream" {
 		return ""
 	}
+	if strings.HasPrefix(rtn, "text/") && bytes.IndexByte(buf, 0) != -1 {
+		// DetectContentType only looks at the first 512 bytes
+		return ""
+	}
 	return rtn
 }
 
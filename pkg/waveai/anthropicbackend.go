@@ -21,6 +21,9 @@ type AnthropicBackend struct{}
 
 var _ AIBackend = AnthropicBackend{}
 
+const DefaultAnthropicBaseURL = "https://api.anthropic.com"
+const DefaultAnthropicMaxTokens = 4096 // the api requires max_tokens
+
 // Claude API request types
 type anthropicMessage struct {
 	Role    string `json:"role"`
@@ -162,6 +165,9 @@ func (AnthropicBackend) StreamCompletion(ctx context.Context, request wshrpc.Wav
 			Stream:    true,
 			MaxTokens: request.Opts.MaxTokens,
 		}
+		if anthropicReq.MaxTokens <= 0 {
+			anthropicReq.MaxTokens = DefaultAnthropicMaxTokens
+		}
 
 		reqBody, err := json.Marshal(anthropicReq)
 		if err != nil {
@@ -169,7 +175,12 @@ func (AnthropicBackend) StreamCompletion(ctx context.Context, request wshrpc.Wav
 			return
 		}
 
-		req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", strings.NewReader(string(reqBody)))
+		baseURL := request.Opts.BaseURL
+		if baseURL == "" {
+			baseURL = DefaultAnthropicBaseURL
+		}
+		messagesURL := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1") + "/v1/messages"
+		req, err := http.NewRequestWithContext(ctx, "POST", messagesURL, strings.NewReader(string(reqBody)))
 		if err != nil {
 			rtn <- makeAIError(fmt.Errorf("failed to create anthropic request: %v", err))
 			return
@@ -0,0 +1,264 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// ai conversations are stored in db_aimessage by block id.  they are not deleted with the block (so they can
+// still be exported), they are deleted by PruneConversations (client:airetentiondays).
+
+const (
+	ConversationFormat_Markdown = "markdown"
+	ConversationFormat_Json     = "json"
+)
+
+// exports read the conversation this many messages at a time
+const exportPageSize = 100
+
+type aiMessageRow struct {
+	BlockId          string `db:"blockid"`
+	Seq              int    `db:"seq"`
+	Role             string `db:"role"`
+	Content          string `db:"content"`
+	Ts               int64  `db:"ts"`
+	PromptTokens     int    `db:"prompttokens"`
+	CompletionTokens int    `db:"completiontokens"`
+}
+
+func (row *aiMessageRow) toMessage() wshrpc.WaveAIConversationMessage {
+	return wshrpc.WaveAIConversationMessage{
+		Seq:              row.Seq,
+		Role:             row.Role,
+		Content:          row.Content,
+		Ts:               row.Ts,
+		PromptTokens:     row.PromptTokens,
+		CompletionTokens: row.CompletionTokens,
+	}
+}
+
+func getClientMetaInt(ctx context.Context, key string) int {
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil || client == nil {
+		return 0
+	}
+	return client.Meta.GetInt(key, 0)
+}
+
+// the retention for PruneConversations (client:airetentiondays), 0 means conversations are kept
+func GetConversationRetention(ctx context.Context) time.Duration {
+	days := getClientMetaInt(ctx, waveobj.MetaKey_ClientAIRetentionDays)
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func appendMessagesTx(tx *wstore.TxWrap, blockId string, msgs []wshrpc.WaveAIConversationMessage, maxMessages int) {
+	now := time.Now().UnixMilli()
+	seq := tx.GetInt(`SELECT COALESCE(max(seq), 0) FROM db_aimessage WHERE blockid = ?`, blockId)
+	for _, msg := range msgs {
+		seq++
+		ts := msg.Ts
+		if ts == 0 {
+			ts = now
+		}
+		query := `INSERT INTO db_aimessage (blockid, seq, role, content, ts, prompttokens, completiontokens) VALUES (?, ?, ?, ?, ?, ?, ?)`
+		tx.Exec(query, blockId, seq, msg.Role, msg.Content, ts, msg.PromptTokens, msg.CompletionTokens)
+	}
+	if maxMessages > 0 {
+		tx.Exec(`DELETE FROM db_aimessage WHERE blockid = ? AND seq <= ?`, blockId, seq-maxMessages)
+	}
+}
+
+func validateMessages(msgs []wshrpc.WaveAIConversationMessage) error {
+	for _, msg := range msgs {
+		if msg.Role == "" {
+			return fmt.Errorf("ai message has no role")
+		}
+	}
+	return nil
+}
+
+// adds the messages to the end of the block's conversation (a ts of 0 is set to now), then drops the oldest
+// messages past client:aimaxmessages
+func AppendConversation(ctx context.Context, blockId string, msgs []wshrpc.WaveAIConversationMessage) error {
+	if err := validateMessages(msgs); err != nil {
+		return err
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	maxMessages := getClientMetaInt(ctx, waveobj.MetaKey_ClientAIMaxMessages)
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		appendMessagesTx(tx, blockId, msgs, maxMessages)
+		return nil
+	})
+}
+
+// replaces the block's conversation with msgs (no msgs clears it)
+func ReplaceConversation(ctx context.Context, blockId string, msgs []wshrpc.WaveAIConversationMessage) error {
+	if err := validateMessages(msgs); err != nil {
+		return err
+	}
+	maxMessages := getClientMetaInt(ctx, waveobj.MetaKey_ClientAIMaxMessages)
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		tx.Exec(`DELETE FROM db_aimessage WHERE blockid = ?`, blockId)
+		appendMessagesTx(tx, blockId, msgs, maxMessages)
+		return nil
+	})
+}
+
+// returns the last maxMessages messages of the block's conversation (all of them when maxMessages is 0), oldest first
+func GetConversation(ctx context.Context, blockId string, maxMessages int) ([]wshrpc.WaveAIConversationMessage, error) {
+	rows, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*aiMessageRow, error) {
+		var rows []*aiMessageRow
+		if maxMessages > 0 {
+			query := `SELECT * FROM (SELECT * FROM db_aimessage WHERE blockid = ? ORDER BY seq DESC LIMIT ?) ORDER BY seq`
+			tx.Select(&rows, query, blockId, maxMessages)
+		} else {
+			tx.Select(&rows, `SELECT * FROM db_aimessage WHERE blockid = ? ORDER BY seq`, blockId)
+		}
+		return rows, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]wshrpc.WaveAIConversationMessage, 0, len(rows))
+	for _, row := range rows {
+		rtn = append(rtn, row.toMessage())
+	}
+	return rtn, nil
+}
+
+// deletes the conversations whose last message is older than retention, returns the number deleted
+func PruneConversations(ctx context.Context, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention).UnixMilli()
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (int, error) {
+		blockIds := tx.SelectStrings(`SELECT blockid FROM db_aimessage GROUP BY blockid HAVING max(ts) < ?`, cutoff)
+		for _, blockId := range blockIds {
+			tx.Exec(`DELETE FROM db_aimessage WHERE blockid = ?`, blockId)
+		}
+		return len(blockIds), nil
+	})
+}
+
+func getMessagePage(ctx context.Context, blockId string, afterSeq int) ([]*aiMessageRow, error) {
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*aiMessageRow, error) {
+		var rows []*aiMessageRow
+		query := `SELECT * FROM db_aimessage WHERE blockid = ? AND seq > ? ORDER BY seq LIMIT ?`
+		tx.Select(&rows, query, blockId, afterSeq, exportPageSize)
+		return rows, nil
+	})
+}
+
+func ValidateConversationFormat(format string) error {
+	if format != ConversationFormat_Markdown && format != ConversationFormat_Json {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid export format %q (must be %s or %s)", format, ConversationFormat_Markdown, ConversationFormat_Json)
+	}
+	return nil
+}
+
+// writes the block's conversation to w as markdown or json.  the conversation is read a page at a time, so long
+// conversations are never all in memory.
+func ExportConversation(ctx context.Context, blockId string, format string, w io.Writer) error {
+	if err := ValidateConversationFormat(format); err != nil {
+		return err
+	}
+	rows, err := getMessagePage(ctx, blockId, 0)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "no ai conversation found for block %s", blockId)
+	}
+	exporter := &conversationExporter{w: w, format: format, blockId: blockId}
+	if err := exporter.writeHeader(); err != nil {
+		return err
+	}
+	for len(rows) > 0 {
+		for _, row := range rows {
+			if err := exporter.writeMessage(row.toMessage()); err != nil {
+				return err
+			}
+		}
+		if len(rows) < exportPageSize {
+			break
+		}
+		rows, err = getMessagePage(ctx, blockId, rows[len(rows)-1].Seq)
+		if err != nil {
+			return err
+		}
+	}
+	return exporter.writeFooter()
+}
+
+type conversationExporter struct {
+	w           io.Writer
+	format      string
+	blockId     string
+	numMessages int
+}
+
+func (e *conversationExporter) writeHeader() error {
+	var err error
+	if e.format == ConversationFormat_Json {
+		_, err = fmt.Fprintf(e.w, "{\n  \"blockid\": %q,\n  \"messages\": [", e.blockId)
+	} else {
+		_, err = fmt.Fprintf(e.w, "# AI Conversation\n\nblock %s\n", e.blockId)
+	}
+	return err
+}
+
+func getRoleTitle(role string) string {
+	if role == "" {
+		return role
+	}
+	return strings.ToUpper(role[0:1]) + role[1:]
+}
+
+func (e *conversationExporter) writeMessage(msg wshrpc.WaveAIConversationMessage) error {
+	defer func() {
+		e.numMessages++
+	}()
+	if e.format == ConversationFormat_Json {
+		barr, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("error encoding ai message: %w", err)
+		}
+		sep := ","
+		if e.numMessages == 0 {
+			sep = ""
+		}
+		_, err = fmt.Fprintf(e.w, "%s\n    %s", sep, barr)
+		return err
+	}
+	heading := getRoleTitle(msg.Role)
+	if msg.Ts > 0 {
+		heading += " - " + time.UnixMilli(msg.Ts).Format("2006-01-02 15:04:05")
+	}
+	if msg.PromptTokens > 0 || msg.CompletionTokens > 0 {
+		heading += fmt.Sprintf(" (%d prompt / %d completion tokens)", msg.PromptTokens, msg.CompletionTokens)
+	}
+	_, err := fmt.Fprintf(e.w, "\n## %s\n\n%s\n", heading, strings.TrimRight(msg.Content, "\n"))
+	return err
+}
+
+func (e *conversationExporter) writeFooter() error {
+	if e.format != ConversationFormat_Json {
+		return nil
+	}
+	_, err := io.WriteString(e.w, "\n  ]\n}\n")
+	return err
+}
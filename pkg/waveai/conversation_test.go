@@ -0,0 +1,59 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+var testConversation = []wshrpc.WaveAIConversationMessage{
+	{Seq: 1, Role: "user", Content: "how do i list files by size", Ts: 1736000000000},
+	{Seq: 2, Role: "assistant", Content: "use `ls -lS`\n", Ts: 1736000001000, PromptTokens: 12, CompletionTokens: 5},
+}
+
+func exportTestConversation(t *testing.T, format string) string {
+	var buf strings.Builder
+	exporter := &conversationExporter{w: &buf, format: format, blockId: "block-1"}
+	if err := exporter.writeHeader(); err != nil {
+		t.Fatalf("error writing header: %v", err)
+	}
+	for _, msg := range testConversation {
+		if err := exporter.writeMessage(msg); err != nil {
+			t.Fatalf("error writing message: %v", err)
+		}
+	}
+	if err := exporter.writeFooter(); err != nil {
+		t.Fatalf("error writing footer: %v", err)
+	}
+	return buf.String()
+}
+
+func TestExportConversationFormats(t *testing.T) {
+	var exported struct {
+		BlockId  string                             `json:"blockid"`
+		Messages []wshrpc.WaveAIConversationMessage `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(exportTestConversation(t, ConversationFormat_Json)), &exported); err != nil {
+		t.Fatalf("json export doesn't parse: %v", err)
+	}
+	if exported.BlockId != "block-1" || len(exported.Messages) != 2 || exported.Messages[1] != testConversation[1] {
+		t.Errorf("unexpected json export %+v", exported)
+	}
+
+	md := exportTestConversation(t, ConversationFormat_Markdown)
+	if !strings.HasPrefix(md, "# AI Conversation\n") || !strings.Contains(md, "\n## User - ") {
+		t.Errorf("unexpected markdown export %q", md)
+	}
+	if !strings.Contains(md, "(12 prompt / 5 completion tokens)\n\nuse `ls -lS`\n") {
+		t.Errorf("expected the assistant message with its token counts, got %q", md)
+	}
+
+	if err := ValidateConversationFormat("html"); err == nil {
+		t.Errorf("expected an invalid format error")
+	}
+}
@@ -19,14 +19,14 @@ func (GoogleBackend) StreamCompletion(ctx context.Context, request wshrpc.WaveAI
 	client, err := genai.NewClient(ctx, option.WithAPIKey(request.Opts.APIToken))
 	if err != nil {
 		log.Printf("failed to create client: %v", err)
-		return nil
+		return makeAIErrorChan(fmt.Errorf("error creating Google AI client: %v", err))
 	}
 
 	model := client.GenerativeModel(request.Opts.Model)
 	if model == nil {
 		log.Println("model not found")
 		client.Close()
-		return nil
+		return makeAIErrorChan(fmt.Errorf("Google AI model %q not found", request.Opts.Model))
 	}
 
 	cs := model.StartChat()
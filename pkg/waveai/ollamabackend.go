@@ -0,0 +1,168 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+type OllamaBackend struct{}
+
+var _ AIBackend = OllamaBackend{}
+
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaOptions struct {
+	NumPredict int `json:"num_predict,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Options  *ollamaOptions  `json:"options,omitempty"`
+}
+
+// the stream is one of these per line (done is set on the last one)
+type ollamaChatResponse struct {
+	Model           string         `json:"model"`
+	Message         *ollamaMessage `json:"message,omitempty"`
+	Done            bool           `json:"done"`
+	DoneReason      string         `json:"done_reason,omitempty"`
+	PromptEvalCount int            `json:"prompt_eval_count,omitempty"`
+	EvalCount       int            `json:"eval_count,omitempty"`
+	Error           string         `json:"error,omitempty"`
+}
+
+// also accepts the url of ollama's openai compatible api (http://localhost:11434/v1)
+func getOllamaChatURL(baseURL string) string {
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	baseURL = strings.TrimSuffix(baseURL, "/v1")
+	return baseURL + "/api/chat"
+}
+
+func (OllamaBackend) StreamCompletion(ctx context.Context, request wshrpc.WaveAIStreamRequest) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType])
+	go func() {
+		defer func() {
+			panicErr := panichandler.PanicHandler("OllamaBackend.StreamCompletion", recover())
+			if panicErr != nil {
+				rtn <- makeAIError(panicErr)
+			}
+			close(rtn)
+		}()
+		if request.Opts == nil {
+			rtn <- makeAIError(errors.New("no ollama opts found"))
+			return
+		}
+		if request.Opts.Model == "" {
+			rtn <- makeAIError(errors.New("no ollama model specified (set ai:model)"))
+			return
+		}
+		chatReq := ollamaChatRequest{Model: request.Opts.Model, Stream: true}
+		for _, msg := range request.Prompt {
+			chatReq.Messages = append(chatReq.Messages, ollamaMessage{Role: msg.Role, Content: msg.Content})
+		}
+		if request.Opts.MaxTokens > 0 {
+			chatReq.Options = &ollamaOptions{NumPredict: request.Opts.MaxTokens}
+		}
+		reqBody, err := json.Marshal(chatReq)
+		if err != nil {
+			rtn <- makeAIError(fmt.Errorf("failed to marshal ollama request: %v", err))
+			return
+		}
+		chatURL := getOllamaChatURL(request.Opts.BaseURL)
+		req, err := http.NewRequestWithContext(ctx, "POST", chatURL, bytes.NewReader(reqBody))
+		if err != nil {
+			rtn <- makeAIError(fmt.Errorf("failed to create ollama request: %v", err))
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if request.Opts.APIToken != "" {
+			// for an ollama server behind a proxy
+			req.Header.Set("Authorization", "Bearer "+request.Opts.APIToken)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			rtn <- makeAIError(fmt.Errorf("error connecting to ollama at %s (is ollama running?): %v", chatURL, err))
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			var errResp ollamaChatResponse
+			if json.Unmarshal(bodyBytes, &errResp) == nil && errResp.Error != "" {
+				rtn <- makeAIError(fmt.Errorf("Ollama API error: %s - %s", resp.Status, errResp.Error))
+				return
+			}
+			rtn <- makeAIError(fmt.Errorf("Ollama API error: %s - %s", resp.Status, string(bodyBytes)))
+			return
+		}
+		sentHeader := false
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var chatResp ollamaChatResponse
+			if err := json.Unmarshal(line, &chatResp); err != nil {
+				rtn <- makeAIError(fmt.Errorf("error parsing ollama response: %v", err))
+				return
+			}
+			if chatResp.Error != "" {
+				rtn <- makeAIError(fmt.Errorf("Ollama API error: %s", chatResp.Error))
+				return
+			}
+			if !sentHeader && chatResp.Model != "" {
+				pk := MakeWaveAIPacket()
+				pk.Model = chatResp.Model
+				rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk}
+				sentHeader = true
+			}
+			if chatResp.Message != nil && chatResp.Message.Content != "" {
+				pk := MakeWaveAIPacket()
+				pk.Text = chatResp.Message.Content
+				rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk}
+			}
+			if chatResp.Done {
+				pk := MakeWaveAIPacket()
+				pk.FinishReason = chatResp.DoneReason
+				pk.Usage = &wshrpc.WaveAIUsageType{
+					PromptTokens:     chatResp.PromptEvalCount,
+					CompletionTokens: chatResp.EvalCount,
+					TotalTokens:      chatResp.PromptEvalCount + chatResp.EvalCount,
+				}
+				rtn <- wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Response: *pk}
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			rtn <- makeAIError(fmt.Errorf("error reading ollama response: %v", err))
+			return
+		}
+		rtn <- makeAIError(errors.New("ollama response ended before it was done"))
+	}()
+	return rtn
+}
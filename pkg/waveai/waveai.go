@@ -5,9 +5,16 @@ package waveai
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
 
+	"github.com/wavetermdev/waveterm/pkg/secretstore"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 )
 
@@ -16,6 +23,35 @@ const ApiType_Anthropic = "anthropic"
 const ApiType_Perplexity = "perplexity"
 const APIType_Google = "google"
 const APIType_OpenAI = "openai"
+const ApiType_Ollama = "ollama"
+
+// the ai:provider values
+const (
+	AIProvider_OpenAI     = "openai" // openai, or any openai compatible api (see ai:baseurl)
+	AIProvider_Anthropic  = "anthropic"
+	AIProvider_Ollama     = "ollama" // a local ollama server (ai:baseurl defaults to http://localhost:11434)
+	AIProvider_Google     = "google"
+	AIProvider_Perplexity = "perplexity"
+	AIProvider_Wave       = "wave" // wave's ai proxy
+)
+
+var aiProviders = map[string]AIBackend{
+	AIProvider_OpenAI:     OpenAIBackend{},
+	AIProvider_Anthropic:  AnthropicBackend{},
+	AIProvider_Ollama:     OllamaBackend{},
+	AIProvider_Google:     GoogleBackend{},
+	AIProvider_Perplexity: PerplexityBackend{},
+	AIProvider_Wave:       WaveAICloudBackend{},
+}
+
+func GetAIProviderNames() []string {
+	names := make([]string, 0, len(aiProviders))
+	for name := range aiProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
 type WaveAICmdInfoPacketOutputType struct {
 	Model        string `json:"model,omitempty"`
@@ -48,41 +84,142 @@ func IsCloudAIRequest(opts *wshrpc.WaveAIOptsType) bool {
 	if opts == nil {
 		return true
 	}
-	return opts.BaseURL == "" && opts.APIToken == ""
+	if opts.Provider != "" {
+		return strings.ToLower(opts.Provider) == AIProvider_Wave
+	}
+	return opts.BaseURL == "" && opts.APIToken == "" && opts.APIKeyRef == ""
 }
 
 func makeAIError(err error) wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
 	return wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]{Error: err}
 }
 
+// a channel that only returns err (so the error is shown in the block instead of the request hanging)
+func makeAIErrorChan(err error) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType], 1)
+	rtn <- makeAIError(err)
+	close(rtn)
+	return rtn
+}
+
+// picks the backend for the request (ai:provider, or the older ai:apitype rules), and fixes up the opts for it
+func getAIBackend(opts *wshrpc.WaveAIOptsType) (AIBackend, error) {
+	if opts.Provider != "" {
+		provider := strings.ToLower(opts.Provider)
+		backend := aiProviders[provider]
+		if backend == nil {
+			return nil, fmt.Errorf("unknown ai provider %q (must be one of %s)", opts.Provider, strings.Join(GetAIProviderNames(), ", "))
+		}
+		switch provider {
+		case AIProvider_OpenAI:
+			// keep the azure api types (an api type that names another provider is replaced)
+			if opts.APIType == "" || aiProviders[strings.ToLower(opts.APIType)] != nil {
+				opts.APIType = APIType_OpenAI
+			}
+		case AIProvider_Wave:
+			opts.APIType = APIType_OpenAI
+			opts.Model = "default"
+		default:
+			opts.APIType = provider
+		}
+		return backend, nil
+	}
+	if opts.APIType == ApiType_Anthropic {
+		return AnthropicBackend{}, nil
+	} else if opts.APIType == ApiType_Perplexity {
+		return PerplexityBackend{}, nil
+	} else if opts.APIType == APIType_Google {
+		return GoogleBackend{}, nil
+	} else if opts.APIType == ApiType_Ollama {
+		return OllamaBackend{}, nil
+	} else if IsCloudAIRequest(opts) {
+		opts.APIType = APIType_OpenAI
+		opts.Model = "default"
+		return WaveAICloudBackend{}, nil
+	}
+	opts.APIType = APIType_OpenAI
+	return OpenAIBackend{}, nil
+}
+
+// the api key comes from the secret store when apikeyref is set.  ai:baseurl can come from block meta (that anything
+// with wsh can change), so the key is only sent to the provider's default endpoint, a local server, or one of
+// allowedBaseURLs (ai:apikeybaseurls in the settings).
+func resolveAPIKey(opts *wshrpc.WaveAIOptsType, allowedBaseURLs []string) error {
+	if opts.APIKeyRef == "" {
+		return nil
+	}
+	if !isAPIKeyBaseURLAllowed(opts.BaseURL, allowedBaseURLs) {
+		return fmt.Errorf("not sending api key %q to %q (add the base url to ai:apikeybaseurls in your settings to allow it)", opts.APIKeyRef, opts.BaseURL)
+	}
+	apiKey, ok, err := secretstore.GetSecret(opts.APIKeyRef)
+	if err != nil {
+		return fmt.Errorf("error getting api key %q: %w", opts.APIKeyRef, err)
+	}
+	if !ok {
+		return fmt.Errorf("api key %q is not set (set it with wsh secret set %s)", opts.APIKeyRef, opts.APIKeyRef)
+	}
+	opts.APIToken = apiKey
+	return nil
+}
+
+func isAPIKeyBaseURLAllowed(baseURL string, allowedBaseURLs []string) bool {
+	if baseURL == "" {
+		return true
+	}
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	host := parsedURL.Hostname()
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	for _, allowedURL := range allowedBaseURLs {
+		if strings.EqualFold(strings.TrimSuffix(allowedURL, "/"), baseURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// error messages are saved in the conversation (to show them in the block), they aren't sent to the model
+func filterPrompt(prompt []wshrpc.WaveAIPromptMessageType) []wshrpc.WaveAIPromptMessageType {
+	var rtn []wshrpc.WaveAIPromptMessageType
+	for _, msg := range prompt {
+		if msg.Role == "error" {
+			continue
+		}
+		rtn = append(rtn, msg)
+	}
+	return rtn
+}
+
 func RunAICommand(ctx context.Context, request wshrpc.WaveAIStreamRequest) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
 	telemetry.GoUpdateActivityWrap(wshrpc.ActivityUpdate{NumAIReqs: 1}, "RunAICommand")
 
+	if request.Opts == nil {
+		request.Opts = &wshrpc.WaveAIOptsType{}
+	} else {
+		// the opts are changed for the backend
+		optsCopy := *request.Opts
+		request.Opts = &optsCopy
+	}
+	if err := resolveAPIKey(request.Opts, wconfig.GetWatcher().GetFullConfig().Settings.AiApiKeyBaseURLs); err != nil {
+		return makeAIErrorChan(err)
+	}
+	backend, err := getAIBackend(request.Opts)
+	if err != nil {
+		return makeAIErrorChan(err)
+	}
+	request.Prompt = filterPrompt(request.Prompt)
 	endpoint := request.Opts.BaseURL
 	if endpoint == "" {
 		endpoint = "default"
 	}
-	var backend AIBackend
-	if request.Opts.APIType == ApiType_Anthropic {
-		backend = AnthropicBackend{}
-	} else if request.Opts.APIType == ApiType_Perplexity {
-		backend = PerplexityBackend{}
-	} else if request.Opts.APIType == APIType_Google {
-		backend = GoogleBackend{}
-	} else if IsCloudAIRequest(request.Opts) {
+	if _, ok := backend.(WaveAICloudBackend); ok {
 		endpoint = "waveterm cloud"
-		request.Opts.APIType = APIType_OpenAI
-		request.Opts.Model = "default"
-		backend = WaveAICloudBackend{}
-	} else {
-		request.Opts.APIType = APIType_OpenAI
-		backend = OpenAIBackend{}
 	}
-	if backend == nil {
-		log.Printf("no backend found for %s\n", request.Opts.APIType)
-		return nil
-	}
-
 	log.Printf("sending ai chat message to %s endpoint %q using model %s\n", request.Opts.APIType, endpoint, request.Opts.Model)
 	return backend.StreamCompletion(ctx, request)
 }
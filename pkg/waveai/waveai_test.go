@@ -0,0 +1,129 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/secretstore"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestGetAIBackend(t *testing.T) {
+	tests := []struct {
+		opts            wshrpc.WaveAIOptsType
+		expected        AIBackend
+		expectedApiType string
+	}{
+		{opts: wshrpc.WaveAIOptsType{}, expected: WaveAICloudBackend{}, expectedApiType: APIType_OpenAI},
+		{opts: wshrpc.WaveAIOptsType{APIType: ApiType_Anthropic}, expected: AnthropicBackend{}, expectedApiType: ApiType_Anthropic},
+		{opts: wshrpc.WaveAIOptsType{APIToken: "x"}, expected: OpenAIBackend{}, expectedApiType: APIType_OpenAI},
+		// the provider overrides the api type
+		{opts: wshrpc.WaveAIOptsType{Provider: "Ollama", APIType: ApiType_Anthropic}, expected: OllamaBackend{}, expectedApiType: ApiType_Ollama},
+		{opts: wshrpc.WaveAIOptsType{Provider: AIProvider_OpenAI, APIType: ApiType_Anthropic}, expected: OpenAIBackend{}, expectedApiType: APIType_OpenAI},
+		{opts: wshrpc.WaveAIOptsType{Provider: AIProvider_OpenAI, APIType: "azure"}, expected: OpenAIBackend{}, expectedApiType: "azure"},
+	}
+	for _, tc := range tests {
+		opts := tc.opts
+		backend, err := getAIBackend(&opts)
+		if err != nil {
+			t.Errorf("%+v: unexpected error %v", tc.opts, err)
+			continue
+		}
+		if backend != tc.expected || opts.APIType != tc.expectedApiType {
+			t.Errorf("%+v: expected %T (%s), got %T (%s)", tc.opts, tc.expected, tc.expectedApiType, backend, opts.APIType)
+		}
+	}
+	if _, err := getAIBackend(&wshrpc.WaveAIOptsType{Provider: "nope"}); err == nil || !strings.Contains(err.Error(), "ollama") {
+		t.Errorf("expected an unknown provider error listing the providers, got %v", err)
+	}
+}
+
+func readAIResponse(t *testing.T, rtnCh chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType]) (string, error) {
+	var text strings.Builder
+	var rtnErr error
+	for resp := range rtnCh {
+		if resp.Error != nil {
+			rtnErr = resp.Error
+			continue
+		}
+		text.WriteString(resp.Response.Text)
+	}
+	return text.String(), rtnErr
+}
+
+func TestOllamaProvider(t *testing.T) {
+	oldDataHome := wavebase.DataHome_VarCache
+	wavebase.DataHome_VarCache = t.TempDir()
+	defer func() { wavebase.DataHome_VarCache = oldDataHome }()
+	if err := secretstore.SetSecret("ollama-proxy", "sekret"); err != nil {
+		t.Fatalf("error setting secret: %v", err)
+	}
+
+	var chatReq ollamaChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" || r.Header.Get("Authorization") != "Bearer sekret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&chatReq)
+		if chatReq.Model != "llama3" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"error":"model '%s' not found"}`, chatReq.Model)
+			return
+		}
+		fmt.Fprintln(w, `{"model":"llama3","message":{"role":"assistant","content":"hello"},"done":false}`)
+		fmt.Fprintln(w, `{"model":"llama3","message":{"role":"assistant","content":" there"},"done":false}`)
+		fmt.Fprintln(w, `{"model":"llama3","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`)
+	}))
+	defer server.Close()
+
+	request := wshrpc.WaveAIStreamRequest{
+		Opts: &wshrpc.WaveAIOptsType{Provider: AIProvider_Ollama, Model: "llama3", BaseURL: server.URL + "/v1", APIKeyRef: "ollama-proxy"},
+		Prompt: []wshrpc.WaveAIPromptMessageType{
+			{Role: "user", Content: "hi"},
+			{Role: "error", Content: "the last request failed"},
+			{Role: "user", Content: "hi again"},
+		},
+	}
+	text, err := readAIResponse(t, RunAICommand(context.Background(), request))
+	if err != nil || text != "hello there" {
+		t.Errorf("expected hello there, got %q (%v)", text, err)
+	}
+	if len(chatReq.Messages) != 2 {
+		t.Errorf("expected the error message to be dropped from the prompt, got %v", chatReq.Messages)
+	}
+	if request.Opts.APIToken != "" {
+		t.Errorf("expected the request's opts not to be changed")
+	}
+
+	request.Opts.Model = "llama4"
+	_, err = readAIResponse(t, RunAICommand(context.Background(), request))
+	if err == nil || !strings.Contains(err.Error(), "model 'llama4' not found") {
+		t.Errorf("expected the ollama error, got %v", err)
+	}
+
+	request.Opts.BaseURL = "https://ollama.example.com/v1"
+	_, err = readAIResponse(t, RunAICommand(context.Background(), request))
+	if err == nil || !strings.Contains(err.Error(), "ai:apikeybaseurls") {
+		t.Errorf("expected the api key not to be sent to another host, got %v", err)
+	}
+	if err := resolveAPIKey(&wshrpc.WaveAIOptsType{BaseURL: "https://ollama.example.com/v1/", APIKeyRef: "ollama-proxy"}, []string{"https://ollama.example.com/v1"}); err != nil {
+		t.Errorf("expected an allowed base url to get the api key, got %v", err)
+	}
+
+	request.Opts.BaseURL = server.URL + "/v1"
+	request.Opts.APIKeyRef = "missing"
+	_, err = readAIResponse(t, RunAICommand(context.Background(), request))
+	if err == nil || !strings.Contains(err.Error(), "wsh secret set missing") {
+		t.Errorf("expected a missing secret error, got %v", err)
+	}
+}
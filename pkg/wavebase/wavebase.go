@@ -25,6 +25,8 @@ import (
 var WaveVersion = "0.0.0"
 var BuildTime = "0"
 
+var ProcessStartTime = time.Now()
+
 const (
 	WaveConfigHomeEnvVar = "WAVETERM_CONFIG_HOME"
 	WaveDataHomeEnvVar   = "WAVETERM_DATA_HOME"
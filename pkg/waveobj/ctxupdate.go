@@ -8,15 +8,36 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
+	"sync"
+	"time"
 )
 
 var waveObjUpdateKey = struct{}{}
 
+type contextUpdateEntry struct {
+	Update   WaveObjUpdate
+	UpdateTs int64
+}
+
+// the updates can be added from multiple goroutines (e.g. when blocks are created in parallel), so every access goes
+// through lock
 type contextUpdatesType struct {
-	UpdatesStack []map[ORef]WaveObjUpdate
+	lock         sync.Mutex
+	UpdatesStack []map[ORef]contextUpdateEntry
+}
+
+func getContextUpdates(ctx context.Context) *contextUpdatesType {
+	updatesVal := ctx.Value(waveObjUpdateKey)
+	if updatesVal == nil {
+		return nil
+	}
+	return updatesVal.(*contextUpdatesType)
 }
 
 func dumpUpdateStack(updates *contextUpdatesType) {
+	updates.lock.Lock()
+	defer updates.lock.Unlock()
 	log.Printf("dumpUpdateStack len:%d\n", len(updates.UpdatesStack))
 	for idx, update := range updates.UpdatesStack {
 		var buf bytes.Buffer
@@ -35,20 +56,13 @@ func ContextWithUpdates(ctx context.Context) context.Context {
 		return ctx
 	}
 	return context.WithValue(ctx, waveObjUpdateKey, &contextUpdatesType{
-		UpdatesStack: []map[ORef]WaveObjUpdate{make(map[ORef]WaveObjUpdate)},
+		UpdatesStack: []map[ORef]contextUpdateEntry{make(map[ORef]contextUpdateEntry)},
 	})
 }
 
-func ContextGetUpdates(ctx context.Context) map[ORef]WaveObjUpdate {
-	updatesVal := ctx.Value(waveObjUpdateKey)
-	if updatesVal == nil {
-		return nil
-	}
-	updates := updatesVal.(*contextUpdatesType)
-	if len(updates.UpdatesStack) == 1 {
-		return updates.UpdatesStack[0]
-	}
-	rtn := make(map[ORef]WaveObjUpdate)
+// must hold updates.lock
+func (updates *contextUpdatesType) mergedEntries() map[ORef]contextUpdateEntry {
+	rtn := make(map[ORef]contextUpdateEntry)
 	for _, update := range updates.UpdatesStack {
 		for k, v := range update {
 			rtn[k] = v
@@ -57,14 +71,30 @@ func ContextGetUpdates(ctx context.Context) map[ORef]WaveObjUpdate {
 	return rtn
 }
 
+func ContextGetUpdates(ctx context.Context) map[ORef]WaveObjUpdate {
+	updates := getContextUpdates(ctx)
+	if updates == nil {
+		return nil
+	}
+	updates.lock.Lock()
+	defer updates.lock.Unlock()
+	rtn := make(map[ORef]WaveObjUpdate)
+	for k, v := range updates.mergedEntries() {
+		rtn[k] = v.Update
+	}
+	return rtn
+}
+
 func ContextGetUpdate(ctx context.Context, oref ORef) *WaveObjUpdate {
-	updatesVal := ctx.Value(waveObjUpdateKey)
-	if updatesVal == nil {
+	updates := getContextUpdates(ctx)
+	if updates == nil {
 		return nil
 	}
-	updates := updatesVal.(*contextUpdatesType)
+	updates.lock.Lock()
+	defer updates.lock.Unlock()
 	for idx := len(updates.UpdatesStack) - 1; idx >= 0; idx-- {
-		if obj, ok := updates.UpdatesStack[idx][oref]; ok {
+		if entry, ok := updates.UpdatesStack[idx][oref]; ok {
+			obj := entry.Update
 			return &obj
 		}
 	}
@@ -72,34 +102,37 @@ func ContextGetUpdate(ctx context.Context, oref ORef) *WaveObjUpdate {
 }
 
 func ContextAddUpdate(ctx context.Context, update WaveObjUpdate) {
-	updatesVal := ctx.Value(waveObjUpdateKey)
-	if updatesVal == nil {
+	updates := getContextUpdates(ctx)
+	if updates == nil {
 		return
 	}
-	updates := updatesVal.(*contextUpdatesType)
 	oref := ORef{
 		OType: update.OType,
 		OID:   update.OID,
 	}
-	updates.UpdatesStack[len(updates.UpdatesStack)-1][oref] = update
+	updates.lock.Lock()
+	defer updates.lock.Unlock()
+	updates.UpdatesStack[len(updates.UpdatesStack)-1][oref] = contextUpdateEntry{Update: update, UpdateTs: time.Now().UnixMilli()}
 }
 
 func ContextUpdatesBeginTx(ctx context.Context) context.Context {
-	updatesVal := ctx.Value(waveObjUpdateKey)
-	if updatesVal == nil {
+	updates := getContextUpdates(ctx)
+	if updates == nil {
 		return ctx
 	}
-	updates := updatesVal.(*contextUpdatesType)
-	updates.UpdatesStack = append(updates.UpdatesStack, make(map[ORef]WaveObjUpdate))
+	updates.lock.Lock()
+	defer updates.lock.Unlock()
+	updates.UpdatesStack = append(updates.UpdatesStack, make(map[ORef]contextUpdateEntry))
 	return ctx
 }
 
 func ContextUpdatesCommitTx(ctx context.Context) {
-	updatesVal := ctx.Value(waveObjUpdateKey)
-	if updatesVal == nil {
+	updates := getContextUpdates(ctx)
+	if updates == nil {
 		return
 	}
-	updates := updatesVal.(*contextUpdatesType)
+	updates.lock.Lock()
+	defer updates.lock.Unlock()
 	if len(updates.UpdatesStack) <= 1 {
 		panic(fmt.Errorf("no updates transaction to commit"))
 	}
@@ -113,41 +146,79 @@ func ContextUpdatesCommitTx(ctx context.Context) {
 }
 
 func ContextUpdatesRollbackTx(ctx context.Context) {
-	updatesVal := ctx.Value(waveObjUpdateKey)
-	if updatesVal == nil {
+	updates := getContextUpdates(ctx)
+	if updates == nil {
 		return
 	}
-	updates := updatesVal.(*contextUpdatesType)
+	updates.lock.Lock()
+	defer updates.lock.Unlock()
 	if len(updates.UpdatesStack) <= 1 {
 		panic(fmt.Errorf("no updates transaction to rollback"))
 	}
 	updates.UpdatesStack = updates.UpdatesStack[:len(updates.UpdatesStack)-1]
 }
 
+// returns the latest update for each object, ordered by update time (then by oid)
 func ContextGetUpdatesRtn(ctx context.Context) UpdatesRtnType {
-	updatesMap := ContextGetUpdates(ctx)
-	if updatesMap == nil {
+	updates := getContextUpdates(ctx)
+	if updates == nil {
 		return nil
 	}
-	rtn := make(UpdatesRtnType, 0, len(updatesMap))
-	for _, v := range updatesMap {
-		rtn = append(rtn, v)
+	updates.lock.Lock()
+	entries := updates.mergedEntries()
+	updates.lock.Unlock()
+	sorted := make([]contextUpdateEntry, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].UpdateTs != sorted[j].UpdateTs {
+			return sorted[i].UpdateTs < sorted[j].UpdateTs
+		}
+		if sorted[i].Update.OID != sorted[j].Update.OID {
+			return sorted[i].Update.OID < sorted[j].Update.OID
+		}
+		return sorted[i].Update.OType < sorted[j].Update.OType
+	})
+	rtn := make(UpdatesRtnType, 0, len(sorted))
+	for _, entry := range sorted {
+		rtn = append(rtn, entry.Update)
+	}
+	return rtn
+}
+
+// collapses multiple updates to the same object into one (the last one, which has the object's latest state).  the
+// updates are kept in the order of each object's last update.
+func DeduplicateUpdates(updates UpdatesRtnType) UpdatesRtnType {
+	if updates == nil {
+		return nil
+	}
+	lastIdx := make(map[ORef]int, len(updates))
+	for idx, update := range updates {
+		lastIdx[ORef{OType: update.OType, OID: update.OID}] = idx
+	}
+	rtn := make(UpdatesRtnType, 0, len(lastIdx))
+	for idx, update := range updates {
+		if lastIdx[ORef{OType: update.OType, OID: update.OID}] == idx {
+			rtn = append(rtn, update)
+		}
 	}
 	return rtn
 }
 
 func ContextPrintUpdates(ctx context.Context) {
-	updatesVal := ctx.Value(waveObjUpdateKey)
-	if updatesVal == nil {
+	updates := getContextUpdates(ctx)
+	if updates == nil {
 		log.Print("no updates\n")
 		return
 	}
-	updates := updatesVal.(*contextUpdatesType)
+	updates.lock.Lock()
+	defer updates.lock.Unlock()
 	log.Printf("updates len:%d\n", len(updates.UpdatesStack))
 	for idx, update := range updates.UpdatesStack {
 		log.Printf("  update[%d]:\n", idx)
 		for k, v := range update {
-			log.Printf("    %s:%s %s\n", k.OType, k.OID, v.UpdateType)
+			log.Printf("    %s:%s %s\n", k.OType, k.OID, v.Update.UpdateType)
 		}
 	}
 }
@@ -0,0 +1,76 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveobj
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func makeTestUpdate(obj WaveObj, oid string) WaveObjUpdate {
+	return WaveObjUpdate{UpdateType: UpdateType_Update, OType: obj.GetOType(), OID: oid, Obj: obj}
+}
+
+// run with -race
+func TestContextUpdatesConcurrent(t *testing.T) {
+	ctx := ContextWithUpdates(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			oid := fmt.Sprintf("block-%02d", i)
+			// the same object updated several times only yields its last update
+			for version := 1; version <= 3; version++ {
+				ContextAddUpdate(ctx, makeTestUpdate(&Block{OID: oid, Version: version}, oid))
+			}
+			ContextGetUpdate(ctx, ORef{OType: OType_Block, OID: oid})
+		}(i)
+	}
+	wg.Wait()
+	updates := ContextGetUpdatesRtn(ctx)
+	if len(updates) != 50 {
+		t.Fatalf("expected 50 updates, got %d", len(updates))
+	}
+	entries := getContextUpdates(ctx).mergedEntries()
+	for idx, update := range updates {
+		if update.Obj.(*Block).Version != 3 {
+			t.Errorf("%s: expected the latest version (3), got %d", update.OID, update.Obj.(*Block).Version)
+		}
+		if idx == 0 {
+			continue
+		}
+		prev := updates[idx-1]
+		prevTs := entries[ORef{OType: prev.OType, OID: prev.OID}].UpdateTs
+		curTs := entries[ORef{OType: update.OType, OID: update.OID}].UpdateTs
+		if prevTs > curTs || (prevTs == curTs && prev.OID >= update.OID) {
+			t.Errorf("updates out of order at %d: %s (%d) before %s (%d)", idx, prev.OID, prevTs, update.OID, curTs)
+		}
+	}
+}
+
+func TestDeduplicateUpdates(t *testing.T) {
+	updates := UpdatesRtnType{
+		makeTestUpdate(&Block{OID: "a", Version: 1}, "a"),
+		makeTestUpdate(&Tab{OID: "a", Version: 1}, "a"),
+		makeTestUpdate(&Block{OID: "b", Version: 1}, "b"),
+		makeTestUpdate(&Block{OID: "a", Version: 2}, "a"),
+		makeTestUpdate(&Block{OID: "a", Version: 3}, "a"),
+	}
+	rtn := DeduplicateUpdates(updates)
+	if len(rtn) != 3 {
+		t.Fatalf("expected 3 updates, got %d", len(rtn))
+	}
+	expected := []string{"tab:a", "block:b", "block:a"}
+	for idx, update := range rtn {
+		if got := update.OType + ":" + update.OID; got != expected[idx] {
+			t.Errorf("update %d: expected %s, got %s", idx, expected[idx], got)
+		}
+	}
+	if rtn[2].Obj.(*Block).Version != 3 {
+		t.Errorf("expected the latest block:a update, got version %d", rtn[2].Obj.(*Block).Version)
+	}
+}
@@ -11,6 +11,15 @@ const (
 	MetaKey_Controller                       = "controller"
 
 	MetaKey_File                             = "file"
+	MetaKey_FileTemp                         = "file:temp"
+	MetaKey_FileWaitCreate                   = "file:waitcreate"
+	MetaKey_FileWaitCreateUntil              = "file:waitcreateuntil"
+	MetaKey_FileSize                         = "file:size"
+	MetaKey_FileSort                         = "file:sort"
+	MetaKey_FileSortDesc                     = "file:sortdesc"
+	MetaKey_FileShowHidden                   = "file:showhidden"
+	MetaKey_FileOffset                       = "file:offset"
+	MetaKey_FileLimit                        = "file:limit"
 
 	MetaKey_Url                              = "url"
 
@@ -20,9 +29,18 @@ const (
 
 	MetaKey_Edit                             = "edit"
 
+	MetaKey_Pinned                           = "pinned"
+
+	MetaKey_Magnified                        = "magnified"
+
 	MetaKey_History                          = "history"
 	MetaKey_HistoryForward                   = "history:forward"
 
+	MetaKey_ControllerRunning                = "controller:running"
+	MetaKey_ControllerRestore                = "controller:restore"
+	MetaKey_ControllerRestoreError           = "controller:restoreerror"
+	MetaKey_ControllerShellType              = "controller:shelltype"
+
 	MetaKey_DisplayName                      = "display:name"
 	MetaKey_DisplayOrder                     = "display:order"
 
@@ -47,6 +65,9 @@ const (
 	MetaKey_CmdCloseOnExit                   = "cmd:closeonexit"
 	MetaKey_CmdCloseOnExitForce              = "cmd:closeonexitforce"
 	MetaKey_CmdCloseOnExitDelay              = "cmd:closeonexitdelay"
+	MetaKey_CmdOnExit                        = "cmd:onexit"
+	MetaKey_CmdOnFail                        = "cmd:onfail"
+	MetaKey_CmdHookTimeoutMs                 = "cmd:hooktimeoutms"
 	MetaKey_CmdEnv                           = "cmd:env"
 	MetaKey_CmdCwd                           = "cmd:cwd"
 	MetaKey_CmdNoWsh                         = "cmd:nowsh"
@@ -55,9 +76,11 @@ const (
 
 	MetaKey_AiClear                          = "ai:*"
 	MetaKey_AiPresetKey                      = "ai:preset"
+	MetaKey_AiProvider                       = "ai:provider"
 	MetaKey_AiApiType                        = "ai:apitype"
 	MetaKey_AiBaseURL                        = "ai:baseurl"
 	MetaKey_AiApiToken                       = "ai:apitoken"
+	MetaKey_AiApiKeyRef                      = "ai:apikeyref"
 	MetaKey_AiName                           = "ai:name"
 	MetaKey_AiModel                          = "ai:model"
 	MetaKey_AiOrgID                          = "ai:orgid"
@@ -76,6 +99,10 @@ const (
 
 	MetaKey_SysinfoType                      = "sysinfo:type"
 
+	MetaKey_PlotClear                        = "plot:*"
+	MetaKey_PlotMetrics                      = "plot:metrics"
+	MetaKey_PlotInterval                     = "plot:interval"
+
 	MetaKey_BgClear                          = "bg:*"
 	MetaKey_Bg                               = "bg"
 	MetaKey_BgOpacity                        = "bg:opacity"
@@ -83,6 +110,8 @@ const (
 	MetaKey_BgBorderColor                    = "bg:bordercolor"
 	MetaKey_BgActiveBorderColor              = "bg:activebordercolor"
 
+	MetaKey_TabTitleTemplate                 = "tab:titletemplate"
+
 	MetaKey_TermClear                        = "term:*"
 	MetaKey_TermFontSize                     = "term:fontsize"
 	MetaKey_TermFontFamily                   = "term:fontfamily"
@@ -91,6 +120,8 @@ const (
 	MetaKey_TermLocalShellPath               = "term:localshellpath"
 	MetaKey_TermLocalShellOpts               = "term:localshellopts"
 	MetaKey_TermScrollback                   = "term:scrollback"
+	MetaKey_TermScrollbackBytes              = "term:scrollbackbytes"
+	MetaKey_TermScrollbackLines              = "term:scrollbacklines"
 	MetaKey_TermVDomSubBlockId               = "term:vdomblockid"
 	MetaKey_TermVDomToolbarBlockId           = "term:vdomtoolbarblockid"
 	MetaKey_TermTransparency                 = "term:transparency"
@@ -99,6 +130,7 @@ const (
 
 	MetaKey_WebZoom                          = "web:zoom"
 	MetaKey_WebHideNav                       = "web:hidenav"
+	MetaKey_WebRendered                      = "web:rendered"
 
 	MetaKey_MarkdownFontSize                 = "markdown:fontsize"
 	MetaKey_MarkdownFixedFontSize            = "markdown:fixedfontsize"
@@ -109,6 +141,19 @@ const (
 	MetaKey_VDomRoute                        = "vdom:route"
 	MetaKey_VDomPersist                      = "vdom:persist"
 
+	MetaKey_ClientDbTimeoutMs                = "client:dbtimeoutms"
+	MetaKey_ClientTheme                      = "client:theme"
+	MetaKey_ClientDefaultShell               = "client:defaultshell"
+	MetaKey_ClientDefaultView                = "client:defaultview"
+	MetaKey_ClientTelemetryOptOut            = "client:telemetryoptout"
+	MetaKey_ClientScrollbackBytes            = "client:scrollbackbytes"
+	MetaKey_ClientScrollbackLines            = "client:scrollbacklines"
+	MetaKey_ClientOnExit                     = "client:onexit"
+	MetaKey_ClientOnFail                     = "client:onfail"
+	MetaKey_ClientAIMaxMessages              = "client:aimaxmessages"
+	MetaKey_ClientAIRetentionDays            = "client:airetentiondays"
+	MetaKey_ClientLogLevels                  = "client:loglevels"
+
 	MetaKey_Count                            = "count"
 )
 
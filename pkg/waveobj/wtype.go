@@ -22,23 +22,27 @@ const (
 )
 
 const (
-	OType_Client      = "client"
-	OType_Window      = "window"
-	OType_Workspace   = "workspace"
-	OType_Tab         = "tab"
-	OType_LayoutState = "layout"
-	OType_Block       = "block"
-	OType_Temp        = "temp"
+	OType_Client       = "client"
+	OType_Window       = "window"
+	OType_Workspace    = "workspace"
+	OType_Tab          = "tab"
+	OType_LayoutState  = "layout"
+	OType_Block        = "block"
+	OType_LayoutPreset = "layoutpreset"
+	OType_BlockLink    = "blocklink"
+	OType_Temp         = "temp"
 )
 
 var ValidOTypes = map[string]bool{
-	OType_Client:      true,
-	OType_Window:      true,
-	OType_Workspace:   true,
-	OType_Tab:         true,
-	OType_LayoutState: true,
-	OType_Block:       true,
-	OType_Temp:        true,
+	OType_Client:       true,
+	OType_Window:       true,
+	OType_Workspace:    true,
+	OType_Tab:          true,
+	OType_LayoutState:  true,
+	OType_Block:        true,
+	OType_LayoutPreset: true,
+	OType_BlockLink:    true,
+	OType_Temp:         true,
 }
 
 type WaveObjUpdate struct {
@@ -146,6 +150,7 @@ type Window struct {
 	WinSize     WinSize     `json:"winsize"`
 	LastFocusTs int64       `json:"lastfocusts"`
 	Meta        MetaMapType `json:"meta"`
+	CreatedTs   int64       `json:"createdts,omitempty"` // not set for windows created by older versions
 }
 
 func (*Window) GetOType() string {
@@ -164,6 +169,12 @@ type ActiveTabUpdate struct {
 	NewActiveTabId string `json:"newactivetabid"`
 }
 
+type WindowBoundsUpdate struct {
+	WindowId string  `json:"windowid"`
+	Pos      Point   `json:"pos"`
+	WinSize  WinSize `json:"winsize"`
+}
+
 type Workspace struct {
 	OID          string      `json:"oid"`
 	Version      int         `json:"version"`
@@ -184,9 +195,12 @@ type Tab struct {
 	OID         string      `json:"oid"`
 	Version     int         `json:"version"`
 	Name        string      `json:"name"`
+	GroupName   string      `json:"groupname,omitempty"` // tabs in the same group are kept next to each other
 	LayoutState string      `json:"layoutstate"`
 	BlockIds    []string    `json:"blockids"`
 	Meta        MetaMapType `json:"meta"`
+	Pinned      bool        `json:"pinned,omitempty"`    // only set in GetTabsForWindow (the workspace's PinnedTabIds are the source of truth)
+	CreatedTs   int64       `json:"createdts,omitempty"` // not set for tabs created by older versions
 }
 
 func (*Tab) GetOType() string {
@@ -209,6 +223,18 @@ type LayoutActionData struct {
 	Focused    bool   `json:"focused"`
 	Magnified  bool   `json:"magnified"`
 	Ephemeral  bool   `json:"ephemeral"`
+	// for split and swap actions
+	TargetBlockId string `json:"targetblockid,omitempty"`
+	Direction     string `json:"direction,omitempty"`
+	// for resize actions, the node's share of its parent
+	SizeFraction float64 `json:"sizefraction,omitempty"`
+}
+
+// the meta keys that changed in a block update (removed keys have nil values)
+type BlockMetaUpdateData struct {
+	BlockId string      `json:"blockid"`
+	Version int         `json:"version"`
+	Meta    MetaMapType `json:"meta"`
 }
 
 type LeafOrderEntry struct {
@@ -231,6 +257,49 @@ func (*LayoutState) GetOType() string {
 	return OType_LayoutState
 }
 
+// a block (and where it goes in the layout), used for the starter layout and for layout presets
+type PortableLayoutEntry struct {
+	IndexArr []int     `json:"indexarr"`
+	Size     *uint     `json:"size,omitempty"`
+	BlockDef *BlockDef `json:"blockdef"`
+	Focused  bool      `json:"focused"`
+}
+
+// a saved tab layout that can be re-applied to a tab (by name)
+type LayoutPreset struct {
+	OID       string                `json:"oid"`
+	Version   int                   `json:"version"`
+	Name      string                `json:"name"`
+	CreatedTs int64                 `json:"createdts"`
+	Layout    []PortableLayoutEntry `json:"layout"`
+	Meta      MetaMapType           `json:"meta,omitempty"`
+}
+
+func (*LayoutPreset) GetOType() string {
+	return OType_LayoutPreset
+}
+
+const (
+	BlockLinkRule_Regex = "regex" // the rule is a regex, the first capture group (or the whole match) is used
+)
+
+// links a block's output to another block: when a line of the source block's output matches the rule, the target
+// block's file (preview) or url (web) is set to the match.  links are removed when either block is deleted.
+type BlockLink struct {
+	OID        string      `json:"oid"`
+	Version    int         `json:"version"`
+	SrcBlockId string      `json:"srcblockid"`
+	DstBlockId string      `json:"dstblockid"`
+	RuleType   string      `json:"ruletype"`
+	Rule       string      `json:"rule"`
+	CreatedTs  int64       `json:"createdts"`
+	Meta       MetaMapType `json:"meta,omitempty"`
+}
+
+func (*BlockLink) GetOType() string {
+	return OType_BlockLink
+}
+
 type FileDef struct {
 	Content string         `json:"content,omitempty"`
 	Meta    map[string]any `json:"meta,omitempty"`
@@ -274,6 +343,14 @@ type WinSize struct {
 	Height int `json:"height"`
 }
 
+// a window position and size, the fields that are not set are left as they are
+type WindowGeometry struct {
+	X      *int `json:"x,omitempty"`
+	Y      *int `json:"y,omitempty"`
+	Width  *int `json:"width,omitempty"`
+	Height *int `json:"height,omitempty"`
+}
+
 type Block struct {
 	OID         string         `json:"oid"`
 	ParentORef  string         `json:"parentoref,omitempty"`
@@ -282,6 +359,8 @@ type Block struct {
 	Stickers    []*StickerType `json:"stickers,omitempty"`
 	Meta        MetaMapType    `json:"meta"`
 	SubBlockIds []string       `json:"subblockids,omitempty"`
+	DeletedTs   int64          `json:"deletedts,omitempty"` // set when the block is in the trash (soft deleted)
+	CreatedTs   int64          `json:"createdts,omitempty"` // not set for blocks created by older versions
 }
 
 func (*Block) GetOType() string {
@@ -296,6 +375,8 @@ func AllWaveObjTypes() []reflect.Type {
 		reflect.TypeOf(&Tab{}),
 		reflect.TypeOf(&Block{}),
 		reflect.TypeOf(&LayoutState{}),
+		reflect.TypeOf(&LayoutPreset{}),
+		reflect.TypeOf(&BlockLink{}),
 	}
 }
 
@@ -4,6 +4,7 @@
 package waveobj
 
 import (
+	"reflect"
 	"strings"
 )
 
@@ -12,15 +13,31 @@ const Entity_Any = "any"
 // for typescript typing
 type MetaTSType struct {
 	// shared
-	View           string   `json:"view,omitempty"`
-	Controller     string   `json:"controller,omitempty"`
-	File           string   `json:"file,omitempty"`
-	Url            string   `json:"url,omitempty"`
-	PinnedUrl      string   `json:"pinnedurl,omitempty"`
-	Connection     string   `json:"connection,omitempty"`
-	Edit           bool     `json:"edit,omitempty"`
-	History        []string `json:"history,omitempty"`
-	HistoryForward []string `json:"history:forward,omitempty"`
+	View                string   `json:"view,omitempty"`
+	Controller          string   `json:"controller,omitempty"`
+	File                string   `json:"file,omitempty"`
+	FileTemp            bool     `json:"file:temp,omitempty"`            // file is a wsh temp file, removed when the block is closed
+	FileWaitCreate      bool     `json:"file:waitcreate,omitempty"`      // file doesn't exist yet, the preview waits for it to be created
+	FileWaitCreateUntil int64    `json:"file:waitcreateuntil,omitempty"` // unix ms, stop waiting after this (0 waits until the block is closed)
+	FileSize            int64    `json:"file:size,omitempty"`            // size of the file when the preview last read it (large files are read in ranges)
+	FileSort            string   `json:"file:sort,omitempty"`            // directory listing sort: name (default), size, or mtime
+	FileSortDesc        bool     `json:"file:sortdesc,omitempty"`        // sort the listing in descending order
+	FileShowHidden      *bool    `json:"file:showhidden,omitempty"`      // overrides the preview:showhiddenfiles setting
+	FileOffset          int      `json:"file:offset,omitempty"`          // directory listings are paged, the first entry shown
+	FileLimit           int      `json:"file:limit,omitempty"`           // entries per page (defaults to 500)
+	Url                 string   `json:"url,omitempty"`
+	PinnedUrl           string   `json:"pinnedurl,omitempty"`
+	Connection          string   `json:"connection,omitempty"`
+	Edit                bool     `json:"edit,omitempty"`
+	Pinned              bool     `json:"pinned,omitempty"`    // pinned blocks are skipped when all the blocks in a tab are closed (unless forced)
+	Magnified           bool     `json:"magnified,omitempty"` // set by SetBlockMagnified (the layout state has the magnified node)
+	History             []string `json:"history,omitempty"`
+	HistoryForward      []string `json:"history:forward,omitempty"`
+
+	ControllerRunning      bool   `json:"controller:running,omitempty"`      // the shell was running (kept when the server shuts down, see blockcontroller.RestoreSession)
+	ControllerRestore      *bool  `json:"controller:restore,omitempty"`      // restart the shell after a server restart if it was running (defaults to true)
+	ControllerRestoreError string `json:"controller:restoreerror,omitempty"` // why the shell couldn't be restarted after the server restart
+	ControllerShellType    string `json:"controller:shelltype,omitempty"`    // the type of the running shell (bash, zsh, fish, pwsh, cmd, or sh), used to quote input
 
 	DisplayName  string  `json:"display:name,omitempty"`
 	DisplayOrder float64 `json:"display:order,omitempty"`
@@ -46,6 +63,9 @@ type MetaTSType struct {
 	CmdCloseOnExit      bool              `json:"cmd:closeonexit,omitempty"`
 	CmdCloseOnExitForce bool              `json:"cmd:closeonexitforce,omitempty"`
 	CmdCloseOnExitDelay float64           `json:"cmd:closeonexitdelay,omitempty"`
+	CmdOnExit           string            `json:"cmd:onexit,omitempty"`        // run locally when the controller exits (overrides client:onexit)
+	CmdOnFail           string            `json:"cmd:onfail,omitempty"`        // run locally when the controller exits with an error (overrides client:onfail)
+	CmdHookTimeoutMs    float64           `json:"cmd:hooktimeoutms,omitempty"` // kill the onexit/onfail hooks after this long (default 30000)
 	CmdEnv              map[string]string `json:"cmd:env,omitempty"`
 	CmdCwd              string            `json:"cmd:cwd,omitempty"`
 	CmdNoWsh            bool              `json:"cmd:nowsh,omitempty"`
@@ -55,9 +75,11 @@ type MetaTSType struct {
 	// AI options match settings
 	AiClear      bool    `json:"ai:*,omitempty"`
 	AiPresetKey  string  `json:"ai:preset,omitempty"`
+	AiProvider   string  `json:"ai:provider,omitempty"` // openai, anthropic, ollama, google, perplexity, or wave (overrides ai:apitype)
 	AiApiType    string  `json:"ai:apitype,omitempty"`
 	AiBaseURL    string  `json:"ai:baseurl,omitempty"`
 	AiApiToken   string  `json:"ai:apitoken,omitempty"`
+	AiApiKeyRef  string  `json:"ai:apikeyref,omitempty"` // the name of the secret with the api key (see wsh secret)
 	AiName       string  `json:"ai:name,omitempty"`
 	AiModel      string  `json:"ai:model,omitempty"`
 	AiOrgID      string  `json:"ai:orgid,omitempty"`
@@ -76,6 +98,10 @@ type MetaTSType struct {
 
 	SysinfoType string `json:"sysinfo:type,omitempty"`
 
+	PlotClear    bool     `json:"plot:*,omitempty"`
+	PlotMetrics  []string `json:"plot:metrics,omitempty"`  // overrides sysinfo:type, e.g. ["cpu", "mem"] (see sysmetrics.MetricMatches)
+	PlotInterval string   `json:"plot:interval,omitempty"` // time between points, e.g. "2s" (min 1s)
+
 	// for tabs
 	BgClear             bool    `json:"bg:*,omitempty"`
 	Bg                  string  `json:"bg,omitempty"`
@@ -84,6 +110,8 @@ type MetaTSType struct {
 	BgBorderColor       string  `json:"bg:bordercolor,omitempty"`       // frame:bordercolor
 	BgActiveBorderColor string  `json:"bg:activebordercolor,omitempty"` // frame:activebordercolor
 
+	TabTitleTemplate string `json:"tab:titletemplate,omitempty"` // e.g. "{conn}:{cwd}", resolved by the backend (see wcore.RenderTabTitle)
+
 	TermClear               bool     `json:"term:*,omitempty"`
 	TermFontSize            int      `json:"term:fontsize,omitempty"`
 	TermFontFamily          string   `json:"term:fontfamily,omitempty"`
@@ -92,14 +120,17 @@ type MetaTSType struct {
 	TermLocalShellPath      string   `json:"term:localshellpath,omitempty"` // matches settings
 	TermLocalShellOpts      []string `json:"term:localshellopts,omitempty"` // matches settings
 	TermScrollback          *int     `json:"term:scrollback,omitempty"`
+	TermScrollbackBytes     int      `json:"term:scrollbackbytes,omitempty"` // overrides client:scrollbackbytes
+	TermScrollbackLines     int      `json:"term:scrollbacklines,omitempty"` // overrides client:scrollbacklines
 	TermVDomSubBlockId      string   `json:"term:vdomblockid,omitempty"`
 	TermVDomToolbarBlockId  string   `json:"term:vdomtoolbarblockid,omitempty"`
 	TermTransparency        *float64 `json:"term:transparency,omitempty"` // default 0.5
 	TermAllowBracketedPaste *bool    `json:"term:allowbracketedpaste,omitempty"`
 	TermConnDebug           string   `json:"term:conndebug,omitempty"` // null, info, debug
 
-	WebZoom    float64 `json:"web:zoom,omitempty"`
-	WebHideNav *bool   `json:"web:hidenav,omitempty"`
+	WebZoom     float64 `json:"web:zoom,omitempty"`
+	WebHideNav  *bool   `json:"web:hidenav,omitempty"`
+	WebRendered bool    `json:"web:rendered,omitempty"` // set by "wsh render --html", the block's url can be a data: url
 
 	MarkdownFontSize      float64 `json:"markdown:fontsize,omitempty"`
 	MarkdownFixedFontSize float64 `json:"markdown:fixedfontsize,omitempty"`
@@ -110,6 +141,21 @@ type MetaTSType struct {
 	VDomRoute         string `json:"vdom:route,omitempty"`
 	VDomPersist       bool   `json:"vdom:persist,omitempty"`
 
+	// for client
+	ClientDbTimeoutMs     float64 `json:"client:dbtimeoutms,omitempty"`     // timeout for ClientService db calls (clamped to 1000-60000, default 2000)
+	ClientTheme           string  `json:"client:theme,omitempty"`           // terminal theme (a name from termthemes)
+	ClientDefaultShell    string  `json:"client:defaultshell,omitempty"`    // shell for local terminals (overrides term:localshellpath in the settings)
+	ClientDefaultView     string  `json:"client:defaultview,omitempty"`     // default view for new blocks
+	ClientTelemetryOptOut bool    `json:"client:telemetryoptout,omitempty"` // turns off telemetry even if telemetry:enabled is set
+	ClientScrollbackBytes int     `json:"client:scrollbackbytes,omitempty"` // max bytes of terminal output kept per block (0 = the default ring buffer)
+	ClientScrollbackLines int     `json:"client:scrollbacklines,omitempty"` // max lines of terminal output kept per block (0 = no limit)
+	ClientOnExit          string  `json:"client:onexit,omitempty"`          // default cmd:onexit for every block
+	ClientOnFail          string  `json:"client:onfail,omitempty"`          // default cmd:onfail for every block
+	ClientAIMaxMessages   int     `json:"client:aimaxmessages,omitempty"`   // max messages kept per ai conversation (0 = no limit)
+	ClientAIRetentionDays int     `json:"client:airetentiondays,omitempty"` // ai conversations are deleted this many days after their last message (0 = kept)
+	// backend log level per subsystem ("*" for the default), see wsh log level
+	ClientLogLevels map[string]string `json:"client:loglevels,omitempty"`
+
 	Count int `json:"count,omitempty"` // temp for cpu plot. will remove later
 }
 
@@ -174,3 +220,20 @@ func MergeMeta(meta MetaMapType, metaUpdate MetaMapType, mergeSpecial bool) Meta
 	}
 	return rtn
 }
+
+// returns the keys that are different in newMeta (keys that were removed have a nil value)
+func GetMetaChanges(oldMeta MetaMapType, newMeta MetaMapType) MetaMapType {
+	changes := make(MetaMapType)
+	for k, v := range newMeta {
+		oldVal, found := oldMeta[k]
+		if !found || !reflect.DeepEqual(oldVal, v) {
+			changes[k] = v
+		}
+	}
+	for k := range oldMeta {
+		if _, found := newMeta[k]; !found {
+			changes[k] = nil
+		}
+	}
+	return changes
+}
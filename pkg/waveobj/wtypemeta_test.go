@@ -0,0 +1,22 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package waveobj
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetMetaChanges(t *testing.T) {
+	oldMeta := MetaMapType{"view": "term", "term:fontsize": 12, "bg": "red", "bg:opacity": 0.5, "cmd:env": map[string]any{"A": "1"}}
+	patch := MetaMapType{"term:fontsize": 14, "view": "term", "bg:*": true, "missing": nil, "cmd:env": map[string]any{"A": "1"}}
+	changes := GetMetaChanges(oldMeta, MergeMeta(oldMeta, patch, false))
+	expected := MetaMapType{"term:fontsize": 14, "bg": nil, "bg:opacity": nil}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changes)
+	}
+	if changes := GetMetaChanges(oldMeta, MergeMeta(oldMeta, MetaMapType{"view": "term"}, false)); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
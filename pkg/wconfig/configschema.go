@@ -0,0 +1,371 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wconfig
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wconfig/defaultconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// one problem found by ValidateConfigFile (Line is 0 if the problem isn't at a specific line)
+type ConfigValidationError struct {
+	Key     string `json:"key,omitempty"` // the path to the value, e.g. "defwidget@files.blockdef.meta"
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ConfigValidationError) String() string {
+	var rtn string
+	if e.Line > 0 {
+		rtn = fmt.Sprintf("line %d: ", e.Line)
+	}
+	if e.Key != "" {
+		rtn += e.Key + ": "
+	}
+	return rtn + e.Message
+}
+
+// data for the configvalidation event, sent when a config file is saved (Errors is empty if the file was valid)
+type ConfigValidationEventData struct {
+	Path   string                  `json:"path"`
+	Errors []ConfigValidationError `json:"errors"`
+}
+
+// the config file schemas by config part (the json tags of FullConfigType), the values of a part's file are
+// checked against the go type the part is read into
+var configSchemas = make(map[string]reflect.Type)
+
+func init() {
+	configRType := reflect.TypeOf(FullConfigType{})
+	for fieldIdx := 0; fieldIdx < configRType.NumField(); fieldIdx++ {
+		field := configRType.Field(fieldIdx)
+		if field.PkgPath != "" || field.Tag.Get("configfile") == "-" {
+			continue
+		}
+		if jsonTag := utilfn.GetJsonTag(field); jsonTag != "" && jsonTag != "-" {
+			configSchemas[jsonTag] = field.Type
+		}
+	}
+}
+
+// returns the config part of a config file name ("settings.json" => "settings", "presets/ai.json" => "presets")
+func getConfigPartName(fileName string) string {
+	fileName = filepath.ToSlash(fileName)
+	if slashIdx := strings.Index(fileName, "/"); slashIdx != -1 {
+		return fileName[:slashIdx]
+	}
+	return strings.TrimSuffix(fileName, ".json")
+}
+
+// converts a section name (as passed to wsh editconfig) to the name of its file in the config dir.
+// "" is settings.json, ".json" is optional ("presets/ai" => "presets/ai.json").
+func GetConfigFileName(section string) (string, error) {
+	if section == "" {
+		return SettingsFile, nil
+	}
+	fileName := filepath.ToSlash(section)
+	if !strings.HasSuffix(fileName, ".json") {
+		fileName += ".json"
+	}
+	cleanName := filepath.ToSlash(filepath.Clean(fileName))
+	if filepath.IsAbs(fileName) || strings.HasPrefix(fileName, "/") || cleanName != fileName || strings.HasPrefix(cleanName, "../") {
+		return "", fmt.Errorf("invalid config section %q (must be a file name in the config dir)", section)
+	}
+	return cleanName, nil
+}
+
+// returns the name of the config file (relative to the config dir) for a local path, or "" if the path isn't a
+// json file in the config dir
+func GetConfigFileForPath(path string) string {
+	path, err := wavebase.ExpandHomeDir(path)
+	if err != nil || !strings.HasSuffix(path, ".json") {
+		return ""
+	}
+	relPath, err := filepath.Rel(wavebase.GetWaveConfigDir(), path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(relPath)
+}
+
+// wave's own config files are checked against their schema before they are saved (an invalid file isn't written).
+// the result is sent as a configvalidation event (scoped by path) so the editor can show the errors inline.
+// paths that are not config files are not checked.
+func ValidateConfigSave(path string, data64 string) error {
+	configFile := GetConfigFileForPath(path)
+	if configFile == "" {
+		return nil
+	}
+	barr, err := base64.StdEncoding.DecodeString(data64)
+	if err != nil {
+		return fmt.Errorf("error decoding data64: %w", err)
+	}
+	validationErrs := ValidateConfigFile(configFile, barr)
+	wps.Broker.Publish(wps.WaveEvent{
+		Event:  wps.Event_ConfigValidation,
+		Scopes: []string{path},
+		Data:   &ConfigValidationEventData{Path: path, Errors: validationErrs},
+	})
+	if len(validationErrs) == 0 {
+		return nil
+	}
+	errStr := validationErrs[0].String()
+	if len(validationErrs) > 1 {
+		errStr += fmt.Sprintf(" (and %d more)", len(validationErrs)-1)
+	}
+	return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid config, %s not saved: %s", configFile, errStr)
+}
+
+// checks the contents of a config file before it is saved: the json must be an object, and the files of a known
+// config part (settings.json, connections.json, widgets.json, etc.) must match its schema (no unknown keys, and
+// values of the right types).  null is allowed everywhere (it unsets the value).
+func ValidateConfigFile(fileName string, barr []byte) []ConfigValidationError {
+	if len(bytes.TrimSpace(barr)) == 0 {
+		return nil
+	}
+	if syntaxErr := getSyntaxError(barr); syntaxErr != nil {
+		return []ConfigValidationError{*syntaxErr}
+	}
+	schema := configSchemas[getConfigPartName(fileName)]
+	if schema == nil {
+		// any object
+		schema = reflect.TypeOf(map[string]any{})
+	}
+	validator := &configValidator{data: barr, dec: json.NewDecoder(bytes.NewReader(barr))}
+	validator.dec.UseNumber()
+	err := validator.validateValue("", 1, schema)
+	if err != nil {
+		validator.addError("", 0, fmt.Sprintf("error reading json: %v", err))
+	}
+	return validator.errs
+}
+
+// returns nil if the json is valid (the message is like the one from readConfigHelper, without the line)
+func getSyntaxError(barr []byte) *ConfigValidationError {
+	var syntaxErr *json.SyntaxError
+	if err := json.Unmarshal(barr, new(any)); !errors.As(err, &syntaxErr) {
+		return nil
+	}
+	offset := int(max(syntaxErr.Offset-1, 0))
+	lineNum, colNum := utilfn.GetLineColFromOffset(barr, offset)
+	message := fmt.Sprintf("json syntax error at col %d: %v", colNum, syntaxErr)
+	if isTrailingCommaError(barr, offset) {
+		message = fmt.Sprintf("json syntax error at col %d: probably an extra trailing comma: %v", colNum, syntaxErr)
+	}
+	return &ConfigValidationError{Line: lineNum, Message: message}
+}
+
+type configValidator struct {
+	data []byte
+	dec  *json.Decoder
+	errs []ConfigValidationError
+}
+
+func (v *configValidator) addError(key string, line int, message string) {
+	v.errs = append(v.errs, ConfigValidationError{Key: key, Line: line, Message: message})
+}
+
+// the line of the last token read
+func (v *configValidator) curLine() int {
+	lineNum, _ := utilfn.GetLineColFromOffset(v.data, int(v.dec.InputOffset()))
+	return lineNum
+}
+
+func joinConfigKey(parent string, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func getJsonFields(rtype reflect.Type) map[string]reflect.Type {
+	rtn := make(map[string]reflect.Type)
+	for fieldIdx := 0; fieldIdx < rtype.NumField(); fieldIdx++ {
+		field := rtype.Field(fieldIdx)
+		if jsonTag := utilfn.GetJsonTag(field); field.PkgPath == "" && jsonTag != "" && jsonTag != "-" {
+			rtn[jsonTag] = field.Type
+		}
+	}
+	return rtn
+}
+
+func describeJsonToken(tok json.Token) string {
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '{' {
+			return "an object"
+		}
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case json.Number:
+		return "a number"
+	}
+	return "null"
+}
+
+// reads the next value from the decoder and checks it against rtype (errors in the value are added to v.errs,
+// the returned error is for json that can't be read)
+func (v *configValidator) validateValue(key string, line int, rtype reflect.Type) error {
+	tok, err := v.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	for rtype.Kind() == reflect.Pointer {
+		rtype = rtype.Elem()
+	}
+	var expected string
+	switch rtype.Kind() {
+	case reflect.Interface:
+		return v.skipValue(tok)
+	case reflect.Struct:
+		if tok != json.Delim('{') {
+			expected = "an object"
+			break
+		}
+		fields := getJsonFields(rtype)
+		return v.validateObject(key, func(childKey string) (reflect.Type, bool) {
+			ftype, ok := fields[childKey]
+			return ftype, ok
+		})
+	case reflect.Map:
+		if tok != json.Delim('{') {
+			expected = "an object"
+			break
+		}
+		return v.validateObject(key, func(string) (reflect.Type, bool) {
+			return rtype.Elem(), true
+		})
+	case reflect.Slice, reflect.Array:
+		if tok != json.Delim('[') {
+			expected = "an array"
+			break
+		}
+		for idx := 0; v.dec.More(); idx++ {
+			if err := v.validateValue(fmt.Sprintf("%s[%d]", key, idx), v.curLine(), rtype.Elem()); err != nil {
+				return err
+			}
+		}
+		_, err = v.dec.Token()
+		return err
+	case reflect.String:
+		if _, ok := tok.(string); !ok {
+			expected = "a string"
+		}
+	case reflect.Bool:
+		if _, ok := tok.(bool); !ok {
+			expected = "a boolean"
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := tok.(json.Number); !ok {
+			expected = "a number"
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, ok := tok.(json.Number)
+		if !ok {
+			expected = "an integer"
+		} else if _, err := num.Int64(); err != nil {
+			expected = "an integer"
+		}
+	}
+	if expected != "" {
+		v.addError(key, line, fmt.Sprintf("expected %s, got %s", expected, describeJsonToken(tok)))
+	}
+	return v.skipValue(tok)
+}
+
+// reads the keys and values of an object (its '{' was read), getType returns the type of a key's value (false
+// if the key isn't allowed)
+func (v *configValidator) validateObject(key string, getType func(childKey string) (reflect.Type, bool)) error {
+	for v.dec.More() {
+		keyTok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		childKey, _ := keyTok.(string)
+		childLine := v.curLine()
+		childType, ok := getType(childKey)
+		if !ok {
+			v.addError(joinConfigKey(key, childKey), childLine, "unknown key")
+			childType = reflect.TypeOf((*any)(nil)).Elem()
+		}
+		if err := v.validateValue(joinConfigKey(key, childKey), childLine, childType); err != nil {
+			return err
+		}
+	}
+	_, err := v.dec.Token()
+	return err
+}
+
+// skips the rest of a value whose first token was read
+func (v *configValidator) skipValue(tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	depth := 1
+	for depth > 0 {
+		tok, err := v.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// replaces a config file with its defaults ({} if the section doesn't have defaults).  the old file is kept as
+// <file>.bak, returns the backup's path ("" if the file didn't exist).
+func ResetConfigFile(fileName string) (string, error) {
+	defaultData, err := fs.ReadFile(defaultconfig.ConfigFS, fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		if configSchemas[getConfigPartName(fileName)] == nil {
+			return "", fmt.Errorf("no defaults for config file %q", fileName)
+		}
+		defaultData, err = []byte("{}\n"), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading defaults for %q: %w", fileName, err)
+	}
+	fullPath := filepath.Join(wavebase.GetWaveConfigDir(), filepath.FromSlash(fileName))
+	var backupPath string
+	if oldData, err := os.ReadFile(fullPath); err == nil {
+		backupPath = fullPath + ".bak"
+		if err := os.WriteFile(backupPath, oldData, 0644); err != nil {
+			return "", fmt.Errorf("error backing up %q: %w", fileName, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("error reading %q: %w", fileName, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return "", fmt.Errorf("error creating config dir: %w", err)
+	}
+	if err := os.WriteFile(fullPath, defaultData, 0644); err != nil {
+		return "", fmt.Errorf("error writing %q: %w", fileName, err)
+	}
+	return backupPath, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wconfig
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wconfig/defaultconfig"
+)
+
+func TestValidateDefaultConfigFiles(t *testing.T) {
+	err := fs.WalkDir(defaultconfig.ConfigFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		barr, err := fs.ReadFile(defaultconfig.ConfigFS, path)
+		if err != nil {
+			return err
+		}
+		if verrs := ValidateConfigFile(path, barr); len(verrs) > 0 {
+			t.Errorf("default config %s is invalid: %v", path, verrs)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error reading the default config: %v", err)
+	}
+}
+
+func TestValidateConfigFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     string
+		expected []ConfigValidationError
+	}{
+		{name: "valid", fileName: "settings.json", data: `{"term:fontsize": 12, "term:copyonselect": null, "window:tilegapsize": 3}`},
+		{name: "empty", fileName: "settings.json", data: "\n"},
+		{name: "unknown key", fileName: "settings.json", data: "{\n  \"term:fontsize\": 12,\n  \"term:fontsise\": 12\n}",
+			expected: []ConfigValidationError{{Key: "term:fontsise", Line: 3, Message: "unknown key"}}},
+		{name: "wrong type", fileName: "settings.json", data: "{\n  \"term:fontsize\": \"big\"\n}",
+			expected: []ConfigValidationError{{Key: "term:fontsize", Line: 2, Message: "expected a number, got a string"}}},
+		{name: "not an integer", fileName: "settings.json", data: `{"window:tilegapsize": 2.5}`,
+			expected: []ConfigValidationError{{Key: "window:tilegapsize", Line: 1, Message: "expected an integer, got a number"}}},
+		{name: "nested", fileName: "connections.json", data: "{\n  \"user@host\": {\n    \"ssh:port\": 22\n  }\n}",
+			expected: []ConfigValidationError{{Key: "user@host.ssh:port", Line: 3, Message: "expected a string, got a number"}}},
+		{name: "sub file", fileName: "presets/ai.json", data: `{"ai@mine": {"display:name": "mine", "ai:model": "x"}}`},
+		{name: "not an object", fileName: "widgets.json", data: `[1, 2]`,
+			expected: []ConfigValidationError{{Line: 1, Message: "expected an object, got an array"}}},
+		{name: "unregistered file", fileName: "myfile.json", data: `{"anything": [1, {"a": true}]}`},
+	}
+	for _, tc := range tests {
+		verrs := ValidateConfigFile(tc.fileName, []byte(tc.data))
+		if len(verrs) != len(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, verrs)
+			continue
+		}
+		for idx, verr := range verrs {
+			if verr != tc.expected[idx] {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.expected[idx], verr)
+			}
+		}
+	}
+	verrs := ValidateConfigFile(SettingsFile, []byte("{\n  \"term:fontsize\": 12,\n}"))
+	if len(verrs) != 1 || verrs[0].Line != 3 || !strings.Contains(verrs[0].Message, "syntax error") {
+		t.Errorf("expected a syntax error on line 3, got %v", verrs)
+	}
+}
+
+func TestGetConfigFileName(t *testing.T) {
+	valid := map[string]string{"": "settings.json", "connections": "connections.json", "presets/ai": "presets/ai.json", "widgets.json": "widgets.json"}
+	for section, expected := range valid {
+		if fileName, err := GetConfigFileName(section); err != nil || fileName != expected {
+			t.Errorf("GetConfigFileName(%q): expected %q, got %q (%v)", section, expected, fileName, err)
+		}
+	}
+	for _, section := range []string{"../settings", "/etc/passwd", "presets/../../x"} {
+		if _, err := GetConfigFileName(section); err == nil {
+			t.Errorf("GetConfigFileName(%q): expected an error", section)
+		}
+	}
+}
+
+func TestResetConfigFile(t *testing.T) {
+	origConfigHome := wavebase.ConfigHome_VarCache
+	wavebase.ConfigHome_VarCache = t.TempDir()
+	defer func() {
+		wavebase.ConfigHome_VarCache = origConfigHome
+	}()
+	settingsPath := filepath.Join(wavebase.GetWaveConfigDir(), SettingsFile)
+	os.WriteFile(settingsPath, []byte(`{"term:fontsize": 20}`), 0644)
+	backupPath, err := ResetConfigFile(SettingsFile)
+	if err != nil {
+		t.Fatalf("error resetting settings: %v", err)
+	}
+	if backup, _ := os.ReadFile(backupPath); string(backup) != `{"term:fontsize": 20}` {
+		t.Errorf("expected the old settings in the backup, got %q", backup)
+	}
+	defaults, _ := fs.ReadFile(defaultconfig.ConfigFS, SettingsFile)
+	if data, _ := os.ReadFile(settingsPath); string(data) != string(defaults) {
+		t.Errorf("expected the default settings to be written")
+	}
+	if backupPath, err := ResetConfigFile(ConnectionsFile); err != nil || backupPath != "" {
+		t.Errorf("expected connections.json to be reset without a backup, got %q (%v)", backupPath, err)
+	}
+	if GetConfigFileForPath(settingsPath) != SettingsFile || GetConfigFileForPath("/tmp/settings.json") != "" {
+		t.Errorf("unexpected config file for path")
+	}
+}
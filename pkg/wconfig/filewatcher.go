@@ -10,9 +10,9 @@ import (
 	"sync"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
-	"github.com/wavetermdev/waveterm/pkg/wps"
 )
 
 var instance *Watcher
@@ -107,9 +107,9 @@ func (w *Watcher) Close() {
 
 func (w *Watcher) broadcast(message WatcherUpdate) {
 	// send to frontend
-	wps.Broker.Publish(wps.WaveEvent{
-		Event: wps.Event_Config,
-		Data:  message,
+	eventbus.SendEventToAllWindows(eventbus.WSEventType{
+		EventType: eventbus.WSEvent_Config,
+		Data:      message,
 	})
 }
 
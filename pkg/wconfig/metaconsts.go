@@ -9,12 +9,17 @@ const (
 	ConfigKey_AppClear                       = "app:*"
 	ConfigKey_AppGlobalHotkey                = "app:globalhotkey"
 	ConfigKey_AppDismissArchitectureWarning  = "app:dismissarchitecturewarning"
+	ConfigKey_AppBlockTrashRetentionHours    = "app:blocktrashretentionhours"
+	ConfigKey_AppMetricsHistoryLen           = "app:metricshistorylen"
 
 	ConfigKey_AiClear                        = "ai:*"
 	ConfigKey_AiPreset                       = "ai:preset"
+	ConfigKey_AiProvider                     = "ai:provider"
 	ConfigKey_AiApiType                      = "ai:apitype"
 	ConfigKey_AiBaseURL                      = "ai:baseurl"
 	ConfigKey_AiApiToken                     = "ai:apitoken"
+	ConfigKey_AiApiKeyRef                    = "ai:apikeyref"
+	ConfigKey_AiApiKeyBaseURLs               = "ai:apikeybaseurls"
 	ConfigKey_AiName                         = "ai:name"
 	ConfigKey_AiModel                        = "ai:model"
 	ConfigKey_AiOrgID                        = "ai:orgid"
@@ -45,6 +50,9 @@ const (
 	ConfigKey_WebOpenLinksInternally         = "web:openlinksinternally"
 	ConfigKey_WebDefaultUrl                  = "web:defaulturl"
 	ConfigKey_WebDefaultSearch               = "web:defaultsearch"
+	ConfigKey_WebAllowFileUrls               = "web:allowfileurls"
+	ConfigKey_WebAllowedDomains              = "web:alloweddomains"
+	ConfigKey_WebBlockedDomains              = "web:blockeddomains"
 
 	ConfigKey_BlockHeaderClear               = "blockheader:*"
 	ConfigKey_BlockHeaderShowBlockIds        = "blockheader:showblockids"
@@ -59,6 +67,7 @@ const (
 	ConfigKey_MarkdownFixedFontSize          = "markdown:fixedfontsize"
 
 	ConfigKey_PreviewShowHiddenFiles         = "preview:showhiddenfiles"
+	ConfigKey_PreviewPollIntervalMs          = "preview:pollintervalms"
 
 	ConfigKey_TabPreset                      = "tab:preset"
 
@@ -91,5 +100,11 @@ const (
 	ConfigKey_ConnClear                      = "conn:*"
 	ConfigKey_ConnAskBeforeWshInstall        = "conn:askbeforewshinstall"
 	ConfigKey_ConnWshEnabled                 = "conn:wshenabled"
+	ConfigKey_ConnAutoReconnect              = "conn:autoreconnect"
+
+	ConfigKey_HistoryClear                   = "history:*"
+	ConfigKey_HistoryDisabled                = "history:disabled"
+	ConfigKey_HistoryMaxRows                 = "history:maxrows"
+	ConfigKey_HistoryIgnorePatterns          = "history:ignorepatterns"
 )
 
@@ -33,23 +33,28 @@ const AnySchema = `
 `
 
 type SettingsType struct {
-	AppClear                      bool   `json:"app:*,omitempty"`
-	AppGlobalHotkey               string `json:"app:globalhotkey,omitempty"`
-	AppDismissArchitectureWarning bool   `json:"app:dismissarchitecturewarning,omitempty"`
-
-	AiClear         bool    `json:"ai:*,omitempty"`
-	AiPreset        string  `json:"ai:preset,omitempty"`
-	AiApiType       string  `json:"ai:apitype,omitempty"`
-	AiBaseURL       string  `json:"ai:baseurl,omitempty"`
-	AiApiToken      string  `json:"ai:apitoken,omitempty"`
-	AiName          string  `json:"ai:name,omitempty"`
-	AiModel         string  `json:"ai:model,omitempty"`
-	AiOrgID         string  `json:"ai:orgid,omitempty"`
-	AIApiVersion    string  `json:"ai:apiversion,omitempty"`
-	AiMaxTokens     float64 `json:"ai:maxtokens,omitempty"`
-	AiTimeoutMs     float64 `json:"ai:timeoutms,omitempty"`
-	AiFontSize      float64 `json:"ai:fontsize,omitempty"`
-	AiFixedFontSize float64 `json:"ai:fixedfontsize,omitempty"`
+	AppClear                      bool    `json:"app:*,omitempty"`
+	AppGlobalHotkey               string  `json:"app:globalhotkey,omitempty"`
+	AppDismissArchitectureWarning bool    `json:"app:dismissarchitecturewarning,omitempty"`
+	AppBlockTrashRetentionHours   float64 `json:"app:blocktrashretentionhours,omitempty"`
+	AppMetricsHistoryLen          int     `json:"app:metricshistorylen,omitempty"`
+
+	AiClear          bool     `json:"ai:*,omitempty"`
+	AiPreset         string   `json:"ai:preset,omitempty"`
+	AiProvider       string   `json:"ai:provider,omitempty"`
+	AiApiType        string   `json:"ai:apitype,omitempty"`
+	AiBaseURL        string   `json:"ai:baseurl,omitempty"`
+	AiApiToken       string   `json:"ai:apitoken,omitempty"`
+	AiApiKeyRef      string   `json:"ai:apikeyref,omitempty"`
+	AiApiKeyBaseURLs []string `json:"ai:apikeybaseurls,omitempty"` // where (besides the provider's default and local servers) an ai:apikeyref secret can be sent
+	AiName           string   `json:"ai:name,omitempty"`
+	AiModel          string   `json:"ai:model,omitempty"`
+	AiOrgID          string   `json:"ai:orgid,omitempty"`
+	AIApiVersion     string   `json:"ai:apiversion,omitempty"`
+	AiMaxTokens      float64  `json:"ai:maxtokens,omitempty"`
+	AiTimeoutMs      float64  `json:"ai:timeoutms,omitempty"`
+	AiFontSize       float64  `json:"ai:fontsize,omitempty"`
+	AiFixedFontSize  float64  `json:"ai:fixedfontsize,omitempty"`
 
 	TermClear               bool     `json:"term:*,omitempty"`
 	TermFontSize            float64  `json:"term:fontsize,omitempty"`
@@ -68,10 +73,13 @@ type SettingsType struct {
 	EditorWordWrap            bool    `json:"editor:wordwrap,omitempty"`
 	EditorFontSize            float64 `json:"editor:fontsize,omitempty"`
 
-	WebClear               bool   `json:"web:*,omitempty"`
-	WebOpenLinksInternally bool   `json:"web:openlinksinternally,omitempty"`
-	WebDefaultUrl          string `json:"web:defaulturl,omitempty"`
-	WebDefaultSearch       string `json:"web:defaultsearch,omitempty"`
+	WebClear               bool     `json:"web:*,omitempty"`
+	WebOpenLinksInternally bool     `json:"web:openlinksinternally,omitempty"`
+	WebDefaultUrl          string   `json:"web:defaulturl,omitempty"`
+	WebDefaultSearch       string   `json:"web:defaultsearch,omitempty"`
+	WebAllowFileUrls       bool     `json:"web:allowfileurls,omitempty"`
+	WebAllowedDomains      []string `json:"web:alloweddomains,omitempty"` // when set, web blocks can only open these domains (and their subdomains)
+	WebBlockedDomains      []string `json:"web:blockeddomains,omitempty"`
 
 	BlockHeaderClear        bool `json:"blockheader:*,omitempty"`
 	BlockHeaderShowBlockIds bool `json:"blockheader:showblockids,omitempty"`
@@ -85,7 +93,8 @@ type SettingsType struct {
 	MarkdownFontSize      float64 `json:"markdown:fontsize,omitempty"`
 	MarkdownFixedFontSize float64 `json:"markdown:fixedfontsize,omitempty"`
 
-	PreviewShowHiddenFiles *bool `json:"preview:showhiddenfiles,omitempty"`
+	PreviewShowHiddenFiles *bool   `json:"preview:showhiddenfiles,omitempty"`
+	PreviewPollIntervalMs  float64 `json:"preview:pollintervalms,omitempty"`
 
 	TabPreset string `json:"tab:preset,omitempty"`
 
@@ -118,6 +127,12 @@ type SettingsType struct {
 	ConnClear               bool  `json:"conn:*,omitempty"`
 	ConnAskBeforeWshInstall *bool `json:"conn:askbeforewshinstall,omitempty"`
 	ConnWshEnabled          bool  `json:"conn:wshenabled,omitempty"`
+	ConnAutoReconnect       *bool `json:"conn:autoreconnect,omitempty"`
+
+	HistoryClear          bool     `json:"history:*,omitempty"`
+	HistoryDisabled       bool     `json:"history:disabled,omitempty"`
+	HistoryMaxRows        int      `json:"history:maxrows,omitempty"`
+	HistoryIgnorePatterns []string `json:"history:ignorepatterns,omitempty"` // regexps, matched against the command line as typed
 }
 
 type ConfigError struct {
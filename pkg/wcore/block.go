@@ -7,17 +7,23 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"maps"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/filewatch"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
 )
 
@@ -28,6 +34,10 @@ func CreateSubBlock(ctx context.Context, blockId string, blockDef *waveobj.Block
 	if blockDef.Meta == nil || blockDef.Meta.GetString(waveobj.MetaKey_View, "") == "" {
 		return nil, fmt.Errorf("no view provided for new block")
 	}
+	err := normalizeWebBlockMeta(ctx, blockDef.Meta)
+	if err != nil {
+		return nil, err
+	}
 	blockData, err := createSubBlockObj(ctx, blockId, blockDef)
 	if err != nil {
 		return nil, fmt.Errorf("error creating sub block: %w", err)
@@ -47,6 +57,7 @@ func createSubBlockObj(ctx context.Context, parentBlockId string, blockDef *wave
 			ParentORef:  waveobj.MakeORef(waveobj.OType_Block, parentBlockId).String(),
 			RuntimeOpts: nil,
 			Meta:        blockDef.Meta,
+			CreatedTs:   time.Now().UnixMilli(),
 		}
 		wstore.DBInsert(tx.Context(), blockData)
 		parentBlock.SubBlockIds = append(parentBlock.SubBlockIds, blockId)
@@ -55,43 +66,31 @@ func createSubBlockObj(ctx context.Context, parentBlockId string, blockDef *wave
 	})
 }
 
-func CreateBlock(ctx context.Context, tabId string, blockDef *waveobj.BlockDef, rtOpts *waveobj.RuntimeOpts) (rtnBlock *waveobj.Block, rtnErr error) {
-	var blockCreated bool
-	var newBlockOID string
-	defer func() {
-		if rtnErr == nil {
-			return
-		}
-		// if there was an error, and we created the block, clean it up since the function failed
-		if blockCreated && newBlockOID != "" {
-			deleteBlockObj(ctx, newBlockOID)
-			filestore.WFS.DeleteZone(ctx, newBlockOID)
-		}
-	}()
+// runs in a transaction, if anything fails (including writing the block files) the block is not created
+func CreateBlock(ctx context.Context, tabId string, blockDef *waveobj.BlockDef, rtOpts *waveobj.RuntimeOpts) (*waveobj.Block, error) {
 	if blockDef == nil {
 		return nil, fmt.Errorf("blockDef is nil")
 	}
 	if blockDef.Meta == nil || blockDef.Meta.GetString(waveobj.MetaKey_View, "") == "" {
 		return nil, fmt.Errorf("no view provided for new block")
 	}
-	blockData, err := createBlockObj(ctx, tabId, blockDef, rtOpts)
+	err := normalizeWebBlockMeta(ctx, blockDef.Meta)
 	if err != nil {
-		return nil, fmt.Errorf("error creating block: %w", err)
+		return nil, err
 	}
-	blockCreated = true
-	newBlockOID = blockData.OID
-	// upload the files if present
-	if len(blockDef.Files) > 0 {
-		for fileName, fileDef := range blockDef.Files {
-			err := filestore.WFS.MakeFile(ctx, newBlockOID, fileName, fileDef.Meta, filestore.FileOptsType{})
-			if err != nil {
-				return nil, fmt.Errorf("error making blockfile %q: %w", fileName, err)
-			}
-			err = filestore.WFS.WriteFile(ctx, newBlockOID, fileName, []byte(fileDef.Content))
-			if err != nil {
-				return nil, fmt.Errorf("error writing blockfile %q: %w", fileName, err)
-			}
+	blockData, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (*waveobj.Block, error) {
+		blockData, err := createBlockObj(tx.Context(), tabId, blockDef, rtOpts)
+		if err != nil {
+			return nil, fmt.Errorf("error creating block: %w", err)
+		}
+		err = makeBlockFiles(ctx, blockData.OID, blockDef.Files)
+		if err != nil {
+			return nil, err
 		}
+		return blockData, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	go func() {
 		defer func() {
@@ -110,18 +109,83 @@ func CreateBlock(ctx context.Context, tabId string, blockDef *waveobj.BlockDef,
 	return blockData, nil
 }
 
+// filestore uses its own db (with the same txwrap context key), so it must not be called with a ctx
+// that holds a wstore transaction. returns a ctx with the same deadline but without the transaction.
+func detachTxContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(context.Background(), deadline)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// creates the block files, on error the block's filestore zone is removed
+func makeBlockFiles(ctx context.Context, blockId string, files map[string]*waveobj.FileDef) (rtnErr error) {
+	if len(files) == 0 {
+		return nil
+	}
+	fsCtx, cancelFn := detachTxContext(ctx)
+	defer cancelFn()
+	defer func() {
+		if rtnErr != nil {
+			filestore.WFS.DeleteZone(fsCtx, blockId)
+		}
+	}()
+	for fileName, fileDef := range files {
+		err := filestore.WFS.MakeFile(fsCtx, blockId, fileName, fileDef.Meta, filestore.FileOptsType{})
+		if err != nil {
+			return fmt.Errorf("error making blockfile %q: %w", fileName, err)
+		}
+		err = filestore.WFS.WriteFile(fsCtx, blockId, fileName, []byte(fileDef.Content))
+		if err != nil {
+			return fmt.Errorf("error writing blockfile %q: %w", fileName, err)
+		}
+	}
+	return nil
+}
+
+// views that run on (or read files from) a connection
+var tabConnectionViews = map[string]bool{"term": true, "preview": true, "sysinfo": true}
+
+// a new block gets the tab's default connection if its meta doesn't have a connection
+// (an empty connection is an explicit local).  the connection is copied, so changing the
+// tab's connection later doesn't change existing blocks.
+func applyTabConnection(tab *waveobj.Tab, meta waveobj.MetaMapType) {
+	tabConn := tab.Meta.GetString(waveobj.MetaKey_Connection, "")
+	if tabConn == "" {
+		return
+	}
+	if _, found := meta[waveobj.MetaKey_Connection]; found {
+		return
+	}
+	if !tabConnectionViews[meta.GetString(waveobj.MetaKey_View, "")] {
+		return
+	}
+	meta[waveobj.MetaKey_Connection] = tabConn
+}
+
+// sets the default connection for new blocks in the tab ("" or "local" clears it)
+func SetTabConnection(ctx context.Context, tabId string, conn string) error {
+	var connVal any
+	if conn != "" && conn != wshrpc.LocalConnName {
+		connVal = conn
+	}
+	return wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Tab, tabId), waveobj.MetaMapType{waveobj.MetaKey_Connection: connVal}, false)
+}
+
 func createBlockObj(ctx context.Context, tabId string, blockDef *waveobj.BlockDef, rtOpts *waveobj.RuntimeOpts) (*waveobj.Block, error) {
 	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (*waveobj.Block, error) {
 		tab, _ := wstore.DBGet[*waveobj.Tab](tx.Context(), tabId)
 		if tab == nil {
 			return nil, fmt.Errorf("tab not found: %q", tabId)
 		}
+		applyTabConnection(tab, blockDef.Meta)
 		blockId := uuid.NewString()
 		blockData := &waveobj.Block{
 			OID:         blockId,
 			ParentORef:  waveobj.MakeORef(waveobj.OType_Tab, tabId).String(),
 			RuntimeOpts: rtOpts,
 			Meta:        blockDef.Meta,
+			CreatedTs:   time.Now().UnixMilli(),
 		}
 		wstore.DBInsert(tx.Context(), blockData)
 		tab.BlockIds = append(tab.BlockIds, blockId)
@@ -130,6 +194,8 @@ func createBlockObj(ctx context.Context, tabId string, blockDef *waveobj.BlockDe
 	})
 }
 
+// Blocks in a tab are soft deleted (moved to the trash, see RestoreBlock and SweepDeletedBlocks),
+// subblocks and blocks without a parent tab are deleted permanently (see PurgeBlock).
 // Must delete all blocks individually first.
 // Also deletes LayoutState.
 // recursive: if true, will recursively close parent tab, window, workspace, if they are empty.
@@ -139,25 +205,20 @@ func DeleteBlock(ctx context.Context, blockId string, recursive bool) error {
 	if err != nil {
 		return fmt.Errorf("error getting block: %w", err)
 	}
-	if block == nil {
+	if block == nil || block.DeletedTs != 0 {
 		return nil
 	}
-	if len(block.SubBlockIds) > 0 {
-		for _, subBlockId := range block.SubBlockIds {
-			err := DeleteBlock(ctx, subBlockId, recursive)
-			if err != nil {
-				return fmt.Errorf("error deleting subblock %s: %w", subBlockId, err)
-			}
-		}
+	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+	if parentORef == nil || parentORef.OType != waveobj.OType_Tab {
+		return PurgeBlock(ctx, blockId)
 	}
-	parentBlockCount, err := deleteBlockObj(ctx, blockId)
+	parentBlockCount, err := softDeleteBlockObj(ctx, blockId)
 	if err != nil {
 		return fmt.Errorf("error deleting block: %w", err)
 	}
 	log.Printf("DeleteBlock: parentBlockCount: %d", parentBlockCount)
-	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
 
-	if recursive && parentORef.OType == waveobj.OType_Tab && parentBlockCount == 0 {
+	if recursive && parentBlockCount == 0 {
 		// if parent tab has no blocks, delete the tab
 		log.Printf("DeleteBlock: parent tab has no blocks, deleting tab %s", parentORef.OID)
 		parentWorkspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, parentORef.OID)
@@ -170,11 +231,445 @@ func DeleteBlock(ctx context.Context, blockId string, recursive bool) error {
 		}
 		SendActiveTabUpdate(ctx, parentWorkspaceId, newActiveTabId)
 	}
+	// the controllers are stopped, but the block files (scrollback) are kept until the block is purged
+	for _, subBlockId := range block.SubBlockIds {
+		go blockcontroller.StopBlockController(subBlockId)
+	}
 	go blockcontroller.StopBlockController(blockId)
+	filewatch.GetPreviewManager().Unwatch(blockId)
 	sendBlockCloseEvent(blockId)
 	return nil
 }
 
+// soft deletes blocks (which must be in tabs) and removes them from their tab layouts in one transaction, if one of
+// them can't be deleted none are.  unlike DeleteBlock, tabs that end up empty are kept open.  returns the ids of the deleted blocks (blocks that are already
+// in the trash are skipped).
+func DeleteBlocks(ctx context.Context, blockIds []string) ([]string, error) {
+	var deleted []*waveobj.Block
+	err := wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		for _, blockId := range blockIds {
+			block, err := wstore.DBMustGet[*waveobj.Block](tx.Context(), blockId)
+			if err != nil {
+				return fmt.Errorf("error getting block %s: %w", blockId, err)
+			}
+			if block.DeletedTs != 0 {
+				continue
+			}
+			parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+			if parentORef == nil || parentORef.OType != waveobj.OType_Tab {
+				return fmt.Errorf("block %s is not in a tab", blockId)
+			}
+			_, err = softDeleteBlockObj(tx.Context(), blockId)
+			if err != nil {
+				return fmt.Errorf("error deleting block %s: %w", blockId, err)
+			}
+			err = QueueLayoutActionForTab(tx.Context(), parentORef.OID, waveobj.LayoutActionData{
+				ActionType: LayoutActionDataType_Remove,
+				BlockId:    blockId,
+			})
+			if err != nil {
+				return fmt.Errorf("error removing block %s from the layout: %w", blockId, err)
+			}
+			deleted = append(deleted, block)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]string, 0, len(deleted))
+	for _, block := range deleted {
+		for _, subBlockId := range block.SubBlockIds {
+			go blockcontroller.StopBlockController(subBlockId)
+		}
+		go blockcontroller.StopBlockController(block.OID)
+		filewatch.GetPreviewManager().Unwatch(block.OID)
+		sendBlockCloseEvent(block.OID)
+		rtn = append(rtn, block.OID)
+	}
+	return rtn, nil
+}
+
+// permanently deletes a block (and its subblocks), including its files.
+// does not close the parent tab if it ends up empty.
+func PurgeBlock(ctx context.Context, blockId string) error {
+	purged, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]*waveobj.Block, error) {
+		return purgeBlockObjs(tx.Context(), blockId)
+	})
+	if err != nil {
+		return err
+	}
+	finishPurgedBlocks(purged)
+	return nil
+}
+
+// deletes the block objects (subblocks first) and returns them.  the caller runs finishPurgedBlocks once the
+// transaction is committed.
+func purgeBlockObjs(ctx context.Context, blockId string) ([]*waveobj.Block, error) {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block: %w", err)
+	}
+	var purged []*waveobj.Block
+	for _, subBlockId := range block.SubBlockIds {
+		subPurged, err := purgeBlockObjs(ctx, subBlockId)
+		if err != nil {
+			return nil, fmt.Errorf("error deleting subblock %s: %w", subBlockId, err)
+		}
+		purged = append(purged, subPurged...)
+	}
+	_, err = deleteBlockObj(ctx, blockId)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting block: %w", err)
+	}
+	return append(purged, block), nil
+}
+
+// stops the controllers of purged blocks and sends their close events (blocks in the trash were already closed)
+func finishPurgedBlocks(blocks []*waveobj.Block) {
+	for _, block := range blocks {
+		if block.DeletedTs == 0 {
+			go blockcontroller.StopBlockController(block.OID)
+			filewatch.GetPreviewManager().Unwatch(block.OID)
+			sendBlockCloseEvent(block.OID)
+		}
+		if block.Meta.GetBool(waveobj.MetaKey_FileTemp, false) {
+			go removeBlockTempFile(block)
+		}
+	}
+}
+
+// returns the updated block count for the parent tab
+func softDeleteBlockObj(ctx context.Context, blockId string) (int, error) {
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (int, error) {
+		block, err := wstore.DBMustGet[*waveobj.Block](tx.Context(), blockId)
+		if err != nil {
+			return -1, fmt.Errorf("error getting block: %w", err)
+		}
+		parentBlockCount := -1
+		parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+		if parentORef != nil && parentORef.OType == waveobj.OType_Tab {
+			tab, _ := wstore.DBGet[*waveobj.Tab](tx.Context(), parentORef.OID)
+			if tab != nil {
+				tab.BlockIds = utilfn.RemoveElemFromSlice(tab.BlockIds, blockId)
+				wstore.DBUpdate(tx.Context(), tab)
+				parentBlockCount = len(tab.BlockIds)
+			}
+		}
+		// keeps the parentoref, so the block can be restored to its original tab
+		block.DeletedTs = time.Now().UnixMilli()
+		wstore.DBUpdate(tx.Context(), block)
+		err = deleteBlockLinks(tx.Context(), blockId)
+		if err != nil {
+			return -1, err
+		}
+		return parentBlockCount, nil
+	})
+}
+
+// moves a soft deleted block out of the trash and back into a tab (tabId defaults to its original tab).
+// queues a layout insert for the tab.
+func RestoreBlock(ctx context.Context, blockId string, tabId string) error {
+	err := wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		block, err := wstore.DBMustGet[*waveobj.Block](tx.Context(), blockId)
+		if err != nil {
+			return fmt.Errorf("error getting block: %w", err)
+		}
+		if block.DeletedTs == 0 {
+			return fmt.Errorf("block %s is not deleted", blockId)
+		}
+		if tabId == "" {
+			parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+			if parentORef == nil || parentORef.OType != waveobj.OType_Tab {
+				return fmt.Errorf("block %s has no parent tab, must specify a tab", blockId)
+			}
+			tabId = parentORef.OID
+		}
+		tab, err := wstore.DBGet[*waveobj.Tab](tx.Context(), tabId)
+		if err != nil {
+			return fmt.Errorf("error getting tab: %w", err)
+		}
+		if tab == nil {
+			return fmt.Errorf("tab not found: %q", tabId)
+		}
+		block.ParentORef = waveobj.MakeORef(waveobj.OType_Tab, tabId).String()
+		block.DeletedTs = 0
+		wstore.DBUpdate(tx.Context(), block)
+		if !utilfn.ContainsStr(tab.BlockIds, blockId) {
+			tab.BlockIds = append(tab.BlockIds, blockId)
+			wstore.DBUpdate(tx.Context(), tab)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
+		ActionType: LayoutActionDataType_Insert,
+		BlockId:    blockId,
+		Focused:    true,
+	})
+}
+
+// returns the blocks that are in the trash (soft deleted)
+func GetDeletedBlocks(ctx context.Context) ([]*waveobj.Block, error) {
+	blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+	if err != nil {
+		return nil, err
+	}
+	var rtn []*waveobj.Block
+	for _, block := range blocks {
+		if block.DeletedTs != 0 {
+			rtn = append(rtn, block)
+		}
+	}
+	return rtn, nil
+}
+
+const DefaultBlockTrashRetention = 24 * time.Hour
+
+// returns app:blocktrashretentionhours (or the default of 24h if it is not set)
+func GetBlockTrashRetention() time.Duration {
+	hours := wconfig.GetWatcher().GetFullConfig().Settings.AppBlockTrashRetentionHours
+	if hours <= 0 {
+		return DefaultBlockTrashRetention
+	}
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// permanently deletes blocks that have been in the trash for longer than retention.
+// returns the number of purged blocks.
+func SweepDeletedBlocks(ctx context.Context, retention time.Duration) (int, error) {
+	blocks, err := GetDeletedBlocks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error getting deleted blocks: %w", err)
+	}
+	cutoff := time.Now().Add(-retention).UnixMilli()
+	numPurged := 0
+	for _, block := range blocks {
+		if block.DeletedTs > cutoff {
+			continue
+		}
+		err := PurgeBlock(ctx, block.OID)
+		if err != nil {
+			return numPurged, fmt.Errorf("error purging block %s: %w", block.OID, err)
+		}
+		numPurged++
+	}
+	return numPurged, nil
+}
+
+// moves a block from its tab to destTabId (which can be in another window), queueing a remove for the source
+// layout and an insert for the destination layout (at indexArr if given). the block controller keeps running.
+// moving a block to the tab it is already in does nothing.
+func MoveBlock(ctx context.Context, blockId string, destTabId string, indexArr []int) error {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error getting block: %w", err)
+	}
+	if block.DeletedTs != 0 {
+		return fmt.Errorf("block %s is deleted", blockId)
+	}
+	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+	if parentORef == nil || parentORef.OType != waveobj.OType_Tab {
+		return fmt.Errorf("block %s is not in a tab", blockId)
+	}
+	srcTabId := parentORef.OID
+	if srcTabId == destTabId {
+		return nil
+	}
+	err = wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		err := wstore.MoveBlockToTab(tx.Context(), srcTabId, destTabId, blockId)
+		if err != nil {
+			return err
+		}
+		err = QueueLayoutActionForTab(tx.Context(), srcTabId, waveobj.LayoutActionData{
+			ActionType: LayoutActionDataType_Remove,
+			BlockId:    blockId,
+		})
+		if err != nil {
+			return err
+		}
+		insertAction := waveobj.LayoutActionData{
+			ActionType: LayoutActionDataType_Insert,
+			BlockId:    blockId,
+			Focused:    true,
+		}
+		if len(indexArr) > 0 {
+			insertAction.ActionType = LayoutActionDataType_InsertAtIndex
+			insertAction.IndexArr = &indexArr
+		}
+		return QueueLayoutActionForTab(tx.Context(), destTabId, insertAction)
+	})
+	if err != nil {
+		return fmt.Errorf("error moving block %s to tab %s: %w", blockId, destTabId, err)
+	}
+	blockcontroller.SetBlockControllerTabId(blockId, destTabId)
+	return nil
+}
+
+// merges the patch into the block's meta if the block is still at expectedVersion (see wstore.UpdateBlockMeta).
+// the changed keys are sent as a blockmetaupdate event (to listeners and to the block's tab) so views can update
+// just what changed. returns the new version.
+func UpdateBlockMeta(ctx context.Context, blockId string, patch waveobj.MetaMapType, expectedVersion int) (int, error) {
+	changes, newVersion, err := wstore.UpdateBlockMeta(ctx, blockId, patch, expectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	if len(changes) == 0 {
+		return newVersion, nil
+	}
+	event := eventbus.WSEventType{
+		EventType: eventbus.WSEvent_BlockMetaUpdate,
+		ORef:      waveobj.MakeORef(waveobj.OType_Block, blockId).String(),
+		Data:      waveobj.BlockMetaUpdateData{BlockId: blockId, Version: newVersion, Meta: changes},
+	}
+	var parentORef *waveobj.ORef
+	block, _ := wstore.DBGet[*waveobj.Block](ctx, blockId)
+	if block != nil && block.ParentORef != "" {
+		event.Scopes = []string{block.ParentORef}
+		parentORef = waveobj.ParseORefNoErr(block.ParentORef)
+	}
+	eventbus.SendEventToListeners(event)
+	if parentORef != nil && parentORef.OType == waveobj.OType_Tab && eventbus.IsWindowConnected(parentORef.OID) {
+		eventbus.SendEventToTab(parentORef.OID, event)
+	}
+	return newVersion, nil
+}
+
+func IsBlockPinned(ctx context.Context, blockId string) bool {
+	block, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+	if err != nil || block == nil {
+		return false
+	}
+	return block.Meta.GetBool(waveobj.MetaKey_Pinned, false)
+}
+
+// pinned is stored in the block meta (so it is kept in snapshots and layout presets)
+func SetBlockPinned(ctx context.Context, blockId string, pinned bool) error {
+	var pinnedVal any
+	if pinned {
+		pinnedVal = true
+	}
+	return wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Block, blockId), waveobj.MetaMapType{waveobj.MetaKey_Pinned: pinnedVal}, false)
+}
+
+// creates a new block in the same tab with a copy of the block's meta and runtime opts (metaOverrides are merged in),
+// inserted next to the original block in the layout. the controller state and block files are not copied.
+func DuplicateBlock(ctx context.Context, blockId string, metaOverrides waveobj.MetaMapType) (*waveobj.Block, error) {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block: %w", err)
+	}
+	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+	if block.DeletedTs != 0 || parentORef == nil || parentORef.OType != waveobj.OType_Tab {
+		return nil, fmt.Errorf("block %s is not in a tab", blockId)
+	}
+	tabId := parentORef.OID
+	var blockDef waveobj.BlockDef
+	err = utilfn.ReUnmarshal(&blockDef.Meta, block.Meta)
+	if err != nil {
+		return nil, fmt.Errorf("error copying block meta: %w", err)
+	}
+	// the original block owns the temp file (it is removed when the original is deleted)
+	delete(blockDef.Meta, waveobj.MetaKey_FileTemp)
+	// the new block is not magnified
+	delete(blockDef.Meta, waveobj.MetaKey_Magnified)
+	if block.Meta.GetBool(waveobj.MetaKey_WebRendered, false) {
+		// a copy of a "wsh render --html" block (web:rendered can't be in the overrides, they come from a client)
+		ctx = ContextWithWebRender(ctx)
+		metaOverrides = maps.Clone(metaOverrides)
+		StripClientWebMeta(metaOverrides)
+	}
+	blockDef.Meta = waveobj.MergeMeta(blockDef.Meta, metaOverrides, false)
+	rtOpts := &waveobj.RuntimeOpts{}
+	if block.RuntimeOpts != nil {
+		*rtOpts = *block.RuntimeOpts
+	}
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (*waveobj.Block, error) {
+		newBlock, err := CreateBlock(tx.Context(), tabId, &blockDef, rtOpts)
+		if err != nil {
+			return nil, err
+		}
+		insertAction := waveobj.LayoutActionData{
+			ActionType: LayoutActionDataType_Insert,
+			BlockId:    newBlock.OID,
+			Focused:    true,
+		}
+		if indexArr := findBlockIndexArrForTab(tx.Context(), tabId, blockId); indexArr != nil {
+			// insertatindex inserts after the node at indexArr
+			insertAction.ActionType = LayoutActionDataType_InsertAtIndex
+			insertAction.IndexArr = &indexArr
+		}
+		err = QueueLayoutActionForTab(tx.Context(), tabId, insertAction)
+		if err != nil {
+			return nil, err
+		}
+		return newBlock, nil
+	})
+}
+
+// returns the position of the block's node in the tab's layout tree (nil if it is not in the layout)
+func findBlockIndexArrForTab(ctx context.Context, tabId string, blockId string) []int {
+	layoutStateId, err := GetLayoutIdForTab(ctx, tabId)
+	if err != nil {
+		return nil
+	}
+	layoutState, err := wstore.DBGet[*waveobj.LayoutState](ctx, layoutStateId)
+	if err != nil || layoutState == nil {
+		return nil
+	}
+	indexArr, found := findLayoutNodePath(layoutState.RootNode, blockId)
+	if !found {
+		return nil
+	}
+	if len(indexArr) == 0 {
+		// the block is the root node
+		return []int{0}
+	}
+	return indexArr
+}
+
+func findLayoutNodePath(node any, blockId string) ([]int, bool) {
+	nodeMap, ok := node.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	if data, ok := nodeMap["data"].(map[string]any); ok && data["blockId"] == blockId {
+		return []int{}, true
+	}
+	children, _ := nodeMap["children"].([]any)
+	for idx, child := range children {
+		if path, found := findLayoutNodePath(child, blockId); found {
+			return append([]int{idx}, path...), true
+		}
+	}
+	return nil, false
+}
+
+// removes the temp file backing a block (created by "wsh view -")
+func removeBlockTempFile(block *waveobj.Block) {
+	defer func() {
+		panichandler.PanicHandler("removeBlockTempFile", recover())
+	}()
+	filePath := block.Meta.GetString(waveobj.MetaKey_File, "")
+	if filePath == "" {
+		return
+	}
+	connName := block.Meta.GetString(waveobj.MetaKey_Connection, "")
+	if connName == "" {
+		connName = wshrpc.LocalConnName
+	}
+	// meta can be set by anyone, the connection only removes files that wsh created in its temp dir
+	err := wshclient.RemoteRemoveTempFileCommand(wshclient.GetBareRpcClient(), filePath, &wshrpc.RpcOpts{
+		Route:   wshutil.MakeConnectionRouteId(connName),
+		Timeout: 2000,
+	})
+	if err != nil {
+		log.Printf("error removing temp file for block %s: %v\n", block.OID, err)
+	}
+}
+
 // returns the updated block count for the parent object
 func deleteBlockObj(ctx context.Context, blockId string) (int, error) {
 	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (int, error) {
@@ -208,6 +703,14 @@ func deleteBlockObj(ctx context.Context, blockId string) (int, error) {
 			}
 		}
 		wstore.DBDelete(tx.Context(), waveobj.OType_Block, blockId)
+		err = deleteBlockLinks(tx.Context(), blockId)
+		if err != nil {
+			return -1, err
+		}
+		err = blockcontroller.DeleteCmdHistory(tx.Context(), blockId)
+		if err != nil {
+			return -1, err
+		}
 		return parentBlockCount, nil
 	})
 }
@@ -0,0 +1,36 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestApplyTabConnection(t *testing.T) {
+	const tabConn = "user@remotehost"
+	tests := []struct {
+		name     string
+		tabMeta  waveobj.MetaMapType
+		meta     waveobj.MetaMapType
+		expected any
+		found    bool
+	}{
+		{name: "inherits", tabMeta: waveobj.MetaMapType{waveobj.MetaKey_Connection: tabConn}, meta: waveobj.MetaMapType{waveobj.MetaKey_View: "term"}, expected: tabConn, found: true},
+		{name: "explicit conn", tabMeta: waveobj.MetaMapType{waveobj.MetaKey_Connection: tabConn}, meta: waveobj.MetaMapType{waveobj.MetaKey_View: "preview", waveobj.MetaKey_Connection: "other"}, expected: "other", found: true},
+		{name: "explicit local", tabMeta: waveobj.MetaMapType{waveobj.MetaKey_Connection: tabConn}, meta: waveobj.MetaMapType{waveobj.MetaKey_View: "term", waveobj.MetaKey_Connection: ""}, expected: "", found: true},
+		{name: "non-connection view", tabMeta: waveobj.MetaMapType{waveobj.MetaKey_Connection: tabConn}, meta: waveobj.MetaMapType{waveobj.MetaKey_View: "web"}},
+		{name: "no tab conn", tabMeta: waveobj.MetaMapType{}, meta: waveobj.MetaMapType{waveobj.MetaKey_View: "term"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			applyTabConnection(&waveobj.Tab{Meta: tc.tabMeta}, tc.meta)
+			conn, found := tc.meta[waveobj.MetaKey_Connection]
+			if found != tc.found || conn != tc.expected {
+				t.Errorf("expected connection %v (found %v), got %v (found %v)", tc.expected, tc.found, conn, found)
+			}
+		})
+	}
+}
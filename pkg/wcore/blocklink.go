@@ -0,0 +1,162 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the views that can be the target of a block link (and the meta key that is set by the link)
+var blockLinkTargetKeys = map[string]string{
+	"preview": waveobj.MetaKey_File,
+	"web":     waveobj.MetaKey_Url,
+}
+
+func GetBlockLinkTargetKey(view string) string {
+	return blockLinkTargetKeys[view]
+}
+
+func validateBlockLinkRule(ruleType string, rule string) error {
+	if ruleType != waveobj.BlockLinkRule_Regex {
+		return fmt.Errorf("invalid link rule type %q", ruleType)
+	}
+	if rule == "" {
+		return fmt.Errorf("link regex cannot be empty")
+	}
+	_, err := regexp.Compile(rule)
+	if err != nil {
+		return fmt.Errorf("invalid link regex: %w", err)
+	}
+	return nil
+}
+
+func getLinkableBlock(ctx context.Context, blockId string) (*waveobj.Block, error) {
+	block, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block %s: %w", blockId, err)
+	}
+	if block == nil || block.DeletedTs != 0 {
+		return nil, fmt.Errorf("block %s not found", blockId)
+	}
+	return block, nil
+}
+
+// links the output of srcBlockId (a terminal) to dstBlockId (a preview or web block).  linking the same blocks with
+// the same rule again returns the existing link.
+func LinkBlocks(ctx context.Context, srcBlockId string, dstBlockId string, ruleType string, rule string) (*waveobj.BlockLink, error) {
+	if srcBlockId == "" || dstBlockId == "" {
+		return nil, fmt.Errorf("source and target blocks are required")
+	}
+	// the target's own output must never drive itself
+	if srcBlockId == dstBlockId {
+		return nil, fmt.Errorf("cannot link a block to itself")
+	}
+	err := validateBlockLinkRule(ruleType, rule)
+	if err != nil {
+		return nil, err
+	}
+	link, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (*waveobj.BlockLink, error) {
+		srcBlock, err := getLinkableBlock(tx.Context(), srcBlockId)
+		if err != nil {
+			return nil, err
+		}
+		if srcBlock.Meta.GetString(waveobj.MetaKey_View, "") != "term" {
+			return nil, fmt.Errorf("block %s is not a terminal (only terminal output can be linked)", srcBlockId)
+		}
+		dstBlock, err := getLinkableBlock(tx.Context(), dstBlockId)
+		if err != nil {
+			return nil, err
+		}
+		if dstView := dstBlock.Meta.GetString(waveobj.MetaKey_View, ""); GetBlockLinkTargetKey(dstView) == "" {
+			return nil, fmt.Errorf("block %s is a %q block (links can only target preview and web blocks)", dstBlockId, dstView)
+		}
+		links, err := ListBlockLinks(tx.Context(), srcBlockId)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			if link.SrcBlockId == srcBlockId && link.DstBlockId == dstBlockId && link.RuleType == ruleType && link.Rule == rule {
+				return link, nil
+			}
+		}
+		link := &waveobj.BlockLink{
+			OID:        uuid.NewString(),
+			SrcBlockId: srcBlockId,
+			DstBlockId: dstBlockId,
+			RuleType:   ruleType,
+			Rule:       rule,
+			CreatedTs:  time.Now().UnixMilli(),
+			Meta:       waveobj.MetaMapType{},
+		}
+		return link, wstore.DBInsert(tx.Context(), link)
+	})
+	if err != nil {
+		return nil, err
+	}
+	blockcontroller.InvalidateBlockLinks()
+	return link, nil
+}
+
+func UnlinkBlocks(ctx context.Context, linkId string) error {
+	err := wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		link, err := wstore.DBGet[*waveobj.BlockLink](tx.Context(), linkId)
+		if err != nil {
+			return err
+		}
+		if link == nil {
+			return fmt.Errorf("block link %s not found", linkId)
+		}
+		return wstore.DBDelete(tx.Context(), waveobj.OType_BlockLink, linkId)
+	})
+	if err != nil {
+		return err
+	}
+	blockcontroller.InvalidateBlockLinks()
+	return nil
+}
+
+// returns the links from or to blockId (all links if blockId is ""), oldest first
+func ListBlockLinks(ctx context.Context, blockId string) ([]*waveobj.BlockLink, error) {
+	links, err := wstore.DBGetAllObjsByType[*waveobj.BlockLink](ctx, waveobj.OType_BlockLink)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block links: %w", err)
+	}
+	var rtn []*waveobj.BlockLink
+	for _, link := range links {
+		if blockId == "" || link.SrcBlockId == blockId || link.DstBlockId == blockId {
+			rtn = append(rtn, link)
+		}
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].CreatedTs < rtn[j].CreatedTs
+	})
+	return rtn, nil
+}
+
+// called when a block is deleted (the block controller reloads its links, see blockcontroller.InvalidateBlockLinks)
+func deleteBlockLinks(ctx context.Context, blockId string) error {
+	links, err := ListBlockLinks(ctx, blockId)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		err = wstore.DBDelete(ctx, waveobj.OType_BlockLink, link.OID)
+		if err != nil {
+			return fmt.Errorf("error deleting block link %s: %w", link.OID, err)
+		}
+	}
+	if len(links) > 0 {
+		blockcontroller.InvalidateBlockLinks()
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// client meta keys with this prefix are not validated
+const ClientUserMetaPrefix = "user:"
+
+// the known client meta keys (used to validate UpdateClientMeta)
+var ClientMetaDecls = []waveobj.MetaDataDecl{
+	{Key: waveobj.MetaKey_ClientDbTimeoutMs, Type: "float", Desc: "timeout for client db calls (ms)", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientTheme, Type: "string", Desc: "terminal theme (a name from termthemes)", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientDefaultShell, Type: "string", Desc: "shell for local terminals", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientDefaultView, Type: "string", Desc: "default view for new blocks", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientTelemetryOptOut, Type: "bool", Desc: "turn off telemetry", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientScrollbackBytes, Type: "int", Desc: "max bytes of terminal output kept per block", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientScrollbackLines, Type: "int", Desc: "max lines of terminal output kept per block", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientOnExit, Type: "string", Desc: "command run when a block's controller exits (default for cmd:onexit)", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientOnFail, Type: "string", Desc: "command run when a block's controller exits with an error (default for cmd:onfail)", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientAIMaxMessages, Type: "int", Desc: "max messages kept per ai conversation", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientAIRetentionDays, Type: "int", Desc: "days an ai conversation is kept after its last message", Entity: []string{"client"}},
+	{Key: waveobj.MetaKey_ClientLogLevels, Type: "object", Desc: "backend log level per subsystem (\"*\" for the default)", Entity: []string{"client"}},
+}
+
+func getClientMetaDecl(key string) *waveobj.MetaDataDecl {
+	for idx := range ClientMetaDecls {
+		if ClientMetaDecls[idx].Key == key {
+			return &ClientMetaDecls[idx]
+		}
+	}
+	return nil
+}
+
+func isMetaNumber(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// checks that every key in meta is a known client meta key (or starts with "user:") with a value of the right type.
+// nil values are allowed (they remove the key).
+func ValidateClientMeta(meta waveobj.MetaMapType) error {
+	for key, val := range meta {
+		if strings.HasPrefix(key, ClientUserMetaPrefix) {
+			if len(key) == len(ClientUserMetaPrefix) {
+				return fmt.Errorf("invalid client meta key %q", key)
+			}
+			continue
+		}
+		decl := getClientMetaDecl(key)
+		if decl == nil {
+			return fmt.Errorf("unknown client meta key %q (custom keys must start with %q)", key, ClientUserMetaPrefix)
+		}
+		if val == nil {
+			continue
+		}
+		typeOk := false
+		switch decl.Type {
+		case "string":
+			_, typeOk = val.(string)
+		case "bool":
+			_, typeOk = val.(bool)
+		case "float":
+			_, typeOk = isMetaNumber(val)
+		case "int":
+			fval, isNum := isMetaNumber(val)
+			typeOk = isNum && fval == math.Trunc(fval)
+		case "object":
+			_, typeOk = val.(map[string]any)
+			if _, isStrMap := val.(map[string]string); isStrMap {
+				typeOk = true
+			}
+		}
+		if !typeOk {
+			return fmt.Errorf("invalid value for client meta key %q (expected %s, got %T)", key, decl.Type, val)
+		}
+		var err error
+		switch key {
+		case waveobj.MetaKey_ClientLogLevels:
+			err = validateClientLogLevels(val)
+		case waveobj.MetaKey_ClientTheme:
+			if _, ok := wconfig.GetWatcher().GetFullConfig().TermThemes[val.(string)]; !ok {
+				err = fmt.Errorf("unknown theme %q (see termthemes)", val)
+			}
+		case waveobj.MetaKey_ClientDefaultView:
+			if !starterLayoutViews[val.(string)] {
+				err = fmt.Errorf("unknown view %q", val)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for client meta key %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// every subsystem (or "*") must map to a level that wlog.ParseLevel accepts
+func validateClientLogLevels(val any) error {
+	levelMap := make(map[string]any)
+	switch v := val.(type) {
+	case map[string]any:
+		levelMap = v
+	case map[string]string:
+		for subsystem, level := range v {
+			levelMap[subsystem] = level
+		}
+	}
+	for subsystem, level := range levelMap {
+		if subsystem == "" {
+			return fmt.Errorf("empty subsystem")
+		}
+		levelStr, ok := level.(string)
+		if !ok {
+			return fmt.Errorf("level for %q must be a string (got %T)", subsystem, level)
+		}
+		_, err := wlog.ParseLevel(levelStr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", subsystem, err)
+		}
+	}
+	return nil
+}
+
+// validates and merges meta into the client meta, sends a clientupdate event (with the updated meta) to all the windows
+func UpdateClientMeta(ctx context.Context, meta waveobj.MetaMapType) error {
+	err := ValidateClientMeta(meta)
+	if err != nil {
+		return err
+	}
+	client, err := GetClientData(ctx)
+	if err != nil {
+		return err
+	}
+	err = wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Client, client.OID), meta, false)
+	if err != nil {
+		return fmt.Errorf("error updating client meta: %w", err)
+	}
+	client, err = GetClientData(ctx) // also reloads the client timeout
+	if err != nil {
+		return err
+	}
+	eventbus.SendEventToAllWindows(eventbus.WSEventType{
+		EventType: eventbus.WSEvent_ClientUpdate,
+		ORef:      waveobj.MakeORef(waveobj.OType_Client, client.OID).String(),
+		Data:      client.Meta,
+	})
+	return nil
+}
+
+// returns the value of the client meta key (nil if it is not set)
+func GetClientSetting(ctx context.Context, key string) (any, error) {
+	client, err := GetClientData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Meta[key], nil
+}
+
+func GetClientSettingString(ctx context.Context, key string, def string) (string, error) {
+	client, err := GetClientData(ctx)
+	if err != nil {
+		return def, err
+	}
+	return client.Meta.GetString(key, def), nil
+}
+
+func GetClientSettingBool(ctx context.Context, key string, def bool) (bool, error) {
+	client, err := GetClientData(ctx)
+	if err != nil {
+		return def, err
+	}
+	return client.Meta.GetBool(key, def), nil
+}
+
+func GetClientSettingInt(ctx context.Context, key string, def int) (int, error) {
+	client, err := GetClientData(ctx)
+	if err != nil {
+		return def, err
+	}
+	return client.Meta.GetInt(key, def), nil
+}
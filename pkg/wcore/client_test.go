@@ -0,0 +1,36 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestValidateClientMeta(t *testing.T) {
+	tests := []struct {
+		name  string
+		meta  waveobj.MetaMapType
+		valid bool
+	}{
+		{name: "onexit", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientOnExit: "notify-send done", waveobj.MetaKey_ClientOnFail: nil}, valid: true},
+		{name: "onfail not a string", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientOnFail: true}, valid: false},
+		{name: "loglevels", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientLogLevels: map[string]any{"*": "warn", "wstore": "debug"}}, valid: true},
+		{name: "loglevels bad level", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientLogLevels: map[string]any{"wstore": "loud"}}, valid: false},
+		{name: "loglevels level not a string", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientLogLevels: map[string]any{"wstore": 1.0}}, valid: false},
+		{name: "loglevels not a map", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientLogLevels: "debug"}, valid: false},
+		{name: "defaultview", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientDefaultView: "preview"}, valid: true},
+		{name: "defaultview unknown view", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientDefaultView: "spreadsheet"}, valid: false},
+		{name: "theme not a string", meta: waveobj.MetaMapType{waveobj.MetaKey_ClientTheme: 1.0}, valid: false},
+		{name: "user key", meta: waveobj.MetaMapType{"user:foo": 5.0}, valid: true},
+		{name: "unknown key", meta: waveobj.MetaMapType{"client:foo": 5.0}, valid: false},
+	}
+	for _, tc := range tests {
+		err := ValidateClientMeta(tc.meta)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: expected valid=%v, got err %v", tc.name, tc.valid, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/util/shellutil"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+var ErrNotTermBlock = fmt.Errorf("not a terminal block")
+
+func getFocusedBlockIdForTab(ctx context.Context, tab *waveobj.Tab) string {
+	layout, _ := wstore.DBGet[*waveobj.LayoutState](ctx, tab.LayoutState)
+	if layout == nil || layout.FocusedNodeId == "" || layout.LeafOrder == nil {
+		return ""
+	}
+	for _, leaf := range *layout.LeafOrder {
+		if leaf.NodeId == layout.FocusedNodeId && slices.Contains(tab.BlockIds, leaf.BlockId) {
+			return leaf.BlockId
+		}
+	}
+	return ""
+}
+
+// returns the block that has the focus in the tab's layout ("" if no block is focused)
+func GetFocusedBlockId(ctx context.Context, tabId string) (string, error) {
+	tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return "", fmt.Errorf("error getting tab: %w", err)
+	}
+	return getFocusedBlockIdForTab(ctx, tab), nil
+}
+
+// types path into a terminal block (quoted for the block's shell, see controller:shelltype) without a newline, so
+// the user can keep editing the command.  blockId defaults to the focused block of tabId.  returns ErrNotTermBlock
+// (wrapped) if the block isn't a terminal, and the id of the block that the path was sent to.
+func InjectPath(ctx context.Context, tabId string, blockId string, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("no path to inject")
+	}
+	// a newline would run the command
+	if strings.ContainsFunc(path, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return "", fmt.Errorf("path %q has control characters", path)
+	}
+	if blockId == "" {
+		focusedBlockId, err := GetFocusedBlockId(ctx, tabId)
+		if err != nil {
+			return "", err
+		}
+		if focusedBlockId == "" {
+			return "", fmt.Errorf("no block is focused in tab %s: %w", tabId, ErrNotTermBlock)
+		}
+		blockId = focusedBlockId
+	}
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return "", fmt.Errorf("error getting block: %w", err)
+	}
+	if view := block.Meta.GetString(waveobj.MetaKey_View, ""); view != "term" {
+		return "", fmt.Errorf("block %s is a %q block: %w", blockId, view, ErrNotTermBlock)
+	}
+	shellType := block.Meta.GetString(waveobj.MetaKey_ControllerShellType, "")
+	err = blockcontroller.SendTermInput(blockId, []byte(shellutil.QuoteShellArg(shellType, path)))
+	if err != nil {
+		return "", fmt.Errorf("error sending path to block %s: %w", blockId, err)
+	}
+	return blockId, nil
+}
@@ -5,11 +5,20 @@ package wcore
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
 )
 
@@ -18,15 +27,33 @@ const (
 	LayoutActionDataType_InsertAtIndex = "insertatindex"
 	LayoutActionDataType_Remove        = "delete"
 	LayoutActionDataType_ClearTree     = "clear"
+	LayoutActionDataType_Focus         = "focus"
+	LayoutActionDataType_Split         = "split"
+	LayoutActionDataType_Resize        = "resize"
+	LayoutActionDataType_Swap          = "swap"
+	LayoutActionDataType_Magnify       = "magnify" // Magnified is the new state
 )
 
-type PortableLayout []struct {
-	IndexArr []int             `json:"indexarr"`
-	Size     *uint             `json:"size,omitempty"`
-	BlockDef *waveobj.BlockDef `json:"blockdef"`
-	Focused  bool              `json:"focused"`
+// user override for GetStarterLayout (in the wave config dir)
+const StarterLayoutFile = "starter-layout.json"
+
+// views that can be used in a starter layout template
+var starterLayoutViews = map[string]bool{
+	"term":    true,
+	"preview": true,
+	"web":     true,
+	"waveai":  true,
+	"sysinfo": true,
+	"cpuplot": true,
+	"help":    true,
+	"tips":    true,
+	"vdom":    true,
 }
 
+type PortableLayoutEntry = waveobj.PortableLayoutEntry
+
+type PortableLayout []PortableLayoutEntry
+
 func GetStarterLayout() PortableLayout {
 	return PortableLayout{
 		{IndexArr: []int{0}, BlockDef: &waveobj.BlockDef{
@@ -76,6 +103,105 @@ func GetStarterLayout() PortableLayout {
 	}
 }
 
+// returns the starter layout from the user's starter-layout.json template.
+// falls back to the built-in GetStarterLayout() if the template does not exist.
+func LoadStarterLayout() (PortableLayout, error) {
+	fileName := filepath.Join(wavebase.GetWaveConfigDir(), StarterLayoutFile)
+	barr, err := os.ReadFile(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return GetStarterLayout(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", StarterLayoutFile, err)
+	}
+	var layout PortableLayout
+	err = json.Unmarshal(barr, &layout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", StarterLayoutFile, err)
+	}
+	err = layout.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", StarterLayoutFile, err)
+	}
+	return layout, nil
+}
+
+// the allowed range for a node size (sizes are relative to the node's siblings, the default is 10)
+const (
+	MinLayoutNodeSize = 1
+	MaxLayoutNodeSize = 1000
+)
+
+// checks that the layout can be applied before any blocks are created.  each indexarr must be non-negative and
+// its parent must be created by an earlier entry (entries directly under the root only need the root, which is
+// created by the first entry).  a node that was inserted at [1] is also the first child of its split, so [1, 0]
+// counts as [1] when looking up parents.  returns all of the violations (joined), or nil if the layout is valid.
+func (layout PortableLayout) Validate() error {
+	if len(layout) == 0 {
+		return fmt.Errorf("layout has no entries")
+	}
+	var errs []error
+	addErr := func(idx int, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("entry %d: %s", idx, fmt.Sprintf(format, args...)))
+	}
+	seenIndexArrs := make(map[string]int)
+	seenNodes := make(map[string]bool)
+	numFocused := 0
+	for idx, entry := range layout {
+		if len(entry.IndexArr) == 0 {
+			addErr(idx, "indexarr must not be empty")
+		} else if !validLayoutIndexArr(entry.IndexArr) {
+			addErr(idx, "indexarr %v contains a negative index", entry.IndexArr)
+		} else {
+			indexKey := fmt.Sprint(entry.IndexArr)
+			if prevIdx, found := seenIndexArrs[indexKey]; found {
+				addErr(idx, "indexarr %v is already used by entry %d", entry.IndexArr, prevIdx)
+			} else {
+				seenIndexArrs[indexKey] = idx
+			}
+			parent := trimLayoutIndexArr(entry.IndexArr[:len(entry.IndexArr)-1])
+			if len(parent) > 0 && !seenNodes[fmt.Sprint(parent)] {
+				addErr(idx, "indexarr %v has no parent %v in an earlier entry", entry.IndexArr, parent)
+			}
+			seenNodes[fmt.Sprint(trimLayoutIndexArr(entry.IndexArr))] = true
+		}
+		if entry.Size != nil && (*entry.Size < MinLayoutNodeSize || *entry.Size > MaxLayoutNodeSize) {
+			addErr(idx, "size %d is out of range (must be between %d and %d)", *entry.Size, MinLayoutNodeSize, MaxLayoutNodeSize)
+		}
+		if entry.BlockDef == nil {
+			addErr(idx, "missing blockdef")
+		} else if view := entry.BlockDef.Meta.GetString(waveobj.MetaKey_View, ""); view == "" {
+			addErr(idx, "blockdef is missing %q", waveobj.MetaKey_View)
+		} else if !starterLayoutViews[view] {
+			addErr(idx, "unknown view %q", view)
+		}
+		if entry.Focused {
+			numFocused++
+		}
+	}
+	if numFocused > 1 {
+		errs = append(errs, fmt.Errorf("only one entry can be focused (found %d)", numFocused))
+	}
+	return errors.Join(errs...)
+}
+
+func validLayoutIndexArr(indexArr []int) bool {
+	for _, nodeIdx := range indexArr {
+		if nodeIdx < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// removes the trailing zeros (the first child of a node is at the node's own position)
+func trimLayoutIndexArr(indexArr []int) []int {
+	for len(indexArr) > 0 && indexArr[len(indexArr)-1] == 0 {
+		indexArr = indexArr[:len(indexArr)-1]
+	}
+	return indexArr
+}
+
 func GetNewTabLayout() PortableLayout {
 	return PortableLayout{
 		{IndexArr: []int{0}, BlockDef: &waveobj.BlockDef{
@@ -111,6 +237,13 @@ func QueueLayoutAction(ctx context.Context, layoutStateId string, actions ...wav
 	if err != nil {
 		return fmt.Errorf("unable to update layout state with new actions: %w", err)
 	}
+	for _, action := range actions {
+		eventbus.SendEventToListeners(eventbus.WSEventType{
+			EventType: eventbus.WSEvent_LayoutAction,
+			ORef:      waveobj.MakeORef(waveobj.OType_LayoutState, layoutStateId).String(),
+			Data:      action,
+		})
+	}
 	return nil
 }
 
@@ -123,68 +256,210 @@ func QueueLayoutActionForTab(ctx context.Context, tabId string, actions ...waveo
 	return QueueLayoutAction(ctx, layoutStateId, actions...)
 }
 
-func ApplyPortableLayout(ctx context.Context, tabId string, layout PortableLayout) error {
-	log.Printf("ApplyPortableLayout, tabId: %s, layout: %v\n", tabId, layout)
-	actions := make([]waveobj.LayoutActionData, len(layout)+1)
-	actions[0] = waveobj.LayoutActionData{ActionType: LayoutActionDataType_ClearTree}
-	for i := 0; i < len(layout); i++ {
-		layoutAction := layout[i]
+const (
+	LayoutPosition_End         = "end"    // after the last child of the root node (the right edge for the default layout)
+	LayoutPositionPrefix_After = "after:" // "after:[blockid]", next to a block in the same tab
+)
 
-		blockData, err := CreateBlock(ctx, tabId, layoutAction.BlockDef, &waveobj.RuntimeOpts{})
-		if err != nil {
-			return fmt.Errorf("unable to create block to apply portable layout to tab %s: %w", tabId, err)
+// converts a block position ("", "end", or "after:[blockid]") to an indexarr for an insertatindex action.
+// returns nil for the default position (or when the tab's layout is empty).
+func ResolveLayoutPosition(ctx context.Context, tabId string, position string) ([]int, error) {
+	if position == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(position, LayoutPositionPrefix_After) {
+		blockId := strings.TrimPrefix(position, LayoutPositionPrefix_After)
+		indexArr := findBlockIndexArrForTab(ctx, tabId, blockId)
+		if indexArr == nil {
+			return nil, fmt.Errorf("block %s is not in the layout for tab %s", blockId, tabId)
 		}
+		return indexArr, nil
+	}
+	if position != LayoutPosition_End {
+		return nil, fmt.Errorf("invalid position %q (must be %q or %q)", position, LayoutPosition_End, LayoutPositionPrefix_After+"[blockid]")
+	}
+	numChildren, err := getLayoutRootChildCount(ctx, tabId)
+	if err != nil || numChildren == 0 {
+		return nil, err
+	}
+	return []int{numChildren - 1}, nil
+}
 
-		actions[i+1] = waveobj.LayoutActionData{
-			ActionType: LayoutActionDataType_InsertAtIndex,
-			BlockId:    blockData.OID,
-			IndexArr:   &layoutAction.IndexArr,
-			NodeSize:   layoutAction.Size,
-			Focused:    layoutAction.Focused,
+// returns the number of children of the layout's root node (1 if the root is a leaf, 0 if the layout is empty)
+func getLayoutRootChildCount(ctx context.Context, tabId string) (int, error) {
+	layoutStateId, err := GetLayoutIdForTab(ctx, tabId)
+	if err != nil {
+		return 0, err
+	}
+	layoutState, err := wstore.DBMustGet[*waveobj.LayoutState](ctx, layoutStateId)
+	if err != nil {
+		return 0, fmt.Errorf("error getting layout state for tab %s: %w", tabId, err)
+	}
+	rootNode, ok := layoutState.RootNode.(map[string]any)
+	if !ok {
+		return 0, nil
+	}
+	children, _ := rootNode["children"].([]any)
+	if len(children) == 0 {
+		return 1, nil
+	}
+	return len(children), nil
+}
+
+// applies the layout to a new tab in a single transaction (either all of the blocks are created or none of them are)
+func ApplyPortableLayout(ctx context.Context, tabId string, layout PortableLayout) error {
+	logger.Debug(ctx, "ApplyPortableLayout", "tabid", tabId, "layout", layout)
+	err := layout.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid layout for tab %s: %w", tabId, err)
+	}
+	defs := make([]wshrpc.BlockDefWithLayout, len(layout))
+	for i, entry := range layout {
+		defs[i] = wshrpc.BlockDefWithLayout{
+			BlockDef: entry.BlockDef,
+			IndexArr: entry.IndexArr,
+			Size:     entry.Size,
+			Focused:  entry.Focused,
 		}
 	}
+	_, err = CreateBlocks(ctx, tabId, defs, true)
+	if err != nil {
+		return fmt.Errorf("unable to apply portable layout to tab %s: %w", tabId, err)
+	}
+	return nil
+}
 
-	err := QueueLayoutActionForTab(ctx, tabId, actions...)
+// creates all of the blocks in a single transaction and queues their layout actions as one batch
+// (blocks with an indexarr are inserted at that position, the others are inserted like new blocks).
+// if any block fails, nothing is created and the error says which definition failed.
+// clearLayout clears the layout tree first (only for tabs that have no blocks yet).
+// returns the new block ids (in the order of defs).
+func CreateBlocks(ctx context.Context, tabId string, defs []wshrpc.BlockDefWithLayout, clearLayout bool) ([]string, error) {
+	err := validateBlockDefLayout(defs)
 	if err != nil {
-		return fmt.Errorf("unable to queue layout actions for portable layout: %w", err)
+		return nil, err
 	}
+	var fileBlockIds []string
+	blockIds, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) ([]string, error) {
+		var actions []waveobj.LayoutActionData
+		if clearLayout {
+			actions = append(actions, waveobj.LayoutActionData{ActionType: LayoutActionDataType_ClearTree})
+		}
+		blockIds := make([]string, 0, len(defs))
+		for idx, def := range defs {
+			if def.BlockDef == nil {
+				return nil, fmt.Errorf("block definition %d: no blockdef", idx)
+			}
+			blockData, err := CreateBlock(tx.Context(), tabId, def.BlockDef, &waveobj.RuntimeOpts{})
+			if err != nil {
+				return nil, fmt.Errorf("block definition %d (view %q): %w", idx, def.BlockDef.Meta.GetString(waveobj.MetaKey_View, ""), err)
+			}
+			blockIds = append(blockIds, blockData.OID)
+			if len(def.BlockDef.Files) > 0 {
+				fileBlockIds = append(fileBlockIds, blockData.OID)
+			}
+			action := waveobj.LayoutActionData{
+				ActionType: LayoutActionDataType_Insert,
+				BlockId:    blockData.OID,
+				NodeSize:   def.Size,
+				Focused:    def.Focused,
+			}
+			if len(def.IndexArr) > 0 {
+				indexArr := def.IndexArr
+				action.ActionType = LayoutActionDataType_InsertAtIndex
+				action.IndexArr = &indexArr
+			}
+			actions = append(actions, action)
+		}
+		err := QueueLayoutActionForTab(tx.Context(), tabId, actions...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to queue layout actions: %w", err)
+		}
+		return blockIds, nil
+	})
+	if err != nil {
+		// the blocks were rolled back, but their files live in the filestore
+		fsCtx, cancelFn := detachTxContext(ctx)
+		defer cancelFn()
+		for _, blockId := range fileBlockIds {
+			filestore.WFS.DeleteZone(fsCtx, blockId)
+		}
+		return nil, err
+	}
+	return blockIds, nil
+}
 
+// the block definitions that have an indexarr are checked as a layout (in order), so a malformed indexarr is
+// caught before any blocks are created (otherwise the blocks are created and only the frontend can't place them)
+func validateBlockDefLayout(defs []wshrpc.BlockDefWithLayout) error {
+	var layout PortableLayout
+	for _, def := range defs {
+		if len(def.IndexArr) == 0 {
+			continue
+		}
+		layout = append(layout, PortableLayoutEntry{
+			IndexArr: def.IndexArr,
+			Size:     def.Size,
+			BlockDef: def.BlockDef,
+			Focused:  def.Focused,
+		})
+	}
+	if len(layout) == 0 {
+		return nil
+	}
+	err := layout.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid layout: %w", err)
+	}
 	return nil
 }
 
-func BootstrapStarterLayout(ctx context.Context) error {
+// applies the starter layout to the active tab of the first window.  if that tab already has blocks (e.g. the
+// layout was already bootstrapped) nothing is created unless force is set.  returns whether the layout was applied.
+func BootstrapStarterLayout(ctx context.Context, force bool) (bool, error) {
 	ctx, cancelFn := context.WithTimeout(ctx, 2*time.Second)
 	defer cancelFn()
 	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
 	if err != nil {
-		log.Printf("unable to find client: %v\n", err)
-		return fmt.Errorf("unable to find client: %w", err)
+		return false, fmt.Errorf("unable to find client: %w", err)
 	}
 
 	if len(client.WindowIds) < 1 {
-		return fmt.Errorf("error bootstrapping layout, no windows exist")
+		return false, fmt.Errorf("error bootstrapping layout, no windows exist")
 	}
 
 	windowId := client.WindowIds[0]
 
 	window, err := wstore.DBMustGet[*waveobj.Window](ctx, windowId)
 	if err != nil {
-		return fmt.Errorf("error getting window: %w", err)
+		return false, fmt.Errorf("error getting window: %w", err)
 	}
 
 	workspace, err := wstore.DBMustGet[*waveobj.Workspace](ctx, window.WorkspaceId)
 	if err != nil {
-		return fmt.Errorf("error getting workspace: %w", err)
+		return false, fmt.Errorf("error getting workspace: %w", err)
 	}
 
 	tabId := workspace.ActiveTabId
+	tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return false, fmt.Errorf("error getting tab: %w", err)
+	}
+	if len(tab.BlockIds) > 0 && !force {
+		logger.Info(ctx, "tab already has blocks, not bootstrapping the starter layout", "tabid", tabId, "numblocks", len(tab.BlockIds))
+		return false, nil
+	}
 
-	starterLayout := GetStarterLayout()
+	starterLayout, err := LoadStarterLayout()
+	if err != nil {
+		logger.Warn(ctx, "error loading starter layout, using default", "err", err)
+		starterLayout = GetStarterLayout()
+	}
 
 	err = ApplyPortableLayout(ctx, tabId, starterLayout)
 	if err != nil {
-		return fmt.Errorf("error applying starter layout: %w", err)
+		return false, fmt.Errorf("error applying starter layout: %w", err)
 	}
 
-	return nil
+	return true, nil
 }
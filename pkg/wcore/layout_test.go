@@ -0,0 +1,127 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func makeTestLayout(indexArrs ...[]int) PortableLayout {
+	var layout PortableLayout
+	for _, indexArr := range indexArrs {
+		layout = append(layout, PortableLayoutEntry{
+			IndexArr: indexArr,
+			BlockDef: &waveobj.BlockDef{Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "term"}},
+		})
+	}
+	return layout
+}
+
+func sizePtr(size uint) *uint {
+	return &size
+}
+
+func TestValidatePortableLayout(t *testing.T) {
+	oversized := makeTestLayout([]int{0}, []int{1})
+	oversized[1].Size = sizePtr(MaxLayoutNodeSize + 1)
+	zeroSize := makeTestLayout([]int{0})
+	zeroSize[0].Size = sizePtr(0)
+	sized := makeTestLayout([]int{0}, []int{1})
+	sized[0].Size = sizePtr(MinLayoutNodeSize)
+	sized[1].Size = sizePtr(MaxLayoutNodeSize)
+	multiError := makeTestLayout([]int{0}, []int{0}, []int{-1}, []int{3, 1})
+	multiError[0].Size = sizePtr(0)
+
+	tests := []struct {
+		name    string
+		layout  PortableLayout
+		errMsgs []string
+	}{
+		{name: "starter layout", layout: GetStarterLayout()},
+		{name: "new tab layout", layout: GetNewTabLayout()},
+		{name: "nested first child", layout: makeTestLayout([]int{0}, []int{1}, []int{1, 1}, []int{1, 0, 1})},
+		{name: "sizes at the limits", layout: sized},
+		{name: "empty layout", layout: nil, errMsgs: []string{"layout has no entries"}},
+		{name: "empty indexarr", layout: makeTestLayout([]int{0}, []int{}), errMsgs: []string{"entry 1: indexarr must not be empty"}},
+		{name: "negative index", layout: makeTestLayout([]int{0}, []int{1, -1}), errMsgs: []string{"entry 1: indexarr [1 -1] contains a negative index"}},
+		{name: "gap", layout: makeTestLayout([]int{0}, []int{1}, []int{2, 5}), errMsgs: []string{"entry 2: indexarr [2 5] has no parent [2]"}},
+		{name: "parent after child", layout: makeTestLayout([]int{0}, []int{1, 1}, []int{1}), errMsgs: []string{"entry 1: indexarr [1 1] has no parent [1]"}},
+		{name: "duplicate position", layout: makeTestLayout([]int{0}, []int{1}, []int{1}), errMsgs: []string{"entry 2: indexarr [1] is already used by entry 1"}},
+		{name: "size too large", layout: oversized, errMsgs: []string{"entry 1: size 1001 is out of range"}},
+		{name: "size zero", layout: zeroSize, errMsgs: []string{"entry 0: size 0 is out of range"}},
+		{
+			name:   "all violations",
+			layout: multiError,
+			errMsgs: []string{
+				"entry 0: size 0 is out of range",
+				"entry 1: indexarr [0] is already used by entry 0",
+				"entry 2: indexarr [-1] contains a negative index",
+				"entry 3: indexarr [3 1] has no parent [3]",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.layout.Validate()
+			if len(tc.errMsgs) == 0 {
+				if err != nil {
+					t.Fatalf("expected a valid layout, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q, got nil", tc.errMsgs)
+			}
+			errLines := strings.Split(err.Error(), "\n")
+			if len(errLines) != len(tc.errMsgs) {
+				t.Fatalf("expected %d error(s), got %d: %v", len(tc.errMsgs), len(errLines), err)
+			}
+			for idx, errMsg := range tc.errMsgs {
+				if !strings.HasPrefix(errLines[idx], errMsg) {
+					t.Errorf("error %d: expected %q, got %q", idx, errMsg, errLines[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestGetMagnifiedBlockId(t *testing.T) {
+	rootNode := map[string]any{
+		"id": "root",
+		"children": []any{
+			map[string]any{"id": "n1", "data": map[string]any{"blockId": "b1"}},
+			map[string]any{"id": "n2", "children": []any{
+				map[string]any{"id": "n3", "data": map[string]any{"blockId": "b3"}},
+			}},
+		},
+	}
+	magnify := func(blockId string, magnified bool) waveobj.LayoutActionData {
+		return waveobj.LayoutActionData{ActionType: LayoutActionDataType_Magnify, BlockId: blockId, Magnified: magnified}
+	}
+	tests := []struct {
+		name            string
+		magnifiedNodeId string
+		pending         []waveobj.LayoutActionData
+		expected        string
+	}{
+		{name: "none", expected: ""},
+		{name: "nested node", magnifiedNodeId: "n3", expected: "b3"},
+		{name: "pending magnify", magnifiedNodeId: "n1", pending: []waveobj.LayoutActionData{magnify("b3", true)}, expected: "b3"},
+		{name: "pending demagnify", magnifiedNodeId: "n1", pending: []waveobj.LayoutActionData{magnify("b1", false)}, expected: ""},
+		{name: "demagnify other", magnifiedNodeId: "n1", pending: []waveobj.LayoutActionData{magnify("b3", false)}, expected: "b1"},
+		{name: "magnified insert", pending: []waveobj.LayoutActionData{{ActionType: LayoutActionDataType_Insert, BlockId: "b4", Magnified: true}}, expected: "b4"},
+	}
+	for _, tc := range tests {
+		layoutState := &waveobj.LayoutState{RootNode: rootNode, MagnifiedNodeId: tc.magnifiedNodeId}
+		if tc.pending != nil {
+			layoutState.PendingBackendActions = &tc.pending
+		}
+		if blockId := getMagnifiedBlockId(layoutState); blockId != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, blockId)
+		}
+	}
+}
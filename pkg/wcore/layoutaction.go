@@ -0,0 +1,273 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the layout actions run by the frontend (see layoutModel.ts).  the actions are stored in the tab's layout state
+// until the frontend applies them, and it saves the new tree, so the result survives a restart.
+var layoutSplitDirections = map[string]bool{
+	wshrpc.SplitDirection_Left:   true,
+	wshrpc.SplitDirection_Right:  true,
+	wshrpc.SplitDirection_Top:    true,
+	wshrpc.SplitDirection_Bottom: true,
+}
+
+// validates the action against the block's tab and its current layout tree and queues it for the tab.  returns the
+// id of the new block for a split.
+func ApplyLayoutAction(ctx context.Context, data wshrpc.CommandLayoutActionData) (string, error) {
+	tabId, indexArr, err := getLayoutBlockPosition(ctx, data.BlockId)
+	if err != nil {
+		return "", err
+	}
+	switch data.ActionType {
+	case wshrpc.LayoutAction_Split:
+		return splitLayoutBlock(ctx, tabId, data)
+
+	case wshrpc.LayoutAction_Resize:
+		if data.Size <= 0 || data.Size >= 1 {
+			return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid size %v (must be between 0 and 1)", data.Size)
+		}
+		if len(indexArr) == 0 {
+			return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "block %s is the only block in its layout and cannot be resized", data.BlockId)
+		}
+		return "", QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
+			ActionType:   LayoutActionDataType_Resize,
+			BlockId:      data.BlockId,
+			SizeFraction: data.Size,
+		})
+
+	case wshrpc.LayoutAction_Swap:
+		if data.OtherBlockId == "" {
+			return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "no block to swap with")
+		}
+		if data.OtherBlockId == data.BlockId {
+			return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "cannot swap block %s with itself", data.BlockId)
+		}
+		otherTabId, _, err := getLayoutBlockPosition(ctx, data.OtherBlockId)
+		if err != nil {
+			return "", err
+		}
+		if otherTabId != tabId {
+			return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "blocks %s and %s are not in the same tab", data.BlockId, data.OtherBlockId)
+		}
+		return "", QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
+			ActionType:    LayoutActionDataType_Swap,
+			BlockId:       data.BlockId,
+			TargetBlockId: data.OtherBlockId,
+		})
+
+	case wshrpc.LayoutAction_Magnify, wshrpc.LayoutAction_Demagnify:
+		_, _, err := SetBlockMagnified(ctx, data.BlockId, data.ActionType == wshrpc.LayoutAction_Magnify, false)
+		return "", err
+
+	default:
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid layout action %q", data.ActionType)
+	}
+}
+
+// creates the new block and queues the split in one transaction (so a failed split doesn't leave a block behind)
+func splitLayoutBlock(ctx context.Context, tabId string, data wshrpc.CommandLayoutActionData) (string, error) {
+	if !layoutSplitDirections[data.Direction] {
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid split direction %q (must be left, right, top, or bottom)", data.Direction)
+	}
+	if data.BlockDef == nil || data.BlockDef.Meta.GetString(waveobj.MetaKey_View, "") == "" {
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "no view for the new block")
+	}
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (string, error) {
+		newBlock, err := CreateBlock(tx.Context(), tabId, data.BlockDef, nil)
+		if err != nil {
+			return "", err
+		}
+		err = QueueLayoutActionForTab(tx.Context(), tabId, waveobj.LayoutActionData{
+			ActionType:    LayoutActionDataType_Split,
+			BlockId:       newBlock.OID,
+			TargetBlockId: data.BlockId,
+			Direction:     data.Direction,
+			Focused:       true,
+		})
+		if err != nil {
+			return "", err
+		}
+		return newBlock.OID, nil
+	})
+}
+
+// returns the block's tab and its path in the tab's layout tree (empty if the block is the root node)
+func getLayoutBlockPosition(ctx context.Context, blockId string) (string, []int, error) {
+	if blockId == "" {
+		return "", nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "no block id")
+	}
+	block, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return "", nil, err
+	}
+	if block == nil || block.DeletedTs != 0 {
+		return "", nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block %s not found", blockId)
+	}
+	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+	if parentORef == nil || parentORef.OType != waveobj.OType_Tab {
+		return "", nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "block %s is not in a tab", blockId)
+	}
+	tabId := parentORef.OID
+	layoutStateId, err := GetLayoutIdForTab(ctx, tabId)
+	if err != nil {
+		return "", nil, err
+	}
+	layoutState, err := wstore.DBMustGet[*waveobj.LayoutState](ctx, layoutStateId)
+	if err != nil {
+		return "", nil, err
+	}
+	indexArr, found := findLayoutNodePath(layoutState.RootNode, blockId)
+	if !found && hasPendingLayoutInsert(layoutState, blockId) {
+		// the frontend hasn't added the block yet (e.g. it was just created), its actions are applied in order
+		return tabId, []int{0}, nil
+	}
+	if !found {
+		return "", nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block %s is not in the layout for tab %s", blockId, tabId)
+	}
+	return tabId, indexArr, nil
+}
+
+func hasPendingLayoutInsert(layoutState *waveobj.LayoutState, blockId string) bool {
+	if layoutState.PendingBackendActions == nil {
+		return false
+	}
+	for _, action := range *layoutState.PendingBackendActions {
+		switch action.ActionType {
+		case LayoutActionDataType_Insert, LayoutActionDataType_InsertAtIndex, LayoutActionDataType_Split:
+			if action.BlockId == blockId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// magnifies (or un-magnifies) the block and records the state in the block meta.  only one block in a tab can be
+// magnified, so the block that was magnified before is un-magnified first (the actions are queued together, so the
+// frontend applies them at once).  with toggle, magnified is ignored and the block's current state is flipped.
+// returns the block's tab and its new state.
+func SetBlockMagnified(ctx context.Context, blockId string, magnified bool, toggle bool) (string, bool, error) {
+	var tabId string
+	err := wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		var indexArr []int
+		var err error
+		tabId, indexArr, err = getLayoutBlockPosition(tx.Context(), blockId)
+		if err != nil {
+			return err
+		}
+		layoutStateId, err := GetLayoutIdForTab(tx.Context(), tabId)
+		if err != nil {
+			return err
+		}
+		layoutState, err := wstore.DBMustGet[*waveobj.LayoutState](tx.Context(), layoutStateId)
+		if err != nil {
+			return err
+		}
+		curBlockId := getMagnifiedBlockId(layoutState)
+		if toggle {
+			magnified = curBlockId != blockId
+		}
+		if magnified && len(indexArr) == 0 {
+			return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "block %s is the only block in its layout and cannot be magnified", blockId)
+		}
+		var actions []waveobj.LayoutActionData
+		if magnified && curBlockId != "" && curBlockId != blockId {
+			actions = append(actions, waveobj.LayoutActionData{ActionType: LayoutActionDataType_Magnify, BlockId: curBlockId, Magnified: false})
+		}
+		actions = append(actions, waveobj.LayoutActionData{ActionType: LayoutActionDataType_Magnify, BlockId: blockId, Magnified: magnified})
+		err = setTabMagnifiedMeta(tx.Context(), tabId, blockId, magnified)
+		if err != nil {
+			return err
+		}
+		return QueueLayoutAction(tx.Context(), layoutStateId, actions...)
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return tabId, magnified, nil
+}
+
+// the magnified block once the frontend has applied the pending actions ("" if there isn't one)
+func getMagnifiedBlockId(layoutState *waveobj.LayoutState) string {
+	var blockId string
+	if layoutState.MagnifiedNodeId != "" {
+		blockId = findLayoutNodeBlockId(layoutState.RootNode, layoutState.MagnifiedNodeId)
+	}
+	if layoutState.PendingBackendActions == nil {
+		return blockId
+	}
+	for _, action := range *layoutState.PendingBackendActions {
+		switch action.ActionType {
+		case LayoutActionDataType_Magnify:
+			if action.Magnified {
+				blockId = action.BlockId
+			} else if action.BlockId == blockId {
+				blockId = ""
+			}
+		case LayoutActionDataType_Insert, LayoutActionDataType_InsertAtIndex, LayoutActionDataType_Focus:
+			if action.Magnified {
+				blockId = action.BlockId
+			}
+		}
+	}
+	return blockId
+}
+
+func findLayoutNodeBlockId(node any, nodeId string) string {
+	nodeMap, ok := node.(map[string]any)
+	if !ok {
+		return ""
+	}
+	if id, _ := nodeMap["id"].(string); id == nodeId {
+		data, _ := nodeMap["data"].(map[string]any)
+		blockId, _ := data["blockId"].(string)
+		return blockId
+	}
+	children, _ := nodeMap["children"].([]any)
+	for _, child := range children {
+		if blockId := findLayoutNodeBlockId(child, nodeId); blockId != "" {
+			return blockId
+		}
+	}
+	return ""
+}
+
+// sets the block's magnified meta, and clears it on the other blocks in the tab when the block is magnified (they
+// can be left over from a block that was un-magnified in the ui)
+func setTabMagnifiedMeta(ctx context.Context, tabId string, blockId string, magnified bool) error {
+	tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return err
+	}
+	for _, tabBlockId := range tab.BlockIds {
+		block, _ := wstore.DBGet[*waveobj.Block](ctx, tabBlockId)
+		if block == nil {
+			continue
+		}
+		if tabBlockId != blockId && !magnified {
+			continue
+		}
+		blockMagnified := tabBlockId == blockId && magnified
+		if block.Meta.GetBool(waveobj.MetaKey_Magnified, false) == blockMagnified {
+			continue
+		}
+		var magnifiedVal any
+		if blockMagnified {
+			magnifiedVal = true
+		}
+		err = wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Block, tabBlockId), waveobj.MetaMapType{waveobj.MetaKey_Magnified: magnifiedVal}, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
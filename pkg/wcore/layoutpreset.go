@@ -0,0 +1,298 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// the meta keys that are saved in a layout preset (by view).  blocks with other views (e.g. vdom) can't be recreated
+// and are left out.  term blocks are saved as shells (in their last cwd), not with the command they were running.
+var layoutPresetMetaKeys = map[string][]string{
+	"term":    {waveobj.MetaKey_Connection, waveobj.MetaKey_CmdCwd},
+	"web":     {waveobj.MetaKey_Url},
+	"preview": {waveobj.MetaKey_Connection, waveobj.MetaKey_File},
+	"sysinfo": {waveobj.MetaKey_Connection, waveobj.MetaKey_SysinfoType, waveobj.MetaKey_PlotMetrics, waveobj.MetaKey_PlotInterval},
+	"cpuplot": {waveobj.MetaKey_Connection},
+	"waveai":  {},
+	"help":    {},
+	"tips":    {},
+}
+
+var layoutPresetCommonMetaKeys = []string{waveobj.MetaKey_FrameTitle, waveobj.MetaKey_FrameIcon, waveobj.MetaKey_Pinned}
+
+func GetLayoutPreset(ctx context.Context, name string) (*waveobj.LayoutPreset, error) {
+	presets, err := ListLayoutPresets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, preset := range presets {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+	return nil, nil
+}
+
+// returns the layout presets sorted by name
+func ListLayoutPresets(ctx context.Context) ([]*waveobj.LayoutPreset, error) {
+	presets, err := wstore.DBGetAllObjsByType[*waveobj.LayoutPreset](ctx, waveobj.OType_LayoutPreset)
+	if err != nil {
+		return nil, fmt.Errorf("error getting layout presets: %w", err)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	return presets, nil
+}
+
+// saves the tab's current layout (and block settings) as a preset, replacing any preset with the same name
+func SaveLayoutPreset(ctx context.Context, tabId string, name string) (*waveobj.LayoutPreset, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("layout preset name cannot be empty")
+	}
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (*waveobj.LayoutPreset, error) {
+		layout, err := getPortableLayoutForTab(tx.Context(), tabId)
+		if err != nil {
+			return nil, err
+		}
+		preset, err := GetLayoutPreset(tx.Context(), name)
+		if err != nil {
+			return nil, err
+		}
+		if preset != nil {
+			preset.Layout = layout
+			preset.CreatedTs = time.Now().UnixMilli()
+			return preset, wstore.DBUpdate(tx.Context(), preset)
+		}
+		preset = &waveobj.LayoutPreset{
+			OID:       uuid.NewString(),
+			Name:      name,
+			CreatedTs: time.Now().UnixMilli(),
+			Layout:    layout,
+			Meta:      waveobj.MetaMapType{},
+		}
+		return preset, wstore.DBInsert(tx.Context(), preset)
+	})
+}
+
+func DeleteLayoutPreset(ctx context.Context, name string) error {
+	return wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		preset, err := GetLayoutPreset(tx.Context(), name)
+		if err != nil {
+			return err
+		}
+		if preset == nil {
+			return fmt.Errorf("layout preset %q not found", name)
+		}
+		return wstore.DBDelete(tx.Context(), waveobj.OType_LayoutPreset, preset.OID)
+	})
+}
+
+// recreates the preset's blocks in the tab.  with clearExisting the tab's current blocks are closed (they go to the
+// block trash) and the preset replaces the layout, otherwise the preset is added at the right edge of the layout.
+// the layout can't be replaced around pinned blocks, so clearExisting fails if the tab has any (unless force is set,
+// which closes them too).  returns the new block ids.
+func ApplyLayoutPreset(ctx context.Context, tabId string, name string, clearExisting bool, force bool) ([]string, error) {
+	preset, err := GetLayoutPreset(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if preset == nil {
+		return nil, fmt.Errorf("layout preset %q not found", name)
+	}
+	if len(preset.Layout) == 0 {
+		return nil, fmt.Errorf("layout preset %q has no blocks", name)
+	}
+	err = PortableLayout(preset.Layout).Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout preset %q: %w", name, err)
+	}
+	tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tab: %w", err)
+	}
+	rootIdx := 0
+	if clearExisting && !force {
+		for _, blockId := range tab.BlockIds {
+			if IsBlockPinned(ctx, blockId) {
+				return nil, fmt.Errorf("tab has pinned blocks (unpin them or force closing them)")
+			}
+		}
+	}
+	if clearExisting {
+		for _, blockId := range tab.BlockIds {
+			err = DeleteBlock(ctx, blockId, false)
+			if err != nil {
+				return nil, fmt.Errorf("error closing block %s: %w", blockId, err)
+			}
+		}
+	} else if len(tab.BlockIds) > 0 {
+		// the preset goes in a new node after the root's last child
+		rootIdx, err = getLayoutRootChildCount(ctx, tabId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defs := make([]wshrpc.BlockDefWithLayout, len(preset.Layout))
+	for idx, entry := range preset.Layout {
+		indexArr := entry.IndexArr
+		if rootIdx > 0 {
+			// the first entry is the preset's root, the others are inside of it
+			if idx == 0 {
+				indexArr = []int{rootIdx}
+			} else {
+				indexArr = append([]int{rootIdx}, entry.IndexArr...)
+			}
+		}
+		defs[idx] = wshrpc.BlockDefWithLayout{
+			BlockDef: entry.BlockDef,
+			IndexArr: indexArr,
+			Size:     entry.Size,
+			Focused:  entry.Focused,
+		}
+	}
+	return CreateBlocks(ctx, tabId, defs, clearExisting || rootIdx == 0)
+}
+
+// converts the tab's layout tree into a list of insert actions that recreate it (see appendLayoutPresetEntries).
+// blocks with views that can't be recreated (e.g. vdom) are left out.
+func getPortableLayoutForTab(ctx context.Context, tabId string) (PortableLayout, error) {
+	layoutStateId, err := GetLayoutIdForTab(ctx, tabId)
+	if err != nil {
+		return nil, err
+	}
+	layoutState, err := wstore.DBMustGet[*waveobj.LayoutState](ctx, layoutStateId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting layout state for tab %s: %w", tabId, err)
+	}
+	tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tab: %w", err)
+	}
+	blocks, err := wstore.DBSelectMap[*waveobj.Block](ctx, tab.BlockIds)
+	if err != nil {
+		return nil, fmt.Errorf("error getting blocks for tab: %w", err)
+	}
+	blockDefs := make(map[string]*waveobj.BlockDef)
+	for blockId, block := range blocks {
+		if blockDef := makeLayoutPresetBlockDef(block); blockDef != nil {
+			blockDefs[blockId] = blockDef
+		}
+	}
+	rootNode := pruneLayoutNode(layoutState.RootNode, blockDefs)
+	if rootNode == nil {
+		return nil, fmt.Errorf("tab %s has no blocks that can be saved in a layout preset", tabId)
+	}
+	var layout PortableLayout
+	appendPresetLeafEntry(&layout, rootNode, []int{0}, blockDefs, layoutState.FocusedNodeId)
+	appendLayoutPresetEntries(&layout, rootNode, nil, blockDefs, layoutState.FocusedNodeId)
+	return layout, nil
+}
+
+func makeLayoutPresetBlockDef(block *waveobj.Block) *waveobj.BlockDef {
+	view := block.Meta.GetString(waveobj.MetaKey_View, "")
+	metaKeys, ok := layoutPresetMetaKeys[view]
+	if !ok {
+		return nil
+	}
+	meta := waveobj.MetaMapType{waveobj.MetaKey_View: view}
+	if view == "term" {
+		meta[waveobj.MetaKey_Controller] = "shell"
+	}
+	for _, keys := range [][]string{metaKeys, layoutPresetCommonMetaKeys} {
+		for _, key := range keys {
+			if val, found := block.Meta[key]; found && val != nil {
+				meta[key] = val
+			}
+		}
+	}
+	return &waveobj.BlockDef{Meta: meta}
+}
+
+// removes the leaves that aren't in blockDefs (and collapses the nodes that are left with a single child).
+// returns a copy of the node (nil if no leaves are left)
+func pruneLayoutNode(node any, blockDefs map[string]*waveobj.BlockDef) map[string]any {
+	nodeMap, ok := node.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if data, ok := nodeMap["data"].(map[string]any); ok {
+		blockId, _ := data["blockId"].(string)
+		if blockDefs[blockId] == nil {
+			return nil
+		}
+		return nodeMap
+	}
+	children, _ := nodeMap["children"].([]any)
+	var newChildren []any
+	for _, child := range children {
+		if newChild := pruneLayoutNode(child, blockDefs); newChild != nil {
+			newChildren = append(newChildren, newChild)
+		}
+	}
+	if len(newChildren) == 0 {
+		return nil
+	}
+	if len(newChildren) == 1 {
+		return newChildren[0].(map[string]any)
+	}
+	rtn := make(map[string]any)
+	for key, val := range nodeMap {
+		rtn[key] = val
+	}
+	rtn["children"] = newChildren
+	return rtn
+}
+
+// an insertatindex action adds the new node after the node at indexarr (splitting it if it is a leaf), so a subtree
+// is recreated by inserting its first leaf (which takes the place of the whole subtree), then the first leaf of each
+// of its other children, and then recursing into the children.  the first leaf of the root is inserted at [0].
+func appendLayoutPresetEntries(layout *PortableLayout, node map[string]any, path []int, blockDefs map[string]*waveobj.BlockDef, focusedNodeId string) {
+	children, _ := node["children"].([]any)
+	for idx := 1; idx < len(children); idx++ {
+		childPath := append(append([]int{}, path...), idx)
+		appendPresetLeafEntry(layout, children[idx].(map[string]any), childPath, blockDefs, focusedNodeId)
+	}
+	for idx, child := range children {
+		childPath := append(append([]int{}, path...), idx)
+		appendLayoutPresetEntries(layout, child.(map[string]any), childPath, blockDefs, focusedNodeId)
+	}
+}
+
+func appendPresetLeafEntry(layout *PortableLayout, node map[string]any, path []int, blockDefs map[string]*waveobj.BlockDef, focusedNodeId string) {
+	leaf := node
+	for {
+		children, _ := leaf["children"].([]any)
+		if len(children) == 0 {
+			break
+		}
+		leaf = children[0].(map[string]any)
+	}
+	data, _ := leaf["data"].(map[string]any)
+	blockId, _ := data["blockId"].(string)
+	entry := PortableLayoutEntry{
+		IndexArr: path,
+		BlockDef: blockDefs[blockId],
+	}
+	// the node that takes the place of the subtree keeps its size
+	if size, ok := node["size"].(float64); ok && size > 0 {
+		usize := uint(min(max(size, MinLayoutNodeSize), MaxLayoutNodeSize))
+		entry.Size = &usize
+	}
+	if nodeId, _ := leaf["id"].(string); nodeId != "" && nodeId == focusedNodeId {
+		entry.Focused = true
+	}
+	*layout = append(*layout, entry)
+}
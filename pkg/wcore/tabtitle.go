@@ -0,0 +1,283 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const TabTitleUpdateInterval = 500 * time.Millisecond // computed titles are pushed at most once per interval
+const tabTitleListenerId = "wcore:tabtitle"
+
+// the variables that can be used in a tab title template (the block vars come from the tab's active block)
+const (
+	TabTitleVar_Name     = "name"     // the tab's name
+	TabTitleVar_Cwd      = "cwd"      // cmd:cwd
+	TabTitleVar_Conn     = "conn"     // the connection ("local" for local blocks)
+	TabTitleVar_Cmd      = "cmd"      // the running command (cmd blocks)
+	TabTitleVar_ExitCode = "exitcode" // the exit code of the last command (once it is done)
+)
+
+var tabTitleVarRe = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// a template without braces is just a static title
+func IsTabTitleTemplate(template string) bool {
+	return strings.ContainsAny(template, "{}")
+}
+
+// substitutes the {var}s in template.  unknown vars are left as they are.
+func RenderTabTitle(template string, vars map[string]string) string {
+	return tabTitleVarRe.ReplaceAllStringFunc(template, func(match string) string {
+		val, ok := vars[match[1:len(match)-1]]
+		if !ok {
+			return match
+		}
+		return val
+	})
+}
+
+// the focused block (or the first terminal if no block is focused)
+func getTabActiveBlock(ctx context.Context, tab *waveobj.Tab) *waveobj.Block {
+	if focusedBlockId := getFocusedBlockIdForTab(ctx, tab); focusedBlockId != "" {
+		block, _ := wstore.DBGet[*waveobj.Block](ctx, focusedBlockId)
+		if block != nil {
+			return block
+		}
+	}
+	var firstBlock *waveobj.Block
+	for _, blockId := range tab.BlockIds {
+		block, _ := wstore.DBGet[*waveobj.Block](ctx, blockId)
+		if block == nil {
+			continue
+		}
+		if block.Meta.GetString(waveobj.MetaKey_View, "") == "term" {
+			return block
+		}
+		if firstBlock == nil {
+			firstBlock = block
+		}
+	}
+	return firstBlock
+}
+
+func getTabTitleVars(ctx context.Context, tab *waveobj.Tab) map[string]string {
+	vars := map[string]string{TabTitleVar_Name: tab.Name}
+	block := getTabActiveBlock(ctx, tab)
+	if block == nil {
+		return vars
+	}
+	vars[TabTitleVar_Cwd] = block.Meta.GetString(waveobj.MetaKey_CmdCwd, "")
+	vars[TabTitleVar_Conn] = block.Meta.GetString(waveobj.MetaKey_Connection, "")
+	if vars[TabTitleVar_Conn] == "" {
+		vars[TabTitleVar_Conn] = wshrpc.LocalConnName
+	}
+	vars[TabTitleVar_Cmd] = ""
+	vars[TabTitleVar_ExitCode] = ""
+	if bc := blockcontroller.GetBlockController(block.OID); bc != nil {
+		bc.WithLock(func() {
+			switch bc.ShellProcStatus {
+			case blockcontroller.Status_Running:
+				vars[TabTitleVar_Cmd] = block.Meta.GetString(waveobj.MetaKey_Cmd, "")
+			case blockcontroller.Status_Done:
+				vars[TabTitleVar_ExitCode] = strconv.Itoa(bc.ShellProcExitCode)
+			}
+		})
+	}
+	return vars
+}
+
+// returns the title to show for the tab (the rendered template, or the tab's name if it doesn't have a template)
+func GetTabTitle(ctx context.Context, tabId string) (string, error) {
+	tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return "", fmt.Errorf("error getting tab: %w", err)
+	}
+	return renderTabTitleForTab(ctx, tab), nil
+}
+
+func renderTabTitleForTab(ctx context.Context, tab *waveobj.Tab) string {
+	template := tab.Meta.GetString(waveobj.MetaKey_TabTitleTemplate, "")
+	if template == "" {
+		return tab.Name
+	}
+	return RenderTabTitle(template, getTabTitleVars(ctx, tab))
+}
+
+// sets the tab's title template.  a template without braces is a static title (it becomes the tab's name and the
+// template is cleared), an empty template clears it.
+func SetTabTitleTemplate(ctx context.Context, tabId string, template string) error {
+	template = strings.TrimSpace(template)
+	err := wstore.WithTx(ctx, func(tx *wstore.TxWrap) error {
+		tab, err := wstore.DBMustGet[*waveobj.Tab](tx.Context(), tabId)
+		if err != nil {
+			return fmt.Errorf("error getting tab: %w", err)
+		}
+		if tab.Meta == nil {
+			tab.Meta = make(waveobj.MetaMapType)
+		}
+		if IsTabTitleTemplate(template) {
+			tab.Meta[waveobj.MetaKey_TabTitleTemplate] = template
+		} else {
+			if template != "" {
+				tab.Name = template
+			}
+			delete(tab.Meta, waveobj.MetaKey_TabTitleTemplate)
+		}
+		return wstore.DBUpdate(tx.Context(), tab)
+	})
+	if err != nil {
+		return err
+	}
+	tabTitles.markDirty(tabId)
+	return nil
+}
+
+// pushes the computed titles of the tabs with templates (as tabtitle events) when their inputs change.
+// the inputs are watched through the waveobj updates (tab, block, and layout objects) and the controller status events.
+type tabTitleResolver struct {
+	lock       sync.Mutex
+	dirty      map[string]bool
+	titles     map[string]string // tab id => the last title that was pushed
+	layoutTabs map[string]string // layout id => tab id (focus changes)
+	timer      *time.Timer
+}
+
+var tabTitles = &tabTitleResolver{
+	dirty:      make(map[string]bool),
+	titles:     make(map[string]string),
+	layoutTabs: make(map[string]string),
+}
+
+func StartTabTitleResolver() {
+	listener := eventbus.RegisterListener(tabTitleListenerId, []string{wps.Event_WaveObjUpdate, wps.Event_ControllerStatus}, nil, 0)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("wcore:tabtitle-resolver", recover())
+		}()
+		for event := range listener.Ch {
+			tabTitles.handleEvent(event)
+		}
+	}()
+}
+
+func (r *tabTitleResolver) handleEvent(event eventbus.WSEventType) {
+	switch event.EventType {
+	case eventbus.WSEvent_Dropped:
+		r.lock.Lock()
+		tabIds := make([]string, 0, len(r.titles))
+		for tabId := range r.titles {
+			tabIds = append(tabIds, tabId)
+		}
+		r.lock.Unlock()
+		for _, tabId := range tabIds {
+			r.markDirty(tabId)
+		}
+	case wps.Event_ControllerStatus:
+		for _, scope := range event.Scopes {
+			if oref := waveobj.ParseORefNoErr(scope); oref != nil && oref.OType == waveobj.OType_Tab {
+				r.markDirty(oref.OID)
+			}
+		}
+	case wps.Event_WaveObjUpdate:
+		update, ok := event.Data.(waveobj.WaveObjUpdate)
+		if !ok {
+			return
+		}
+		switch update.OType {
+		case waveobj.OType_Tab:
+			r.markDirty(update.OID)
+		case waveobj.OType_Block:
+			block, ok := update.Obj.(*waveobj.Block)
+			if !ok {
+				// deletes also update the tab
+				return
+			}
+			if oref := waveobj.ParseORefNoErr(block.ParentORef); oref != nil && oref.OType == waveobj.OType_Tab {
+				r.markDirty(oref.OID)
+			}
+		case waveobj.OType_LayoutState:
+			r.lock.Lock()
+			tabId := r.layoutTabs[update.OID]
+			r.lock.Unlock()
+			if tabId != "" {
+				r.markDirty(tabId)
+			}
+		}
+	}
+}
+
+func (r *tabTitleResolver) markDirty(tabId string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.dirty[tabId] = true
+	if r.timer == nil {
+		r.timer = time.AfterFunc(TabTitleUpdateInterval, r.flush)
+	}
+}
+
+func (r *tabTitleResolver) flush() {
+	r.lock.Lock()
+	dirty := r.dirty
+	r.dirty = make(map[string]bool)
+	r.timer = nil
+	r.lock.Unlock()
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	for tabId := range dirty {
+		err := r.updateTab(ctx, tabId)
+		if err != nil {
+			log.Printf("error updating title for tab %s: %v\n", tabId, err)
+		}
+	}
+}
+
+func (r *tabTitleResolver) updateTab(ctx context.Context, tabId string) error {
+	tab, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return err
+	}
+	if tab == nil || tab.Meta.GetString(waveobj.MetaKey_TabTitleTemplate, "") == "" {
+		// deleted, or a static title (the tab's name is shown)
+		r.lock.Lock()
+		delete(r.titles, tabId)
+		for layoutId, layoutTabId := range r.layoutTabs {
+			if layoutTabId == tabId {
+				delete(r.layoutTabs, layoutId)
+			}
+		}
+		r.lock.Unlock()
+		return nil
+	}
+	title := renderTabTitleForTab(ctx, tab)
+	r.lock.Lock()
+	r.layoutTabs[tab.LayoutState] = tabId
+	lastTitle, found := r.titles[tabId]
+	r.titles[tabId] = title
+	r.lock.Unlock()
+	if found && lastTitle == title {
+		return nil
+	}
+	// every window shows the tab bar of its workspace, so the event goes to all the windows
+	eventbus.SendEventToAllWindows(eventbus.WSEventType{
+		EventType: eventbus.WSEvent_TabTitle,
+		ORef:      waveobj.MakeORef(waveobj.OType_Tab, tabId).String(),
+		Data:      wshrpc.TabTitleData{TabId: tabId, Title: title},
+	})
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import "testing"
+
+func TestRenderTabTitle(t *testing.T) {
+	vars := map[string]string{
+		TabTitleVar_Conn:     "user@host",
+		TabTitleVar_Cwd:      "/home/user/src",
+		TabTitleVar_ExitCode: "",
+	}
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{template: "{conn}:{cwd}", expected: "user@host:/home/user/src"},
+		{template: "{cwd} ({exitcode})", expected: "/home/user/src ()"},
+		{template: "{unknown}:{cwd}", expected: "{unknown}:/home/user/src"},
+		{template: "{conn", expected: "{conn"},
+		{template: "{}", expected: "{}"},
+		{template: "build", expected: "build"},
+	}
+	for _, tc := range tests {
+		if got := RenderTabTitle(tc.template, vars); got != tc.expected {
+			t.Errorf("RenderTabTitle(%q) = %q, expected %q", tc.template, got, tc.expected)
+		}
+	}
+	if IsTabTitleTemplate("build") || !IsTabTitleTemplate("{cwd}") {
+		t.Errorf("IsTabTitleTemplate: a template without braces should be a static title")
+	}
+}
@@ -8,21 +8,124 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
 )
 
 // the wcore package coordinates actions across the storage layer
 // orchestrating the wave object store, the wave pubsub system, and the wave rpc system
 
+const DefaultClientTimeout = 2 * time.Second
+const MinClientTimeout = 1 * time.Second
+const MaxClientTimeout = 60 * time.Second
+
+var clientTimeout atomic.Int64 // 0 means DefaultClientTimeout
+
+var logger = wlog.New("wcore")
+
+var logLevelsLock = &sync.Mutex{}
+var appliedLogLevels map[string]string
+
+// returns the timeout to use for client db calls (set with "client:dbtimeoutms" in the client meta)
+func GetClientTimeout() time.Duration {
+	timeout := time.Duration(clientTimeout.Load())
+	if timeout == 0 {
+		return DefaultClientTimeout
+	}
+	return timeout
+}
+
+// updates the cached client timeout from the client meta
+func UpdateClientTimeout(client *waveobj.Client) {
+	if client == nil {
+		return
+	}
+	timeoutMs := client.Meta.GetFloat(waveobj.MetaKey_ClientDbTimeoutMs, 0)
+	if timeoutMs <= 0 {
+		clientTimeout.Store(0)
+		return
+	}
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	timeout = max(MinClientTimeout, min(MaxClientTimeout, timeout))
+	clientTimeout.Store(int64(timeout))
+}
+
+// applies the log levels from the client meta ("client:loglevels", subsystem => level, "*" for the default) if they
+// changed
+func UpdateClientLogLevels(client *waveobj.Client) {
+	if client == nil {
+		return
+	}
+	levelMap := make(map[string]string)
+	for subsystem, level := range client.Meta.GetMap(waveobj.MetaKey_ClientLogLevels) {
+		if levelStr, ok := level.(string); ok {
+			levelMap[subsystem] = levelStr
+		}
+	}
+	logLevelsLock.Lock()
+	defer logLevelsLock.Unlock()
+	if appliedLogLevels != nil && maps.Equal(levelMap, appliedLogLevels) {
+		return
+	}
+	appliedLogLevels = levelMap
+	err := wlog.SetLevels(levelMap)
+	if err != nil {
+		logger.Warn(context.Background(), "invalid log levels", "key", waveobj.MetaKey_ClientLogLevels, "err", err)
+	}
+}
+
+// sets the subsystem's log level in the client meta and applies it.  an empty subsystem (or "*") sets the default level,
+// an empty level removes the subsystem's level.
+func SetClientLogLevel(ctx context.Context, subsystem string, level string) error {
+	if subsystem == "" {
+		subsystem = wlog.DefaultSubsystem
+	}
+	if level != "" {
+		parsedLevel, err := wlog.ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		level = parsedLevel.String()
+	}
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil {
+		return fmt.Errorf("error getting client: %w", err)
+	}
+	levelMap := make(map[string]any)
+	for key, val := range client.Meta.GetMap(waveobj.MetaKey_ClientLogLevels) {
+		levelMap[key] = val
+	}
+	if level == "" {
+		delete(levelMap, subsystem)
+	} else {
+		levelMap[subsystem] = level
+	}
+	var metaVal any
+	if len(levelMap) > 0 {
+		metaVal = levelMap
+	}
+	err = wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Client, client.OID), waveobj.MetaMapType{waveobj.MetaKey_ClientLogLevels: metaVal}, false)
+	if err != nil {
+		return fmt.Errorf("error updating client meta: %w", err)
+	}
+	_, err = GetClientData(ctx) // applies the levels
+	return err
+}
+
 // Ensures that the initial data is present in the store, creates an initial window if needed
 func EnsureInitialData() error {
 	// does not need to run in a transaction since it is called on startup
-	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	// uses the max timeout since we don't know the configured timeout until the client is loaded
+	ctx, cancelFn := context.WithTimeout(context.Background(), MaxClientTimeout)
 	defer cancelFn()
 	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
 	firstLaunch := false
@@ -37,6 +140,8 @@ func EnsureInitialData() error {
 		}
 		firstLaunch = true
 	}
+	UpdateClientTimeout(client)
+	UpdateClientLogLevels(client)
 	if client.TempOID == "" {
 		log.Println("client.TempOID is empty")
 		client.TempOID = uuid.NewString()
@@ -64,7 +169,7 @@ func EnsureInitialData() error {
 		}
 		wsId = starterWs.OID
 	}
-	_, err = CreateWindow(ctx, nil, wsId)
+	_, err = MakeWindow(ctx, &MakeWindowOpts{WorkspaceId: wsId})
 	if err != nil {
 		return fmt.Errorf("error creating window: %w", err)
 	}
@@ -84,9 +189,89 @@ func CreateClient(ctx context.Context) (*waveobj.Client, error) {
 }
 
 func GetClientData(ctx context.Context) (*waveobj.Client, error) {
-	clientData, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	clientData, err := wstore.DBGetSingletonCached[*waveobj.Client](ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error getting client data: %w", err)
 	}
+	UpdateClientTimeout(clientData)
+	UpdateClientLogLevels(clientData)
 	return clientData, nil
 }
+
+// deletes tabs that are not in any workspace and blocks whose parent no longer exists (blocks in the trash are skipped)
+// (these can be left behind if wavesrv crashes in the middle of a delete).
+// block controllers for the swept blocks are stopped once the deletes are committed. returns the number of objects
+// deleted.
+func SweepOrphanedObjects(ctx context.Context) (int, error) {
+	var purged []*waveobj.Block
+	numDeleted, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (int, error) {
+		ctx := tx.Context()
+		workspaces, err := wstore.DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
+		if err != nil {
+			return 0, fmt.Errorf("error getting workspaces: %w", err)
+		}
+		wsTabIds := make(map[string]bool)
+		for _, ws := range workspaces {
+			for _, tabId := range slices.Concat(ws.TabIds, ws.PinnedTabIds) {
+				wsTabIds[tabId] = true
+			}
+		}
+		tabs, err := wstore.DBGetAllObjsByType[*waveobj.Tab](ctx, waveobj.OType_Tab)
+		if err != nil {
+			return 0, fmt.Errorf("error getting tabs: %w", err)
+		}
+		numDeleted := 0
+		for _, tab := range tabs {
+			if wsTabIds[tab.OID] {
+				continue
+			}
+			log.Printf("sweeping orphaned tab %s\n", tab.OID)
+			for _, blockId := range tab.BlockIds {
+				blocks, err := purgeBlockObjs(ctx, blockId)
+				if err != nil {
+					log.Printf("error deleting block %s in orphaned tab %s: %v\n", blockId, tab.OID, err)
+					continue
+				}
+				purged = append(purged, blocks...)
+				numDeleted++
+			}
+			wstore.DBDelete(ctx, waveobj.OType_Tab, tab.OID)
+			wstore.DBDelete(ctx, waveobj.OType_LayoutState, tab.LayoutState)
+			numDeleted++
+		}
+		blocks, err := wstore.DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+		if err != nil {
+			return numDeleted, fmt.Errorf("error getting blocks: %w", err)
+		}
+		for _, block := range blocks {
+			parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+			if parentORef == nil || block.DeletedTs != 0 {
+				// blocks in the trash are purged by SweepDeletedBlocks
+				continue
+			}
+			parentExists, err := wstore.DBExistsORef(ctx, *parentORef)
+			if err != nil || parentExists {
+				continue
+			}
+			exists, _ := wstore.DBExistsORef(ctx, waveobj.MakeORef(waveobj.OType_Block, block.OID))
+			if !exists {
+				// already deleted as a subblock of an earlier orphan
+				continue
+			}
+			log.Printf("sweeping orphaned block %s (parent %s)\n", block.OID, block.ParentORef)
+			blocks, err := purgeBlockObjs(ctx, block.OID)
+			if err != nil {
+				log.Printf("error deleting orphaned block %s: %v\n", block.OID, err)
+				continue
+			}
+			purged = append(purged, blocks...)
+			numDeleted++
+		}
+		return numDeleted, nil
+	})
+	if err != nil {
+		return numDeleted, err
+	}
+	finishPurgedBlocks(purged)
+	return numDeleted, nil
+}
@@ -0,0 +1,245 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+	"golang.org/x/net/idna"
+)
+
+// the urls of web blocks are checked (and made canonical) when a web block is created or its url is set, and the
+// web view checks the pages it navigates to (see WebCheckUrlCommand).  http and https urls are allowed, file urls
+// only with web:allowfileurls, and data urls only in blocks opened by "wsh render --html".  javascript: (and any
+// other scheme) is rejected.  web:alloweddomains and web:blockeddomains limit the hosts.
+var webUrlSchemes = map[string]bool{"http": true, "https": true}
+
+type webRenderContextKey struct{}
+
+// converts unicode hosts to punycode (lowercased), underscores are allowed since some real hosts have them
+var webHostProfile = idna.New(idna.MapForLookup(), idna.Transitional(false), idna.StrictDomainName(false))
+
+type WebUrlPolicy struct {
+	AllowFile      bool
+	AllowData      bool
+	AllowedDomains []string
+	BlockedDomains []string
+}
+
+func getWebUrlPolicy(allowData bool) WebUrlPolicy {
+	settings := wconfig.GetWatcher().GetFullConfig().Settings
+	return WebUrlPolicy{
+		AllowFile:      settings.WebAllowFileUrls,
+		AllowData:      allowData,
+		AllowedDomains: settings.WebAllowedDomains,
+		BlockedDomains: settings.WebBlockedDomains,
+	}
+}
+
+// returns the canonical form of the url (lowercase scheme and host, punycode, no trailing dot on the host), or an
+// ErrorCode_InvalidArg error saying why the url is not allowed
+func NormalizeWebUrl(rawUrl string, policy WebUrlPolicy) (string, error) {
+	rawUrl = strings.TrimSpace(rawUrl)
+	if rawUrl == "" {
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "empty url")
+	}
+	if rawUrl == "about:blank" {
+		return rawUrl, nil
+	}
+	urlObj, err := url.Parse(rawUrl)
+	if err != nil {
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid url %q: %v", rawUrl, err)
+	}
+	scheme := strings.ToLower(urlObj.Scheme)
+	switch {
+	case scheme == "":
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "url %q has no scheme (use http:// or https://)", rawUrl)
+	case scheme == "data":
+		if !policy.AllowData {
+			return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "data: urls are not allowed in web blocks (use wsh render --html)")
+		}
+		return rawUrl, nil
+	case scheme == "file":
+		if !policy.AllowFile {
+			return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "file: urls are not allowed in web blocks (set web:allowfileurls to allow them, or use wsh view)")
+		}
+		urlObj.Scheme = scheme
+		return urlObj.String(), nil
+	case !webUrlSchemes[scheme]:
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "%s: urls are not allowed in web blocks (only http and https)", scheme)
+	}
+	host, err := normalizeWebHost(urlObj.Hostname())
+	if err != nil {
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid url %q: %v", rawUrl, err)
+	}
+	err = checkWebDomain(host, policy)
+	if err != nil {
+		return "", err
+	}
+	if port := urlObj.Port(); port != "" {
+		urlObj.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		urlObj.Host = "[" + host + "]"
+	} else {
+		urlObj.Host = host
+	}
+	urlObj.Scheme = scheme
+	return urlObj.String(), nil
+}
+
+func normalizeWebHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("no host")
+	}
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	asciiHost, err := webHostProfile.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid host %q: %w", host, err)
+	}
+	return asciiHost, nil
+}
+
+// the domain matches itself and its subdomains ("example.com" matches "docs.example.com"), a leading "*." or "."
+// is ignored
+func matchWebDomain(host string, domain string) bool {
+	domain = strings.TrimPrefix(strings.TrimPrefix(domain, "*"), ".")
+	domain, err := normalizeWebHost(domain)
+	if err != nil {
+		return false
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func checkWebDomain(host string, policy WebUrlPolicy) error {
+	for _, domain := range policy.BlockedDomains {
+		if matchWebDomain(host, domain) {
+			return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "%s is blocked (web:blockeddomains has %q)", host, domain)
+		}
+	}
+	if len(policy.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, domain := range policy.AllowedDomains {
+		if matchWebDomain(host, domain) {
+			return nil
+		}
+	}
+	return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "%s is not in web:alloweddomains", host)
+}
+
+// for the server side of "wsh render --html" (see MakeRenderHtmlUrl), the only place a data: url can be set and
+// web:rendered is kept.  web:rendered in any other meta (it comes from a client) is removed.
+func ContextWithWebRender(ctx context.Context) context.Context {
+	return context.WithValue(ctx, webRenderContextKey{}, true)
+}
+
+func isWebRenderContext(ctx context.Context) bool {
+	return ctx.Value(webRenderContextKey{}) != nil
+}
+
+// removes web:rendered from meta that came from a client (see ContextWithWebRender)
+func StripClientWebMeta(meta waveobj.MetaMapType) {
+	delete(meta, waveobj.MetaKey_WebRendered)
+}
+
+func MakeRenderHtmlUrl(html string) string {
+	return "data:text/html;charset=utf-8;base64," + base64.StdEncoding.EncodeToString([]byte(html))
+}
+
+// checks a url a web block is navigating to.  when it is not allowed a webnavblocked event (with the reason) is sent
+// to the block.
+func CheckWebNavigation(blockId string, rawUrl string, allowData bool) (string, error) {
+	canonicalUrl, err := NormalizeWebUrl(rawUrl, getWebUrlPolicy(allowData))
+	if err != nil {
+		wps.Broker.Publish(wps.WaveEvent{
+			Event:  wps.Event_WebNavBlocked,
+			Scopes: []string{waveobj.MakeORef(waveobj.OType_Block, blockId).String()},
+			Data:   wshrpc.WebNavBlockedData{BlockId: blockId, Url: rawUrl, Reason: err.Error()},
+		})
+		return "", err
+	}
+	return canonicalUrl, nil
+}
+
+// for new blocks, the url of a web block is replaced with its canonical form
+func normalizeWebBlockMeta(ctx context.Context, meta waveobj.MetaMapType) error {
+	if !isWebRenderContext(ctx) {
+		StripClientWebMeta(meta)
+	}
+	rawUrl := meta.GetString(waveobj.MetaKey_Url, "")
+	if meta.GetString(waveobj.MetaKey_View, "") != "web" || rawUrl == "" {
+		return nil
+	}
+	canonicalUrl, err := NormalizeWebUrl(rawUrl, getWebUrlPolicy(meta.GetBool(waveobj.MetaKey_WebRendered, false)))
+	if err != nil {
+		return err
+	}
+	meta[waveobj.MetaKey_Url] = canonicalUrl
+	return nil
+}
+
+// for meta updates (e.g. "wsh web nav" or "wsh setmeta view=web"), checks the url of the block when it is (or becomes)
+// a web block, whether the url is in the update or already on the block, and replaces it with its canonical form.
+// data: urls are only allowed for "wsh render --html" (see ContextWithWebRender).
+func NormalizeWebMetaUpdate(ctx context.Context, oref waveobj.ORef, meta waveobj.MetaMapType) error {
+	if !isWebRenderContext(ctx) {
+		StripClientWebMeta(meta)
+	}
+	_, hasUrl := meta[waveobj.MetaKey_Url]
+	_, hasView := meta[waveobj.MetaKey_View]
+	if oref.OType != waveobj.OType_Block || (!hasUrl && !hasView) {
+		return nil
+	}
+	block, err := wstore.DBGet[*waveobj.Block](ctx, oref.OID)
+	if err != nil || block == nil {
+		return err
+	}
+	newMeta := waveobj.MergeMeta(block.Meta, meta, false)
+	// a data url already on the block was set by the server (for a rendered block), the web view sends it back
+	// when it navigates
+	sameUrl := newMeta.GetString(waveobj.MetaKey_Url, "") == block.Meta.GetString(waveobj.MetaKey_Url, "")
+	allowData := isWebRenderContext(ctx) || (sameUrl && newMeta.GetBool(waveobj.MetaKey_WebRendered, false))
+	canonicalUrl, err := checkWebBlockUrl(oref.OID, newMeta, allowData)
+	if err != nil {
+		return err
+	}
+	if hasUrl && canonicalUrl != "" {
+		meta[waveobj.MetaKey_Url] = canonicalUrl
+	}
+	return nil
+}
+
+// for a whole block written by a client (its web:rendered must be the stored one), see NormalizeWebMetaUpdate
+func NormalizeWebBlockUpdate(block *waveobj.Block) error {
+	canonicalUrl, err := checkWebBlockUrl(block.OID, block.Meta, block.Meta.GetBool(waveobj.MetaKey_WebRendered, false))
+	if err != nil {
+		return err
+	}
+	if canonicalUrl != "" {
+		block.Meta[waveobj.MetaKey_Url] = canonicalUrl
+	}
+	return nil
+}
+
+// returns "" when the meta is not for a web block with a url
+func checkWebBlockUrl(blockId string, meta waveobj.MetaMapType, allowData bool) (string, error) {
+	rawUrl := meta.GetString(waveobj.MetaKey_Url, "")
+	if meta.GetString(waveobj.MetaKey_View, "") != "web" || rawUrl == "" {
+		return "", nil
+	}
+	return CheckWebNavigation(blockId, rawUrl, allowData)
+}
@@ -0,0 +1,138 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func TestNormalizeWebUrl(t *testing.T) {
+	domainPolicy := WebUrlPolicy{AllowedDomains: []string{"example.com", "*.wave.dev"}, BlockedDomains: []string{"ads.example.com"}}
+	tests := []struct {
+		name     string
+		url      string
+		policy   WebUrlPolicy
+		expected string // "" if the url is rejected
+	}{
+		{name: "https", url: "https://github.com/wavetermdev", expected: "https://github.com/wavetermdev"},
+		{name: "case and trailing dot", url: "HTTPS://GitHub.COM./a?q=1", expected: "https://github.com/a?q=1"},
+		{name: "punycode", url: "https://bücher.example/x", expected: "https://xn--bcher-kva.example/x"},
+		{name: "port", url: "http://LocalHost.:8080/", expected: "http://localhost:8080/"},
+		{name: "ipv6", url: "http://[::1]/", expected: "http://[::1]/"},
+		{name: "javascript", url: "javascript:alert(1)"},
+		{name: "javascript mixed case", url: " JavaScript:alert(1)"},
+		{name: "data", url: "data:text/html,<b>hi</b>"},
+		{name: "data allowed", url: "data:text/html,<b>hi</b>", policy: WebUrlPolicy{AllowData: true}, expected: "data:text/html,<b>hi</b>"},
+		{name: "file", url: "file:///etc/passwd"},
+		{name: "file allowed", url: "file:///tmp/a.html", policy: WebUrlPolicy{AllowFile: true}, expected: "file:///tmp/a.html"},
+		{name: "no scheme", url: "github.com"},
+		{name: "no host", url: "https:///path"},
+		{name: "allowed domain", url: "https://docs.example.com/", policy: domainPolicy, expected: "https://docs.example.com/"},
+		{name: "allowed wildcard", url: "https://wave.dev/", policy: domainPolicy, expected: "https://wave.dev/"},
+		{name: "blocked subdomain", url: "https://x.ads.example.com/", policy: domainPolicy},
+		{name: "not allowed", url: "https://notexample.com/", policy: domainPolicy},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rtn, err := NormalizeWebUrl(tc.url, tc.policy)
+			if tc.expected == "" {
+				if err == nil {
+					t.Fatalf("expected %q to be rejected, got %q", tc.url, rtn)
+				}
+				if wshrpc.GetErrorCode(err) != wshrpc.ErrorCode_InvalidArg {
+					t.Errorf("expected an invalid-argument error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %v", tc.url, err)
+			}
+			if rtn != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, rtn)
+			}
+		})
+	}
+}
+
+func TestNormalizeWebBlockMetaRendered(t *testing.T) {
+	dataUrl := MakeRenderHtmlUrl("<b>hi</b>")
+	makeMeta := func() waveobj.MetaMapType {
+		return waveobj.MetaMapType{waveobj.MetaKey_View: "web", waveobj.MetaKey_Url: dataUrl, waveobj.MetaKey_WebRendered: true}
+	}
+	// web:rendered from a client doesn't allow the data url (and is removed)
+	meta := makeMeta()
+	err := normalizeWebBlockMeta(context.Background(), meta)
+	if err == nil {
+		t.Fatalf("expected the data url to be rejected for client meta")
+	}
+	if _, ok := meta[waveobj.MetaKey_WebRendered]; ok {
+		t.Errorf("expected web:rendered to be removed from client meta")
+	}
+	meta = makeMeta()
+	err = normalizeWebBlockMeta(ContextWithWebRender(context.Background()), meta)
+	if err != nil {
+		t.Fatalf("unexpected error for a rendered block: %v", err)
+	}
+	if !meta.GetBool(waveobj.MetaKey_WebRendered, false) || meta.GetString(waveobj.MetaKey_Url, "") != dataUrl {
+		t.Errorf("expected the rendered meta to be kept, got %v", meta)
+	}
+}
+
+func TestNormalizeWebMetaUpdate(t *testing.T) {
+	wavebase.DataHome_VarCache = t.TempDir()
+	wavebase.ConfigHome_VarCache = t.TempDir()
+	err := os.MkdirAll(filepath.Join(wavebase.GetWaveDataDir(), wavebase.WaveDBDir), 0700)
+	if err != nil {
+		t.Fatalf("error creating db dir: %v", err)
+	}
+	err = wstore.InitWStore()
+	if err != nil {
+		t.Fatalf("error initializing wstore: %v", err)
+	}
+	ctx := context.Background()
+	insertBlock := func(meta waveobj.MetaMapType) waveobj.ORef {
+		block := &waveobj.Block{OID: uuid.NewString(), Meta: meta}
+		err := wstore.DBInsert(ctx, block)
+		if err != nil {
+			t.Fatalf("error inserting block: %v", err)
+		}
+		return waveobj.MakeORef(waveobj.OType_Block, block.OID)
+	}
+	dataUrl := MakeRenderHtmlUrl("<b>hi</b>")
+	termORef := insertBlock(waveobj.MetaMapType{waveobj.MetaKey_View: "term", waveobj.MetaKey_Url: "javascript:alert(1)"})
+	renderedORef := insertBlock(waveobj.MetaMapType{waveobj.MetaKey_View: "web", waveobj.MetaKey_Url: dataUrl, waveobj.MetaKey_WebRendered: true})
+	tests := []struct {
+		name  string
+		oref  waveobj.ORef
+		meta  waveobj.MetaMapType
+		isErr bool
+	}{
+		{name: "view change checks the stored url", oref: termORef, meta: waveobj.MetaMapType{waveobj.MetaKey_View: "web"}, isErr: true},
+		{name: "not a web block", oref: termORef, meta: waveobj.MetaMapType{waveobj.MetaKey_View: "preview"}},
+		{name: "view and url", oref: termORef, meta: waveobj.MetaMapType{waveobj.MetaKey_View: "web", waveobj.MetaKey_Url: "https://example.com"}},
+		{name: "rendered same url", oref: renderedORef, meta: waveobj.MetaMapType{waveobj.MetaKey_Url: dataUrl}},
+		{name: "rendered new data url", oref: renderedORef, meta: waveobj.MetaMapType{waveobj.MetaKey_Url: MakeRenderHtmlUrl("<b>bye</b>")}, isErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NormalizeWebMetaUpdate(ctx, tc.oref, tc.meta)
+			if tc.isErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.isErr, err)
+			}
+		})
+	}
+	err = NormalizeWebMetaUpdate(ContextWithWebRender(ctx), renderedORef, waveobj.MetaMapType{waveobj.MetaKey_Url: MakeRenderHtmlUrl("<b>bye</b>")})
+	if err != nil {
+		t.Errorf("unexpected error for a server render: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/wavetermdev/waveterm/pkg/eventbus"
@@ -70,7 +71,7 @@ func SwitchWorkspace(ctx context.Context, windowId string, workspaceId string) (
 }
 
 func GetWindow(ctx context.Context, windowId string) (*waveobj.Window, error) {
-	window, err := wstore.DBMustGet[*waveobj.Window](ctx, windowId)
+	window, err := wstore.DBMustGetCached[*waveobj.Window](ctx, windowId)
 	if err != nil {
 		log.Printf("error getting window %q: %v\n", windowId, err)
 		return nil, err
@@ -78,22 +79,57 @@ func GetWindow(ctx context.Context, windowId string) (*waveobj.Window, error) {
 	return window, nil
 }
 
-func CreateWindow(ctx context.Context, winSize *waveobj.WinSize, workspaceId string) (*waveobj.Window, error) {
-	log.Printf("CreateWindow %v %v\n", winSize, workspaceId)
+// the options for MakeWindow, all of them are optional
+type MakeWindowOpts struct {
+	// the initial geometry (electron picks a default size when these are not set)
+	Pos         *waveobj.Point
+	WinSize     *waveobj.WinSize
+	WorkspaceId string         // the workspace the window shows (a new workspace is created when not set)
+	TabName     string         // the name of the window's tab
+	Layout      PortableLayout // the blocks in the window's tab (instead of the new tab layout)
+}
+
+// creates a window (and its workspace, tab, and blocks) in a single transaction, so the window is complete (or not
+// created at all) when the caller announces it to electron.  with an existing workspace, a new tab is only created
+// (and made active) when TabName or Layout is set.  opts can be nil.
+func MakeWindow(ctx context.Context, opts *MakeWindowOpts) (*waveobj.Window, error) {
+	if opts == nil {
+		opts = &MakeWindowOpts{}
+	}
+	log.Printf("MakeWindow %v %v %q %q (%d blocks)\n", opts.Pos, opts.WinSize, opts.WorkspaceId, opts.TabName, len(opts.Layout))
+	if len(opts.Layout) > 0 {
+		err := opts.Layout.Validate()
+		if err != nil {
+			return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid layout: %v", err)
+		}
+	}
+	return wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (*waveobj.Window, error) {
+		return makeWindowObj(tx.Context(), opts)
+	})
+}
+
+func makeWindowObj(ctx context.Context, opts *MakeWindowOpts) (*waveobj.Window, error) {
 	var ws *waveobj.Workspace
-	if workspaceId == "" {
-		ws1, err := CreateWorkspace(ctx, "", "", "", false, false)
+	if opts.WorkspaceId == "" {
+		ws1, err := createWorkspace(ctx, "", "", "", false, false, opts.TabName, opts.Layout)
 		if err != nil {
 			return nil, fmt.Errorf("error creating workspace: %w", err)
 		}
 		ws = ws1
 	} else {
-		ws1, err := GetWorkspace(ctx, workspaceId)
+		ws1, err := GetWorkspace(ctx, opts.WorkspaceId)
 		if err != nil {
 			return nil, fmt.Errorf("error getting workspace: %w", err)
 		}
 		ws = ws1
+		if opts.TabName != "" || len(opts.Layout) > 0 {
+			_, err = CreateTabWithLayout(ctx, ws.OID, opts.TabName, true, false, false, opts.Layout)
+			if err != nil {
+				return nil, fmt.Errorf("error creating tab: %w", err)
+			}
+		}
 	}
+	pos, winSize := opts.Pos, opts.WinSize
 	windowId := uuid.NewString()
 	if winSize == nil {
 		winSize = &waveobj.WinSize{
@@ -101,15 +137,19 @@ func CreateWindow(ctx context.Context, winSize *waveobj.WinSize, workspaceId str
 			Height: 0,
 		}
 	}
+	if pos == nil {
+		pos = &waveobj.Point{
+			X: 0,
+			Y: 0,
+		}
+	}
 	window := &waveobj.Window{
 		OID:         windowId,
 		WorkspaceId: ws.OID,
 		IsNew:       true,
-		Pos: waveobj.Point{
-			X: 0,
-			Y: 0,
-		},
-		WinSize: *winSize,
+		Pos:         *pos,
+		WinSize:     *winSize,
+		CreatedTs:   time.Now().UnixMilli(),
 	}
 	err := wstore.DBInsert(ctx, window)
 	if err != nil {
@@ -127,6 +167,8 @@ func CreateWindow(ctx context.Context, winSize *waveobj.WinSize, workspaceId str
 	return GetWindow(ctx, windowId)
 }
 
+var ErrLastWindow = fmt.Errorf("cannot close the last window")
+
 // CloseWindow closes a window and deletes its workspace if it is empty and not named.
 // If fromElectron is true, it does not send an event to Electron.
 func CloseWindow(ctx context.Context, windowId string, fromElectron bool) error {
@@ -191,19 +233,70 @@ func CheckAndFixWindow(ctx context.Context, windowId string) *waveobj.Window {
 	return window
 }
 
+// moves the window to the front of client.WindowIds and tells electron to raise it.
+// returns wstore.ErrWindowNotFound if the window is not in the client data (no-op if it is already in front).
 func FocusWindow(ctx context.Context, windowId string) error {
 	log.Printf("FocusWindow %s\n", windowId)
-	client, err := GetClientData(ctx)
+	changed, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (bool, error) {
+		client, err := GetClientData(tx.Context())
+		if err != nil {
+			return false, err
+		}
+		winIdx := utilfn.SliceIdx(client.WindowIds, windowId)
+		if winIdx == -1 {
+			return false, fmt.Errorf("%w: %s", wstore.ErrWindowNotFound, windowId)
+		}
+		if winIdx == 0 {
+			return false, nil
+		}
+		client.WindowIds = utilfn.MoveSliceIdxToFront(client.WindowIds, winIdx)
+		log.Printf("client.WindowIds: %v\n", client.WindowIds)
+		return true, wstore.DBUpdate(tx.Context(), client)
+	})
 	if err != nil {
-		log.Printf("error getting client data: %v\n", err)
 		return err
 	}
-	winIdx := utilfn.SliceIdx(client.WindowIds, windowId)
-	if winIdx == -1 {
-		log.Printf("window %s not found in client data\n", windowId)
-		return nil
+	if changed {
+		eventbus.SendEventToElectron(eventbus.WSEventType{
+			EventType: eventbus.WSEvent_ElectronFocusWindow,
+			Data:      windowId,
+		})
 	}
-	client.WindowIds = utilfn.MoveSliceIdxToFront(client.WindowIds, winIdx)
-	log.Printf("client.WindowIds: %v\n", client.WindowIds)
-	return wstore.DBUpdate(ctx, client)
+	return nil
+}
+
+const MinWindowSize = 100
+
+// updates the window's position and size (the fields set in geom) and tells electron to move the window.
+// electron keeps the window on a visible display, and sends back the bounds it ends up with.
+func SetWindowGeometry(ctx context.Context, windowId string, geom waveobj.WindowGeometry) (*waveobj.Window, error) {
+	if (geom.Width != nil && *geom.Width < MinWindowSize) || (geom.Height != nil && *geom.Height < MinWindowSize) {
+		return nil, fmt.Errorf("window width and height must be at least %d", MinWindowSize)
+	}
+	window, err := GetWindow(ctx, windowId)
+	if err != nil {
+		return nil, err
+	}
+	if geom.X != nil {
+		window.Pos.X = *geom.X
+	}
+	if geom.Y != nil {
+		window.Pos.Y = *geom.Y
+	}
+	if geom.Width != nil {
+		window.WinSize.Width = *geom.Width
+	}
+	if geom.Height != nil {
+		window.WinSize.Height = *geom.Height
+	}
+	window.IsNew = false
+	err = wstore.DBUpdate(ctx, window)
+	if err != nil {
+		return nil, fmt.Errorf("error updating window: %w", err)
+	}
+	eventbus.SendEventToElectron(eventbus.WSEventType{
+		EventType: eventbus.WSEvent_ElectronSetWindowBounds,
+		Data:      &waveobj.WindowBoundsUpdate{WindowId: windowId, Pos: window.Pos, WinSize: window.WinSize},
+	})
+	return window, nil
 }
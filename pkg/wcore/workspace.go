@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -48,6 +49,11 @@ var WorkspaceIcons = [...]string{
 }
 
 func CreateWorkspace(ctx context.Context, name string, icon string, color string, applyDefaults bool, isInitialLaunch bool) (*waveobj.Workspace, error) {
+	return createWorkspace(ctx, name, icon, color, applyDefaults, isInitialLaunch, "", nil)
+}
+
+// tabName and layout are for the workspace's first tab (see CreateTabWithLayout)
+func createWorkspace(ctx context.Context, name string, icon string, color string, applyDefaults bool, isInitialLaunch bool, tabName string, layout PortableLayout) (*waveobj.Workspace, error) {
 	ws := &waveobj.Workspace{
 		OID:          uuid.NewString(),
 		TabIds:       []string{},
@@ -60,7 +66,7 @@ func CreateWorkspace(ctx context.Context, name string, icon string, color string
 	if err != nil {
 		return nil, fmt.Errorf("error inserting workspace: %w", err)
 	}
-	_, err = CreateTab(ctx, ws.OID, "", true, false, isInitialLaunch)
+	_, err = CreateTabWithLayout(ctx, ws.OID, tabName, true, false, isInitialLaunch, layout)
 	if err != nil {
 		return nil, fmt.Errorf("error creating tab: %w", err)
 	}
@@ -185,6 +191,49 @@ func DeleteWorkspace(ctx context.Context, workspaceId string, force bool) (bool,
 	return true, "", nil
 }
 
+var ErrWorkspaceHasTabs = fmt.Errorf("workspace still has tabs")
+
+// deletes a workspace that is not open in a window.
+// if the workspace has tabs, they are moved to moveToWorkspaceId (if set), otherwise
+// the delete fails with ErrWorkspaceHasTabs unless force is true (then the tabs and their blocks are deleted).
+func DeleteClosedWorkspace(ctx context.Context, workspaceId string, force bool, moveToWorkspaceId string) error {
+	ws, err := GetWorkspace(ctx, workspaceId)
+	if err != nil {
+		return fmt.Errorf("workspace %s not found: %w", workspaceId, err)
+	}
+	windowId, err := wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+	if err != nil {
+		return fmt.Errorf("error finding window for workspace: %w", err)
+	}
+	if windowId != "" {
+		return fmt.Errorf("workspace %s is open in window %s", workspaceId, windowId)
+	}
+	numTabs := len(ws.TabIds) + len(ws.PinnedTabIds)
+	if numTabs > 0 && moveToWorkspaceId != "" {
+		if moveToWorkspaceId == workspaceId {
+			return fmt.Errorf("cannot move tabs to the workspace being deleted")
+		}
+		targetWs, err := GetWorkspace(ctx, moveToWorkspaceId)
+		if err != nil {
+			return fmt.Errorf("workspace %s not found: %w", moveToWorkspaceId, err)
+		}
+		targetWs.TabIds = append(targetWs.TabIds, ws.TabIds...)
+		targetWs.PinnedTabIds = append(targetWs.PinnedTabIds, ws.PinnedTabIds...)
+		if targetWs.ActiveTabId == "" {
+			targetWs.ActiveTabId = ws.ActiveTabId
+		}
+		ws.TabIds = []string{}
+		ws.PinnedTabIds = []string{}
+		ws.ActiveTabId = ""
+		wstore.DBUpdate(ctx, targetWs)
+		wstore.DBUpdate(ctx, ws)
+	} else if numTabs > 0 && !force {
+		return fmt.Errorf("%w (%d tabs), use force to delete them", ErrWorkspaceHasTabs, numTabs)
+	}
+	_, _, err = DeleteWorkspace(ctx, workspaceId, true)
+	return err
+}
+
 func GetWorkspace(ctx context.Context, wsID string) (*waveobj.Workspace, error) {
 	return wstore.DBMustGet[*waveobj.Workspace](ctx, wsID)
 }
@@ -201,6 +250,12 @@ func getTabPresetMeta() (waveobj.MetaMapType, error) {
 
 // returns tabid
 func CreateTab(ctx context.Context, workspaceId string, tabName string, activateTab bool, pinned bool, isInitialLaunch bool) (string, error) {
+	return CreateTabWithLayout(ctx, workspaceId, tabName, activateTab, pinned, isInitialLaunch, nil)
+}
+
+// like CreateTab, but the tab gets the blocks in layout instead of the new tab layout (when layout is not empty,
+// it is not used for the initial launch)
+func CreateTabWithLayout(ctx context.Context, workspaceId string, tabName string, activateTab bool, pinned bool, isInitialLaunch bool, layout PortableLayout) (string, error) {
 	if tabName == "" {
 		ws, err := GetWorkspace(ctx, workspaceId)
 		if err != nil {
@@ -223,7 +278,10 @@ func CreateTab(ctx context.Context, workspaceId string, tabName string, activate
 
 	// No need to apply an initial layout for the initial launch, since the starter layout will get applied after TOS modal dismissal
 	if !isInitialLaunch {
-		err = ApplyPortableLayout(ctx, tab.OID, GetNewTabLayout())
+		if len(layout) == 0 {
+			layout = GetNewTabLayout()
+		}
+		err = ApplyPortableLayout(ctx, tab.OID, layout)
 		if err != nil {
 			return tab.OID, fmt.Errorf("error applying new tab layout: %w", err)
 		}
@@ -250,6 +308,7 @@ func createTabObj(ctx context.Context, workspaceId string, name string, pinned b
 		Name:        name,
 		BlockIds:    []string{},
 		LayoutState: layoutStateId,
+		CreatedTs:   time.Now().UnixMilli(),
 	}
 	layoutState := &waveobj.LayoutState{
 		OID: layoutStateId,
@@ -301,13 +360,12 @@ func DeleteTab(ctx context.Context, workspaceId string, tabId string, recursive
 	// if the tab is active, determine new active tab
 	newActiveTabId := ws.ActiveTabId
 	if ws.ActiveTabId == tabId {
-		if len(ws.TabIds) > 0 && tabIdx != -1 {
-			newActiveTabId = ws.TabIds[max(0, min(tabIdx-1, len(ws.TabIds)-1))]
-		} else if len(ws.PinnedTabIds) > 0 {
-			newActiveTabId = ws.PinnedTabIds[0]
-		} else {
-			newActiveTabId = ""
+		// the pinned tabs are shown first
+		visualIdx := tabIdxPinned
+		if tabIdx != -1 {
+			visualIdx = len(ws.PinnedTabIds) + tabIdx
 		}
+		newActiveTabId = getAdjacentTabId(append(slices.Clone(ws.PinnedTabIds), ws.TabIds...), visualIdx)
 	}
 	ws.ActiveTabId = newActiveTabId
 
@@ -330,6 +388,90 @@ func DeleteTab(ctx context.Context, workspaceId string, tabId string, recursive
 	return newActiveTabId, nil
 }
 
+// returns the tab that takes the place of the tab that was at removedIdx (tabIds no longer has it).
+// prefers the tab to the left, the first tab is replaced by the tab to its right.  "" if there are no tabs.
+func getAdjacentTabId(tabIds []string, removedIdx int) string {
+	if len(tabIds) == 0 {
+		return ""
+	}
+	return tabIds[max(0, min(removedIdx-1, len(tabIds)-1))]
+}
+
+// moves the tab to index (clamped) within its section (pinned tabs stay pinned)
+func MoveTab(ctx context.Context, workspaceId string, tabId string, index int) error {
+	ws, _ := wstore.DBGet[*waveobj.Workspace](ctx, workspaceId)
+	if ws == nil {
+		return fmt.Errorf("workspace not found: %q", workspaceId)
+	}
+	tabIds := &ws.TabIds
+	if utilfn.FindStringInSlice(ws.PinnedTabIds, tabId) != -1 {
+		tabIds = &ws.PinnedTabIds
+	} else if utilfn.FindStringInSlice(ws.TabIds, tabId) == -1 {
+		return fmt.Errorf("tab %s not found in workspace %s", tabId, workspaceId)
+	}
+	newTabIds := utilfn.RemoveElemFromSlice(*tabIds, tabId)
+	index = max(0, min(index, len(newTabIds)))
+	*tabIds = slices.Insert(newTabIds, index, tabId)
+	orderWorkspaceTabIds(ctx, ws)
+	wstore.DBUpdate(ctx, ws)
+	return nil
+}
+
+// keeps the tabs of a group next to each other, the groups are in the order of their first tab and the tabs keep
+// their order within a group (tabs without a group don't move relative to the groups)
+func groupTabIds(tabIds []string, groupNames map[string]string) []string {
+	var keys []string
+	groups := make(map[string][]string)
+	for _, tabId := range tabIds {
+		key := "tab:" + tabId
+		if groupName := groupNames[tabId]; groupName != "" {
+			key = "group:" + groupName
+		}
+		if _, found := groups[key]; !found {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], tabId)
+	}
+	rtn := make([]string, 0, len(tabIds))
+	for _, key := range keys {
+		rtn = append(rtn, groups[key]...)
+	}
+	return rtn
+}
+
+// groups the workspace's tabs (see groupTabIds), the pinned tabs are grouped separately (they are always first)
+func orderWorkspaceTabIds(ctx context.Context, ws *waveobj.Workspace) {
+	groupNames := make(map[string]string)
+	for _, tabId := range append(slices.Clone(ws.PinnedTabIds), ws.TabIds...) {
+		tab, _ := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+		if tab != nil && tab.GroupName != "" {
+			groupNames[tabId] = tab.GroupName
+		}
+	}
+	ws.PinnedTabIds = groupTabIds(ws.PinnedTabIds, groupNames)
+	ws.TabIds = groupTabIds(ws.TabIds, groupNames)
+}
+
+// sets the tab's group ("" removes it from its group), the tab is moved next to the group's other tabs
+func SetTabGroup(ctx context.Context, workspaceId string, tabId string, groupName string) error {
+	ws, _ := wstore.DBGet[*waveobj.Workspace](ctx, workspaceId)
+	if ws == nil {
+		return fmt.Errorf("workspace not found: %q", workspaceId)
+	}
+	if utilfn.FindStringInSlice(ws.TabIds, tabId) == -1 && utilfn.FindStringInSlice(ws.PinnedTabIds, tabId) == -1 {
+		return fmt.Errorf("tab %s not found in workspace %s", tabId, workspaceId)
+	}
+	tab, _ := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+	if tab == nil {
+		return fmt.Errorf("tab not found: %q", tabId)
+	}
+	tab.GroupName = groupName
+	wstore.DBUpdate(ctx, tab)
+	orderWorkspaceTabIds(ctx, ws)
+	wstore.DBUpdate(ctx, ws)
+	return nil
+}
+
 func SetActiveTab(ctx context.Context, workspaceId string, tabId string) error {
 	if tabId != "" && workspaceId != "" {
 		workspace, err := GetWorkspace(ctx, workspaceId)
@@ -365,6 +507,7 @@ func ChangeTabPinning(ctx context.Context, workspaceId string, tabId string, pin
 			workspace.PinnedTabIds = utilfn.RemoveElemFromSlice(workspace.PinnedTabIds, tabId)
 			workspace.TabIds = append([]string{tabId}, workspace.TabIds...)
 		}
+		orderWorkspaceTabIds(ctx, workspace)
 		wstore.DBUpdate(ctx, workspace)
 	}
 	return nil
@@ -382,12 +525,30 @@ func UpdateWorkspaceTabIds(ctx context.Context, workspaceId string, tabIds []str
 	if ws == nil {
 		return fmt.Errorf("workspace not found: %q", workspaceId)
 	}
+	pinningChanged := tabPinningChanged(ws.PinnedTabIds, pinnedTabIds)
 	ws.TabIds = tabIds
 	ws.PinnedTabIds = pinnedTabIds
+	if pinningChanged {
+		// a plain reorder (a dragged tab) is kept as is, the groups are only fixed up when tabs are pinned/unpinned
+		orderWorkspaceTabIds(ctx, ws)
+	}
 	wstore.DBUpdate(ctx, ws)
 	return nil
 }
 
+// true if a tab was pinned or unpinned (the order of the pinned tabs doesn't matter)
+func tabPinningChanged(oldPinnedTabIds []string, newPinnedTabIds []string) bool {
+	if len(oldPinnedTabIds) != len(newPinnedTabIds) {
+		return true
+	}
+	for _, tabId := range newPinnedTabIds {
+		if utilfn.FindStringInSlice(oldPinnedTabIds, tabId) == -1 {
+			return true
+		}
+	}
+	return false
+}
+
 func ListWorkspaces(ctx context.Context) (waveobj.WorkspaceList, error) {
 	workspaces, err := wstore.DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
 	if err != nil {
@@ -0,0 +1,59 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wcore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetAdjacentTabId(t *testing.T) {
+	tests := []struct {
+		name       string
+		tabIds     []string
+		removedIdx int
+		expected   string
+	}{
+		{"middle prefers left", []string{"a", "c"}, 1, "a"},
+		{"last prefers left", []string{"a", "b"}, 2, "b"},
+		{"first uses right", []string{"b", "c"}, 0, "b"},
+		{"only tab", nil, 0, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rtn := getAdjacentTabId(tc.tabIds, tc.removedIdx)
+			if rtn != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, rtn)
+			}
+		})
+	}
+}
+
+func TestGroupTabIds(t *testing.T) {
+	groupNames := map[string]string{"a1": "a", "a2": "a", "a3": "a", "b1": "b", "b2": "b"}
+	tabIds := []string{"x", "a1", "b1", "y", "a2", "b2", "a3", "z"}
+	expected := []string{"x", "a1", "a2", "a3", "b1", "b2", "y", "z"}
+	rtn := groupTabIds(tabIds, groupNames)
+	if strings.Join(rtn, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, rtn)
+	}
+}
+
+func TestTabPinningChanged(t *testing.T) {
+	tests := []struct {
+		oldIds   []string
+		newIds   []string
+		expected bool
+	}{
+		{oldIds: []string{"a", "b"}, newIds: []string{"b", "a"}, expected: false},
+		{oldIds: []string{"a"}, newIds: []string{"a", "b"}, expected: true},
+		{oldIds: []string{"a", "b"}, newIds: []string{"a", "c"}, expected: true},
+		{oldIds: nil, newIds: []string{}, expected: false},
+	}
+	for _, tc := range tests {
+		if rtn := tabPinningChanged(tc.oldIds, tc.newIds); rtn != tc.expected {
+			t.Errorf("%v -> %v: expected %v, got %v", tc.oldIds, tc.newIds, tc.expected, rtn)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+// leveled, structured logging for the backend.  each subsystem gets a Logger (wlog.New("wstore")) and logs a message
+// with key/value fields.  records at or above the subsystem's level are written to the regular log (log.Print), and
+// every record that a subscriber wants (see Subscribe, used by wsh log tail) is sent to it, whatever the levels are.
+// records logged with a ctx from an rpc request (see ContextWithReqId) include the request id.
+package wlog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+const DefaultLevel = LevelInfo
+
+// the key for the default level in SetLevels (the level of subsystems that don't have their own)
+const DefaultSubsystem = "*"
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+func ParseLevel(levelStr string) (Level, error) {
+	levelStr = strings.ToLower(strings.TrimSpace(levelStr))
+	if levelStr == "warning" {
+		return LevelWarn, nil
+	}
+	idx := slices.Index(levelNames, levelStr)
+	if idx < 0 {
+		return 0, fmt.Errorf("invalid log level %q (must be one of %s)", levelStr, strings.Join(levelNames, ", "))
+	}
+	return Level(idx), nil
+}
+
+type LogField struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type LogRecord struct {
+	Ts        int64      `json:"ts"` // unix ms
+	Level     string     `json:"level"`
+	Subsystem string     `json:"subsystem"`
+	Msg       string     `json:"msg"`
+	ReqId     string     `json:"reqid,omitempty"` // the rpc request that the work was done for
+	Fields    []LogField `json:"fields,omitempty"`
+}
+
+// "INFO wstore: msg key=val reqid=..." (without the time)
+func (rec LogRecord) String() string {
+	var buf strings.Builder
+	buf.WriteString(strings.ToUpper(rec.Level))
+	buf.WriteString(" ")
+	buf.WriteString(rec.Subsystem)
+	buf.WriteString(": ")
+	buf.WriteString(rec.Msg)
+	for _, field := range rec.Fields {
+		buf.WriteString(" ")
+		buf.WriteString(field.Key)
+		buf.WriteString("=")
+		buf.WriteString(quoteValue(field.Value))
+	}
+	if rec.ReqId != "" {
+		buf.WriteString(" reqid=")
+		buf.WriteString(rec.ReqId)
+	}
+	return buf.String()
+}
+
+func quoteValue(val string) string {
+	if val == "" || strings.ContainsAny(val, " \t\r\n\"=") {
+		return strconv.Quote(val)
+	}
+	return val
+}
+
+func formatValue(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// kv is key, value, key, value, ... (a key without a value, or a key that isn't a string, is logged as !BADKEY)
+func makeFields(kv []any) []LogField {
+	if len(kv) == 0 {
+		return nil
+	}
+	rtn := make([]LogField, 0, (len(kv)+1)/2)
+	for len(kv) > 0 {
+		key, ok := kv[0].(string)
+		if !ok || len(kv) == 1 {
+			rtn = append(rtn, LogField{Key: "!BADKEY", Value: formatValue(kv[0])})
+			kv = kv[1:]
+			continue
+		}
+		rtn = append(rtn, LogField{Key: key, Value: formatValue(kv[1])})
+		kv = kv[2:]
+	}
+	return rtn
+}
+
+type reqIdContextKey struct{}
+
+// the request id is added to the records logged with ctx (and the contexts made from it)
+func ContextWithReqId(ctx context.Context, reqId string) context.Context {
+	if reqId == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, reqIdContextKey{}, reqId)
+}
+
+func GetReqIdFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	reqId, _ := ctx.Value(reqIdContextKey{}).(string)
+	return reqId
+}
+
+type levelsType struct {
+	Default    Level
+	Subsystems map[string]Level
+}
+
+var levels atomic.Pointer[levelsType]
+
+func init() {
+	levels.Store(&levelsType{Default: DefaultLevel})
+}
+
+func GetLevel(subsystem string) Level {
+	curLevels := levels.Load()
+	if level, ok := curLevels.Subsystems[subsystem]; ok {
+		return level
+	}
+	return curLevels.Default
+}
+
+// replaces the levels (subsystem => level name, DefaultSubsystem sets the default).  invalid levels are skipped and
+// returned as an error.
+func SetLevels(levelMap map[string]string) error {
+	newLevels := &levelsType{Default: DefaultLevel, Subsystems: make(map[string]Level)}
+	var errs []string
+	for subsystem, levelStr := range levelMap {
+		level, err := ParseLevel(levelStr)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", subsystem, err))
+			continue
+		}
+		if subsystem == DefaultSubsystem {
+			newLevels.Default = level
+		} else {
+			newLevels.Subsystems[subsystem] = level
+		}
+	}
+	levels.Store(newLevels)
+	if len(errs) > 0 {
+		slices.Sort(errs)
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+type Logger struct {
+	subsystem string
+}
+
+func New(subsystem string) *Logger {
+	return &Logger{subsystem: subsystem}
+}
+
+func (l *Logger) Subsystem() string {
+	return l.subsystem
+}
+
+func (l *Logger) Debug(ctx context.Context, msg string, kv ...any) {
+	l.logRecord(ctx, LevelDebug, msg, kv)
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, kv ...any) {
+	l.logRecord(ctx, LevelInfo, msg, kv)
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, kv ...any) {
+	l.logRecord(ctx, LevelWarn, msg, kv)
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, kv ...any) {
+	l.logRecord(ctx, LevelError, msg, kv)
+}
+
+// true if a record at level would be written (or sent to a subscriber), to skip expensive fields
+func (l *Logger) Enabled(level Level) bool {
+	return level >= GetLevel(l.subsystem) || level >= Level(minSubLevel.Load())
+}
+
+func (l *Logger) logRecord(ctx context.Context, level Level, msg string, kv []any) {
+	writeLog := level >= GetLevel(l.subsystem)
+	if !writeLog && level < Level(minSubLevel.Load()) {
+		return
+	}
+	rec := LogRecord{
+		Ts:        time.Now().UnixMilli(),
+		Level:     level.String(),
+		Subsystem: l.subsystem,
+		Msg:       msg,
+		ReqId:     GetReqIdFromContext(ctx),
+		Fields:    makeFields(kv),
+	}
+	if writeLog {
+		log.Print(rec.String() + "\n")
+	}
+	publishRecord(level, rec)
+}
@@ -0,0 +1,103 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wlog
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+const SubscriberChSize = 256
+
+type SubscribeOpts struct {
+	Level      Level
+	Subsystems []string // empty for all
+	ReqId      string   // only the records for this request
+}
+
+type Subscription struct {
+	opts    SubscribeOpts
+	ch      chan LogRecord
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+var subLock = &sync.Mutex{}
+var subscribers = make(map[*Subscription]bool)
+
+// the lowest level a subscriber wants (above LevelError when there are no subscribers), so records below the log
+// level are only made when someone is listening
+var minSubLevel atomic.Int32
+
+func init() {
+	minSubLevel.Store(int32(LevelError + 1))
+}
+
+func updateMinSubLevel() {
+	minLevel := LevelError + 1
+	for sub := range subscribers {
+		minLevel = min(minLevel, sub.opts.Level)
+	}
+	minSubLevel.Store(int32(minLevel))
+}
+
+func (sub *Subscription) wants(level Level, rec LogRecord) bool {
+	if level < sub.opts.Level {
+		return false
+	}
+	if len(sub.opts.Subsystems) > 0 && !slices.Contains(sub.opts.Subsystems, rec.Subsystem) {
+		return false
+	}
+	return sub.opts.ReqId == "" || sub.opts.ReqId == rec.ReqId
+}
+
+// the records that match opts (from now on) are sent to the subscription's channel.  records are dropped (not
+// waited for) when the channel is full, see Dropped.  call Close when done.
+func Subscribe(opts SubscribeOpts) *Subscription {
+	sub := &Subscription{opts: opts, ch: make(chan LogRecord, SubscriberChSize)}
+	subLock.Lock()
+	defer subLock.Unlock()
+	subscribers[sub] = true
+	updateMinSubLevel()
+	return sub
+}
+
+func (sub *Subscription) Ch() <-chan LogRecord {
+	return sub.ch
+}
+
+// the number of records dropped so far
+func (sub *Subscription) Dropped() int64 {
+	return sub.dropped.Load()
+}
+
+// unsubscribes and closes the channel
+func (sub *Subscription) Close() {
+	sub.once.Do(func() {
+		subLock.Lock()
+		defer subLock.Unlock()
+		delete(subscribers, sub)
+		updateMinSubLevel()
+		close(sub.ch)
+	})
+}
+
+func publishRecord(level Level, rec LogRecord) {
+	if level < Level(minSubLevel.Load()) {
+		return
+	}
+	subLock.Lock()
+	defer subLock.Unlock()
+	for sub := range subscribers {
+		if !sub.wants(level, rec) {
+			continue
+		}
+		select {
+		case sub.ch <- rec:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	origFlags := log.Flags()
+	origWriter := log.Writer()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(origWriter)
+		log.SetFlags(origFlags)
+		SetLevels(nil)
+	})
+	return &buf
+}
+
+func TestLogRecord(t *testing.T) {
+	buf := captureLog(t)
+	logger := New("wstore")
+	ctx := ContextWithReqId(context.Background(), "req1")
+	logger.Info(ctx, "slow db call", "took", "2s", "query", "SELECT 1", "err", errors.New("bad"), "dangling")
+	logger.Debug(ctx, "hidden")
+	expected := `INFO wstore: slow db call took=2s query="SELECT 1" err=bad !BADKEY=dangling reqid=req1` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestSetLevels(t *testing.T) {
+	buf := captureLog(t)
+	err := SetLevels(map[string]string{"wstore": "debug", DefaultSubsystem: "warn", "eventbus": "loud"})
+	if err == nil || !strings.Contains(err.Error(), "eventbus") {
+		t.Errorf("expected an error for the invalid level, got %v", err)
+	}
+	New("wstore").Debug(context.Background(), "a")
+	New("eventbus").Info(context.Background(), "b")
+	New("rpc").Warn(context.Background(), "c")
+	if buf.String() != "DEBUG wstore: a\nWARN rpc: c\n" {
+		t.Errorf("unexpected log output %q", buf.String())
+	}
+	if level, err := ParseLevel("WARNING"); err != nil || level != LevelWarn {
+		t.Errorf("expected warning to parse as warn, got %v (%v)", level, err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	buf := captureLog(t)
+	sub := Subscribe(SubscribeOpts{Level: LevelDebug, Subsystems: []string{"wstore"}})
+	reqSub := Subscribe(SubscribeOpts{Level: LevelDebug, ReqId: "req1"})
+	ctx := ContextWithReqId(context.Background(), "req1")
+	New("wstore").Debug(context.Background(), "a")
+	New("rpc").Debug(ctx, "b")
+	New("eventbus").Info(context.Background(), "c")
+	sub.Close()
+	reqSub.Close()
+	var msgs []string
+	for rec := range sub.Ch() {
+		msgs = append(msgs, rec.Msg)
+	}
+	for rec := range reqSub.Ch() {
+		msgs = append(msgs, rec.Msg)
+	}
+	if strings.Join(msgs, ",") != "a,b" {
+		t.Errorf("expected the subscribers to get a and b, got %v", msgs)
+	}
+	// the debug records are sent to the subscribers, but not written to the log
+	if buf.String() != "INFO eventbus: c\n" {
+		t.Errorf("unexpected log output %q", buf.String())
+	}
+	if New("wstore").Enabled(LevelDebug) {
+		t.Errorf("expected debug to be disabled after the subscribers are closed")
+	}
+}
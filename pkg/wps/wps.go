@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 )
@@ -233,6 +234,7 @@ func (b *BrokerType) Publish(event WaveEvent) {
 	if event.Persist > 0 {
 		b.persistEvent(event)
 	}
+	eventbus.SendEventToListeners(eventbus.WSEventType{EventType: event.Event, Scopes: event.Scopes, Data: event.Data})
 	client := b.GetClient()
 	if client == nil {
 		return
@@ -250,7 +252,26 @@ func (b *BrokerType) SendUpdateEvents(updates waveobj.UpdatesRtnType) {
 			Scopes: []string{waveobj.MakeORef(update.OType, update.OID).String()},
 			Data:   update,
 		})
+		if update.OType == waveobj.OType_Block {
+			sendBlockUpdateToListeners(update)
+		}
+	}
+}
+
+func sendBlockUpdateToListeners(update waveobj.WaveObjUpdate) {
+	if !eventbus.HasListeners() {
+		return
+	}
+	var scopes []string
+	if block, ok := update.Obj.(*waveobj.Block); ok && block.ParentORef != "" {
+		scopes = append(scopes, block.ParentORef)
 	}
+	eventbus.SendEventToListeners(eventbus.WSEventType{
+		EventType: eventbus.WSEvent_BlockUpdate,
+		ORef:      waveobj.MakeORef(update.OType, update.OID).String(),
+		Scopes:    scopes,
+		Data:      update,
+	})
 }
 
 func (b *BrokerType) getMatchingRouteIds(event WaveEvent) []string {
@@ -7,12 +7,18 @@ const (
 	Event_ConnChange       = "connchange"
 	Event_SysInfo          = "sysinfo"
 	Event_ControllerStatus = "controllerstatus"
+	Event_ControllerExit   = "controllerexit"
 	Event_WaveObjUpdate    = "waveobj:update"
 	Event_BlockFile        = "blockfile"
-	Event_Config           = "config"
 	Event_UserInput        = "userinput"
 	Event_RouteGone        = "route:gone"
 	Event_WorkspaceUpdate  = "workspace:update"
+	Event_SessionRestore   = "sessionrestore"   // data is wshrpc.SessionRestoreStatus (persisted)
+	Event_ConfigValidation = "configvalidation" // scoped by the saved file's path, data is wconfig.ConfigValidationEventData
+	Event_DirChange        = "dirchange"        // scoped by "connection:dir", data is wshrpc.DirChangeData
+	Event_BlockRefresh     = "blockrefresh"     // scoped by the block oref, data is wshrpc.BlockRefreshData (see filewatch)
+	Event_WebNavBlocked    = "webnavblocked"    // scoped by the block oref, data is wshrpc.WebNavBlockedData
+	Event_HostKeyChanged   = "hostkeychanged"   // scoped by "connection:<name>", data is wshrpc.HostKeyChangedData
 )
 
 type WaveEvent struct {
@@ -39,6 +45,7 @@ const (
 	FileOp_Append     = "append"
 	FileOp_Truncate   = "truncate"
 	FileOp_Invalidate = "invalidate"
+	FileOp_Reset      = "reset" // the start of the file moved (its head was trimmed), the end is unchanged
 )
 
 type WSFileEventData struct {
@@ -47,3 +54,8 @@ type WSFileEventData struct {
 	FileOp   string `json:"fileop"`
 	Data64   string `json:"data64"`
 }
+
+type ControllerExitEventData struct {
+	BlockId  string `json:"blockid"`
+	ExitCode int    `json:"exitcode"`
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/vdom"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
 )
 
 // command "activity", wshserver.ActivityCommand
@@ -25,24 +27,63 @@ func AiSendMessageCommand(w *wshutil.WshRpc, data wshrpc.AiMessageData, opts *ws
 	return err
 }
 
+// command "aistreammessage", wshserver.AiStreamMessageCommand
+func AiStreamMessageCommand(w *wshutil.WshRpc, data wshrpc.AiMessageData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.WaveAIPacketType] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.WaveAIPacketType](w, "aistreammessage", data, opts)
+}
+
+// command "applylayoutpreset", wshserver.ApplyLayoutPresetCommand
+func ApplyLayoutPresetCommand(w *wshutil.WshRpc, data wshrpc.CommandLayoutPresetData, opts *wshrpc.RpcOpts) ([]string, error) {
+	resp, err := sendRpcRequestCallHelper[[]string](w, "applylayoutpreset", data, opts)
+	return resp, err
+}
+
 // command "authenticate", wshserver.AuthenticateCommand
 func AuthenticateCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (wshrpc.CommandAuthenticateRtnData, error) {
 	resp, err := sendRpcRequestCallHelper[wshrpc.CommandAuthenticateRtnData](w, "authenticate", data, opts)
 	return resp, err
 }
 
+// command "authpromptresponse", wshserver.AuthPromptResponseCommand
+func AuthPromptResponseCommand(w *wshutil.WshRpc, data wshrpc.CommandAuthPromptResponseData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "authpromptresponse", data, opts)
+	return err
+}
+
 // command "blockinfo", wshserver.BlockInfoCommand
 func BlockInfoCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (*wshrpc.BlockInfoData, error) {
 	resp, err := sendRpcRequestCallHelper[*wshrpc.BlockInfoData](w, "blockinfo", data, opts)
 	return resp, err
 }
 
+// command "captureblock", wshserver.CaptureBlockCommand
+func CaptureBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandCaptureBlockData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[string] {
+	return sendRpcRequestResponseStreamHelper[string](w, "captureblock", data, opts)
+}
+
+// command "closetab", wshserver.CloseTabCommand
+func CloseTabCommand(w *wshutil.WshRpc, data wshrpc.CommandCloseTabData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "closetab", data, opts)
+	return err
+}
+
+// command "closewindow", wshserver.CloseWindowCommand
+func CloseWindowCommand(w *wshutil.WshRpc, data wshrpc.CommandCloseWindowData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "closewindow", data, opts)
+	return err
+}
+
 // command "connconnect", wshserver.ConnConnectCommand
 func ConnConnectCommand(w *wshutil.WshRpc, data wshrpc.ConnRequest, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "connconnect", data, opts)
 	return err
 }
 
+// command "connconnectinteractive", wshserver.ConnConnectInteractiveCommand
+func ConnConnectInteractiveCommand(w *wshutil.WshRpc, data wshrpc.CommandConnConnectInteractiveData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.ConnAuthPrompt] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.ConnAuthPrompt](w, "connconnectinteractive", data, opts)
+}
+
 // command "conndisconnect", wshserver.ConnDisconnectCommand
 func ConnDisconnectCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "conndisconnect", data, opts)
@@ -61,6 +102,12 @@ func ConnListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]string, error)
 	return resp, err
 }
 
+// command "connliststatus", wshserver.ConnListStatusCommand
+func ConnListStatusCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.ConnStatus, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ConnStatus](w, "connliststatus", nil, opts)
+	return resp, err
+}
+
 // command "connreinstallwsh", wshserver.ConnReinstallWshCommand
 func ConnReinstallWshCommand(w *wshutil.WshRpc, data wshrpc.ConnExtData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "connreinstallwsh", data, opts)
@@ -73,6 +120,18 @@ func ConnStatusCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.ConnSt
 	return resp, err
 }
 
+// command "conntest", wshserver.ConnTestCommand
+func ConnTestCommand(w *wshutil.WshRpc, data wshrpc.CommandConnTestData, opts *wshrpc.RpcOpts) (wshrpc.CommandConnTestRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CommandConnTestRtnData](w, "conntest", data, opts)
+	return resp, err
+}
+
+// command "conntrusthostkey", wshserver.ConnTrustHostKeyCommand
+func ConnTrustHostKeyCommand(w *wshutil.WshRpc, data wshrpc.CommandConnTrustHostKeyData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "conntrusthostkey", data, opts)
+	return err
+}
+
 // command "connupdatewsh", wshserver.ConnUpdateWshCommand
 func ConnUpdateWshCommand(w *wshutil.WshRpc, data wshrpc.RemoteInfo, opts *wshrpc.RpcOpts) (bool, error) {
 	resp, err := sendRpcRequestCallHelper[bool](w, "connupdatewsh", data, opts)
@@ -91,6 +150,12 @@ func ControllerInputCommand(w *wshutil.WshRpc, data wshrpc.CommandBlockInputData
 	return err
 }
 
+// command "controllerrestart", wshserver.ControllerRestartCommand
+func ControllerRestartCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerRestartData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "controllerrestart", data, opts)
+	return err
+}
+
 // command "controllerresync", wshserver.ControllerResyncCommand
 func ControllerResyncCommand(w *wshutil.WshRpc, data wshrpc.CommandControllerResyncData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "controllerresync", data, opts)
@@ -104,8 +169,14 @@ func ControllerStopCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts)
 }
 
 // command "createblock", wshserver.CreateBlockCommand
-func CreateBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandCreateBlockData, opts *wshrpc.RpcOpts) (waveobj.ORef, error) {
-	resp, err := sendRpcRequestCallHelper[waveobj.ORef](w, "createblock", data, opts)
+func CreateBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandCreateBlockData, opts *wshrpc.RpcOpts) (wshrpc.CommandCreateBlockRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CommandCreateBlockRtnData](w, "createblock", data, opts)
+	return resp, err
+}
+
+// command "createblocks", wshserver.CreateBlocksCommand
+func CreateBlocksCommand(w *wshutil.WshRpc, data wshrpc.CommandCreateBlocksData, opts *wshrpc.RpcOpts) ([]string, error) {
+	resp, err := sendRpcRequestCallHelper[[]string](w, "createblocks", data, opts)
 	return resp, err
 }
 
@@ -115,12 +186,54 @@ func CreateSubBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandCreateSubBlockD
 	return resp, err
 }
 
+// command "createtab", wshserver.CreateTabCommand
+func CreateTabCommand(w *wshutil.WshRpc, data wshrpc.CommandCreateTabData, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "createtab", data, opts)
+	return resp, err
+}
+
+// command "debugcachestats", wshserver.DebugCacheStatsCommand
+func DebugCacheStatsCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (wshrpc.DebugCacheStatsData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.DebugCacheStatsData](w, "debugcachestats", nil, opts)
+	return resp, err
+}
+
+// command "debugdbversion", wshserver.DebugDBVersionCommand
+func DebugDBVersionCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (*wshrpc.DebugDBVersionRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.DebugDBVersionRtnData](w, "debugdbversion", nil, opts)
+	return resp, err
+}
+
+// command "debugintegrity", wshserver.DebugIntegrityCommand
+func DebugIntegrityCommand(w *wshutil.WshRpc, data wshrpc.CommandDebugIntegrityData, opts *wshrpc.RpcOpts) (*wshrpc.DebugIntegrityRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.DebugIntegrityRtnData](w, "debugintegrity", data, opts)
+	return resp, err
+}
+
 // command "deleteblock", wshserver.DeleteBlockCommand
-func DeleteBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandDeleteBlockData, opts *wshrpc.RpcOpts) error {
-	_, err := sendRpcRequestCallHelper[any](w, "deleteblock", data, opts)
+func DeleteBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandDeleteBlockData, opts *wshrpc.RpcOpts) (*wshrpc.CommandDeleteBlockRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.CommandDeleteBlockRtnData](w, "deleteblock", data, opts)
+	return resp, err
+}
+
+// command "deleteblocks", wshserver.DeleteBlocksCommand
+func DeleteBlocksCommand(w *wshutil.WshRpc, data wshrpc.CommandDeleteBlocksData, opts *wshrpc.RpcOpts) (*wshrpc.DeleteBlocksRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.DeleteBlocksRtnData](w, "deleteblocks", data, opts)
+	return resp, err
+}
+
+// command "deletelayoutpreset", wshserver.DeleteLayoutPresetCommand
+func DeleteLayoutPresetCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "deletelayoutpreset", data, opts)
 	return err
 }
 
+// command "deletesecret", wshserver.DeleteSecretCommand
+func DeleteSecretCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (bool, error) {
+	resp, err := sendRpcRequestCallHelper[bool](w, "deletesecret", data, opts)
+	return resp, err
+}
+
 // command "deletesubblock", wshserver.DeleteSubBlockCommand
 func DeleteSubBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandDeleteBlockData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "deletesubblock", data, opts)
@@ -139,6 +252,12 @@ func DisposeCommand(w *wshutil.WshRpc, data wshrpc.CommandDisposeData, opts *wsh
 	return err
 }
 
+// command "duplicateblock", wshserver.DuplicateBlockCommand
+func DuplicateBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandDuplicateBlockData, opts *wshrpc.RpcOpts) (waveobj.ORef, error) {
+	resp, err := sendRpcRequestCallHelper[waveobj.ORef](w, "duplicateblock", data, opts)
+	return resp, err
+}
+
 // command "eventpublish", wshserver.EventPublishCommand
 func EventPublishCommand(w *wshutil.WshRpc, data wps.WaveEvent, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "eventpublish", data, opts)
@@ -175,6 +294,11 @@ func EventUnsubAllCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
 	return err
 }
 
+// command "exportaiconversation", wshserver.ExportAIConversationCommand
+func ExportAIConversationCommand(w *wshutil.WshRpc, data wshrpc.CommandExportAIConversationData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[string] {
+	return sendRpcRequestResponseStreamHelper[string](w, "exportaiconversation", data, opts)
+}
+
 // command "fileappend", wshserver.FileAppendCommand
 func FileAppendCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "fileappend", data, opts)
@@ -211,30 +335,130 @@ func FileListCommand(w *wshutil.WshRpc, data wshrpc.CommandFileListData, opts *w
 	return resp, err
 }
 
+// command "filemkdir", wshserver.FileMkdirCommand
+func FileMkdirCommand(w *wshutil.WshRpc, data wshrpc.CommandFileOpData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "filemkdir", data, opts)
+	return err
+}
+
 // command "fileread", wshserver.FileReadCommand
 func FileReadCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrpc.RpcOpts) (string, error) {
 	resp, err := sendRpcRequestCallHelper[string](w, "fileread", data, opts)
 	return resp, err
 }
 
+// command "fileremove", wshserver.FileRemoveCommand
+func FileRemoveCommand(w *wshutil.WshRpc, data wshrpc.CommandFileOpData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "fileremove", data, opts)
+	return err
+}
+
+// command "filerename", wshserver.FileRenameCommand
+func FileRenameCommand(w *wshutil.WshRpc, data wshrpc.CommandFileOpData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "filerename", data, opts)
+	return err
+}
+
+// command "filesave", wshserver.FileSaveCommand
+func FileSaveCommand(w *wshutil.WshRpc, data wshrpc.CommandFileSaveData, opts *wshrpc.RpcOpts) (*wshrpc.FileVersion, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.FileVersion](w, "filesave", data, opts)
+	return resp, err
+}
+
+// command "filetouch", wshserver.FileTouchCommand
+func FileTouchCommand(w *wshutil.WshRpc, data wshrpc.CommandFileOpData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "filetouch", data, opts)
+	return err
+}
+
+// command "filetransfer", wshserver.FileTransferCommand
+func FileTransferCommand(w *wshutil.WshRpc, data wshrpc.CommandFileTransferData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.FileTransferProgress] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.FileTransferProgress](w, "filetransfer", data, opts)
+}
+
 // command "filewrite", wshserver.FileWriteCommand
 func FileWriteCommand(w *wshutil.WshRpc, data wshrpc.CommandFileData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "filewrite", data, opts)
 	return err
 }
 
+// command "focusblock", wshserver.FocusBlockCommand
+func FocusBlockCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "focusblock", data, opts)
+	return err
+}
+
+// command "focustab", wshserver.FocusTabCommand
+func FocusTabCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "focustab", data, opts)
+	return err
+}
+
 // command "focuswindow", wshserver.FocusWindowCommand
 func FocusWindowCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "focuswindow", data, opts)
 	return err
 }
 
+// command "getactivitystats", wshserver.GetActivityStatsCommand
+func GetActivityStatsCommand(w *wshutil.WshRpc, data int, opts *wshrpc.RpcOpts) ([]wshrpc.ActivityStat, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.ActivityStat](w, "getactivitystats", data, opts)
+	return resp, err
+}
+
+// command "getcmdhistoryforblock", wshserver.GetCmdHistoryForBlockCommand
+func GetCmdHistoryForBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandGetCmdHistoryData, opts *wshrpc.RpcOpts) ([]wshrpc.CmdRecord, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.CmdRecord](w, "getcmdhistoryforblock", data, opts)
+	return resp, err
+}
+
+// command "getconfigpath", wshserver.GetConfigPathCommand
+func GetConfigPathCommand(w *wshutil.WshRpc, data wshrpc.CommandConfigPathData, opts *wshrpc.RpcOpts) (*wshrpc.ConfigPathRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ConfigPathRtnData](w, "getconfigpath", data, opts)
+	return resp, err
+}
+
+// command "getfocusedblock", wshserver.GetFocusedBlockCommand
+func GetFocusedBlockCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "getfocusedblock", data, opts)
+	return resp, err
+}
+
 // command "getmeta", wshserver.GetMetaCommand
 func GetMetaCommand(w *wshutil.WshRpc, data wshrpc.CommandGetMetaData, opts *wshrpc.RpcOpts) (waveobj.MetaMapType, error) {
 	resp, err := sendRpcRequestCallHelper[waveobj.MetaMapType](w, "getmeta", data, opts)
 	return resp, err
 }
 
+// command "getmetrics", wshserver.GetMetricsCommand
+func GetMetricsCommand(w *wshutil.WshRpc, data wshrpc.CommandGetMetricsData, opts *wshrpc.RpcOpts) ([]wshrpc.TimeSeriesData, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.TimeSeriesData](w, "getmetrics", data, opts)
+	return resp, err
+}
+
+// command "getscrollback", wshserver.GetScrollbackCommand
+func GetScrollbackCommand(w *wshutil.WshRpc, data wshrpc.CommandGetScrollbackData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.CommandGetScrollbackRtnData] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.CommandGetScrollbackRtnData](w, "getscrollback", data, opts)
+}
+
+// command "getsecretnames", wshserver.GetSecretNamesCommand
+func GetSecretNamesCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]string, error) {
+	resp, err := sendRpcRequestCallHelper[[]string](w, "getsecretnames", nil, opts)
+	return resp, err
+}
+
+// command "getserverstatus", wshserver.GetServerStatusCommand
+func GetServerStatusCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (*wshrpc.ServerStatusData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ServerStatusData](w, "getserverstatus", nil, opts)
+	return resp, err
+}
+
+// command "gettabtitle", wshserver.GetTabTitleCommand
+func GetTabTitleCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "gettabtitle", data, opts)
+	return resp, err
+}
+
 // command "getupdatechannel", wshserver.GetUpdateChannelCommand
 func GetUpdateChannelCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (string, error) {
 	resp, err := sendRpcRequestCallHelper[string](w, "getupdatechannel", nil, opts)
@@ -247,12 +471,89 @@ func GetVarCommand(w *wshutil.WshRpc, data wshrpc.CommandVarData, opts *wshrpc.R
 	return resp, err
 }
 
+// command "importhistory", wshserver.ImportHistoryCommand
+func ImportHistoryCommand(w *wshutil.WshRpc, data wshrpc.CommandImportHistoryData, opts *wshrpc.RpcOpts) (int, error) {
+	resp, err := sendRpcRequestCallHelper[int](w, "importhistory", data, opts)
+	return resp, err
+}
+
+// command "injectpath", wshserver.InjectPathCommand
+func InjectPathCommand(w *wshutil.WshRpc, data wshrpc.CommandInjectPathData, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "injectpath", data, opts)
+	return resp, err
+}
+
+// command "launchwindow", wshserver.LaunchWindowCommand
+func LaunchWindowCommand(w *wshutil.WshRpc, data wshrpc.CommandLaunchWindowData, opts *wshrpc.RpcOpts) (*wshrpc.LaunchWindowRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.LaunchWindowRtnData](w, "launchwindow", data, opts)
+	return resp, err
+}
+
+// command "layoutaction", wshserver.LayoutActionCommand
+func LayoutActionCommand(w *wshutil.WshRpc, data wshrpc.CommandLayoutActionData, opts *wshrpc.RpcOpts) (*wshrpc.CommandLayoutActionRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.CommandLayoutActionRtnData](w, "layoutaction", data, opts)
+	return resp, err
+}
+
+// command "linkblocks", wshserver.LinkBlocksCommand
+func LinkBlocksCommand(w *wshutil.WshRpc, data wshrpc.CommandLinkBlocksData, opts *wshrpc.RpcOpts) (*waveobj.BlockLink, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.BlockLink](w, "linkblocks", data, opts)
+	return resp, err
+}
+
+// command "listblocklinks", wshserver.ListBlockLinksCommand
+func ListBlockLinksCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) ([]*waveobj.BlockLink, error) {
+	resp, err := sendRpcRequestCallHelper[[]*waveobj.BlockLink](w, "listblocklinks", data, opts)
+	return resp, err
+}
+
+// command "listblocks", wshserver.ListBlocksCommand
+func ListBlocksCommand(w *wshutil.WshRpc, data wshrpc.CommandListData, opts *wshrpc.RpcOpts) ([]wshrpc.BlockListEntry, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.BlockListEntry](w, "listblocks", data, opts)
+	return resp, err
+}
+
+// command "listlayoutpresets", wshserver.ListLayoutPresetsCommand
+func ListLayoutPresetsCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]waveobj.LayoutPreset, error) {
+	resp, err := sendRpcRequestCallHelper[[]waveobj.LayoutPreset](w, "listlayoutpresets", nil, opts)
+	return resp, err
+}
+
+// command "listtabs", wshserver.ListTabsCommand
+func ListTabsCommand(w *wshutil.WshRpc, data wshrpc.CommandListData, opts *wshrpc.RpcOpts) ([]wshrpc.TabListEntry, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.TabListEntry](w, "listtabs", data, opts)
+	return resp, err
+}
+
+// command "listwindows", wshserver.ListWindowsCommand
+func ListWindowsCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.WindowListEntry, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.WindowListEntry](w, "listwindows", nil, opts)
+	return resp, err
+}
+
+// command "logtail", wshserver.LogTailCommand
+func LogTailCommand(w *wshutil.WshRpc, data wshrpc.CommandLogTailData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wlog.LogRecord] {
+	return sendRpcRequestResponseStreamHelper[wlog.LogRecord](w, "logtail", data, opts)
+}
+
 // command "message", wshserver.MessageCommand
 func MessageCommand(w *wshutil.WshRpc, data wshrpc.CommandMessageData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "message", data, opts)
 	return err
 }
 
+// command "moveblock", wshserver.MoveBlockCommand
+func MoveBlockCommand(w *wshutil.WshRpc, data wshrpc.CommandMoveBlockData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "moveblock", data, opts)
+	return err
+}
+
+// command "movetab", wshserver.MoveTabCommand
+func MoveTabCommand(w *wshutil.WshRpc, data wshrpc.CommandMoveTabData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "movetab", data, opts)
+	return err
+}
+
 // command "notify", wshserver.NotifyCommand
 func NotifyCommand(w *wshutil.WshRpc, data wshrpc.WaveNotificationOptions, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "notify", data, opts)
@@ -265,6 +566,47 @@ func PathCommand(w *wshutil.WshRpc, data wshrpc.PathCommandData, opts *wshrpc.Rp
 	return resp, err
 }
 
+// command "previewunwatch", wshserver.PreviewUnwatchCommand
+func PreviewUnwatchCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "previewunwatch", data, opts)
+	return err
+}
+
+// command "previewwatch", wshserver.PreviewWatchCommand
+func PreviewWatchCommand(w *wshutil.WshRpc, data wshrpc.CommandPreviewWatchData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "previewwatch", data, opts)
+	return err
+}
+
+// command "queryblocks", wshserver.QueryBlocksCommand
+func QueryBlocksCommand(w *wshutil.WshRpc, data wshrpc.CommandQueryBlocksData, opts *wshrpc.RpcOpts) ([]wshrpc.BlockListEntry, error) {
+	resp, err := sendRpcRequestCallHelper[[]wshrpc.BlockListEntry](w, "queryblocks", data, opts)
+	return resp, err
+}
+
+// command "recordactivity", wshserver.RecordActivityCommand
+func RecordActivityCommand(w *wshutil.WshRpc, data wshrpc.ActivityRecord, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "recordactivity", data, opts)
+	return err
+}
+
+// command "remoteexec", wshserver.RemoteExecCommand
+func RemoteExecCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteExecData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.RemoteExecPacket] {
+	return sendRpcRequestResponseStreamHelper[wshrpc.RemoteExecPacket](w, "remoteexec", data, opts)
+}
+
+// command "remoteexecinput", wshserver.RemoteExecInputCommand
+func RemoteExecInputCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteExecInputData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remoteexecinput", data, opts)
+	return err
+}
+
+// command "remotefilechecksum", wshserver.RemoteFileChecksumCommand
+func RemoteFileChecksumCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "remotefilechecksum", data, opts)
+	return resp, err
+}
+
 // command "remotefiledelete", wshserver.RemoteFileDeleteCommand
 func RemoteFileDeleteCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "remotefiledelete", data, opts)
@@ -283,6 +625,24 @@ func RemoteFileJoinCommand(w *wshutil.WshRpc, data []string, opts *wshrpc.RpcOpt
 	return resp, err
 }
 
+// command "remotefilereadat", wshserver.RemoteFileReadAtCommand
+func RemoteFileReadAtCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileReadAtData, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "remotefilereadat", data, opts)
+	return resp, err
+}
+
+// command "remotefilereadrange", wshserver.RemoteFileReadRangeCommand
+func RemoteFileReadRangeCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileReadRangeData, opts *wshrpc.RpcOpts) (*wshrpc.FileReadRangeRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.FileReadRangeRtnData](w, "remotefilereadrange", data, opts)
+	return resp, err
+}
+
+// command "remotefileremove", wshserver.RemoteFileRemoveCommand
+func RemoteFileRemoveCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileRemoveData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remotefileremove", data, opts)
+	return err
+}
+
 // command "remotefilerename", wshserver.RemoteFileRenameCommand
 func RemoteFileRenameCommand(w *wshutil.WshRpc, data [2]string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "remotefilerename", data, opts)
@@ -295,6 +655,12 @@ func RemoteFileTouchCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts
 	return err
 }
 
+// command "remotefilewriteat", wshserver.RemoteFileWriteAtCommand
+func RemoteFileWriteAtCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteFileWriteAtData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remotefilewriteat", data, opts)
+	return err
+}
+
 // command "remotegetinfo", wshserver.RemoteGetInfoCommand
 func RemoteGetInfoCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (wshrpc.RemoteInfo, error) {
 	resp, err := sendRpcRequestCallHelper[wshrpc.RemoteInfo](w, "remotegetinfo", nil, opts)
@@ -307,12 +673,24 @@ func RemoteInstallRcFilesCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) error
 	return err
 }
 
+// command "remotelistentries", wshserver.RemoteListEntriesCommand
+func RemoteListEntriesCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteListEntriesData, opts *wshrpc.RpcOpts) (*wshrpc.RemoteListEntriesRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.RemoteListEntriesRtnData](w, "remotelistentries", data, opts)
+	return resp, err
+}
+
 // command "remotemkdir", wshserver.RemoteMkdirCommand
 func RemoteMkdirCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "remotemkdir", data, opts)
 	return err
 }
 
+// command "remoteremovetempfile", wshserver.RemoteRemoveTempFileCommand
+func RemoteRemoveTempFileCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remoteremovetempfile", data, opts)
+	return err
+}
+
 // command "remotestreamcpudata", wshserver.RemoteStreamCpuDataCommand
 func RemoteStreamCpuDataCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
 	return sendRpcRequestResponseStreamHelper[wshrpc.TimeSeriesData](w, "remotestreamcpudata", nil, opts)
@@ -323,12 +701,48 @@ func RemoteStreamFileCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteStreamF
 	return sendRpcRequestResponseStreamHelper[wshrpc.CommandRemoteStreamFileRtnData](w, "remotestreamfile", data, opts)
 }
 
+// command "remotetardir", wshserver.RemoteTarDirCommand
+func RemoteTarDirCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "remotetardir", data, opts)
+	return resp, err
+}
+
+// command "remotethumbnail", wshserver.RemoteThumbnailCommand
+func RemoteThumbnailCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteThumbnailData, opts *wshrpc.RpcOpts) (*wshrpc.ThumbnailRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ThumbnailRtnData](w, "remotethumbnail", data, opts)
+	return resp, err
+}
+
+// command "remoteuntar", wshserver.RemoteUntarCommand
+func RemoteUntarCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteUntarData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "remoteuntar", data, opts)
+	return err
+}
+
 // command "remotewritefile", wshserver.RemoteWriteFileCommand
 func RemoteWriteFileCommand(w *wshutil.WshRpc, data wshrpc.CommandRemoteWriteFileData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "remotewritefile", data, opts)
 	return err
 }
 
+// command "renametab", wshserver.RenameTabCommand
+func RenameTabCommand(w *wshutil.WshRpc, data wshrpc.CommandRenameTabData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "renametab", data, opts)
+	return err
+}
+
+// command "renderhtml", wshserver.RenderHtmlCommand
+func RenderHtmlCommand(w *wshutil.WshRpc, data wshrpc.CommandRenderHtmlData, opts *wshrpc.RpcOpts) (wshrpc.CommandCreateBlockRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[wshrpc.CommandCreateBlockRtnData](w, "renderhtml", data, opts)
+	return resp, err
+}
+
+// command "resetconfig", wshserver.ResetConfigCommand
+func ResetConfigCommand(w *wshutil.WshRpc, data wshrpc.CommandConfigPathData, opts *wshrpc.RpcOpts) (*wshrpc.ConfigPathRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.ConfigPathRtnData](w, "resetconfig", data, opts)
+	return resp, err
+}
+
 // command "resolveids", wshserver.ResolveIdsCommand
 func ResolveIdsCommand(w *wshutil.WshRpc, data wshrpc.CommandResolveIdsData, opts *wshrpc.RpcOpts) (wshrpc.CommandResolveIdsRtnData, error) {
 	resp, err := sendRpcRequestCallHelper[wshrpc.CommandResolveIdsRtnData](w, "resolveids", data, opts)
@@ -347,6 +761,30 @@ func RouteUnannounceCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) error {
 	return err
 }
 
+// command "savelayoutpreset", wshserver.SaveLayoutPresetCommand
+func SaveLayoutPresetCommand(w *wshutil.WshRpc, data wshrpc.CommandLayoutPresetData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "savelayoutpreset", data, opts)
+	return err
+}
+
+// command "searchhistory", wshserver.SearchHistoryCommand
+func SearchHistoryCommand(w *wshutil.WshRpc, data wshrpc.CommandSearchHistoryData, opts *wshrpc.RpcOpts) (*wshrpc.CommandSearchHistoryRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.CommandSearchHistoryRtnData](w, "searchhistory", data, opts)
+	return resp, err
+}
+
+// command "searchscrollback", wshserver.SearchScrollbackCommand
+func SearchScrollbackCommand(w *wshutil.WshRpc, data wshrpc.CommandSearchScrollbackData, opts *wshrpc.RpcOpts) (*wshrpc.CommandSearchScrollbackRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.CommandSearchScrollbackRtnData](w, "searchscrollback", data, opts)
+	return resp, err
+}
+
+// command "setblockmagnified", wshserver.SetBlockMagnifiedCommand
+func SetBlockMagnifiedCommand(w *wshutil.WshRpc, data wshrpc.CommandSetBlockMagnifiedData, opts *wshrpc.RpcOpts) (*wshrpc.CommandSetBlockMagnifiedRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.CommandSetBlockMagnifiedRtnData](w, "setblockmagnified", data, opts)
+	return resp, err
+}
+
 // command "setconfig", wshserver.SetConfigCommand
 func SetConfigCommand(w *wshutil.WshRpc, data wshrpc.MetaSettingsType, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "setconfig", data, opts)
@@ -359,12 +797,42 @@ func SetConnectionsConfigCommand(w *wshutil.WshRpc, data wshrpc.ConnConfigReques
 	return err
 }
 
+// command "setloglevel", wshserver.SetLogLevelCommand
+func SetLogLevelCommand(w *wshutil.WshRpc, data wshrpc.CommandSetLogLevelData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "setloglevel", data, opts)
+	return err
+}
+
 // command "setmeta", wshserver.SetMetaCommand
 func SetMetaCommand(w *wshutil.WshRpc, data wshrpc.CommandSetMetaData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "setmeta", data, opts)
 	return err
 }
 
+// command "setsecret", wshserver.SetSecretCommand
+func SetSecretCommand(w *wshutil.WshRpc, data wshrpc.CommandSetSecretData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "setsecret", data, opts)
+	return err
+}
+
+// command "settabgroup", wshserver.SetTabGroupCommand
+func SetTabGroupCommand(w *wshutil.WshRpc, data wshrpc.CommandSetTabGroupData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "settabgroup", data, opts)
+	return err
+}
+
+// command "settabpinned", wshserver.SetTabPinnedCommand
+func SetTabPinnedCommand(w *wshutil.WshRpc, data wshrpc.CommandSetTabPinnedData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "settabpinned", data, opts)
+	return err
+}
+
+// command "settabtitletemplate", wshserver.SetTabTitleTemplateCommand
+func SetTabTitleTemplateCommand(w *wshutil.WshRpc, data wshrpc.CommandSetTabTitleTemplateData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "settabtitletemplate", data, opts)
+	return err
+}
+
 // command "setvar", wshserver.SetVarCommand
 func SetVarCommand(w *wshutil.WshRpc, data wshrpc.CommandVarData, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "setvar", data, opts)
@@ -377,11 +845,34 @@ func SetViewCommand(w *wshutil.WshRpc, data wshrpc.CommandBlockSetViewData, opts
 	return err
 }
 
+// command "setwindowgeometry", wshserver.SetWindowGeometryCommand
+func SetWindowGeometryCommand(w *wshutil.WshRpc, data wshrpc.CommandSetWindowGeometryData, opts *wshrpc.RpcOpts) (*waveobj.Window, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Window](w, "setwindowgeometry", data, opts)
+	return resp, err
+}
+
+// command "snapshotexport", wshserver.SnapshotExportCommand
+func SnapshotExportCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "snapshotexport", nil, opts)
+	return resp, err
+}
+
+// command "snapshotimport", wshserver.SnapshotImportCommand
+func SnapshotImportCommand(w *wshutil.WshRpc, data wshrpc.CommandSnapshotImportData, opts *wshrpc.RpcOpts) (*wshrpc.SnapshotImportRtnData, error) {
+	resp, err := sendRpcRequestCallHelper[*wshrpc.SnapshotImportRtnData](w, "snapshotimport", data, opts)
+	return resp, err
+}
+
 // command "streamcpudata", wshserver.StreamCpuDataCommand
 func StreamCpuDataCommand(w *wshutil.WshRpc, data wshrpc.CpuDataRequest, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[wshrpc.TimeSeriesData] {
 	return sendRpcRequestResponseStreamHelper[wshrpc.TimeSeriesData](w, "streamcpudata", data, opts)
 }
 
+// command "streamevents", wshserver.StreamEventsCommand
+func StreamEventsCommand(w *wshutil.WshRpc, data wshrpc.CommandStreamEventsData, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[eventbus.WSEventType] {
+	return sendRpcRequestResponseStreamHelper[eventbus.WSEventType](w, "streamevents", data, opts)
+}
+
 // command "streamtest", wshserver.StreamTestCommand
 func StreamTestCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) chan wshrpc.RespOrErrorUnion[int] {
 	return sendRpcRequestResponseStreamHelper[int](w, "streamtest", nil, opts)
@@ -392,12 +883,42 @@ func StreamWaveAiCommand(w *wshutil.WshRpc, data wshrpc.WaveAIStreamRequest, opt
 	return sendRpcRequestResponseStreamHelper[wshrpc.WaveAIPacketType](w, "streamwaveai", data, opts)
 }
 
+// command "termclear", wshserver.TermClearCommand
+func TermClearCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "termclear", data, opts)
+	return err
+}
+
+// command "termresize", wshserver.TermResizeCommand
+func TermResizeCommand(w *wshutil.WshRpc, data wshrpc.CommandTermResizeData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "termresize", data, opts)
+	return err
+}
+
+// command "termsend", wshserver.TermSendCommand
+func TermSendCommand(w *wshutil.WshRpc, data wshrpc.CommandTermSendData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "termsend", data, opts)
+	return err
+}
+
+// command "termtrim", wshserver.TermTrimCommand
+func TermTrimCommand(w *wshutil.WshRpc, data wshrpc.CommandTermTrimData, opts *wshrpc.RpcOpts) (int64, error) {
+	resp, err := sendRpcRequestCallHelper[int64](w, "termtrim", data, opts)
+	return resp, err
+}
+
 // command "test", wshserver.TestCommand
 func TestCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "test", data, opts)
 	return err
 }
 
+// command "unlinkblocks", wshserver.UnlinkBlocksCommand
+func UnlinkBlocksCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "unlinkblocks", data, opts)
+	return err
+}
+
 // command "vdomasyncinitiation", wshserver.VDomAsyncInitiationCommand
 func VDomAsyncInitiationCommand(w *wshutil.WshRpc, data vdom.VDomAsyncInitiationRequest, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "vdomasyncinitiation", data, opts)
@@ -426,24 +947,66 @@ func WaitForRouteCommand(w *wshutil.WshRpc, data wshrpc.CommandWaitForRouteData,
 	return resp, err
 }
 
+// command "watchfilecreate", wshserver.WatchFileCreateCommand
+func WatchFileCreateCommand(w *wshutil.WshRpc, data string, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "watchfilecreate", data, opts)
+	return err
+}
+
 // command "waveinfo", wshserver.WaveInfoCommand
 func WaveInfoCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) (*wshrpc.WaveInfoData, error) {
 	resp, err := sendRpcRequestCallHelper[*wshrpc.WaveInfoData](w, "waveinfo", nil, opts)
 	return resp, err
 }
 
+// command "webcheckurl", wshserver.WebCheckUrlCommand
+func WebCheckUrlCommand(w *wshutil.WshRpc, data wshrpc.CommandWebCheckUrlData, opts *wshrpc.RpcOpts) (string, error) {
+	resp, err := sendRpcRequestCallHelper[string](w, "webcheckurl", data, opts)
+	return resp, err
+}
+
+// command "webnavigate", wshserver.WebNavigateCommand
+func WebNavigateCommand(w *wshutil.WshRpc, data wshrpc.CommandWebNavigateData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "webnavigate", data, opts)
+	return err
+}
+
 // command "webselector", wshserver.WebSelectorCommand
 func WebSelectorCommand(w *wshutil.WshRpc, data wshrpc.CommandWebSelectorData, opts *wshrpc.RpcOpts) ([]string, error) {
 	resp, err := sendRpcRequestCallHelper[[]string](w, "webselector", data, opts)
 	return resp, err
 }
 
+// command "workspacecreate", wshserver.WorkspaceCreateCommand
+func WorkspaceCreateCommand(w *wshutil.WshRpc, data wshrpc.CommandWorkspaceCreateData, opts *wshrpc.RpcOpts) (*waveobj.Workspace, error) {
+	resp, err := sendRpcRequestCallHelper[*waveobj.Workspace](w, "workspacecreate", data, opts)
+	return resp, err
+}
+
+// command "workspacedelete", wshserver.WorkspaceDeleteCommand
+func WorkspaceDeleteCommand(w *wshutil.WshRpc, data wshrpc.CommandWorkspaceDeleteData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "workspacedelete", data, opts)
+	return err
+}
+
 // command "workspacelist", wshserver.WorkspaceListCommand
 func WorkspaceListCommand(w *wshutil.WshRpc, opts *wshrpc.RpcOpts) ([]wshrpc.WorkspaceInfoData, error) {
 	resp, err := sendRpcRequestCallHelper[[]wshrpc.WorkspaceInfoData](w, "workspacelist", nil, opts)
 	return resp, err
 }
 
+// command "workspacerename", wshserver.WorkspaceRenameCommand
+func WorkspaceRenameCommand(w *wshutil.WshRpc, data wshrpc.CommandWorkspaceRenameData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "workspacerename", data, opts)
+	return err
+}
+
+// command "workspaceswitch", wshserver.WorkspaceSwitchCommand
+func WorkspaceSwitchCommand(w *wshutil.WshRpc, data wshrpc.CommandWorkspaceSwitchData, opts *wshrpc.RpcOpts) error {
+	_, err := sendRpcRequestCallHelper[any](w, "workspaceswitch", data, opts)
+	return err
+}
+
 // command "wshactivity", wshserver.WshActivityCommand
 func WshActivityCommand(w *wshutil.WshRpc, data map[string]int, opts *wshrpc.RpcOpts) error {
 	_, err := sendRpcRequestCallHelper[any](w, "wshactivity", data, opts)
@@ -0,0 +1,339 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// max size of a single read (file transfers and preview range reads are chunked, so files can be larger than MaxFileSize)
+const MaxReadAtSize = 1024 * 1024
+
+// the preview reads large files in ranges of this size (as the user scrolls)
+const DefaultReadRangeSize = 256 * 1024
+
+const TransferTarPrefix = "wsh-transfer-"
+
+func (*ServerImpl) RemoteFileReadAtCommand(ctx context.Context, data wshrpc.CommandRemoteFileReadAtData) (string, error) {
+	if data.Offset < 0 || data.Size <= 0 || data.Size > MaxReadAtSize {
+		return "", fmt.Errorf("invalid read range (offset %d, size %d)", data.Offset, data.Size)
+	}
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return "", err
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot open file %q: %w", data.Path, err)
+	}
+	defer fd.Close()
+	buf := make([]byte, data.Size)
+	n, err := fd.ReadAt(buf, data.Offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("cannot read file %q: %w", data.Path, err)
+	}
+	return base64.StdEncoding.EncodeToString(buf[:n]), nil
+}
+
+// resolves a (possibly negative) offset against the file size, returns the start and the number of bytes to read
+func resolveReadRange(offset int64, size int64, fileSize int64) (int64, int64, error) {
+	if size == 0 {
+		size = DefaultReadRangeSize
+	}
+	if size < 0 || size > MaxReadAtSize {
+		return 0, 0, fmt.Errorf("invalid read size %d (max %d)", size, MaxReadAtSize)
+	}
+	if offset < 0 {
+		offset = max(fileSize+offset, 0)
+	}
+	if offset >= fileSize {
+		return fileSize, 0, nil
+	}
+	return offset, min(size, fileSize-offset), nil
+}
+
+func (*ServerImpl) RemoteFileReadRangeCommand(ctx context.Context, data wshrpc.CommandRemoteFileReadRangeData) (*wshrpc.FileReadRangeRtnData, error) {
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file %q: %w", data.Path, err)
+	}
+	defer fd.Close()
+	finfo, err := fd.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat file %q: %w", data.Path, err)
+	}
+	if finfo.IsDir() {
+		return nil, fmt.Errorf("%q is a directory", data.Path)
+	}
+	offset, size, err := resolveReadRange(data.Offset, data.Size, finfo.Size())
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	n, err := fd.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot read file %q: %w", data.Path, err)
+	}
+	return &wshrpc.FileReadRangeRtnData{
+		Data64:   base64.StdEncoding.EncodeToString(buf[:n]),
+		Offset:   offset,
+		FileSize: finfo.Size(),
+		EOF:      offset+int64(n) >= finfo.Size(),
+	}, nil
+}
+
+func (*ServerImpl) RemoteFileWriteAtCommand(ctx context.Context, data wshrpc.CommandRemoteFileWriteAtData) error {
+	if data.Offset < 0 {
+		return fmt.Errorf("invalid offset %d", data.Offset)
+	}
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return err
+	}
+	dataBytes, err := base64.StdEncoding.DecodeString(data.Data64)
+	if err != nil {
+		return fmt.Errorf("cannot decode base64 data: %w", err)
+	}
+	createMode := data.CreateMode.Perm()
+	if createMode == 0 {
+		createMode = 0644
+	}
+	fd, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, createMode)
+	if err != nil {
+		return fmt.Errorf("cannot open file %q: %w", data.Path, err)
+	}
+	defer fd.Close()
+	if data.Truncate {
+		err = fd.Truncate(data.Offset)
+		if err != nil {
+			return fmt.Errorf("cannot truncate file %q: %w", data.Path, err)
+		}
+	}
+	if len(dataBytes) > 0 {
+		_, err = fd.WriteAt(dataBytes, data.Offset)
+		if err != nil {
+			return fmt.Errorf("cannot write file %q: %w", data.Path, err)
+		}
+	}
+	return fd.Close()
+}
+
+// returns the sha256 of the file (hex encoded)
+func (*ServerImpl) RemoteFileChecksumCommand(ctx context.Context, path string) (string, error) {
+	expandedPath, err := wavebase.ExpandHomeDir(path)
+	if err != nil {
+		return "", err
+	}
+	return fileSha256(expandedPath)
+}
+
+// writes the contents of the directory to a temp tar file (names are relative to the directory) and returns the
+// path of the tar file.  the caller removes it when it is done.
+func (*ServerImpl) RemoteTarDirCommand(ctx context.Context, path string) (string, error) {
+	dirPath, err := wavebase.ExpandHomeDir(path)
+	if err != nil {
+		return "", err
+	}
+	dirPath = filepath.Clean(dirPath)
+	fd, err := os.CreateTemp("", TransferTarPrefix+"*.tar")
+	if err != nil {
+		return "", fmt.Errorf("cannot create tar file: %w", err)
+	}
+	err = writeDirTar(ctx, dirPath, fd)
+	closeErr := fd.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fd.Name())
+		return "", fmt.Errorf("cannot tar directory %q: %w", path, err)
+	}
+	return fd.Name(), nil
+}
+
+func writeDirTar(ctx context.Context, dirPath string, writer io.Writer) error {
+	tw := tar.NewWriter(writer)
+	err := filepath.WalkDir(dirPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if filePath == dirPath {
+			return nil
+		}
+		relPath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return err
+		}
+		finfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		var linkTarget string
+		if finfo.Mode()&fs.ModeSymlink != 0 {
+			linkTarget, err = os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+		} else if !finfo.Mode().IsRegular() && !finfo.IsDir() {
+			// sockets, devices, etc. can't be copied
+			return nil
+		}
+		header, err := tar.FileInfoHeader(finfo, linkTarget)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if finfo.IsDir() {
+			header.Name += "/"
+		}
+		err = tw.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		if !finfo.Mode().IsRegular() {
+			return nil
+		}
+		fd, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		_, err = io.Copy(tw, fd)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extracts the tar file into the destination directory (creating it) and removes the tar file
+func (*ServerImpl) RemoteUntarCommand(ctx context.Context, data wshrpc.CommandRemoteUntarData) error {
+	tarPath, err := wavebase.ExpandHomeDir(data.TarPath)
+	if err != nil {
+		return err
+	}
+	destPath, err := wavebase.ExpandHomeDir(data.DestPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tarPath)
+	fd, err := os.Open(tarPath)
+	if err != nil {
+		return fmt.Errorf("cannot open tar file %q: %w", data.TarPath, err)
+	}
+	defer fd.Close()
+	err = extractTar(ctx, fd, filepath.Clean(destPath))
+	if err != nil {
+		return fmt.Errorf("cannot extract to %q: %w", data.DestPath, err)
+	}
+	return nil
+}
+
+func extractTar(ctx context.Context, reader io.Reader, destPath string) error {
+	err := os.MkdirAll(destPath, 0755)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(reader)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		targetPath, err := getTarEntryPath(destPath, header.Name)
+		if err != nil {
+			return err
+		}
+		mode := header.FileInfo().Mode().Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(targetPath, mode|0700)
+		case tar.TypeReg:
+			err = extractTarFile(tr, targetPath, mode)
+		case tar.TypeSymlink:
+			os.Remove(targetPath)
+			err = os.Symlink(header.Linkname, targetPath)
+		default:
+			// hard links, devices, etc. are skipped
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractTarFile(reader io.Reader, targetPath string, mode fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(targetPath), 0755)
+	if err != nil {
+		return err
+	}
+	// never write through a symlink (an earlier entry could point it outside of the destination)
+	if finfo, err := os.Lstat(targetPath); err == nil && finfo.Mode()&fs.ModeSymlink != 0 {
+		os.Remove(targetPath)
+	}
+	fd, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fd, reader)
+	closeErr := fd.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// returns the path for a tar entry, which must stay inside of destPath (including through symlinked directories)
+func getTarEntryPath(destPath string, name string) (string, error) {
+	relPath := filepath.FromSlash(strings.TrimSuffix(name, "/"))
+	if relPath == "" || filepath.IsAbs(relPath) || !filepath.IsLocal(relPath) {
+		return "", fmt.Errorf("invalid path in tar file: %q", name)
+	}
+	targetPath := filepath.Join(destPath, relPath)
+	realDest, err := filepath.EvalSymlinks(destPath)
+	if err != nil {
+		return "", err
+	}
+	// check the closest ancestor that exists (the missing directories are created inside of it)
+	parentPath := filepath.Dir(targetPath)
+	realParent, err := filepath.EvalSymlinks(parentPath)
+	for errors.Is(err, fs.ErrNotExist) && parentPath != destPath {
+		parentPath = filepath.Dir(parentPath)
+		realParent, err = filepath.EvalSymlinks(parentPath)
+	}
+	if err != nil {
+		return "", err
+	}
+	if realParent != realDest && !strings.HasPrefix(realParent, realDest+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path in tar file (outside of the destination): %q", name)
+	}
+	return targetPath, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirTarRoundTrip(t *testing.T) {
+	srcDir := t.TempDir()
+	os.MkdirAll(filepath.Join(srcDir, "sub", "empty"), 0755)
+	os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("hello"), 0644)
+	os.WriteFile(filepath.Join(srcDir, "sub", "b.sh"), []byte("#!/bin/sh\n"), 0755)
+	os.Symlink("a.txt", filepath.Join(srcDir, "link"))
+
+	var buf bytes.Buffer
+	err := writeDirTar(context.Background(), srcDir, &buf)
+	if err != nil {
+		t.Fatalf("error writing tar: %v", err)
+	}
+	destDir := filepath.Join(t.TempDir(), "dest")
+	err = extractTar(context.Background(), &buf, destDir)
+	if err != nil {
+		t.Fatalf("error extracting tar: %v", err)
+	}
+	for name, expected := range map[string]string{"a.txt": "hello", "sub/b.sh": "#!/bin/sh\n", "link": "hello"} {
+		contents, err := os.ReadFile(filepath.Join(destDir, name))
+		if err != nil {
+			t.Errorf("error reading %s: %v", name, err)
+		} else if string(contents) != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, contents)
+		}
+	}
+	if finfo, err := os.Stat(filepath.Join(destDir, "sub", "b.sh")); err != nil || finfo.Mode().Perm() != 0755 {
+		t.Errorf("expected sub/b.sh to keep mode 0755, got %v (err %v)", finfo.Mode().Perm(), err)
+	}
+	if finfo, err := os.Stat(filepath.Join(destDir, "sub", "empty")); err != nil || !finfo.IsDir() {
+		t.Errorf("expected empty directory sub/empty (err %v)", err)
+	}
+}
+
+func TestResolveReadRange(t *testing.T) {
+	tests := []struct {
+		offset, size, fileSize int64
+		start, n               int64
+	}{
+		{offset: 0, size: 0, fileSize: 1024 * 1024, start: 0, n: DefaultReadRangeSize},
+		{offset: 100, size: 50, fileSize: 120, start: 100, n: 20},
+		{offset: -30, size: 100, fileSize: 120, start: 90, n: 30},
+		{offset: -500, size: 100, fileSize: 120, start: 0, n: 100},
+		{offset: 200, size: 100, fileSize: 120, start: 120, n: 0},
+	}
+	for _, tc := range tests {
+		start, n, err := resolveReadRange(tc.offset, tc.size, tc.fileSize)
+		if err != nil || start != tc.start || n != tc.n {
+			t.Errorf("resolveReadRange(%d, %d, %d): expected (%d, %d), got (%d, %d, %v)", tc.offset, tc.size, tc.fileSize, tc.start, tc.n, start, n, err)
+		}
+	}
+	if _, _, err := resolveReadRange(0, MaxReadAtSize+1, 10); err == nil {
+		t.Errorf("expected an error for a read larger than MaxReadAtSize")
+	}
+}
+
+func TestExtractTarRejectsEscapes(t *testing.T) {
+	outsideDir := t.TempDir()
+	tests := []struct {
+		name    string
+		entries []*tar.Header
+	}{
+		{name: "parent dir", entries: []*tar.Header{{Name: "../evil.txt", Typeflag: tar.TypeReg, Mode: 0644}}},
+		{name: "absolute", entries: []*tar.Header{{Name: filepath.Join(outsideDir, "evil.txt"), Typeflag: tar.TypeReg, Mode: 0644}}},
+		{
+			name: "through symlink",
+			entries: []*tar.Header{
+				{Name: "out", Typeflag: tar.TypeSymlink, Linkname: outsideDir},
+				{Name: "out/evil.txt", Typeflag: tar.TypeReg, Mode: 0644},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for _, header := range tc.entries {
+				tw.WriteHeader(header)
+			}
+			tw.Close()
+			err := extractTar(context.Background(), &buf, t.TempDir())
+			if err == nil {
+				t.Errorf("expected an error")
+			}
+			if _, err := os.Stat(filepath.Join(outsideDir, "evil.txt")); err == nil {
+				t.Fatalf("file was written outside of the destination")
+			}
+		})
+	}
+}
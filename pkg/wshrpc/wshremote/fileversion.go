@@ -0,0 +1,70 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// the sha256 of the file (hex encoded)
+func fileSha256(path string) (string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot open file %q: %w", path, err)
+	}
+	defer fd.Close()
+	hash := sha256.New()
+	_, err = io.Copy(hash, fd)
+	if err != nil {
+		return "", fmt.Errorf("cannot read file %q: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// returns an ErrorCode_Conflict error if the file (the expanded path) isn't the version.  the file is only read when
+// its modtime or size changed (a touched file with the same contents is the same version).
+func checkFileVersion(path string, version *wshrpc.FileVersion) error {
+	conflict := wshrpc.FileConflictData{Path: path, ExpectedSha256: version.Sha256}
+	finfo, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		conflict.Removed = true
+		return makeConflictError(conflict)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot stat file %q: %w", path, err)
+	}
+	if finfo.ModTime().UnixMilli() == version.ModTime && finfo.Size() == version.Size {
+		return nil
+	}
+	conflict.CurrentModTime = finfo.ModTime().UnixMilli()
+	conflict.CurrentSize = finfo.Size()
+	if finfo.Size() <= wshrpc.FileVersionHashMaxSize {
+		conflict.CurrentSha256, err = fileSha256(path)
+		if err != nil {
+			return err
+		}
+		if version.Sha256 != "" && conflict.CurrentSha256 == version.Sha256 {
+			return nil
+		}
+	}
+	return makeConflictError(conflict)
+}
+
+func makeConflictError(conflict wshrpc.FileConflictData) error {
+	msg := "it was changed"
+	if conflict.Removed {
+		msg = "it was removed"
+	}
+	return &wshrpc.CodedError{
+		Code: wshrpc.ErrorCode_Conflict,
+		Err:  fmt.Errorf("file %q was not saved, %s since it was opened", conflict.Path, msg),
+		Data: conflict,
+	}
+}
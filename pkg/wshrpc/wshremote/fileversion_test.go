@@ -0,0 +1,49 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestCheckFileVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	err := os.WriteFile(path, []byte("hello"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	finfo, _ := os.Stat(path)
+	sha, _ := fileSha256(path)
+	version := &wshrpc.FileVersion{ModTime: finfo.ModTime().UnixMilli(), Size: finfo.Size(), Sha256: sha}
+	if err := checkFileVersion(path, version); err != nil {
+		t.Fatalf("unchanged file: %v", err)
+	}
+	// touched, same contents
+	later := finfo.ModTime().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	if err := checkFileVersion(path, version); err != nil {
+		t.Fatalf("touched file: %v", err)
+	}
+	// same size, and written in the same millisecond as the original (so the mtime has to be moved too)
+	os.WriteFile(path, []byte("world"), 0644)
+	os.Chtimes(path, later.Add(time.Minute), later.Add(time.Minute))
+	err = checkFileVersion(path, version)
+	if wshrpc.GetErrorCode(err) != wshrpc.ErrorCode_Conflict {
+		t.Fatalf("changed file: expected a conflict, got %v", err)
+	}
+	conflict := wshrpc.GetErrorData(err).(wshrpc.FileConflictData)
+	if conflict.CurrentSha256 == "" || conflict.CurrentSha256 == sha {
+		t.Fatalf("changed file: bad current sha256 %q", conflict.CurrentSha256)
+	}
+	os.Remove(path)
+	err = checkFileVersion(path, version)
+	if !wshrpc.GetErrorData(err).(wshrpc.FileConflictData).Removed {
+		t.Fatalf("removed file: expected a removed conflict, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const DefaultListLimit = 500
+const MaxListLimit = 5000
+
+// directories are read this many entries at a time
+const DirReadPageSize = 1024
+
+type dirListEntry struct {
+	entry fs.DirEntry
+	info  fs.FileInfo // only set when sorting by size or mtime (otherwise only the returned page is stat'ed)
+}
+
+func (e *dirListEntry) getInfo() fs.FileInfo {
+	if e.info == nil {
+		info, err := e.entry.Info()
+		if err != nil {
+			return nil
+		}
+		e.info = info
+	}
+	return e.info
+}
+
+func (e *dirListEntry) sortSize() int64 {
+	info := e.getInfo()
+	if info == nil || info.IsDir() {
+		return -1
+	}
+	return info.Size()
+}
+
+func (e *dirListEntry) sortModTime() int64 {
+	info := e.getInfo()
+	if info == nil {
+		return 0
+	}
+	return info.ModTime().UnixMilli()
+}
+
+func compareNames(a string, b string) int {
+	if cmp := strings.Compare(strings.ToLower(a), strings.ToLower(b)); cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(a, b)
+}
+
+// reads the directory in pages (no stat calls), keeping the entries that match opts
+func readDirEntries(ctx context.Context, path string, opts wshrpc.FileListOpts) ([]*dirListEntry, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open dir %q: %w", path, err)
+	}
+	defer fd.Close()
+	filter := strings.ToLower(opts.Filter)
+	var rtn []*dirListEntry
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		entries, err := fd.ReadDir(DirReadPageSize)
+		for _, entry := range entries {
+			name := entry.Name()
+			if !opts.ShowHidden && strings.HasPrefix(name, ".") {
+				continue
+			}
+			if filter != "" && !strings.Contains(strings.ToLower(name), filter) {
+				continue
+			}
+			rtn = append(rtn, &dirListEntry{entry: entry})
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read dir %q: %w", path, err)
+		}
+	}
+	return rtn, nil
+}
+
+func sortDirEntries(entries []*dirListEntry, opts wshrpc.FileListOpts) {
+	var cmpFn func(a, b *dirListEntry) int
+	switch opts.Sort {
+	case wshrpc.FileListSort_Size:
+		cmpFn = func(a, b *dirListEntry) int {
+			sa, sb := a.sortSize(), b.sortSize()
+			if sa != sb {
+				if sa < sb {
+					return -1
+				}
+				return 1
+			}
+			return compareNames(a.entry.Name(), b.entry.Name())
+		}
+	case wshrpc.FileListSort_MTime:
+		cmpFn = func(a, b *dirListEntry) int {
+			ta, tb := a.sortModTime(), b.sortModTime()
+			if ta != tb {
+				if ta < tb {
+					return -1
+				}
+				return 1
+			}
+			return compareNames(a.entry.Name(), b.entry.Name())
+		}
+	default:
+		cmpFn = func(a, b *dirListEntry) int {
+			return compareNames(a.entry.Name(), b.entry.Name())
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if opts.SortDesc {
+			return cmpFn(entries[j], entries[i]) < 0
+		}
+		return cmpFn(entries[i], entries[j]) < 0
+	})
+}
+
+func listDirEntries(ctx context.Context, path string, opts wshrpc.FileListOpts) (*wshrpc.RemoteListEntriesRtnData, error) {
+	switch opts.Sort {
+	case "", wshrpc.FileListSort_Name, wshrpc.FileListSort_Size, wshrpc.FileListSort_MTime:
+	default:
+		return nil, fmt.Errorf("invalid sort %q (must be name, size, or mtime)", opts.Sort)
+	}
+	if opts.Offset < 0 || opts.Limit < 0 {
+		return nil, fmt.Errorf("invalid offset/limit (%d, %d)", opts.Offset, opts.Limit)
+	}
+	limit := opts.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+	entries, err := readDirEntries(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	sortDirEntries(entries, opts)
+	rtn := &wshrpc.RemoteListEntriesRtnData{Total: len(entries), Entries: []*wshrpc.FileInfo{}}
+	offset := min(opts.Offset, len(entries))
+	rtn.Offset = offset
+	for _, entry := range entries[offset:min(offset+limit, len(entries))] {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		info := entry.getInfo()
+		if info == nil {
+			// removed since the directory was read
+			continue
+		}
+		rtn.Entries = append(rtn.Entries, statToFileInfo(filepath.Join(path, info.Name()), info, false))
+	}
+	return rtn, nil
+}
+
+// lists a page of a directory's entries.  only the entries in the page are stat'ed (unless sorting by size or mtime,
+// which needs every entry's stat info).
+func (impl *ServerImpl) RemoteListEntriesCommand(ctx context.Context, data wshrpc.CommandRemoteListEntriesData) (*wshrpc.RemoteListEntriesRtnData, error) {
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Clean(path)
+	var opts wshrpc.FileListOpts
+	if data.Opts != nil {
+		opts = *data.Opts
+	}
+	rtn, err := listDirEntries(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	parent := filepath.Dir(path)
+	if parent != path {
+		parentInfo, err := impl.fileInfoInternal(parent, false)
+		if err == nil {
+			parentInfo.Name = ".."
+			parentInfo.Size = -1
+			rtn.Parent = parentInfo
+		}
+	}
+	return rtn, nil
+}
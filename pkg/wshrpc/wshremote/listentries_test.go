@@ -0,0 +1,62 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestListDirEntries(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{"b.txt": 30, "A.txt": 10, "c.txt": 20, ".hidden": 5}
+	modTime := time.Now().Add(-time.Hour)
+	for _, name := range []string{"c.txt", "A.txt", "b.txt", ".hidden"} {
+		os.WriteFile(filepath.Join(dir, name), make([]byte, files[name]), 0644)
+		os.Chtimes(filepath.Join(dir, name), modTime, modTime)
+		modTime = modTime.Add(time.Minute)
+	}
+	os.Mkdir(filepath.Join(dir, "subdir"), 0755)
+
+	entryNames := func(rtn *wshrpc.RemoteListEntriesRtnData) []string {
+		var names []string
+		for _, entry := range rtn.Entries {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+	tests := []struct {
+		name     string
+		opts     wshrpc.FileListOpts
+		expected []string
+		total    int
+	}{
+		{name: "default", opts: wshrpc.FileListOpts{}, expected: []string{"A.txt", "b.txt", "c.txt", "subdir"}, total: 4},
+		{name: "hidden", opts: wshrpc.FileListOpts{ShowHidden: true}, expected: []string{".hidden", "A.txt", "b.txt", "c.txt", "subdir"}, total: 5},
+		{name: "size", opts: wshrpc.FileListOpts{Sort: wshrpc.FileListSort_Size}, expected: []string{"subdir", "A.txt", "c.txt", "b.txt"}, total: 4},
+		{name: "mtime desc", opts: wshrpc.FileListOpts{Sort: wshrpc.FileListSort_MTime, SortDesc: true, Limit: 3}, expected: []string{"subdir", "b.txt", "A.txt"}, total: 4},
+		{name: "page", opts: wshrpc.FileListOpts{Offset: 1, Limit: 2}, expected: []string{"b.txt", "c.txt"}, total: 4},
+		{name: "past end", opts: wshrpc.FileListOpts{Offset: 10}, expected: nil, total: 4},
+		{name: "filter", opts: wshrpc.FileListOpts{Filter: "B."}, expected: []string{"b.txt"}, total: 1},
+	}
+	for _, tc := range tests {
+		rtn, err := listDirEntries(context.Background(), dir, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: error listing entries: %v", tc.name, err)
+		}
+		if names := entryNames(rtn); !slices.Equal(names, tc.expected) || rtn.Total != tc.total {
+			t.Errorf("%s: expected %v (total %d), got %v (total %d)", tc.name, tc.expected, tc.total, names, rtn.Total)
+		}
+	}
+	_, err := listDirEntries(context.Background(), dir, wshrpc.FileListOpts{Sort: "bogus"})
+	if err == nil {
+		t.Errorf("expected an error for an invalid sort")
+	}
+}
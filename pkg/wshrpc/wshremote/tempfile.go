@@ -0,0 +1,54 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// the path comes from block meta (that anything can set), so it must be a regular file made by wsh (see
+// wshrpc.WshTempFilePrefix) directly in the temp dir, with symlinks resolved
+func checkWshTempFile(path string) (string, error) {
+	if !strings.HasPrefix(filepath.Base(path), wshrpc.WshTempFilePrefix) {
+		return "", fmt.Errorf("%q is not a wsh temp file", path)
+	}
+	tempDir, err := filepath.EvalSymlinks(os.TempDir())
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve the temp dir: %w", err)
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(filepath.Clean(path)))
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve %q: %w", path, err)
+	}
+	if dir != tempDir {
+		return "", fmt.Errorf("%q is not in the temp dir %q", path, tempDir)
+	}
+	resolvedPath := filepath.Join(dir, filepath.Base(path))
+	finfo, err := os.Lstat(resolvedPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot stat %q: %w", path, err)
+	}
+	if !finfo.Mode().IsRegular() {
+		return "", fmt.Errorf("%q is not a regular file", path)
+	}
+	return resolvedPath, nil
+}
+
+func (*ServerImpl) RemoteRemoveTempFileCommand(ctx context.Context, path string) error {
+	resolvedPath, err := checkWshTempFile(path)
+	if err != nil {
+		return fmt.Errorf("not removing temp file: %w", err)
+	}
+	err = os.Remove(resolvedPath)
+	if err != nil {
+		return fmt.Errorf("cannot remove temp file %q: %w", path, err)
+	}
+	return nil
+}
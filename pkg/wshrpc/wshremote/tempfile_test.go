@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestCheckWshTempFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("os.TempDir doesn't use TMPDIR on windows")
+	}
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+	writeFile := func(path string) string {
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+		return path
+	}
+	tempFile := writeFile(filepath.Join(tempDir, wshrpc.WshTempFilePrefix+"a.txt"))
+	otherFile := writeFile(filepath.Join(tempDir, "notes.txt"))
+	os.Mkdir(filepath.Join(tempDir, "sub"), 0700)
+	subFile := writeFile(filepath.Join(tempDir, "sub", wshrpc.WshTempFilePrefix+"b.txt"))
+	outsideFile := writeFile(filepath.Join(t.TempDir(), wshrpc.WshTempFilePrefix+"c.txt"))
+	symlinkFile := filepath.Join(tempDir, wshrpc.WshTempFilePrefix+"link.txt")
+	if err := os.Symlink(otherFile, symlinkFile); err != nil {
+		t.Fatalf("error creating symlink: %v", err)
+	}
+	tests := []struct {
+		name  string
+		path  string
+		valid bool
+	}{
+		{name: "temp file", path: tempFile, valid: true},
+		{name: "dotdot", path: filepath.Join(tempDir, "sub", "..", filepath.Base(tempFile)), valid: true},
+		{name: "no prefix", path: otherFile},
+		{name: "subdir", path: subFile},
+		{name: "outside", path: outsideFile},
+		{name: "symlink", path: symlinkFile},
+		{name: "missing", path: filepath.Join(tempDir, wshrpc.WshTempFilePrefix+"missing.txt")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := checkWshTempFile(tc.path)
+			if tc.valid != (err == nil) {
+				t.Errorf("expected valid %v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,471 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const DefaultThumbnailDim = 256
+const MaxThumbnailDim = 1024
+const ThumbnailJpegQuality = 80
+const ThumbCacheDirName = "thumbcache"
+const ThumbCacheMaxSize = 64 * 1024 * 1024
+
+// images with more pixels than this aren't decoded (the decoded image would use 4 bytes per pixel)
+const MaxThumbnailPixels = 64 * 1024 * 1024
+
+// enough for the image header and the exif segment of a jpeg
+const imageHeaderSize = 64 * 1024
+
+// decoding is cpu and memory heavy, a directory of photos shouldn't decode all of them at once
+const maxThumbnailDecodes = 4
+
+// the output pixels average a grid of up to this many source pixels in each direction
+const thumbSamplesPerPixel = 4
+
+var thumbDecodeSem = make(chan struct{}, maxThumbnailDecodes)
+
+func (*ServerImpl) RemoteThumbnailCommand(ctx context.Context, data wshrpc.CommandRemoteThumbnailData) (*wshrpc.ThumbnailRtnData, error) {
+	maxDim := data.MaxDim
+	if maxDim == 0 {
+		maxDim = DefaultThumbnailDim
+	}
+	if maxDim < 0 || maxDim > MaxThumbnailDim {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid thumbnail size %d (max %d)", data.MaxDim, MaxThumbnailDim)
+	}
+	path, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file %q: %w", data.Path, err)
+	}
+	defer fd.Close()
+	finfo, err := fd.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("cannot stat file %q: %w", data.Path, err)
+	}
+	if finfo.IsDir() {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NoThumbnail, "%q is a directory", data.Path)
+	}
+	rtn, err := readImageMeta(fd)
+	if err != nil {
+		return nil, wshrpc.MakeCodedError(wshrpc.ErrorCode_NoThumbnail, fmt.Errorf("%q: %w", data.Path, err))
+	}
+	if data.MetaOnly {
+		return rtn, nil
+	}
+	if rtn.MimeType == "image/webp" {
+		// there is no webp decoder in the standard library
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NoThumbnail, "%q: cannot decode webp images", data.Path)
+	}
+	if int64(rtn.Width)*int64(rtn.Height) > MaxThumbnailPixels {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NoThumbnail, "%q: image is too large (%dx%d)", data.Path, rtn.Width, rtn.Height)
+	}
+	cacheName := getThumbCacheName(path, finfo, maxDim)
+	thumbData := globalThumbCache.get(cacheName)
+	if thumbData == nil {
+		select {
+		case thumbDecodeSem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		thumbData, err = makeThumbnail(fd, rtn.Orientation, maxDim)
+		<-thumbDecodeSem
+		if err != nil {
+			return nil, wshrpc.MakeCodedError(wshrpc.ErrorCode_NoThumbnail, fmt.Errorf("cannot decode %q: %w", data.Path, err))
+		}
+		globalThumbCache.put(cacheName, thumbData)
+	}
+	thumbConfig, err := jpeg.DecodeConfig(bytes.NewReader(thumbData))
+	if err != nil {
+		return nil, fmt.Errorf("invalid thumbnail for %q: %w", data.Path, err)
+	}
+	rtn.Data64 = base64.StdEncoding.EncodeToString(thumbData)
+	rtn.ThumbWidth = thumbConfig.Width
+	rtn.ThumbHeight = thumbConfig.Height
+	return rtn, nil
+}
+
+// reads the image's type, size, and orientation from its header (without decoding it)
+func readImageMeta(fd *os.File) (*wshrpc.ThumbnailRtnData, error) {
+	header := make([]byte, imageHeaderSize)
+	n, err := io.ReadFull(fd, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	header = header[:n]
+	rtn := &wshrpc.ThumbnailRtnData{}
+	var decodeConfig func(io.Reader) (image.Config, error)
+	switch {
+	case bytes.HasPrefix(header, []byte("\xff\xd8\xff")):
+		rtn.MimeType = "image/jpeg"
+		rtn.Orientation = getJpegOrientation(header)
+		decodeConfig = jpeg.DecodeConfig
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		rtn.MimeType = "image/png"
+		decodeConfig = png.DecodeConfig
+	case bytes.HasPrefix(header, []byte("GIF8")):
+		rtn.MimeType = "image/gif"
+		decodeConfig = gif.DecodeConfig
+	case len(header) >= 12 && string(header[:4]) == "RIFF" && string(header[8:12]) == "WEBP":
+		rtn.MimeType = "image/webp"
+		rtn.Width, rtn.Height, err = getWebpSize(header)
+		if err != nil {
+			return nil, err
+		}
+		return rtn, nil
+	default:
+		return nil, fmt.Errorf("not a supported image (jpeg, png, gif, or webp)")
+	}
+	// jpegs can have large segments before the frame header, so it can be past the header we read
+	config, err := decodeConfig(io.MultiReader(bytes.NewReader(header), fd))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", rtn.MimeType, err)
+	}
+	rtn.Width, rtn.Height = config.Width, config.Height
+	if rtn.Orientation >= 5 {
+		rtn.Width, rtn.Height = rtn.Height, rtn.Width
+	}
+	return rtn, nil
+}
+
+// the canvas size from the header of the first chunk (VP8, VP8L, or VP8X)
+func getWebpSize(header []byte) (int, int, error) {
+	if len(header) < 30 {
+		return 0, 0, fmt.Errorf("invalid webp header")
+	}
+	chunk := header[20:]
+	switch string(header[12:16]) {
+	case "VP8 ":
+		if chunk[3] != 0x9d || chunk[4] != 0x01 || chunk[5] != 0x2a {
+			return 0, 0, fmt.Errorf("invalid webp frame header")
+		}
+		return int(binary.LittleEndian.Uint16(chunk[6:]) & 0x3fff), int(binary.LittleEndian.Uint16(chunk[8:]) & 0x3fff), nil
+	case "VP8L":
+		if chunk[0] != 0x2f {
+			return 0, 0, fmt.Errorf("invalid webp lossless header")
+		}
+		bits := binary.LittleEndian.Uint32(chunk[1:])
+		return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1, nil
+	case "VP8X":
+		width := int(chunk[4]) | int(chunk[5])<<8 | int(chunk[6])<<16
+		height := int(chunk[7]) | int(chunk[8])<<8 | int(chunk[9])<<16
+		return width + 1, height + 1, nil
+	}
+	return 0, 0, fmt.Errorf("unknown webp chunk %q", header[12:16])
+}
+
+// the orientation from the exif data in a jpeg's APP1 segment (0 if there is none)
+func getJpegOrientation(data []byte) int {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xff {
+			return 0
+		}
+		marker := data[pos+1]
+		if marker == 0xff {
+			// fill byte
+			pos++
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			// the image data starts, there are no more metadata segments
+			return 0
+		}
+		segEnd := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if segEnd < pos+4 || segEnd > len(data) {
+			return 0
+		}
+		segData := data[pos+4 : segEnd]
+		if marker == 0xe1 && bytes.HasPrefix(segData, []byte("Exif\x00\x00")) {
+			return getExifOrientation(segData[6:])
+		}
+		pos = segEnd
+	}
+	return 0
+}
+
+// the orientation tag (0x0112) of the first IFD of the exif (tiff) data
+func getExifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifdOffset := int(order.Uint32(tiff[4:]))
+	if ifdOffset < 8 || ifdOffset+2 > len(tiff) {
+		return 0
+	}
+	numEntries := int(order.Uint16(tiff[ifdOffset:]))
+	for idx := 0; idx < numEntries; idx++ {
+		entry := ifdOffset + 2 + idx*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		// a SHORT, its value is at the start of the value field
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 0
+		}
+		return orientation
+	}
+	return 0
+}
+
+func makeThumbnail(fd *os.File, orientation int, maxDim int) ([]byte, error) {
+	_, err := fd.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bufio.NewReader(fd))
+	if err != nil {
+		return nil, err
+	}
+	thumb := orientImage(scaleImage(img, maxDim), orientation)
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: ThumbnailJpegQuality})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scales the image to fit in maxDim x maxDim (images that already fit keep their size).  transparent pixels are
+// drawn over white (jpeg has no alpha).
+func scaleImage(img image.Image, maxDim int) *image.RGBA {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	width, height := srcWidth, srcHeight
+	if width > maxDim || height > maxDim {
+		if width >= height {
+			width, height = maxDim, max(srcHeight*maxDim/srcWidth, 1)
+		} else {
+			width, height = max(srcWidth*maxDim/srcHeight, 1), maxDim
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY0, srcY1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		stepY := max((srcY1-srcY0)/thumbSamplesPerPixel, 1)
+		for x := 0; x < width; x++ {
+			srcX0, srcX1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+			stepX := max((srcX1-srcX0)/thumbSamplesPerPixel, 1)
+			var r, g, b, a, count uint64
+			for sy := srcY0; sy < srcY1; sy += stepY {
+				for sx := srcX0; sx < srcX1; sx += stepX {
+					sr, sg, sb, sa := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a = r+uint64(sr), g+uint64(sg), b+uint64(sb), a+uint64(sa)
+					count++
+				}
+			}
+			// premultiplied, so adding the missing alpha as white draws the pixel over white
+			white := count*0xffff - a
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r + white) / count >> 8),
+				G: uint8((g + white) / count >> 8),
+				B: uint8((b + white) / count >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// applies the exif orientation, so the image is displayed upright
+func orientImage(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // flipped horizontally
+				dx, dy = width-1-x, y
+			case 3: // rotated 180
+				dx, dy = width-1-x, height-1-y
+			case 4: // flipped vertically
+				dx, dy = x, height-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = height-1-y, x
+			case 7: // transversed
+				dx, dy = height-1-y, width-1-x
+			case 8: // rotated 90 counterclockwise
+				dx, dy = y, width-1-x
+			}
+			dst.SetRGBA(dx, dy, img.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
+
+// the cache is keyed by the file's path, mtime, and size (and the thumbnail size), so a changed file gets a new
+// thumbnail
+func getThumbCacheName(path string, finfo os.FileInfo, maxDim int) string {
+	key := fmt.Sprintf("%s|%d|%d|%d", path, finfo.ModTime().UnixNano(), finfo.Size(), maxDim)
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:16]) + ".jpg"
+}
+
+func getThumbCacheDir() string {
+	if dataDir := wavebase.GetWaveDataDir(); dataDir != "" {
+		return filepath.Join(dataDir, ThumbCacheDirName)
+	}
+	return filepath.Join(wavebase.GetHomeDir(), wavebase.RemoteWaveHomeDirName, ThumbCacheDirName)
+}
+
+type thumbCacheEntry struct {
+	size     int64
+	lastUsed time.Time
+}
+
+// thumbnails on disk, the least recently used ones are removed when the cache is over maxSize.  the entries are
+// loaded from the directory on first use (the file mtimes are the last use times).
+type thumbCache struct {
+	lock    sync.Mutex
+	dir     string // "" uses getThumbCacheDir()
+	maxSize int64
+	loaded  bool
+	entries map[string]*thumbCacheEntry
+	size    int64
+}
+
+var globalThumbCache = &thumbCache{maxSize: ThumbCacheMaxSize}
+
+func (c *thumbCache) getDir() string {
+	if c.dir == "" {
+		c.dir = getThumbCacheDir()
+	}
+	return c.dir
+}
+
+func (c *thumbCache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	c.entries = make(map[string]*thumbCacheEntry)
+	dirEntries, err := os.ReadDir(c.getDir())
+	if err != nil {
+		return
+	}
+	for _, dirEntry := range dirEntries {
+		finfo, err := dirEntry.Info()
+		if err != nil || !finfo.Mode().IsRegular() {
+			continue
+		}
+		c.entries[dirEntry.Name()] = &thumbCacheEntry{size: finfo.Size(), lastUsed: finfo.ModTime()}
+		c.size += finfo.Size()
+	}
+}
+
+// returns nil if the thumbnail isn't cached
+func (c *thumbCache) get(name string) []byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.load()
+	entry := c.entries[name]
+	if entry == nil {
+		return nil
+	}
+	cachePath := filepath.Join(c.getDir(), name)
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		c.size -= entry.size
+		delete(c.entries, name)
+		return nil
+	}
+	entry.lastUsed = time.Now()
+	os.Chtimes(cachePath, entry.lastUsed, entry.lastUsed)
+	return data
+}
+
+// errors are only logged (the thumbnail is just made again next time)
+func (c *thumbCache) put(name string, data []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.load()
+	err := os.MkdirAll(c.getDir(), 0700)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(c.getDir(), name), data, 0600)
+	}
+	if err != nil {
+		log.Printf("error writing thumbnail to the cache: %v\n", err)
+		return
+	}
+	if oldEntry := c.entries[name]; oldEntry != nil {
+		c.size -= oldEntry.size
+	}
+	c.entries[name] = &thumbCacheEntry{size: int64(len(data)), lastUsed: time.Now()}
+	c.size += int64(len(data))
+	c.evict()
+}
+
+// removes the least recently used thumbnails until the cache is at 90% of its max size (so it doesn't evict on
+// every put)
+func (c *thumbCache) evict() {
+	if c.size <= c.maxSize {
+		return
+	}
+	names := make([]string, 0, len(c.entries))
+	for name := range c.entries {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return c.entries[names[i]].lastUsed.Before(c.entries[names[j]].lastUsed)
+	})
+	targetSize := c.maxSize / 10 * 9
+	for _, name := range names {
+		if c.size <= targetSize {
+			break
+		}
+		os.Remove(filepath.Join(c.getDir(), name))
+		c.size -= c.entries[name].size
+		delete(c.entries, name)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func makeTestImage(width int, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// red on the left half, blue on the right half
+			if x < width/2 {
+				img.SetRGBA(x, y, color.RGBA{R: 0xff, A: 0xff})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{B: 0xff, A: 0xff})
+			}
+		}
+	}
+	return img
+}
+
+// a jpeg with an exif APP1 segment (big endian, one IFD entry) that has the orientation
+func makeTestExifJpeg(t *testing.T, img image.Image, orientation uint16) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("error encoding jpeg: %v", err)
+	}
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, byte(orientation >> 8), byte(orientation), 0, 0, 0, 0, 0, 0}
+	segData := append([]byte("Exif\x00\x00"), tiff...)
+	segLen := len(segData) + 2
+	app1 := append([]byte{0xff, 0xe1, byte(segLen >> 8), byte(segLen)}, segData...)
+	jpegData := buf.Bytes()
+	return append(append(append([]byte{}, jpegData[:2]...), app1...), jpegData[2:]...)
+}
+
+func TestRemoteThumbnailCommand(t *testing.T) {
+	dir := t.TempDir()
+	origCache := globalThumbCache
+	globalThumbCache = &thumbCache{dir: filepath.Join(dir, "cache"), maxSize: ThumbCacheMaxSize}
+	defer func() {
+		globalThumbCache = origCache
+	}()
+	var pngBuf bytes.Buffer
+	png.Encode(&pngBuf, makeTestImage(400, 100))
+	os.WriteFile(filepath.Join(dir, "wide.png"), pngBuf.Bytes(), 0644)
+	os.WriteFile(filepath.Join(dir, "rotated.jpg"), makeTestExifJpeg(t, makeTestImage(200, 100), 6), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not an image"), 0644)
+	impl := &ServerImpl{}
+	ctx := context.Background()
+
+	rtn, err := impl.RemoteThumbnailCommand(ctx, wshrpc.CommandRemoteThumbnailData{Path: filepath.Join(dir, "wide.png"), MaxDim: 100})
+	if err != nil {
+		t.Fatalf("error making png thumbnail: %v", err)
+	}
+	if rtn.MimeType != "image/png" || rtn.Width != 400 || rtn.Height != 100 || rtn.ThumbWidth != 100 || rtn.ThumbHeight != 25 {
+		t.Errorf("unexpected png thumbnail: %s %dx%d, thumbnail %dx%d", rtn.MimeType, rtn.Width, rtn.Height, rtn.ThumbWidth, rtn.ThumbHeight)
+	}
+	if _, err := os.Stat(filepath.Join(globalThumbCache.dir, getThumbCacheNameForTest(t, filepath.Join(dir, "wide.png"), 100))); err != nil {
+		t.Errorf("expected the thumbnail to be cached: %v", err)
+	}
+
+	// rotated 90 clockwise, the left (red) half of the image is at the top
+	rtn, err = impl.RemoteThumbnailCommand(ctx, wshrpc.CommandRemoteThumbnailData{Path: filepath.Join(dir, "rotated.jpg"), MaxDim: 50})
+	if err != nil {
+		t.Fatalf("error making jpeg thumbnail: %v", err)
+	}
+	if rtn.Orientation != 6 || rtn.Width != 100 || rtn.Height != 200 || rtn.ThumbWidth != 25 || rtn.ThumbHeight != 50 {
+		t.Errorf("unexpected jpeg thumbnail: orientation %d, %dx%d, thumbnail %dx%d", rtn.Orientation, rtn.Width, rtn.Height, rtn.ThumbWidth, rtn.ThumbHeight)
+	}
+	thumbData, _ := base64.StdEncoding.DecodeString(rtn.Data64)
+	thumb, err := jpeg.Decode(bytes.NewReader(thumbData))
+	if err != nil {
+		t.Fatalf("error decoding the thumbnail: %v", err)
+	}
+	if r, _, b, _ := thumb.At(12, 5).RGBA(); r < 0xc000 || b > 0x4000 {
+		t.Errorf("expected the top of the rotated thumbnail to be red, got r=%x b=%x", r, b)
+	}
+
+	rtn, err = impl.RemoteThumbnailCommand(ctx, wshrpc.CommandRemoteThumbnailData{Path: filepath.Join(dir, "wide.png"), MetaOnly: true})
+	if err != nil || rtn.Width != 400 || rtn.Data64 != "" {
+		t.Errorf("expected only the metadata, got %v (%v)", rtn, err)
+	}
+	_, err = impl.RemoteThumbnailCommand(ctx, wshrpc.CommandRemoteThumbnailData{Path: filepath.Join(dir, "notes.txt")})
+	if code := wshrpc.GetErrorCode(err); code != wshrpc.ErrorCode_NoThumbnail {
+		t.Errorf("expected a nothumbnail error for a text file, got %q (%v)", code, err)
+	}
+	_, err = impl.RemoteThumbnailCommand(ctx, wshrpc.CommandRemoteThumbnailData{Path: filepath.Join(dir, "missing.png")})
+	if code := wshrpc.GetErrorCode(err); code != wshrpc.ErrorCode_NotFound {
+		t.Errorf("expected a notfound error for a missing file, got %q (%v)", code, err)
+	}
+}
+
+func getThumbCacheNameForTest(t *testing.T, path string, maxDim int) string {
+	finfo, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("error getting file info: %v", err)
+	}
+	return getThumbCacheName(path, finfo, maxDim)
+}
+
+func TestGetWebpSize(t *testing.T) {
+	lossy := []byte("RIFF\x00\x00\x00\x00WEBPVP8 \x00\x00\x00\x00\x00\x00\x00\x9d\x01\x2a\x40\x01\xf0\x00")
+	if width, height, err := getWebpSize(lossy); err != nil || width != 320 || height != 240 {
+		t.Errorf("expected 320x240 for the lossy header, got %dx%d (%v)", width, height, err)
+	}
+	extended := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00\x3f\x01\x00\xef\x00\x00")
+	if width, height, err := getWebpSize(extended); err != nil || width != 320 || height != 240 {
+		t.Errorf("expected 320x240 for the extended header, got %dx%d (%v)", width, height, err)
+	}
+}
+
+func TestThumbCacheEvict(t *testing.T) {
+	cache := &thumbCache{dir: t.TempDir(), maxSize: 1000}
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		cache.put(name, make([]byte, 400))
+		// the eviction order comes from the last use times
+		time.Sleep(10 * time.Millisecond)
+		if name == "b.jpg" {
+			cache.get("a.jpg")
+		}
+	}
+	if cache.get("b.jpg") != nil {
+		t.Errorf("expected the least recently used thumbnail to be evicted")
+	}
+	if cache.get("a.jpg") == nil || cache.get("c.jpg") == nil {
+		t.Errorf("expected the recently used thumbnails to be kept")
+	}
+	if cache.size != 800 {
+		t.Errorf("expected the cache size to be 800, got %d", cache.size)
+	}
+	// a new cache loads the entries from the directory
+	cache = &thumbCache{dir: cache.dir, maxSize: 1000}
+	if cache.get("c.jpg") == nil || cache.size != 800 {
+		t.Errorf("expected the cache to be loaded from the directory (size %d)", cache.size)
+	}
+}
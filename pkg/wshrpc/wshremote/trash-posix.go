@@ -0,0 +1,15 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package wshremote
+
+import "runtime"
+
+func moveToTrash(path string) error {
+	if runtime.GOOS == "darwin" {
+		return trashFileDarwin(path)
+	}
+	return trashFileFreedesktop(path)
+}
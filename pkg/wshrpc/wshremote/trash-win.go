@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package wshremote
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fo_Delete          = 0x3
+	fof_Silent         = 0x4
+	fof_NoConfirmation = 0x10
+	fof_AllowUndo      = 0x40
+	fof_NoErrorUI      = 0x400
+)
+
+// SHFILEOPSTRUCTW
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+var procSHFileOperationW = syscall.NewLazyDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// moves the file to the recycle bin (a delete with FOF_ALLOWUNDO)
+func moveToTrash(path string) error {
+	pathArr, err := syscall.UTF16FromString(path)
+	if err != nil {
+		return fmt.Errorf("cannot move %q to the recycle bin: %w", path, err)
+	}
+	// pFrom is a list of paths, it ends with two nulls
+	pathArr = append(pathArr, 0)
+	op := shFileOpStruct{
+		wFunc:  fo_Delete,
+		pFrom:  &pathArr[0],
+		fFlags: fof_AllowUndo | fof_NoConfirmation | fof_Silent | fof_NoErrorUI,
+	}
+	rtn, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op)))
+	if rtn != 0 {
+		return fmt.Errorf("cannot move %q to the recycle bin (error 0x%x)", path, rtn)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return fmt.Errorf("cannot move %q to the recycle bin (aborted)", path)
+	}
+	return nil
+}
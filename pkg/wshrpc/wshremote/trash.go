@@ -0,0 +1,98 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+)
+
+const maxTrashNameTries = 1000
+
+func trashRenameErr(path string, err error) error {
+	if errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("cannot move %q to the trash, it is not on the same filesystem as the trash", path)
+	}
+	return fmt.Errorf("cannot move %q to the trash: %w", path, err)
+}
+
+// "name", "name 2.txt", "name 3.txt", etc. (like the finder)
+func makeTrashName(name string, num int) string {
+	if num == 1 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if ext == name {
+		ext = ""
+	}
+	return strings.TrimSuffix(name, ext) + " " + strconv.Itoa(num) + ext
+}
+
+// moves the file to ~/.Trash (the trash of the home volume, "put back" isn't available for these files)
+func trashFileDarwin(path string) error {
+	trashDir := filepath.Join(wavebase.GetHomeDir(), ".Trash")
+	name := filepath.Base(path)
+	for num := 1; num <= maxTrashNameTries; num++ {
+		trashPath := filepath.Join(trashDir, makeTrashName(name, num))
+		if _, err := os.Lstat(trashPath); err == nil {
+			continue
+		}
+		if err := os.Rename(path, trashPath); err != nil {
+			return trashRenameErr(path, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot move %q to the trash, too many files named %q in the trash", path, name)
+}
+
+// the freedesktop.org trash (used by the linux file managers), $XDG_DATA_HOME/Trash (defaults to
+// ~/.local/share/Trash).  the .trashinfo file has the original path so the file manager can restore the file.
+func trashFileFreedesktop(path string) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(wavebase.GetHomeDir(), ".local", "share")
+	}
+	trashDir := filepath.Join(dataHome, "Trash")
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("cannot create trash directory %q: %w", dir, err)
+		}
+	}
+	trashInfo := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n", (&url.URL{Path: path}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	name := filepath.Base(path)
+	for num := 1; num <= maxTrashNameTries; num++ {
+		trashName := makeTrashName(name, num)
+		// the info file is created first (O_EXCL), it reserves the name in the trash
+		infoPath := filepath.Join(infoDir, trashName+".trashinfo")
+		fd, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot create trash info file %q: %w", infoPath, err)
+		}
+		_, err = fd.WriteString(trashInfo)
+		fd.Close()
+		if err == nil {
+			err = os.Rename(path, filepath.Join(filesDir, trashName))
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return trashRenameErr(path, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot move %q to the trash, too many files named %q in the trash", path, name)
+}
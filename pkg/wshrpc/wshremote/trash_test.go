@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMakeTrashName(t *testing.T) {
+	tests := []struct {
+		name     string
+		num      int
+		expected string
+	}{
+		{"notes.txt", 1, "notes.txt"},
+		{"notes.txt", 2, "notes 2.txt"},
+		{"build", 3, "build 3"},
+		{".bashrc", 2, ".bashrc 2"},
+	}
+	for _, tc := range tests {
+		if got := makeTrashName(tc.name, tc.num); got != tc.expected {
+			t.Errorf("makeTrashName(%q, %d) = %q, expected %q", tc.name, tc.num, got, tc.expected)
+		}
+	}
+}
+
+func TestTrashFileFreedesktop(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	srcDir := t.TempDir()
+	for idx := 0; idx < 2; idx++ {
+		srcPath := filepath.Join(srcDir, "my file.txt")
+		os.WriteFile(srcPath, []byte("hello"), 0644)
+		if err := trashFileFreedesktop(srcPath); err != nil {
+			t.Fatalf("error moving to the trash: %v", err)
+		}
+		if _, err := os.Stat(srcPath); !os.IsNotExist(err) {
+			t.Fatalf("expected %q to be removed", srcPath)
+		}
+	}
+	for _, name := range []string{"my file.txt", "my file 2.txt"} {
+		if _, err := os.Stat(filepath.Join(dataHome, "Trash", "files", name)); err != nil {
+			t.Errorf("expected %q in the trash: %v", name, err)
+		}
+		info, err := os.ReadFile(filepath.Join(dataHome, "Trash", "info", name+".trashinfo"))
+		if err != nil {
+			t.Fatalf("expected the trash info for %q: %v", name, err)
+		}
+		if !strings.Contains(string(info), "Path="+filepath.ToSlash(srcDir)+"/my%20file.txt\n") {
+			t.Errorf("unexpected trash info %q", string(info))
+		}
+	}
+}
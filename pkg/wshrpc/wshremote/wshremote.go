@@ -265,16 +265,42 @@ func computeDirPart(path string, isDir bool) string {
 	return filepath.Dir(path)
 }
 
+// returns the path with the symlinks resolved.  for a path that doesn't exist, the symlinks in its longest existing
+// parent are resolved.  returns "" if it can't be resolved (e.g. a dangling link).
+func resolveRealPath(cleanedPath string) string {
+	curPath := cleanedPath
+	var rest []string
+	for {
+		resolvedPath, err := filepath.EvalSymlinks(curPath)
+		if err == nil {
+			return filepath.ToSlash(filepath.Join(append([]string{resolvedPath}, rest...)...))
+		}
+		if _, lstatErr := os.Lstat(curPath); !os.IsNotExist(err) || lstatErr == nil {
+			return ""
+		}
+		parent := filepath.Dir(curPath)
+		if parent == curPath {
+			return ""
+		}
+		rest = append([]string{filepath.Base(curPath)}, rest...)
+		curPath = parent
+	}
+}
+
 func (*ServerImpl) fileInfoInternal(path string, extended bool) (*wshrpc.FileInfo, error) {
 	cleanedPath := filepath.Clean(wavebase.ExpandHomeDirSafe(path))
 	finfo, err := os.Stat(cleanedPath)
 	if os.IsNotExist(err) {
-		return &wshrpc.FileInfo{
+		rtn := &wshrpc.FileInfo{
 			Path:     wavebase.ReplaceHomeDir(path),
 			Dir:      computeDirPart(path, false),
 			NotFound: true,
 			ReadOnly: checkIsReadOnly(cleanedPath, finfo, false),
-		}, nil
+		}
+		if extended {
+			rtn.RealPath = resolveRealPath(cleanedPath)
+		}
+		return rtn, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("cannot stat file %q: %w", path, err)
@@ -282,6 +308,7 @@ func (*ServerImpl) fileInfoInternal(path string, extended bool) (*wshrpc.FileInf
 	rtn := statToFileInfo(cleanedPath, finfo, extended)
 	if extended {
 		rtn.ReadOnly = checkIsReadOnly(cleanedPath, finfo, true)
+		rtn.RealPath = resolveRealPath(cleanedPath)
 	}
 	return rtn, nil
 }
@@ -359,6 +386,12 @@ func (*ServerImpl) RemoteWriteFileCommand(ctx context.Context, data wshrpc.Comma
 	if err != nil {
 		return err
 	}
+	if data.Version != nil {
+		err = checkFileVersion(filepath.Clean(path), data.Version)
+		if err != nil {
+			return err
+		}
+	}
 	createMode := data.CreateMode
 	if createMode == 0 {
 		createMode = 0644
@@ -389,6 +422,33 @@ func (*ServerImpl) RemoteFileDeleteCommand(ctx context.Context, path string) err
 	return nil
 }
 
+func (*ServerImpl) RemoteFileRemoveCommand(ctx context.Context, data wshrpc.CommandRemoteFileRemoveData) error {
+	expandedPath, err := wavebase.ExpandHomeDir(data.Path)
+	if err != nil {
+		return fmt.Errorf("cannot remove %q: %w", data.Path, err)
+	}
+	cleanedPath := filepath.Clean(expandedPath)
+	finfo, err := os.Lstat(cleanedPath)
+	if os.IsNotExist(err) {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "cannot remove %q: no such file or directory", data.Path)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot remove %q: %w", data.Path, err)
+	}
+	if data.Trash {
+		return moveToTrash(cleanedPath)
+	}
+	if finfo.IsDir() && data.Recursive {
+		err = os.RemoveAll(cleanedPath)
+	} else {
+		err = os.Remove(cleanedPath)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot remove %q: %w", data.Path, err)
+	}
+	return nil
+}
+
 func (*ServerImpl) RemoteGetInfoCommand(ctx context.Context) (wshrpc.RemoteInfo, error) {
 	return wshutil.GetInfo(), nil
 }
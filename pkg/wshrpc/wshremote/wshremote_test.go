@@ -0,0 +1,47 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshremote
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestResolveRealPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires symlinks")
+	}
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outsideDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outsideDir, "missing"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: root, expected: root},
+		{path: filepath.Join(root, "link"), expected: outsideDir},
+		{path: filepath.Join(root, "link", "new", "file.txt"), expected: filepath.Join(outsideDir, "new", "file.txt")},
+		{path: filepath.Join(root, "new", "file.txt"), expected: filepath.Join(root, "new", "file.txt")},
+		{path: filepath.Join(root, "dangling"), expected: ""},
+		{path: filepath.Join(root, "dangling", "file.txt"), expected: ""},
+	}
+	for _, tc := range tests {
+		if got := resolveRealPath(tc.path); got != filepath.ToSlash(tc.expected) {
+			t.Errorf("resolveRealPath(%q): expected %q, got %q", tc.path, tc.expected, got)
+		}
+	}
+}
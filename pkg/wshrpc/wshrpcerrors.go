@@ -0,0 +1,83 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// error codes sent with an rpc error response (the errorcode field), so callers can tell the kinds of errors
+// apart without matching the error text.  errors without a code are general errors.
+const (
+	ErrorCode_NotFound       = "notfound"
+	ErrorCode_Permission     = "permission"
+	ErrorCode_Timeout        = "timeout"
+	ErrorCode_ConnRefused    = "connrefused"
+	ErrorCode_InvalidArg     = "invalidarg"
+	ErrorCode_NoThumbnail    = "nothumbnail"    // the file isn't an image that a thumbnail can be made of
+	ErrorCode_Conflict       = "conflict"       // the file changed since it was read (the error data is a FileConflictData)
+	ErrorCode_HostKeyChanged = "hostkeychanged" // the ssh host key doesn't match known_hosts (the error data is a HostKeyChangedData)
+)
+
+// an error with an error code.  handlers can return one (or wrap one with %w) to set the code of their error
+// response, and the rpc client returns one for an error response that has a code.  Data is sent with the error
+// response (the errordata field), the rpc client's copy is the unmarshaled json (see utilfn.ReUnmarshal).
+type CodedError struct {
+	Code string
+	Err  error
+	Data any
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+func MakeCodedError(code string, err error) error {
+	return &CodedError{Code: code, Err: err}
+}
+
+func CodedErrorf(code string, format string, args ...any) error {
+	return &CodedError{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// the data of the CodedError in err's chain (nil if there isn't one)
+func GetErrorData(err error) any {
+	var codedErr *CodedError
+	if errors.As(err, &codedErr) {
+		return codedErr.Data
+	}
+	return nil
+}
+
+// the code of a CodedError in err's chain, otherwise a code for the well known errors (missing files, timeouts,
+// etc.) in the chain.  returns "" for other errors.
+func GetErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var codedErr *CodedError
+	if errors.As(err, &codedErr) {
+		return codedErr.Code
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrorCode_NotFound
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorCode_Permission
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrorCode_Timeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorCode_ConnRefused
+	}
+	return ""
+}
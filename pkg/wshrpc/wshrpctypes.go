@@ -12,15 +12,20 @@ import (
 	"os"
 	"reflect"
 
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/ijson"
 	"github.com/wavetermdev/waveterm/pkg/vdom"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 )
 
 const LocalConnName = "local"
 
+// prefix for temp files created by wsh (e.g. "wsh view -"), blocks with file:temp set will only remove files with this prefix
+const WshTempFilePrefix = "wsh-temp-"
+
 const (
 	RpcType_Call             = "call"             // single response (regular rpc)
 	RpcType_ResponseStream   = "responsestream"   // stream of responses (streaming rpc)
@@ -29,78 +34,173 @@ const (
 )
 
 const (
-	Command_Authenticate         = "authenticate"    // special
-	Command_Dispose              = "dispose"         // special (disposes of the route, for multiproxy only)
-	Command_RouteAnnounce        = "routeannounce"   // special (for routing)
-	Command_RouteUnannounce      = "routeunannounce" // special (for routing)
-	Command_Message              = "message"
-	Command_GetMeta              = "getmeta"
-	Command_SetMeta              = "setmeta"
-	Command_SetView              = "setview"
-	Command_ControllerInput      = "controllerinput"
-	Command_ControllerRestart    = "controllerrestart"
-	Command_ControllerStop       = "controllerstop"
-	Command_ControllerResync     = "controllerresync"
-	Command_FileAppend           = "fileappend"
-	Command_FileAppendIJson      = "fileappendijson"
-	Command_ResolveIds           = "resolveids"
-	Command_BlockInfo            = "blockinfo"
-	Command_CreateBlock          = "createblock"
-	Command_DeleteBlock          = "deleteblock"
-	Command_FileWrite            = "filewrite"
-	Command_FileRead             = "fileread"
-	Command_EventPublish         = "eventpublish"
-	Command_EventRecv            = "eventrecv"
-	Command_EventSub             = "eventsub"
-	Command_EventUnsub           = "eventunsub"
-	Command_EventUnsubAll        = "eventunsuball"
-	Command_EventReadHistory     = "eventreadhistory"
-	Command_StreamTest           = "streamtest"
-	Command_StreamWaveAi         = "streamwaveai"
-	Command_StreamCpuData        = "streamcpudata"
-	Command_Test                 = "test"
-	Command_SetConfig            = "setconfig"
-	Command_SetConnectionsConfig = "connectionsconfig"
-	Command_RemoteStreamFile     = "remotestreamfile"
-	Command_RemoteFileInfo       = "remotefileinfo"
-	Command_RemoteFileTouch      = "remotefiletouch"
-	Command_RemoteWriteFile      = "remotewritefile"
-	Command_RemoteFileDelete     = "remotefiledelete"
-	Command_RemoteFileJoin       = "remotefilejoin"
-	Command_WaveInfo             = "waveinfo"
-	Command_WshActivity          = "wshactivity"
-	Command_Activity             = "activity"
-	Command_GetVar               = "getvar"
-	Command_SetVar               = "setvar"
-	Command_RemoteMkdir          = "remotemkdir"
-	Command_RemoteGetInfo        = "remotegetinfo"
-	Command_RemoteInstallRcfiles = "remoteinstallrcfiles"
-
-	Command_ConnStatus       = "connstatus"
-	Command_WslStatus        = "wslstatus"
-	Command_ConnEnsure       = "connensure"
-	Command_ConnReinstallWsh = "connreinstallwsh"
-	Command_ConnConnect      = "connconnect"
-	Command_ConnDisconnect   = "conndisconnect"
-	Command_ConnList         = "connlist"
-	Command_WslList          = "wsllist"
-	Command_WslDefaultDistro = "wsldefaultdistro"
-	Command_DismissWshFail   = "dismisswshfail"
-	Command_ConnUpdateWsh    = "updatewsh"
-
-	Command_WorkspaceList = "workspacelist"
+	Command_Authenticate          = "authenticate"    // special
+	Command_Dispose               = "dispose"         // special (disposes of the route, for multiproxy only)
+	Command_RouteAnnounce         = "routeannounce"   // special (for routing)
+	Command_RouteUnannounce       = "routeunannounce" // special (for routing)
+	Command_Message               = "message"
+	Command_GetMeta               = "getmeta"
+	Command_SetMeta               = "setmeta"
+	Command_SetView               = "setview"
+	Command_ControllerInput       = "controllerinput"
+	Command_ControllerRestart     = "controllerrestart"
+	Command_ControllerStop        = "controllerstop"
+	Command_ControllerResync      = "controllerresync"
+	Command_TermSend              = "termsend"
+	Command_TermResize            = "termresize"
+	Command_TermClear             = "termclear"
+	Command_TermTrim              = "termtrim"
+	Command_InjectPath            = "injectpath"
+	Command_GetScrollback         = "getscrollback"
+	Command_SearchScrollback      = "searchscrollback"
+	Command_GetCmdHistoryForBlock = "getcmdhistoryforblock"
+	Command_SearchHistory         = "searchhistory"
+	Command_ImportHistory         = "importhistory"
+	Command_SaveLayoutPreset      = "savelayoutpreset"
+	Command_ApplyLayoutPreset     = "applylayoutpreset"
+	Command_LayoutAction          = "layoutaction"
+	Command_SetBlockMagnified     = "setblockmagnified"
+	Command_ListLayoutPresets     = "listlayoutpresets"
+	Command_DeleteLayoutPreset    = "deletelayoutpreset"
+	Command_FileAppend            = "fileappend"
+	Command_FileAppendIJson       = "fileappendijson"
+	Command_ResolveIds            = "resolveids"
+	Command_BlockInfo             = "blockinfo"
+	Command_CreateBlock           = "createblock"
+	Command_CreateBlocks          = "createblocks"
+	Command_RenderHtml            = "renderhtml"
+	Command_DeleteBlock           = "deleteblock"
+	Command_DeleteBlocks          = "deleteblocks"
+	Command_LinkBlocks            = "linkblocks"
+	Command_UnlinkBlocks          = "unlinkblocks"
+	Command_ListBlockLinks        = "listblocklinks"
+	Command_MoveBlock             = "moveblock"
+	Command_DuplicateBlock        = "duplicateblock"
+	Command_CreateTab             = "createtab"
+	Command_CloseTab              = "closetab"
+	Command_RenameTab             = "renametab"
+	Command_MoveTab               = "movetab"
+	Command_SetTabPinned          = "settabpinned"
+	Command_SetTabGroup           = "settabgroup"
+	Command_SetTabTitleTemplate   = "settabtitletemplate"
+	Command_GetTabTitle           = "gettabtitle"
+	Command_CloseWindow           = "closewindow"
+	Command_SetWindowGeometry     = "setwindowgeometry"
+	Command_FileWrite             = "filewrite"
+	Command_FileRead              = "fileread"
+	Command_EventPublish          = "eventpublish"
+	Command_EventRecv             = "eventrecv"
+	Command_EventSub              = "eventsub"
+	Command_EventUnsub            = "eventunsub"
+	Command_EventUnsubAll         = "eventunsuball"
+	Command_EventReadHistory      = "eventreadhistory"
+	Command_StreamTest            = "streamtest"
+	Command_StreamWaveAi          = "streamwaveai"
+	Command_StreamCpuData         = "streamcpudata"
+	Command_GetMetrics            = "getmetrics"
+	Command_StreamEvents          = "streamevents"
+	Command_Test                  = "test"
+	Command_DebugCacheStats       = "debugcachestats"
+	Command_DebugIntegrity        = "debugintegrity"
+	Command_DebugDBVersion        = "debugdbversion"
+	Command_SetLogLevel           = "setloglevel"
+	Command_LogTail               = "logtail"
+	Command_SetSecret             = "setsecret"
+	Command_DeleteSecret          = "deletesecret"
+	Command_GetSecretNames        = "getsecretnames"
+	Command_ExportAIConversation  = "exportaiconversation"
+	Command_SetConfig             = "setconfig"
+	Command_SetConnectionsConfig  = "connectionsconfig"
+	Command_GetConfigPath         = "getconfigpath"
+	Command_ResetConfig           = "resetconfig"
+	Command_RemoteStreamFile      = "remotestreamfile"
+	Command_RemoteFileInfo        = "remotefileinfo"
+	Command_RemoteFileTouch       = "remotefiletouch"
+	Command_RemoteWriteFile       = "remotewritefile"
+	Command_RemoteFileDelete      = "remotefiledelete"
+	Command_RemoteFileJoin        = "remotefilejoin"
+	Command_WaveInfo              = "waveinfo"
+	Command_WshActivity           = "wshactivity"
+	Command_Activity              = "activity"
+	Command_RecordActivity        = "recordactivity"
+	Command_GetActivityStats      = "getactivitystats"
+	Command_GetServerStatus       = "getserverstatus"
+	Command_WatchFileCreate       = "watchfilecreate"
+	Command_PreviewWatch          = "previewwatch"
+	Command_PreviewUnwatch        = "previewunwatch"
+	Command_GetVar                = "getvar"
+	Command_SetVar                = "setvar"
+	Command_RemoteMkdir           = "remotemkdir"
+	Command_RemoteGetInfo         = "remotegetinfo"
+	Command_RemoteInstallRcfiles  = "remoteinstallrcfiles"
+	Command_RemoteFileReadAt      = "remotefilereadat"
+	Command_RemoteFileReadRange   = "remotefilereadrange"
+	Command_RemoteListEntries     = "remotelistentries"
+	Command_RemoteFileWriteAt     = "remotefilewriteat"
+	Command_RemoteFileChecksum    = "remotefilechecksum"
+	Command_RemoteTarDir          = "remotetardir"
+	Command_RemoteUntar           = "remoteuntar"
+	Command_RemoteThumbnail       = "remotethumbnail"
+	Command_RemoteFileRemove      = "remotefileremove"
+	Command_RemoteRemoveTempFile  = "remoteremovetempfile"
+	Command_FileTransfer          = "filetransfer"
+	Command_FileMkdir             = "filemkdir"
+	Command_FileTouch             = "filetouch"
+	Command_FileRename            = "filerename"
+	Command_FileRemove            = "fileremove"
+	Command_FileSave              = "filesave"
+
+	Command_ConnStatus             = "connstatus"
+	Command_WslStatus              = "wslstatus"
+	Command_ConnEnsure             = "connensure"
+	Command_ConnReinstallWsh       = "connreinstallwsh"
+	Command_ConnConnect            = "connconnect"
+	Command_ConnConnectInteractive = "connconnectinteractive"
+	Command_AuthPromptResponse     = "authpromptresponse"
+	Command_ConnDisconnect         = "conndisconnect"
+	Command_ConnList               = "connlist"
+	Command_ConnListStatus         = "connliststatus"
+	Command_ConnTest               = "conntest"
+	Command_ConnTrustHostKey       = "conntrusthostkey"
+	Command_RemoteExec             = "remoteexec"
+	Command_RemoteExecInput        = "remoteexecinput"
+	Command_WslList                = "wsllist"
+	Command_WslDefaultDistro       = "wsldefaultdistro"
+	Command_DismissWshFail         = "dismisswshfail"
+	Command_ConnUpdateWsh          = "updatewsh"
+
+	Command_WorkspaceList   = "workspacelist"
+	Command_WorkspaceCreate = "workspacecreate"
+	Command_WorkspaceRename = "workspacerename"
+	Command_WorkspaceDelete = "workspacedelete"
+	Command_WorkspaceSwitch = "workspaceswitch"
+	Command_SnapshotExport  = "snapshotexport"
+	Command_SnapshotImport  = "snapshotimport"
+	Command_ListWindows     = "listwindows"
+	Command_LaunchWindow    = "launchwindow"
+	Command_ListTabs        = "listtabs"
+	Command_ListBlocks      = "listblocks"
+	Command_QueryBlocks     = "queryblocks"
 
 	Command_WebSelector      = "webselector"
 	Command_Notify           = "notify"
 	Command_FocusWindow      = "focuswindow"
+	Command_FocusTab         = "focustab"
+	Command_FocusBlock       = "focusblock"
+	Command_GetFocusedBlock  = "getfocusedblock"
 	Command_GetUpdateChannel = "getupdatechannel"
+	Command_CaptureBlock     = "captureblock"
 
 	Command_VDomCreateContext   = "vdomcreatecontext"
 	Command_VDomAsyncInitiation = "vdomasyncinitiation"
 	Command_VDomRender          = "vdomrender"
 	Command_VDomUrlRequest      = "vdomurlrequest"
 
-	Command_AiSendMessage = "aisendmessage"
+	Command_AiSendMessage   = "aisendmessage"
+	Command_AiStreamMessage = "aistreammessage"
+
+	Command_WebNavigate = "webnavigate"
+	Command_WebCheckUrl = "webcheckurl"
 )
 
 type RespOrErrorUnion[T any] struct {
@@ -121,12 +221,47 @@ type WshRpcInterface interface {
 	ControllerInputCommand(ctx context.Context, data CommandBlockInputData) error
 	ControllerStopCommand(ctx context.Context, blockId string) error
 	ControllerResyncCommand(ctx context.Context, data CommandControllerResyncData) error
+	ControllerRestartCommand(ctx context.Context, data CommandControllerRestartData) error
+	TermSendCommand(ctx context.Context, data CommandTermSendData) error
+	TermResizeCommand(ctx context.Context, data CommandTermResizeData) error
+	TermClearCommand(ctx context.Context, blockId string) error
+	TermTrimCommand(ctx context.Context, data CommandTermTrimData) (int64, error)
+	InjectPathCommand(ctx context.Context, data CommandInjectPathData) (string, error)
+	GetScrollbackCommand(ctx context.Context, data CommandGetScrollbackData) chan RespOrErrorUnion[CommandGetScrollbackRtnData]
+	SearchScrollbackCommand(ctx context.Context, data CommandSearchScrollbackData) (*CommandSearchScrollbackRtnData, error)
+	GetCmdHistoryForBlockCommand(ctx context.Context, data CommandGetCmdHistoryData) ([]CmdRecord, error)
+	SearchHistoryCommand(ctx context.Context, data CommandSearchHistoryData) (*CommandSearchHistoryRtnData, error)
+	ImportHistoryCommand(ctx context.Context, data CommandImportHistoryData) (int, error)
+	SaveLayoutPresetCommand(ctx context.Context, data CommandLayoutPresetData) error
+	ApplyLayoutPresetCommand(ctx context.Context, data CommandLayoutPresetData) ([]string, error)
+	ListLayoutPresetsCommand(ctx context.Context) ([]waveobj.LayoutPreset, error)
+	DeleteLayoutPresetCommand(ctx context.Context, name string) error
+	LayoutActionCommand(ctx context.Context, data CommandLayoutActionData) (*CommandLayoutActionRtnData, error)
+	SetBlockMagnifiedCommand(ctx context.Context, data CommandSetBlockMagnifiedData) (*CommandSetBlockMagnifiedRtnData, error)
 	ControllerAppendOutputCommand(ctx context.Context, data CommandControllerAppendOutputData) error
 	ResolveIdsCommand(ctx context.Context, data CommandResolveIdsData) (CommandResolveIdsRtnData, error)
-	CreateBlockCommand(ctx context.Context, data CommandCreateBlockData) (waveobj.ORef, error)
+	CreateBlockCommand(ctx context.Context, data CommandCreateBlockData) (CommandCreateBlockRtnData, error)
+	CreateBlocksCommand(ctx context.Context, data CommandCreateBlocksData) ([]string, error)
+	RenderHtmlCommand(ctx context.Context, data CommandRenderHtmlData) (CommandCreateBlockRtnData, error)
 	CreateSubBlockCommand(ctx context.Context, data CommandCreateSubBlockData) (waveobj.ORef, error)
-	DeleteBlockCommand(ctx context.Context, data CommandDeleteBlockData) error
+	DeleteBlockCommand(ctx context.Context, data CommandDeleteBlockData) (*CommandDeleteBlockRtnData, error)
+	DeleteBlocksCommand(ctx context.Context, data CommandDeleteBlocksData) (*DeleteBlocksRtnData, error)
+	LinkBlocksCommand(ctx context.Context, data CommandLinkBlocksData) (*waveobj.BlockLink, error)
+	UnlinkBlocksCommand(ctx context.Context, linkId string) error
+	ListBlockLinksCommand(ctx context.Context, blockId string) ([]*waveobj.BlockLink, error)
 	DeleteSubBlockCommand(ctx context.Context, data CommandDeleteBlockData) error
+	MoveBlockCommand(ctx context.Context, data CommandMoveBlockData) error
+	DuplicateBlockCommand(ctx context.Context, data CommandDuplicateBlockData) (waveobj.ORef, error)
+	CreateTabCommand(ctx context.Context, data CommandCreateTabData) (string, error)
+	CloseTabCommand(ctx context.Context, data CommandCloseTabData) error
+	RenameTabCommand(ctx context.Context, data CommandRenameTabData) error
+	MoveTabCommand(ctx context.Context, data CommandMoveTabData) error
+	SetTabPinnedCommand(ctx context.Context, data CommandSetTabPinnedData) error
+	SetTabGroupCommand(ctx context.Context, data CommandSetTabGroupData) error
+	SetTabTitleTemplateCommand(ctx context.Context, data CommandSetTabTitleTemplateData) error
+	GetTabTitleCommand(ctx context.Context, tabId string) (string, error)
+	CloseWindowCommand(ctx context.Context, data CommandCloseWindowData) error
+	SetWindowGeometryCommand(ctx context.Context, data CommandSetWindowGeometryData) (*waveobj.Window, error)
 	WaitForRouteCommand(ctx context.Context, data CommandWaitForRouteData) (bool, error)
 	FileCreateCommand(ctx context.Context, data CommandFileCreateData) error
 	FileDeleteCommand(ctx context.Context, data CommandFileData) error
@@ -144,13 +279,23 @@ type WshRpcInterface interface {
 	StreamTestCommand(ctx context.Context) chan RespOrErrorUnion[int]
 	StreamWaveAiCommand(ctx context.Context, request WaveAIStreamRequest) chan RespOrErrorUnion[WaveAIPacketType]
 	StreamCpuDataCommand(ctx context.Context, request CpuDataRequest) chan RespOrErrorUnion[TimeSeriesData]
+	GetMetricsCommand(ctx context.Context, data CommandGetMetricsData) ([]TimeSeriesData, error) // from the sysinfo history (see sysmetrics)
+	StreamEventsCommand(ctx context.Context, data CommandStreamEventsData) chan RespOrErrorUnion[eventbus.WSEventType]
 	TestCommand(ctx context.Context, data string) error
 	SetConfigCommand(ctx context.Context, data MetaSettingsType) error
 	SetConnectionsConfigCommand(ctx context.Context, data ConnConfigRequest) error
+	GetConfigPathCommand(ctx context.Context, data CommandConfigPathData) (*ConfigPathRtnData, error)
+	ResetConfigCommand(ctx context.Context, data CommandConfigPathData) (*ConfigPathRtnData, error)
 	BlockInfoCommand(ctx context.Context, blockId string) (*BlockInfoData, error)
 	WaveInfoCommand(ctx context.Context) (*WaveInfoData, error)
 	WshActivityCommand(ct context.Context, data map[string]int) error
 	ActivityCommand(ctx context.Context, data ActivityUpdate) error
+	RecordActivityCommand(ctx context.Context, data ActivityRecord) error
+	GetActivityStatsCommand(ctx context.Context, sinceDays int) ([]ActivityStat, error)
+	GetServerStatusCommand(ctx context.Context) (*ServerStatusData, error)
+	WatchFileCreateCommand(ctx context.Context, blockId string) error
+	PreviewWatchCommand(ctx context.Context, data CommandPreviewWatchData) error
+	PreviewUnwatchCommand(ctx context.Context, blockId string) error
 	GetVarCommand(ctx context.Context, data CommandVarData) (*CommandVarResponseData, error)
 	SetVarCommand(ctx context.Context, data CommandVarData) error
 	PathCommand(ctx context.Context, data PathCommandData) (string, error)
@@ -161,8 +306,15 @@ type WshRpcInterface interface {
 	ConnEnsureCommand(ctx context.Context, data ConnExtData) error
 	ConnReinstallWshCommand(ctx context.Context, data ConnExtData) error
 	ConnConnectCommand(ctx context.Context, connRequest ConnRequest) error
+	ConnConnectInteractiveCommand(ctx context.Context, data CommandConnConnectInteractiveData) chan RespOrErrorUnion[ConnAuthPrompt] // connects, streaming the auth prompts back (to be answered on the terminal)
+	AuthPromptResponseCommand(ctx context.Context, data CommandAuthPromptResponseData) error
 	ConnDisconnectCommand(ctx context.Context, connName string) error
 	ConnListCommand(ctx context.Context) ([]string, error)
+	ConnListStatusCommand(ctx context.Context) ([]ConnStatus, error)
+	ConnTestCommand(ctx context.Context, data CommandConnTestData) (CommandConnTestRtnData, error)
+	ConnTrustHostKeyCommand(ctx context.Context, data CommandConnTrustHostKeyData) error
+	RemoteExecCommand(ctx context.Context, data CommandRemoteExecData) chan RespOrErrorUnion[RemoteExecPacket] // runs a command over an ssh connection (without a block)
+	RemoteExecInputCommand(ctx context.Context, data CommandRemoteExecInputData) error                         // writes to the stdin of a running RemoteExecCommand
 	WslListCommand(ctx context.Context) ([]string, error)
 	WslDefaultDistroCommand(ctx context.Context) (string, error)
 	DismissWshFailCommand(ctx context.Context, connName string) error
@@ -183,13 +335,55 @@ type WshRpcInterface interface {
 	RemoteStreamCpuDataCommand(ctx context.Context) chan RespOrErrorUnion[TimeSeriesData]
 	RemoteGetInfoCommand(ctx context.Context) (RemoteInfo, error)
 	RemoteInstallRcFilesCommand(ctx context.Context) error
+	RemoteFileReadAtCommand(ctx context.Context, data CommandRemoteFileReadAtData) (string, error)
+	RemoteFileReadRangeCommand(ctx context.Context, data CommandRemoteFileReadRangeData) (*FileReadRangeRtnData, error)
+	RemoteListEntriesCommand(ctx context.Context, data CommandRemoteListEntriesData) (*RemoteListEntriesRtnData, error)
+	RemoteFileWriteAtCommand(ctx context.Context, data CommandRemoteFileWriteAtData) error
+	RemoteFileChecksumCommand(ctx context.Context, path string) (string, error)
+	RemoteTarDirCommand(ctx context.Context, path string) (string, error)
+	RemoteUntarCommand(ctx context.Context, data CommandRemoteUntarData) error
+	RemoteThumbnailCommand(ctx context.Context, data CommandRemoteThumbnailData) (*ThumbnailRtnData, error)
+	RemoteFileRemoveCommand(ctx context.Context, data CommandRemoteFileRemoveData) error
+	RemoteRemoveTempFileCommand(ctx context.Context, path string) error // only removes files made by wsh in the temp dir
+	FileTransferCommand(ctx context.Context, data CommandFileTransferData) chan RespOrErrorUnion[FileTransferProgress]
+
+	// the file operations of the preview block and wsh file (they run in wavesrv, see CommandFileOpData)
+	FileMkdirCommand(ctx context.Context, data CommandFileOpData) error
+	FileTouchCommand(ctx context.Context, data CommandFileOpData) error
+	FileRenameCommand(ctx context.Context, data CommandFileOpData) error
+	FileRemoveCommand(ctx context.Context, data CommandFileOpData) error
+	FileSaveCommand(ctx context.Context, data CommandFileSaveData) (*FileVersion, error)
 
 	// emain
 	WebSelectorCommand(ctx context.Context, data CommandWebSelectorData) ([]string, error)
 	NotifyCommand(ctx context.Context, notificationOptions WaveNotificationOptions) error
 	FocusWindowCommand(ctx context.Context, windowId string) error
+	FocusTabCommand(ctx context.Context, tabId string) error
+	FocusBlockCommand(ctx context.Context, blockId string) error
+	GetFocusedBlockCommand(ctx context.Context, tabId string) (string, error)
+	CaptureBlockCommand(ctx context.Context, data CommandCaptureBlockData) chan RespOrErrorUnion[string] // streams the png (base64, in chunks)
 
 	WorkspaceListCommand(ctx context.Context) ([]WorkspaceInfoData, error)
+	WorkspaceCreateCommand(ctx context.Context, data CommandWorkspaceCreateData) (*waveobj.Workspace, error)
+	WorkspaceRenameCommand(ctx context.Context, data CommandWorkspaceRenameData) error
+	WorkspaceDeleteCommand(ctx context.Context, data CommandWorkspaceDeleteData) error
+	WorkspaceSwitchCommand(ctx context.Context, data CommandWorkspaceSwitchData) error
+	DebugCacheStatsCommand(ctx context.Context) (DebugCacheStatsData, error)
+	DebugIntegrityCommand(ctx context.Context, data CommandDebugIntegrityData) (*DebugIntegrityRtnData, error)
+	DebugDBVersionCommand(ctx context.Context) (*DebugDBVersionRtnData, error)
+	SetLogLevelCommand(ctx context.Context, data CommandSetLogLevelData) error
+	LogTailCommand(ctx context.Context, data CommandLogTailData) chan RespOrErrorUnion[wlog.LogRecord]
+	SetSecretCommand(ctx context.Context, data CommandSetSecretData) error
+	DeleteSecretCommand(ctx context.Context, name string) (bool, error)
+	GetSecretNamesCommand(ctx context.Context) ([]string, error)
+	ExportAIConversationCommand(ctx context.Context, data CommandExportAIConversationData) chan RespOrErrorUnion[string] // streams the export (in chunks)
+	SnapshotExportCommand(ctx context.Context) (string, error)
+	SnapshotImportCommand(ctx context.Context, data CommandSnapshotImportData) (*SnapshotImportRtnData, error)
+	ListWindowsCommand(ctx context.Context) ([]WindowListEntry, error)
+	LaunchWindowCommand(ctx context.Context, data CommandLaunchWindowData) (*LaunchWindowRtnData, error)
+	ListTabsCommand(ctx context.Context, data CommandListData) ([]TabListEntry, error)
+	ListBlocksCommand(ctx context.Context, data CommandListData) ([]BlockListEntry, error)
+	QueryBlocksCommand(ctx context.Context, data CommandQueryBlocksData) ([]BlockListEntry, error)
 	GetUpdateChannelCommand(ctx context.Context) (string, error)
 
 	// terminal
@@ -198,6 +392,11 @@ type WshRpcInterface interface {
 
 	// ai
 	AiSendMessageCommand(ctx context.Context, data AiMessageData) error
+	AiStreamMessageCommand(ctx context.Context, data AiMessageData) chan RespOrErrorUnion[WaveAIPacketType] // sends the message and streams back the response (as it is shown in the block)
+
+	// web
+	WebNavigateCommand(ctx context.Context, data CommandWebNavigateData) error
+	WebCheckUrlCommand(ctx context.Context, data CommandWebCheckUrlData) (string, error) // returns the canonical url
 
 	// proc
 	VDomRenderCommand(ctx context.Context, data vdom.VDomFrontendUpdate) chan RespOrErrorUnion[*vdom.VDomBackendUpdate]
@@ -295,10 +494,84 @@ type CommandResolveIdsRtnData struct {
 
 type CommandCreateBlockData struct {
 	TabId     string               `json:"tabid" wshcontext:"TabId"`
+	WindowId  string               `json:"windowid,omitempty"` // if set (and tabid is not in this window), the block goes in the window's active tab
 	BlockDef  *waveobj.BlockDef    `json:"blockdef"`
 	RtOpts    *waveobj.RuntimeOpts `json:"rtopts,omitempty"`
 	Magnified bool                 `json:"magnified,omitempty"`
 	Ephemeral bool                 `json:"ephemeral,omitempty"`
+	IndexArr  []int                `json:"indexarr,omitempty"` // insert after the layout node at this path (takes precedence over position)
+	Position  string               `json:"position,omitempty"` // "end" or "after:[blockid]"
+	// for a preview block, focus a preview of the same file in the tab (if there is one) instead of creating a block
+	ReuseExisting bool `json:"reuseexisting,omitempty"`
+}
+
+type CommandCreateBlockRtnData struct {
+	BlockORef waveobj.ORef `json:"blockoref"`
+	BlockId   string       `json:"blockid"`
+	TabId     string       `json:"tabid"`
+	WindowId  string       `json:"windowid"`
+	IndexArr  []int        `json:"indexarr,omitempty"` // the layout position (not set when the block is inserted in the default position)
+	Reused    bool         `json:"reused,omitempty"`   // an existing block was focused (see ReuseExisting)
+}
+
+// for "wsh render --html", opens a web block with the html as a data url (or replaces the html in blockid)
+type CommandRenderHtmlData struct {
+	Html      string `json:"html"`
+	BlockId   string `json:"blockid,omitempty"` // a block opened by an earlier "wsh render --html"
+	TabId     string `json:"tabid" wshcontext:"TabId"`
+	Position  string `json:"position,omitempty"` // "end" or "after:[blockid]"
+	Magnified bool   `json:"magnified,omitempty"`
+}
+
+type CommandCreateTabData struct {
+	WorkspaceId string `json:"workspaceid,omitempty"`
+	WindowId    string `json:"windowid,omitempty"`       // creates the tab in the window's workspace (when workspaceid is not set)
+	TabId       string `json:"tabid" wshcontext:"TabId"` // used to find the workspace when workspaceid and windowid are not set
+	TabName     string `json:"tabname,omitempty"`
+	NoActivate  bool   `json:"noactivate,omitempty"`
+	Pinned      bool   `json:"pinned,omitempty"`
+}
+
+type CommandCloseTabData struct {
+	TabId     string `json:"tabid" wshcontext:"TabId"`
+	Force     bool   `json:"force,omitempty"`     // allow closing a pinned tab or the last tab (which closes the window), with allblocks also close pinned blocks
+	AllBlocks bool   `json:"allblocks,omitempty"` // close all the blocks in the tab, but keep the tab
+}
+
+type CommandRenameTabData struct {
+	TabId string `json:"tabid" wshcontext:"TabId"`
+	Name  string `json:"name"`
+}
+
+type CommandMoveTabData struct {
+	TabId string `json:"tabid" wshcontext:"TabId"`
+	Index int    `json:"index"` // the new position within the pinned (or unpinned) tabs, clamped to the number of tabs
+}
+
+type CommandSetTabPinnedData struct {
+	TabId  string `json:"tabid" wshcontext:"TabId"`
+	Pinned bool   `json:"pinned"`
+}
+
+type CommandSetTabGroupData struct {
+	TabId     string `json:"tabid" wshcontext:"TabId"`
+	GroupName string `json:"groupname"` // "" removes the tab from its group
+}
+
+type CommandSetTabTitleTemplateData struct {
+	TabId    string `json:"tabid" wshcontext:"TabId"`
+	Template string `json:"template"` // e.g. "{conn}:{cwd}" (without braces it is a static title, empty clears it)
+}
+
+type CommandCloseWindowData struct {
+	WindowId string `json:"windowid,omitempty"`
+	TabId    string `json:"tabid" wshcontext:"TabId"` // used to find the window when windowid is not set
+}
+
+type CommandSetWindowGeometryData struct {
+	WindowId string                 `json:"windowid,omitempty"`
+	TabId    string                 `json:"tabid" wshcontext:"TabId"` // used to find the window when windowid is not set
+	Geometry waveobj.WindowGeometry `json:"geometry"`
 }
 
 type CommandCreateSubBlockData struct {
@@ -318,6 +591,11 @@ type CommandControllerResyncData struct {
 	RtOpts       *waveobj.RuntimeOpts `json:"rtopts,omitempty"`
 }
 
+type CommandControllerRestartData struct {
+	BlockId string `json:"blockid"`
+	Force   bool   `json:"force,omitempty"` // restart right away, even if the shell keeps crashing
+}
+
 type CommandControllerAppendOutputData struct {
 	BlockId string `json:"blockid"`
 	Data64  string `json:"data64"`
@@ -330,6 +608,165 @@ type CommandBlockInputData struct {
 	TermSize    *waveobj.TermSize `json:"termsize,omitempty"`
 }
 
+type CommandTermSendData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Data64  string `json:"data64"`
+	Start   bool   `json:"start,omitempty"` // start the block's controller if it isn't running
+}
+
+// the path is quoted for the block's shell and typed into the terminal (without a newline)
+type CommandInjectPathData struct {
+	TabId   string `json:"tabid" wshcontext:"TabId"`
+	BlockId string `json:"blockid,omitempty"` // defaults to the focused block of the tab
+	Path    string `json:"path"`
+}
+
+type CommandTermResizeData struct {
+	BlockId  string           `json:"blockid" wshcontext:"BlockId"`
+	TermSize waveobj.TermSize `json:"termsize"`
+}
+
+// trims the scrollback to (about) the last KeepBytes bytes (at a line boundary)
+type CommandTermTrimData struct {
+	BlockId   string `json:"blockid" wshcontext:"BlockId"`
+	KeepBytes int64  `json:"keepbytes"`
+}
+
+type CommandGetScrollbackData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Lines   int    `json:"lines,omitempty"`  // only the last n lines (0 for all of the scrollback)
+	Raw     bool   `json:"raw,omitempty"`    // keep the ansi escape sequences
+	Follow  bool   `json:"follow,omitempty"` // keep streaming new output until canceled
+}
+
+type CommandGetCmdHistoryData struct {
+	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Limit   int    `json:"limit,omitempty"` // only the last n commands (0 for all that are kept)
+}
+
+// a command run in a terminal block, reported by the shell integration (see "wsh shell-init")
+type CmdRecord struct {
+	Seq      int    `json:"seq"`
+	Cmd      string `json:"cmd"`
+	Cwd      string `json:"cwd,omitempty"`
+	StartTs  int64  `json:"startts"`
+	EndTs    int64  `json:"endts"`
+	ExitCode *int   `json:"exitcode,omitempty"` // nil if the shell exited (or a new command started) before it finished
+}
+
+// a command in the global history (from all of the blocks, and imported shell history files)
+type HistoryRecord struct {
+	HistoryId  int64  `json:"historyid"`
+	Ts         int64  `json:"ts"` // when it started (0 if not known, for imported commands)
+	Cmd        string `json:"cmd"`
+	Cwd        string `json:"cwd,omitempty"`
+	Connection string `json:"connection,omitempty"` // "" for local
+	ExitCode   *int   `json:"exitcode,omitempty"`
+	DurationMs int64  `json:"durationms,omitempty"`
+	BlockId    string `json:"blockid,omitempty"`
+	Source     string `json:"source"` // "wave" or "import:<shell>"
+	Count      int    `json:"count"`  // consecutive runs that were merged into this record
+}
+
+type CommandSearchHistoryData struct {
+	Query      string `json:"query,omitempty"`      // a substring of the command (case sensitive)
+	Prefix     bool   `json:"prefix,omitempty"`     // the command starts with the query
+	Cwd        string `json:"cwd,omitempty"`        // run in this directory (or one under it)
+	Connection string `json:"connection,omitempty"` // "local" also matches the commands without a connection
+	Failed     bool   `json:"failed,omitempty"`     // only commands with a non-zero exit code
+	Offset     int    `json:"offset,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+}
+
+// newest first
+type CommandSearchHistoryRtnData struct {
+	Records []HistoryRecord `json:"records"`
+	HasMore bool            `json:"hasmore,omitempty"`
+}
+
+type CommandImportHistoryData struct {
+	Shell      string          `json:"shell"` // tagged as source "import:<shell>"
+	Connection string          `json:"connection,omitempty"`
+	Replace    bool            `json:"replace,omitempty"` // delete the commands imported from this shell before
+	Records    []HistoryRecord `json:"records"`           // oldest first (only cmd and ts are used)
+}
+
+type CommandGetScrollbackRtnData struct {
+	Data64 string `json:"data64"`
+}
+
+// searches the scrollback of a terminal block line by line (the ansi escape sequences are removed before matching)
+type CommandSearchScrollbackData struct {
+	BlockId       string `json:"blockid" wshcontext:"BlockId"`
+	Pattern       string `json:"pattern"` // a go regexp
+	CaseSensitive bool   `json:"casesensitive,omitempty"`
+	MaxResults    int    `json:"maxresults,omitempty"` // defaults to 1000
+}
+
+type ScrollbackMatch struct {
+	Line          int    `json:"line"`          // the line number in the scrollback (starting at 1)
+	Offset        int64  `json:"offset"`        // the offset of the match in the scrollback file (with the escape sequences)
+	Length        int64  `json:"length"`        // the length of the match in the scrollback file
+	Snippet       string `json:"snippet"`       // the (stripped) line, shortened around the match for long lines
+	SnippetOffset int    `json:"snippetoffset"` // the offset of the match in the snippet
+	SnippetLength int    `json:"snippetlength"`
+}
+
+type CommandSearchScrollbackRtnData struct {
+	Matches      []ScrollbackMatch `json:"matches"`
+	ScannedBytes int64             `json:"scannedbytes"`
+	Truncated    bool              `json:"truncated,omitempty"`  // stopped at maxresults
+	Incomplete   bool              `json:"incomplete,omitempty"` // stopped at the time or size limit, the rest of the scrollback wasn't searched
+}
+
+const (
+	LayoutAction_Split     = "split"  // put a new block (BlockDef) next to the block, in the direction
+	LayoutAction_Resize    = "resize" // set the block's share of its split (Size)
+	LayoutAction_Swap      = "swap"   // exchange the positions of the block and OtherBlockId
+	LayoutAction_Magnify   = "magnify"
+	LayoutAction_Demagnify = "demagnify"
+)
+
+const (
+	SplitDirection_Left   = "left"
+	SplitDirection_Right  = "right"
+	SplitDirection_Top    = "top"
+	SplitDirection_Bottom = "bottom"
+)
+
+type CommandLayoutActionData struct {
+	ActionType   string            `json:"actiontype"`
+	BlockId      string            `json:"blockid"`
+	OtherBlockId string            `json:"otherblockid,omitempty"` // swap
+	Direction    string            `json:"direction,omitempty"`    // split
+	BlockDef     *waveobj.BlockDef `json:"blockdef,omitempty"`     // split
+	Size         float64           `json:"size,omitempty"`         // resize, a fraction between 0 and 1 (exclusive)
+}
+
+type CommandLayoutActionRtnData struct {
+	BlockId string `json:"blockid,omitempty"` // the new block (split)
+}
+
+// magnifying a block un-magnifies the other block in its tab (if there is one), and focuses the tab
+type CommandSetBlockMagnifiedData struct {
+	BlockId   string `json:"blockid"`
+	Magnified bool   `json:"magnified"`
+	Toggle    bool   `json:"toggle,omitempty"`  // flip the block's current state (magnified is ignored)
+	NoFocus   bool   `json:"nofocus,omitempty"` // don't switch to the block's tab
+}
+
+type CommandSetBlockMagnifiedRtnData struct {
+	TabId     string `json:"tabid"`
+	Magnified bool   `json:"magnified"` // the new state
+}
+
+type CommandLayoutPresetData struct {
+	TabId         string `json:"tabid" wshcontext:"TabId"`
+	Name          string `json:"name"`
+	ClearExisting bool   `json:"clearexisting,omitempty"` // for apply, close the tab's current blocks first
+	Force         bool   `json:"force,omitempty"`         // with clearexisting, also close pinned blocks
+}
+
 type CommandFileDataAt struct {
 	Offset int64 `json:"offset"`
 	Size   int64 `json:"size,omitempty"`
@@ -381,6 +818,52 @@ type CommandWaitForRouteData struct {
 
 type CommandDeleteBlockData struct {
 	BlockId string `json:"blockid" wshcontext:"BlockId"`
+	Kill    bool   `json:"kill,omitempty"`    // kill the processes started by the block's shell (not just the shell)
+	GraceMs int    `json:"gracems,omitempty"` // with kill, how long the processes get to exit before they are force killed
+}
+
+type CommandDeleteBlockRtnData struct {
+	NumKilled int `json:"numkilled,omitempty"`
+}
+
+type CommandDeleteBlocksData struct {
+	BlockIds      []string `json:"blockids"`
+	IncludePinned bool     `json:"includepinned,omitempty"`
+}
+
+type DeleteBlocksRtnData struct {
+	Deleted []string `json:"deleted"`
+	Skipped []string `json:"skipped,omitempty"` // pinned blocks
+}
+
+type CommandLinkBlocksData struct {
+	SrcBlockId string `json:"srcblockid"`
+	DstBlockId string `json:"dstblockid"`
+	RuleType   string `json:"ruletype"`
+	Rule       string `json:"rule"`
+}
+
+type BlockDefWithLayout struct {
+	BlockDef *waveobj.BlockDef `json:"blockdef"`
+	IndexArr []int             `json:"indexarr,omitempty"` // position in the layout (inserted like a new block if empty)
+	Size     *uint             `json:"size,omitempty"`
+	Focused  bool              `json:"focused,omitempty"`
+}
+
+type CommandCreateBlocksData struct {
+	TabId  string               `json:"tabid" wshcontext:"TabId"`
+	Blocks []BlockDefWithLayout `json:"blocks"`
+}
+
+type CommandMoveBlockData struct {
+	BlockId   string `json:"blockid" wshcontext:"BlockId"`
+	DestTabId string `json:"desttabid"`
+	IndexArr  []int  `json:"indexarr,omitempty"`
+}
+
+type CommandDuplicateBlockData struct {
+	BlockId string              `json:"blockid" wshcontext:"BlockId"`
+	Meta    waveobj.MetaMapType `json:"meta,omitempty"` // merged into the copied meta
 }
 
 type CommandEventReadHistoryData struct {
@@ -401,10 +884,22 @@ type WaveAIPromptMessageType struct {
 	Name    string `json:"name,omitempty"`
 }
 
+// a message in a block's saved ai conversation (see waveai.AppendConversation), seq orders the conversation
+type WaveAIConversationMessage struct {
+	Seq              int    `json:"seq,omitempty"`
+	Role             string `json:"role"`
+	Content          string `json:"content"`
+	Ts               int64  `json:"ts,omitempty"`
+	PromptTokens     int    `json:"prompttokens,omitempty"`
+	CompletionTokens int    `json:"completiontokens,omitempty"`
+}
+
 type WaveAIOptsType struct {
 	Model      string `json:"model"`
+	Provider   string `json:"provider,omitempty"` // selects the backend (see waveai.AIProvider_*), overrides apitype
 	APIType    string `json:"apitype,omitempty"`
 	APIToken   string `json:"apitoken"`
+	APIKeyRef  string `json:"apikeyref,omitempty"` // the secret with the api key (replaces apitoken)
 	OrgID      string `json:"orgid,omitempty"`
 	APIVersion string `json:"apiversion,omitempty"`
 	BaseURL    string `json:"baseurl,omitempty"`
@@ -452,6 +947,7 @@ type FileInfo struct {
 	IsDir    bool        `json:"isdir,omitempty"`
 	MimeType string      `json:"mimetype,omitempty"`
 	ReadOnly bool        `json:"readonly,omitempty"` // this is not set for fileinfo's returned from directory listings
+	RealPath string      `json:"realpath,omitempty"` // the path with the symlinks resolved (separators are "/"), only set by fileinfo and filejoin
 }
 
 type CommandRemoteStreamFileData struct {
@@ -465,16 +961,172 @@ type CommandRemoteStreamFileRtnData struct {
 }
 
 type CommandRemoteWriteFileData struct {
+	Path       string       `json:"path"`
+	Data64     string       `json:"data64"`
+	CreateMode os.FileMode  `json:"createmode,omitempty"`
+	Version    *FileVersion `json:"version,omitempty"` // fail with ErrorCode_Conflict if the file isn't this version
+}
+
+// files up to this size have a Sha256 in their FileVersion
+const FileVersionHashMaxSize = 10 * 1024 * 1024
+
+// the version of a file when it was read (by the edit view).  when the modtime or size is different, the file
+// only changed if its sha256 is different too (or there is no sha256).
+type FileVersion struct {
+	ModTime int64  `json:"modtime"`
+	Size    int64  `json:"size"`
+	Sha256  string `json:"sha256,omitempty"`
+}
+
+// the error data of an ErrorCode_Conflict error
+type FileConflictData struct {
+	Path           string `json:"path"`
+	ExpectedSha256 string `json:"expectedsha256,omitempty"`
+	CurrentSha256  string `json:"currentsha256,omitempty"` // "" if the file was removed (or is too large)
+	CurrentModTime int64  `json:"currentmodtime,omitempty"`
+	CurrentSize    int64  `json:"currentsize,omitempty"`
+	Removed        bool   `json:"removed,omitempty"`
+}
+
+// saves a file from the edit view.  the save fails with ErrorCode_Conflict if version is set and the file changed
+// since it was read, force saves it anyway (to overwrite the other change).
+type CommandFileSaveData struct {
+	Connection string       `json:"connection,omitempty"`
+	Path       string       `json:"path"`
+	Data64     string       `json:"data64"`
+	Version    *FileVersion `json:"version,omitempty"`
+	Force      bool         `json:"force,omitempty"`
+}
+
+type CommandRemoteFileReadAtData struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+}
+
+// sort keys for directory listings
+const (
+	FileListSort_Name  = "name"
+	FileListSort_Size  = "size"
+	FileListSort_MTime = "mtime"
+)
+
+type FileListOpts struct {
+	Sort       string `json:"sort,omitempty"` // name (the default), size, or mtime
+	SortDesc   bool   `json:"sortdesc,omitempty"`
+	ShowHidden bool   `json:"showhidden,omitempty"`
+	Filter     string `json:"filter,omitempty"` // only entries whose name contains this (case insensitive)
+	Offset     int    `json:"offset,omitempty"`
+	Limit      int    `json:"limit,omitempty"` // defaults to 500
+}
+
+type CommandRemoteListEntriesData struct {
+	Path string        `json:"path"`
+	Opts *FileListOpts `json:"opts,omitempty"`
+}
+
+type RemoteListEntriesRtnData struct {
+	Entries []*FileInfo `json:"entries"`
+	Parent  *FileInfo   `json:"parent,omitempty"` // the ".." entry (not set for the root directory)
+	Offset  int         `json:"offset"`           // the resolved offset of the first entry
+	Total   int         `json:"total"`            // the number of entries that match (across all pages)
+}
+
+type CommandRemoteFileReadRangeData struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`         // a negative offset is from the end of the file (a tail read)
+	Size   int64  `json:"size,omitempty"` // defaults to 256k
+}
+
+type FileReadRangeRtnData struct {
+	Data64   string `json:"data64,omitempty"`
+	Offset   int64  `json:"offset"` // the resolved offset of the data
+	FileSize int64  `json:"filesize"`
+	EOF      bool   `json:"eof,omitempty"` // the data ends at the end of the file
+}
+
+type CommandRemoteFileWriteAtData struct {
 	Path       string      `json:"path"`
-	Data64     string      `json:"data64"`
+	Offset     int64       `json:"offset"`
+	Data64     string      `json:"data64,omitempty"`
+	Truncate   bool        `json:"truncate,omitempty"` // truncate the file at offset before writing
 	CreateMode os.FileMode `json:"createmode,omitempty"`
 }
 
+type CommandRemoteUntarData struct {
+	TarPath  string `json:"tarpath"`
+	DestPath string `json:"destpath"`
+}
+
+type CommandRemoteThumbnailData struct {
+	Path     string `json:"path"`
+	MaxDim   int    `json:"maxdim,omitempty"`   // the max width and height of the thumbnail (defaults to 256)
+	MetaOnly bool   `json:"metaonly,omitempty"` // only return the image's metadata (no thumbnail)
+}
+
+// an image's metadata and a jpeg thumbnail of it (already rotated for the orientation).  images that can't be
+// thumbnailed return an error with ErrorCode_NoThumbnail.
+type ThumbnailRtnData struct {
+	MimeType    string `json:"mimetype"`
+	Width       int    `json:"width"` // the image's size as it is displayed (after the orientation is applied)
+	Height      int    `json:"height"`
+	Orientation int    `json:"orientation,omitempty"` // the exif orientation (1-8), 0 if the image doesn't have one
+	Data64      string `json:"data64,omitempty"`      // the jpeg thumbnail
+	ThumbWidth  int    `json:"thumbwidth,omitempty"`
+	ThumbHeight int    `json:"thumbheight,omitempty"`
+}
+
+// copies a file (or a directory with recursive) between two connections ("" is the local machine)
+type CommandFileTransferData struct {
+	SrcConn   string `json:"srcconn,omitempty"`
+	SrcPath   string `json:"srcpath"`
+	DestConn  string `json:"destconn,omitempty"`
+	DestPath  string `json:"destpath"`
+	Resume    bool   `json:"resume,omitempty"`    // continue a partial copy (from the size of the destination file)
+	Recursive bool   `json:"recursive,omitempty"` // directories are sent as a tar file
+	MaxSize   int64  `json:"maxsize,omitempty"`   // refuse to copy more than this (0 for no limit)
+}
+
+type CommandRemoteFileRemoveData struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive,omitempty"` // remove a directory with everything in it
+	Trash     bool   `json:"trash,omitempty"`     // move to the trash (or recycle bin) instead
+}
+
+// a file operation on a connection ("" is the local machine).  the paths must be absolute (or start with "~"), and
+// paths (and newpath) must be under root unless norootcheck is set (root is the directory the block is showing).
+type CommandFileOpData struct {
+	Connection  string `json:"connection,omitempty"`
+	Root        string `json:"root,omitempty"`
+	NoRootCheck bool   `json:"norootcheck,omitempty"`
+	Path        string `json:"path"`
+	NewPath     string `json:"newpath,omitempty"`   // for rename
+	Recursive   bool   `json:"recursive,omitempty"` // for remove
+	Trash       bool   `json:"trash,omitempty"`     // for remove
+}
+
+// sent (as a dirchange event) after a file operation changes the entries of a directory
+type DirChangeData struct {
+	Connection string `json:"connection"`
+	Dir        string `json:"dir"`
+}
+
+type FileTransferProgress struct {
+	DestPath    string `json:"destpath"`
+	Size        int64  `json:"size"`
+	Transferred int64  `json:"transferred"` // includes the resumed part
+	ResumedAt   int64  `json:"resumedat,omitempty"`
+	IsDir       bool   `json:"isdir,omitempty"`
+	Done        bool   `json:"done,omitempty"`
+	Sha256      string `json:"sha256,omitempty"`
+}
+
 type ConnKeywords struct {
 	ConnWshEnabled          *bool  `json:"conn:wshenabled,omitempty"`
 	ConnAskBeforeWshInstall *bool  `json:"conn:askbeforewshinstall,omitempty"`
 	ConnOverrideConfig      bool   `json:"conn:overrideconfig,omitempty"`
 	ConnWshPath             string `json:"conn:wshpath,omitempty"`
+	ConnAutoReconnect       *bool  `json:"conn:autoreconnect,omitempty"`
 
 	DisplayHidden *bool   `json:"display:hidden,omitempty"`
 	DisplayOrder  float32 `json:"display:order,omitempty"`
@@ -514,7 +1166,11 @@ type RemoteInfo struct {
 }
 
 const (
-	TimeSeries_Cpu = "cpu"
+	TimeSeries_Cpu  = "cpu"
+	TimeSeries_Mem  = "mem"
+	TimeSeries_Load = "load"
+	TimeSeries_Disk = "disk"
+	TimeSeries_Net  = "net"
 )
 
 type TimeSeriesData struct {
@@ -522,6 +1178,13 @@ type TimeSeriesData struct {
 	Values map[string]float64 `json:"values"`
 }
 
+type CommandGetMetricsData struct {
+	Connection string   `json:"connection,omitempty"` // defaults to local
+	Metrics    []string `json:"metrics,omitempty"`    // "cpu" selects cpu and cpu:0, cpu:1, etc. (empty selects all)
+	Count      int      `json:"count,omitempty"`      // the number of samples (0 for all of the history)
+	IntervalMs int      `json:"intervalms,omitempty"` // the spacing of the samples (defaults to every sample)
+}
+
 type MetaSettingsType struct {
 	waveobj.MetaMapType
 }
@@ -546,6 +1209,24 @@ type ConnConfigRequest struct {
 	MetaMapType waveobj.MetaMapType `json:"metamaptype"`
 }
 
+type CommandConfigPathData struct {
+	Section string `json:"section,omitempty"` // a config file name, "settings" (the default), "connections", "presets/ai", etc.
+}
+
+type ConfigPathRtnData struct {
+	Section    string `json:"section"` // the file name (relative to the config dir)
+	Path       string `json:"path"`
+	Exists     bool   `json:"exists"`
+	BackupPath string `json:"backuppath,omitempty"` // the file before it was reset (see ResetConfigCommand)
+}
+
+// where a connection in the connection list comes from (connections.json takes precedence over the ssh config)
+const (
+	ConnSource_Connections = "connections"
+	ConnSource_SshConfig   = "sshconfig"
+	ConnSource_Wsl         = "wsl"
+)
+
 type ConnStatus struct {
 	Status        string `json:"status"`
 	WshEnabled    bool   `json:"wshenabled"`
@@ -554,9 +1235,52 @@ type ConnStatus struct {
 	HasConnected  bool   `json:"hasconnected"` // true if it has *ever* connected successfully
 	ActiveConnNum int    `json:"activeconnnum"`
 	Error         string `json:"error,omitempty"`
+	ErrorHop      string `json:"errorhop,omitempty"` // the jump host (ProxyJump) the connection failed at
 	WshError      string `json:"wsherror,omitempty"`
 	NoWshReason   string `json:"nowshreason,omitempty"`
 	WshVersion    string `json:"wshversion,omitempty"`
+	Source        string `json:"source,omitempty"` // only set by the connection list (see ConnSource_*)
+
+	// set while the connection is being re-established after an unexpected disconnect
+	Reconnecting     bool  `json:"reconnecting,omitempty"`
+	ReconnectAttempt int   `json:"reconnectattempt,omitempty"`
+	NextReconnectTs  int64 `json:"nextreconnectts,omitempty"` // 0 while an attempt is running
+}
+
+type CommandConnTestData struct {
+	ConnName string `json:"connname"`
+	Timeout  int    `json:"timeout,omitempty"` // in ms
+}
+
+type CommandConnTestRtnData struct {
+	Connection    string `json:"connection"`
+	AuthMethod    string `json:"authmethod"`
+	ConnectTimeMs int64  `json:"connecttimems"`
+	LatencyMs     int64  `json:"latencyms"`
+}
+
+const (
+	WebNavigateAction_Reload  = "reload"
+	WebNavigateAction_Back    = "back"
+	WebNavigateAction_Forward = "forward"
+)
+
+type CommandWebNavigateData struct {
+	BlockId string `json:"blockid"`
+	Action  string `json:"action"`
+}
+
+// a url a web block is about to open (see wcore.CheckWebNavigation)
+type CommandWebCheckUrlData struct {
+	BlockId string `json:"blockid"`
+	Url     string `json:"url"`
+}
+
+// a web block was not allowed to open a url
+type WebNavBlockedData struct {
+	BlockId string `json:"blockid"`
+	Url     string `json:"url"`
+	Reason  string `json:"reason"`
 }
 
 type WebSelectorOpts struct {
@@ -581,9 +1305,10 @@ type BlockInfoData struct {
 }
 
 type WaveNotificationOptions struct {
-	Title  string `json:"title,omitempty"`
-	Body   string `json:"body,omitempty"`
-	Silent bool   `json:"silent,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Body    string `json:"body,omitempty"`
+	Silent  bool   `json:"silent,omitempty"`
+	BlockId string `json:"blockid,omitempty"` // the block that sent the notification (clicking the notification focuses it)
 }
 
 type VDomUrlRequestData struct {
@@ -612,6 +1337,202 @@ type WorkspaceInfoData struct {
 	WorkspaceData *waveobj.Workspace `json:"workspacedata"`
 }
 
+type CommandWorkspaceCreateData struct {
+	Name  string `json:"name"`
+	Icon  string `json:"icon,omitempty"`
+	Color string `json:"color,omitempty"`
+}
+
+type CommandWorkspaceRenameData struct {
+	WorkspaceId string `json:"workspaceid"`
+	Name        string `json:"name"`
+}
+
+// hit/miss counts for the wstore read cache
+type DebugCacheStatsData struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+type CommandDebugIntegrityData struct {
+	Repair        bool `json:"repair,omitempty"`
+	DeleteOrphans bool `json:"deleteorphans,omitempty"` // delete orphans instead of re-parenting them (with repair)
+}
+
+type IntegrityIssueData struct {
+	ORef   string `json:"oref"`
+	Field  string `json:"field,omitempty"`
+	RefId  string `json:"refid,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// the issues are the ones found before the repair
+type DebugIntegrityRtnData struct {
+	Dangling   []IntegrityIssueData `json:"dangling"`
+	Orphans    []IntegrityIssueData `json:"orphans"`
+	Repaired   bool                 `json:"repaired,omitempty"`
+	NumUpdated int                  `json:"numupdated,omitempty"`
+	NumDeleted int                  `json:"numdeleted,omitempty"`
+}
+
+// saved in the client meta (client:loglevels)
+type CommandSetLogLevelData struct {
+	Subsystem string `json:"subsystem,omitempty"` // empty (or "*") for the default level
+	Level     string `json:"level,omitempty"`     // debug, info, warn, or error ("" removes the subsystem's level)
+}
+
+type CommandLogTailData struct {
+	Level      string   `json:"level,omitempty"`      // the lowest level to send (default info)
+	Subsystems []string `json:"subsystems,omitempty"` // empty for all
+	ReqId      string   `json:"reqid,omitempty"`      // only the records logged for this request
+}
+
+// there is no command to get a secret's value, secrets are only used by the server (see secretstore)
+type CommandSetSecretData struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type DBVersionInfo struct {
+	Store    string `json:"store"`
+	Version  uint   `json:"version"`
+	Expected uint   `json:"expected"` // the version this build migrates the store to
+	Dirty    bool   `json:"dirty,omitempty"`
+}
+
+type DebugDBVersionRtnData struct {
+	Stores []DBVersionInfo `json:"stores"`
+}
+
+type CommandSnapshotImportData struct {
+	Data      string `json:"data"` // snapshot json (from SnapshotExportCommand)
+	KeepIds   bool   `json:"keepids,omitempty"`
+	NewWindow bool   `json:"newwindow,omitempty"` // open the first imported workspace in a new window
+}
+
+type SnapshotImportRtnData struct {
+	WorkspaceIds []string `json:"workspaceids"`
+	NumTabs      int      `json:"numtabs"`
+	NumBlocks    int      `json:"numblocks"`
+	WindowId     string   `json:"windowid,omitempty"`
+}
+
+// used by wsh launch (also passed to the app as --wave-launch when the server isn't running)
+type CommandLaunchWindowData struct {
+	Workspace string            `json:"workspace,omitempty"` // workspace name or id (defaults to a new workspace)
+	BlockDef  *waveobj.BlockDef `json:"blockdef,omitempty"`  // a block to open in the workspace's active tab
+	// the window's tab (a new tab when the workspace is already open)
+	TabName string                        `json:"tabname,omitempty"`
+	Layout  []waveobj.PortableLayoutEntry `json:"layout,omitempty"` // the tab's blocks (the new tab layout by default)
+}
+
+type LaunchWindowRtnData struct {
+	WindowId string `json:"windowid"`
+	TabId    string `json:"tabid"`
+	BlockId  string `json:"blockid,omitempty"`
+}
+
+type CommandWorkspaceDeleteData struct {
+	WorkspaceId       string `json:"workspaceid"`
+	Force             bool   `json:"force,omitempty"`             // delete the workspace's tabs (and blocks)
+	MoveToWorkspaceId string `json:"movetoworkspaceid,omitempty"` // move the workspace's tabs to this workspace
+}
+
+type CommandCaptureBlockData struct {
+	BlockId  string `json:"blockid,omitempty"`
+	TabId    string `json:"tabid" wshcontext:"TabId"` // with tab (and no blockid), the tab to capture
+	Tab      bool   `json:"tab,omitempty"`            // capture the whole tab (the block's tab if blockid is set)
+	WindowId string `json:"windowid,omitempty"`       // set by wavesrv when the capture is forwarded to electron
+	MaxSize  int    `json:"maxsize,omitempty"`        // max png size in bytes
+}
+
+// wavesrv resolves the window and forwards the switch to electron (which swaps the window's tab views)
+type CommandWorkspaceSwitchData struct {
+	WindowId    string `json:"windowid,omitempty"` // defaults to the window showing TabId
+	TabId       string `json:"tabid,omitempty"`
+	WorkspaceId string `json:"workspaceid"`
+}
+
+// streams the eventbus events (the events wavesrv sends to the windows) that match the filters
+type CommandStreamEventsData struct {
+	EventTypes []string `json:"eventtypes"`           // "*" for all events
+	TabId      string   `json:"tabid,omitempty"`      // only events for the tab (and its blocks and layout)
+	BlockId    string   `json:"blockid,omitempty"`    // only events for the block
+	BufferSize int      `json:"buffersize,omitempty"` // events are dropped when the client falls this far behind
+}
+
+// filters for the list commands (empty fields match everything)
+type CommandListData struct {
+	WindowId string `json:"windowid,omitempty"`
+	TabId    string `json:"tabid,omitempty"`
+	View     string `json:"view,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"` // list the blocks in the trash instead (blocks only)
+	Pinned   bool   `json:"pinned,omitempty"`  // only list pinned blocks (blocks only)
+	Sizes    bool   `json:"sizes,omitempty"`   // set ScrollbackSize (blocks only)
+}
+
+// finds blocks by their meta (see wstore.BlockFilter), empty fields don't filter.  unlike listblocks this also
+// matches the blocks in workspaces that aren't open (their windowid is empty).
+type CommandQueryBlocksData struct {
+	View         string `json:"view,omitempty"`
+	UrlPrefix    string `json:"urlprefix,omitempty"`
+	UrlContains  string `json:"urlcontains,omitempty"`
+	FilePrefix   string `json:"fileprefix,omitempty"`
+	FileContains string `json:"filecontains,omitempty"`
+	Connection   string `json:"connection,omitempty"` // "local" also matches the blocks without a connection
+	Pinned       bool   `json:"pinned,omitempty"`
+	TabId        string `json:"tabid,omitempty"`
+	WindowId     string `json:"windowid,omitempty"`
+	CreatedAfter int64  `json:"createdafter,omitempty"` // unix ms
+	Limit        int    `json:"limit,omitempty"`
+	Sizes        bool   `json:"sizes,omitempty"` // set ScrollbackSize
+}
+
+type WindowListEntry struct {
+	WindowId      string `json:"windowid"`
+	WorkspaceId   string `json:"workspaceid"`
+	WorkspaceName string `json:"workspacename,omitempty"`
+	ActiveTabId   string `json:"activetabid,omitempty"`
+	NumTabs       int    `json:"numtabs"`
+	LastFocusTs   int64  `json:"lastfocusts,omitempty"`
+	CreatedTs     int64  `json:"createdts,omitempty"`
+}
+
+type TabListEntry struct {
+	TabId       string `json:"tabid"`
+	WindowId    string `json:"windowid"`
+	WorkspaceId string `json:"workspaceid"`
+	Name        string `json:"name"`
+	Pinned      bool   `json:"pinned,omitempty"`
+	GroupName   string `json:"groupname,omitempty"`
+	Active      bool   `json:"active,omitempty"`
+	NumBlocks   int    `json:"numblocks"`
+	Cwd         string `json:"cwd,omitempty"` // the cwd of the first terminal in the tab (see BlockListEntry.Cwd)
+	CreatedTs   int64  `json:"createdts,omitempty"`
+}
+
+type BlockListEntry struct {
+	BlockId     string              `json:"blockid"`
+	TabId       string              `json:"tabid"`
+	WindowId    string              `json:"windowid"`
+	WorkspaceId string              `json:"workspaceid"`
+	View        string              `json:"view,omitempty"`
+	Meta        waveobj.MetaMapType `json:"meta"`
+	DeletedTs   int64               `json:"deletedts,omitempty"`
+	CreatedTs   int64               `json:"createdts,omitempty"`
+	Cwd         string              `json:"cwd,omitempty"` // cmd:cwd, kept up to date for running shells (from OSC 7 or the shell process)
+	// the status of the block's shell (running, done, etc.), not set if the block has no controller
+	ControllerStatus   string `json:"controllerstatus,omitempty"`
+	ControllerExitCode int    `json:"controllerexitcode,omitempty"`
+	ScrollbackSize     *int64 `json:"scrollbacksize,omitempty"` // bytes of terminal output kept (only with CommandListData.Sizes)
+}
+
+type CommandExportAIConversationData struct {
+	BlockId string `json:"blockid"`
+	Format  string `json:"format"` // markdown or json
+}
+
 type AiMessageData struct {
 	Message string `json:"message,omitempty"`
 }
@@ -670,7 +1591,154 @@ type ActivityUpdate struct {
 	Conn          map[string]int        `json:"conn,omitempty"`
 }
 
+// a single use of a feature (e.g. a wsh command), rolled up per day and only stored locally
+type ActivityRecord struct {
+	Feature    string         `json:"feature"`
+	Success    bool           `json:"success"`
+	DurationMs int64          `json:"durationms,omitempty"`
+	Meta       map[string]any `json:"meta,omitempty"`
+}
+
+type ActivityStat struct {
+	Feature       string         `json:"feature"`
+	Count         int            `json:"count"`
+	Errors        int            `json:"errors"`
+	AvgDurationMs int64          `json:"avgdurationms"`
+	LastDay       string         `json:"lastday"`
+	MetaCounts    map[string]int `json:"metacounts,omitempty"`
+}
+
+type DBStatus struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Open      bool   `json:"open"`
+	Locked    bool   `json:"locked"`
+	InFlight  int    `json:"inflight"` // db calls that are running (or waiting for the db)
+	LastOkTs  int64  `json:"lastokts,omitempty"`
+	LastErr   string `json:"lasterr,omitempty"`
+	LastErrTs int64  `json:"lasterrts,omitempty"`
+}
+
+type ServerStatusData struct {
+	Version               string                `json:"version"`
+	BuildTime             string                `json:"buildtime,omitempty"`
+	StartTs               int64                 `json:"startts"`
+	UptimeMs              int64                 `json:"uptimems"`
+	Healthy               bool                  `json:"healthy"` // false if a db is not open or is locked
+	DBs                   []DBStatus            `json:"dbs"`
+	NumWebSockets         int                   `json:"numwebsockets"`
+	NumConnectedWindows   int                   `json:"numconnectedwindows"`
+	NumRunningControllers int                   `json:"numrunningcontrollers"`
+	NumEventListeners     int                   `json:"numeventlisteners"`
+	ListenerQueuedEvents  int                   `json:"listenerqueuedevents"`
+	NumWindowQueues       int                   `json:"numwindowqueues"`
+	WindowQueuedEvents    int                   `json:"windowqueuedevents"`
+	Restore               *SessionRestoreStatus `json:"restore,omitempty"`
+}
+
+// the progress of restarting the shells that were running when the server last shut down
+type SessionRestoreStatus struct {
+	Running  bool  `json:"running"`  // still starting the local shells
+	Total    int   `json:"total"`    // blocks to restore
+	Restored int   `json:"restored"` // blocks whose shell was restarted
+	Failed   int   `json:"failed"`   // blocks that got controller:restoreerror
+	Deferred int   `json:"deferred"` // blocks on a connection, restored when they are first used (and the connection is made)
+	StartTs  int64 `json:"startts"`
+	DoneTs   int64 `json:"donets,omitempty"`
+}
+
+type PreviewReloadData struct {
+	BlockId string `json:"blockid"`
+}
+
+// the file (or directory) shown by a preview block changed
+type BlockRefreshData struct {
+	BlockId    string `json:"blockid"`
+	Connection string `json:"connection"`
+	Path       string `json:"path"`
+}
+
+type CommandPreviewWatchData struct {
+	BlockId    string `json:"blockid"`
+	Connection string `json:"connection,omitempty"`
+	Path       string `json:"path"`
+}
+
+type TabTitleData struct {
+	TabId string `json:"tabid"`
+	Title string `json:"title"`
+}
+
+type FileCreatedData struct {
+	BlockId string `json:"blockid"`
+	Path    string `json:"path"`
+	Created bool   `json:"created"` // false if the wait timed out
+}
+
 type ConnExtData struct {
 	ConnName   string `json:"connname"`
 	LogBlockId string `json:"logblockid,omitempty"`
 }
+
+// pre-approves a host key, so the first connect doesn't ask (see "wsh conn trust")
+type CommandConnTrustHostKeyData struct {
+	ConnName    string `json:"connname"`
+	Fingerprint string `json:"fingerprint"` // SHA256:... (as printed by ssh-keygen -l)
+}
+
+// the host key of a connection doesn't match the one in known_hosts (the connection fails)
+type HostKeyChangedData struct {
+	ConnName    string   `json:"connname"`
+	Hostname    string   `json:"hostname"`
+	KeyType     string   `json:"keytype"`
+	Fingerprint string   `json:"fingerprint"`
+	KnownKeys   []string `json:"knownkeys"` // file:line of the old keys
+}
+
+type CommandConnConnectInteractiveData struct {
+	ConnName   string       `json:"connname"`
+	Keywords   ConnKeywords `json:"keywords,omitempty"`
+	LogBlockId string       `json:"logblockid,omitempty"`
+	Ensure     bool         `json:"ensure,omitempty"` // like ConnEnsureCommand (does nothing if already connected)
+}
+
+// a password (or keyboard-interactive question, or host key confirmation) for a connection started by wsh
+type ConnAuthPrompt struct {
+	PromptId     string `json:"promptid"`
+	ResponseType string `json:"responsetype"` // "text" or "confirm"
+	Title        string `json:"title"`
+	QueryText    string `json:"querytext"`
+	Echo         bool   `json:"echo,omitempty"` // false for secrets
+	TimeoutMs    int    `json:"timeoutms"`
+}
+
+type CommandAuthPromptResponseData struct {
+	PromptId string `json:"promptid"`
+	Text     string `json:"text,omitempty"`
+	Confirm  bool   `json:"confirm,omitempty"`
+	Cancel   bool   `json:"cancel,omitempty"`
+}
+
+type CommandRemoteExecData struct {
+	ConnName  string   `json:"connname"`
+	Cmd       []string `json:"cmd"`                 // one element is run as a shell command, more are quoted as args
+	Input     bool     `json:"input,omitempty"`     // stdin is sent with RemoteExecInputCommand (stdin is empty otherwise)
+	NoConnect bool     `json:"noconnect,omitempty"` // fail if the connection isn't connected (instead of connecting)
+}
+
+// the output is streamed as it is read, the last packet has Done set.  when Input is set, the first packet has the
+// ExecId to send the input to (once the command is started).
+type RemoteExecPacket struct {
+	ExecId     string `json:"execid,omitempty"`
+	Stream     string `json:"stream,omitempty"` // "stdout" or "stderr"
+	Data64     string `json:"data64,omitempty"`
+	Done       bool   `json:"done,omitempty"`
+	ExitCode   int    `json:"exitcode"`
+	ExitSignal string `json:"exitsignal,omitempty"` // set if the command was killed by a signal (ExitCode is 255)
+}
+
+type CommandRemoteExecInputData struct {
+	ExecId  string `json:"execid"`
+	Input64 string `json:"input64,omitempty"`
+	Eof     bool   `json:"eof,omitempty"` // closes the command's stdin (after Input64 is written)
+}
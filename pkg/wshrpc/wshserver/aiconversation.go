@@ -0,0 +1,52 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"bufio"
+	"context"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveai"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+const aiExportChunkSize = 32 * 1024
+
+// sends every write as a chunk of the response
+type chunkWriter struct {
+	ctx context.Context
+	rtn chan wshrpc.RespOrErrorUnion[string]
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	select {
+	case cw.rtn <- wshrpc.RespOrErrorUnion[string]{Response: string(p)}:
+		return len(p), nil
+	case <-cw.ctx.Done():
+		return 0, cw.ctx.Err()
+	}
+}
+
+func (ws *WshServer) ExportAIConversationCommand(ctx context.Context, data wshrpc.CommandExportAIConversationData) chan wshrpc.RespOrErrorUnion[string] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[string], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("ExportAIConversationCommand", recover())
+		}()
+		defer close(rtn)
+		bufWriter := bufio.NewWriterSize(&chunkWriter{ctx: ctx, rtn: rtn}, aiExportChunkSize)
+		err := waveai.ExportConversation(ctx, data.BlockId, data.Format, bufWriter)
+		if err == nil {
+			err = bufWriter.Flush()
+		}
+		if err != nil {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[string]{Error: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return rtn
+}
@@ -0,0 +1,72 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func isLocalConn(conn string) bool {
+	return conn == "" || conn == wshrpc.LocalConnName
+}
+
+// the path used to compare preview blocks' files.  local paths are made absolute with the symlinks resolved (for a
+// file that doesn't exist yet, the symlinks in its parent dir are resolved).  remote paths are only cleaned (they
+// are already absolute, wsh resolves them against the remote cwd).
+func canonicalFilePath(conn string, filePath string) string {
+	if !isLocalConn(conn) {
+		return path.Clean(filePath)
+	}
+	absPath, err := filepath.Abs(wavebase.ExpandHomeDirSafe(filePath))
+	if err != nil {
+		return filepath.Clean(filePath)
+	}
+	if realPath, err := filepath.EvalSymlinks(absPath); err == nil {
+		return realPath
+	}
+	if realParent, err := filepath.EvalSymlinks(filepath.Dir(absPath)); err == nil {
+		return filepath.Join(realParent, filepath.Base(absPath))
+	}
+	return absPath
+}
+
+// finds a preview block in the tab that shows the same file (on the same connection) as blockDef.  returns nil if
+// there isn't one, or if blockDef isn't a preview of a regular file (temp files are never shared).
+func findReusablePreviewBlock(ctx context.Context, tabId string, blockDef *waveobj.BlockDef) (*waveobj.Block, error) {
+	if blockDef == nil || blockDef.Meta.GetString(waveobj.MetaKey_View, "") != "preview" {
+		return nil, nil
+	}
+	filePath := blockDef.Meta.GetString(waveobj.MetaKey_File, "")
+	if filePath == "" || blockDef.Meta.GetBool(waveobj.MetaKey_FileTemp, false) {
+		return nil, nil
+	}
+	conn := blockDef.Meta.GetString(waveobj.MetaKey_Connection, "")
+	canonicalPath := canonicalFilePath(conn, filePath)
+	blocks, err := wstore.QueryBlocks(ctx, wstore.BlockFilter{TabId: tabId, View: "preview"})
+	if err != nil {
+		return nil, fmt.Errorf("error getting tab blocks: %w", err)
+	}
+	for _, block := range blocks {
+		if block.Meta.GetBool(waveobj.MetaKey_FileTemp, false) {
+			continue
+		}
+		blockConn := block.Meta.GetString(waveobj.MetaKey_Connection, "")
+		if blockConn != conn && !(isLocalConn(blockConn) && isLocalConn(conn)) {
+			continue
+		}
+		blockFile := block.Meta.GetString(waveobj.MetaKey_File, "")
+		if blockFile != "" && canonicalFilePath(blockConn, blockFile) == canonicalPath {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCanonicalFilePath(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("error resolving temp dir: %v", err)
+	}
+	realFile := filepath.Join(dir, "real.txt")
+	os.WriteFile(realFile, []byte("x"), 0644)
+	os.Symlink(realFile, filepath.Join(dir, "link.txt"))
+	os.Symlink(dir, filepath.Join(dir, "linkdir"))
+	tests := []struct {
+		conn     string
+		path     string
+		expected string
+	}{
+		{"", realFile, realFile},
+		{"local", filepath.Join(dir, "link.txt"), realFile},
+		{"", filepath.Join(dir, "linkdir", "sub", "..", "real.txt"), realFile},
+		{"", filepath.Join(dir, "linkdir", "new.txt"), filepath.Join(dir, "new.txt")},
+		{"user@host", "/home/user/./x/../a.txt", "/home/user/a.txt"},
+	}
+	for _, tc := range tests {
+		if got := canonicalFilePath(tc.conn, tc.path); got != tc.expected {
+			t.Errorf("canonicalFilePath(%q, %q): expected %q, got %q", tc.conn, tc.path, tc.expected, got)
+		}
+	}
+}
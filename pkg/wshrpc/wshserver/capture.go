@@ -0,0 +1,106 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	CaptureBlockDefaultTimeout = 10 * time.Second // when the request has no timeout
+	CaptureMaxSize             = 32 * 1024 * 1024
+)
+
+// captures a block (or a whole tab) as a png.  the capture is done by electron from the tab's web contents, so the
+// tab must be loaded in a window with a connected frontend (this fails right away when it isn't).
+func (ws *WshServer) CaptureBlockCommand(ctx context.Context, data wshrpc.CommandCaptureBlockData) chan wshrpc.RespOrErrorUnion[string] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[string], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("CaptureBlockCommand", recover())
+		}()
+		defer close(rtn)
+		err := captureBlock(ctx, data, rtn)
+		if err != nil {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[string]{Error: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return rtn
+}
+
+func captureBlock(ctx context.Context, data wshrpc.CommandCaptureBlockData, rtn chan wshrpc.RespOrErrorUnion[string]) error {
+	tabId := data.TabId
+	if data.BlockId != "" {
+		var err error
+		tabId, err = wstore.DBFindTabForBlockId(ctx, data.BlockId)
+		if err != nil {
+			return fmt.Errorf("error finding tab for block: %w", err)
+		}
+		if tabId == "" {
+			return fmt.Errorf("block %s is not in a tab", data.BlockId)
+		}
+	} else if !data.Tab {
+		return fmt.Errorf("no block to capture")
+	}
+	if tabId == "" {
+		return fmt.Errorf("no tab to capture")
+	}
+	workspaceId, err := findWorkspaceForTab(ctx, tabId)
+	if err != nil {
+		return err
+	}
+	windowId, err := wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+	if err != nil {
+		return fmt.Errorf("error finding window for workspace: %w", err)
+	}
+	if windowId == "" {
+		return fmt.Errorf("tab %s is not open in a window", tabId)
+	}
+	if !eventbus.IsWindowConnected(tabId) {
+		return fmt.Errorf("tab %s has no connected frontend (it has not been loaded in its window)", tabId)
+	}
+	fwdData := data
+	fwdData.TabId = tabId
+	fwdData.WindowId = windowId
+	if fwdData.Tab {
+		fwdData.BlockId = ""
+	}
+	if fwdData.MaxSize <= 0 || fwdData.MaxSize > CaptureMaxSize {
+		fwdData.MaxSize = CaptureMaxSize
+	}
+	timeout := CaptureBlockDefaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	opts := &wshrpc.RpcOpts{Route: wshutil.ElectronRoute, Timeout: max(int(timeout.Milliseconds()), 1)}
+	respCh := wshclient.CaptureBlockCommand(wshclient.GetBareRpcClient(), fwdData, opts)
+	for resp := range respCh {
+		if resp.Error != nil {
+			return resp.Error
+		}
+		select {
+		case rtn <- resp:
+		case <-ctx.Done():
+			if opts.StreamCancelFn != nil {
+				opts.StreamCancelFn()
+			}
+			for range respCh {
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/userinput"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// connections started by wsh (wsh conn connect, wsh ssh, ...) ask for passwords, keyboard-interactive answers and
+// host key confirmations on the terminal wsh runs in instead of in the frontend.  the prompts are streamed back to
+// wsh, which answers each one with AuthPromptResponseCommand.  the answers are never logged.
+const ConnAuthMaxPrompts = 10 // for the whole connect (including the jump hosts)
+
+func (ws *WshServer) ConnConnectInteractiveCommand(ctx context.Context, data wshrpc.CommandConnConnectInteractiveData) chan wshrpc.RespOrErrorUnion[wshrpc.ConnAuthPrompt] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.ConnAuthPrompt], 1)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("ConnConnectInteractiveCommand", recover())
+		}()
+		defer close(rtn)
+		var numPrompts atomic.Int32
+		prompter := func(request *userinput.UserInputRequest) error {
+			if numPrompts.Add(1) > ConnAuthMaxPrompts {
+				return fmt.Errorf("too many authentication prompts (max %d)", ConnAuthMaxPrompts)
+			}
+			prompt := wshrpc.ConnAuthPrompt{
+				PromptId:     request.RequestId,
+				ResponseType: request.ResponseType,
+				Title:        request.Title,
+				QueryText:    request.QueryText,
+				Echo:         request.PublicText,
+				TimeoutMs:    request.TimeoutMs,
+			}
+			if request.Markdown {
+				prompt.QueryText = strings.ReplaceAll(strings.ReplaceAll(prompt.QueryText, "  \n", "\n"), "**", "")
+			}
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[wshrpc.ConnAuthPrompt]{Response: prompt}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		connCtx := userinput.ContextWithPrompter(ctx, prompter)
+		var err error
+		if data.Ensure {
+			err = ws.ConnEnsureCommand(connCtx, wshrpc.ConnExtData{ConnName: data.ConnName, LogBlockId: data.LogBlockId})
+		} else {
+			err = ws.ConnConnectCommand(connCtx, wshrpc.ConnRequest{Host: data.ConnName, Keywords: data.Keywords, LogBlockId: data.LogBlockId})
+		}
+		if err != nil {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[wshrpc.ConnAuthPrompt]{Error: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return rtn
+}
+
+func (ws *WshServer) AuthPromptResponseCommand(ctx context.Context, data wshrpc.CommandAuthPromptResponseData) error {
+	response := &userinput.UserInputResponse{
+		Type:      "userinputresp",
+		RequestId: data.PromptId,
+		Text:      data.Text,
+		Confirm:   data.Confirm,
+	}
+	if data.Cancel {
+		response.ErrorMsg = "Canceled by the user"
+	}
+	userinput.SendUserInputResponse(response)
+	return nil
+}
+
+// the key is added to Wave's known_hosts file on the next connect (when it sends a key with this fingerprint), a
+// different known key still fails the connection
+func (ws *WshServer) ConnTrustHostKeyCommand(ctx context.Context, data wshrpc.CommandConnTrustHostKeyData) error {
+	if strings.HasPrefix(data.ConnName, "wsl://") {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "wsl connections do not have host keys")
+	}
+	connOpts, err := remote.ParseOpts(data.ConnName)
+	if err != nil {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "error parsing connection name: %v", err)
+	}
+	err = remote.TrustHostKey(connOpts.SSHHost, data.Fingerprint)
+	if err != nil {
+		return fmt.Errorf("trusting host key for %s: %w", data.ConnName, err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/wps"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const (
+	FileOpTimeout       = 10000 // ms
+	FileOpRemoveTimeout = 60000 // ms, a recursive remove can take a while
+)
+
+var windowsAbsPathRe = regexp.MustCompile(`^[a-zA-Z]:[\\/]`)
+var windowsDriveRe = regexp.MustCompile(`^[a-zA-Z]:$`)
+
+// the file operations of the preview block and wsh file (mkdir, touch, rename, and remove) run here so the root
+// check and the dirchange events are the same for both.  the paths are resolved on the connection (so "~" is
+// expanded, the separators are "/", and the symlinks are resolved, see FileInfo.RealPath) before they are checked
+// against the root.
+type fileOp struct {
+	connName    string
+	opts        *wshrpc.RpcOpts
+	root        string
+	realRoot    string
+	noRootCheck bool
+}
+
+func makeFileOp(data wshrpc.CommandFileOpData, timeout int) (*fileOp, error) {
+	connName := data.Connection
+	if connName == "" {
+		connName = wshrpc.LocalConnName
+	}
+	op := &fileOp{
+		connName:    connName,
+		opts:        &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(connName), Timeout: timeout},
+		noRootCheck: data.NoRootCheck,
+	}
+	if data.NoRootCheck {
+		return op, nil
+	}
+	if data.Root == "" {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "no root directory for the file operation")
+	}
+	rootInfo, err := op.getFileInfo(data.Root)
+	if err != nil {
+		return nil, err
+	}
+	if rootInfo.NotFound || !rootInfo.IsDir {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "root %q is not a directory", data.Root)
+	}
+	if rootInfo.RealPath == "" {
+		// an older wsh on the connection doesn't resolve the symlinks
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "cannot resolve root %q on %s", data.Root, connName)
+	}
+	op.root = rootInfo.Dir
+	op.realRoot = rootInfo.RealPath
+	return op, nil
+}
+
+func (op *fileOp) getFileInfo(filePath string) (*wshrpc.FileInfo, error) {
+	if !isAbsFilePath(filePath) {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "%q is not an absolute path", filePath)
+	}
+	finfo, err := wshclient.RemoteFileInfoCommand(GetMainRpcClient(), filePath, op.opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file info for %q: %w", filePath, err)
+	}
+	return finfo, nil
+}
+
+// returns the resolved path (and its file info), the path must be under the root with the symlinks resolved (on the
+// connection), so a link under the root that points outside of it is refused
+func (op *fileOp) resolvePath(filePath string) (string, *wshrpc.FileInfo, error) {
+	finfo, err := op.getFileInfo(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	fullPath := finfo.Dir
+	if !finfo.IsDir {
+		fullPath = path.Join(finfo.Dir, path.Base(filepath.ToSlash(filePath)))
+	}
+	if !op.noRootCheck && (finfo.RealPath == "" || !isPathUnder(op.connName, op.realRoot, finfo.RealPath)) {
+		return "", nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_Permission, "%q is not under %q", filePath, op.root)
+	}
+	return fullPath, finfo, nil
+}
+
+// other preview blocks showing the directory reload it
+func (op *fileOp) publishDirChange(dirs ...string) {
+	published := make(map[string]bool)
+	for _, dir := range dirs {
+		if published[dir] {
+			continue
+		}
+		published[dir] = true
+		wps.Broker.Publish(wps.WaveEvent{
+			Event:  wps.Event_DirChange,
+			Scopes: []string{op.connName + ":" + dir},
+			Data:   wshrpc.DirChangeData{Connection: op.connName, Dir: dir},
+		})
+	}
+}
+
+func isAbsFilePath(filePath string) bool {
+	return filePath == "~" || strings.HasPrefix(filePath, "~/") || path.IsAbs(filePath) || windowsAbsPathRe.MatchString(filePath)
+}
+
+// the root itself is not under the root.  for local paths the symlinks are resolved first (see canonicalFilePath),
+// remote paths are only compared as they are (pass the paths resolved on the connection).
+func isPathUnder(connName string, root string, filePath string) bool {
+	relPath, err := filepath.Rel(canonicalFilePath(connName, root), canonicalFilePath(connName, filePath))
+	if err != nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+	return relPath != "." && relPath != ".." && !strings.HasPrefix(relPath, "../")
+}
+
+func (ws *WshServer) FileMkdirCommand(ctx context.Context, data wshrpc.CommandFileOpData) error {
+	op, err := makeFileOp(data, FileOpTimeout)
+	if err != nil {
+		return err
+	}
+	fullPath, _, err := op.resolvePath(data.Path)
+	if err != nil {
+		return err
+	}
+	err = wshclient.RemoteMkdirCommand(GetMainRpcClient(), fullPath, op.opts)
+	if err != nil {
+		return err
+	}
+	op.publishDirChange(path.Dir(fullPath))
+	return nil
+}
+
+func (ws *WshServer) FileTouchCommand(ctx context.Context, data wshrpc.CommandFileOpData) error {
+	op, err := makeFileOp(data, FileOpTimeout)
+	if err != nil {
+		return err
+	}
+	fullPath, _, err := op.resolvePath(data.Path)
+	if err != nil {
+		return err
+	}
+	err = wshclient.RemoteFileTouchCommand(GetMainRpcClient(), fullPath, op.opts)
+	if err != nil {
+		return err
+	}
+	op.publishDirChange(path.Dir(fullPath))
+	return nil
+}
+
+func (ws *WshServer) FileRenameCommand(ctx context.Context, data wshrpc.CommandFileOpData) error {
+	if data.NewPath == "" {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "no new path for rename")
+	}
+	op, err := makeFileOp(data, FileOpTimeout)
+	if err != nil {
+		return err
+	}
+	fullPath, finfo, err := op.resolvePath(data.Path)
+	if err != nil {
+		return err
+	}
+	if finfo.NotFound {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "%s: no such file or directory", data.Path)
+	}
+	newFullPath, _, err := op.resolvePath(data.NewPath)
+	if err != nil {
+		return err
+	}
+	err = wshclient.RemoteFileRenameCommand(GetMainRpcClient(), [2]string{fullPath, newFullPath}, op.opts)
+	if err != nil {
+		return err
+	}
+	op.publishDirChange(path.Dir(fullPath), path.Dir(newFullPath))
+	return nil
+}
+
+func (ws *WshServer) FileRemoveCommand(ctx context.Context, data wshrpc.CommandFileOpData) error {
+	op, err := makeFileOp(data, FileOpRemoveTimeout)
+	if err != nil {
+		return err
+	}
+	fullPath, finfo, err := op.resolvePath(data.Path)
+	if err != nil {
+		return err
+	}
+	if finfo.NotFound {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "%s: no such file or directory", data.Path)
+	}
+	if fullPath == "/" || windowsDriveRe.MatchString(fullPath) {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "cannot remove %q", data.Path)
+	}
+	removeData := wshrpc.CommandRemoteFileRemoveData{Path: fullPath, Recursive: data.Recursive, Trash: data.Trash}
+	err = wshclient.RemoteFileRemoveCommand(GetMainRpcClient(), removeData, op.opts)
+	if err != nil {
+		return err
+	}
+	op.publishDirChange(path.Dir(fullPath))
+	return nil
+}
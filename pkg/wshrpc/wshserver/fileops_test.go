@@ -0,0 +1,60 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestIsPathUnder(t *testing.T) {
+	tests := []struct {
+		root     string
+		filePath string
+		expected bool
+	}{
+		{root: "/home/user", filePath: "/home/user/file.txt", expected: true},
+		{root: "/home/user/", filePath: "/home/user/dir/file.txt", expected: true},
+		{root: "/home/user", filePath: "/home/user", expected: false},
+		{root: "/home/user", filePath: "/home/username/file.txt", expected: false},
+		{root: "/home/user", filePath: "/home/user/../other/file.txt", expected: false},
+		{root: "/home/user", filePath: "/home/user/..file.txt", expected: true},
+	}
+	for _, tc := range tests {
+		if rtn := isPathUnder("user@host", tc.root, tc.filePath); rtn != tc.expected {
+			t.Errorf("isPathUnder(%q, %q): expected %v, got %v", tc.root, tc.filePath, tc.expected, rtn)
+		}
+	}
+}
+
+func TestIsPathUnderSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires symlinks")
+	}
+	root := t.TempDir()
+	outsideDir := t.TempDir()
+	err := os.Symlink(outsideDir, filepath.Join(root, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isPathUnder(wshrpc.LocalConnName, root, filepath.Join(root, "link", "file.txt")) {
+		t.Errorf("expected a path through a link to outside of the root not to be under the root")
+	}
+	if !isPathUnder(wshrpc.LocalConnName, root, filepath.Join(root, "newfile.txt")) {
+		t.Errorf("expected a new file in the root to be under the root")
+	}
+	// the root itself can be a link
+	rootLink := filepath.Join(t.TempDir(), "rootlink")
+	err = os.Symlink(root, rootLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isPathUnder(wshrpc.LocalConnName, rootLink, filepath.Join(root, "newfile.txt")) {
+		t.Errorf("expected a file in the linked root to be under the root")
+	}
+}
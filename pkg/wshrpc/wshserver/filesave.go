@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/wavetermdev/waveterm/pkg/wconfig"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const FileSaveTimeout = 10000 // ms
+
+// the edit view saves here.  the file must still be the version that was read (data.Version), otherwise an
+// ErrorCode_Conflict error is returned (with a FileConflictData) and the file is not written.  returns the new version.
+func (ws *WshServer) FileSaveCommand(ctx context.Context, data wshrpc.CommandFileSaveData) (*wshrpc.FileVersion, error) {
+	connName := data.Connection
+	if connName == "" {
+		connName = wshrpc.LocalConnName
+	}
+	barr, err := base64.StdEncoding.DecodeString(data.Data64)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding data64: %w", err)
+	}
+	if connName == wshrpc.LocalConnName {
+		err = wconfig.ValidateConfigSave(data.Path, data.Data64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	opts := &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(connName), Timeout: FileSaveTimeout}
+	writeData := wshrpc.CommandRemoteWriteFileData{Path: data.Path, Data64: data.Data64}
+	if !data.Force {
+		writeData.Version = data.Version
+	}
+	err = wshclient.RemoteWriteFileCommand(GetMainRpcClient(), writeData, opts)
+	if err != nil {
+		return nil, err
+	}
+	finfo, err := wshclient.RemoteFileInfoCommand(GetMainRpcClient(), data.Path, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting file info for %q: %w", data.Path, err)
+	}
+	version := &wshrpc.FileVersion{ModTime: finfo.ModTime, Size: finfo.Size}
+	if len(barr) <= wshrpc.FileVersionHashMaxSize {
+		hash := sha256.Sum256(barr)
+		version.Sha256 = hex.EncodeToString(hash[:])
+	}
+	return version, nil
+}
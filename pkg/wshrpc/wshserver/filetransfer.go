@@ -0,0 +1,234 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+)
+
+const (
+	FileTransferChunkSize        = 256 * 1024
+	FileTransferChunkTimeout     = 30000          // ms, for each chunk (and the other short calls)
+	FileTransferLongTimeout      = 10 * 60 * 1000 // ms, for checksums and tar/untar
+	FileTransferReadAhead        = 4              // chunks that are read while the previous ones are written
+	FileTransferProgressInterval = 250 * time.Millisecond
+	FileTransferTarSuffix        = ".wsh-transfer.tar"
+)
+
+// copies a file between two connections through wavesrv (so the existing connections are used, no new ssh auth).
+// the file is read and written in chunks (so memory use is bounded), and the sha256 of both copies is compared at
+// the end.  directories (with recursive) are sent as a tar file that is extracted on the destination.
+func (ws *WshServer) FileTransferCommand(ctx context.Context, data wshrpc.CommandFileTransferData) chan wshrpc.RespOrErrorUnion[wshrpc.FileTransferProgress] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.FileTransferProgress], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("FileTransferCommand", recover())
+		}()
+		defer close(rtn)
+		sendFn := func(progress wshrpc.FileTransferProgress) {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[wshrpc.FileTransferProgress]{Response: progress}:
+			case <-ctx.Done():
+			}
+		}
+		err := transferFile(ctx, data, sendFn)
+		if err != nil {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[wshrpc.FileTransferProgress]{Error: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return rtn
+}
+
+type transferEnd struct {
+	path string
+	opts *wshrpc.RpcOpts
+}
+
+func makeTransferEnd(conn string, path string) transferEnd {
+	if conn == "" {
+		conn = wshrpc.LocalConnName
+	}
+	return transferEnd{path: path, opts: &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(conn), Timeout: FileTransferChunkTimeout}}
+}
+
+func (end transferEnd) longOpts() *wshrpc.RpcOpts {
+	return &wshrpc.RpcOpts{Route: end.opts.Route, Timeout: FileTransferLongTimeout}
+}
+
+func transferFile(ctx context.Context, data wshrpc.CommandFileTransferData, sendFn func(wshrpc.FileTransferProgress)) error {
+	client := GetMainRpcClient()
+	src := makeTransferEnd(data.SrcConn, data.SrcPath)
+	dest := makeTransferEnd(data.DestConn, data.DestPath)
+	srcInfo, err := wshclient.RemoteFileInfoCommand(client, src.path, src.opts)
+	if err != nil {
+		return fmt.Errorf("error getting file info for %q: %w", src.path, err)
+	}
+	if srcInfo.NotFound {
+		return fmt.Errorf("%s: no such file or directory", src.path)
+	}
+	if srcInfo.IsDir && !data.Recursive {
+		return fmt.Errorf("%s is a directory (use --recursive to copy it)", src.path)
+	}
+	if srcInfo.IsDir && data.Resume {
+		return fmt.Errorf("directory copies can't be resumed")
+	}
+	// copying into an existing directory uses the name of the source
+	destInfo, err := wshclient.RemoteFileInfoCommand(client, dest.path, dest.opts)
+	if err != nil {
+		return fmt.Errorf("error getting file info for %q: %w", dest.path, err)
+	}
+	if !destInfo.NotFound && destInfo.IsDir {
+		destInfo, err = wshclient.RemoteFileJoinCommand(client, []string{dest.path, srcInfo.Name}, dest.opts)
+		if err != nil {
+			return fmt.Errorf("error getting file info for %q: %w", dest.path, err)
+		}
+		dest.path = destInfo.Path
+	}
+	progress := wshrpc.FileTransferProgress{DestPath: dest.path, IsDir: srcInfo.IsDir}
+	copySrc, copyDest := src, dest
+	createMode := srcInfo.Mode
+	if srcInfo.IsDir {
+		if !destInfo.NotFound && !destInfo.IsDir {
+			return fmt.Errorf("%s already exists and is not a directory", dest.path)
+		}
+		tarPath, err := wshclient.RemoteTarDirCommand(client, src.path, src.longOpts())
+		if err != nil {
+			return fmt.Errorf("error creating tar file: %w", err)
+		}
+		defer wshclient.RemoteFileDeleteCommand(client, tarPath, src.opts)
+		tarInfo, err := wshclient.RemoteFileInfoCommand(client, tarPath, src.opts)
+		if err != nil {
+			return fmt.Errorf("error getting file info for %q: %w", tarPath, err)
+		}
+		srcInfo = tarInfo
+		copySrc.path = tarPath
+		copyDest.path = dest.path + FileTransferTarSuffix
+		createMode = 0600
+		destInfo = &wshrpc.FileInfo{NotFound: true}
+	} else if !destInfo.NotFound && destInfo.IsDir {
+		return fmt.Errorf("%s is a directory", dest.path)
+	}
+	if data.MaxSize > 0 && srcInfo.Size > data.MaxSize {
+		return fmt.Errorf("%s is %d bytes, which is more than the max size of %d bytes", src.path, srcInfo.Size, data.MaxSize)
+	}
+	progress.Size = srcInfo.Size
+	if data.Resume && !destInfo.NotFound {
+		if destInfo.Size > srcInfo.Size {
+			return fmt.Errorf("can't resume, %s is larger than the source", dest.path)
+		}
+		progress.ResumedAt = destInfo.Size
+		progress.Transferred = destInfo.Size
+	}
+	sendFn(progress)
+	lastSendTime := time.Now()
+	err = copyFileChunks(ctx, copySrc, copyDest, progress.ResumedAt, srcInfo.Size, createMode, func(transferred int64) {
+		progress.Transferred = transferred
+		if time.Since(lastSendTime) >= FileTransferProgressInterval {
+			sendFn(progress)
+			lastSendTime = time.Now()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	srcSum, err := wshclient.RemoteFileChecksumCommand(client, copySrc.path, copySrc.longOpts())
+	if err != nil {
+		return fmt.Errorf("error getting checksum for %q: %w", copySrc.path, err)
+	}
+	destSum, err := wshclient.RemoteFileChecksumCommand(client, copyDest.path, copyDest.longOpts())
+	if err != nil {
+		return fmt.Errorf("error getting checksum for %q: %w", copyDest.path, err)
+	}
+	if srcSum != destSum {
+		if progress.ResumedAt > 0 {
+			return fmt.Errorf("checksum mismatch for %s (the partial file does not match the source, copy it again without resuming)", dest.path)
+		}
+		return fmt.Errorf("checksum mismatch for %s (the source may have changed during the copy)", dest.path)
+	}
+	if progress.IsDir {
+		err = wshclient.RemoteUntarCommand(client, wshrpc.CommandRemoteUntarData{TarPath: copyDest.path, DestPath: dest.path}, dest.longOpts())
+		if err != nil {
+			return fmt.Errorf("error extracting tar file: %w", err)
+		}
+	}
+	progress.Done = true
+	progress.Sha256 = srcSum
+	sendFn(progress)
+	return nil
+}
+
+type transferChunk struct {
+	offset int64
+	size   int64
+	data64 string
+	err    error
+}
+
+// copies [offset, size) from src to dest.  reads run ahead of the writes (in a separate goroutine) so the two
+// connections are used at the same time.
+func copyFileChunks(ctx context.Context, src transferEnd, dest transferEnd, offset int64, size int64, createMode os.FileMode, progressFn func(int64)) error {
+	client := GetMainRpcClient()
+	readCtx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+	readCh := make(chan transferChunk, FileTransferReadAhead)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("copyFileChunks:read", recover())
+		}()
+		defer close(readCh)
+		for pos := offset; pos < size; pos += FileTransferChunkSize {
+			readData := wshrpc.CommandRemoteFileReadAtData{Path: src.path, Offset: pos, Size: min(FileTransferChunkSize, size-pos)}
+			data64, err := wshclient.RemoteFileReadAtCommand(client, readData, src.opts)
+			select {
+			case readCh <- transferChunk{offset: pos, size: readData.Size, data64: data64, err: err}:
+			case <-readCtx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	// the first write creates the file (and drops anything after the offset)
+	writeData := wshrpc.CommandRemoteFileWriteAtData{Path: dest.path, Offset: offset, Truncate: true, CreateMode: createMode}
+	err := wshclient.RemoteFileWriteAtCommand(client, writeData, dest.opts)
+	if err != nil {
+		return fmt.Errorf("error writing %q: %w", dest.path, err)
+	}
+	for chunk := range readCh {
+		if chunk.err != nil {
+			return fmt.Errorf("error reading %q at offset %d: %w", src.path, chunk.offset, chunk.err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		chunkSize := base64DecodedSize(chunk.data64)
+		if chunkSize != chunk.size {
+			return fmt.Errorf("%s is shorter than expected (it may have changed during the copy)", src.path)
+		}
+		writeData := wshrpc.CommandRemoteFileWriteAtData{Path: dest.path, Offset: chunk.offset, Data64: chunk.data64}
+		err := wshclient.RemoteFileWriteAtCommand(client, writeData, dest.opts)
+		if err != nil {
+			return fmt.Errorf("error writing %q at offset %d: %w", dest.path, chunk.offset, err)
+		}
+		progressFn(chunk.offset + chunkSize)
+	}
+	return nil
+}
+
+func base64DecodedSize(data64 string) int64 {
+	return int64(len(data64)/4*3 - strings.Count(data64[max(0, len(data64)-2):], "="))
+}
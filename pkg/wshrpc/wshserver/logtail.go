@@ -0,0 +1,107 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wcore"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+func (ws *WshServer) SetLogLevelCommand(ctx context.Context, data wshrpc.CommandSetLogLevelData) error {
+	if data.Level != "" {
+		if _, err := wlog.ParseLevel(data.Level); err != nil {
+			return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "%v", err)
+		}
+	}
+	err := wcore.SetClientLogLevel(ctx, data.Subsystem, data.Level)
+	if err != nil {
+		return err
+	}
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err == nil {
+		sendWaveObjUpdate(waveobj.MakeORef(waveobj.OType_Client, client.OID))
+	}
+	return nil
+}
+
+// streams the log records (from now on) until the request is canceled.  when the client falls behind records are
+// dropped, and a warning record says how many.
+func (ws *WshServer) LogTailCommand(ctx context.Context, data wshrpc.CommandLogTailData) chan wshrpc.RespOrErrorUnion[wlog.LogRecord] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wlog.LogRecord])
+	level := wlog.LevelInfo
+	if data.Level != "" {
+		var err error
+		level, err = wlog.ParseLevel(data.Level)
+		if err != nil {
+			go func() {
+				rtn <- wshrpc.RespOrErrorUnion[wlog.LogRecord]{Error: wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "%v", err)}
+				close(rtn)
+			}()
+			return rtn
+		}
+	}
+	sub := wlog.Subscribe(wlog.SubscribeOpts{Level: level, Subsystems: data.Subsystems, ReqId: data.ReqId})
+	listenerId := uuid.New().String()
+	routeGoneCh := registerRouteGoneListener(ctx, listenerId)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("LogTailCommand", recover())
+		}()
+		defer close(rtn)
+		defer sub.Close()
+		defer eventbus.UnregisterListener(listenerId + ":routegone")
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		var reportedDropped int64
+		send := func(rec wlog.LogRecord) bool {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[wlog.LogRecord]{Response: rec}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-routeGoneCh:
+				return
+			case <-ticker.C:
+				if wshutil.GetIsCanceledFromContext(ctx) {
+					return
+				}
+				if dropped := sub.Dropped(); dropped > reportedDropped {
+					dropRec := wlog.LogRecord{
+						Ts:        time.Now().UnixMilli(),
+						Level:     wlog.LevelWarn.String(),
+						Subsystem: "logtail",
+						Msg:       "dropped log records (the client is too slow)",
+						Fields:    []wlog.LogField{{Key: "count", Value: strconv.FormatInt(dropped-reportedDropped, 10)}},
+					}
+					reportedDropped = dropped
+					if !send(dropRec) {
+						return
+					}
+				}
+			case rec := <-sub.Ch():
+				if !send(rec) {
+					return
+				}
+			}
+		}
+	}()
+	return rtn
+}
@@ -0,0 +1,83 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+const (
+	NotifyRateLimit  = 10 // max notifications per block in NotifyRateWindow
+	NotifyRateWindow = time.Minute
+)
+
+var notifyLimiter = makeNotifyRateLimiter(NotifyRateLimit, NotifyRateWindow)
+
+// sliding window rate limiter (keyed by block id) so a runaway loop can't spam notifications
+type notifyRateLimiter struct {
+	lock   *sync.Mutex
+	limit  int
+	window time.Duration
+	sends  map[string][]time.Time
+}
+
+func makeNotifyRateLimiter(limit int, window time.Duration) *notifyRateLimiter {
+	return &notifyRateLimiter{lock: &sync.Mutex{}, limit: limit, window: window, sends: make(map[string][]time.Time)}
+}
+
+func (rl *notifyRateLimiter) allow(key string, now time.Time) bool {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	cutoff := now.Add(-rl.window)
+	for sendKey, times := range rl.sends {
+		recent := times[:0]
+		for _, ts := range times {
+			if ts.After(cutoff) {
+				recent = append(recent, ts)
+			}
+		}
+		if len(recent) == 0 {
+			delete(rl.sends, sendKey)
+		} else {
+			rl.sends[sendKey] = recent
+		}
+	}
+	if len(rl.sends[key]) >= rl.limit {
+		return false
+	}
+	rl.sends[key] = append(rl.sends[key], now)
+	return true
+}
+
+// sends the notification to the tab that owns the block (the frontend shows it as an OS notification, or as an
+// in-app notification if OS notifications are denied). if the tab isn't loaded, electron shows it instead.
+func (ws *WshServer) NotifyCommand(ctx context.Context, data wshrpc.WaveNotificationOptions) error {
+	if !notifyLimiter.allow(data.BlockId, time.Now()) {
+		return fmt.Errorf("too many notifications (the limit is %d per %v for each block)", NotifyRateLimit, NotifyRateWindow)
+	}
+	electronOpts := &wshrpc.RpcOpts{Route: wshutil.ElectronRoute, Timeout: 2000}
+	if data.BlockId == "" {
+		return wshclient.NotifyCommand(wshclient.GetBareRpcClient(), data, electronOpts)
+	}
+	tabId, err := wstore.DBFindTabForBlockId(ctx, data.BlockId)
+	if err != nil {
+		return fmt.Errorf("error finding tab for block: %w", err)
+	}
+	if tabId == "" || !eventbus.IsWindowConnected(tabId) {
+		return wshclient.NotifyCommand(wshclient.GetBareRpcClient(), data, electronOpts)
+	}
+	return eventbus.SendEventToTab(tabId, eventbus.WSEventType{
+		EventType: eventbus.WSEvent_Notify,
+		Data:      data,
+	})
+}
@@ -0,0 +1,36 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotifyRateLimiter(t *testing.T) {
+	rl := makeNotifyRateLimiter(3, time.Minute)
+	start := time.Now()
+	for idx := 0; idx < 3; idx++ {
+		if !rl.allow("block-a", start.Add(time.Duration(idx)*time.Second)) {
+			t.Fatalf("send %d should be allowed", idx)
+		}
+	}
+	if rl.allow("block-a", start.Add(10*time.Second)) {
+		t.Errorf("fourth send within the window should be rejected")
+	}
+	if !rl.allow("block-b", start.Add(10*time.Second)) {
+		t.Errorf("other blocks should have their own limit")
+	}
+	// the first send drops out of the window, which frees one slot
+	if !rl.allow("block-a", start.Add(time.Minute+time.Second/2)) {
+		t.Errorf("send should be allowed once the oldest send is outside of the window")
+	}
+	if rl.allow("block-a", start.Add(time.Minute+time.Second/2)) {
+		t.Errorf("only one slot should have been freed")
+	}
+	rl.allow("block-c", start.Add(3*time.Minute))
+	if len(rl.sends) != 1 {
+		t.Errorf("expected expired blocks to be removed, got %d entries", len(rl.sends))
+	}
+}
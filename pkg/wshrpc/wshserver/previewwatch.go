@@ -0,0 +1,25 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+
+	"github.com/wavetermdev/waveterm/pkg/filewatch"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// preview blocks watch the file (or directory) they show, and reload it on a blockrefresh event.  the watch is
+// released when the block stops showing the path, or when the block is closed (see wcore.DeleteBlock).
+func (ws *WshServer) PreviewWatchCommand(ctx context.Context, data wshrpc.CommandPreviewWatchData) error {
+	if data.BlockId == "" || data.Path == "" {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "blockid and path are required")
+	}
+	return filewatch.GetPreviewManager().Watch(data.BlockId, data.Connection, data.Path)
+}
+
+func (ws *WshServer) PreviewUnwatchCommand(ctx context.Context, blockId string) error {
+	filewatch.GetPreviewManager().Unwatch(blockId)
+	return nil
+}
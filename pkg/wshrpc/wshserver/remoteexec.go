@@ -0,0 +1,224 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/genconn"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/remote"
+	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"golang.org/x/crypto/ssh"
+)
+
+const RemoteExecChunkSize = 32 * 1024
+
+// the stdin of the running commands (that have Input set), by exec id
+var remoteExecStdinLock = &sync.Mutex{}
+var remoteExecStdinMap = make(map[string]io.WriteCloser)
+
+func registerRemoteExecStdin(stdin io.WriteCloser) string {
+	remoteExecStdinLock.Lock()
+	defer remoteExecStdinLock.Unlock()
+	execId := uuid.New().String()
+	remoteExecStdinMap[execId] = stdin
+	return execId
+}
+
+func unregisterRemoteExecStdin(execId string) {
+	remoteExecStdinLock.Lock()
+	defer remoteExecStdinLock.Unlock()
+	delete(remoteExecStdinMap, execId)
+}
+
+func getRemoteExecStdin(execId string) io.WriteCloser {
+	remoteExecStdinLock.Lock()
+	defer remoteExecStdinLock.Unlock()
+	return remoteExecStdinMap[execId]
+}
+
+// runs the command in its own session on the connection's ssh client (like `ssh host cmd`, there is no pty).  the
+// command is killed when the request is canceled or times out.
+func (ws *WshServer) RemoteExecCommand(ctx context.Context, data wshrpc.CommandRemoteExecData) chan wshrpc.RespOrErrorUnion[wshrpc.RemoteExecPacket] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.RemoteExecPacket], 16)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("RemoteExecCommand", recover())
+		}()
+		defer close(rtn)
+		err := remoteExec(ctx, data, rtn)
+		if err != nil {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[wshrpc.RemoteExecPacket]{Error: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return rtn
+}
+
+func getRemoteExecClient(ctx context.Context, connName string, noConnect bool) (*ssh.Client, error) {
+	if connName == "" || connName == "local" || strings.HasPrefix(connName, "local:") {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "no connection given (local commands can be run in a shell)")
+	}
+	if strings.HasPrefix(connName, "wsl://") {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "wsl connections are not supported (only ssh connections)")
+	}
+	connOpts, err := remote.ParseOpts(connName)
+	if err != nil {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "error parsing connection name: %v", err)
+	}
+	conn := conncontroller.GetConn(ctx, connOpts, false, &wshrpc.ConnKeywords{})
+	if conn == nil {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "connection not found: %s", connName)
+	}
+	if noConnect {
+		if conn.GetStatus() != conncontroller.Status_Connected {
+			return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_ConnRefused, "connection %s is not connected (status %q)", connName, conn.GetStatus())
+		}
+	} else {
+		err = conncontroller.EnsureConnection(ctx, connName)
+		if err != nil {
+			return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_ConnRefused, "error connecting to %s: %v", connName, err)
+		}
+	}
+	client := conn.GetClient()
+	if client == nil {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_ConnRefused, "connection %s is not connected", connName)
+	}
+	return client, nil
+}
+
+// one arg is a shell command (e.g. "ls | wc -l"), more args are quoted so each one is passed as it is
+func makeRemoteExecCmd(cmd []string) string {
+	if len(cmd) == 1 {
+		return cmd[0]
+	}
+	quoted := make([]string, 0, len(cmd))
+	for _, arg := range cmd {
+		quoted = append(quoted, genconn.HardQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+func remoteExec(ctx context.Context, data wshrpc.CommandRemoteExecData, rtn chan wshrpc.RespOrErrorUnion[wshrpc.RemoteExecPacket]) error {
+	if len(data.Cmd) == 0 || strings.TrimSpace(strings.Join(data.Cmd, "")) == "" {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "no command given")
+	}
+	client, err := getRemoteExecClient(ctx, data.ConnName, data.NoConnect)
+	if err != nil {
+		return err
+	}
+	proc, err := genconn.MakeSSHShellClient(client).MakeProcessController(genconn.CommandSpec{Cmd: makeRemoteExecCmd(data.Cmd)})
+	if err != nil {
+		return err
+	}
+	stdout, err := proc.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := proc.StderrPipe()
+	if err != nil {
+		return err
+	}
+	var stdin io.WriteCloser
+	if data.Input {
+		stdin, err = proc.StdinPipe()
+		if err != nil {
+			return err
+		}
+	}
+	err = proc.Start()
+	if err != nil {
+		return err
+	}
+	if stdin != nil {
+		execId := registerRemoteExecStdin(stdin)
+		defer unregisterRemoteExecStdin(execId)
+		select {
+		case rtn <- wshrpc.RespOrErrorUnion[wshrpc.RemoteExecPacket]{Response: wshrpc.RemoteExecPacket{ExecId: execId}}:
+		case <-ctx.Done():
+		}
+	}
+	var wg sync.WaitGroup
+	sendOutput := func(stream string, reader io.Reader) {
+		defer wg.Done()
+		defer func() {
+			panichandler.PanicHandler("remoteExec:"+stream, recover())
+		}()
+		buf := make([]byte, RemoteExecChunkSize)
+		for {
+			n, readErr := reader.Read(buf)
+			if n > 0 {
+				packet := wshrpc.RemoteExecPacket{Stream: stream, Data64: base64.StdEncoding.EncodeToString(buf[:n])}
+				select {
+				case rtn <- wshrpc.RespOrErrorUnion[wshrpc.RemoteExecPacket]{Response: packet}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if readErr != nil {
+				return
+			}
+		}
+	}
+	wg.Add(2)
+	go sendOutput("stdout", stdout)
+	go sendOutput("stderr", stderr)
+	waitErr := genconn.ProcessContextWait(ctx, proc)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return fmt.Errorf("command killed: %w", ctx.Err())
+	}
+	donePacket := wshrpc.RemoteExecPacket{Done: true}
+	var exitErr *ssh.ExitError
+	var exitMissingErr *ssh.ExitMissingError
+	if errors.As(waitErr, &exitErr) {
+		donePacket.ExitCode = exitErr.ExitStatus()
+		if exitErr.Signal() != "" {
+			donePacket.ExitCode = 255
+			donePacket.ExitSignal = exitErr.Signal()
+		}
+	} else if errors.As(waitErr, &exitMissingErr) {
+		donePacket.ExitCode = 255
+	} else if waitErr != nil {
+		return fmt.Errorf("error running command: %w", waitErr)
+	}
+	select {
+	case rtn <- wshrpc.RespOrErrorUnion[wshrpc.RemoteExecPacket]{Response: donePacket}:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func (ws *WshServer) RemoteExecInputCommand(ctx context.Context, data wshrpc.CommandRemoteExecInputData) error {
+	stdin := getRemoteExecStdin(data.ExecId)
+	if stdin == nil {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "command not found (it may have exited): %s", data.ExecId)
+	}
+	input, err := base64.StdEncoding.DecodeString(data.Input64)
+	if err != nil {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "error decoding input: %v", err)
+	}
+	if len(input) > 0 {
+		_, err = stdin.Write(input)
+		if err != nil {
+			return fmt.Errorf("error writing to stdin: %w", err)
+		}
+	}
+	if data.Eof {
+		unregisterRemoteExecStdin(data.ExecId)
+		return stdin.Close()
+	}
+	return nil
+}
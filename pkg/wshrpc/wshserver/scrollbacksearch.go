@@ -0,0 +1,169 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
+	"github.com/wavetermdev/waveterm/pkg/filestore"
+	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// go regexps run in linear time (there is no backtracking), a search is still limited by time and by the bytes
+// scanned so a large scrollback can't hold up the server.  the matches found before a limit is hit are returned
+// (with Incomplete set).
+const (
+	SearchScrollbackDefaultMaxResults = 1000
+	SearchScrollbackMaxResults        = 10000
+	SearchScrollbackMaxPatternLen     = 1000
+	SearchScrollbackMaxScanBytes      = 64 * 1024 * 1024
+	SearchScrollbackTimeout           = 5 * time.Second
+	SearchScrollbackMaxLineLen        = 64 * 1024 // longer lines are searched in pieces
+	SearchScrollbackSnippetLen        = 200       // longer lines are shortened around the match
+	SearchScrollbackSnippetContext    = 80
+)
+
+var errSearchDone = errors.New("search done")
+
+type scrollbackSearcher struct {
+	re          *regexp.Regexp
+	maxResults  int
+	deadline    time.Time
+	stripper    utilfn.AnsiStripper
+	lineNum     int
+	lineBuf     []byte
+	lineOffsets []int64 // the offset in the scrollback file of each byte in lineBuf
+	rtn         *wshrpc.CommandSearchScrollbackRtnData
+}
+
+func (ws *WshServer) SearchScrollbackCommand(ctx context.Context, data wshrpc.CommandSearchScrollbackData) (*wshrpc.CommandSearchScrollbackRtnData, error) {
+	if data.Pattern == "" {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "no search pattern")
+	}
+	if len(data.Pattern) > SearchScrollbackMaxPatternLen {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "search pattern is too long (max %d bytes)", SearchScrollbackMaxPatternLen)
+	}
+	pattern := data.Pattern
+	if !data.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid search pattern: %v", err)
+	}
+	maxResults := data.MaxResults
+	if maxResults <= 0 {
+		maxResults = SearchScrollbackDefaultMaxResults
+	}
+	maxResults = min(maxResults, SearchScrollbackMaxResults)
+	err = checkTermBlock(ctx, data.BlockId)
+	if err != nil {
+		return nil, err
+	}
+	rtn := &wshrpc.CommandSearchScrollbackRtnData{Matches: []wshrpc.ScrollbackMatch{}}
+	file, err := filestore.WFS.Stat(ctx, data.BlockId, blockcontroller.BlockFile_Term)
+	if err == fs.ErrNotExist {
+		return rtn, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting scrollback file: %w", err)
+	}
+	searcher := &scrollbackSearcher{
+		re:         re,
+		maxResults: maxResults,
+		deadline:   time.Now().Add(SearchScrollbackTimeout),
+		lineNum:    1,
+		rtn:        rtn,
+	}
+	startOffset := file.DataStartIdx()
+	endOffset := min(file.Size, startOffset+SearchScrollbackMaxScanBytes)
+	if endOffset < file.Size {
+		rtn.Incomplete = true
+	}
+	_, err = readScrollbackRange(ctx, data.BlockId, startOffset, endOffset, searcher.searchChunk)
+	if err == nil {
+		err = searcher.searchLine()
+	}
+	if err != nil && err != errSearchDone {
+		return nil, err
+	}
+	return rtn, nil
+}
+
+func (s *scrollbackSearcher) searchChunk(offset int64, chunk []byte) error {
+	s.rtn.ScannedBytes += int64(len(chunk))
+	stripped, idxs := s.stripper.StripWithIndexes(chunk)
+	for idx, ch := range stripped {
+		if ch == '\n' {
+			err := s.searchLine()
+			if err != nil {
+				return err
+			}
+			s.lineNum++
+			continue
+		}
+		s.lineBuf = append(s.lineBuf, ch)
+		s.lineOffsets = append(s.lineOffsets, offset+int64(idxs[idx]))
+		if len(s.lineBuf) >= SearchScrollbackMaxLineLen {
+			err := s.searchLine()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searches (and clears) the current line, empty matches are skipped
+func (s *scrollbackSearcher) searchLine() error {
+	defer func() {
+		s.lineBuf = s.lineBuf[:0]
+		s.lineOffsets = s.lineOffsets[:0]
+	}()
+	if time.Now().After(s.deadline) {
+		s.rtn.Incomplete = true
+		return errSearchDone
+	}
+	if len(s.lineBuf) == 0 {
+		return nil
+	}
+	for _, loc := range s.re.FindAllIndex(s.lineBuf, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		if len(s.rtn.Matches) >= s.maxResults {
+			s.rtn.Truncated = true
+			return errSearchDone
+		}
+		snippetStart, snippetEnd := 0, len(s.lineBuf)
+		if len(s.lineBuf) > SearchScrollbackSnippetLen {
+			snippetStart = max(0, loc[0]-SearchScrollbackSnippetContext)
+			snippetEnd = min(len(s.lineBuf), loc[1]+SearchScrollbackSnippetContext)
+			for snippetStart < loc[0] && !utf8.RuneStart(s.lineBuf[snippetStart]) {
+				snippetStart++
+			}
+			for snippetEnd < len(s.lineBuf) && !utf8.RuneStart(s.lineBuf[snippetEnd]) {
+				snippetEnd++
+			}
+		}
+		offset := s.lineOffsets[loc[0]]
+		s.rtn.Matches = append(s.rtn.Matches, wshrpc.ScrollbackMatch{
+			Line:          s.lineNum,
+			Offset:        offset,
+			Length:        s.lineOffsets[loc[1]-1] + 1 - offset,
+			Snippet:       string(s.lineBuf[snippetStart:snippetEnd]),
+			SnippetOffset: loc[0] - snippetStart,
+			SnippetLength: loc[1] - loc[0],
+		})
+	}
+	return nil
+}
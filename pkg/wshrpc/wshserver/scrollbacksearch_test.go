@@ -0,0 +1,52 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func TestScrollbackSearcher(t *testing.T) {
+	rtn := &wshrpc.CommandSearchScrollbackRtnData{}
+	searcher := &scrollbackSearcher{
+		re:         regexp.MustCompile("(?i)error"),
+		maxResults: 10,
+		deadline:   time.Now().Add(time.Minute),
+		lineNum:    1,
+		rtn:        rtn,
+	}
+	// the escape sequence and the second line are split across chunks
+	chunks := []string{"ok\r\n\x1b[31mERR", "OR\x1b[0m: disk\r\n", "no error here, error"}
+	offset := int64(100)
+	for _, chunk := range chunks {
+		err := searcher.searchChunk(offset, []byte(chunk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset += int64(len(chunk))
+	}
+	if err := searcher.searchLine(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rtn.Matches) != 3 {
+		t.Fatalf("expected 3 matches, got %#v", rtn.Matches)
+	}
+	first := rtn.Matches[0]
+	// "ERROR" is at 109 (after "ok\r\n\x1b[31m"), the escape sequence after it isn't part of the match
+	if first.Line != 2 || first.Offset != 109 || first.Length != 5 || first.Snippet != "ERROR: disk" {
+		t.Fatalf("bad first match %#v", first)
+	}
+	if rtn.Matches[2].Line != 3 || rtn.Matches[2].SnippetOffset != 15 {
+		t.Fatalf("bad last match %#v", rtn.Matches[2])
+	}
+	searcher.maxResults = 3
+	searcher.searchChunk(offset, []byte("\nerror\n"))
+	if !rtn.Truncated || len(rtn.Matches) != 3 {
+		t.Fatalf("expected the search to stop at maxresults")
+	}
+}
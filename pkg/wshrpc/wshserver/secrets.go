@@ -0,0 +1,30 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+
+	"github.com/wavetermdev/waveterm/pkg/secretstore"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+func (ws *WshServer) SetSecretCommand(ctx context.Context, data wshrpc.CommandSetSecretData) error {
+	if err := secretstore.ValidateSecretName(data.Name); err != nil {
+		return wshrpc.MakeCodedError(wshrpc.ErrorCode_InvalidArg, err)
+	}
+	if data.Value == "" {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "secret %q has no value", data.Name)
+	}
+	return secretstore.SetSecret(data.Name, data.Value)
+}
+
+// returns false if the secret wasn't set
+func (ws *WshServer) DeleteSecretCommand(ctx context.Context, name string) (bool, error) {
+	return secretstore.DeleteSecret(name)
+}
+
+func (ws *WshServer) GetSecretNamesCommand(ctx context.Context) ([]string, error) {
+	return secretstore.GetSecretNames()
+}
@@ -0,0 +1,201 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wavebase"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
+	"github.com/wavetermdev/waveterm/pkg/wshutil"
+	"github.com/wavetermdev/waveterm/pkg/wstore"
+)
+
+// local files are watched with fsnotify (the poll is a fallback, and checks that the block is still waiting),
+// files on a connection are polled
+const WaitCreatePollInterval = 2 * time.Second
+
+var waitCreateLock = &sync.Mutex{}
+var waitCreateBlocks = make(map[string]bool)
+
+type fileCreateWaiter struct {
+	blockId   string
+	conn      string
+	path      string // the file meta (for a local file ~ is expanded in watchPath)
+	watchPath string
+	deadline  time.Time // zero waits until the block is closed (or stops waiting)
+}
+
+// starts waiting for the block's file to be created (if the block has file:waitcreate set).  when it is created
+// (or the wait times out) file:waitcreate is cleared and a WSEvent_FileCreated is sent to the block's tab.
+// calling this again for a block that is already waiting is a no-op.
+func (ws *WshServer) WatchFileCreateCommand(ctx context.Context, blockId string) error {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error getting block: %w", err)
+	}
+	if !block.Meta.GetBool(waveobj.MetaKey_FileWaitCreate, false) {
+		return nil
+	}
+	filePath := block.Meta.GetString(waveobj.MetaKey_File, "")
+	if filePath == "" {
+		return fmt.Errorf("block %s does not have a file", blockId)
+	}
+	waiter := &fileCreateWaiter{
+		blockId: blockId,
+		conn:    block.Meta.GetString(waveobj.MetaKey_Connection, ""),
+		path:    filePath,
+	}
+	if untilMs := int64(block.Meta.GetFloat(waveobj.MetaKey_FileWaitCreateUntil, 0)); untilMs > 0 {
+		waiter.deadline = time.UnixMilli(untilMs)
+	}
+	waitCreateLock.Lock()
+	defer waitCreateLock.Unlock()
+	if waitCreateBlocks[blockId] {
+		return nil
+	}
+	waitCreateBlocks[blockId] = true
+	go waiter.run()
+	return nil
+}
+
+func (w *fileCreateWaiter) isLocal() bool {
+	return w.conn == "" || w.conn == wshrpc.LocalConnName
+}
+
+func (w *fileCreateWaiter) fileExists() bool {
+	conn := w.conn
+	if conn == "" {
+		conn = wshrpc.LocalConnName
+	}
+	finfo, err := wshclient.RemoteFileInfoCommand(GetMainRpcClient(), w.path, &wshrpc.RpcOpts{Route: wshutil.MakeConnectionRouteId(conn), Timeout: 5000})
+	if err != nil {
+		// the connection may not be up yet, keep waiting
+		return false
+	}
+	return !finfo.NotFound
+}
+
+// false if the block was closed or file:waitcreate was cleared (e.g. the user opened another file)
+func (w *fileCreateWaiter) blockIsWaiting() bool {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	block, err := wstore.DBGet[*waveobj.Block](ctx, w.blockId)
+	if err != nil || block == nil || block.DeletedTs != 0 {
+		return false
+	}
+	return block.Meta.GetBool(waveobj.MetaKey_FileWaitCreate, false) && block.Meta.GetString(waveobj.MetaKey_File, "") == w.path
+}
+
+// returns the fsnotify watcher for the parent directory (nil if the file is remote or the watch fails)
+func (w *fileCreateWaiter) makeParentWatcher() *fsnotify.Watcher {
+	if !w.isLocal() {
+		return nil
+	}
+	localPath, err := wavebase.ExpandHomeDir(w.path)
+	if err != nil {
+		return nil
+	}
+	w.watchPath = filepath.Clean(localPath)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("error creating watcher for %q: %v\n", w.watchPath, err)
+		return nil
+	}
+	err = watcher.Add(filepath.Dir(w.watchPath))
+	if err != nil {
+		log.Printf("error watching %q: %v\n", filepath.Dir(w.watchPath), err)
+		watcher.Close()
+		return nil
+	}
+	return watcher
+}
+
+func (w *fileCreateWaiter) run() {
+	defer func() {
+		panichandler.PanicHandler("fileCreateWaiter:run", recover())
+	}()
+	defer func() {
+		waitCreateLock.Lock()
+		defer waitCreateLock.Unlock()
+		delete(waitCreateBlocks, w.blockId)
+	}()
+	var events chan fsnotify.Event
+	if watcher := w.makeParentWatcher(); watcher != nil {
+		defer watcher.Close()
+		events = watcher.Events
+	}
+	ticker := time.NewTicker(WaitCreatePollInterval)
+	defer ticker.Stop()
+	for {
+		if w.fileExists() {
+			w.finish(true)
+			return
+		}
+		if !w.deadline.IsZero() && time.Now().After(w.deadline) {
+			w.finish(false)
+			return
+		}
+	waitLoop:
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				if filepath.Clean(event.Name) == w.watchPath && event.Has(fsnotify.Create|fsnotify.Rename|fsnotify.Write) {
+					break waitLoop
+				}
+			case <-ticker.C:
+				if !w.blockIsWaiting() {
+					return
+				}
+				break waitLoop
+			}
+		}
+	}
+}
+
+func (w *fileCreateWaiter) finish(created bool) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	if !w.blockIsWaiting() {
+		return
+	}
+	oref := waveobj.MakeORef(waveobj.OType_Block, w.blockId)
+	meta := waveobj.MetaMapType{
+		waveobj.MetaKey_FileWaitCreate:      nil,
+		waveobj.MetaKey_FileWaitCreateUntil: nil,
+	}
+	err := wstore.UpdateObjectMeta(ctx, oref, meta, false)
+	if err != nil {
+		log.Printf("error clearing file:waitcreate for block %s: %v\n", w.blockId, err)
+		return
+	}
+	sendWaveObjUpdate(oref)
+	block, err := wstore.DBGet[*waveobj.Block](ctx, w.blockId)
+	if err != nil || block == nil {
+		return
+	}
+	parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+	if parentORef == nil || parentORef.OType != waveobj.OType_Tab || !eventbus.IsWindowConnected(parentORef.OID) {
+		return
+	}
+	eventbus.SendEventToTab(parentORef.OID, eventbus.WSEventType{
+		EventType: eventbus.WSEvent_FileCreated,
+		ORef:      oref.String(),
+		Data:      wshrpc.FileCreatedData{BlockId: w.blockId, Path: w.path, Created: created},
+	})
+}
@@ -9,23 +9,32 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/skratchdot/open-golang/open"
 	"github.com/wavetermdev/waveterm/pkg/blockcontroller"
 	"github.com/wavetermdev/waveterm/pkg/blocklogger"
+	"github.com/wavetermdev/waveterm/pkg/cmdhistory"
+	"github.com/wavetermdev/waveterm/pkg/eventbus"
 	"github.com/wavetermdev/waveterm/pkg/filestore"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/remote"
 	"github.com/wavetermdev/waveterm/pkg/remote/conncontroller"
+	"github.com/wavetermdev/waveterm/pkg/sysmetrics"
 	"github.com/wavetermdev/waveterm/pkg/telemetry"
+	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
 	"github.com/wavetermdev/waveterm/pkg/util/envutil"
+	"github.com/wavetermdev/waveterm/pkg/util/migrateutil"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveai"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
@@ -34,6 +43,7 @@ import (
 	"github.com/wavetermdev/waveterm/pkg/wcore"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc/wshclient"
 	"github.com/wavetermdev/waveterm/pkg/wshutil"
 	"github.com/wavetermdev/waveterm/pkg/wsl"
 	"github.com/wavetermdev/waveterm/pkg/wstore"
@@ -82,6 +92,277 @@ func (ws *WshServer) StreamWaveAiCommand(ctx context.Context, request wshrpc.Wav
 	return waveai.RunAICommand(ctx, request)
 }
 
+// forwards the matching eventbus events until the request is canceled, times out, or the client's route goes away.
+// if the client stops reading, events are buffered (up to data.BufferSize) and then dropped.
+func (ws *WshServer) StreamEventsCommand(ctx context.Context, data wshrpc.CommandStreamEventsData) chan wshrpc.RespOrErrorUnion[eventbus.WSEventType] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[eventbus.WSEventType])
+	scopes, err := getStreamEventsScopes(ctx, data)
+	if err == nil && len(data.EventTypes) == 0 {
+		err = fmt.Errorf("no event types given")
+	}
+	if err != nil {
+		go func() {
+			rtn <- wshrpc.RespOrErrorUnion[eventbus.WSEventType]{Error: err}
+			close(rtn)
+		}()
+		return rtn
+	}
+	listenerId := uuid.New().String()
+	listener := eventbus.RegisterListener(listenerId, data.EventTypes, scopes, data.BufferSize)
+	routeGoneCh := registerRouteGoneListener(ctx, listenerId)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("StreamEventsCommand", recover())
+		}()
+		defer close(rtn)
+		defer eventbus.UnregisterListener(listenerId)
+		defer eventbus.UnregisterListener(listenerId + ":routegone")
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-routeGoneCh:
+				return
+			case <-ticker.C:
+				if wshutil.GetIsCanceledFromContext(ctx) {
+					return
+				}
+			case event, ok := <-listener.Ch:
+				if !ok {
+					return
+				}
+				select {
+				case rtn <- wshrpc.RespOrErrorUnion[eventbus.WSEventType]{Response: event}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return rtn
+}
+
+// returns a channel that receives an event when the rpc caller's route goes away (nil if the caller has no route).
+// the listener is registered as listenerId+":routegone"
+func registerRouteGoneListener(ctx context.Context, listenerId string) chan eventbus.WSEventType {
+	source := wshutil.GetRpcSourceFromContext(ctx)
+	if source == "" {
+		return nil
+	}
+	return eventbus.RegisterListener(listenerId+":routegone", []string{wps.Event_RouteGone}, []string{source}, 1).Ch
+}
+
+// the orefs to filter the events by (tab filters also match the tab's layout and its current blocks)
+func getStreamEventsScopes(ctx context.Context, data wshrpc.CommandStreamEventsData) ([]string, error) {
+	var scopes []string
+	if data.TabId != "" {
+		tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, data.TabId)
+		if err != nil {
+			return nil, fmt.Errorf("error getting tab %q: %w", data.TabId, err)
+		}
+		scopes = append(scopes, waveobj.MakeORef(waveobj.OType_Tab, tab.OID).String())
+		scopes = append(scopes, waveobj.MakeORef(waveobj.OType_LayoutState, tab.LayoutState).String())
+		for _, blockId := range tab.BlockIds {
+			scopes = append(scopes, waveobj.MakeORef(waveobj.OType_Block, blockId).String())
+		}
+	}
+	if data.BlockId != "" {
+		scopes = append(scopes, waveobj.MakeORef(waveobj.OType_Block, data.BlockId).String())
+	}
+	return scopes, nil
+}
+
+const ScrollbackChunkSize = 64 * 1024
+
+func (ws *WshServer) GetScrollbackCommand(ctx context.Context, data wshrpc.CommandGetScrollbackData) chan wshrpc.RespOrErrorUnion[wshrpc.CommandGetScrollbackRtnData] {
+	rtn := make(chan wshrpc.RespOrErrorUnion[wshrpc.CommandGetScrollbackRtnData])
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("GetScrollbackCommand", recover())
+		}()
+		defer close(rtn)
+		err := streamScrollback(ctx, data, rtn)
+		if err != nil {
+			select {
+			case rtn <- wshrpc.RespOrErrorUnion[wshrpc.CommandGetScrollbackRtnData]{Error: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return rtn
+}
+
+func streamScrollback(ctx context.Context, data wshrpc.CommandGetScrollbackData, rtn chan wshrpc.RespOrErrorUnion[wshrpc.CommandGetScrollbackRtnData]) error {
+	err := checkTermBlock(ctx, data.BlockId)
+	if err != nil {
+		return err
+	}
+	var listenerCh chan eventbus.WSEventType
+	var routeGoneCh chan eventbus.WSEventType
+	if data.Follow {
+		// register before reading, so no appends are missed
+		listenerId := uuid.New().String()
+		blockScope := waveobj.MakeORef(waveobj.OType_Block, data.BlockId).String()
+		listenerCh = eventbus.RegisterListener(listenerId, []string{wps.Event_BlockFile}, []string{blockScope}, 16).Ch
+		defer eventbus.UnregisterListener(listenerId)
+		routeGoneCh = registerRouteGoneListener(ctx, listenerId)
+		defer eventbus.UnregisterListener(listenerId + ":routegone")
+	}
+	var stripper *utilfn.AnsiStripper
+	if !data.Raw {
+		stripper = &utilfn.AnsiStripper{}
+	}
+	sendChunk := func(_ int64, chunk []byte) error {
+		if stripper != nil {
+			chunk = stripper.Strip(chunk)
+		}
+		if len(chunk) == 0 {
+			return nil
+		}
+		select {
+		case rtn <- wshrpc.RespOrErrorUnion[wshrpc.CommandGetScrollbackRtnData]{Response: wshrpc.CommandGetScrollbackRtnData{Data64: base64.StdEncoding.EncodeToString(chunk)}}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	file, err := filestore.WFS.Stat(ctx, data.BlockId, blockcontroller.BlockFile_Term)
+	if err == fs.ErrNotExist {
+		if !data.Follow {
+			return nil
+		}
+		file = &filestore.WaveFile{}
+	} else if err != nil {
+		return fmt.Errorf("error getting scrollback file: %w", err)
+	}
+	startOffset := file.DataStartIdx()
+	if data.Lines > 0 {
+		startOffset, err = findScrollbackLinesOffset(ctx, file, data.Lines)
+		if err != nil {
+			return err
+		}
+	}
+	offset, err := readScrollbackRange(ctx, data.BlockId, startOffset, file.Size, sendChunk)
+	if err != nil || !data.Follow {
+		return err
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-routeGoneCh:
+			return nil
+		case <-ticker.C:
+			if wshutil.GetIsCanceledFromContext(ctx) {
+				return nil
+			}
+		case <-listenerCh:
+		}
+		file, err = filestore.WFS.Stat(ctx, data.BlockId, blockcontroller.BlockFile_Term)
+		if err == fs.ErrNotExist {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error getting scrollback file: %w", err)
+		}
+		if file.Size < offset {
+			// the scrollback was cleared
+			offset = 0
+		}
+		if file.Size == offset {
+			continue
+		}
+		offset, err = readScrollbackRange(ctx, file.ZoneId, max(offset, file.DataStartIdx()), file.Size, sendChunk)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func checkTermBlock(ctx context.Context, blockId string) error {
+	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error getting block %q: %w", blockId, err)
+	}
+	if view := block.Meta.GetString(waveobj.MetaKey_View, ""); view != "term" {
+		return fmt.Errorf("block %s is not a terminal (view is %q)", blockId, view)
+	}
+	return nil
+}
+
+func (ws *WshServer) GetCmdHistoryForBlockCommand(ctx context.Context, data wshrpc.CommandGetCmdHistoryData) ([]wshrpc.CmdRecord, error) {
+	err := checkTermBlock(ctx, data.BlockId)
+	if err != nil {
+		return nil, err
+	}
+	return blockcontroller.GetCmdHistory(ctx, data.BlockId, data.Limit)
+}
+
+func (ws *WshServer) SearchHistoryCommand(ctx context.Context, data wshrpc.CommandSearchHistoryData) (*wshrpc.CommandSearchHistoryRtnData, error) {
+	return cmdhistory.SearchHistory(ctx, data)
+}
+
+func (ws *WshServer) ImportHistoryCommand(ctx context.Context, data wshrpc.CommandImportHistoryData) (int, error) {
+	return cmdhistory.ImportHistory(ctx, data)
+}
+
+// finds the offset of the start of the last numLines lines (reading backwards, one chunk at a time)
+func findScrollbackLinesOffset(ctx context.Context, file *filestore.WaveFile, numLines int) (int64, error) {
+	startIdx := file.DataStartIdx()
+	pos := file.Size
+	numFound := 0
+	isLastByte := true
+	for pos > startIdx {
+		chunkStart := max(startIdx, pos-ScrollbackChunkSize)
+		_, chunk, err := filestore.WFS.ReadAt(ctx, file.ZoneId, file.Name, chunkStart, pos-chunkStart)
+		if err != nil {
+			return 0, fmt.Errorf("error reading scrollback: %w", err)
+		}
+		for idx := len(chunk) - 1; idx >= 0; idx-- {
+			// a trailing newline doesn't start a new line
+			if chunk[idx] == '\n' && !isLastByte {
+				numFound++
+				if numFound == numLines {
+					return chunkStart + int64(idx) + 1, nil
+				}
+			}
+			isLastByte = false
+		}
+		pos = chunkStart
+	}
+	return startIdx, nil
+}
+
+// sends the scrollback between startOffset and endOffset in chunks (with the offset of each chunk), returns the offset
+// after the last byte read
+func readScrollbackRange(ctx context.Context, blockId string, startOffset int64, endOffset int64, sendFn func(int64, []byte) error) (int64, error) {
+	offset := startOffset
+	for offset < endOffset {
+		readOffset, chunk, err := filestore.WFS.ReadAt(ctx, blockId, blockcontroller.BlockFile_Term, offset, min(ScrollbackChunkSize, endOffset-offset))
+		if err != nil {
+			return offset, fmt.Errorf("error reading scrollback: %w", err)
+		}
+		if len(chunk) == 0 {
+			// the data was overwritten (circular file), skip ahead
+			if readOffset <= offset {
+				break
+			}
+			offset = readOffset
+			continue
+		}
+		err = sendFn(readOffset, chunk)
+		if err != nil {
+			return offset, err
+		}
+		offset = readOffset + int64(len(chunk))
+	}
+	return max(offset, endOffset), nil
+}
+
 func MakePlotData(ctx context.Context, blockId string) error {
 	block, err := wstore.DBMustGet[*waveobj.Block](ctx, blockId)
 	if err != nil {
@@ -113,13 +394,28 @@ func SavePlotData(ctx context.Context, blockId string, history string) error {
 	return filestore.WFS.WriteFile(ctx, blockId, "cpuplotdata", historyBytes)
 }
 
+func (ws *WshServer) GetMetricsCommand(ctx context.Context, data wshrpc.CommandGetMetricsData) ([]wshrpc.TimeSeriesData, error) {
+	connName := data.Connection
+	if connName == "" {
+		connName = wshrpc.LocalConnName
+	}
+	if data.Count < 0 || data.IntervalMs < 0 {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "count and interval can't be negative")
+	}
+	if !sysmetrics.HasConnMetrics(connName) {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "no metrics for connection %q (is it connected?)", connName)
+	}
+	interval := max(time.Duration(data.IntervalMs)*time.Millisecond, sysmetrics.SampleInterval)
+	return sysmetrics.GetHistory(connName, data.Metrics, data.Count, interval), nil
+}
+
 func (ws *WshServer) GetMetaCommand(ctx context.Context, data wshrpc.CommandGetMetaData) (waveobj.MetaMapType, error) {
 	obj, err := wstore.DBGetORef(ctx, data.ORef)
 	if err != nil {
 		return nil, fmt.Errorf("error getting object: %w", err)
 	}
 	if obj == nil {
-		return nil, fmt.Errorf("object not found: %s", data.ORef)
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "object not found: %s", data.ORef)
 	}
 	return waveobj.GetMeta(obj), nil
 }
@@ -127,7 +423,20 @@ func (ws *WshServer) GetMetaCommand(ctx context.Context, data wshrpc.CommandGetM
 func (ws *WshServer) SetMetaCommand(ctx context.Context, data wshrpc.CommandSetMetaData) error {
 	log.Printf("SetMetaCommand: %s | %v\n", data.ORef, data.Meta)
 	oref := data.ORef
-	err := wstore.UpdateObjectMeta(ctx, oref, data.Meta, false)
+	if oref.OType == waveobj.OType_Client {
+		// validated (and reloads the client timeout)
+		err := wcore.UpdateClientMeta(ctx, data.Meta)
+		if err != nil {
+			return err
+		}
+		sendWaveObjUpdate(oref)
+		return nil
+	}
+	err := wcore.NormalizeWebMetaUpdate(ctx, oref, data.Meta)
+	if err != nil {
+		return err
+	}
+	err = wstore.UpdateObjectMeta(ctx, oref, data.Meta, false)
 	if err != nil {
 		return fmt.Errorf("error updating object meta: %w", err)
 	}
@@ -135,6 +444,18 @@ func (ws *WshServer) SetMetaCommand(ctx context.Context, data wshrpc.CommandSetM
 	return nil
 }
 
+// the web view calls this before it navigates (to a typed url or a link), see wcore.NormalizeWebUrl
+func (ws *WshServer) WebCheckUrlCommand(ctx context.Context, data wshrpc.CommandWebCheckUrlData) (string, error) {
+	block, err := wstore.DBGet[*waveobj.Block](ctx, data.BlockId)
+	if err != nil {
+		return "", fmt.Errorf("error getting block: %w", err)
+	}
+	if block == nil {
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block %s not found", data.BlockId)
+	}
+	return wcore.CheckWebNavigation(data.BlockId, data.Url, block.Meta.GetBool(waveobj.MetaKey_WebRendered, false))
+}
+
 func sendWaveObjUpdate(oref waveobj.ORef) {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancelFn()
@@ -179,26 +500,301 @@ func (ws *WshServer) ResolveIdsCommand(ctx context.Context, data wshrpc.CommandR
 	return rtn, nil
 }
 
-func (ws *WshServer) CreateBlockCommand(ctx context.Context, data wshrpc.CommandCreateBlockData) (*waveobj.ORef, error) {
-	ctx = waveobj.ContextWithUpdates(ctx)
+// resolves the tab (and window) that a new block goes into.  the tab must be open in a window.
+func resolveBlockPlacement(ctx context.Context, data wshrpc.CommandCreateBlockData) (string, string, error) {
 	tabId := data.TabId
+	if data.WindowId != "" {
+		window, err := wstore.DBGet[*waveobj.Window](ctx, data.WindowId)
+		if err != nil {
+			return "", "", fmt.Errorf("error getting window %s: %w", data.WindowId, err)
+		}
+		if window == nil {
+			return "", "", wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "window %s not found", data.WindowId)
+		}
+		workspaceId := ""
+		if tabId != "" {
+			workspaceId, err = wstore.DBFindWorkspaceForTabId(ctx, tabId)
+			if err != nil {
+				return "", "", fmt.Errorf("error finding workspace for tab %s: %w", tabId, err)
+			}
+		}
+		if tabId == "" || workspaceId != window.WorkspaceId {
+			workspace, err := wstore.DBMustGet[*waveobj.Workspace](ctx, window.WorkspaceId)
+			if err != nil {
+				return "", "", fmt.Errorf("error getting workspace for window %s: %w", data.WindowId, err)
+			}
+			tabId = workspace.ActiveTabId
+		}
+	}
+	if tabId == "" {
+		return "", "", fmt.Errorf("no tabid provided")
+	}
+	tab, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+	if err != nil {
+		return "", "", fmt.Errorf("error getting tab %s: %w", tabId, err)
+	}
+	if tab == nil {
+		return "", "", wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "tab %s not found", tabId)
+	}
+	workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, tabId)
+	if err != nil {
+		return "", "", fmt.Errorf("error finding workspace for tab %s: %w", tabId, err)
+	}
+	windowId := ""
+	if workspaceId != "" {
+		windowId, err = wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+		if err != nil {
+			return "", "", fmt.Errorf("error finding window for tab %s: %w", tabId, err)
+		}
+	}
+	if windowId == "" {
+		return "", "", fmt.Errorf("tab %s is not open in a window", tabId)
+	}
+	return tabId, windowId, nil
+}
+
+func (ws *WshServer) CreateBlockCommand(ctx context.Context, data wshrpc.CommandCreateBlockData) (*wshrpc.CommandCreateBlockRtnData, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	tabId, windowId, err := resolveBlockPlacement(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	if data.ReuseExisting {
+		existingBlock, err := findReusablePreviewBlock(ctx, tabId, data.BlockDef)
+		if err != nil {
+			return nil, err
+		}
+		if existingBlock != nil {
+			return reusePreviewBlock(ctx, existingBlock, tabId, windowId, data)
+		}
+	}
+	indexArr := data.IndexArr
+	if len(indexArr) == 0 {
+		indexArr, err = wcore.ResolveLayoutPosition(ctx, tabId, data.Position)
+		if err != nil {
+			return nil, err
+		}
+	}
 	blockData, err := wcore.CreateBlock(ctx, tabId, data.BlockDef, data.RtOpts)
 	if err != nil {
 		return nil, fmt.Errorf("error creating block: %w", err)
 	}
-	err = wcore.QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
+	layoutAction := waveobj.LayoutActionData{
 		ActionType: wcore.LayoutActionDataType_Insert,
 		BlockId:    blockData.OID,
 		Magnified:  data.Magnified,
 		Ephemeral:  data.Ephemeral,
 		Focused:    true,
-	})
+	}
+	if len(indexArr) > 0 {
+		layoutAction.ActionType = wcore.LayoutActionDataType_InsertAtIndex
+		layoutAction.IndexArr = &indexArr
+	}
+	err = wcore.QueueLayoutActionForTab(ctx, tabId, layoutAction)
 	if err != nil {
 		return nil, fmt.Errorf("error queuing layout action: %w", err)
 	}
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	wps.Broker.SendUpdateEvents(updates)
-	return &waveobj.ORef{OType: waveobj.OType_Block, OID: blockData.OID}, nil
+	return &wshrpc.CommandCreateBlockRtnData{
+		BlockORef: waveobj.ORef{OType: waveobj.OType_Block, OID: blockData.OID},
+		BlockId:   blockData.OID,
+		TabId:     tabId,
+		WindowId:  windowId,
+		IndexArr:  indexArr,
+	}, nil
+}
+
+// data: urls (and web:rendered) can only be set here, see wcore.ContextWithWebRender
+func (ws *WshServer) RenderHtmlCommand(ctx context.Context, data wshrpc.CommandRenderHtmlData) (*wshrpc.CommandCreateBlockRtnData, error) {
+	ctx = wcore.ContextWithWebRender(ctx)
+	url := wcore.MakeRenderHtmlUrl(data.Html)
+	if data.BlockId == "" {
+		return ws.CreateBlockCommand(ctx, wshrpc.CommandCreateBlockData{
+			TabId:     data.TabId,
+			BlockDef:  &waveobj.BlockDef{Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "web", waveobj.MetaKey_Url: url, waveobj.MetaKey_WebRendered: true}},
+			Magnified: data.Magnified,
+			Position:  data.Position,
+		})
+	}
+	block, err := wstore.DBGet[*waveobj.Block](ctx, data.BlockId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block: %w", err)
+	}
+	if block == nil {
+		return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block %s not found", data.BlockId)
+	}
+	if block.Meta.GetString(waveobj.MetaKey_View, "") != "web" || !block.Meta.GetBool(waveobj.MetaKey_WebRendered, false) {
+		return nil, fmt.Errorf("block %s was not opened by wsh render --html", data.BlockId)
+	}
+	oref := waveobj.MakeORef(waveobj.OType_Block, block.OID)
+	err = ws.SetMetaCommand(ctx, wshrpc.CommandSetMetaData{ORef: oref, Meta: waveobj.MetaMapType{waveobj.MetaKey_Url: url}})
+	if err != nil {
+		return nil, err
+	}
+	return &wshrpc.CommandCreateBlockRtnData{BlockORef: oref, BlockId: block.OID}, nil
+}
+
+// focuses the existing block (magnifying it if the new block would have been magnified), and makes it an editor
+// if the new block would have been one
+func reusePreviewBlock(ctx context.Context, block *waveobj.Block, tabId string, windowId string, data wshrpc.CommandCreateBlockData) (*wshrpc.CommandCreateBlockRtnData, error) {
+	if data.BlockDef.Meta.GetBool(waveobj.MetaKey_Edit, false) && !block.Meta.GetBool(waveobj.MetaKey_Edit, false) {
+		err := wstore.UpdateObjectMeta(ctx, waveobj.MakeORef(waveobj.OType_Block, block.OID), waveobj.MetaMapType{waveobj.MetaKey_Edit: true}, false)
+		if err != nil {
+			return nil, fmt.Errorf("error updating block meta: %w", err)
+		}
+	}
+	err := wcore.QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
+		ActionType: wcore.LayoutActionDataType_Focus,
+		BlockId:    block.OID,
+		Magnified:  data.Magnified,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error queuing focus action: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return &wshrpc.CommandCreateBlockRtnData{
+		BlockORef: waveobj.MakeORef(waveobj.OType_Block, block.OID),
+		BlockId:   block.OID,
+		TabId:     tabId,
+		WindowId:  windowId,
+		Reused:    true,
+	}, nil
+}
+
+func (ws *WshServer) CreateBlocksCommand(ctx context.Context, data wshrpc.CommandCreateBlocksData) ([]string, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return nil, fmt.Errorf("no tabid provided")
+	}
+	blockIds, err := wcore.CreateBlocks(ctx, data.TabId, data.Blocks, false)
+	if err != nil {
+		return nil, fmt.Errorf("error creating blocks: %w", err)
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	return blockIds, nil
+}
+
+func (ws *WshServer) SaveLayoutPresetCommand(ctx context.Context, data wshrpc.CommandLayoutPresetData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	_, err := wcore.SaveLayoutPreset(ctx, data.TabId, data.Name)
+	if err != nil {
+		return fmt.Errorf("error saving layout preset: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) ApplyLayoutPresetCommand(ctx context.Context, data wshrpc.CommandLayoutPresetData) ([]string, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return nil, fmt.Errorf("no tabid provided")
+	}
+	blockIds, err := wcore.ApplyLayoutPreset(ctx, data.TabId, data.Name, data.ClearExisting, data.Force)
+	if err != nil {
+		return nil, fmt.Errorf("error applying layout preset: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return blockIds, nil
+}
+
+func (ws *WshServer) ListLayoutPresetsCommand(ctx context.Context) ([]waveobj.LayoutPreset, error) {
+	presets, err := wcore.ListLayoutPresets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]waveobj.LayoutPreset, 0, len(presets))
+	for _, preset := range presets {
+		rtn = append(rtn, *preset)
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) DeleteLayoutPresetCommand(ctx context.Context, name string) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.DeleteLayoutPreset(ctx, name)
+	if err != nil {
+		return err
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) LayoutActionCommand(ctx context.Context, data wshrpc.CommandLayoutActionData) (*wshrpc.CommandLayoutActionRtnData, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	newBlockId, err := wcore.ApplyLayoutAction(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return &wshrpc.CommandLayoutActionRtnData{BlockId: newBlockId}, nil
+}
+
+func (ws *WshServer) SetBlockMagnifiedCommand(ctx context.Context, data wshrpc.CommandSetBlockMagnifiedData) (*wshrpc.CommandSetBlockMagnifiedRtnData, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	tabId, magnified, err := wcore.SetBlockMagnified(ctx, data.BlockId, data.Magnified, data.Toggle)
+	if err != nil {
+		return nil, err
+	}
+	// magnifying a block in another tab switches to the tab
+	var focusWorkspaceId string
+	if magnified && !data.NoFocus {
+		workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, tabId)
+		if err != nil {
+			return nil, fmt.Errorf("error finding workspace for tab: %w", err)
+		}
+		workspace, _ := wstore.DBGet[*waveobj.Workspace](ctx, workspaceId)
+		if workspace != nil && workspace.ActiveTabId != tabId {
+			err = wcore.SetActiveTab(ctx, workspaceId, tabId)
+			if err != nil {
+				return nil, fmt.Errorf("error setting active tab: %w", err)
+			}
+			focusWorkspaceId = workspaceId
+		}
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	if focusWorkspaceId != "" {
+		wcore.SendActiveTabUpdate(ctx, focusWorkspaceId, tabId)
+		err = focusWorkspaceWindow(ctx, focusWorkspaceId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &wshrpc.CommandSetBlockMagnifiedRtnData{TabId: tabId, Magnified: magnified}, nil
+}
+
+func (ws *WshServer) CreateTabCommand(ctx context.Context, data wshrpc.CommandCreateTabData) (string, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	workspaceId := data.WorkspaceId
+	if workspaceId == "" && data.WindowId != "" {
+		window, err := wstore.DBMustGet[*waveobj.Window](ctx, data.WindowId)
+		if err != nil {
+			return "", fmt.Errorf("error getting window: %w", err)
+		}
+		workspaceId = window.WorkspaceId
+	}
+	if workspaceId == "" {
+		if data.TabId == "" {
+			return "", fmt.Errorf("no workspaceid, windowid, or tabid provided")
+		}
+		var err error
+		workspaceId, err = findWorkspaceForTab(ctx, data.TabId)
+		if err != nil {
+			return "", err
+		}
+	}
+	activate := !data.NoActivate
+	tabId, err := wcore.CreateTab(ctx, workspaceId, data.TabName, activate, data.Pinned, false)
+	if err != nil {
+		return "", fmt.Errorf("error creating tab: %w", err)
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	if activate {
+		wcore.SendActiveTabUpdate(ctx, workspaceId, tabId)
+	}
+	return tabId, nil
 }
 
 func (ws *WshServer) CreateSubBlockCommand(ctx context.Context, data wshrpc.CommandCreateSubBlockData) (*waveobj.ORef, error) {
@@ -218,6 +814,17 @@ func (ws *WshServer) SetViewCommand(ctx context.Context, data wshrpc.CommandBloc
 	if err != nil {
 		return fmt.Errorf("error getting block: %w", err)
 	}
+	if block == nil {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block %s not found", data.BlockId)
+	}
+	// checks the block's url if it becomes a web block
+	err = wcore.NormalizeWebMetaUpdate(ctx, waveobj.MakeORef(waveobj.OType_Block, block.OID), waveobj.MetaMapType{waveobj.MetaKey_View: data.View})
+	if err != nil {
+		return err
+	}
+	if block.Meta == nil {
+		block.Meta = make(waveobj.MetaMapType)
+	}
 	block.Meta[waveobj.MetaKey_View] = data.View
 	err = wstore.DBUpdate(ctx, block)
 	if err != nil {
@@ -242,10 +849,15 @@ func (ws *WshServer) ControllerResyncCommand(ctx context.Context, data wshrpc.Co
 	return blockcontroller.ResyncController(ctx, data.TabId, data.BlockId, data.RtOpts, data.ForceRestart)
 }
 
+func (ws *WshServer) ControllerRestartCommand(ctx context.Context, data wshrpc.CommandControllerRestartData) error {
+	ctx = termCtxWithLogBlockId(ctx, data.BlockId)
+	return blockcontroller.RestartController(ctx, data.BlockId, data.Force)
+}
+
 func (ws *WshServer) ControllerInputCommand(ctx context.Context, data wshrpc.CommandBlockInputData) error {
 	bc := blockcontroller.GetBlockController(data.BlockId)
 	if bc == nil {
-		return fmt.Errorf("block controller not found for block %q", data.BlockId)
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block controller not found for block %q", data.BlockId)
 	}
 	inputUnion := &blockcontroller.BlockInputUnion{
 		SigName:  data.SigName,
@@ -262,6 +874,43 @@ func (ws *WshServer) ControllerInputCommand(ctx context.Context, data wshrpc.Com
 	return bc.SendInput(inputUnion)
 }
 
+func (ws *WshServer) TermSendCommand(ctx context.Context, data wshrpc.CommandTermSendData) error {
+	inputBuf, err := base64.StdEncoding.DecodeString(data.Data64)
+	if err != nil {
+		return fmt.Errorf("error decoding input data: %w", err)
+	}
+	if data.Start {
+		err = blockcontroller.StartControllerAndWait(ctx, data.BlockId)
+		if err != nil {
+			return fmt.Errorf("error starting controller: %w", err)
+		}
+	}
+	return blockcontroller.SendTermInput(data.BlockId, inputBuf)
+}
+
+func (ws *WshServer) TermResizeCommand(ctx context.Context, data wshrpc.CommandTermResizeData) error {
+	return blockcontroller.ResizeTerm(data.BlockId, data.TermSize)
+}
+
+func (ws *WshServer) TermClearCommand(ctx context.Context, blockId string) error {
+	return blockcontroller.ClearTerm(blockId)
+}
+
+func (ws *WshServer) TermTrimCommand(ctx context.Context, data wshrpc.CommandTermTrimData) (int64, error) {
+	return blockcontroller.TrimScrollback(ctx, data.BlockId, data.KeepBytes)
+}
+
+func (ws *WshServer) InjectPathCommand(ctx context.Context, data wshrpc.CommandInjectPathData) (string, error) {
+	if data.BlockId == "" && data.TabId == "" {
+		return "", wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "tabid or blockid must be set")
+	}
+	blockId, err := wcore.InjectPath(ctx, data.TabId, data.BlockId, data.Path)
+	if errors.Is(err, wcore.ErrNotTermBlock) {
+		return "", wshrpc.MakeCodedError(wshrpc.ErrorCode_InvalidArg, err)
+	}
+	return blockId, err
+}
+
 func (ws *WshServer) ControllerAppendOutputCommand(ctx context.Context, data wshrpc.CommandControllerAppendOutputData) error {
 	outputBuf := make([]byte, base64.StdEncoding.DecodedLen(len(data.Data64)))
 	nw, err := base64.StdEncoding.Decode(outputBuf, []byte(data.Data64))
@@ -514,18 +1163,29 @@ func (ws *WshServer) DeleteSubBlockCommand(ctx context.Context, data wshrpc.Comm
 	return nil
 }
 
-func (ws *WshServer) DeleteBlockCommand(ctx context.Context, data wshrpc.CommandDeleteBlockData) error {
+// how long the processes get to exit (with kill) before they are force killed
+const DefaultDeleteBlockKillGrace = 2 * time.Second
+
+func (ws *WshServer) DeleteBlockCommand(ctx context.Context, data wshrpc.CommandDeleteBlockData) (*wshrpc.CommandDeleteBlockRtnData, error) {
 	ctx = waveobj.ContextWithUpdates(ctx)
 	tabId, err := wstore.DBFindTabForBlockId(ctx, data.BlockId)
 	if err != nil {
-		return fmt.Errorf("error finding tab for block: %w", err)
+		return nil, fmt.Errorf("error finding tab for block: %w", err)
 	}
 	if tabId == "" {
-		return fmt.Errorf("no tab found for block")
+		return nil, fmt.Errorf("no tab found for block")
+	}
+	rtn := &wshrpc.CommandDeleteBlockRtnData{}
+	if data.Kill {
+		grace := DefaultDeleteBlockKillGrace
+		if data.GraceMs > 0 {
+			grace = time.Duration(data.GraceMs) * time.Millisecond
+		}
+		rtn.NumKilled = blockcontroller.StopBlockControllerAndKillProcs(data.BlockId, grace)
 	}
 	err = wcore.DeleteBlock(ctx, data.BlockId, true)
 	if err != nil {
-		return fmt.Errorf("error deleting block: %w", err)
+		return nil, fmt.Errorf("error deleting block: %w", err)
 	}
 	wcore.QueueLayoutActionForTab(ctx, tabId, waveobj.LayoutActionData{
 		ActionType: wcore.LayoutActionDataType_Remove,
@@ -533,9 +1193,350 @@ func (ws *WshServer) DeleteBlockCommand(ctx context.Context, data wshrpc.Command
 	})
 	updates := waveobj.ContextGetUpdatesRtn(ctx)
 	wps.Broker.SendUpdateEvents(updates)
+	return rtn, nil
+}
+
+// deletes the blocks in one transaction (all or none), skipping pinned blocks unless IncludePinned is set.  unlike
+// DeleteBlockCommand, tabs that end up empty are not closed.
+func (ws *WshServer) DeleteBlocksCommand(ctx context.Context, data wshrpc.CommandDeleteBlocksData) (*wshrpc.DeleteBlocksRtnData, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	rtn := &wshrpc.DeleteBlocksRtnData{Deleted: []string{}}
+	var toDelete []string
+	seen := make(map[string]bool)
+	for _, blockId := range data.BlockIds {
+		if seen[blockId] {
+			continue
+		}
+		seen[blockId] = true
+		tabId, err := wstore.DBFindTabForBlockId(ctx, blockId)
+		if err != nil {
+			return nil, fmt.Errorf("error finding tab for block %s: %w", blockId, err)
+		}
+		if tabId == "" {
+			return nil, fmt.Errorf("no tab found for block %s", blockId)
+		}
+		if !data.IncludePinned && wcore.IsBlockPinned(ctx, blockId) {
+			rtn.Skipped = append(rtn.Skipped, blockId)
+			continue
+		}
+		toDelete = append(toDelete, blockId)
+	}
+	deleted, err := wcore.DeleteBlocks(ctx, toDelete)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting blocks: %w", err)
+	}
+	rtn.Deleted = append(rtn.Deleted, deleted...)
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	return rtn, nil
+}
+
+func (ws *WshServer) LinkBlocksCommand(ctx context.Context, data wshrpc.CommandLinkBlocksData) (*waveobj.BlockLink, error) {
+	return wcore.LinkBlocks(ctx, data.SrcBlockId, data.DstBlockId, data.RuleType, data.Rule)
+}
+
+func (ws *WshServer) UnlinkBlocksCommand(ctx context.Context, linkId string) error {
+	return wcore.UnlinkBlocks(ctx, linkId)
+}
+
+func (ws *WshServer) ListBlockLinksCommand(ctx context.Context, blockId string) ([]*waveobj.BlockLink, error) {
+	links, err := wcore.ListBlockLinks(ctx, blockId)
+	if err != nil {
+		return nil, err
+	}
+	if links == nil {
+		links = []*waveobj.BlockLink{}
+	}
+	return links, nil
+}
+
+func (ws *WshServer) MoveBlockCommand(ctx context.Context, data wshrpc.CommandMoveBlockData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.DestTabId == "" {
+		return fmt.Errorf("no destination tab provided")
+	}
+	err := wcore.MoveBlock(ctx, data.BlockId, data.DestTabId, data.IndexArr)
+	if err != nil {
+		return err
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
 	return nil
 }
 
+func (ws *WshServer) DuplicateBlockCommand(ctx context.Context, data wshrpc.CommandDuplicateBlockData) (waveobj.ORef, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	newBlock, err := wcore.DuplicateBlock(ctx, data.BlockId, data.Meta)
+	if err != nil {
+		return waveobj.ORef{}, err
+	}
+	updates := waveobj.ContextGetUpdatesRtn(ctx)
+	wps.Broker.SendUpdateEvents(updates)
+	return waveobj.MakeORef(waveobj.OType_Block, newBlock.OID), nil
+}
+
+func (ws *WshServer) CloseTabCommand(ctx context.Context, data wshrpc.CommandCloseTabData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return fmt.Errorf("no tabid provided")
+	}
+	tab, err := wstore.DBMustGet[*waveobj.Tab](ctx, data.TabId)
+	if err != nil {
+		return fmt.Errorf("error getting tab: %w", err)
+	}
+	if data.AllBlocks {
+		for _, blockId := range tab.BlockIds {
+			if !data.Force && wcore.IsBlockPinned(ctx, blockId) {
+				continue
+			}
+			err = wcore.DeleteBlock(ctx, blockId, false)
+			if err != nil {
+				return fmt.Errorf("error deleting block %s: %w", blockId, err)
+			}
+			wcore.QueueLayoutActionForTab(ctx, data.TabId, waveobj.LayoutActionData{
+				ActionType: wcore.LayoutActionDataType_Remove,
+				BlockId:    blockId,
+			})
+		}
+		wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+		return nil
+	}
+	workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, data.TabId)
+	if err != nil {
+		return fmt.Errorf("error finding workspace for tab: %w", err)
+	}
+	workspace, err := wstore.DBMustGet[*waveobj.Workspace](ctx, workspaceId)
+	if err != nil {
+		return fmt.Errorf("error getting workspace: %w", err)
+	}
+	if utilfn.FindStringInSlice(workspace.PinnedTabIds, data.TabId) != -1 && !data.Force {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "tab %s is pinned (unpin it or use force to close it)", data.TabId)
+	}
+	if len(workspace.TabIds)+len(workspace.PinnedTabIds) <= 1 && !data.Force {
+		return fmt.Errorf("cannot close the last tab in a workspace (use force to also close the window)")
+	}
+	oldActiveTabId := workspace.ActiveTabId
+	newActiveTabId, err := wcore.DeleteTab(ctx, workspaceId, data.TabId, true)
+	if err != nil {
+		return fmt.Errorf("error closing tab: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	if newActiveTabId != "" && newActiveTabId != oldActiveTabId {
+		wcore.SendActiveTabUpdate(ctx, workspaceId, newActiveTabId)
+	}
+	return nil
+}
+
+func findWorkspaceForTab(ctx context.Context, tabId string) (string, error) {
+	workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, tabId)
+	if err != nil {
+		return "", fmt.Errorf("error finding workspace for tab: %w", err)
+	}
+	if workspaceId == "" {
+		return "", fmt.Errorf("no workspace found for tab %q", tabId)
+	}
+	return workspaceId, nil
+}
+
+func (ws *WshServer) RenameTabCommand(ctx context.Context, data wshrpc.CommandRenameTabData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return fmt.Errorf("no tabid provided")
+	}
+	if strings.TrimSpace(data.Name) == "" {
+		return fmt.Errorf("tab name cannot be empty")
+	}
+	err := wstore.UpdateTabName(ctx, data.TabId, data.Name)
+	if err != nil {
+		return fmt.Errorf("error renaming tab: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) MoveTabCommand(ctx context.Context, data wshrpc.CommandMoveTabData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return fmt.Errorf("no tabid provided")
+	}
+	workspaceId, err := findWorkspaceForTab(ctx, data.TabId)
+	if err != nil {
+		return err
+	}
+	err = wcore.MoveTab(ctx, workspaceId, data.TabId, data.Index)
+	if err != nil {
+		return fmt.Errorf("error moving tab: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) SetTabPinnedCommand(ctx context.Context, data wshrpc.CommandSetTabPinnedData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return fmt.Errorf("no tabid provided")
+	}
+	workspaceId, err := findWorkspaceForTab(ctx, data.TabId)
+	if err != nil {
+		return err
+	}
+	err = wcore.ChangeTabPinning(ctx, workspaceId, data.TabId, data.Pinned)
+	if err != nil {
+		return fmt.Errorf("error changing tab pinning: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) SetTabGroupCommand(ctx context.Context, data wshrpc.CommandSetTabGroupData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return fmt.Errorf("no tabid provided")
+	}
+	workspaceId, err := findWorkspaceForTab(ctx, data.TabId)
+	if err != nil {
+		return err
+	}
+	err = wcore.SetTabGroup(ctx, workspaceId, data.TabId, strings.TrimSpace(data.GroupName))
+	if err != nil {
+		return fmt.Errorf("error setting tab group: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) SetTabTitleTemplateCommand(ctx context.Context, data wshrpc.CommandSetTabTitleTemplateData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	if data.TabId == "" {
+		return fmt.Errorf("no tabid provided")
+	}
+	err := wcore.SetTabTitleTemplate(ctx, data.TabId, data.Template)
+	if err != nil {
+		return fmt.Errorf("error setting tab title template: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) GetTabTitleCommand(ctx context.Context, tabId string) (string, error) {
+	if tabId == "" {
+		return "", fmt.Errorf("no tabid provided")
+	}
+	return wcore.GetTabTitle(ctx, tabId)
+}
+
+// returns windowId if it is set, otherwise the window that is showing the tab
+func findWindowId(ctx context.Context, windowId string, tabId string) (string, error) {
+	if windowId != "" {
+		return windowId, nil
+	}
+	if tabId == "" {
+		return "", fmt.Errorf("no windowid or tabid provided")
+	}
+	workspaceId, err := findWorkspaceForTab(ctx, tabId)
+	if err != nil {
+		return "", err
+	}
+	windowId, err = wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+	if err != nil {
+		return "", fmt.Errorf("error finding window for workspace: %w", err)
+	}
+	if windowId == "" {
+		return "", fmt.Errorf("no window found for workspace %q", workspaceId)
+	}
+	return windowId, nil
+}
+
+func (ws *WshServer) CloseWindowCommand(ctx context.Context, data wshrpc.CommandCloseWindowData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	windowId, err := findWindowId(ctx, data.WindowId, data.TabId)
+	if err != nil {
+		return err
+	}
+	err = wcore.CloseWindow(ctx, windowId, false)
+	if err != nil {
+		return fmt.Errorf("error closing window: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) SetWindowGeometryCommand(ctx context.Context, data wshrpc.CommandSetWindowGeometryData) (*waveobj.Window, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	windowId, err := findWindowId(ctx, data.WindowId, data.TabId)
+	if err != nil {
+		return nil, err
+	}
+	window, err := wcore.SetWindowGeometry(ctx, windowId, data.Geometry)
+	if err != nil {
+		return nil, err
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return window, nil
+}
+
+// focuses the window that is showing the given workspace (electron updates the client window order on focus)
+func focusWorkspaceWindow(ctx context.Context, workspaceId string) error {
+	windowId, err := wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+	if err != nil {
+		return fmt.Errorf("error finding window for workspace: %w", err)
+	}
+	if windowId == "" {
+		return fmt.Errorf("workspace %q is not open in a window", workspaceId)
+	}
+	return wshclient.FocusWindowCommand(wshclient.GetBareRpcClient(), windowId, &wshrpc.RpcOpts{Route: wshutil.ElectronRoute})
+}
+
+func (ws *WshServer) FocusTabCommand(ctx context.Context, tabId string) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	tab, _ := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+	if tab == nil {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "tab not found: %q", tabId)
+	}
+	workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, tabId)
+	if err != nil {
+		return fmt.Errorf("error finding workspace for tab: %w", err)
+	}
+	if workspaceId == "" {
+		return fmt.Errorf("no workspace found for tab %q", tabId)
+	}
+	err = wcore.SetActiveTab(ctx, workspaceId, tabId)
+	if err != nil {
+		return fmt.Errorf("error setting active tab: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	wcore.SendActiveTabUpdate(ctx, workspaceId, tabId)
+	return focusWorkspaceWindow(ctx, workspaceId)
+}
+
+func (ws *WshServer) FocusBlockCommand(ctx context.Context, blockId string) error {
+	block, _ := wstore.DBGet[*waveobj.Block](ctx, blockId)
+	if block == nil {
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "block not found: %q", blockId)
+	}
+	tabId, err := wstore.DBFindTabForBlockId(ctx, blockId)
+	if err != nil {
+		return fmt.Errorf("error finding tab for block: %w", err)
+	}
+	if tabId == "" {
+		return fmt.Errorf("no tab found for block %q", blockId)
+	}
+	updatesCtx := waveobj.ContextWithUpdates(ctx)
+	err = wcore.QueueLayoutActionForTab(updatesCtx, tabId, waveobj.LayoutActionData{
+		ActionType: wcore.LayoutActionDataType_Focus,
+		BlockId:    blockId,
+	})
+	if err != nil {
+		return fmt.Errorf("error queuing focus action: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(updatesCtx))
+	return ws.FocusTabCommand(ctx, tabId)
+}
+
+func (ws *WshServer) GetFocusedBlockCommand(ctx context.Context, tabId string) (string, error) {
+	return wcore.GetFocusedBlockId(ctx, tabId)
+}
+
 func (ws *WshServer) WaitForRouteCommand(ctx context.Context, data wshrpc.CommandWaitForRouteData) (bool, error) {
 	waitCtx, cancelFn := context.WithTimeout(ctx, time.Duration(data.WaitMs)*time.Millisecond)
 	defer cancelFn()
@@ -555,6 +1556,9 @@ func (ws *WshServer) EventPublishCommand(ctx context.Context, data wps.WaveEvent
 	if data.Sender == "" {
 		data.Sender = rpcSource
 	}
+	if data.Event == wps.Event_SysInfo {
+		sysmetrics.AddSysInfoEvent(data)
+	}
 	wps.Broker.Publish(data)
 	return nil
 }
@@ -601,6 +1605,36 @@ func (ws *WshServer) SetConnectionsConfigCommand(ctx context.Context, data wshrp
 	return wconfig.SetConnectionsConfigValue(data.Host, data.MetaMapType)
 }
 
+func makeConfigPathRtn(section string) (*wshrpc.ConfigPathRtnData, error) {
+	fileName, err := wconfig.GetConfigFileName(section)
+	if err != nil {
+		return nil, wshrpc.MakeCodedError(wshrpc.ErrorCode_InvalidArg, err)
+	}
+	fullPath := filepath.Join(wavebase.GetWaveConfigDir(), filepath.FromSlash(fileName))
+	_, statErr := os.Stat(fullPath)
+	return &wshrpc.ConfigPathRtnData{Section: fileName, Path: fullPath, Exists: statErr == nil}, nil
+}
+
+// the path of a config file (on the wave host, the config dir differs per platform)
+func (ws *WshServer) GetConfigPathCommand(ctx context.Context, data wshrpc.CommandConfigPathData) (*wshrpc.ConfigPathRtnData, error) {
+	return makeConfigPathRtn(data.Section)
+}
+
+// replaces a config file with its defaults (the old file is kept as <file>.bak)
+func (ws *WshServer) ResetConfigCommand(ctx context.Context, data wshrpc.CommandConfigPathData) (*wshrpc.ConfigPathRtnData, error) {
+	rtn, err := makeConfigPathRtn(data.Section)
+	if err != nil {
+		return nil, err
+	}
+	backupPath, err := wconfig.ResetConfigFile(rtn.Section)
+	if err != nil {
+		return nil, err
+	}
+	rtn.Exists = true
+	rtn.BackupPath = backupPath
+	return rtn, nil
+}
+
 func (ws *WshServer) ConnStatusCommand(ctx context.Context) ([]wshrpc.ConnStatus, error) {
 	rtn := conncontroller.GetAllConnStatus()
 	return rtn, nil
@@ -640,7 +1674,7 @@ func (ws *WshServer) ConnDisconnectCommand(ctx context.Context, connName string)
 		distroName := strings.TrimPrefix(connName, "wsl://")
 		conn := wsl.GetWslConn(ctx, distroName, false)
 		if conn == nil {
-			return fmt.Errorf("distro not found: %s", connName)
+			return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "distro not found: %s", connName)
 		}
 		return conn.Close()
 	}
@@ -650,7 +1684,7 @@ func (ws *WshServer) ConnDisconnectCommand(ctx context.Context, connName string)
 	}
 	conn := conncontroller.GetConn(ctx, connOpts, false, &wshrpc.ConnKeywords{})
 	if conn == nil {
-		return fmt.Errorf("connection not found: %s", connName)
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "connection not found: %s", connName)
 	}
 	return conn.Close()
 }
@@ -662,7 +1696,7 @@ func (ws *WshServer) ConnConnectCommand(ctx context.Context, connRequest wshrpc.
 		distroName := strings.TrimPrefix(connName, "wsl://")
 		conn := wsl.GetWslConn(ctx, distroName, false)
 		if conn == nil {
-			return fmt.Errorf("connection not found: %s", connName)
+			return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "connection not found: %s", connName)
 		}
 		return conn.Connect(ctx)
 	}
@@ -672,7 +1706,7 @@ func (ws *WshServer) ConnConnectCommand(ctx context.Context, connRequest wshrpc.
 	}
 	conn := conncontroller.GetConn(ctx, connOpts, false, &connRequest.Keywords)
 	if conn == nil {
-		return fmt.Errorf("connection not found: %s", connName)
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "connection not found: %s", connName)
 	}
 	return conn.Connect(ctx, &connRequest.Keywords)
 }
@@ -684,7 +1718,7 @@ func (ws *WshServer) ConnReinstallWshCommand(ctx context.Context, data wshrpc.Co
 		distroName := strings.TrimPrefix(connName, "wsl://")
 		conn := wsl.GetWslConn(ctx, distroName, false)
 		if conn == nil {
-			return fmt.Errorf("connection not found: %s", connName)
+			return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "connection not found: %s", connName)
 		}
 		return conn.CheckAndInstallWsh(ctx, connName, &wsl.WshInstallOpts{Force: true, NoUserPrompt: true})
 	}
@@ -694,7 +1728,7 @@ func (ws *WshServer) ConnReinstallWshCommand(ctx context.Context, data wshrpc.Co
 	}
 	conn := conncontroller.GetConn(ctx, connOpts, false, &wshrpc.ConnKeywords{})
 	if conn == nil {
-		return fmt.Errorf("connection not found: %s", connName)
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "connection not found: %s", connName)
 	}
 	return conn.InstallWsh(ctx)
 }
@@ -731,7 +1765,7 @@ func (ws *WshServer) ConnUpdateWshCommand(ctx context.Context, remoteInfo wshrpc
 	}
 	conn := conncontroller.GetConn(ctx, connOpts, false, &wshrpc.ConnKeywords{})
 	if conn == nil {
-		return false, fmt.Errorf("connection not found: %s", connName)
+		return false, wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "connection not found: %s", connName)
 	}
 	err = conn.UpdateWsh(ctx, connName, &remoteInfo)
 	if err != nil {
@@ -746,6 +1780,38 @@ func (ws *WshServer) ConnListCommand(ctx context.Context) ([]string, error) {
 	return conncontroller.GetConnectionsList()
 }
 
+func (ws *WshServer) ConnListStatusCommand(ctx context.Context) ([]wshrpc.ConnStatus, error) {
+	return conncontroller.GetConnectionsListStatus()
+}
+
+const DefaultConnTestTimeout = 10 * time.Second
+
+func (ws *WshServer) ConnTestCommand(ctx context.Context, data wshrpc.CommandConnTestData) (wshrpc.CommandConnTestRtnData, error) {
+	if strings.HasPrefix(data.ConnName, "wsl://") {
+		return wshrpc.CommandConnTestRtnData{}, fmt.Errorf("connection test is only supported for ssh connections")
+	}
+	connOpts, err := remote.ParseOpts(data.ConnName)
+	if err != nil {
+		return wshrpc.CommandConnTestRtnData{}, fmt.Errorf("error parsing connection name: %w", err)
+	}
+	timeout := DefaultConnTestTimeout
+	if data.Timeout > 0 {
+		timeout = time.Duration(data.Timeout) * time.Millisecond
+	}
+	ctx, cancelFn := context.WithTimeout(ctx, timeout)
+	defer cancelFn()
+	result, err := remote.TestConnection(ctx, connOpts, &wshrpc.ConnKeywords{})
+	if err != nil {
+		return wshrpc.CommandConnTestRtnData{}, err
+	}
+	return wshrpc.CommandConnTestRtnData{
+		Connection:    connOpts.String(),
+		AuthMethod:    result.AuthMethod,
+		ConnectTimeMs: result.ConnectTime.Milliseconds(),
+		LatencyMs:     result.Latency.Milliseconds(),
+	}, nil
+}
+
 func (ws *WshServer) WslListCommand(ctx context.Context) ([]string, error) {
 	distros, err := wsl.RegisteredDistros(ctx)
 	if err != nil {
@@ -783,7 +1849,7 @@ func (ws *WshServer) DismissWshFailCommand(ctx context.Context, connName string)
 	}
 	conn := conncontroller.GetConn(ctx, opts, false, nil)
 	if conn == nil {
-		return fmt.Errorf("connection %s not found", connName)
+		return wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "connection %s not found", connName)
 	}
 	conn.ClearWshError()
 	conn.FireConnChangeEvent()
@@ -849,6 +1915,527 @@ func (ws *WshServer) WorkspaceListCommand(ctx context.Context) ([]wshrpc.Workspa
 	return rtn, nil
 }
 
+func (ws *WshServer) WorkspaceCreateCommand(ctx context.Context, data wshrpc.CommandWorkspaceCreateData) (*waveobj.Workspace, error) {
+	if data.Name == "" {
+		return nil, fmt.Errorf("workspace name is required")
+	}
+	ctx = waveobj.ContextWithUpdates(ctx)
+	newWs, err := wcore.CreateWorkspace(ctx, data.Name, data.Icon, data.Color, true, false)
+	if err != nil {
+		return nil, fmt.Errorf("error creating workspace: %w", err)
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return newWs, nil
+}
+
+func (ws *WshServer) WorkspaceRenameCommand(ctx context.Context, data wshrpc.CommandWorkspaceRenameData) error {
+	if data.Name == "" {
+		return fmt.Errorf("workspace name is required")
+	}
+	ctx = waveobj.ContextWithUpdates(ctx)
+	_, updated, err := wcore.UpdateWorkspace(ctx, data.WorkspaceId, data.Name, "", "", true)
+	if err != nil {
+		return fmt.Errorf("error renaming workspace: %w", err)
+	}
+	if updated {
+		wps.Broker.Publish(wps.WaveEvent{
+			Event: wps.Event_WorkspaceUpdate,
+		})
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+func (ws *WshServer) WorkspaceDeleteCommand(ctx context.Context, data wshrpc.CommandWorkspaceDeleteData) error {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	err := wcore.DeleteClosedWorkspace(ctx, data.WorkspaceId, data.Force, data.MoveToWorkspaceId)
+	if err != nil {
+		return err
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return nil
+}
+
+// switching to the workspace the window already shows is a no-op.  the blocks in the workspace that is switched
+// away from keep running (its tabs are just not shown).
+func (ws *WshServer) WorkspaceSwitchCommand(ctx context.Context, data wshrpc.CommandWorkspaceSwitchData) error {
+	if data.WorkspaceId == "" {
+		return fmt.Errorf("workspace id is required")
+	}
+	windowId := data.WindowId
+	if windowId == "" {
+		if data.TabId == "" {
+			return fmt.Errorf("window id or tab id is required")
+		}
+		workspaceId, err := wstore.DBFindWorkspaceForTabId(ctx, data.TabId)
+		if err != nil {
+			return fmt.Errorf("error finding workspace for tab: %w", err)
+		}
+		windowId, err = wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+		if err != nil {
+			return fmt.Errorf("error finding window for workspace: %w", err)
+		}
+		if windowId == "" {
+			return fmt.Errorf("tab %q is not open in a window", data.TabId)
+		}
+	}
+	window, err := wstore.DBMustGet[*waveobj.Window](ctx, windowId)
+	if err != nil {
+		return fmt.Errorf("error getting window: %w", err)
+	}
+	if window.WorkspaceId == data.WorkspaceId {
+		return nil
+	}
+	_, err = wstore.DBMustGet[*waveobj.Workspace](ctx, data.WorkspaceId)
+	if err != nil {
+		return fmt.Errorf("error getting workspace: %w", err)
+	}
+	switchData := wshrpc.CommandWorkspaceSwitchData{WindowId: windowId, WorkspaceId: data.WorkspaceId}
+	return wshclient.WorkspaceSwitchCommand(wshclient.GetBareRpcClient(), switchData, &wshrpc.RpcOpts{Route: wshutil.ElectronRoute, Timeout: 5000})
+}
+
+func (ws *WshServer) DebugCacheStatsCommand(ctx context.Context) (wshrpc.DebugCacheStatsData, error) {
+	stats := wstore.GetCacheStats()
+	return wshrpc.DebugCacheStatsData{Hits: stats.Hits, Misses: stats.Misses, Entries: stats.Entries}, nil
+}
+
+func makeIntegrityIssueData(issues []wstore.IntegrityIssue) []wshrpc.IntegrityIssueData {
+	rtn := make([]wshrpc.IntegrityIssueData, 0, len(issues))
+	for _, issue := range issues {
+		rtn = append(rtn, wshrpc.IntegrityIssueData{ORef: issue.ORef, Field: issue.Field, RefId: issue.RefId, Reason: issue.Reason})
+	}
+	return rtn
+}
+
+func (ws *WshServer) DebugIntegrityCommand(ctx context.Context, data wshrpc.CommandDebugIntegrityData) (*wshrpc.DebugIntegrityRtnData, error) {
+	if !data.Repair {
+		report, err := wstore.CheckIntegrity(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error checking integrity: %w", err)
+		}
+		return &wshrpc.DebugIntegrityRtnData{Dangling: makeIntegrityIssueData(report.Dangling), Orphans: makeIntegrityIssueData(report.Orphans)}, nil
+	}
+	ctx = waveobj.ContextWithUpdates(ctx)
+	result, err := wstore.RepairIntegrity(ctx, wstore.RepairIntegrityOpts{DeleteOrphans: data.DeleteOrphans})
+	if err != nil {
+		return nil, fmt.Errorf("error repairing integrity: %w", err)
+	}
+	for _, oref := range result.Deleted {
+		if oref.OType == waveobj.OType_Block {
+			go blockcontroller.StopBlockController(oref.OID)
+		}
+	}
+	for tabId, blockIds := range result.LayoutInserts {
+		var actions []waveobj.LayoutActionData
+		for _, blockId := range blockIds {
+			actions = append(actions, waveobj.LayoutActionData{ActionType: wcore.LayoutActionDataType_Insert, BlockId: blockId})
+		}
+		err := wcore.QueueLayoutActionForTab(ctx, tabId, actions...)
+		if err != nil {
+			log.Printf("error queuing layout inserts for repaired tab %s: %v\n", tabId, err)
+		}
+	}
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return &wshrpc.DebugIntegrityRtnData{
+		Dangling:   makeIntegrityIssueData(result.Report.Dangling),
+		Orphans:    makeIntegrityIssueData(result.Report.Orphans),
+		Repaired:   true,
+		NumUpdated: len(result.Updated),
+		NumDeleted: len(result.Deleted),
+	}, nil
+}
+
+// rolling back a migration is done with the server stopped ("wavesrv --rollback-db"), not over rpc
+func (ws *WshServer) DebugDBVersionCommand(ctx context.Context) (*wshrpc.DebugDBVersionRtnData, error) {
+	rtn := &wshrpc.DebugDBVersionRtnData{}
+	for _, store := range []struct {
+		name   string
+		infoFn func() (*migrateutil.VersionInfo, error)
+	}{{"wstore", wstore.GetDBVersionInfo}, {"filestore", filestore.GetDBVersionInfo}} {
+		info, err := store.infoFn()
+		if err != nil {
+			return nil, err
+		}
+		rtn.Stores = append(rtn.Stores, wshrpc.DBVersionInfo{Store: store.name, Version: info.Version, Expected: info.Latest, Dirty: info.Dirty})
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) SnapshotExportCommand(ctx context.Context) (string, error) {
+	var buf strings.Builder
+	err := wstore.ExportSnapshot(ctx, &buf)
+	if err != nil {
+		return "", fmt.Errorf("error exporting snapshot: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func (ws *WshServer) SnapshotImportCommand(ctx context.Context, data wshrpc.CommandSnapshotImportData) (*wshrpc.SnapshotImportRtnData, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	result, err := wstore.ImportSnapshot(ctx, strings.NewReader(data.Data), wstore.ImportSnapshotOpts{KeepIds: data.KeepIds})
+	if err != nil {
+		return nil, err
+	}
+	for _, wsId := range result.WorkspaceIds {
+		// unsaved workspaces get a name/icon/color so they show up in the workspace list
+		_, _, err := wcore.UpdateWorkspace(ctx, wsId, "", "", "", true)
+		if err != nil {
+			return nil, fmt.Errorf("error updating imported workspace: %w", err)
+		}
+	}
+	rtn := &wshrpc.SnapshotImportRtnData{
+		WorkspaceIds: result.WorkspaceIds,
+		NumTabs:      result.NumTabs,
+		NumBlocks:    result.NumBlocks,
+	}
+	if data.NewWindow && len(result.WorkspaceIds) > 0 {
+		window, err := wcore.MakeWindow(ctx, &wcore.MakeWindowOpts{WorkspaceId: result.WorkspaceIds[0]})
+		if err != nil {
+			return nil, fmt.Errorf("error creating window: %w", err)
+		}
+		eventbus.SendEventToElectron(eventbus.WSEventType{
+			EventType: eventbus.WSEvent_ElectronNewWindow,
+			Data:      window.OID,
+		})
+		rtn.WindowId = window.OID
+	}
+	wps.Broker.Publish(wps.WaveEvent{
+		Event: wps.Event_WorkspaceUpdate,
+	})
+	wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	return rtn, nil
+}
+
+type listWindowInfo struct {
+	Window    *waveobj.Window
+	Workspace *waveobj.Workspace
+}
+
+// walks client -> windows -> workspaces (open windows only)
+func getListWindows(ctx context.Context, windowId string) ([]listWindowInfo, error) {
+	client, err := wstore.DBGetSingleton[*waveobj.Client](ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %w", err)
+	}
+	var rtn []listWindowInfo
+	for _, curWindowId := range client.WindowIds {
+		if windowId != "" && curWindowId != windowId {
+			continue
+		}
+		window, err := wstore.DBGet[*waveobj.Window](ctx, curWindowId)
+		if err != nil || window == nil {
+			continue
+		}
+		workspace, err := wstore.DBGet[*waveobj.Workspace](ctx, window.WorkspaceId)
+		if err != nil || workspace == nil {
+			continue
+		}
+		rtn = append(rtn, listWindowInfo{Window: window, Workspace: workspace})
+	}
+	return rtn, nil
+}
+
+// finds a workspace by name (or id)
+func findWorkspaceByName(ctx context.Context, nameOrId string) (*waveobj.Workspace, error) {
+	workspaces, err := wstore.DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting workspaces: %w", err)
+	}
+	for _, workspace := range workspaces {
+		if workspace.Name == nameOrId {
+			return workspace, nil
+		}
+	}
+	for _, workspace := range workspaces {
+		if workspace.OID == nameOrId {
+			return workspace, nil
+		}
+	}
+	return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_NotFound, "workspace %q not found", nameOrId)
+}
+
+// opens a new window (on the given workspace, or on a new one), or focuses the window that already shows the
+// workspace.  with a tab name or layout the window gets a tab with those blocks (a new one in an existing workspace),
+// created along with the window.  the block (if given) is opened in the workspace's active tab.
+func (ws *WshServer) LaunchWindowCommand(ctx context.Context, data wshrpc.CommandLaunchWindowData) (*wshrpc.LaunchWindowRtnData, error) {
+	ctx = waveobj.ContextWithUpdates(ctx)
+	layout := wcore.PortableLayout(data.Layout)
+	if len(layout) > 0 {
+		err := layout.Validate()
+		if err != nil {
+			return nil, wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "invalid layout: %v", err)
+		}
+	}
+	newTab := data.TabName != "" || len(layout) > 0
+	var workspaceId, windowId string
+	if data.Workspace != "" {
+		workspace, err := findWorkspaceByName(ctx, data.Workspace)
+		if err != nil {
+			return nil, err
+		}
+		workspaceId = workspace.OID
+		windowId, err = wstore.DBFindWindowForWorkspaceId(ctx, workspaceId)
+		if err != nil {
+			return nil, fmt.Errorf("error finding window for workspace: %w", err)
+		}
+	}
+	newWindow := windowId == ""
+	if newWindow {
+		windowOpts := &wcore.MakeWindowOpts{WorkspaceId: workspaceId, TabName: data.TabName, Layout: layout}
+		window, err := wcore.MakeWindow(ctx, windowOpts)
+		if err != nil {
+			return nil, fmt.Errorf("error creating window: %w", err)
+		}
+		windowId = window.OID
+		workspaceId = window.WorkspaceId
+	} else if newTab {
+		tabId, err := wstore.WithTxRtn(ctx, func(tx *wstore.TxWrap) (string, error) {
+			return wcore.CreateTabWithLayout(tx.Context(), workspaceId, data.TabName, true, false, false, layout)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error creating tab: %w", err)
+		}
+		wcore.SendActiveTabUpdate(ctx, workspaceId, tabId)
+	}
+	workspace, err := wcore.GetWorkspace(ctx, workspaceId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting workspace: %w", err)
+	}
+	rtn := &wshrpc.LaunchWindowRtnData{WindowId: windowId, TabId: workspace.ActiveTabId}
+	if data.BlockDef != nil {
+		createData := wshrpc.CommandCreateBlockData{
+			TabId:         workspace.ActiveTabId,
+			BlockDef:      data.BlockDef,
+			ReuseExisting: data.BlockDef.Meta.GetString(waveobj.MetaKey_View, "") == "preview" && !data.BlockDef.Meta.GetBool(waveobj.MetaKey_FileTemp, false),
+		}
+		blockRtn, err := ws.CreateBlockCommand(ctx, createData)
+		if err != nil {
+			return nil, err
+		}
+		rtn.BlockId = blockRtn.BlockId
+	} else {
+		wps.Broker.SendUpdateEvents(waveobj.ContextGetUpdatesRtn(ctx))
+	}
+	if newWindow {
+		eventbus.SendEventToElectron(eventbus.WSEventType{
+			EventType: eventbus.WSEvent_ElectronNewWindow,
+			Data:      windowId,
+		})
+	} else {
+		err = wcore.FocusWindow(ctx, windowId)
+		if err != nil {
+			return nil, fmt.Errorf("error focusing window: %w", err)
+		}
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) ListWindowsCommand(ctx context.Context) ([]wshrpc.WindowListEntry, error) {
+	windows, err := getListWindows(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]wshrpc.WindowListEntry, 0, len(windows))
+	for _, winInfo := range windows {
+		rtn = append(rtn, wshrpc.WindowListEntry{
+			WindowId:      winInfo.Window.OID,
+			WorkspaceId:   winInfo.Workspace.OID,
+			WorkspaceName: winInfo.Workspace.Name,
+			ActiveTabId:   winInfo.Workspace.ActiveTabId,
+			NumTabs:       len(winInfo.Workspace.PinnedTabIds) + len(winInfo.Workspace.TabIds),
+			LastFocusTs:   winInfo.Window.LastFocusTs,
+			CreatedTs:     winInfo.Window.CreatedTs,
+		})
+	}
+	return rtn, nil
+}
+
+func (ws *WshServer) ListTabsCommand(ctx context.Context, data wshrpc.CommandListData) ([]wshrpc.TabListEntry, error) {
+	windows, err := getListWindows(ctx, data.WindowId)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]wshrpc.TabListEntry, 0)
+	for _, winInfo := range windows {
+		workspace := winInfo.Workspace
+		tabIds := append(append([]string{}, workspace.PinnedTabIds...), workspace.TabIds...)
+		for idx, tabId := range tabIds {
+			if data.TabId != "" && tabId != data.TabId {
+				continue
+			}
+			tab, err := wstore.DBGet[*waveobj.Tab](ctx, tabId)
+			if err != nil || tab == nil {
+				continue
+			}
+			rtn = append(rtn, wshrpc.TabListEntry{
+				TabId:       tab.OID,
+				WindowId:    winInfo.Window.OID,
+				WorkspaceId: workspace.OID,
+				Name:        tab.Name,
+				Pinned:      idx < len(workspace.PinnedTabIds),
+				GroupName:   tab.GroupName,
+				Active:      tab.OID == workspace.ActiveTabId,
+				NumBlocks:   len(tab.BlockIds),
+				Cwd:         getTabCwd(ctx, tab),
+				CreatedTs:   tab.CreatedTs,
+			})
+		}
+	}
+	return rtn, nil
+}
+
+func getTabCwd(ctx context.Context, tab *waveobj.Tab) string {
+	for _, blockId := range tab.BlockIds {
+		block, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+		if err != nil || block == nil || block.Meta.GetString(waveobj.MetaKey_View, "") != "term" {
+			continue
+		}
+		if cwd := block.Meta.GetString(waveobj.MetaKey_CmdCwd, ""); cwd != "" {
+			return cwd
+		}
+	}
+	return ""
+}
+
+func (ws *WshServer) ListBlocksCommand(ctx context.Context, data wshrpc.CommandListData) ([]wshrpc.BlockListEntry, error) {
+	if data.Deleted {
+		return listDeletedBlocks(ctx, data)
+	}
+	tabs, err := ws.ListTabsCommand(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]wshrpc.BlockListEntry, 0)
+	for _, tabEntry := range tabs {
+		tab, err := wstore.DBGet[*waveobj.Tab](ctx, tabEntry.TabId)
+		if err != nil || tab == nil {
+			continue
+		}
+		for _, blockId := range tab.BlockIds {
+			block, err := wstore.DBGet[*waveobj.Block](ctx, blockId)
+			if err != nil || block == nil {
+				continue
+			}
+			view := block.Meta.GetString(waveobj.MetaKey_View, "")
+			if data.View != "" && view != data.View {
+				continue
+			}
+			if data.Pinned && !block.Meta.GetBool(waveobj.MetaKey_Pinned, false) {
+				continue
+			}
+			rtn = append(rtn, makeBlockListEntry(ctx, block, tabEntry.TabId, tabEntry.WindowId, tabEntry.WorkspaceId, data.Sizes))
+		}
+	}
+	return rtn, nil
+}
+
+func makeBlockListEntry(ctx context.Context, block *waveobj.Block, tabId string, windowId string, workspaceId string, withSize bool) wshrpc.BlockListEntry {
+	entry := wshrpc.BlockListEntry{
+		BlockId:     block.OID,
+		TabId:       tabId,
+		WindowId:    windowId,
+		WorkspaceId: workspaceId,
+		View:        block.Meta.GetString(waveobj.MetaKey_View, ""),
+		Meta:        block.Meta,
+		CreatedTs:   block.CreatedTs,
+		Cwd:         block.Meta.GetString(waveobj.MetaKey_CmdCwd, ""),
+	}
+	if bc := blockcontroller.GetBlockController(block.OID); bc != nil {
+		rtStatus := bc.GetRuntimeStatus()
+		entry.ControllerStatus = rtStatus.ShellProcStatus
+		entry.ControllerExitCode = rtStatus.ShellProcExitCode
+	}
+	if withSize {
+		scrollbackSize, err := blockcontroller.GetScrollbackSize(ctx, block.OID)
+		if err == nil {
+			entry.ScrollbackSize = &scrollbackSize
+		}
+	}
+	return entry
+}
+
+func (ws *WshServer) QueryBlocksCommand(ctx context.Context, data wshrpc.CommandQueryBlocksData) ([]wshrpc.BlockListEntry, error) {
+	blocks, err := wstore.QueryBlocks(ctx, wstore.BlockFilter{
+		View:         data.View,
+		UrlPrefix:    data.UrlPrefix,
+		UrlContains:  data.UrlContains,
+		FilePrefix:   data.FilePrefix,
+		FileContains: data.FileContains,
+		Connection:   data.Connection,
+		Pinned:       data.Pinned,
+		TabId:        data.TabId,
+		WindowId:     data.WindowId,
+		CreatedAfter: data.CreatedAfter,
+		Limit:        data.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error querying blocks: %w", err)
+	}
+	// tab id => the workspace (and window) it is in
+	tabWorkspaces := make(map[string]string)
+	workspaces, err := wstore.DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting workspaces: %w", err)
+	}
+	for _, workspace := range workspaces {
+		for _, tabId := range append(append([]string{}, workspace.PinnedTabIds...), workspace.TabIds...) {
+			tabWorkspaces[tabId] = workspace.OID
+		}
+	}
+	workspaceWindows := make(map[string]string)
+	windows, err := getListWindows(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, winInfo := range windows {
+		workspaceWindows[winInfo.Workspace.OID] = winInfo.Window.OID
+	}
+	rtn := make([]wshrpc.BlockListEntry, 0, len(blocks))
+	for _, block := range blocks {
+		var tabId string
+		if parentORef := waveobj.ParseORefNoErr(block.ParentORef); parentORef != nil {
+			tabId = parentORef.OID
+		}
+		workspaceId := tabWorkspaces[tabId]
+		rtn = append(rtn, makeBlockListEntry(ctx, block, tabId, workspaceWindows[workspaceId], workspaceId, data.Sizes))
+	}
+	return rtn, nil
+}
+
+// tabid is the tab the block was deleted from (the tab itself might not exist anymore)
+func listDeletedBlocks(ctx context.Context, data wshrpc.CommandListData) ([]wshrpc.BlockListEntry, error) {
+	blocks, err := wcore.GetDeletedBlocks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting deleted blocks: %w", err)
+	}
+	rtn := make([]wshrpc.BlockListEntry, 0)
+	for _, block := range blocks {
+		var tabId string
+		if parentORef := waveobj.ParseORefNoErr(block.ParentORef); parentORef != nil && parentORef.OType == waveobj.OType_Tab {
+			tabId = parentORef.OID
+		}
+		if data.TabId != "" && tabId != data.TabId {
+			continue
+		}
+		view := block.Meta.GetString(waveobj.MetaKey_View, "")
+		if data.View != "" && view != data.View {
+			continue
+		}
+		rtn = append(rtn, wshrpc.BlockListEntry{
+			BlockId:   block.OID,
+			TabId:     tabId,
+			View:      view,
+			Meta:      block.Meta,
+			DeletedTs: block.DeletedTs,
+			CreatedTs: block.CreatedTs,
+		})
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].DeletedTs > rtn[j].DeletedTs
+	})
+	return rtn, nil
+}
+
 var wshActivityRe = regexp.MustCompile(`^[a-z:#]+$`)
 
 func (ws *WshServer) WshActivityCommand(ctx context.Context, data map[string]int) error {
@@ -878,6 +2465,74 @@ func (ws *WshServer) ActivityCommand(ctx context.Context, activity wshrpc.Activi
 	return nil
 }
 
+func (ws *WshServer) RecordActivityCommand(ctx context.Context, data wshrpc.ActivityRecord) error {
+	err := telemetry.ValidateActivityRecord(data)
+	if err != nil {
+		return err
+	}
+	telemetry.GoRecordActivityWrap(data)
+	// wsh commands are also counted in the (uploadable) daily activity
+	if strings.HasPrefix(data.Feature, "wsh:") {
+		wshCmdName := strings.TrimPrefix(data.Feature, "wsh:")
+		wshCmds := map[string]int{wshCmdName: 1}
+		if !data.Success {
+			wshCmds[wshCmdName+"#error"] = 1
+		}
+		return ws.WshActivityCommand(ctx, wshCmds)
+	}
+	return nil
+}
+
+func (ws *WshServer) GetActivityStatsCommand(ctx context.Context, sinceDays int) ([]wshrpc.ActivityStat, error) {
+	return telemetry.GetActivityStats(ctx, sinceDays)
+}
+
+func makeDBStatus(name string, path string, open bool, health dbutil.HealthStatus) wshrpc.DBStatus {
+	return wshrpc.DBStatus{
+		Name:      name,
+		Path:      path,
+		Open:      open,
+		Locked:    health.Locked,
+		InFlight:  health.InFlight,
+		LastOkTs:  health.LastOkTs,
+		LastErr:   health.LastErr,
+		LastErrTs: health.LastErrTs,
+	}
+}
+
+// only reads cached state (never the db), so it answers even when the db is locked
+func (ws *WshServer) GetServerStatusCommand(ctx context.Context) (*wshrpc.ServerStatusData, error) {
+	now := time.Now()
+	rtn := &wshrpc.ServerStatusData{
+		Version:               wavebase.WaveVersion,
+		BuildTime:             wavebase.BuildTime,
+		StartTs:               wavebase.ProcessStartTime.UnixMilli(),
+		UptimeMs:              now.Sub(wavebase.ProcessStartTime).Milliseconds(),
+		NumRunningControllers: blockcontroller.NumRunningControllers(),
+		Healthy:               true,
+		Restore:               blockcontroller.GetRestoreStatus(),
+	}
+	wstoreOpen, wstoreHealth := wstore.GetDBHealth()
+	filestoreOpen, filestoreHealth := filestore.GetDBHealth()
+	rtn.DBs = []wshrpc.DBStatus{
+		makeDBStatus("wstore", wstore.GetDBName(), wstoreOpen, wstoreHealth),
+		makeDBStatus("filestore", filestore.GetDBName(), filestoreOpen, filestoreHealth),
+	}
+	for _, dbStatus := range rtn.DBs {
+		if !dbStatus.Open || dbStatus.Locked {
+			rtn.Healthy = false
+		}
+	}
+	busStats := eventbus.GetStats()
+	rtn.NumWebSockets = busStats.NumWebSockets
+	rtn.NumConnectedWindows = busStats.NumConnectedWindows
+	rtn.NumEventListeners = busStats.NumListeners
+	rtn.ListenerQueuedEvents = busStats.ListenerQueuedEvents
+	rtn.NumWindowQueues = busStats.NumWindowQueues
+	rtn.WindowQueuedEvents = busStats.WindowQueuedEvents
+	return rtn, nil
+}
+
 func (ws *WshServer) GetVarCommand(ctx context.Context, data wshrpc.CommandVarData) (*wshrpc.CommandVarResponseData, error) {
 	_, fileData, err := filestore.WFS.ReadFile(ctx, data.ZoneId, data.FileName)
 	if err == fs.ErrNotExist {
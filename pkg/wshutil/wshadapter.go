@@ -137,18 +137,26 @@ func serverImplAdapter(impl any) func(*RpcResponseHandler) bool {
 				}()
 				defer handler.Finalize()
 				// must use reflection here because we don't know the generic type of RespOrErrorUnion
+				canceled := false
 				for {
 					respVal, ok := rtnChVal.Recv()
 					if !ok {
 						break
 					}
+					if canceled {
+						// keep reading so a handler that doesn't check its context isn't blocked
+						continue
+					}
 					errorVal := respVal.FieldByName("Error")
 					if !errorVal.IsNil() {
 						handler.SendResponseError(errorVal.Interface().(error))
 						break
 					}
 					respData := respVal.FieldByName("Response").Interface()
-					handler.SendResponse(respData, false)
+					err := handler.SendResponse(respData, false)
+					if err != nil {
+						canceled = true
+					}
 				}
 			}()
 			return false
@@ -70,8 +70,9 @@ func (p *WshRpcMultiProxy) sendResponseError(msg RpcMessage, sendErr error) {
 		return
 	}
 	resp := RpcMessage{
-		ResId: msg.ReqId,
-		Error: sendErr.Error(),
+		ResId:     msg.ReqId,
+		Error:     sendErr.Error(),
+		ErrorCode: wshrpc.GetErrorCode(sendErr),
 	}
 	respBytes, _ := json.Marshal(resp)
 	p.ToRemoteCh <- respBytes
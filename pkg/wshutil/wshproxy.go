@@ -58,9 +58,10 @@ func (p *WshRpcProxy) sendResponseError(msg RpcMessage, sendErr error) {
 		return
 	}
 	resp := RpcMessage{
-		ResId: msg.ReqId,
-		Route: msg.Source,
-		Error: sendErr.Error(),
+		ResId:     msg.ReqId,
+		Route:     msg.Source,
+		Error:     sendErr.Error(),
+		ErrorCode: wshrpc.GetErrorCode(sendErr),
 	}
 	respBytes, _ := json.Marshal(resp)
 	p.SendRpcMessage(respBytes)
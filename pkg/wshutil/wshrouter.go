@@ -127,8 +127,9 @@ func (router *WshRouter) handleNoRoute(msg RpcMessage) {
 	}
 	// send error response
 	response := RpcMessage{
-		ResId: msg.ReqId,
-		Error: nrErr.Error(),
+		ResId:     msg.ReqId,
+		Error:     nrErr.Error(),
+		ErrorCode: wshrpc.ErrorCode_ConnRefused,
 	}
 	respBytes, _ := json.Marshal(response)
 	router.sendRoutedMessage(respBytes, msg.Source)
@@ -427,7 +428,7 @@ func (router *WshRouter) RunSimpleRawCommand(ctx context.Context, msg RpcMessage
 		return nil, ctx.Err()
 	case resp := <-respCh:
 		if resp.Error != "" {
-			return nil, errors.New(resp.Error)
+			return nil, makeRespError(resp)
 		}
 		return resp, nil
 	}
@@ -17,16 +17,23 @@ import (
 	"github.com/google/uuid"
 	"github.com/wavetermdev/waveterm/pkg/panichandler"
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
 	"github.com/wavetermdev/waveterm/pkg/wps"
 	"github.com/wavetermdev/waveterm/pkg/wshrpc"
 )
 
 const DefaultTimeoutMs = 5000
+const MaxTimeoutMs = 24 * 60 * 60 * 1000 // used for "no timeout" (the other side still needs a timeout for its context)
+const timeoutErrorStr = "EC-TIME: timeout waiting for response"
 const RespChSize = 32
 const DefaultMessageChSize = 32
 
+var errOutputClosed = errors.New("rpc output is closed")
+
 type ResponseFnType = func(any) error
 
+var rpcLog = wlog.New("rpc")
+
 // returns true if handler is complete, false for an async handler
 type CommandHandlerFnType = func(*RpcResponseHandler) bool
 
@@ -52,6 +59,20 @@ type WshRpc struct {
 	ResponseHandlerMap map[string]*RpcResponseHandler // reqId => handler
 	Debug              bool
 	DebugName          string
+	outputLock         *sync.Mutex   // guards outputClosed and the sends to OutputCh (see sendOutput)
+	outputClosed       bool          // set when runServer exits (it closes OutputCh)
+	outputDoneCh       chan struct{} // closed when runServer exits, unblocks the sends waiting on a full OutputCh
+	timeoutOverrideMs  atomic.Int64  // see SetTimeoutOverride
+}
+
+// returned when no response was received before the request's timeout
+type RpcTimeoutError struct {
+	Timeout time.Duration
+	Route   string
+}
+
+func (e *RpcTimeoutError) Error() string {
+	return fmt.Sprintf("request timed out after %v", e.Timeout)
 }
 
 type wshRpcContextKey struct{}
@@ -116,9 +137,16 @@ type RpcMessage struct {
 	Source    string `json:"source,omitempty"`    // source route id
 	Cont      bool   `json:"cont,omitempty"`      // flag if additional requests/responses are forthcoming
 	Cancel    bool   `json:"cancel,omitempty"`    // used to cancel a streaming request or response (sent from the side that is not streaming)
-	Error     string `json:"error,omitempty"`
-	DataType  string `json:"datatype,omitempty"`
-	Data      any    `json:"data,omitempty"`
+	// flow control for response streams.  on a request, the most data frames the client will buffer before it
+	// acks (it is echoed on the data frames if the server applies it).  on an ack packet (reqid set, no command),
+	// StreamAck is the number of data frames received so far.
+	StreamWindow int    `json:"streamwindow,omitempty"`
+	StreamAck    int    `json:"streamack,omitempty"`
+	Error        string `json:"error,omitempty"`
+	ErrorCode    string `json:"errorcode,omitempty"` // see wshrpc.ErrorCode_* (only set on error responses)
+	ErrorData    any    `json:"errordata,omitempty"` // extra data for some of the error codes (see wshrpc.CodedError)
+	DataType     string `json:"datatype,omitempty"`
+	Data         any    `json:"data,omitempty"`
 }
 
 func (r *RpcMessage) IsRpcRequest() bool {
@@ -141,6 +169,12 @@ func (r *RpcMessage) Validate() error {
 		}
 		return nil
 	}
+	if r.StreamAck > 0 {
+		if r.Command != "" || r.ReqId == "" || r.ResId != "" {
+			return fmt.Errorf("stream ack packets must have only reqid set")
+		}
+		return nil
+	}
 	if r.Command != "" {
 		if r.ResId != "" {
 			return fmt.Errorf("command packets may not have resid set")
@@ -211,12 +245,20 @@ func MakeWshRpc(inputCh chan []byte, outputCh chan []byte, rpcCtx wshrpc.RpcCont
 		EventListener:      MakeEventListener(),
 		ServerImpl:         serverImpl,
 		ResponseHandlerMap: make(map[string]*RpcResponseHandler),
+		outputLock:         &sync.Mutex{},
+		outputDoneCh:       make(chan struct{}),
 	}
 	rtn.RpcContext.Store(&rpcCtx)
 	go rtn.runServer()
 	return rtn
 }
 
+// replaces the timeout of every request sent by this client (e.g. for wsh --timeout), 0 removes the override.  use
+// MaxTimeoutMs for no timeout.
+func (w *WshRpc) SetTimeoutOverride(timeoutMs int) {
+	w.timeoutOverrideMs.Store(int64(min(max(timeoutMs, 0), MaxTimeoutMs)))
+}
+
 func (w *WshRpc) ClientId() string {
 	return w.clientId
 }
@@ -259,8 +301,93 @@ func (w *WshRpc) cancelRequest(reqId string) {
 	handler := w.ResponseHandlerMap[reqId]
 	if handler != nil {
 		handler.canceled.Store(true)
+		// the handler's context is canceled so it can stop work that the requestor is no longer waiting for
+		handler.cancelContext()
+	}
+}
+
+func (w *WshRpc) handleStreamAck(reqId string, numAcked int) {
+	w.Lock.Lock()
+	handler := w.ResponseHandlerMap[reqId]
+	w.Lock.Unlock()
+	if handler == nil {
+		return
+	}
+	if int64(numAcked) > handler.streamAcked.Load() {
+		handler.streamAcked.Store(int64(numAcked))
+	}
+	select {
+	case handler.streamAckCh <- struct{}{}:
+	default:
+	}
+}
+
+// every message goes out through here, so nothing is sent on OutputCh once runServer has closed it
+func (w *WshRpc) sendOutput(barr []byte) error {
+	w.outputLock.Lock()
+	defer w.outputLock.Unlock()
+	if w.outputClosed {
+		return errOutputClosed
+	}
+	select {
+	case w.OutputCh <- barr:
+		return nil
+	case <-w.outputDoneCh:
+		return errOutputClosed
+	}
+}
+
+func (w *WshRpc) closeOutput() {
+	close(w.outputDoneCh)
+	w.outputLock.Lock()
+	defer w.outputLock.Unlock()
+	w.outputClosed = true
+	close(w.OutputCh)
+}
+
+func (w *WshRpc) sendStreamAck(reqId string, numRecv int) {
+	msg := &RpcMessage{
+		ReqId:     reqId,
+		StreamAck: numRecv,
+		AuthToken: w.GetAuthToken(),
 	}
+	barr, _ := json.Marshal(msg) // will never fail
+	w.sendOutput(barr)
+}
+
+// sends a cancel for every request that is still waiting for a response and fails them locally
+// (used when the process is interrupted, so the other side doesn't keep working on them)
+func (w *WshRpc) CancelAllRequests() {
+	w.Lock.Lock()
+	var reqIds []string
+	for reqId := range w.RpcMap {
+		if reqId != "" {
+			reqIds = append(reqIds, reqId)
+		}
+	}
+	w.Lock.Unlock()
+	for _, reqId := range reqIds {
+		w.sendCancelMessage(reqId)
+		w.unregisterRpc(reqId, fmt.Errorf("request canceled"))
+	}
+}
 
+// waits (up to timeout) for the queued output messages to be picked up by the writer
+func (w *WshRpc) WaitForOutputFlush(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for len(w.OutputCh) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (w *WshRpc) sendCancelMessage(reqId string) {
+	msg := &RpcMessage{
+		Cancel:    true,
+		ReqId:     reqId,
+		AuthToken: w.GetAuthToken(),
+	}
+	barr, _ := json.Marshal(msg) // will never fail
+	w.sendOutput(barr)
 }
 
 func (w *WshRpc) handleRequest(req *RpcMessage) {
@@ -287,6 +414,9 @@ func (w *WshRpc) handleRequest(req *RpcMessage) {
 	}
 	ctx, cancelFn := context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
 	ctx = withWshRpcContext(ctx, w)
+	// so the work done for the request can be followed in the logs (wsh log tail --reqid)
+	ctx = wlog.ContextWithReqId(ctx, req.ReqId)
+	rpcLog.Debug(ctx, "request", "command", req.Command, "source", req.Source)
 	respHandler = &RpcResponseHandler{
 		w:               w,
 		ctx:             ctx,
@@ -298,6 +428,9 @@ func (w *WshRpc) handleRequest(req *RpcMessage) {
 		canceled:        &atomic.Bool{},
 		contextCancelFn: &atomic.Pointer[context.CancelFunc]{},
 		rpcCtx:          w.GetRpcContext(),
+		streamWindow:    req.StreamWindow,
+		streamAcked:     &atomic.Int64{},
+		streamAckCh:     make(chan struct{}, 1),
 	}
 	respHandler.contextCancelFn.Store(&cancelFn)
 	respHandler.ctx = withRespHandler(ctx, respHandler)
@@ -325,14 +458,28 @@ func (w *WshRpc) handleRequest(req *RpcMessage) {
 	isAsync = !handlerFn(respHandler)
 }
 
+// the data of these commands (e.g. a password sent back by wsh, a secret's value) is never written to the debug log.
+// no command returns a secret's value (only GetSecretNames), so no responses need to be redacted.
+var redactedDataCommands = map[string]bool{
+	wshrpc.Command_AuthPromptResponse: true,
+	wshrpc.Command_SetSecret:          true,
+}
+
+func getDebugMsgStr(msg RpcMessage, msgBytes []byte) string {
+	if !redactedDataCommands[msg.Command] {
+		return string(msgBytes)
+	}
+	return fmt.Sprintf("{command:%q reqid:%q data:<redacted>}", msg.Command, msg.ReqId)
+}
+
 func (w *WshRpc) runServer() {
-	defer close(w.OutputCh)
+	defer w.closeOutput()
 	for msgBytes := range w.InputCh {
-		if w.Debug {
-			log.Printf("[%s] received message: %s\n", w.DebugName, string(msgBytes))
-		}
 		var msg RpcMessage
 		err := json.Unmarshal(msgBytes, &msg)
+		if w.Debug {
+			log.Printf("[%s] received message: %s\n", w.DebugName, getDebugMsgStr(msg, msgBytes))
+		}
 		if err != nil {
 			log.Printf("wshrpc received bad message: %v\n", err)
 			continue
@@ -343,6 +490,10 @@ func (w *WshRpc) runServer() {
 			}
 			continue
 		}
+		if msg.StreamAck > 0 && msg.Command == "" {
+			w.handleStreamAck(msg.ReqId, msg.StreamAck)
+			continue
+		}
 		if msg.IsRpcRequest() {
 			go func() {
 				defer func() {
@@ -350,15 +501,15 @@ func (w *WshRpc) runServer() {
 				}()
 				w.handleRequest(&msg)
 			}()
-		} else {
+		} else if msg.Cont {
 			respCh := w.getResponseCh(msg.ResId)
 			if respCh == nil {
 				continue
 			}
 			respCh <- &msg
-			if !msg.Cont {
-				w.unregisterRpc(msg.ResId, nil)
-			}
+		} else {
+			// the rpc is unregistered with its last response, so a finalize after reading it doesn't send a cancel
+			w.unregisterRpcWithResp(msg.ResId, &msg)
 		}
 	}
 }
@@ -396,27 +547,41 @@ func (w *WshRpc) registerRpc(ctx context.Context, reqId string) chan *RpcMessage
 			panichandler.PanicHandler("registerRpc:timeout", recover())
 		}()
 		<-ctx.Done()
-		w.unregisterRpc(reqId, fmt.Errorf("EC-TIME: timeout waiting for response"))
+		stillWaiting := w.unregisterRpc(reqId, errors.New(timeoutErrorStr))
+		if stillWaiting && reqId != "" {
+			// timed out or canceled, the other side may still be working on the request
+			w.sendCancelMessage(reqId)
+		}
 	}()
 	return rpcCh
 }
 
-func (w *WshRpc) unregisterRpc(reqId string, err error) {
+// returns false if the rpc was already unregistered
+func (w *WshRpc) unregisterRpc(reqId string, err error) bool {
+	var errResp *RpcMessage
+	if err != nil {
+		errResp = &RpcMessage{
+			ResId: reqId,
+			Error: err.Error(),
+		}
+	}
+	return w.unregisterRpcWithResp(reqId, errResp)
+}
+
+// resp (if set) is the last message sent to the response channel before it is closed
+func (w *WshRpc) unregisterRpcWithResp(reqId string, resp *RpcMessage) bool {
 	w.Lock.Lock()
 	defer w.Lock.Unlock()
 	rd := w.RpcMap[reqId]
 	if rd == nil {
-		return
+		return false
 	}
-	if err != nil {
-		errResp := &RpcMessage{
-			ResId: reqId,
-			Error: err.Error(),
-		}
-		rd.ResCh <- errResp
+	if resp != nil {
+		rd.ResCh <- resp
 	}
 	delete(w.RpcMap, reqId)
 	close(rd.ResCh)
+	return true
 }
 
 // no response
@@ -455,25 +620,32 @@ type RpcRequestHandler struct {
 	ctx         context.Context
 	ctxCancelFn *atomic.Pointer[context.CancelFunc]
 	reqId       string
+	timeoutMs   int
+	route       string
 	respCh      chan *RpcMessage
 	cachedResp  *RpcMessage
 }
 
+func (handler *RpcRequestHandler) makeRespError(resp *RpcMessage) error {
+	if resp.Error == timeoutErrorStr {
+		return &RpcTimeoutError{Timeout: time.Duration(handler.timeoutMs) * time.Millisecond, Route: handler.route}
+	}
+	return makeRespError(resp)
+}
+
+// the error of an error response (a wshrpc.CodedError if it has an error code)
+func makeRespError(resp *RpcMessage) error {
+	if resp.ErrorCode != "" {
+		return &wshrpc.CodedError{Code: resp.ErrorCode, Err: errors.New(resp.Error), Data: resp.ErrorData}
+	}
+	return errors.New(resp.Error)
+}
+
 func (handler *RpcRequestHandler) Context() context.Context {
 	return handler.ctx
 }
 
 func (handler *RpcRequestHandler) SendCancel() {
-	defer func() {
-		panichandler.PanicHandler("SendCancel", recover())
-	}()
-	msg := &RpcMessage{
-		Cancel:    true,
-		ReqId:     handler.reqId,
-		AuthToken: handler.w.GetAuthToken(),
-	}
-	barr, _ := json.Marshal(msg) // will never fail
-	handler.w.OutputCh <- barr
 	handler.finalize()
 }
 
@@ -505,19 +677,50 @@ func (handler *RpcRequestHandler) NextResponse() (any, error) {
 		return nil, errors.New("response channel closed")
 	}
 	if resp.Error != "" {
-		return nil, errors.New(resp.Error)
+		return nil, handler.makeRespError(resp)
 	}
 	return resp.Data, nil
 }
 
+// like NextResponse, but also returns whether more responses will follow (the final message of a response stream
+// has no data)
+func (handler *RpcRequestHandler) NextResponseWithCont() (any, bool, error) {
+	var resp *RpcMessage
+	if handler.cachedResp != nil {
+		resp = handler.cachedResp
+		handler.cachedResp = nil
+	} else {
+		resp = <-handler.respCh
+	}
+	if resp == nil {
+		return nil, false, errors.New("response channel closed")
+	}
+	if resp.Error != "" {
+		return nil, false, handler.makeRespError(resp)
+	}
+	return resp.Data, resp.Cont, nil
+}
+
+// releases the request (cancels its context and unregisters it), for callers that read the responses with
+// NextResponseWithCont
+func (handler *RpcRequestHandler) Finalize() {
+	handler.finalize()
+}
+
+// whoever unregisters the rpc first (this or the timeout in registerRpc) sends the cancel, so it is sent once
 func (handler *RpcRequestHandler) finalize() {
+	stillWaiting := false
+	if handler.reqId != "" {
+		stillWaiting = handler.w.unregisterRpc(handler.reqId, nil)
+	}
 	cancelFnPtr := handler.ctxCancelFn.Load()
 	if cancelFnPtr != nil && *cancelFnPtr != nil {
 		(*cancelFnPtr)()
 		handler.ctxCancelFn.Store(nil)
 	}
-	if handler.reqId != "" {
-		handler.w.unregisterRpc(handler.reqId, nil)
+	if stillWaiting {
+		// the caller stopped reading before the last response, the other side may still be working on the request
+		handler.w.sendCancelMessage(handler.reqId)
 	}
 }
 
@@ -532,6 +735,10 @@ type RpcResponseHandler struct {
 	rpcCtx          wshrpc.RpcContext
 	canceled        *atomic.Bool // canceled by requestor
 	done            *atomic.Bool
+	streamWindow    int // if set, at most this many data frames are sent ahead of the requestor's acks
+	streamSent      int
+	streamAcked     *atomic.Int64
+	streamAckCh     chan struct{}
 }
 
 func (handler *RpcResponseHandler) Context() context.Context {
@@ -567,7 +774,7 @@ func (handler *RpcResponseHandler) SendMessage(msg string) {
 		AuthToken: handler.w.GetAuthToken(),
 	}
 	msgBytes, _ := json.Marshal(rpcMsg) // will never fail
-	handler.w.OutputCh <- msgBytes
+	handler.w.sendOutput(msgBytes)
 }
 
 func (handler *RpcResponseHandler) SendResponse(data any, done bool) error {
@@ -589,12 +796,19 @@ func (handler *RpcResponseHandler) SendResponse(data any, done bool) error {
 		Cont:      !done,
 		AuthToken: handler.w.GetAuthToken(),
 	}
+	if !done && handler.streamWindow > 0 {
+		err := handler.waitForStreamWindow()
+		if err != nil {
+			return err
+		}
+		handler.streamSent++
+		msg.StreamWindow = handler.streamWindow
+	}
 	barr, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
-	handler.w.OutputCh <- barr
-	return nil
+	return handler.w.sendOutput(barr)
 }
 
 func (handler *RpcResponseHandler) SendResponseError(err error) {
@@ -608,22 +822,41 @@ func (handler *RpcResponseHandler) SendResponseError(err error) {
 	msg := &RpcMessage{
 		ResId:     handler.reqId,
 		Error:     err.Error(),
+		ErrorCode: wshrpc.GetErrorCode(err),
+		ErrorData: wshrpc.GetErrorData(err),
 		AuthToken: handler.w.GetAuthToken(),
 	}
 	barr, _ := json.Marshal(msg) // will never fail
-	handler.w.OutputCh <- barr
+	handler.w.sendOutput(barr)
+}
+
+// blocks until the requestor has acked enough data frames to send another one (data frames are sent one at a time,
+// so streamSent is only used from the sending goroutine)
+func (handler *RpcResponseHandler) waitForStreamWindow() error {
+	for int64(handler.streamSent)-handler.streamAcked.Load() >= int64(handler.streamWindow) {
+		select {
+		case <-handler.streamAckCh:
+		case <-handler.ctx.Done():
+			return fmt.Errorf("stream canceled: %w", handler.ctx.Err())
+		}
+	}
+	return nil
 }
 
 func (handler *RpcResponseHandler) IsCanceled() bool {
 	return handler.canceled.Load()
 }
 
-func (handler *RpcResponseHandler) close() {
+func (handler *RpcResponseHandler) cancelContext() {
 	cancelFn := handler.contextCancelFn.Load()
 	if cancelFn != nil && *cancelFn != nil {
 		(*cancelFn)()
 		handler.contextCancelFn.Store(nil)
 	}
+}
+
+func (handler *RpcResponseHandler) close() {
+	handler.cancelContext()
 	handler.done.Store(true)
 }
 
@@ -642,10 +875,18 @@ func (handler *RpcResponseHandler) IsDone() bool {
 }
 
 func (w *WshRpc) SendComplexRequest(command string, data any, opts *wshrpc.RpcOpts) (rtnHandler *RpcRequestHandler, rtnErr error) {
+	return w.sendComplexRequest(command, data, opts, 0)
+}
+
+// streamWindow is only set for response streams that ack their data frames (see SendRpcRequestStream)
+func (w *WshRpc) sendComplexRequest(command string, data any, opts *wshrpc.RpcOpts, streamWindow int) (rtnHandler *RpcRequestHandler, rtnErr error) {
 	if opts == nil {
 		opts = &wshrpc.RpcOpts{}
 	}
 	timeoutMs := opts.Timeout
+	if override := w.timeoutOverrideMs.Load(); override > 0 && !opts.NoResponse {
+		timeoutMs = int(override)
+	}
 	if timeoutMs <= 0 {
 		timeoutMs = DefaultTimeoutMs
 	}
@@ -658,6 +899,8 @@ func (w *WshRpc) SendComplexRequest(command string, data any, opts *wshrpc.RpcOp
 	handler := &RpcRequestHandler{
 		w:           w,
 		ctxCancelFn: &atomic.Pointer[context.CancelFunc]{},
+		timeoutMs:   timeoutMs,
+		route:       opts.Route,
 	}
 	var cancelFn context.CancelFunc
 	handler.ctx, cancelFn = context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
@@ -666,18 +909,23 @@ func (w *WshRpc) SendComplexRequest(command string, data any, opts *wshrpc.RpcOp
 		handler.reqId = uuid.New().String()
 	}
 	req := &RpcMessage{
-		Command:   command,
-		ReqId:     handler.reqId,
-		Data:      data,
-		Timeout:   timeoutMs,
-		Route:     opts.Route,
-		AuthToken: w.GetAuthToken(),
+		Command:      command,
+		ReqId:        handler.reqId,
+		Data:         data,
+		Timeout:      timeoutMs,
+		Route:        opts.Route,
+		AuthToken:    w.GetAuthToken(),
+		StreamWindow: streamWindow,
 	}
 	barr, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
 	handler.respCh = w.registerRpc(handler.ctx, handler.reqId)
-	w.OutputCh <- barr
+	err = w.sendOutput(barr)
+	if err != nil {
+		cancelFn()
+		return nil, err
+	}
 	return handler, nil
 }
@@ -0,0 +1,200 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// a handler that doesn't return until its context is done
+type slowTestServer struct {
+	started chan struct{}
+	exited  chan error
+	// for the stream test
+	numStream  int
+	numWritten atomic.Int64
+}
+
+func (*slowTestServer) WshServerImpl() {}
+
+func (s *slowTestServer) RemoteFileInfoCommand(ctx context.Context, path string) (*wshrpc.FileInfo, error) {
+	s.started <- struct{}{}
+	<-ctx.Done()
+	s.exited <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+// streams numStream ints (and fails after them with an invalidarg error if numStream is odd)
+func (s *slowTestServer) StreamTestCommand(ctx context.Context) chan wshrpc.RespOrErrorUnion[int] {
+	sw := MakeStreamWriter[int](ctx)
+	go func() {
+		for i := 0; i < s.numStream; i++ {
+			if err := sw.Write(i); err != nil {
+				sw.Close(err)
+				return
+			}
+			s.numWritten.Add(1)
+		}
+		if s.numStream%2 == 1 {
+			sw.Close(wshrpc.CodedErrorf(wshrpc.ErrorCode_InvalidArg, "odd stream"))
+			return
+		}
+		sw.Close(nil)
+	}()
+	return sw.Chan()
+}
+
+func makeTestRpcPair() (*WshRpc, *slowTestServer) {
+	server := &slowTestServer{started: make(chan struct{}, 1), exited: make(chan error, 1)}
+	client := MakeWshRpc(nil, nil, wshrpc.RpcContext{}, nil)
+	// the server reads what the client writes and the other way around
+	MakeWshRpc(client.OutputCh, client.InputCh, wshrpc.RpcContext{}, server)
+	return client, server
+}
+
+func waitForHandlerExit(t *testing.T, server *slowTestServer) {
+	select {
+	case err := <-server.exited:
+		if err != context.Canceled {
+			t.Errorf("expected the handler context to be canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler did not exit after the cancel")
+	}
+}
+
+func TestCancelAllRequests(t *testing.T) {
+	client, server := makeTestRpcPair()
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.SendRpcRequest(wshrpc.Command_RemoteFileInfo, "/slow", &wshrpc.RpcOpts{Timeout: 30000})
+		errCh <- err
+	}()
+	select {
+	case <-server.started:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler was not called")
+	}
+	client.CancelAllRequests()
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Errorf("expected an error for a canceled request")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("request did not return after the cancel")
+	}
+	waitForHandlerExit(t, server)
+}
+
+func TestFinalizeSendsCancel(t *testing.T) {
+	client, server := makeTestRpcPair()
+	handler, err := client.SendComplexRequest(wshrpc.Command_RemoteFileInfo, "/slow", &wshrpc.RpcOpts{Timeout: 30000})
+	if err != nil {
+		t.Fatalf("error sending request: %v", err)
+	}
+	select {
+	case <-server.started:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("handler was not called")
+	}
+	// the caller gives up before the response (its context is canceled, it doesn't time out)
+	handler.Finalize()
+	waitForHandlerExit(t, server)
+}
+
+func TestSendAfterOutputClosed(t *testing.T) {
+	inputCh := make(chan []byte)
+	rpc := MakeWshRpc(inputCh, nil, wshrpc.RpcContext{}, nil)
+	close(inputCh)
+	for range rpc.OutputCh {
+	}
+	// runServer has closed OutputCh, the sends fail instead of panicking
+	rpc.sendCancelMessage("req1")
+	rpc.sendStreamAck("req1", 1)
+	if _, err := rpc.SendComplexRequest(wshrpc.Command_RemoteFileInfo, "/", nil); err != errOutputClosed {
+		t.Errorf("expected %v, got %v", errOutputClosed, err)
+	}
+}
+
+func readTestStream(t *testing.T, frameCh <-chan RpcFrame) ([]int, error) {
+	var rtn []int
+	for frame := range frameCh {
+		if frame.Done {
+			return rtn, frame.Error
+		}
+		rtn = append(rtn, int(frame.Data.(float64)))
+	}
+	t.Fatalf("stream channel closed without a done frame")
+	return nil, nil
+}
+
+func TestSendRpcRequestStream(t *testing.T) {
+	client, server := makeTestRpcPair()
+	server.numStream = 3 * DefaultStreamWindow
+	frameCh, err := client.SendRpcRequestStream(wshrpc.Command_StreamTest, nil, &wshrpc.RpcOpts{Timeout: 5000})
+	if err != nil {
+		t.Fatalf("error sending stream request: %v", err)
+	}
+	// nothing is read, so the handler is held up by the flow control
+	time.Sleep(200 * time.Millisecond)
+	// a frame can be in flight in each of the channels between the writer and the client
+	if numWritten := server.numWritten.Load(); numWritten > DefaultStreamWindow+3 {
+		t.Errorf("expected at most %d frames to be written ahead of the acks, got %d", DefaultStreamWindow+3, numWritten)
+	}
+	vals, err := readTestStream(t, frameCh)
+	if err != nil {
+		t.Fatalf("unexpected stream error: %v", err)
+	}
+	if len(vals) != server.numStream {
+		t.Fatalf("expected %d frames, got %d", server.numStream, len(vals))
+	}
+	for i, val := range vals {
+		if val != i {
+			t.Fatalf("expected frame %d to be %d, got %d", i, i, val)
+		}
+	}
+
+	server.numStream = 5
+	frameCh, err = client.SendRpcRequestStream(wshrpc.Command_StreamTest, nil, nil)
+	if err != nil {
+		t.Fatalf("error sending stream request: %v", err)
+	}
+	vals, err = readTestStream(t, frameCh)
+	if err == nil || err.Error() != "odd stream" || len(vals) != 5 {
+		t.Errorf("expected 5 frames and the stream error, got %d frames, %v", len(vals), err)
+	}
+	if code := wshrpc.GetErrorCode(err); code != wshrpc.ErrorCode_InvalidArg {
+		t.Errorf("expected the stream error to have code %q, got %q", wshrpc.ErrorCode_InvalidArg, code)
+	}
+}
+
+func TestDebugMsgRedacted(t *testing.T) {
+	for _, msgStr := range []string{
+		`{"command":"authpromptresponse","reqid":"r1","data":{"promptid":"p1","text":"hunter2"}}`,
+		`{"command":"setsecret","reqid":"r1","data":{"name":"openai","value":"hunter2"}}`,
+	} {
+		msgBytes := []byte(msgStr)
+		var msg RpcMessage
+		if err := json.Unmarshal(msgBytes, &msg); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if str := getDebugMsgStr(msg, msgBytes); strings.Contains(str, "hunter2") {
+			t.Errorf("secret in debug message: %s", str)
+		}
+	}
+	var msg RpcMessage
+	msgBytes := []byte(`{"command":"connstatus","reqid":"r2"}`)
+	msg = RpcMessage{Command: wshrpc.Command_ConnStatus, ReqId: "r2"}
+	if str := getDebugMsgStr(msg, msgBytes); str != string(msgBytes) {
+		t.Errorf("expected the message unchanged, got %s", str)
+	}
+}
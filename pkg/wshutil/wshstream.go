@@ -0,0 +1,137 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wshutil
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/wshrpc"
+)
+
+// the client acks every StreamAckInterval data frames, the server sends at most DefaultStreamWindow frames ahead
+// of the acks (so a slow reader holds up the handler instead of the frames piling up in the channels)
+const StreamAckInterval = 16
+const DefaultStreamWindow = 4 * StreamAckInterval
+
+// a frame of a response stream.  the last frame has Done set, with the stream's error (if it failed).
+type RpcFrame struct {
+	Data  any
+	Error error
+	Done  bool
+}
+
+// sends a request for a response stream (with flow control).  the frames are returned on the channel, which is
+// closed after the Done frame.  opts.StreamCancelFn is set, call it to stop reading early (the request is canceled
+// on the server).  servers that don't support flow control still work, they just aren't sent acks.
+func (w *WshRpc) SendRpcRequestStream(command string, data any, opts *wshrpc.RpcOpts) (<-chan RpcFrame, error) {
+	if opts == nil {
+		opts = &wshrpc.RpcOpts{}
+	}
+	optsCopy := *opts
+	optsCopy.NoResponse = false
+	handler, err := w.sendComplexRequest(command, data, &optsCopy, DefaultStreamWindow)
+	if err != nil {
+		return nil, err
+	}
+	stopCh := make(chan struct{})
+	var stopOnce sync.Once
+	opts.StreamCancelFn = func() {
+		stopOnce.Do(func() {
+			close(stopCh)
+			handler.SendCancel()
+		})
+	}
+	frameCh := make(chan RpcFrame)
+	go func() {
+		defer func() {
+			panichandler.PanicHandler("SendRpcRequestStream", recover())
+		}()
+		defer close(frameCh)
+		defer handler.finalize()
+		sendFrame := func(frame RpcFrame) bool {
+			select {
+			case frameCh <- frame:
+				return true
+			case <-stopCh:
+				return false
+			}
+		}
+		numRecv := 0
+		for {
+			var resp *RpcMessage
+			select {
+			case resp = <-handler.respCh:
+			case <-stopCh:
+				return
+			}
+			if resp == nil {
+				sendFrame(RpcFrame{Error: errors.New("response channel closed"), Done: true})
+				return
+			}
+			if resp.Error != "" {
+				sendFrame(RpcFrame{Error: makeRespError(resp), Done: true})
+				return
+			}
+			if !resp.Cont {
+				sendFrame(RpcFrame{Data: resp.Data, Done: true})
+				return
+			}
+			if !sendFrame(RpcFrame{Data: resp.Data}) {
+				return
+			}
+			numRecv++
+			// acks are only sent if the server echoed the window (older servers would treat the ack as a request)
+			if resp.StreamWindow > 0 && numRecv%StreamAckInterval == 0 {
+				w.sendStreamAck(handler.reqId, numRecv)
+			}
+		}
+	}()
+	return frameCh, nil
+}
+
+// for response stream handlers: the handler returns Chan(), writes the data frames with Write, and ends the stream
+// with Close.  Write blocks while the client is behind (flow control) and fails once the request is canceled.
+type StreamWriter[T any] struct {
+	ctx       context.Context
+	ch        chan wshrpc.RespOrErrorUnion[T]
+	closeOnce sync.Once
+}
+
+func MakeStreamWriter[T any](ctx context.Context) *StreamWriter[T] {
+	return &StreamWriter[T]{ctx: ctx, ch: make(chan wshrpc.RespOrErrorUnion[T])}
+}
+
+func (sw *StreamWriter[T]) Chan() chan wshrpc.RespOrErrorUnion[T] {
+	return sw.ch
+}
+
+func (sw *StreamWriter[T]) Context() context.Context {
+	return sw.ctx
+}
+
+func (sw *StreamWriter[T]) Write(data T) error {
+	select {
+	case sw.ch <- wshrpc.RespOrErrorUnion[T]{Response: data}:
+		return nil
+	case <-sw.ctx.Done():
+		return sw.ctx.Err()
+	}
+}
+
+// ends the stream, a non-nil err is sent to the client as the stream's error
+func (sw *StreamWriter[T]) Close(err error) {
+	sw.closeOnce.Do(func() {
+		defer close(sw.ch)
+		if err == nil {
+			return
+		}
+		select {
+		case sw.ch <- wshrpc.RespOrErrorUnion[T]{Error: err}:
+		case <-sw.ctx.Done():
+		}
+	})
+}
@@ -263,19 +263,24 @@ func SetupConnRpcClient(conn net.Conn, serverImpl ServerImpl) (*WshRpc, chan err
 	return rtn, writeErrCh, nil
 }
 
-func tryTcpSocket(sockName string) (net.Conn, error) {
+func tryTcpSocket(sockName string, timeout time.Duration) (net.Conn, error) {
 	addr, err := net.ResolveTCPAddr("tcp", sockName)
 	if err != nil {
 		return nil, err
 	}
-	return net.DialTCP("tcp", nil, addr)
+	return net.DialTimeout("tcp", addr.String(), timeout)
 }
 
 func SetupDomainSocketRpcClient(sockName string, serverImpl ServerImpl) (*WshRpc, error) {
-	conn, tcpErr := tryTcpSocket(sockName)
+	return SetupDomainSocketRpcClientWithTimeout(sockName, serverImpl, 0)
+}
+
+// like SetupDomainSocketRpcClient, but fails if the socket doesn't connect within timeout (0 is no timeout)
+func SetupDomainSocketRpcClientWithTimeout(sockName string, serverImpl ServerImpl, timeout time.Duration) (*WshRpc, error) {
+	conn, tcpErr := tryTcpSocket(sockName, timeout)
 	var unixErr error
 	if tcpErr != nil {
-		conn, unixErr = net.Dial("unix", sockName)
+		conn, unixErr = net.DialTimeout("unix", sockName, timeout)
 	}
 	if tcpErr != nil && unixErr != nil {
 		return nil, fmt.Errorf("failed to connect to tcp or unix domain socket: tcp err:%w: unix socket err: %w", tcpErr, unixErr)
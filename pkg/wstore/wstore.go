@@ -9,8 +9,11 @@ import (
 
 	"github.com/wavetermdev/waveterm/pkg/util/utilfn"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
+	"github.com/wavetermdev/waveterm/pkg/wlog"
 )
 
+var logger = wlog.New("wstore")
+
 func init() {
 	for _, rtype := range waveobj.AllWaveObjTypes() {
 		waveobj.RegisterType(rtype)
@@ -51,6 +54,40 @@ func UpdateObjectMeta(ctx context.Context, oref waveobj.ORef, meta waveobj.MetaM
 	})
 }
 
+// merges the patch into the block's meta (nil values delete keys), but only if the block is still at
+// expectedVersion (otherwise it returns ErrVersionMismatch). returns the keys that changed (removed keys have
+// nil values) and the block's new version. the version is not bumped if nothing changed.
+func UpdateBlockMeta(ctx context.Context, blockId string, patch waveobj.MetaMapType, expectedVersion int) (waveobj.MetaMapType, int, error) {
+	var changes waveobj.MetaMapType
+	var newVersion int
+	err := WithTx(ctx, func(tx *TxWrap) error {
+		block, _ := DBGet[*waveobj.Block](tx.Context(), blockId)
+		if block == nil {
+			return ErrNotFound
+		}
+		if block.Version != expectedVersion {
+			return fmt.Errorf("%w: block is at version %d, expected %d", ErrVersionMismatch, block.Version, expectedVersion)
+		}
+		newMeta := waveobj.MergeMeta(block.Meta, patch, false)
+		changes = waveobj.GetMetaChanges(block.Meta, newMeta)
+		newVersion = block.Version
+		if len(changes) == 0 {
+			return nil
+		}
+		block.Meta = newMeta
+		err := DBUpdate(tx.Context(), block)
+		if err != nil {
+			return err
+		}
+		newVersion = block.Version
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return changes, newVersion, nil
+}
+
 func MoveBlockToTab(ctx context.Context, currentTabId string, newTabId string, blockId string) error {
 	return WithTx(ctx, func(tx *TxWrap) error {
 		block, _ := DBGet[*waveobj.Block](tx.Context(), blockId)
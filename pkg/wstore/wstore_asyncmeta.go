@@ -0,0 +1,221 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wavetermdev/waveterm/pkg/panichandler"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// coalesced meta writes for objects that change often (cwd tracking, controller state, etc.).  UpdateObjectMetaAsync
+// merges the patch into a pending patch for the object (in memory) and the pending patches are written together,
+// in one transaction, at most once per AsyncMetaFlushInterval.  reads (DBGet, DBGetORef, DBSelectMap, etc.) see the
+// pending patches, so the in-memory state is what everyone reads.  a DBUpdate of the object writes the pending patch
+// (the object it is given was read with the patch) and a DBDelete drops it, once their transaction commits.  a flush
+// skips objects that were written (at a newer version) after it took the pending patches.  queries that filter in
+// sqlite (QueryBlocks) flush first.  call FlushAsyncMeta before shutting down.
+
+const DefaultAsyncMetaFlushInterval = 250 * time.Millisecond
+
+// can be changed before the first async update (for tests and benchmarks)
+var AsyncMetaFlushInterval = DefaultAsyncMetaFlushInterval
+
+type pendingMeta struct {
+	OType   string
+	Patch   waveobj.MetaMapType // nil values delete keys
+	Version int                 // the version the object was given in the last update event
+}
+
+var asyncMetaLock = &sync.Mutex{}
+var asyncMetaPending = make(map[string]*pendingMeta) // oid => pending patch
+var asyncMetaTimer *time.Timer
+var asyncMetaFlushLock = &sync.Mutex{} // one flush at a time
+
+// incremented by the object writes that replace a pending patch (DBUpdate, DBDelete), an async update that raced
+// with one reads the object again
+var objWriteGen atomic.Uint64
+
+// object writes (DBUpdate, and each object written by a flush), for the benchmarks
+var objWriteCount atomic.Int64
+
+func getPendingMeta(oid string) *pendingMeta {
+	asyncMetaLock.Lock()
+	defer asyncMetaLock.Unlock()
+	return asyncMetaPending[oid]
+}
+
+func hasPendingMeta(oid string) bool {
+	return getPendingMeta(oid) != nil
+}
+
+// called when the object is deleted (after the commit)
+func clearPendingMeta(oid string) {
+	asyncMetaLock.Lock()
+	defer asyncMetaLock.Unlock()
+	objWriteGen.Add(1)
+	delete(asyncMetaPending, oid)
+}
+
+// called when the object is written (after the commit), readVersion is the version of the object that was written.
+// the pending patch is kept if there were async updates after the object was read (the patch is newer).
+func clearPendingMetaVersion(oid string, readVersion int) {
+	asyncMetaLock.Lock()
+	defer asyncMetaLock.Unlock()
+	objWriteGen.Add(1)
+	if pending := asyncMetaPending[oid]; pending != nil && pending.Version <= readVersion {
+		delete(asyncMetaPending, oid)
+	}
+}
+
+// applies the object's pending patch (if there is one) to an object read from the db
+func overlayPendingMeta(obj waveobj.WaveObj) waveobj.WaveObj {
+	if obj == nil {
+		return nil
+	}
+	asyncMetaLock.Lock()
+	pending := asyncMetaPending[waveobj.GetOID(obj)]
+	var patch waveobj.MetaMapType
+	var version int
+	if pending != nil && pending.OType == obj.GetOType() {
+		patch = copyMetaPatch(pending.Patch)
+		version = pending.Version
+	}
+	asyncMetaLock.Unlock()
+	if patch == nil {
+		return obj
+	}
+	waveobj.SetMeta(obj, waveobj.MergeMeta(waveobj.GetMeta(obj), patch, false))
+	waveobj.SetVersion(obj, max(version, waveobj.GetVersion(obj)))
+	return obj
+}
+
+func copyMetaPatch(patch waveobj.MetaMapType) waveobj.MetaMapType {
+	rtn := make(waveobj.MetaMapType, len(patch))
+	for key, val := range patch {
+		rtn[key] = val
+	}
+	return rtn
+}
+
+// like UpdateObjectMeta (without mergeSpecial), but the write is coalesced with the object's other async updates and
+// written later (see AsyncMetaFlushInterval).  the update for the object (with its new meta and version) is added to
+// ctx right away, so the caller can send it with SendUpdateEvents as usual.
+func UpdateObjectMetaAsync(ctx context.Context, oref waveobj.ORef, patch waveobj.MetaMapType) error {
+	if oref.IsEmpty() {
+		return fmt.Errorf("empty object reference")
+	}
+	var obj waveobj.WaveObj
+	for {
+		writeGen := objWriteGen.Load()
+		var err error
+		obj, err = DBGetORef(ctx, oref)
+		if err != nil {
+			return err
+		}
+		if obj == nil {
+			return ErrNotFound
+		}
+		asyncMetaLock.Lock()
+		if objWriteGen.Load() == writeGen {
+			break
+		}
+		asyncMetaLock.Unlock()
+	}
+	pending := asyncMetaPending[oref.OID]
+	if pending == nil {
+		pending = &pendingMeta{OType: oref.OType, Patch: make(waveobj.MetaMapType)}
+		asyncMetaPending[oref.OID] = pending
+	}
+	for key, val := range patch {
+		pending.Patch[key] = val
+	}
+	pending.Version = max(pending.Version, waveobj.GetVersion(obj)) + 1
+	newVersion := pending.Version
+	if asyncMetaTimer == nil {
+		asyncMetaTimer = time.AfterFunc(AsyncMetaFlushInterval, asyncMetaTimerFlush)
+	}
+	asyncMetaLock.Unlock()
+	cacheInvalidate(oref.OType, oref.OID)
+	waveobj.SetMeta(obj, waveobj.MergeMeta(waveobj.GetMeta(obj), patch, false))
+	waveobj.SetVersion(obj, newVersion)
+	waveobj.ContextAddUpdate(ctx, waveobj.WaveObjUpdate{UpdateType: waveobj.UpdateType_Update, OType: oref.OType, OID: oref.OID, Obj: obj})
+	return nil
+}
+
+func asyncMetaTimerFlush() {
+	defer func() {
+		panichandler.PanicHandler("wstore:asyncMetaTimerFlush", recover())
+	}()
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	err := FlushAsyncMeta(ctx)
+	if err != nil {
+		logger.Error(ctx, "error flushing async meta updates", "err", err)
+	}
+}
+
+// writes the pending async meta updates (in one transaction)
+func FlushAsyncMeta(ctx context.Context) error {
+	asyncMetaFlushLock.Lock()
+	defer asyncMetaFlushLock.Unlock()
+	asyncMetaLock.Lock()
+	if asyncMetaTimer != nil {
+		asyncMetaTimer.Stop()
+		asyncMetaTimer = nil
+	}
+	toFlush := make(map[string]pendingMeta, len(asyncMetaPending))
+	for oid, pending := range asyncMetaPending {
+		toFlush[oid] = pendingMeta{OType: pending.OType, Patch: copyMetaPatch(pending.Patch), Version: pending.Version}
+	}
+	asyncMetaLock.Unlock()
+	if len(toFlush) == 0 {
+		return nil
+	}
+	err := WithTx(ctx, func(tx *TxWrap) error {
+		for oid, pending := range toFlush {
+			table := tableNameFromOType(pending.OType)
+			var row idDataType
+			if !tx.Get(&row, fmt.Sprintf("SELECT oid, version, data FROM %s WHERE oid = ?", table), oid) {
+				// deleted
+				continue
+			}
+			if row.Version > pending.Version {
+				// written by a DBUpdate (that was read with the patch) after the pending patches were taken
+				continue
+			}
+			obj, err := waveobj.FromJson(row.Data)
+			if err != nil {
+				return err
+			}
+			waveobj.SetMeta(obj, waveobj.MergeMeta(waveobj.GetMeta(obj), pending.Patch, false))
+			jsonData, err := waveobj.ToJson(obj)
+			if err != nil {
+				return err
+			}
+			query := fmt.Sprintf("UPDATE %s SET data = ?, version = MAX(version, ?) WHERE oid = ?", table)
+			tx.Exec(query, jsonData, pending.Version, oid)
+			objWriteCount.Add(1)
+			cacheInvalidateTx(tx, pending.OType, oid)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	asyncMetaLock.Lock()
+	defer asyncMetaLock.Unlock()
+	for oid, flushed := range toFlush {
+		// updates that were made during the flush stay pending (they are written by the next flush)
+		if pending := asyncMetaPending[oid]; pending != nil && pending.Version == flushed.Version {
+			delete(asyncMetaPending, oid)
+		}
+	}
+	return nil
+}
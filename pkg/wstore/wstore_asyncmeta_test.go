@@ -0,0 +1,207 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func initAsyncMetaTest(tb testing.TB, flushInterval time.Duration) {
+	initTestDb(tb)
+	AsyncMetaFlushInterval = flushInterval
+	tb.Cleanup(func() {
+		FlushAsyncMeta(context.Background())
+		AsyncMetaFlushInterval = DefaultAsyncMetaFlushInterval
+	})
+}
+
+// reads the block without the pending meta
+func getDBBlockRaw(tb testing.TB, ctx context.Context, blockId string) *waveobj.Block {
+	var row idDataType
+	err := WithTx(ctx, func(tx *TxWrap) error {
+		tx.Get(&row, "SELECT oid, version, data FROM db_block WHERE oid = ?", blockId)
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("error reading block: %v", err)
+	}
+	obj, err := waveobj.FromJson(row.Data)
+	if err != nil {
+		tb.Fatalf("error decoding block: %v", err)
+	}
+	waveobj.SetVersion(obj, row.Version)
+	return obj.(*waveobj.Block)
+}
+
+func insertAsyncMetaTestBlock(tb testing.TB, ctx context.Context) string {
+	block := &waveobj.Block{OID: uuid.NewString(), Meta: waveobj.MetaMapType{waveobj.MetaKey_View: "term"}}
+	if err := DBInsert(ctx, block); err != nil {
+		tb.Fatalf("error inserting block: %v", err)
+	}
+	return block.OID
+}
+
+func TestUpdateObjectMetaAsync(t *testing.T) {
+	initAsyncMetaTest(t, time.Hour)
+	ctx := context.Background()
+	blockId := insertAsyncMetaTestBlock(t, ctx)
+	oref := waveobj.MakeORef(waveobj.OType_Block, blockId)
+
+	var lastUpdate waveobj.WaveObjUpdate
+	for _, cwd := range []string{"/a", "/b", "/c"} {
+		updateCtx := waveobj.ContextWithUpdates(ctx)
+		if err := UpdateObjectMetaAsync(updateCtx, oref, waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: cwd}); err != nil {
+			t.Fatalf("error updating meta: %v", err)
+		}
+		updates := waveobj.ContextGetUpdatesRtn(updateCtx)
+		if len(updates) != 1 {
+			t.Fatalf("expected one update, got %d", len(updates))
+		}
+		lastUpdate = updates[0]
+	}
+	if lastUpdate.Obj.(*waveobj.Block).Meta.GetString(waveobj.MetaKey_CmdCwd, "") != "/c" || waveobj.GetVersion(lastUpdate.Obj) != 4 {
+		t.Errorf("expected the update to have the new cwd at version 4, got %v", lastUpdate.Obj)
+	}
+	block, _ := DBGet[*waveobj.Block](ctx, blockId)
+	if block.Meta.GetString(waveobj.MetaKey_CmdCwd, "") != "/c" || block.Version != 4 {
+		t.Errorf("expected reads to see the pending meta, got %v (version %d)", block.Meta, block.Version)
+	}
+	if raw := getDBBlockRaw(t, ctx, blockId); raw.Meta.GetString(waveobj.MetaKey_CmdCwd, "") != "" {
+		t.Errorf("expected the meta not to be written before the flush")
+	}
+
+	if err := FlushAsyncMeta(ctx); err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	raw := getDBBlockRaw(t, ctx, blockId)
+	if raw.Meta.GetString(waveobj.MetaKey_CmdCwd, "") != "/c" || raw.Version != 4 || raw.Meta.GetString(waveobj.MetaKey_View, "") != "term" {
+		t.Errorf("expected the flush to write the meta at version 4, got %v (version %d)", raw.Meta, raw.Version)
+	}
+
+	// a write of the object includes (and replaces) the pending meta
+	UpdateObjectMetaAsync(ctx, oref, waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: "/d"})
+	if err := UpdateObjectMeta(ctx, oref, waveobj.MetaMapType{waveobj.MetaKey_Frame: true}, false); err != nil {
+		t.Fatalf("error updating meta: %v", err)
+	}
+	if hasPendingMeta(blockId) {
+		t.Errorf("expected the pending meta to be written by UpdateObjectMeta")
+	}
+	raw = getDBBlockRaw(t, ctx, blockId)
+	if raw.Meta.GetString(waveobj.MetaKey_CmdCwd, "") != "/d" || !raw.Meta.GetBool(waveobj.MetaKey_Frame, false) || raw.Version != 6 {
+		t.Errorf("expected both updates at version 6, got %v (version %d)", raw.Meta, raw.Version)
+	}
+
+	UpdateObjectMetaAsync(ctx, oref, waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: "/e"})
+	if err := DBDelete(ctx, waveobj.OType_Block, blockId); err != nil {
+		t.Fatalf("error deleting block: %v", err)
+	}
+	if hasPendingMeta(blockId) {
+		t.Errorf("expected the pending meta to be dropped when the block is deleted")
+	}
+}
+
+func TestAsyncMetaConcurrentWrites(t *testing.T) {
+	initAsyncMetaTest(t, time.Hour)
+	ctx := context.Background()
+	blockId := insertAsyncMetaTestBlock(t, ctx)
+	oref := waveobj.MakeORef(waveobj.OType_Block, blockId)
+
+	// a rolled back write keeps the pending meta
+	UpdateObjectMetaAsync(ctx, oref, waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: "/a"})
+	err := WithTx(ctx, func(tx *TxWrap) error {
+		block, _ := DBGet[*waveobj.Block](tx.Context(), blockId)
+		if err := DBUpdate(tx.Context(), block); err != nil {
+			return err
+		}
+		return fmt.Errorf("rollback")
+	})
+	if err == nil || !hasPendingMeta(blockId) {
+		t.Fatalf("expected the pending meta to be kept after a rollback (err %v)", err)
+	}
+
+	// an async update after the object was read is not replaced by the write
+	block, _ := DBGet[*waveobj.Block](ctx, blockId)
+	UpdateObjectMetaAsync(ctx, oref, waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: "/b"})
+	block.Meta[waveobj.MetaKey_Frame] = true
+	if err := DBUpdate(ctx, block); err != nil {
+		t.Fatalf("error updating block: %v", err)
+	}
+	block, _ = DBGet[*waveobj.Block](ctx, blockId)
+	if block.Meta.GetString(waveobj.MetaKey_CmdCwd, "") != "/b" || !block.Meta.GetBool(waveobj.MetaKey_Frame, false) {
+		t.Errorf("expected both updates, got %v", block.Meta)
+	}
+
+	// a write that commits after a flush took the pending meta (but before it writes) is not overwritten
+	if err := FlushAsyncMeta(ctx); err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	UpdateObjectMetaAsync(ctx, oref, waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: "/c"})
+	pendingVersion := getPendingMeta(blockId).Version
+	newBlock := getDBBlockRaw(t, ctx, blockId)
+	newBlock.Meta[waveobj.MetaKey_CmdCwd] = "/d"
+	jsonData, _ := waveobj.ToJson(newBlock)
+	err = WithTx(ctx, func(tx *TxWrap) error {
+		tx.Exec("UPDATE db_block SET data = ?, version = ? WHERE oid = ?", jsonData, pendingVersion+1, blockId)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error writing block: %v", err)
+	}
+	if err := FlushAsyncMeta(ctx); err != nil {
+		t.Fatalf("error flushing: %v", err)
+	}
+	if raw := getDBBlockRaw(t, ctx, blockId); raw.Meta.GetString(waveobj.MetaKey_CmdCwd, "") != "/d" {
+		t.Errorf("expected the newer write to be kept, got %v (version %d)", raw.Meta, raw.Version)
+	}
+}
+
+func TestAsyncMetaTimerFlush(t *testing.T) {
+	initAsyncMetaTest(t, 20*time.Millisecond)
+	ctx := context.Background()
+	blockId := insertAsyncMetaTestBlock(t, ctx)
+	UpdateObjectMetaAsync(ctx, waveobj.MakeORef(waveobj.OType_Block, blockId), waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: "/a"})
+	for start := time.Now(); time.Since(start) < 2*time.Second; time.Sleep(10 * time.Millisecond) {
+		if getDBBlockRaw(t, ctx, blockId).Meta.GetString(waveobj.MetaKey_CmdCwd, "") == "/a" {
+			return
+		}
+	}
+	t.Fatalf("expected the pending meta to be flushed")
+}
+
+// a block that gets 100 meta updates per second (e.g. a busy terminal tracking its running command), reports the
+// db writes per update
+func BenchmarkBlockMetaUpdates(b *testing.B) {
+	for _, async := range []bool{false, true} {
+		b.Run(fmt.Sprintf("async=%v", async), func(b *testing.B) {
+			initAsyncMetaTest(b, DefaultAsyncMetaFlushInterval)
+			ctx := context.Background()
+			oref := waveobj.MakeORef(waveobj.OType_Block, insertAsyncMetaTestBlock(b, ctx))
+			ticker := time.NewTicker(10 * time.Millisecond)
+			defer ticker.Stop()
+			startWriteCount := objWriteCount.Load()
+			b.ResetTimer()
+			for idx := 0; idx < b.N; idx++ {
+				<-ticker.C
+				patch := waveobj.MetaMapType{waveobj.MetaKey_CmdCwd: fmt.Sprintf("/dir/%d", idx)}
+				var err error
+				if async {
+					err = UpdateObjectMetaAsync(ctx, oref, patch)
+				} else {
+					err = UpdateObjectMeta(ctx, oref, patch, false)
+				}
+				if err != nil {
+					b.Fatalf("error updating meta: %v", err)
+				}
+			}
+			FlushAsyncMeta(ctx)
+			b.ReportMetric(float64(objWriteCount.Load()-startWriteCount)/float64(b.N), "dbwrites/op")
+		})
+	}
+}
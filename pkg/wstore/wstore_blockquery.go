@@ -0,0 +1,130 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+const localConnName = "local"
+
+// filters the blocks by their meta (see QueryBlocks), empty fields don't filter.  only the blocks in tabs are
+// matched (not sub blocks or blocks in the trash).
+type BlockFilter struct {
+	View         string // the block's view
+	UrlPrefix    string
+	UrlContains  string
+	FilePrefix   string
+	FileContains string
+	Connection   string // the block's connection ("local" also matches the blocks without one)
+	Pinned       bool   // only pinned blocks
+	TabId        string // only the blocks in this tab
+	WindowId     string // only the blocks in the tabs of this window's workspace
+	CreatedAfter int64  // only the blocks created after this (unix ms)
+	Limit        int
+}
+
+// the view expression must match idx_block_view (see migration 10)
+const blockViewExpr = `json_extract(data, '$.meta.view')`
+
+func (filter BlockFilter) makeQuery() (string, []any) {
+	conds := []string{
+		`json_extract(data, '$.deletedts') IS NULL`,
+		`json_extract(data, '$.parentoref') LIKE 'tab:%'`,
+	}
+	var args []any
+	if filter.View != "" {
+		conds = append(conds, blockViewExpr+` = ?`)
+		args = append(args, filter.View)
+	}
+	// instr is case sensitive (unlike LIKE) and doesn't need the value to be escaped
+	if filter.UrlPrefix != "" {
+		conds = append(conds, `instr(json_extract(data, '$.meta.url'), ?) = 1`)
+		args = append(args, filter.UrlPrefix)
+	}
+	if filter.UrlContains != "" {
+		conds = append(conds, `instr(json_extract(data, '$.meta.url'), ?) > 0`)
+		args = append(args, filter.UrlContains)
+	}
+	if filter.FilePrefix != "" {
+		conds = append(conds, `instr(json_extract(data, '$.meta.file'), ?) = 1`)
+		args = append(args, filter.FilePrefix)
+	}
+	if filter.FileContains != "" {
+		conds = append(conds, `instr(json_extract(data, '$.meta.file'), ?) > 0`)
+		args = append(args, filter.FileContains)
+	}
+	if filter.Connection == localConnName {
+		conds = append(conds, `COALESCE(json_extract(data, '$.meta.connection'), '') IN ('', ?)`)
+		args = append(args, localConnName)
+	} else if filter.Connection != "" {
+		conds = append(conds, `json_extract(data, '$.meta.connection') = ?`)
+		args = append(args, filter.Connection)
+	}
+	if filter.Pinned {
+		conds = append(conds, `json_extract(data, '$.meta.pinned') = 1`)
+	}
+	if filter.TabId != "" {
+		conds = append(conds, `json_extract(data, '$.parentoref') = ?`)
+		args = append(args, waveobj.MakeORef(waveobj.OType_Tab, filter.TabId).String())
+	}
+	if filter.WindowId != "" {
+		conds = append(conds, `json_extract(data, '$.parentoref') IN (
+			SELECT 'tab:' || je.value
+			FROM db_window w
+			JOIN db_workspace ws ON ws.oid = json_extract(w.data, '$.workspaceid'),
+			json_each(ws.data, '$.tabids') AS je
+			WHERE w.oid = ?
+			UNION ALL
+			SELECT 'tab:' || je.value
+			FROM db_window w
+			JOIN db_workspace ws ON ws.oid = json_extract(w.data, '$.workspaceid'),
+			json_each(ws.data, '$.pinnedtabids') AS je
+			WHERE w.oid = ?
+		)`)
+		args = append(args, filter.WindowId, filter.WindowId)
+	}
+	if filter.CreatedAfter > 0 {
+		conds = append(conds, `json_extract(data, '$.createdts') > ?`)
+		args = append(args, filter.CreatedAfter)
+	}
+	query := `SELECT oid, version, data FROM db_block WHERE ` + strings.Join(conds, " AND ") +
+		` ORDER BY COALESCE(json_extract(data, '$.createdts'), 0), oid`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+	return query, args
+}
+
+// returns the blocks that match the filter (oldest first).  the filter runs in sqlite, so the blocks that don't
+// match are never loaded (the pending async meta updates are flushed first).
+func QueryBlocks(ctx context.Context, filter BlockFilter) ([]*waveobj.Block, error) {
+	err := FlushAsyncMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query, args := filter.makeQuery()
+	return WithTxRtn(ctx, func(tx *TxWrap) ([]*waveobj.Block, error) {
+		var rows []idDataType
+		tx.Select(&rows, query, args...)
+		rtn := make([]*waveobj.Block, 0, len(rows))
+		for _, row := range rows {
+			waveObj, err := waveobj.FromJson(row.Data)
+			if err != nil {
+				return nil, err
+			}
+			block, ok := waveObj.(*waveobj.Block)
+			if !ok {
+				continue
+			}
+			waveobj.SetVersion(block, row.Version)
+			rtn = append(rtn, block)
+		}
+		return rtn, nil
+	})
+}
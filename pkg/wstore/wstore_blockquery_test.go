@@ -0,0 +1,190 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func initTestDb(tb testing.TB) {
+	useTestingDb = true
+	err := InitWStore()
+	if err != nil {
+		tb.Fatalf("error initializing wstore: %v", err)
+	}
+	tb.Cleanup(func() {
+		globalDB.Close()
+		globalDB = nil
+		useTestingDb = false
+		InvalidateCache()
+	})
+}
+
+var testQueryViews = []string{"term", "preview", "web", "sysinfo"}
+
+// numBlocks blocks spread over 10 tabs (in one window's workspace), one in ten is pinned and one in a hundred is
+// in the trash
+func insertQueryTestBlocks(tb testing.TB, ctx context.Context, numBlocks int) []string {
+	tabIds := make([]string, 10)
+	for idx := range tabIds {
+		tabIds[idx] = uuid.NewString()
+	}
+	workspace := &waveobj.Workspace{OID: uuid.NewString(), TabIds: tabIds[1:], PinnedTabIds: tabIds[:1]}
+	window := &waveobj.Window{OID: uuid.NewString(), WorkspaceId: workspace.OID}
+	err := WithTx(ctx, func(tx *TxWrap) error {
+		for _, obj := range []waveobj.WaveObj{workspace, window} {
+			if err := DBInsert(tx.Context(), obj); err != nil {
+				return err
+			}
+		}
+		for idx := 0; idx < numBlocks; idx++ {
+			view := testQueryViews[idx%len(testQueryViews)]
+			meta := waveobj.MetaMapType{waveobj.MetaKey_View: view}
+			switch view {
+			case "preview":
+				meta[waveobj.MetaKey_File] = fmt.Sprintf("/home/user/src/file%d.go", idx)
+			case "web":
+				meta[waveobj.MetaKey_Url] = fmt.Sprintf("https://github.com/org/repo%d", idx)
+			case "term":
+				if idx%3 == 0 {
+					meta[waveobj.MetaKey_Connection] = "user@host"
+				}
+			}
+			if idx%10 == 0 {
+				meta[waveobj.MetaKey_Pinned] = true
+			}
+			block := &waveobj.Block{
+				OID:        uuid.NewString(),
+				ParentORef: waveobj.MakeORef(waveobj.OType_Tab, tabIds[idx%len(tabIds)]).String(),
+				Meta:       meta,
+				CreatedTs:  int64(1000 + idx),
+			}
+			if idx%100 == 99 {
+				block.DeletedTs = 5000
+			}
+			if err := DBInsert(tx.Context(), block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		tb.Fatalf("error inserting blocks: %v", err)
+	}
+	return append([]string{window.OID}, tabIds...)
+}
+
+func TestQueryBlocks(t *testing.T) {
+	initTestDb(t)
+	ctx := context.Background()
+	ids := insertQueryTestBlocks(t, ctx, 400)
+	windowId, tabIds := ids[0], ids[1:]
+	tests := []struct {
+		name     string
+		filter   BlockFilter
+		expected int
+	}{
+		{name: "all", filter: BlockFilter{}, expected: 396},
+		{name: "view", filter: BlockFilter{View: "web"}, expected: 100},
+		{name: "url prefix", filter: BlockFilter{UrlPrefix: "https://github.com/org/repo1"}, expected: 28},
+		{name: "url case", filter: BlockFilter{UrlPrefix: "https://GitHub.com"}, expected: 0},
+		{name: "file contains", filter: BlockFilter{FileContains: "file1"}, expected: 28},
+		{name: "local conn", filter: BlockFilter{View: "term", Connection: "local"}, expected: 66},
+		{name: "remote conn", filter: BlockFilter{Connection: "user@host"}, expected: 34},
+		{name: "pinned", filter: BlockFilter{Pinned: true}, expected: 40},
+		{name: "tab", filter: BlockFilter{TabId: tabIds[0]}, expected: 40},
+		{name: "window", filter: BlockFilter{WindowId: windowId}, expected: 396},
+		{name: "other window", filter: BlockFilter{WindowId: uuid.NewString()}, expected: 0},
+		{name: "created after", filter: BlockFilter{CreatedAfter: 1300}, expected: 98},
+		{name: "limit", filter: BlockFilter{View: "term", Limit: 5}, expected: 5},
+	}
+	for _, tc := range tests {
+		blocks, err := QueryBlocks(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: error querying blocks: %v", tc.name, err)
+		}
+		if len(blocks) != tc.expected {
+			t.Errorf("%s: expected %d blocks, got %d", tc.name, tc.expected, len(blocks))
+		}
+		for idx := 1; idx < len(blocks); idx++ {
+			if blocks[idx].CreatedTs < blocks[idx-1].CreatedTs {
+				t.Errorf("%s: blocks are not in creation order", tc.name)
+				break
+			}
+		}
+	}
+}
+
+func TestQueryBlocksViewIndex(t *testing.T) {
+	initTestDb(t)
+	query, args := BlockFilter{View: "term"}.makeQuery()
+	var planRows []struct {
+		Id      int    `db:"id"`
+		Parent  int    `db:"parent"`
+		NotUsed int    `db:"notused"`
+		Detail  string `db:"detail"`
+	}
+	err := WithTx(context.Background(), func(tx *TxWrap) error {
+		tx.Select(&planRows, "EXPLAIN QUERY PLAN "+query, args...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error getting the query plan: %v", err)
+	}
+	var plan []string
+	for _, row := range planRows {
+		plan = append(plan, row.Detail)
+	}
+	if !strings.Contains(strings.Join(plan, "\n"), "idx_block_view") {
+		t.Errorf("expected the query to use idx_block_view, plan:\n%s", strings.Join(plan, "\n"))
+	}
+}
+
+func benchmarkQueryBlocks(b *testing.B, filter BlockFilter) {
+	initTestDb(b)
+	ctx := context.Background()
+	insertQueryTestBlocks(b, ctx, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := QueryBlocks(ctx, filter)
+		if err != nil {
+			b.Fatalf("error querying blocks: %v", err)
+		}
+	}
+}
+
+func BenchmarkQueryBlocksView(b *testing.B) {
+	benchmarkQueryBlocks(b, BlockFilter{View: "sysinfo", Limit: 10})
+}
+
+func BenchmarkQueryBlocksUrlPrefix(b *testing.B) {
+	benchmarkQueryBlocks(b, BlockFilter{View: "web", UrlPrefix: "https://github.com/org/repo9999"})
+}
+
+func BenchmarkQueryBlocksFileContains(b *testing.B) {
+	benchmarkQueryBlocks(b, BlockFilter{FileContains: "file42.go"})
+}
+
+// loading every block and filtering in memory (what QueryBlocks replaces)
+func BenchmarkScanAllBlocks(b *testing.B) {
+	initTestDb(b)
+	ctx := context.Background()
+	insertQueryTestBlocks(b, ctx, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		blocks, err := DBGetAllObjsByType[*waveobj.Block](ctx, waveobj.OType_Block)
+		if err != nil {
+			b.Fatalf("error getting blocks: %v", err)
+		}
+		for _, block := range blocks {
+			_ = strings.Contains(block.Meta.GetString(waveobj.MetaKey_File, ""), "file42.go")
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sawka/txwrap"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// read cache for objects that are read often but change rarely (client, windows, tabs).
+// entries are invalidated on DBInsert/DBUpdate/DBDelete (instead of written through, so a rolled back
+// transaction can never leave a stale value behind), and again once the transaction is done (a read outside of
+// the transaction that ran before the commit could have cached the old row).  reads inside of a transaction skip
+// the cache.
+// cached values are stored as json, every read returns a fresh copy that is safe to modify.
+
+const CacheTTL = 10 * time.Second
+
+type cacheEntry struct {
+	OType   string
+	Data    []byte
+	Version int
+	Ts      time.Time
+}
+
+type cacheInvalidation struct {
+	OType string
+	OID   string
+}
+
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+var objCacheLock = &sync.Mutex{}
+var objCache = make(map[string]*cacheEntry) // oid (or singleton key) => entry
+var objCacheGen uint64                      // incremented on every invalidation
+var txCacheInvalidations = make(map[*TxWrap][]cacheInvalidation)
+var objCacheHits atomic.Int64
+var objCacheMisses atomic.Int64
+
+func isCacheableOType(otype string) bool {
+	return otype == waveobj.OType_Client || otype == waveobj.OType_Window || otype == waveobj.OType_Tab
+}
+
+func singletonCacheKey(otype string) string {
+	return "singleton:" + otype
+}
+
+func cacheGet(key string) waveobj.WaveObj {
+	objCacheLock.Lock()
+	entry := objCache[key]
+	if entry != nil && time.Since(entry.Ts) > CacheTTL {
+		delete(objCache, key)
+		entry = nil
+	}
+	objCacheLock.Unlock()
+	if entry == nil {
+		objCacheMisses.Add(1)
+		return nil
+	}
+	obj, err := waveobj.FromJson(entry.Data)
+	if err != nil {
+		objCacheMisses.Add(1)
+		return nil
+	}
+	waveobj.SetVersion(obj, entry.Version)
+	objCacheHits.Add(1)
+	return obj
+}
+
+func cacheGetGen() uint64 {
+	objCacheLock.Lock()
+	defer objCacheLock.Unlock()
+	return objCacheGen
+}
+
+// only stores the value if there were no invalidations since gen (so a slow read can't cache a stale value)
+func cachePut(key string, gen uint64, obj waveobj.WaveObj) {
+	data, err := waveobj.ToJson(obj)
+	if err != nil {
+		return
+	}
+	objCacheLock.Lock()
+	defer objCacheLock.Unlock()
+	if gen != objCacheGen {
+		return
+	}
+	objCache[key] = &cacheEntry{OType: obj.GetOType(), Data: data, Version: waveobj.GetVersion(obj), Ts: time.Now()}
+}
+
+func cacheInvalidate(otype string, oid string) {
+	if !isCacheableOType(otype) {
+		return
+	}
+	objCacheLock.Lock()
+	defer objCacheLock.Unlock()
+	cacheInvalidateLocked(otype, oid)
+}
+
+func cacheInvalidateLocked(otype string, oid string) {
+	objCacheGen++
+	delete(objCache, oid)
+	delete(objCache, singletonCacheKey(otype))
+}
+
+// for writes in a transaction, invalidates now and again after the outermost transaction is done (see cacheTxDone)
+func cacheInvalidateTx(tx *TxWrap, otype string, oid string) {
+	if !isCacheableOType(otype) {
+		return
+	}
+	objCacheLock.Lock()
+	defer objCacheLock.Unlock()
+	cacheInvalidateLocked(otype, oid)
+	txCacheInvalidations[tx] = append(txCacheInvalidations[tx], cacheInvalidation{OType: otype, OID: oid})
+}
+
+func cacheTxDone(tx *TxWrap) {
+	objCacheLock.Lock()
+	defer objCacheLock.Unlock()
+	for _, inv := range txCacheInvalidations[tx] {
+		cacheInvalidateLocked(inv.OType, inv.OID)
+	}
+	delete(txCacheInvalidations, tx)
+}
+
+// clears the read cache (and resets the stats)
+func InvalidateCache() {
+	objCacheLock.Lock()
+	defer objCacheLock.Unlock()
+	objCacheGen++
+	objCache = make(map[string]*cacheEntry)
+	objCacheHits.Store(0)
+	objCacheMisses.Store(0)
+}
+
+func GetCacheStats() CacheStats {
+	objCacheLock.Lock()
+	defer objCacheLock.Unlock()
+	return CacheStats{
+		Hits:    objCacheHits.Load(),
+		Misses:  objCacheMisses.Load(),
+		Entries: len(objCache),
+	}
+}
+
+// like DBGetORef, but returns the cached value if there is one (client, window, and tab objects only)
+func DBGetORefCached(ctx context.Context, oref waveobj.ORef) (waveobj.WaveObj, error) {
+	if !isCacheableOType(oref.OType) || txwrap.IsTxWrapContext(ctx) || hasPendingMeta(oref.OID) {
+		return DBGetORef(ctx, oref)
+	}
+	if obj := cacheGet(oref.OID); obj != nil {
+		return obj, nil
+	}
+	gen := cacheGetGen()
+	rtn, err := DBGetORef(ctx, oref)
+	if err == nil && rtn != nil {
+		cachePut(oref.OID, gen, rtn)
+	}
+	return rtn, err
+}
+
+// like DBGet, but uses the cache (see DBGetORefCached)
+func DBGetCached[T waveobj.WaveObj](ctx context.Context, id string) (T, error) {
+	rtn, err := DBGetORefCached(ctx, waveobj.ORef{OType: getOTypeGen[T](), OID: id})
+	return genericCastWithErr[T](rtn, err)
+}
+
+// like DBMustGet, but uses the cache (see DBGetORefCached)
+func DBMustGetCached[T waveobj.WaveObj](ctx context.Context, id string) (T, error) {
+	rtn, err := DBGetORefCached(ctx, waveobj.ORef{OType: getOTypeGen[T](), OID: id})
+	if err != nil {
+		var zeroVal T
+		return zeroVal, err
+	}
+	if rtn == nil {
+		var zeroVal T
+		return zeroVal, ErrNotFound
+	}
+	return rtn.(T), nil
+}
+
+// like DBGetSingleton, but uses the cache (see DBGetORefCached)
+func DBGetSingletonCached[T waveobj.WaveObj](ctx context.Context) (T, error) {
+	otype := getOTypeGen[T]()
+	if !isCacheableOType(otype) || txwrap.IsTxWrapContext(ctx) {
+		return DBGetSingleton[T](ctx)
+	}
+	key := singletonCacheKey(otype)
+	if obj := cacheGet(key); obj != nil {
+		return genericCastWithErr[T](obj, nil)
+	}
+	gen := cacheGetGen()
+	rtn, err := DBGetSingletonByType(ctx, otype)
+	if err == nil && rtn != nil {
+		cachePut(key, gen, rtn)
+	}
+	return genericCastWithErr[T](rtn, err)
+}
@@ -0,0 +1,57 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func TestCacheInvalidatedAfterCommit(t *testing.T) {
+	initTestDb(t)
+	ctx := context.Background()
+	tab := &waveobj.Tab{OID: uuid.NewString(), Name: "old"}
+	if err := DBInsert(ctx, tab); err != nil {
+		t.Fatal(err)
+	}
+	readDone := make(chan *waveobj.Tab)
+	err := WithTx(ctx, func(tx *TxWrap) error {
+		newTab := *tab
+		newTab.Name = "new"
+		if err := DBUpdate(tx.Context(), &newTab); err != nil {
+			return err
+		}
+		// a reader that fetched the committed row before the commit caches it under the current generation
+		staleTab := *tab
+		cachePut(tab.OID, cacheGetGen(), &staleTab)
+		go func() {
+			rtn, _ := DBGetCached[*waveobj.Tab](ctx, tab.OID)
+			readDone <- rtn
+		}()
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-readDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the cached read")
+	}
+	rtn, err := DBGetCached[*waveobj.Tab](ctx, tab.OID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rtn == nil || rtn.Name != "new" {
+		t.Errorf("expected the committed tab from the cache, got %#v", rtn)
+	}
+	if len(txCacheInvalidations) != 0 {
+		t.Errorf("expected no pending tx invalidations, got %d", len(txCacheInvalidations))
+	}
+}
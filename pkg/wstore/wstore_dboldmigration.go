@@ -6,7 +6,6 @@ package wstore
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -94,7 +93,7 @@ func TryMigrateOldHistory() error {
 	if err != nil {
 		return err
 	}
-	log.Printf("migrated %d old wave history records\n", len(hist))
+	logger.Info(ctx, "migrated old wave history records", "count", len(hist))
 	client, err := DBGetSingleton[*waveobj.Client](ctx)
 	if err != nil {
 		return err
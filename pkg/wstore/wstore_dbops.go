@@ -6,7 +6,6 @@ package wstore
 import (
 	"context"
 	"fmt"
-	"log"
 	"reflect"
 	"regexp"
 	"time"
@@ -18,6 +17,8 @@ import (
 )
 
 var ErrNotFound = fmt.Errorf("not found")
+var ErrWindowNotFound = fmt.Errorf("window not found")
+var ErrVersionMismatch = fmt.Errorf("version mismatch (the object was modified, re-read it and try again)")
 
 func waveObjTableName(w waveobj.WaveObj) string {
 	return "db_" + w.GetOType()
@@ -65,7 +66,7 @@ var viewRe = regexp.MustCompile(`^[a-z0-9]{1,20}$`)
 
 func DBGetBlockViewCounts(ctx context.Context) (map[string]int, error) {
 	return WithTxRtn(ctx, func(tx *TxWrap) (map[string]int, error) {
-		query := `SELECT COALESCE(json_extract(data, '$.meta.view'), '') AS view FROM db_block`
+		query := `SELECT COALESCE(json_extract(data, '$.meta.view'), '') AS view FROM db_block WHERE json_extract(data, '$.deletedts') IS NULL`
 		views := tx.SelectStrings(query)
 		rtn := make(map[string]int)
 		for _, view := range views {
@@ -118,7 +119,7 @@ func DBGetSingletonByType(ctx context.Context, otype string) (waveobj.WaveObj, e
 			return rtn, err
 		}
 		waveobj.SetVersion(rtn, row.Version)
-		return rtn, nil
+		return overlayPendingMeta(rtn), nil
 	})
 }
 
@@ -162,7 +163,7 @@ func DBGetORef(ctx context.Context, oref waveobj.ORef) (waveobj.WaveObj, error)
 			return rtn, err
 		}
 		waveobj.SetVersion(rtn, row.Version)
-		return rtn, nil
+		return overlayPendingMeta(rtn), nil
 	})
 }
 
@@ -179,7 +180,7 @@ func dbSelectOIDs(ctx context.Context, otype string, oids []string) ([]waveobj.W
 				return nil, err
 			}
 			waveobj.SetVersion(waveObj, row.Version)
-			rtn = append(rtn, waveObj)
+			rtn = append(rtn, overlayPendingMeta(waveObj))
 		}
 		return rtn, nil
 	})
@@ -207,7 +208,7 @@ func DBGetAllOIDsByType(ctx context.Context, otype string) ([]string, error) {
 	return WithTxRtn(ctx, func(tx *TxWrap) ([]string, error) {
 		rtn := make([]string, 0)
 		table := tableNameFromOType(otype)
-		log.Printf("DBGetAllOIDsByType table: %s\n", table)
+		logger.Debug(ctx, "get all oids", "table", table)
 		query := fmt.Sprintf("SELECT oid FROM %s", table)
 		var rows []idDataType
 		tx.Select(&rows, query)
@@ -222,7 +223,7 @@ func DBGetAllObjsByType[T waveobj.WaveObj](ctx context.Context, otype string) ([
 	return WithTxRtn(ctx, func(tx *TxWrap) ([]T, error) {
 		rtn := make([]T, 0)
 		table := tableNameFromOType(otype)
-		log.Printf("DBGetAllObjsByType table: %s\n", table)
+		logger.Debug(ctx, "get all objects", "table", table)
 		query := fmt.Sprintf("SELECT oid, version, data FROM %s", table)
 		var rows []idDataType
 		tx.Select(&rows, query)
@@ -233,7 +234,7 @@ func DBGetAllObjsByType[T waveobj.WaveObj](ctx context.Context, otype string) ([
 			}
 			waveobj.SetVersion(waveObj, row.Version)
 
-			rtn = append(rtn, waveObj.(T))
+			rtn = append(rtn, overlayPendingMeta(waveObj).(T))
 		}
 		return rtn, nil
 	})
@@ -273,11 +274,28 @@ func DBSelectMap[T waveobj.WaveObj](ctx context.Context, ids []string) (map[stri
 	return rtnMap, nil
 }
 
+// returns the objects in the same order as ids (ids that are not found are skipped)
+func DBGetMultiple[T waveobj.WaveObj](ctx context.Context, ids []string) ([]T, error) {
+	objMap, err := DBSelectMap[T](ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	rtn := make([]T, 0, len(ids))
+	for _, id := range ids {
+		if obj, ok := objMap[id]; ok {
+			rtn = append(rtn, obj)
+		}
+	}
+	return rtn, nil
+}
+
 func DBDelete(ctx context.Context, otype string, id string) error {
 	err := WithTx(ctx, func(tx *TxWrap) error {
 		table := tableNameFromOType(otype)
 		query := fmt.Sprintf("DELETE FROM %s WHERE oid = ?", table)
 		tx.Exec(query, id)
+		cacheInvalidateTx(tx, otype, id)
+		txOnCommit(tx, func() { clearPendingMeta(id) })
 		waveobj.ContextAddUpdate(ctx, waveobj.WaveObjUpdate{UpdateType: waveobj.UpdateType_Delete, OType: otype, OID: id})
 		return nil
 	})
@@ -294,7 +312,7 @@ func DBDelete(ctx context.Context, otype string, id string) error {
 		defer cancelFn()
 		err := filestore.WFS.DeleteZone(deleteCtx, id)
 		if err != nil {
-			log.Printf("error deleting filestore zone (after deleting block): %v", err)
+			logger.Error(ctx, "error deleting filestore zone (after deleting block)", "blockid", id, "err", err)
 		}
 	}()
 	return nil
@@ -311,8 +329,14 @@ func DBUpdate(ctx context.Context, val waveobj.WaveObj) error {
 	}
 	return WithTx(ctx, func(tx *TxWrap) error {
 		table := waveObjTableName(val)
-		query := fmt.Sprintf("UPDATE %s SET data = ?, version = version+1 WHERE oid = ? RETURNING version", table)
-		newVersion := tx.GetInt(query, jsonData, oid)
+		// val includes the object's pending async meta (it was read with it), and it can be at the pending
+		// version (see UpdateObjectMetaAsync)
+		query := fmt.Sprintf("UPDATE %s SET data = ?, version = MAX(version, ?)+1 WHERE oid = ? RETURNING version", table)
+		readVersion := waveobj.GetVersion(val)
+		newVersion := tx.GetInt(query, jsonData, readVersion, oid)
+		objWriteCount.Add(1)
+		cacheInvalidateTx(tx, val.GetOType(), oid)
+		txOnCommit(tx, func() { clearPendingMetaVersion(oid, readVersion) })
 		waveobj.SetVersion(val, newVersion)
 		waveobj.ContextAddUpdate(ctx, waveobj.WaveObjUpdate{UpdateType: waveobj.UpdateType_Update, OType: val.GetOType(), OID: oid, Obj: val})
 		return nil
@@ -333,6 +357,7 @@ func DBInsert(ctx context.Context, val waveobj.WaveObj) error {
 		waveobj.SetVersion(val, 1)
 		query := fmt.Sprintf("INSERT INTO %s (oid, version, data) VALUES (?, ?, ?)", table)
 		tx.Exec(query, oid, 1, jsonData)
+		cacheInvalidateTx(tx, val.GetOType(), oid)
 		waveobj.ContextAddUpdate(ctx, waveobj.WaveObjUpdate{UpdateType: waveobj.UpdateType_Update, OType: val.GetOType(), OID: oid, Obj: val})
 		return nil
 	})
@@ -368,7 +393,6 @@ func DBFindTabForBlockId(ctx context.Context, blockId string) (string, error) {
 }
 
 func DBFindWorkspaceForTabId(ctx context.Context, tabId string) (string, error) {
-	log.Printf("DBFindWorkspaceForTabId tabId: %s\n", tabId)
 	return WithTxRtn(ctx, func(tx *TxWrap) (string, error) {
 		query := `
 			WITH variable(value) AS (
@@ -388,7 +412,7 @@ func DBFindWorkspaceForTabId(ctx context.Context, tabId string) (string, error)
 			);
 			`
 		wsId := tx.GetString(query, tabId)
-		log.Printf("DBFindWorkspaceForTabId wsId: %s\n", wsId)
+		logger.Debug(ctx, "found workspace for tab", "tabid", tabId, "workspaceid", wsId)
 		return wsId, nil
 	})
 }
@@ -401,3 +425,12 @@ func DBFindWindowForWorkspaceId(ctx context.Context, workspaceId string) (string
 		return tx.GetString(query, workspaceId), nil
 	})
 }
+
+// returns "" if the tab isn't open in a window
+func DBFindWindowForTabId(ctx context.Context, tabId string) (string, error) {
+	workspaceId, err := DBFindWorkspaceForTabId(ctx, tabId)
+	if err != nil || workspaceId == "" {
+		return "", err
+	}
+	return DBFindWindowForWorkspaceId(ctx, workspaceId)
+}
@@ -6,12 +6,13 @@ package wstore
 import (
 	"context"
 	"fmt"
-	"log"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/sawka/txwrap"
+	"github.com/wavetermdev/waveterm/pkg/util/dbutil"
 	"github.com/wavetermdev/waveterm/pkg/util/migrateutil"
 	"github.com/wavetermdev/waveterm/pkg/wavebase"
 	"github.com/wavetermdev/waveterm/pkg/waveobj"
@@ -24,6 +25,8 @@ const WStoreDBName = "waveterm.db"
 type TxWrap = txwrap.TxWrap
 
 var globalDB *sqlx.DB
+var useTestingDb bool // just for testing (forces MakeDB() to return an in-memory db)
+var dbHealth dbutil.HealthTracker
 
 func InitWStore() error {
 	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
@@ -37,18 +40,52 @@ func InitWStore() error {
 	if err != nil {
 		return err
 	}
-	log.Printf("wstore initialized\n")
+	logger.Info(ctx, "wstore initialized")
 	return nil
 }
 
+func GetDBVersionInfo() (*migrateutil.VersionInfo, error) {
+	if globalDB == nil {
+		return nil, fmt.Errorf("wstore not initialized")
+	}
+	return migrateutil.GetVersionInfo("wstore", globalDB.DB, dbfs.WStoreMigrationFS, "migrations-wstore")
+}
+
+// reverts the most recent wstore migration (for downgrading Wave).  only for when the server is not running (see
+// "wavesrv --rollback-db"), it opens the db itself and fails if wstore was initialized in this process.
+func RollbackLastMigration() (uint, error) {
+	if globalDB != nil {
+		return 0, fmt.Errorf("cannot roll back wstore while it is open")
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelFn()
+	db, err := MakeDB(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	return migrateutil.MigrateDownOne("wstore", db.DB, dbfs.WStoreMigrationFS, "migrations-wstore")
+}
+
+// doesn't touch the db (see dbutil.HealthTracker), so it can't block when the db is locked
+func GetDBHealth() (bool, dbutil.HealthStatus) {
+	return globalDB != nil, dbHealth.GetStatus(time.Now())
+}
+
 func GetDBName() string {
 	waveHome := wavebase.GetWaveDataDir()
 	return filepath.Join(waveHome, wavebase.WaveDBDir, WStoreDBName)
 }
 
 func MakeDB(ctx context.Context) (*sqlx.DB, error) {
-	dbName := GetDBName()
-	rtn, err := sqlx.Open("sqlite3", fmt.Sprintf("file:%s?mode=rwc&_journal_mode=WAL&_busy_timeout=5000", dbName))
+	var rtn *sqlx.DB
+	var err error
+	if useTestingDb {
+		rtn, err = sqlx.Open("sqlite3", ":memory:")
+	} else {
+		dbName := GetDBName()
+		rtn, err = sqlx.Open("sqlite3", fmt.Sprintf("file:%s?mode=rwc&_journal_mode=WAL&_busy_timeout=5000", dbName))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +93,63 @@ func MakeDB(ctx context.Context) (*sqlx.DB, error) {
 	return rtn, nil
 }
 
+// logs a warning if a db call used more than half of the time remaining on ctx (helps diagnose slow storage)
+func logSlowDBCall(ctx context.Context, startTs time.Time) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	elapsed := time.Since(startTs)
+	budget := deadline.Sub(startTs)
+	if elapsed > budget/2 {
+		logger.Warn(ctx, "slow db call", "took", elapsed.Round(time.Millisecond), "timeout", budget.Round(time.Millisecond))
+	}
+}
+
+var txCommitHooksLock = &sync.Mutex{}
+var txCommitHooks = make(map[*TxWrap][]func())
+
+// runs fn after the (outermost) transaction is committed, it is not run if the transaction is rolled back
+func txOnCommit(tx *TxWrap, fn func()) {
+	txCommitHooksLock.Lock()
+	defer txCommitHooksLock.Unlock()
+	txCommitHooks[tx] = append(txCommitHooks[tx], fn)
+}
+
+// for the outermost transaction, wraps fn to remember its TxWrap.  the returned func (to be called after the
+// transaction is committed or rolled back) runs the cache invalidations again (see cacheInvalidateTx) and, if the
+// transaction was committed, the commit hooks (see txOnCommit)
+func trackTxDone(ctx context.Context, fn func(tx *TxWrap) error) (func(tx *TxWrap) error, func(committed bool)) {
+	if txwrap.IsTxWrapContext(ctx) {
+		return fn, func(bool) {}
+	}
+	var outerTx *TxWrap
+	wrappedFn := func(tx *TxWrap) error {
+		outerTx = tx
+		return fn(tx)
+	}
+	return wrappedFn, func(committed bool) {
+		if outerTx == nil {
+			return
+		}
+		cacheTxDone(outerTx)
+		txCommitHooksLock.Lock()
+		hooks := txCommitHooks[outerTx]
+		delete(txCommitHooks, outerTx)
+		txCommitHooksLock.Unlock()
+		if !committed {
+			return
+		}
+		for _, hook := range hooks {
+			hook()
+		}
+	}
+}
+
+// runs fn in a single transaction (rolled back if fn returns an error).  nested calls (made with tx.Context())
+// reuse the outer transaction.  updates collected with waveobj.ContextWithUpdates are only kept if the transaction commits.
 func WithTx(ctx context.Context, fn func(tx *TxWrap) error) (rtnErr error) {
+	defer logSlowDBCall(ctx, time.Now())
 	waveobj.ContextUpdatesBeginTx(ctx)
 	defer func() {
 		if rtnErr != nil {
@@ -65,10 +158,15 @@ func WithTx(ctx context.Context, fn func(tx *TxWrap) error) (rtnErr error) {
 			waveobj.ContextUpdatesCommitTx(ctx)
 		}
 	}()
-	return txwrap.WithTx(ctx, globalDB, fn)
+	fn, txDoneFn := trackTxDone(ctx, fn)
+	defer func() { txDoneFn(rtnErr == nil) }()
+	trackedFn, doneFn := dbHealth.TrackTx(fn)
+	defer func() { doneFn(rtnErr) }()
+	return txwrap.WithTx(ctx, globalDB, trackedFn)
 }
 
 func WithTxRtn[RT any](ctx context.Context, fn func(tx *TxWrap) (RT, error)) (rtnVal RT, rtnErr error) {
+	defer logSlowDBCall(ctx, time.Now())
 	waveobj.ContextUpdatesBeginTx(ctx)
 	defer func() {
 		if rtnErr != nil {
@@ -77,5 +175,16 @@ func WithTxRtn[RT any](ctx context.Context, fn func(tx *TxWrap) (RT, error)) (rt
 			waveobj.ContextUpdatesCommitTx(ctx)
 		}
 	}()
-	return txwrap.WithTxRtn(ctx, globalDB, fn)
+	rtnFn, txDoneFn := trackTxDone(ctx, func(tx *TxWrap) error {
+		val, err := fn(tx)
+		if err == nil {
+			rtnVal = val
+		}
+		return err
+	})
+	defer func() { txDoneFn(rtnErr == nil) }()
+	trackedFn, doneFn := dbHealth.TrackTx(rtnFn)
+	defer func() { doneFn(rtnErr) }()
+	rtnErr = txwrap.WithTx(ctx, globalDB, trackedFn)
+	return rtnVal, rtnErr
 }
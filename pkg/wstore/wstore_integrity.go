@@ -0,0 +1,566 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// checks the parent -> child references between the stored objects (client -> windows, window -> workspace,
+// workspace -> tabs, tab -> layout/blocks, block -> subblocks).  blocks in the trash are not listed by their
+// parent, so they are never orphans.
+
+type IntegrityIssue struct {
+	ORef   string `json:"oref"`            // the object with the problem
+	Field  string `json:"field,omitempty"` // the field holding the dangling reference
+	RefId  string `json:"refid,omitempty"` // the dangling id
+	Reason string `json:"reason"`
+}
+
+type IntegrityReport struct {
+	Dangling []IntegrityIssue `json:"dangling"` // references to objects that don't exist (or are in the wrong place)
+	Orphans  []IntegrityIssue `json:"orphans"`  // objects that are not referenced by their parent
+}
+
+func (r *IntegrityReport) NumIssues() int {
+	return len(r.Dangling) + len(r.Orphans)
+}
+
+type RepairIntegrityOpts struct {
+	DeleteOrphans bool // delete orphaned windows, tabs, and blocks (the default re-parents them)
+}
+
+type RepairIntegrityResult struct {
+	Report        *IntegrityReport    // the issues found before the repair
+	Updated       []waveobj.ORef      // includes inserted objects (new layouts for tabs with a missing layout)
+	Deleted       []waveobj.ORef      // deleted objects (orphaned layouts are always deleted)
+	LayoutInserts map[string][]string // tabid -> blockids that were (re-)added to the tab and need a layout insert
+}
+
+func CheckIntegrity(ctx context.Context) (*IntegrityReport, error) {
+	snapshot, err := MakeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return checkSnapshotIntegrity(snapshot), nil
+}
+
+// prunes dangling references and re-parents (or deletes) orphans, in a single transaction.
+// windows whose workspace does not exist are deleted, and orphaned blocks whose parent is gone are always deleted.
+func RepairIntegrity(ctx context.Context, opts RepairIntegrityOpts) (*RepairIntegrityResult, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) (*RepairIntegrityResult, error) {
+		snapshot, err := MakeSnapshot(tx.Context())
+		if err != nil {
+			return nil, err
+		}
+		report := checkSnapshotIntegrity(snapshot)
+		if report.NumIssues() == 0 {
+			return &RepairIntegrityResult{Report: report}, nil
+		}
+		repair := repairSnapshot(snapshot, opts)
+		for _, obj := range repair.inserted {
+			if err := DBInsert(tx.Context(), obj); err != nil {
+				return nil, fmt.Errorf("error inserting %s: %w", waveobj.ORefFromWaveObj(obj), err)
+			}
+		}
+		for _, obj := range repair.updated {
+			if repair.isDeleted(waveobj.ORefFromWaveObj(obj)) {
+				continue
+			}
+			if err := DBUpdate(tx.Context(), obj); err != nil {
+				return nil, fmt.Errorf("error updating %s: %w", waveobj.ORefFromWaveObj(obj), err)
+			}
+		}
+		for _, oref := range repair.deleted {
+			if err := DBDelete(tx.Context(), oref.OType, oref.OID); err != nil {
+				return nil, fmt.Errorf("error deleting %s: %w", oref, err)
+			}
+		}
+		rtn := &RepairIntegrityResult{Report: report, Deleted: repair.deleted, LayoutInserts: repair.layoutInserts}
+		for _, obj := range append(repair.inserted, repair.updated...) {
+			if !repair.isDeleted(waveobj.ORefFromWaveObj(obj)) {
+				rtn.Updated = append(rtn.Updated, *waveobj.ORefFromWaveObj(obj))
+			}
+		}
+		return rtn, nil
+	})
+}
+
+type integrityObjs struct {
+	snap       *Snapshot
+	windows    map[string]*waveobj.Window
+	workspaces map[string]*waveobj.Workspace
+	tabs       map[string]*waveobj.Tab
+	layouts    map[string]*waveobj.LayoutState
+	blocks     map[string]*waveobj.Block
+}
+
+func makeIntegrityObjs(snap *Snapshot) *integrityObjs {
+	objs := &integrityObjs{
+		snap:       snap,
+		windows:    make(map[string]*waveobj.Window),
+		workspaces: make(map[string]*waveobj.Workspace),
+		tabs:       make(map[string]*waveobj.Tab),
+		layouts:    make(map[string]*waveobj.LayoutState),
+		blocks:     make(map[string]*waveobj.Block),
+	}
+	for _, window := range snap.Windows {
+		objs.windows[window.OID] = window
+	}
+	for _, ws := range snap.Workspaces {
+		objs.workspaces[ws.OID] = ws
+	}
+	for _, tab := range snap.Tabs {
+		objs.tabs[tab.OID] = tab
+	}
+	for _, layoutState := range snap.LayoutStates {
+		objs.layouts[layoutState.OID] = layoutState
+	}
+	for _, block := range snap.Blocks {
+		objs.blocks[block.OID] = block
+	}
+	return objs
+}
+
+func (objs *integrityObjs) objExists(oref *waveobj.ORef) bool {
+	if oref == nil {
+		return false
+	}
+	switch oref.OType {
+	case waveobj.OType_Tab:
+		return objs.tabs[oref.OID] != nil
+	case waveobj.OType_Block:
+		return objs.blocks[oref.OID] != nil
+	}
+	return false
+}
+
+// returns the object that lists each (live) block: blockid -> parent oref
+func (objs *integrityObjs) blockListers() map[string]string {
+	rtn := make(map[string]string)
+	for _, tab := range objs.snap.Tabs {
+		if objs.tabs[tab.OID] == nil {
+			continue
+		}
+		for _, blockId := range tab.BlockIds {
+			rtn[blockId] = waveobj.MakeORef(waveobj.OType_Tab, tab.OID).String()
+		}
+	}
+	for _, block := range objs.snap.Blocks {
+		if objs.blocks[block.OID] == nil {
+			continue
+		}
+		for _, subBlockId := range block.SubBlockIds {
+			rtn[subBlockId] = waveobj.MakeORef(waveobj.OType_Block, block.OID).String()
+		}
+	}
+	return rtn
+}
+
+func (objs *integrityObjs) workspaceTabIds(ws *waveobj.Workspace) map[string]bool {
+	rtn := make(map[string]bool)
+	for _, tabId := range append(append([]string{}, ws.TabIds...), ws.PinnedTabIds...) {
+		rtn[tabId] = true
+	}
+	return rtn
+}
+
+func checkSnapshotIntegrity(snap *Snapshot) *IntegrityReport {
+	objs := makeIntegrityObjs(snap)
+	report := &IntegrityReport{Dangling: []IntegrityIssue{}, Orphans: []IntegrityIssue{}}
+	addDangling := func(obj waveobj.WaveObj, field string, refId string, reason string) {
+		report.Dangling = append(report.Dangling, IntegrityIssue{ORef: waveobj.ORefFromWaveObj(obj).String(), Field: field, RefId: refId, Reason: reason})
+	}
+	addOrphan := func(obj waveobj.WaveObj, reason string) {
+		report.Orphans = append(report.Orphans, IntegrityIssue{ORef: waveobj.ORefFromWaveObj(obj).String(), Reason: reason})
+	}
+	clientWindowIds := make(map[string]bool)
+	if snap.Client != nil {
+		for _, windowId := range snap.Client.WindowIds {
+			clientWindowIds[windowId] = true
+			if objs.windows[windowId] == nil {
+				addDangling(snap.Client, "windowids", windowId, "window does not exist")
+			}
+		}
+	}
+	for _, window := range snap.Windows {
+		if objs.workspaces[window.WorkspaceId] == nil {
+			addDangling(window, "workspaceid", window.WorkspaceId, "workspace does not exist")
+		}
+		if snap.Client != nil && !clientWindowIds[window.OID] {
+			addOrphan(window, "not in the client's window list")
+		}
+	}
+	listedTabs := make(map[string]bool)
+	for _, ws := range snap.Workspaces {
+		for _, tabId := range ws.TabIds {
+			if objs.tabs[tabId] == nil {
+				addDangling(ws, "tabids", tabId, "tab does not exist")
+			}
+		}
+		for _, tabId := range ws.PinnedTabIds {
+			if objs.tabs[tabId] == nil {
+				addDangling(ws, "pinnedtabids", tabId, "tab does not exist")
+			}
+		}
+		wsTabIds := objs.workspaceTabIds(ws)
+		for tabId := range wsTabIds {
+			listedTabs[tabId] = true
+		}
+		if ws.ActiveTabId != "" && (!wsTabIds[ws.ActiveTabId] || objs.tabs[ws.ActiveTabId] == nil) {
+			addDangling(ws, "activetabid", ws.ActiveTabId, "tab is not in the workspace")
+		}
+	}
+	usedLayouts := make(map[string]bool)
+	for _, tab := range snap.Tabs {
+		if !listedTabs[tab.OID] {
+			addOrphan(tab, "not in any workspace")
+		}
+		usedLayouts[tab.LayoutState] = true
+		if objs.layouts[tab.LayoutState] == nil {
+			addDangling(tab, "layoutstate", tab.LayoutState, "layout does not exist")
+		}
+		for _, blockId := range tab.BlockIds {
+			if block := objs.blocks[blockId]; block == nil {
+				addDangling(tab, "blockids", blockId, "block does not exist")
+			} else if block.DeletedTs != 0 {
+				addDangling(tab, "blockids", blockId, "block is in the trash")
+			}
+		}
+	}
+	for _, layoutState := range snap.LayoutStates {
+		if !usedLayouts[layoutState.OID] {
+			addOrphan(layoutState, "not used by any tab")
+		}
+	}
+	listers := objs.blockListers()
+	for _, block := range snap.Blocks {
+		for _, subBlockId := range block.SubBlockIds {
+			if objs.blocks[subBlockId] == nil {
+				addDangling(block, "subblockids", subBlockId, "block does not exist")
+			}
+		}
+		if block.DeletedTs != 0 {
+			continue
+		}
+		lister, listed := listers[block.OID]
+		if !listed {
+			if parentORef := waveobj.ParseORefNoErr(block.ParentORef); !objs.objExists(parentORef) {
+				addOrphan(block, fmt.Sprintf("parent %q does not exist", block.ParentORef))
+			} else {
+				addOrphan(block, fmt.Sprintf("not in the block list of its parent %s", block.ParentORef))
+			}
+			continue
+		}
+		if lister != block.ParentORef {
+			addDangling(block, "parentoref", block.ParentORef, fmt.Sprintf("block is listed by %s", lister))
+		}
+	}
+	return report
+}
+
+type integrityRepair struct {
+	objs          *integrityObjs
+	opts          RepairIntegrityOpts
+	updated       []waveobj.WaveObj
+	updatedSet    map[waveobj.ORef]bool
+	inserted      []waveobj.WaveObj
+	deleted       []waveobj.ORef
+	deletedSet    map[waveobj.ORef]bool
+	layoutInserts map[string][]string
+}
+
+func (r *integrityRepair) markUpdated(obj waveobj.WaveObj) {
+	oref := *waveobj.ORefFromWaveObj(obj)
+	if r.updatedSet[oref] {
+		return
+	}
+	r.updatedSet[oref] = true
+	r.updated = append(r.updated, obj)
+}
+
+func (r *integrityRepair) markDeleted(otype string, oid string) {
+	oref := waveobj.MakeORef(otype, oid)
+	if r.deletedSet[oref] {
+		return
+	}
+	r.deletedSet[oref] = true
+	r.deleted = append(r.deleted, oref)
+}
+
+func (r *integrityRepair) isDeleted(oref *waveobj.ORef) bool {
+	return r.deletedSet[*oref]
+}
+
+func (r *integrityRepair) deleteWindow(window *waveobj.Window) {
+	delete(r.objs.windows, window.OID)
+	r.markDeleted(waveobj.OType_Window, window.OID)
+}
+
+func (r *integrityRepair) deleteBlock(blockId string) {
+	block := r.objs.blocks[blockId]
+	if block == nil {
+		return
+	}
+	delete(r.objs.blocks, blockId)
+	r.markDeleted(waveobj.OType_Block, blockId)
+	for _, subBlockId := range block.SubBlockIds {
+		r.deleteBlock(subBlockId)
+	}
+}
+
+func (r *integrityRepair) deleteTab(tab *waveobj.Tab) {
+	delete(r.objs.tabs, tab.OID)
+	r.markDeleted(waveobj.OType_Tab, tab.OID)
+	if r.objs.layouts[tab.LayoutState] != nil {
+		delete(r.objs.layouts, tab.LayoutState)
+		r.markDeleted(waveobj.OType_LayoutState, tab.LayoutState)
+	}
+	for _, blockId := range tab.BlockIds {
+		r.deleteBlock(blockId)
+	}
+	// blocks that point at the tab (including ones in the trash) would be orphans once it is gone
+	tabORef := waveobj.MakeORef(waveobj.OType_Tab, tab.OID).String()
+	for _, block := range r.objs.snap.Blocks {
+		if block.ParentORef == tabORef {
+			r.deleteBlock(block.OID)
+		}
+	}
+}
+
+// the workspace for re-parented tabs (the workspace of the first window, or the first workspace)
+func (r *integrityRepair) targetWorkspace() *waveobj.Workspace {
+	if client := r.objs.snap.Client; client != nil {
+		for _, windowId := range client.WindowIds {
+			if window := r.objs.windows[windowId]; window != nil && r.objs.workspaces[window.WorkspaceId] != nil {
+				return r.objs.workspaces[window.WorkspaceId]
+			}
+		}
+	}
+	for _, ws := range r.objs.snap.Workspaces {
+		return ws
+	}
+	return nil
+}
+
+func pruneIds(ids []string, keepFn func(string) bool) ([]string, bool) {
+	rtn := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if keepFn(id) {
+			rtn = append(rtn, id)
+		}
+	}
+	return rtn, len(rtn) != len(ids)
+}
+
+// fixes the objects in the snapshot in place (deleted objects are removed from it), and records what changed
+func repairSnapshot(snap *Snapshot, opts RepairIntegrityOpts) *integrityRepair {
+	objs := makeIntegrityObjs(snap)
+	r := &integrityRepair{
+		objs:          objs,
+		opts:          opts,
+		updatedSet:    make(map[waveobj.ORef]bool),
+		deletedSet:    make(map[waveobj.ORef]bool),
+		layoutInserts: make(map[string][]string),
+	}
+
+	// windows
+	for _, window := range snap.Windows {
+		if objs.workspaces[window.WorkspaceId] == nil {
+			r.deleteWindow(window)
+		}
+	}
+	if client := snap.Client; client != nil {
+		var changed bool
+		client.WindowIds, changed = pruneIds(client.WindowIds, func(id string) bool { return objs.windows[id] != nil })
+		clientWindowIds := make(map[string]bool)
+		for _, windowId := range client.WindowIds {
+			clientWindowIds[windowId] = true
+		}
+		for _, window := range snap.Windows {
+			if objs.windows[window.OID] == nil || clientWindowIds[window.OID] {
+				continue
+			}
+			if opts.DeleteOrphans {
+				r.deleteWindow(window)
+			} else {
+				client.WindowIds = append(client.WindowIds, window.OID)
+				changed = true
+			}
+		}
+		if changed {
+			r.markUpdated(client)
+		}
+	}
+
+	// workspaces and tabs
+	listedTabs := make(map[string]bool)
+	for _, ws := range snap.Workspaces {
+		tabExists := func(id string) bool { return objs.tabs[id] != nil }
+		var tabsChanged, pinnedChanged bool
+		ws.TabIds, tabsChanged = pruneIds(ws.TabIds, tabExists)
+		ws.PinnedTabIds, pinnedChanged = pruneIds(ws.PinnedTabIds, tabExists)
+		if tabsChanged || pinnedChanged {
+			r.markUpdated(ws)
+		}
+		for tabId := range objs.workspaceTabIds(ws) {
+			listedTabs[tabId] = true
+		}
+	}
+	for _, tab := range snap.Tabs {
+		if listedTabs[tab.OID] {
+			continue
+		}
+		targetWs := r.targetWorkspace()
+		if opts.DeleteOrphans || targetWs == nil {
+			r.deleteTab(tab)
+			continue
+		}
+		targetWs.TabIds = append(targetWs.TabIds, tab.OID)
+		listedTabs[tab.OID] = true
+		r.markUpdated(targetWs)
+	}
+	for _, ws := range snap.Workspaces {
+		wsTabIds := objs.workspaceTabIds(ws)
+		if ws.ActiveTabId != "" && wsTabIds[ws.ActiveTabId] {
+			continue
+		}
+		newActiveTabId := ""
+		if len(ws.TabIds) > 0 {
+			newActiveTabId = ws.TabIds[0]
+		} else if len(ws.PinnedTabIds) > 0 {
+			newActiveTabId = ws.PinnedTabIds[0]
+		}
+		if newActiveTabId != ws.ActiveTabId {
+			ws.ActiveTabId = newActiveTabId
+			r.markUpdated(ws)
+		}
+	}
+	for _, tab := range snap.Tabs {
+		if objs.tabs[tab.OID] == nil {
+			continue
+		}
+		var changed bool
+		tab.BlockIds, changed = pruneIds(tab.BlockIds, func(id string) bool {
+			block := objs.blocks[id]
+			return block != nil && block.DeletedTs == 0
+		})
+		if objs.layouts[tab.LayoutState] == nil {
+			layoutState := &waveobj.LayoutState{OID: uuid.NewString()}
+			objs.layouts[layoutState.OID] = layoutState
+			snap.LayoutStates = append(snap.LayoutStates, layoutState)
+			r.inserted = append(r.inserted, layoutState)
+			tab.LayoutState = layoutState.OID
+			changed = true
+			if len(tab.BlockIds) > 0 {
+				r.layoutInserts[tab.OID] = append(r.layoutInserts[tab.OID], tab.BlockIds...)
+			}
+		}
+		if changed {
+			r.markUpdated(tab)
+		}
+	}
+	usedLayouts := make(map[string]bool)
+	for _, tab := range objs.tabs {
+		usedLayouts[tab.LayoutState] = true
+	}
+	for _, layoutState := range snap.LayoutStates {
+		if objs.layouts[layoutState.OID] != nil && !usedLayouts[layoutState.OID] {
+			delete(objs.layouts, layoutState.OID)
+			r.markDeleted(waveobj.OType_LayoutState, layoutState.OID)
+		}
+	}
+
+	// blocks
+	for _, block := range snap.Blocks {
+		if objs.blocks[block.OID] == nil {
+			continue
+		}
+		var changed bool
+		block.SubBlockIds, changed = pruneIds(block.SubBlockIds, func(id string) bool { return objs.blocks[id] != nil })
+		if changed {
+			r.markUpdated(block)
+		}
+	}
+	listers := objs.blockListers()
+	for _, block := range snap.Blocks {
+		if objs.blocks[block.OID] == nil || block.DeletedTs != 0 {
+			continue
+		}
+		if lister, listed := listers[block.OID]; listed {
+			if lister != block.ParentORef {
+				block.ParentORef = lister
+				r.markUpdated(block)
+			}
+			continue
+		}
+		parentORef := waveobj.ParseORefNoErr(block.ParentORef)
+		if opts.DeleteOrphans || !objs.objExists(parentORef) {
+			r.deleteBlock(block.OID)
+			continue
+		}
+		if parentORef.OType == waveobj.OType_Tab {
+			tab := objs.tabs[parentORef.OID]
+			tab.BlockIds = append(tab.BlockIds, block.OID)
+			r.layoutInserts[tab.OID] = append(r.layoutInserts[tab.OID], block.OID)
+			r.markUpdated(tab)
+		} else {
+			parentBlock := objs.blocks[parentORef.OID]
+			parentBlock.SubBlockIds = append(parentBlock.SubBlockIds, block.OID)
+			r.markUpdated(parentBlock)
+		}
+		listers[block.OID] = block.ParentORef
+	}
+
+	// the re-parent and delete passes above can leave references to deleted blocks
+	for _, tab := range snap.Tabs {
+		if objs.tabs[tab.OID] == nil {
+			continue
+		}
+		var changed bool
+		tab.BlockIds, changed = pruneIds(tab.BlockIds, func(id string) bool { return objs.blocks[id] != nil })
+		if changed {
+			r.markUpdated(tab)
+		}
+	}
+	for _, block := range snap.Blocks {
+		if objs.blocks[block.OID] == nil {
+			continue
+		}
+		var changed bool
+		block.SubBlockIds, changed = pruneIds(block.SubBlockIds, func(id string) bool { return objs.blocks[id] != nil })
+		if changed {
+			r.markUpdated(block)
+		}
+	}
+	for tabId, blockIds := range r.layoutInserts {
+		blockIds, _ = pruneIds(blockIds, func(id string) bool { return objs.blocks[id] != nil })
+		if len(blockIds) == 0 || objs.tabs[tabId] == nil {
+			delete(r.layoutInserts, tabId)
+		} else {
+			r.layoutInserts[tabId] = blockIds
+		}
+	}
+
+	snap.Windows = filterIntegrityObjs(snap.Windows, objs.windows)
+	snap.Workspaces = filterIntegrityObjs(snap.Workspaces, objs.workspaces)
+	snap.Tabs = filterIntegrityObjs(snap.Tabs, objs.tabs)
+	snap.LayoutStates = filterIntegrityObjs(snap.LayoutStates, objs.layouts)
+	snap.Blocks = filterIntegrityObjs(snap.Blocks, objs.blocks)
+	return r
+}
+
+func filterIntegrityObjs[T waveobj.WaveObj](arr []T, keep map[string]T) []T {
+	var rtn []T
+	for _, obj := range arr {
+		if _, found := keep[waveobj.GetOID(obj)]; found {
+			rtn = append(rtn, obj)
+		}
+	}
+	return rtn
+}
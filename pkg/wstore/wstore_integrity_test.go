@@ -0,0 +1,300 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+const (
+	testClientId  = "00000000-0000-0000-0000-0000000000c0"
+	testWin1      = "00000000-0000-0000-0000-0000000000a1"
+	testWs1       = "00000000-0000-0000-0000-0000000000e1"
+	testTab1      = "00000000-0000-0000-0000-0000000000b1"
+	testTab2      = "00000000-0000-0000-0000-0000000000b2"
+	testLayout1   = "00000000-0000-0000-0000-0000000000f1"
+	testLayout2   = "00000000-0000-0000-0000-0000000000f2"
+	testLayout3   = "00000000-0000-0000-0000-0000000000f3"
+	testBlock1    = "00000000-0000-0000-0000-0000000000d1"
+	testSub1      = "00000000-0000-0000-0000-0000000000d5"
+	testBlock2    = "00000000-0000-0000-0000-0000000000d2"
+	testBlock3    = "00000000-0000-0000-0000-0000000000d3"
+	testTrash1    = "00000000-0000-0000-0000-0000000000d9"
+	testMissingId = "00000000-0000-0000-0000-000000000000"
+)
+
+// client -> window -> workspace -> tabs testTab1, testTab2 (pinned).  testTab1 has testBlock1 (with testSub1)
+// and a trashed block (testTrash1), testTab2 has testBlock2.
+func makeTestSnapshot() *Snapshot {
+	return &Snapshot{
+		Client:     &waveobj.Client{OID: testClientId, WindowIds: []string{testWin1}},
+		Windows:    []*waveobj.Window{{OID: testWin1, WorkspaceId: testWs1}},
+		Workspaces: []*waveobj.Workspace{{OID: testWs1, TabIds: []string{testTab1}, PinnedTabIds: []string{testTab2}, ActiveTabId: testTab1}},
+		Tabs: []*waveobj.Tab{
+			{OID: testTab1, LayoutState: testLayout1, BlockIds: []string{testBlock1}},
+			{OID: testTab2, LayoutState: testLayout2, BlockIds: []string{testBlock2}},
+		},
+		LayoutStates: []*waveobj.LayoutState{{OID: testLayout1}, {OID: testLayout2}},
+		Blocks: []*waveobj.Block{
+			{OID: testBlock1, ParentORef: "tab:" + testTab1, SubBlockIds: []string{testSub1}},
+			{OID: testSub1, ParentORef: "block:" + testBlock1},
+			{OID: testBlock2, ParentORef: "tab:" + testTab2},
+			{OID: testTrash1, ParentORef: "tab:" + testTab1, DeletedTs: 1000},
+		},
+	}
+}
+
+func findTestTab(snap *Snapshot, tabId string) *waveobj.Tab {
+	for _, tab := range snap.Tabs {
+		if tab.OID == tabId {
+			return tab
+		}
+	}
+	return nil
+}
+
+func findTestBlock(snap *Snapshot, blockId string) *waveobj.Block {
+	for _, block := range snap.Blocks {
+		if block.OID == blockId {
+			return block
+		}
+	}
+	return nil
+}
+
+func TestCheckIntegrityClean(t *testing.T) {
+	report := checkSnapshotIntegrity(makeTestSnapshot())
+	if report.NumIssues() != 0 {
+		t.Fatalf("expected no issues, got %+v", report)
+	}
+}
+
+func TestIntegrityDanglingRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(snap *Snapshot)
+		oref    string
+		field   string
+		verify  func(t *testing.T, snap *Snapshot)
+	}{
+		{
+			name:    "client window",
+			corrupt: func(snap *Snapshot) { snap.Client.WindowIds = append(snap.Client.WindowIds, testMissingId) },
+			oref:    "client:" + testClientId,
+			field:   "windowids",
+			verify: func(t *testing.T, snap *Snapshot) {
+				if len(snap.Client.WindowIds) != 1 {
+					t.Errorf("expected missing window to be pruned, got %v", snap.Client.WindowIds)
+				}
+			},
+		},
+		{
+			name:    "window workspace",
+			corrupt: func(snap *Snapshot) { snap.Windows[0].WorkspaceId = testMissingId },
+			oref:    "window:" + testWin1,
+			field:   "workspaceid",
+			verify: func(t *testing.T, snap *Snapshot) {
+				if len(snap.Windows) != 0 || len(snap.Client.WindowIds) != 0 {
+					t.Errorf("expected window to be deleted, got %v %v", snap.Windows, snap.Client.WindowIds)
+				}
+			},
+		},
+		{
+			name: "workspace tab",
+			corrupt: func(snap *Snapshot) {
+				snap.Workspaces[0].TabIds = append(snap.Workspaces[0].TabIds, testMissingId)
+				snap.Workspaces[0].ActiveTabId = testMissingId
+			},
+			oref:  "workspace:" + testWs1,
+			field: "tabids",
+			verify: func(t *testing.T, snap *Snapshot) {
+				ws := snap.Workspaces[0]
+				if len(ws.TabIds) != 1 || ws.ActiveTabId != testTab1 {
+					t.Errorf("expected missing tab to be pruned, got %v (active %q)", ws.TabIds, ws.ActiveTabId)
+				}
+			},
+		},
+		{
+			name:    "tab block",
+			corrupt: func(snap *Snapshot) { snap.Tabs[0].BlockIds = append(snap.Tabs[0].BlockIds, testMissingId, testTrash1) },
+			oref:    "tab:" + testTab1,
+			field:   "blockids",
+			verify: func(t *testing.T, snap *Snapshot) {
+				if blockIds := findTestTab(snap, testTab1).BlockIds; len(blockIds) != 1 || blockIds[0] != testBlock1 {
+					t.Errorf("expected missing and trashed blocks to be pruned, got %v", blockIds)
+				}
+				if findTestBlock(snap, testTrash1) == nil {
+					t.Errorf("expected trashed block to be kept")
+				}
+			},
+		},
+		{
+			name: "tab layout",
+			corrupt: func(snap *Snapshot) {
+				snap.Tabs[0].LayoutState = testMissingId
+				snap.LayoutStates = snap.LayoutStates[1:]
+			},
+			oref:  "tab:" + testTab1,
+			field: "layoutstate",
+			verify: func(t *testing.T, snap *Snapshot) {
+				tab := findTestTab(snap, testTab1)
+				if tab.LayoutState == testMissingId || tab.LayoutState == "" {
+					t.Errorf("expected a new layout, got %q", tab.LayoutState)
+				}
+			},
+		},
+		{
+			name:    "subblock",
+			corrupt: func(snap *Snapshot) { snap.Blocks[0].SubBlockIds = append(snap.Blocks[0].SubBlockIds, testMissingId) },
+			oref:    "block:" + testBlock1,
+			field:   "subblockids",
+			verify: func(t *testing.T, snap *Snapshot) {
+				if subBlockIds := findTestBlock(snap, testBlock1).SubBlockIds; len(subBlockIds) != 1 {
+					t.Errorf("expected missing subblock to be pruned, got %v", subBlockIds)
+				}
+			},
+		},
+		{
+			name: "block parent",
+			corrupt: func(snap *Snapshot) {
+				findTestBlock(snap, testBlock2).ParentORef = "tab:" + testTab1
+			},
+			oref:  "block:" + testBlock2,
+			field: "parentoref",
+			verify: func(t *testing.T, snap *Snapshot) {
+				if parentORef := findTestBlock(snap, testBlock2).ParentORef; parentORef != "tab:"+testTab2 {
+					t.Errorf("expected parent to be fixed, got %q", parentORef)
+				}
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			snap := makeTestSnapshot()
+			tc.corrupt(snap)
+			report := checkSnapshotIntegrity(snap)
+			if len(report.Orphans) != 0 {
+				t.Errorf("expected no orphans, got %+v", report.Orphans)
+			}
+			var found bool
+			for _, issue := range report.Dangling {
+				if issue.ORef == tc.oref && issue.Field == tc.field {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("expected dangling %s %s, got %+v", tc.oref, tc.field, report.Dangling)
+			}
+			repairSnapshot(snap, RepairIntegrityOpts{})
+			if report := checkSnapshotIntegrity(snap); report.NumIssues() != 0 {
+				t.Errorf("expected no issues after repair, got %+v", report)
+			}
+			tc.verify(t, snap)
+		})
+	}
+}
+
+func TestIntegrityOrphans(t *testing.T) {
+	tests := []struct {
+		name      string
+		corrupt   func(snap *Snapshot)
+		oref      string
+		reparent  func(t *testing.T, snap *Snapshot, repair *integrityRepair)
+		deleteIds []string
+	}{
+		{
+			name:    "window",
+			corrupt: func(snap *Snapshot) { snap.Client.WindowIds = nil },
+			oref:    "window:" + testWin1,
+			reparent: func(t *testing.T, snap *Snapshot, repair *integrityRepair) {
+				if len(snap.Client.WindowIds) != 1 {
+					t.Errorf("expected window to be re-added to the client, got %v", snap.Client.WindowIds)
+				}
+			},
+			deleteIds: []string{testWin1},
+		},
+		{
+			name:    "tab",
+			corrupt: func(snap *Snapshot) { snap.Workspaces[0].PinnedTabIds = nil },
+			oref:    "tab:" + testTab2,
+			reparent: func(t *testing.T, snap *Snapshot, repair *integrityRepair) {
+				if tabIds := snap.Workspaces[0].TabIds; len(tabIds) != 2 || tabIds[1] != testTab2 {
+					t.Errorf("expected tab to be re-added to the workspace, got %v", tabIds)
+				}
+			},
+			deleteIds: []string{testTab2, testLayout2, testBlock2},
+		},
+		{
+			name:    "block",
+			corrupt: func(snap *Snapshot) { snap.Tabs[0].BlockIds = nil },
+			oref:    "block:" + testBlock1,
+			reparent: func(t *testing.T, snap *Snapshot, repair *integrityRepair) {
+				if blockIds := findTestTab(snap, testTab1).BlockIds; len(blockIds) != 1 || blockIds[0] != testBlock1 {
+					t.Errorf("expected block to be re-added to the tab, got %v", blockIds)
+				}
+				if inserts := repair.layoutInserts[testTab1]; len(inserts) != 1 || inserts[0] != testBlock1 {
+					t.Errorf("expected a layout insert for the block, got %v", repair.layoutInserts)
+				}
+			},
+			deleteIds: []string{testBlock1, testSub1},
+		},
+		{
+			name: "block with missing parent",
+			corrupt: func(snap *Snapshot) {
+				snap.Blocks = append(snap.Blocks, &waveobj.Block{OID: testBlock3, ParentORef: "tab:" + testMissingId})
+			},
+			oref: "block:" + testBlock3,
+			reparent: func(t *testing.T, snap *Snapshot, repair *integrityRepair) {
+				if findTestBlock(snap, testBlock3) != nil {
+					t.Errorf("expected block without a parent to be deleted")
+				}
+			},
+			deleteIds: []string{testBlock3},
+		},
+	}
+	for _, tc := range tests {
+		snap := makeTestSnapshot()
+		tc.corrupt(snap)
+		report := checkSnapshotIntegrity(snap)
+		if len(report.Orphans) != 1 || report.Orphans[0].ORef != tc.oref {
+			t.Fatalf("%s: expected orphan %s, got %+v", tc.name, tc.oref, report.Orphans)
+		}
+		repair := repairSnapshot(snap, RepairIntegrityOpts{})
+		if report := checkSnapshotIntegrity(snap); report.NumIssues() != 0 {
+			t.Errorf("%s: expected no issues after re-parenting, got %+v", tc.name, report)
+		}
+		tc.reparent(t, snap, repair)
+
+		snap = makeTestSnapshot()
+		tc.corrupt(snap)
+		repair = repairSnapshot(snap, RepairIntegrityOpts{DeleteOrphans: true})
+		if report := checkSnapshotIntegrity(snap); report.NumIssues() != 0 {
+			t.Errorf("%s: expected no issues after deleting, got %+v", tc.name, report)
+		}
+		deletedIds := make(map[string]bool)
+		for _, oref := range repair.deleted {
+			deletedIds[oref.OID] = true
+		}
+		for _, id := range tc.deleteIds {
+			if !deletedIds[id] {
+				t.Errorf("%s: expected %s to be deleted, got %v", tc.name, id, repair.deleted)
+			}
+		}
+	}
+}
+
+func TestIntegrityOrphanedLayout(t *testing.T) {
+	snap := makeTestSnapshot()
+	snap.LayoutStates = append(snap.LayoutStates, &waveobj.LayoutState{OID: testLayout3})
+	report := checkSnapshotIntegrity(snap)
+	if len(report.Orphans) != 1 || report.Orphans[0].ORef != "layout:"+testLayout3 {
+		t.Fatalf("expected orphaned layout, got %+v", report.Orphans)
+	}
+	repair := repairSnapshot(snap, RepairIntegrityOpts{})
+	if len(repair.deleted) != 1 || repair.deleted[0].OID != testLayout3 || len(snap.LayoutStates) != 2 {
+		t.Errorf("expected orphaned layout to be deleted, got %v", repair.deleted)
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+// bump when the snapshot format changes (ImportSnapshot rejects newer versions)
+const SnapshotVersion = 1
+
+// a backup of the wave object store (block files are not included)
+type Snapshot struct {
+	Version      int                    `json:"version"`
+	ExportTs     int64                  `json:"exportts"`
+	Client       *waveobj.Client        `json:"client,omitempty"`
+	Windows      []*waveobj.Window      `json:"windows"`
+	Workspaces   []*waveobj.Workspace   `json:"workspaces"`
+	Tabs         []*waveobj.Tab         `json:"tabs"`
+	LayoutStates []*waveobj.LayoutState `json:"layoutstates"`
+	Blocks       []*waveobj.Block       `json:"blocks"`
+}
+
+type ImportSnapshotOpts struct {
+	KeepIds bool // keep the ids from the snapshot (fails if any of the objects already exist)
+}
+
+type ImportSnapshotResult struct {
+	WorkspaceIds []string // the ids of the imported workspaces (in snapshot order)
+	NumTabs      int
+	NumBlocks    int
+}
+
+func MakeSnapshot(ctx context.Context) (*Snapshot, error) {
+	return WithTxRtn(ctx, func(tx *TxWrap) (*Snapshot, error) {
+		var err error
+		snapshot := &Snapshot{Version: SnapshotVersion, ExportTs: time.Now().UnixMilli()}
+		snapshot.Client, err = DBGetSingleton[*waveobj.Client](tx.Context())
+		if err != nil {
+			return nil, fmt.Errorf("error getting client: %w", err)
+		}
+		if snapshot.Windows, err = DBGetAllObjsByType[*waveobj.Window](tx.Context(), waveobj.OType_Window); err != nil {
+			return nil, fmt.Errorf("error getting windows: %w", err)
+		}
+		if snapshot.Workspaces, err = DBGetAllObjsByType[*waveobj.Workspace](tx.Context(), waveobj.OType_Workspace); err != nil {
+			return nil, fmt.Errorf("error getting workspaces: %w", err)
+		}
+		if snapshot.Tabs, err = DBGetAllObjsByType[*waveobj.Tab](tx.Context(), waveobj.OType_Tab); err != nil {
+			return nil, fmt.Errorf("error getting tabs: %w", err)
+		}
+		if snapshot.LayoutStates, err = DBGetAllObjsByType[*waveobj.LayoutState](tx.Context(), waveobj.OType_LayoutState); err != nil {
+			return nil, fmt.Errorf("error getting layout states: %w", err)
+		}
+		if snapshot.Blocks, err = DBGetAllObjsByType[*waveobj.Block](tx.Context(), waveobj.OType_Block); err != nil {
+			return nil, fmt.Errorf("error getting blocks: %w", err)
+		}
+		return snapshot, nil
+	})
+}
+
+// writes a versioned json snapshot of the client, windows, workspaces, tabs, layouts, and blocks
+func ExportSnapshot(ctx context.Context, w io.Writer) error {
+	snapshot, err := MakeSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snapshot)
+}
+
+// recreates the workspaces in the snapshot (with their tabs, layouts, and blocks).
+// the client and windows are not imported (windows are bound to the running app).
+// unless opts.KeepIds is set, all of the objects get new ids and the references between them are remapped.
+func ImportSnapshot(ctx context.Context, r io.Reader, opts ImportSnapshotOpts) (*ImportSnapshotResult, error) {
+	var snapshot Snapshot
+	err := json.NewDecoder(r).Decode(&snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing snapshot: %w", err)
+	}
+	if snapshot.Version <= 0 {
+		return nil, fmt.Errorf("invalid snapshot, missing version")
+	}
+	if snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("snapshot version %d is newer than the supported version %d, upgrade Wave to import it", snapshot.Version, SnapshotVersion)
+	}
+	return WithTxRtn(ctx, func(tx *TxWrap) (*ImportSnapshotResult, error) {
+		imp := &snapshotImporter{
+			ctx:          tx.Context(),
+			opts:         opts,
+			idMap:        make(map[string]string),
+			tabs:         make(map[string]*waveobj.Tab),
+			layoutStates: make(map[string]*waveobj.LayoutState),
+			blocks:       make(map[string]*waveobj.Block),
+			importedTabs: make(map[string]bool),
+		}
+		for _, tab := range snapshot.Tabs {
+			imp.tabs[tab.OID] = tab
+		}
+		for _, layoutState := range snapshot.LayoutStates {
+			imp.layoutStates[layoutState.OID] = layoutState
+		}
+		for _, block := range snapshot.Blocks {
+			imp.blocks[block.OID] = block
+		}
+		rtn := &ImportSnapshotResult{}
+		for _, ws := range snapshot.Workspaces {
+			newWsId, err := imp.importWorkspace(ws)
+			if err != nil {
+				return nil, err
+			}
+			rtn.WorkspaceIds = append(rtn.WorkspaceIds, newWsId)
+		}
+		rtn.NumTabs = imp.numTabs
+		rtn.NumBlocks = imp.numBlocks
+		return rtn, nil
+	})
+}
+
+type snapshotImporter struct {
+	ctx          context.Context
+	opts         ImportSnapshotOpts
+	idMap        map[string]string // old id => new id
+	tabs         map[string]*waveobj.Tab
+	layoutStates map[string]*waveobj.LayoutState
+	blocks       map[string]*waveobj.Block
+	importedTabs map[string]bool
+	numTabs      int
+	numBlocks    int
+}
+
+func (imp *snapshotImporter) mapId(oldId string) string {
+	if oldId == "" {
+		return ""
+	}
+	if newId, ok := imp.idMap[oldId]; ok {
+		return newId
+	}
+	newId := oldId
+	if !imp.opts.KeepIds {
+		newId = uuid.NewString()
+	}
+	imp.idMap[oldId] = newId
+	return newId
+}
+
+func (imp *snapshotImporter) mapIds(oldIds []string) []string {
+	rtn := make([]string, 0, len(oldIds))
+	for _, oldId := range oldIds {
+		rtn = append(rtn, imp.mapId(oldId))
+	}
+	return rtn
+}
+
+func (imp *snapshotImporter) insert(obj waveobj.WaveObj) error {
+	if imp.opts.KeepIds {
+		exists, err := DBExistsORef(imp.ctx, waveobj.MakeORef(obj.GetOType(), waveobj.GetOID(obj)))
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%s %s already exists (import without keeping ids to make a copy)", obj.GetOType(), waveobj.GetOID(obj))
+		}
+	}
+	return DBInsert(imp.ctx, obj)
+}
+
+func (imp *snapshotImporter) importWorkspace(ws *waveobj.Workspace) (string, error) {
+	var tabIds, pinnedTabIds []string
+	for _, tabId := range ws.TabIds {
+		if imp.tabs[tabId] != nil {
+			tabIds = append(tabIds, tabId)
+		}
+	}
+	for _, tabId := range ws.PinnedTabIds {
+		if imp.tabs[tabId] != nil {
+			pinnedTabIds = append(pinnedTabIds, tabId)
+		}
+	}
+	for _, tabId := range append(tabIds, pinnedTabIds...) {
+		err := imp.importTab(imp.tabs[tabId])
+		if err != nil {
+			return "", err
+		}
+	}
+	newWs := &waveobj.Workspace{
+		OID:          imp.mapId(ws.OID),
+		Name:         ws.Name,
+		Icon:         ws.Icon,
+		Color:        ws.Color,
+		TabIds:       imp.mapIds(tabIds),
+		PinnedTabIds: imp.mapIds(pinnedTabIds),
+		ActiveTabId:  imp.mapId(ws.ActiveTabId),
+		Meta:         ws.Meta,
+	}
+	if imp.tabs[ws.ActiveTabId] == nil {
+		newWs.ActiveTabId = ""
+		if len(newWs.TabIds) > 0 {
+			newWs.ActiveTabId = newWs.TabIds[0]
+		} else if len(newWs.PinnedTabIds) > 0 {
+			newWs.ActiveTabId = newWs.PinnedTabIds[0]
+		}
+	}
+	err := imp.insert(newWs)
+	if err != nil {
+		return "", fmt.Errorf("error importing workspace %s: %w", ws.OID, err)
+	}
+	return newWs.OID, nil
+}
+
+func (imp *snapshotImporter) importTab(tab *waveobj.Tab) error {
+	if imp.importedTabs[tab.OID] {
+		return nil
+	}
+	imp.importedTabs[tab.OID] = true
+	var blockIds []string
+	for _, blockId := range tab.BlockIds {
+		if imp.blocks[blockId] == nil {
+			continue
+		}
+		err := imp.importBlock(imp.blocks[blockId], waveobj.MakeORef(waveobj.OType_Tab, imp.mapId(tab.OID)).String())
+		if err != nil {
+			return err
+		}
+		blockIds = append(blockIds, blockId)
+	}
+	oldLayoutState := imp.layoutStates[tab.LayoutState]
+	if oldLayoutState == nil {
+		oldLayoutState = &waveobj.LayoutState{OID: tab.LayoutState}
+	}
+	err := imp.importLayoutState(oldLayoutState)
+	if err != nil {
+		return err
+	}
+	newTab := &waveobj.Tab{
+		OID:         imp.mapId(tab.OID),
+		Name:        tab.Name,
+		GroupName:   tab.GroupName,
+		LayoutState: imp.mapId(oldLayoutState.OID),
+		BlockIds:    imp.mapIds(blockIds),
+		Meta:        tab.Meta,
+		CreatedTs:   tab.CreatedTs,
+	}
+	err = imp.insert(newTab)
+	if err != nil {
+		return fmt.Errorf("error importing tab %s: %w", tab.OID, err)
+	}
+	imp.numTabs++
+	return nil
+}
+
+func (imp *snapshotImporter) importBlock(block *waveobj.Block, parentORef string) error {
+	newBlockId := imp.mapId(block.OID)
+	var subBlockIds []string
+	for _, subBlockId := range block.SubBlockIds {
+		if imp.blocks[subBlockId] == nil {
+			continue
+		}
+		err := imp.importBlock(imp.blocks[subBlockId], waveobj.MakeORef(waveobj.OType_Block, newBlockId).String())
+		if err != nil {
+			return err
+		}
+		subBlockIds = append(subBlockIds, subBlockId)
+	}
+	newBlock := &waveobj.Block{
+		OID:         newBlockId,
+		ParentORef:  parentORef,
+		RuntimeOpts: block.RuntimeOpts,
+		Stickers:    block.Stickers,
+		Meta:        block.Meta,
+		CreatedTs:   block.CreatedTs,
+	}
+	if len(subBlockIds) > 0 {
+		newBlock.SubBlockIds = imp.mapIds(subBlockIds)
+	}
+	err := imp.insert(newBlock)
+	if err != nil {
+		return fmt.Errorf("error importing block %s: %w", block.OID, err)
+	}
+	imp.numBlocks++
+	return nil
+}
+
+func (imp *snapshotImporter) importLayoutState(layoutState *waveobj.LayoutState) error {
+	newLayoutState := &waveobj.LayoutState{
+		OID:             imp.mapId(layoutState.OID),
+		RootNode:        imp.remapLayoutNode(layoutState.RootNode),
+		MagnifiedNodeId: layoutState.MagnifiedNodeId,
+		FocusedNodeId:   layoutState.FocusedNodeId,
+		Meta:            layoutState.Meta,
+	}
+	if layoutState.LeafOrder != nil {
+		leafOrder := make([]waveobj.LeafOrderEntry, 0, len(*layoutState.LeafOrder))
+		for _, entry := range *layoutState.LeafOrder {
+			leafOrder = append(leafOrder, waveobj.LeafOrderEntry{NodeId: entry.NodeId, BlockId: imp.mapId(entry.BlockId)})
+		}
+		newLayoutState.LeafOrder = &leafOrder
+	}
+	if layoutState.PendingBackendActions != nil {
+		actions := make([]waveobj.LayoutActionData, 0, len(*layoutState.PendingBackendActions))
+		for _, action := range *layoutState.PendingBackendActions {
+			action.BlockId = imp.mapId(action.BlockId)
+			actions = append(actions, action)
+		}
+		newLayoutState.PendingBackendActions = &actions
+	}
+	err := imp.insert(newLayoutState)
+	if err != nil {
+		return fmt.Errorf("error importing layout state %s: %w", layoutState.OID, err)
+	}
+	return nil
+}
+
+// the layout tree is stored as raw json, leaf nodes reference blocks with data.blockId
+func (imp *snapshotImporter) remapLayoutNode(node any) any {
+	switch v := node.(type) {
+	case map[string]any:
+		rtn := make(map[string]any, len(v))
+		for key, val := range v {
+			if key == "blockId" {
+				if blockId, ok := val.(string); ok {
+					rtn[key] = imp.mapId(blockId)
+					continue
+				}
+			}
+			rtn[key] = imp.remapLayoutNode(val)
+		}
+		return rtn
+	case []any:
+		rtn := make([]any, 0, len(v))
+		for _, elem := range v {
+			rtn = append(rtn, imp.remapLayoutNode(elem))
+		}
+		return rtn
+	default:
+		return node
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2025, Command Line Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package wstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/wavetermdev/waveterm/pkg/waveobj"
+)
+
+func makeTestSnapshotJson(t *testing.T, version int) []byte {
+	snap := makeTestSnapshot()
+	snap.Version = version
+	snap.LayoutStates[0].RootNode = map[string]any{
+		"id": "root",
+		"children": []any{
+			map[string]any{"id": "leaf1", "data": map[string]any{"blockId": testBlock1}},
+		},
+	}
+	snap.LayoutStates[0].LeafOrder = &[]waveobj.LeafOrderEntry{{NodeId: "leaf1", BlockId: testBlock1}}
+	snap.LayoutStates[1].PendingBackendActions = &[]waveobj.LayoutActionData{{ActionType: "insert", BlockId: testBlock2}}
+	barr, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return barr
+}
+
+func TestImportSnapshotRemap(t *testing.T) {
+	initTestDb(t)
+	ctx := context.Background()
+	snapJson := makeTestSnapshotJson(t, SnapshotVersion)
+	for range 2 {
+		// importing the same snapshot twice makes two copies
+		rtn, err := ImportSnapshot(ctx, bytes.NewReader(snapJson), ImportSnapshotOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rtn.WorkspaceIds) != 1 || rtn.WorkspaceIds[0] == testWs1 || rtn.NumTabs != 2 || rtn.NumBlocks != 3 {
+			t.Fatalf("bad import result %#v", rtn)
+		}
+		ws, err := DBMustGet[*waveobj.Workspace](ctx, rtn.WorkspaceIds[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ws.TabIds) != 1 || len(ws.PinnedTabIds) != 1 || ws.ActiveTabId != ws.TabIds[0] || ws.TabIds[0] == testTab1 {
+			t.Fatalf("bad workspace tabs %#v", ws)
+		}
+		tab, err := DBMustGet[*waveobj.Tab](ctx, ws.TabIds[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tab.BlockIds) != 1 || tab.BlockIds[0] == testBlock1 || tab.LayoutState == testLayout1 {
+			t.Fatalf("expected remapped ids (and the trashed block left out), got %#v", tab)
+		}
+		block, err := DBMustGet[*waveobj.Block](ctx, tab.BlockIds[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block.ParentORef != "tab:"+tab.OID || len(block.SubBlockIds) != 1 || block.SubBlockIds[0] == testSub1 {
+			t.Fatalf("bad block %#v", block)
+		}
+		subBlock, err := DBMustGet[*waveobj.Block](ctx, block.SubBlockIds[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subBlock.ParentORef != "block:"+block.OID {
+			t.Errorf("expected the subblock parent to be remapped, got %q", subBlock.ParentORef)
+		}
+		layoutState, err := DBMustGet[*waveobj.LayoutState](ctx, tab.LayoutState)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf := layoutState.RootNode.(map[string]any)["children"].([]any)[0].(map[string]any)
+		if leaf["data"].(map[string]any)["blockId"] != block.OID || (*layoutState.LeafOrder)[0].BlockId != block.OID {
+			t.Errorf("expected the layout to reference the new block id, got %#v", layoutState)
+		}
+		pinnedTab, err := DBMustGet[*waveobj.Tab](ctx, ws.PinnedTabIds[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		pinnedLayout, err := DBMustGet[*waveobj.LayoutState](ctx, pinnedTab.LayoutState)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (*pinnedLayout.PendingBackendActions)[0].BlockId != pinnedTab.BlockIds[0] {
+			t.Errorf("expected the pending action to reference the new block id, got %#v", *pinnedLayout.PendingBackendActions)
+		}
+	}
+	workspaces, err := DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
+	if err != nil || len(workspaces) != 2 {
+		t.Errorf("expected 2 imported workspaces, got %d (err %v)", len(workspaces), err)
+	}
+}
+
+func TestImportSnapshotKeepIds(t *testing.T) {
+	initTestDb(t)
+	ctx := context.Background()
+	snapJson := makeTestSnapshotJson(t, SnapshotVersion)
+	rtn, err := ImportSnapshot(ctx, bytes.NewReader(snapJson), ImportSnapshotOpts{KeepIds: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rtn.WorkspaceIds) != 1 || rtn.WorkspaceIds[0] != testWs1 {
+		t.Fatalf("expected the workspace id to be kept, got %v", rtn.WorkspaceIds)
+	}
+	block, err := DBMustGet[*waveobj.Block](ctx, testSub1)
+	if err != nil || block.ParentORef != "block:"+testBlock1 {
+		t.Fatalf("expected the subblock with its original parent, got %#v (err %v)", block, err)
+	}
+	// the objects already exist, so the second import fails (and adds nothing)
+	_, err = ImportSnapshot(ctx, bytes.NewReader(snapJson), ImportSnapshotOpts{KeepIds: true})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected an already exists error, got %v", err)
+	}
+	tabs, err := DBGetAllObjsByType[*waveobj.Tab](ctx, waveobj.OType_Tab)
+	if err != nil || len(tabs) != 2 {
+		t.Errorf("expected the 2 tabs from the first import, got %d (err %v)", len(tabs), err)
+	}
+}
+
+func TestImportSnapshotVersion(t *testing.T) {
+	initTestDb(t)
+	ctx := context.Background()
+	tests := []struct {
+		version int
+		errMsg  string
+	}{
+		{version: 0, errMsg: "missing version"},
+		{version: SnapshotVersion + 1, errMsg: "newer than the supported version"},
+	}
+	for _, tc := range tests {
+		_, err := ImportSnapshot(ctx, bytes.NewReader(makeTestSnapshotJson(t, tc.version)), ImportSnapshotOpts{})
+		if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+			t.Errorf("version %d: expected %q error, got %v", tc.version, tc.errMsg, err)
+		}
+	}
+	workspaces, err := DBGetAllObjsByType[*waveobj.Workspace](ctx, waveobj.OType_Workspace)
+	if err != nil || len(workspaces) != 0 {
+		t.Errorf("expected nothing to be imported, got %d workspaces (err %v)", len(workspaces), err)
+	}
+}